@@ -94,6 +94,8 @@ var help []string = []string{
 	"                         0x01-01,0x01-02,0x01-03,0x01-04,0x01-06,0x7f-05",
 	" Trimble               : RT17",
 	" Septentrio            : SBF",
+	" Unicore               : OBSVM, OBSVMCMP, GPSEPH, GLOEPH, BDSEPH, GALEPH, QZSSEPH,",
+	"                         IONUTC",
 	" RINEX                 : OBS, NAV, GNAV, HNAV, LNAV, QNAV",
 	"",
 	" Options [default]",
@@ -119,6 +121,7 @@ var help []string = []string{
 	"                  binex= BINEX",
 	"                  rt17 = Trimble RT17",
 	"                  sbf  = Septentrio SBF",
+	"                  unicore= Unicore UM980/UB4B0",
 	"                  rinex= RINEX",
 	"     -ro opt      receiver options",
 	"     -f freq      number of frequencies [5]",
@@ -174,6 +177,7 @@ var help []string = []string{
 	"     *.bnx,*binex  BINEX",
 	"     *.rt17        Trimble RT17",
 	"     *.sbf         Septentrio SBF",
+	"     *.unc         Unicore",
 	"     *.obs,*.*o    RINEX OBS",
 	"     *.rnx         RINEX OBS",
 	"     *.nav,*.*n    RINEX NAV"}
@@ -798,6 +802,8 @@ func cmdopts(opt *gnssgo.RnxOpt, ifile *string, ofile []string, dir *string, tra
 			format = gnssgo.STRFMT_RT17
 		case "sbf":
 			format = gnssgo.STRFMT_SEPT
+		case "unicore":
+			format = gnssgo.STRFMT_UNICORE
 		case "rinex":
 			format = gnssgo.STRFMT_RINEX
 		}
@@ -839,6 +845,8 @@ func cmdopts(opt *gnssgo.RnxOpt, ifile *string, ofile []string, dir *string, tra
 			format = gnssgo.STRFMT_RT17
 		case path[idx:] == ".sbf":
 			format = gnssgo.STRFMT_SEPT
+		case path[idx:] == ".unc":
+			format = gnssgo.STRFMT_UNICORE
 		case path[idx:] == ".obs":
 			format = gnssgo.STRFMT_RINEX
 		case path[idx+3:] == "o":
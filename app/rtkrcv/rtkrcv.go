@@ -35,6 +35,9 @@
 *           2016/09/19 1.20 support multiple remote console connections
 *                           add option -w
 *           2017/09/01 1.21 add command ssr
+*           2026/10/16 1.22 support mqtt for input and output streams
+*           2026/10/16 1.23 support websocket for input and output streams
+*           2026/10/16 1.24 support QZSS L6 (CLAS) input and clas grid file
 *-----------------------------------------------------------------------------*/
 
 package main
@@ -180,9 +183,12 @@ var pathopts []string = []string{ /* path options help */
 var TIMOPT string = "0:gpst,1:utc,2:jst,3:tow"
 var CONOPT string = "0:dms,1:deg,2:xyz,3:enu,4:pyl"
 var FLGOPT string = "0:off,1:std+2:age/ratio/ns"
-var ISTOPT string = "0:off,1:serial,2:file,3:tcpsvr,4:tcpcli,5:ntripsvr,6:ntripcli,7:ftp,8:http"
-var OSTOPT string = "0:off,1:serial,2:file,3:tcpsvr,4:tcpcli,6:ntripsvr,11:ntripc_c"
-var FMTOPT string = "0:rtcm2,1:rtcm3,2:oem4,3:oem3,4:ubx,5:ss2,6:hemis,7:skytraq,8:gw10,9:javad,10:nvs,11:binex,12:rt17,13:sbf,14:cmr,15:tersus,18:sp3"
+var ISTOPT string = "0:off,1:serial,2:file,3:tcpsvr,4:tcpcli,5:ntripsvr,6:ntripcli,7:ftp,8:http,13:mqtt,15:wscli"
+var OSTOPT string = "0:off,1:serial,2:file,3:tcpsvr,4:tcpcli,6:ntripsvr,11:ntripc_c,13:mqtt,14:wssvr"
+
+/* stream format numbers: 13:unicore shifts rinex and later formats by one
+ * (sp3: 14 -> 15) from configurations before unicore support */
+var FMTOPT string = "0:rtcm2,1:rtcm3,2:oem4,3:oem3,4:ubx,5:ss2,6:hemis,7:skytraq,8:javad,9:nvs,10:binex,11:rt17,12:sbf,13:unicore,15:sp3,19:l6"
 var NMEOPT string = "0:off,1:latlon,2:single"
 var SOLOPT string = "0:llh,1:xyz,2:enu,3:nmea,4:stat"
 var MSGOPT string = "0:all,1:rover,2:base,3:corr"
@@ -389,6 +395,10 @@ func startsvr() int {
 		sta[0].Name = sta_name
 		svr.NavData.ReadDcb(filopt.Dcb, sta[:])
 	}
+	/* read clas grid definition file */
+	if len(filopt.Grid) > 0 && svr.NavData.ReadCssrGrid(filopt.Grid) == 0 {
+		log.Printf("clas grid file read error: %s\n", filopt.Grid)
+	}
 	/* open geoid data file */
 	if solopt[0].Geoid > 0 && gnssgo.OpenGeoid(solopt[0].Geoid, filopt.Geoid) == 0 {
 		log.Printf("geoid data open error: %s\n", filopt.Geoid)
@@ -419,18 +429,22 @@ func startsvr() int {
 	solopt[0].Posf = strfmt[3]
 	solopt[1].Posf = strfmt[4]
 
+	/* subscribe server events before start not to miss the first epochs */
+	//	go writeObs(svr.Subscribe(gnssgo.SVREV_OBS, gnssgo.MAXOBSBUF))
+	//	go writeObs2MongoDB(svr.Subscribe(gnssgo.SVREV_OBS, gnssgo.MAXOBSBUF))
+	// go writeObs2ElasticSearch(svr.Subscribe(gnssgo.SVREV_OBS, gnssgo.MAXOBSBUF))
+	// go writeRbSol(svr.Subscribe(gnssgo.SVREV_SOL, 10))
+	sub := svr.Subscribe(gnssgo.SVREV_OBS, gnssgo.MAXOBSBUF)
+
 	/* start rtk server */
 	if svr.RtkSvrStart(svrcycle, buffsize, strtype, paths, strfmt, navmsgsel,
 		cmds[:], cmds_periodic[:], ropts, nmeacycle, nmeareq, npos[:], &prcopt,
 		solopt[:], &moni, &errmsg) == 0 {
 		log.Printf("rtk server start error (%s)\n", errmsg)
+		svr.Unsubscribe(sub)
 		return 0
 	}
-	//	go writeObs(gnssgo.ObsChannel)
-	//	go writeObs2MongoDB(gnssgo.ObsChannel)
-	// go writeObs2ElasticSearch(gnssgo.ObsChannel)
-	// go writeRbSol(gnssgo.RbSolChannel)
-	go writeObs2ClickHouse(gnssgo.ObsChannel)
+	go writeObs2ClickHouse(sub)
 	return 1
 }
 
@@ -472,15 +486,12 @@ func stopsvr() {
 }
 
 /* write obs data to influxDB */
-func writeObs(ch chan gnssgo.ObsD) {
+func writeObs(sub *gnssgo.SvrSubscriber) {
 	// client := db.NewClient("http://localhost:8086", "qdhQU9SHk2xlWZGwA9UxxQDdSjFfeGJOfVLWjACQ1isHgWCZye7bziwPF00AAqb9jd2QuszxksmedJ98CZ21Sw==")
 	// writeAPI := client.WriteAPI("idtsz", "gnss")
 
-	// for intflg == 0 {
-	// 	data, ok := <-ch
-	// 	if !ok {
-	// 		continue
-	// 	}
+	// for ev := range sub.C {
+	// 	data := ev.Obs[0]
 	// 	// t := time.Unix(int64(data.Time.Time), int64(data.Time.Sec))
 
 	// 	p := db.NewPointWithMeasurement("obs2").
@@ -512,18 +523,15 @@ func writeObs(ch chan gnssgo.ObsD) {
 	// }
 }
 
-func writeRbSol(ch chan gnssgo.RBSol) {
+func writeRbSol(sub *gnssgo.SvrSubscriber) {
 	// client := db.NewClient("http://localhost:8086", "qdhQU9SHk2xlWZGwA9UxxQDdSjFfeGJOfVLWjACQ1isHgWCZye7bziwPF00AAqb9jd2QuszxksmedJ98CZ21Sw==")
 	// writeAPI := client.WriteAPI("idtsz", "gnss")
 	// var (
 	// 	pos, rr, enu [3]float64
 	// 	P, Q         [9]float64
 	// )
-	// for intflg == 0 {
-	// 	data, ok := <-ch
-	// 	if !ok {
-	// 		continue
-	// 	}
+	// for ev := range sub.C {
+	// 	data := ev
 	// 	t := time.Unix(int64(data.Sol.Time.Time), 0)
 	// 	for i := 0; i < 3; i++ {
 	// 		rr[i] = data.Sol.Rr[i] - data.Rb[i]
@@ -556,7 +564,7 @@ func writeRbSol(ch chan gnssgo.RBSol) {
 	// writeAPI.Flush()
 }
 
-func writeObs2MongoDB(ch chan gnssgo.ObsD) {
+func writeObs2MongoDB(sub *gnssgo.SvrSubscriber) {
 	// clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
 
 	// // 连接到MongoDB
@@ -567,11 +575,8 @@ func writeObs2MongoDB(ch chan gnssgo.ObsD) {
 	// 	return
 	// }
 	// collection := client.Database("gnss").Collection("gnsstest")
-	// for intflg == 0 {
-	// 	data, ok := <-ch
-	// 	if !ok {
-	// 		continue
-	// 	}
+	// for ev := range sub.C {
+	// 	data := ev.Obs[0]
 
 	// 	//插入某一条数据
 	// 	if _, err = collection.InsertOne(context.TODO(), data); err != nil {
@@ -593,7 +598,7 @@ type obsitem struct {
 	// D    []float64 `json:"d"`
 }
 
-func writeObs2ElasticSearch(ch chan gnssgo.ObsD) {
+func writeObs2ElasticSearch(sub *gnssgo.SvrSubscriber) {
 	// client, err := es.NewClient(
 	// 	es.SetSniff(false),                  // SetSniff启用或禁用嗅探器（默认情况下启用）。
 	// 	es.SetURL("http://127.0.0.1:9200/"), // URL地址
@@ -604,11 +609,8 @@ func writeObs2ElasticSearch(ch chan gnssgo.ObsD) {
 	// 	panic(err)
 	// }
 
-	// for intflg == 0 {
-	// 	data, ok := <-ch
-	// 	if !ok {
-	// 		continue
-	// 	}
+	// for ev := range sub.C {
+	// 	data := ev.Obs[0]
 	// 	jdata, _ := json.Marshal(data)
 	// 	fmt.Print(string(jdata))
 	// 	item := obsitem{
@@ -633,7 +635,9 @@ func writeObs2ElasticSearch(ch chan gnssgo.ObsD) {
 	// }
 }
 
-func writeObs2ClickHouse(ch chan gnssgo.ObsD) {
+func writeObs2ClickHouse(sub *gnssgo.SvrSubscriber) {
+	defer svr.Unsubscribe(sub)
+
 	host1 := "192.168.1.181:8123"
 	otherHost := "192.168.1.181:9000"
 	user := "admin"
@@ -651,10 +655,9 @@ func writeObs2ClickHouse(ch chan gnssgo.ObsD) {
 	client.SetMaxOpenConns(50)
 	client.SetMaxIdleConns(50)
 
-	for intflg == 0 {
-		data, ok := <-ch
-		if !ok {
-			continue
+	for ev := range sub.C {
+		if intflg != 0 {
+			break
 		}
 		tx, err := client.Begin()
 		if err != nil {
@@ -667,19 +670,21 @@ func writeObs2ClickHouse(ch chan gnssgo.ObsD) {
 			log.Println("xxx2", err)
 			panic("failed to invoke tx.Prepare(\"insert into obs (`Time`,Sat, Rcv, SNR, Code, LLI, L, P, D)\")")
 		}
-		t := time.Unix(int64(data.Time.Time), 0)
-		if _, err := stmt.Exec(
-			t,
-			data.Sat,
-			data.Rcv,
-			data.SNR[:],
-			data.Code[:],
-			data.LLI[:],
-			data.L[:],
-			data.P[:],
-			data.D[:],
-		); err != nil {
-			log.Fatal("2", err)
+		for _, data := range ev.Obs {
+			t := time.Unix(int64(data.Time.Time), 0)
+			if _, err := stmt.Exec(
+				t,
+				data.Sat,
+				data.Rcv,
+				data.SNR[:],
+				data.Code[:],
+				data.LLI[:],
+				data.L[:],
+				data.P[:],
+				data.D[:],
+			); err != nil {
+				log.Fatal("2", err)
+			}
 		}
 
 		if err := tx.Commit(); err != nil {
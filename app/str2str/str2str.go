@@ -28,6 +28,14 @@
 *           2016/09/17  1.16 add option -b
 *           2017/05/26  1.17 add input format tersus
 *           2020/11/30  1.18 support api change strsvrstart(),strsvrstat()
+*           2026/10/16  1.19 add option -ca,-cs
+*                            support input ntrip caster (source) stream
+*                            support ntrip2:// and ntrips2:// (ntrip 2.0)
+*                            support tls options in stream path
+*                            support rtcm 2 output
+*           2026/10/16  1.20 support stream types registered by scheme
+*           2026/10/16  1.21 support mqtt stream (mqtt://)
+*           2026/10/16  1.22 support websocket stream (ws://, wss://)
 *-----------------------------------------------------------------------------*/
 package main
 
@@ -78,12 +86,20 @@ var help []string = []string{
 	"    tcp server   : tcpsvr://:port",
 	"    tcp client   : tcpcli://addr[:port]",
 	"    ntrip client : ntrip://[user[:passwd]@]addr[:port][/mntpnt]",
-	"    ntrip server : ntrips://[:passwd@]addr[:port]/mntpnt[:str] (only out)",
-	"    ntrip caster : ntripc://[user:passwd@][:port]/mntpnt[:srctbl] (only out)",
+	"    ntrip server : ntrips://[[user]:passwd@]addr[:port]/mntpnt[:str] (only out)",
+	"    ntrip 2.0    : ntrip2://..., ntrips2://... (force ntrip 2.0)",
+	"    ntrip caster : ntripc://[user:passwd@][:port]/mntpnt[:srctbl]",
 	"    file         : [file://]path[::T][::+start][::xseppd][::S=swap]",
+	"    mqtt         : mqtt://[user[:passwd]@]addr[:port]/topic[::qos=n][::retain]",
+	"    ws server    : ws[s]://:port[/path][::text] (broadcast to clients)",
+	"    ws client    : ws[s]://[user[:passwd]@]addr[:port][/path]",
+	"    tls options  : path::tls[::ca=file][::cert=file][::key=file][::sni=host]",
+	"                   [::noverify] (tcpsvr, tcpcli, ntrip, ntrips)",
+	"    others       : scheme://path (stream types registered by scheme, e.g.",
+	"                   udpsvr://:port, udpcli://addr:port)",
 	"",
 	"  format",
-	"    rtcm2        : RTCM 2 (only in)",
+	"    rtcm2        : RTCM 2",
 	"    rtcm3        : RTCM 3",
 	"    nov          : NovAtel OEMV/4/6,OEMStar (only in)",
 	"    oem3         : NovAtel OEM3 (only in)",
@@ -96,9 +112,11 @@ var help []string = []string{
 	"    binex        : BINEX (only in)",
 	"    rt17         : Trimble RT17 (only in)",
 	"    sbf          : Septentrio SBF (only in)",
+	"    unicore      : Unicore (only in)",
 	"",
 	" -msg \"type[(tint)][,type[(tint)]...]\"",
 	"                   rtcm message types and output intervals (s)",
+	"                   (rtcm 2: 1,3,9,18,19,20,21,22,24,31)",
 	" -sta sta          station id",
 	" -opt opt          receiver dependent options",
 	" -s  msec          timeout time (ms) [10000]",
@@ -117,6 +135,8 @@ var help []string = []string{
 	" -o  e n u         antenna offset (e,n,u) (m)",
 	" -l  local_dir     ftp/http local directory []",
 	" -x  proxy_addr    http/ntrip proxy address [no]",
+	" -ca file          ntrip caster users file [no]",
+	" -cs file          ntrip caster sourcetable file [no]",
 	" -b  str_no        relay back messages from output str to input str [no]",
 	" -t  level         trace level [0]",
 	" -fl file          log file [str2str.trace]",
@@ -188,6 +208,8 @@ func decodefmt(path *string, fmt *int) {
 			*fmt = gnssgo.STRFMT_RT17
 		case "#sbf":
 			*fmt = gnssgo.STRFMT_SEPT
+		case "#unicore":
+			*fmt = gnssgo.STRFMT_UNICORE
 		default:
 			return
 		}
@@ -212,6 +234,13 @@ func decodepath(path string, ctype *int, strpath *string, f *int) int {
 		return 1
 	}
 	switch {
+	case strings.HasPrefix(buff, "ws://") || strings.HasPrefix(buff, "wss://"):
+		/* websocket server for no address (ws://:port), client for others */
+		if *ctype = gnssgo.STR_WSCLI; buff[idx+3] == ':' {
+			*ctype = gnssgo.STR_WSSVR
+		}
+		*strpath = buff
+		return 1
 	case path[:6] == "serial":
 		*ctype = gnssgo.STR_SERIAL
 	case path[:6] == "tcpsvr":
@@ -220,15 +249,25 @@ func decodepath(path string, ctype *int, strpath *string, f *int) int {
 		*ctype = gnssgo.STR_TCPCLI
 	case path[:6] == "ntripc":
 		*ctype = gnssgo.STR_NTRIPCAS
+	case strings.HasPrefix(path, "ntrips2"):
+		*ctype = gnssgo.STR_NTRIPSVR
+		*strpath = "ntrip2://" + buff[idx+3:]
+		return 1
+	case path[:6] == "ntrip2":
+		*ctype = gnssgo.STR_NTRIPCLI
+		*strpath = "ntrip2://" + buff[idx+3:]
+		return 1
 	case path[:6] == "ntrips":
 		*ctype = gnssgo.STR_NTRIPSVR
 	case path[:5] == "ntrip":
 		*ctype = gnssgo.STR_NTRIPCLI
 	case path[:4] == "file":
 		*ctype = gnssgo.STR_FILE
-	default:
-		fmt.Fprintf(os.Stderr, "stream path error: %s\n", buff)
-		return 0
+	default: /* stream type registered by gnssgo.RegisterStreamType() */
+		if *ctype = gnssgo.StreamType(buff[:idx]); *ctype == gnssgo.STR_NONE {
+			fmt.Fprintf(os.Stderr, "stream path error: %s\n", buff)
+			return 0
+		}
 	}
 	//*strpath = buff
 	*strpath = buff[idx+3:]
@@ -300,6 +339,7 @@ func main() {
 		pos, stapos, stadel                        [3]float64
 		s1, s2, paths, logs                        [MAXSTR]string
 		local, proxy, msg, opt, buff, strmsg       string
+		casusr, castbl                             string
 		ant                                        []string = []string{"", "", ""}
 		rcv                                        []string = []string{"", "", ""}
 		i, n, trlevel, sta                         int
@@ -351,6 +391,8 @@ func main() {
 	flag.StringVar(&rcvinfo, "i", rcvinfo, searchHelp("-i"))
 	flag.StringVar(&local, "l", local, searchHelp("-l"))
 	flag.StringVar(&proxy, "x", proxy, searchHelp("-x"))
+	flag.StringVar(&casusr, "ca", casusr, searchHelp("-ca"))
+	flag.StringVar(&castbl, "cs", castbl, searchHelp("-cs"))
 	flag.StringVar(&logfile, "fl", logfile, searchHelp("-fl"))
 	flag.IntVar(&trlevel, "t", trlevel, searchHelp("-t"))
 	flag.Parse()
@@ -458,6 +500,7 @@ func main() {
 
 	gnssgo.StreamSetDir(local)
 	gnssgo.StreamSetProxy(proxy)
+	gnssgo.StreamSetCaster(casusr, castbl)
 
 	for i = 0; i < MAXSTR; i++ {
 		if len(cmdfile[i]) > 0 {
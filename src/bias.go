@@ -0,0 +1,374 @@
+/*------------------------------------------------------------------------------
+* bias.go : signal bias functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] IGS, SINEX_BIAS - Solution (Software/technique) INdependent EXchange
+*         Format for GNSS BIASes Version 1.00, December 7, 2016
+*     [2] O.Montenbruck, S.Schaer et al., Differential Code Bias Estimation
+*         using Multi-GNSS Observations and Global Ionosphere Maps, Navigation,
+*         61(3), 2014
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, support SINEX-BIAS 1.00 OSB and DSB
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+)
+
+/* satellite clock reference codes -------------------------------------------*/
+func clkrefcode(sys int) (uint8, uint8) {
+	switch sys {
+	case SYS_GPS:
+		return CODE_L1W, CODE_L2W /* C1W-C2W */
+	case SYS_GLO:
+		return CODE_L1P, CODE_L2P /* C1P-C2P */
+	case SYS_GAL:
+		return CODE_L1C, CODE_L5Q /* C1C-C5Q */
+	case SYS_QZS:
+		return CODE_L1C, CODE_L2L /* C1C-C2L */
+	case SYS_CMP:
+		return CODE_L2I, CODE_L6I /* C2I-C6I */
+	case SYS_IRN:
+		return CODE_L5A, CODE_L9A /* C5A-C9A */
+	}
+	return CODE_NONE, CODE_NONE
+}
+
+/* string to SINEX time (YYYY:DDD:SSSSS) -------------------------------------*/
+func str2sinextime(s string) Gtime {
+	var (
+		t   Gtime
+		v   [3]float64
+		ep  []float64 = []float64{2000, 1, 1, 0, 0, 0}
+		str []string
+	)
+	if str = strings.Split(strings.TrimSpace(s), ":"); len(str) < 3 {
+		return t
+	}
+	for i := 0; i < 3; i++ {
+		v[i] = Str2Num(str[i], 0, len(str[i]))
+	}
+	if v[0] == 0.0 && v[1] == 0.0 && v[2] == 0.0 {
+		return t
+	}
+	if len(str[0]) <= 2 { /* YY:DDD:SSSSS */
+		if v[0] < 50.0 {
+			v[0] += 2000.0
+		} else {
+			v[0] += 1900.0
+		}
+	}
+	ep[0] = v[0]
+	return TimeAdd(Epoch2Time(ep), (v[1]-1.0)*86400.0+v[2])
+}
+
+/* add signal bias -----------------------------------------------------------*/
+func (nav *Nav) AddBias(key BiasKey, bias *Bias) {
+	if nav.Bias == nil {
+		nav.Bias = make(map[BiasKey][]Bias)
+	}
+	nav.Bias[key] = append(nav.Bias[key], *bias)
+}
+
+/* read SINEX-BIAS file --------------------------------------------------------
+* read SINEX-BIAS file and add OSB/DSB to the signal bias table
+* args   : char   *file     I   SINEX-BIAS file path
+*          sta_t  *sta      I   station info data to read receiver biases
+*                               (NULL: no receiver biases)
+* return : status (1:ok,0:error)
+* notes  : biases are stored in m. the biases in cycle are converted by the
+*          nominal frequency of the signal (GLONASS FCN not considered).
+*          station names are compared with the leading 4 characters.
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) ReadBiasF(file string, sta []Sta) int {
+	var (
+		fp               *os.File
+		bias             Bias
+		key              BiasKey
+		buff, btype, prn string
+		name, obs1, unit string
+		freq             float64
+		i, block, nb     int
+		err              error
+	)
+
+	Trace(3, "readbiasf: file=%s\n", file)
+
+	if fp, err = os.OpenFile(file, os.O_RDONLY, 0666); err != nil {
+		Trace(2, "sinex bias file open error: %s\n", file)
+		return 0
+	}
+	defer fp.Close()
+
+	rd := bufio.NewReader(fp)
+	for {
+		buff, err = rd.ReadString('\n')
+		if err != nil && len(buff) == 0 {
+			break
+		}
+		if strings.HasPrefix(buff, "+BIAS/SOLUTION") {
+			block = 1
+			continue
+		} else if strings.HasPrefix(buff, "-BIAS/SOLUTION") {
+			block = 0
+			continue
+		}
+		if block == 0 || len(buff) < 92 || buff[0] != ' ' {
+			continue
+		}
+		buff = strings.TrimRight(buff, "\r\n") + strings.Repeat(" ", 12)
+
+		btype = buff[1:4]
+		prn = strings.TrimSpace(buff[11:14])
+		name = strings.TrimSpace(buff[15:24])
+		obs1 = strings.TrimSpace(buff[25:29])
+		unit = strings.TrimSpace(buff[65:69])
+
+		if (btype != "OSB" && btype != "DSB") || len(obs1) < 3 {
+			continue
+		}
+		key = BiasKey{}
+
+		/* satellite or system */
+		if len(prn) >= 3 {
+			if key.Sat = SatId2No(prn); key.Sat == 0 {
+				continue
+			}
+			key.Sys = SatSys(key.Sat, nil)
+		} else if len(prn) > 0 {
+			key.Sys = code2sys(rune(prn[0]))
+		} else if svn := strings.TrimSpace(buff[6:10]); len(svn) > 0 {
+			key.Sys = code2sys(rune(svn[0]))
+		}
+		if key.Sys == SYS_NONE {
+			continue
+		}
+		/* station */
+		if len(name) > 0 {
+			for i = 0; i < len(sta); i++ {
+				if strings.EqualFold(sta[i].Name, name) ||
+					(len(name) >= 4 && len(sta[i].Name) >= 4 &&
+						strings.EqualFold(sta[i].Name[:4], name[:4])) {
+					break
+				}
+			}
+			if i >= len(sta) {
+				continue
+			}
+			key.Rcv = i + 1
+		} else if key.Sat == 0 {
+			continue
+		}
+		/* observation codes */
+		if obs1[0] == 'L' {
+			key.Phase = 1
+		} else if obs1[0] != 'C' {
+			continue
+		}
+		if key.Code1 = Obs2Code(obs1[1:3]); key.Code1 == CODE_NONE {
+			continue
+		}
+		if btype == "DSB" {
+			obs2 := strings.TrimSpace(buff[30:34])
+			if len(obs2) < 3 || obs2[0] != obs1[0] {
+				continue
+			}
+			if key.Code2 = Obs2Code(obs2[1:3]); key.Code2 == CODE_NONE {
+				continue
+			}
+		}
+		bias.Ts = str2sinextime(buff[35:49])
+		bias.Te = str2sinextime(buff[50:64])
+		bias.Val = Str2Num(buff, 70, 21)
+		bias.Std = Str2Num(buff, 92, 11)
+
+		switch unit {
+		case "ns":
+			bias.Val *= 1e-9 * CLIGHT /* ns -> m */
+			bias.Std *= 1e-9 * CLIGHT
+		case "cyc":
+			if freq = Code2Freq(key.Sys, key.Code1, 0); freq == 0.0 {
+				continue
+			}
+			bias.Val *= CLIGHT / freq /* cycle -> m */
+			bias.Std *= CLIGHT / freq
+		default:
+			continue
+		}
+		nav.AddBias(key, &bias)
+		nb++
+	}
+	/* sort biases by start time */
+	for _, v := range nav.Bias {
+		sort.SliceStable(v, func(i, j int) bool {
+			return TimeDiff(v[i].Ts, v[j].Ts) < 0.0
+		})
+	}
+	Trace(4, "readbiasf: nbias=%d\n", nb)
+	return 1
+}
+
+/* read SINEX-BIAS files -------------------------------------------------------
+* read SINEX-BIAS files (wild-card * is expanded)
+* args   : char   *file     I   SINEX-BIAS file path (wild-card * expanded)
+*          sta_t  *sta      I   station info data to read receiver biases
+*                               (NULL: no receiver biases)
+* return : status (1:ok,0:error)
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) ReadBias(file string, sta []Sta) int {
+	var (
+		i, n   int
+		efiles []string = make([]string, MAXEXFILE)
+	)
+
+	Trace(3, "readbias: file=%s\n", file)
+
+	n = ExPath(file, efiles, MAXEXFILE)
+
+	for i = 0; i < n; i++ {
+		nav.ReadBiasF(efiles[i], sta)
+	}
+	return 1
+}
+
+/* search signal bias valid at time ------------------------------------------*/
+func (nav *Nav) searchbias(time Gtime, key BiasKey, bias *float64) int {
+	for _, b := range nav.Bias[key] {
+		if b.Ts.Time != 0 && TimeDiff(time, b.Ts) < 0.0 {
+			continue
+		}
+		if b.Te.Time != 0 && TimeDiff(time, b.Te) >= 0.0 {
+			continue
+		}
+		*bias = b.Val
+		return 1
+	}
+	return 0
+}
+
+/* search OSB or DSB for satellite or receiver -------------------------------*/
+func (nav *Nav) getbias(time Gtime, sat, rcv, sys, phase int, code1, code2 uint8,
+	bias *float64) int {
+	key := BiasKey{Sat: sat, Rcv: rcv, Sys: sys, Phase: phase, Code1: code1, Code2: code2}
+
+	if nav.searchbias(time, key, bias) > 0 {
+		return 1
+	}
+	if rcv > 0 && sat > 0 { /* receiver bias common to system */
+		key.Sat = 0
+		return nav.searchbias(time, key, bias)
+	}
+	return 0
+}
+
+/* search DSB (code1-code2) --------------------------------------------------*/
+func (nav *Nav) getdsb(time Gtime, sat, rcv, sys int, code1, code2 uint8,
+	dsb *float64) int {
+	var b float64
+
+	if nav.getbias(time, sat, rcv, sys, 0, code1, code2, &b) > 0 {
+		*dsb = b
+		return 1
+	}
+	if nav.getbias(time, sat, rcv, sys, 0, code2, code1, &b) > 0 {
+		*dsb = -b
+		return 1
+	}
+	return 0
+}
+
+/* code OSB derived from DSB -------------------------------------------------*/
+func (nav *Nav) dsb2osb(time Gtime, sat, rcv, sys int, code uint8, bias *float64) int {
+	var f1, f2, d12, d, osb1, osb2 float64
+
+	ref1, ref2 := clkrefcode(sys)
+	if ref1 == CODE_NONE {
+		return 0
+	}
+	if nav.getdsb(time, sat, rcv, sys, ref1, ref2, &d12) == 0 && rcv == 0 {
+		return 0 /* receiver DSB between reference codes assumed 0 if none */
+	}
+	f1 = Code2Freq(sys, ref1, 0)
+	f2 = Code2Freq(sys, ref2, 0)
+	if f1 == 0.0 || f2 == 0.0 {
+		return 0
+	}
+	/* OSBs of reference codes free of iono-free combination */
+	osb1 = -SQR(f2) / (SQR(f1) - SQR(f2)) * d12
+	osb2 = -SQR(f1) / (SQR(f1) - SQR(f2)) * d12
+
+	switch {
+	case code == ref1:
+		*bias = osb1
+	case code == ref2:
+		*bias = osb2
+	case nav.getdsb(time, sat, rcv, sys, code, ref1, &d) > 0:
+		*bias = osb1 + d
+	case nav.getdsb(time, sat, rcv, sys, code, ref2, &d) > 0:
+		*bias = osb2 + d
+	default:
+		return 0
+	}
+	return 1
+}
+
+/* signal bias -----------------------------------------------------------------
+* get signal bias (OSB) of satellite or receiver
+* args   : gtime_t time     I   time (GPST)
+*          int    sat       I   satellite number
+*          int    rcv       I   receiver number (0:satellite bias)
+*          uint8  code      I   obs code (CODE_???)
+*          int    phase     I   observable (0:code,1:phase)
+*          double *bias     O   signal bias (m)
+* return : status (0:no bias,1:OSB,2:OSB derived from DSB)
+* notes  : corrected observable = observable - bias
+*          code OSB is derived from DSB if no OSB. the DSB between the clock
+*          reference codes is distributed to make the iono-free combination
+*          of the reference codes free of bias.
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) SigBias(time Gtime, sat, rcv int, code uint8, phase int,
+	bias *float64) int {
+	var sys int
+
+	*bias = 0.0
+	if len(nav.Bias) == 0 || code == CODE_NONE {
+		return 0
+	}
+	sys = SatSys(sat, nil)
+
+	if nav.getbias(time, sat, rcv, sys, phase, code, CODE_NONE, bias) > 0 {
+		return 1
+	}
+	if phase == 0 && nav.dsb2osb(time, sat, rcv, sys, code, bias) > 0 {
+		return 2
+	}
+	return 0
+}
+
+/* observation signal bias -----------------------------------------------------
+* get sum of satellite and receiver signal biases for observation
+* args   : obsd_t *obs      I   observation data
+*          int    f         I   frequency index
+*          int    phase     I   observable (0:code,1:phase)
+*          double *bias     O   signal bias (m)
+* return : status (1:satellite bias found,0:no satellite bias)
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) ObsBias(obs *ObsD, f, phase int, bias *float64) int {
+	var b float64
+
+	*bias = 0.0
+	if nav.SigBias(obs.Time, obs.Sat, 0, obs.Code[f], phase, bias) == 0 {
+		return 0
+	}
+	if obs.Rcv > 0 && nav.SigBias(obs.Time, obs.Sat, obs.Rcv, obs.Code[f], phase, &b) > 0 {
+		*bias += b
+	}
+	return 1
+}
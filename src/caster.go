@@ -0,0 +1,1033 @@
+/*------------------------------------------------------------------------------
+* caster.go : ntrip caster functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* references :
+*     [1] RTCM Recommendaed Standards for Networked Transport for RTCM via
+*         Internet Protocol (Ntrip), Version 1.0, Semptember 30, 2004
+*     [2] RTCM Recommendaed Standards for Networked Transport for RTCM via
+*         Internet Protocol (Ntrip), Version 2.0, June 28, 2011
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, multi-mountpoint caster with sourcetable,
+*                           user authentication and client statistics
+*           2026/10/16 1.1  accept Ntrip-GGA header of ntrip 2.0 client
+*           2026/10/16 1.2  authenticate clients only for listed mountpoints
+*           2026/10/16 1.3  add DelUser(), merge users loaded from file
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httputil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	CASTER_MAXCON   = 1024  /* max number of caster connections */
+	CASTER_NQUEUE   = 256   /* max number of queued data blocks for client */
+	CASTER_MAXDROP  = 1024  /* max number of dropped blocks to disconnect client */
+	CASTER_BUFFSIZE = 4096  /* caster source read buffer size (bytes) */
+	CASTER_RBUFSIZE = 65536 /* caster local read buffer size (bytes) */
+	CASTER_TIMEOUT  = 10    /* caster request/send timeout (s) */
+	CASTER_SRCTMO   = 60    /* caster source inactive timeout (s) */
+
+	CASTER_RSP_TAKEN = "ERROR - Mount Point Taken or Invalid\r\n" /* ntrip response: taken */
+	CASTER_RSP_BADRQ = "HTTP/1.1 400 Bad Request\r\n"             /* ntrip response: bad request */
+)
+
+type CasterStat struct { /* caster connection status type */
+	Type     int    /* connection type (0:source,1:client) */
+	Ver      int    /* ntrip version (1,2) */
+	Mntpnt   string /* mountpoint */
+	User     string /* user */
+	Agent    string /* source/user agent */
+	Addr     string /* remote address */
+	Tcon     Gtime  /* connect time (UTC) */
+	InBytes  uint64 /* input bytes */
+	OutBytes uint64 /* output bytes */
+	Drops    uint64 /* dropped data blocks */
+	Gga      string /* last nmea GGA from client */
+}
+
+type caster_user struct { /* caster user type */
+	ctype  int      /* user type (0:source,1:client) */
+	name   string   /* user name */
+	passwd string   /* password */
+	mnts   []string /* allowed mountpoints ("*":all) */
+}
+
+type caster_con struct { /* caster connection type */
+	stat  CasterStat    /* connection status (bytes/drops are atomic) */
+	conn  net.Conn      /* socket */
+	queue chan []byte   /* output data queue (client) */
+	quit  chan struct{} /* disconnect signal */
+	once  sync.Once     /* disconnect once */
+}
+
+type caster_mnt struct { /* caster mountpoint type */
+	name  string                   /* mountpoint */
+	str   string                   /* sourcetable STR record */
+	src   *caster_con              /* remote source (nil:local or none) */
+	local int                      /* local source (0:no,1:write,2:read) */
+	clis  map[*caster_con]struct{} /* clients */
+	rbuf  []byte                   /* local read buffer */
+}
+
+type Caster struct { /* ntrip caster type */
+	state  int                    /* state (0:close,1:wait,2:connect) */
+	port   string                 /* port */
+	ln     net.Listener           /* listener */
+	lock   sync.Mutex             /* lock flag */
+	wg     sync.WaitGroup         /* connection threads */
+	users  []caster_user          /* source/client users */
+	srctbl []string               /* static sourcetable records (STR/CAS/NET) */
+	mnts   map[string]*caster_mnt /* mountpoints */
+	cons   map[*caster_con]struct{}
+	nref   int /* reference count for shared caster */
+}
+
+var (
+	casters    = map[string]*Caster{} /* shared casters by port */
+	casterlock sync.Mutex
+	casterfile [2]string /* caster users and sourcetable files */
+)
+
+/* open ntrip caster -----------------------------------------------------------
+* open ntrip caster and start to accept connections
+* args   : string port      I   port to listen ("":2101)
+*          string *msg      O   error message
+* return : caster (nil: error)
+* notes  : the caster accepts following requests on the port
+*            GET /mntpnt      : ntrip 1.0/2.0 client (no mntpnt: sourcetable)
+*            SOURCE pwd /mnt  : ntrip 1.0 server
+*            POST /mntpnt     : ntrip 2.0 server
+*-----------------------------------------------------------------------------*/
+func OpenCaster(port string, msg *string) *Caster {
+	var err error
+
+	Tracet(3, "opencaster: port=%s\n", port)
+
+	if len(port) == 0 {
+		port = fmt.Sprintf("%d", NTRIP_CLI_PORT)
+	}
+	cas := &Caster{port: port, mnts: make(map[string]*caster_mnt),
+		cons: make(map[*caster_con]struct{})}
+
+	if cas.ln, err = net.Listen("tcp", ":"+port); err != nil {
+		*msg = fmt.Sprintf("bind error (%s)", err.Error())
+		Tracet(2, "opencaster: listen error port=%s err=%s\n", port, err.Error())
+		return nil
+	}
+	cas.state = 1
+	cas.wg.Add(1)
+	go cas.accept()
+	return cas
+}
+
+/* close ntrip caster --------------------------------------------------------*/
+func (cas *Caster) CloseCaster() {
+	Tracet(3, "closecaster: port=%s\n", cas.port)
+
+	cas.lock.Lock()
+	cas.state = 0
+	cas.ln.Close()
+	for c := range cas.cons {
+		c.close()
+	}
+	cas.lock.Unlock()
+
+	cas.wg.Wait()
+}
+
+/* load caster users -----------------------------------------------------------
+* load caster source/client users from credentials file
+* args   : string file      I   credentials file
+* return : status (1:ok,0:error)
+* notes  : the credentials file consists of records as follows. "#" for
+*          comment. mountpoints are separated by "," ("*": all mountpoints)
+*            USER;user;passwd;mntpnt[,mntpnt...]   : client user
+*            SOURCE;user;passwd;mntpnt[,mntpnt...] : source (server) user
+*          clients are authenticated only for the mountpoints listed by client
+*          users, other mountpoints are open for clients. sources are accepted
+*          only for defined source users (ntrip 1.0 source is checked by
+*          password).
+*          the users are merged to the current users (added by AddUser() or
+*          loaded before). the same users are not added twice.
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) LoadUsers(file string) int {
+	var users []caster_user
+
+	Tracet(3, "loadusers: file=%s\n", file)
+
+	fp, err := os.Open(file)
+	if err != nil {
+		Tracet(2, "caster users file open error: %s\n", file)
+		return 0
+	}
+	defer fp.Close()
+
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		buff := strings.TrimSpace(sc.Text())
+		if len(buff) == 0 || buff[0] == '#' {
+			continue
+		}
+		v := strings.Split(buff, ";")
+		if len(v) < 4 {
+			Tracet(2, "caster users file error: %s\n", buff)
+			continue
+		}
+		user := caster_user{name: v[1], passwd: v[2]}
+		switch v[0] {
+		case "SOURCE":
+			user.ctype = 0
+		case "USER":
+			user.ctype = 1
+		default:
+			continue
+		}
+		for _, m := range strings.Split(v[3], ",") {
+			if m = strings.TrimSpace(m); len(m) > 0 {
+				user.mnts = append(user.mnts, m)
+			}
+		}
+		users = append(users, user)
+	}
+	cas.lock.Lock()
+	for i := range users {
+		if cas.finduser(&users[i]) < 0 {
+			cas.users = append(cas.users, users[i])
+		}
+	}
+	cas.lock.Unlock()
+	return 1
+}
+
+/* load caster sourcetable -----------------------------------------------------
+* load static sourcetable records (STR, CAS and NET)
+* args   : string file      I   sourcetable file
+* return : status (1:ok,0:error)
+* notes  : STR records are used for the mountpoints and output in the
+*          sourcetable only if the sources are online. CAS and NET records
+*          are always output.
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) LoadSrcTbl(file string) int {
+	var srctbl []string
+
+	Tracet(3, "loadsrctbl: file=%s\n", file)
+
+	fp, err := os.Open(file)
+	if err != nil {
+		Tracet(2, "caster sourcetable file open error: %s\n", file)
+		return 0
+	}
+	defer fp.Close()
+
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		buff := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(buff, "STR;") || strings.HasPrefix(buff, "CAS;") ||
+			strings.HasPrefix(buff, "NET;") {
+			srctbl = append(srctbl, buff)
+		}
+	}
+	cas.lock.Lock()
+	cas.srctbl = srctbl
+	cas.lock.Unlock()
+	return 1
+}
+
+/* add user --------------------------------------------------------------------
+* add caster source/client user
+* args   : int    ctype     I   user type (0:source,1:client)
+*          string user      I   user name
+*          string passwd    I   password
+*          string mntpnt    I   mountpoint ("*": all)
+* return : none
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) AddUser(ctype int, user, passwd, mntpnt string) {
+	cas.lock.Lock()
+	cas.users = append(cas.users, caster_user{ctype: ctype, name: user,
+		passwd: passwd, mnts: []string{mntpnt}})
+	cas.lock.Unlock()
+}
+
+/* delete user ---------------------------------------------------------------
+* delete caster source/client user added by AddUser()
+* args   : int    ctype     I   user type (0:source,1:client)
+*          string user      I   user name
+*          string passwd    I   password
+*          string mntpnt    I   mountpoint ("*": all)
+* return : none
+* notes  : only one of the same users added by AddUser() is deleted
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) DelUser(ctype int, user, passwd, mntpnt string) {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	if i := cas.finduser(&caster_user{ctype: ctype, name: user, passwd: passwd,
+		mnts: []string{mntpnt}}); i >= 0 {
+		cas.users = append(cas.users[:i], cas.users[i+1:]...)
+	}
+}
+
+/* search same user ----------------------------------------------------------*/
+func (cas *Caster) finduser(user *caster_user) int {
+	for i, u := range cas.users {
+		if u.ctype != user.ctype || u.name != user.name || u.passwd != user.passwd ||
+			len(u.mnts) != len(user.mnts) {
+			continue
+		}
+		j := 0
+		for j < len(u.mnts) && u.mnts[j] == user.mnts[j] {
+			j++
+		}
+		if j == len(u.mnts) {
+			return i
+		}
+	}
+	return -1
+}
+
+/* add local mountpoint --------------------------------------------------------
+* add local mountpoint written by WriteCaster() or read by ReadCaster()
+* args   : string mntpnt    I   mountpoint
+*          string str       I   sourcetable STR record after mountpoint
+*                               ("": STR record in sourcetable file)
+*          int    mode      I   local mode (1:write (source),2:read (sink))
+* return : status (1:ok,0:error)
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) AddMount(mntpnt, str string, mode int) int {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	mnt := cas.getmnt(mntpnt)
+	if mnt.local != 0 || (mode == 1 && mnt.src != nil) {
+		Tracet(2, "addmount: mountpoint taken %s\n", mntpnt)
+		return 0
+	}
+	mnt.local = mode
+	if len(str) > 0 {
+		mnt.str = fmt.Sprintf("STR;%s;%s", mntpnt, str)
+	}
+	return 1
+}
+
+/* delete local mountpoint ---------------------------------------------------*/
+func (cas *Caster) DelMount(mntpnt string) {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	if mnt, ok := cas.mnts[mntpnt]; ok {
+		mnt.local = 0
+		mnt.rbuf = nil
+		if mnt.src == nil {
+			cas.closemnt(mnt)
+		}
+	}
+}
+
+/* write data to local mountpoint ----------------------------------------------
+* write data to clients of local mountpoint
+* args   : string mntpnt    I   mountpoint
+*          uint8  *buff     I   data
+*          int    n         I   data length
+* return : number of bytes written
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) WriteCaster(mntpnt string, buff []byte, n int) int {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	mnt, ok := cas.mnts[mntpnt]
+	if !ok || mnt.local != 1 {
+		return 0
+	}
+	cas.fanout(mnt, buff[:n])
+	return n
+}
+
+/* read data from local mountpoint ---------------------------------------------
+* read data sent by remote source to local mountpoint
+* args   : string mntpnt    I   mountpoint
+*          uint8  *buff     O   data
+*          int    n         I   max data length
+* return : number of bytes read
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) ReadCaster(mntpnt string, buff []byte, n int) int {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	mnt, ok := cas.mnts[mntpnt]
+	if !ok || mnt.local != 2 || len(mnt.rbuf) == 0 {
+		return 0
+	}
+	nr := copy(buff[:n], mnt.rbuf)
+	mnt.rbuf = mnt.rbuf[nr:]
+	return nr
+}
+
+/* get caster state (0:close,1:wait,2:connect) -------------------------------*/
+func (cas *Caster) StateCaster() int {
+	if cas == nil {
+		return 0
+	}
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	if cas.state > 0 && len(cas.cons) > 0 {
+		return 2
+	}
+	return cas.state
+}
+
+/* get caster connection status ------------------------------------------------
+* get status of caster connections (sources and clients)
+* args   : none
+* return : connection status sorted by mountpoint
+*-----------------------------------------------------------------------------*/
+func (cas *Caster) StatCaster() []CasterStat {
+	var stat []CasterStat
+
+	cas.lock.Lock()
+	for c := range cas.cons {
+		stat = append(stat, CasterStat{
+			Type: c.stat.Type, Ver: c.stat.Ver, Mntpnt: c.stat.Mntpnt,
+			User: c.stat.User, Agent: c.stat.Agent, Addr: c.stat.Addr,
+			Tcon: c.stat.Tcon, Gga: c.stat.Gga,
+			InBytes:  atomic.LoadUint64(&c.stat.InBytes),
+			OutBytes: atomic.LoadUint64(&c.stat.OutBytes),
+			Drops:    atomic.LoadUint64(&c.stat.Drops)})
+	}
+	cas.lock.Unlock()
+
+	sort.Slice(stat, func(i, j int) bool {
+		if stat[i].Mntpnt != stat[j].Mntpnt {
+			return stat[i].Mntpnt < stat[j].Mntpnt
+		}
+		if stat[i].Type != stat[j].Type {
+			return stat[i].Type < stat[j].Type
+		}
+		return TimeDiff(stat[i].Tcon, stat[j].Tcon) < 0.0
+	})
+	return stat
+}
+
+/* get extended caster state -------------------------------------------------*/
+func (cas *Caster) StatExCaster(msg *string) int {
+	state := cas.StateCaster()
+
+	*msg += "caster:\n"
+	*msg += fmt.Sprintf("  state   = %d\n", state)
+	if state == 0 {
+		return 0
+	}
+	*msg += fmt.Sprintf("  port    = %s\n", cas.port)
+	for i, s := range cas.StatCaster() {
+		ctype := "source"
+		if s.Type == 1 {
+			ctype = "client"
+		}
+		*msg += fmt.Sprintf("  con#%d:\n", i)
+		*msg += fmt.Sprintf("    type  = %s (ntrip %d.0)\n", ctype, s.Ver)
+		*msg += fmt.Sprintf("    mntpnt= %s\n", s.Mntpnt)
+		*msg += fmt.Sprintf("    user  = %s\n", s.User)
+		*msg += fmt.Sprintf("    agent = %s\n", s.Agent)
+		*msg += fmt.Sprintf("    addr  = %s\n", s.Addr)
+		*msg += fmt.Sprintf("    tcon  = %s\n", TimeStr(s.Tcon, 0))
+		*msg += fmt.Sprintf("    inbyte= %d\n", s.InBytes)
+		*msg += fmt.Sprintf("    outbyt= %d\n", s.OutBytes)
+		*msg += fmt.Sprintf("    drops = %d\n", s.Drops)
+	}
+	return state
+}
+
+/* generate sourcetable ------------------------------------------------------*/
+func (cas *Caster) SrcTbl() string {
+	var buff string
+
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	names := make([]string, 0, len(cas.mnts))
+	for name, mnt := range cas.mnts {
+		if mnt.src != nil || mnt.local == 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		buff += cas.strrec(cas.mnts[name]) + "\r\n"
+	}
+	for _, rec := range cas.srctbl {
+		if !strings.HasPrefix(rec, "STR;") {
+			buff += rec + "\r\n"
+		}
+	}
+	return buff + NTRIP_RSP_TBLEND + "\r\n"
+}
+
+/* sourcetable STR record of mountpoint --------------------------------------*/
+func (cas *Caster) strrec(mnt *caster_mnt) string {
+	var str string
+
+	if len(mnt.str) > 0 {
+		str = mnt.str
+	} else {
+		for _, rec := range cas.srctbl {
+			if strings.HasPrefix(rec, "STR;"+mnt.name+";") {
+				str = rec
+				break
+			}
+		}
+	}
+	if len(str) == 0 {
+		str = fmt.Sprintf("STR;%s;%s;RTCM 3;;0;;;;0.00;0.00;0;0;%s;none;N;N;0;",
+			mnt.name, mnt.name, NTRIP_AGENT)
+	}
+	/* authentication field */
+	v := strings.Split(str, ";")
+	if len(v) > 16 {
+		v[15] = "N"
+		if cas.protected(mnt.name) {
+			v[15] = "B"
+		}
+		str = strings.Join(v, ";")
+	}
+	return str
+}
+
+/* get or create mountpoint --------------------------------------------------*/
+func (cas *Caster) getmnt(name string) *caster_mnt {
+	mnt, ok := cas.mnts[name]
+	if !ok {
+		mnt = &caster_mnt{name: name, clis: make(map[*caster_con]struct{})}
+		cas.mnts[name] = mnt
+	}
+	return mnt
+}
+
+/* close clients of mountpoint and delete mountpoint -------------------------*/
+func (cas *Caster) closemnt(mnt *caster_mnt) {
+	for c := range mnt.clis {
+		c.close()
+	}
+	delete(cas.mnts, mnt.name)
+}
+
+/* fan-out data to clients of mountpoint -------------------------------------*/
+func (cas *Caster) fanout(mnt *caster_mnt, buff []byte) {
+	if mnt.local == 2 {
+		if len(mnt.rbuf)+len(buff) > CASTER_RBUFSIZE { /* overflow */
+			mnt.rbuf = nil
+		}
+		mnt.rbuf = append(mnt.rbuf, buff...)
+	}
+	if len(mnt.clis) == 0 {
+		return
+	}
+	data := append([]byte(nil), buff...)
+
+	for c := range mnt.clis {
+		select {
+		case c.queue <- data:
+		default: /* drop data for slow client */
+			if atomic.AddUint64(&c.stat.Drops, 1) >= CASTER_MAXDROP {
+				Tracet(2, "caster: slow client disconnected addr=%s\n", c.stat.Addr)
+				c.close()
+			}
+		}
+	}
+}
+
+/* test mountpoint protected by client users ---------------------------------*/
+func (cas *Caster) protected(mntpnt string) bool {
+	for _, u := range cas.users {
+		if u.ctype != 1 {
+			continue
+		}
+		for _, m := range u.mnts {
+			if m == "*" || m == mntpnt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/* authenticate user (user="": password only) --------------------------------*/
+func (cas *Caster) auth(ctype int, user, passwd, mntpnt string) bool {
+	cas.lock.Lock()
+	defer cas.lock.Unlock()
+
+	if ctype == 1 && !cas.protected(mntpnt) {
+		return true /* open mountpoint for clients */
+	}
+	for _, u := range cas.users {
+		if u.ctype != ctype || u.passwd != passwd || (len(user) > 0 && u.name != user) {
+			continue
+		}
+		for _, m := range u.mnts {
+			if m == "*" || m == mntpnt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/* close connection ----------------------------------------------------------*/
+func (c *caster_con) close() {
+	c.once.Do(func() {
+		close(c.quit)
+		c.conn.Close()
+	})
+}
+
+/* accept connections --------------------------------------------------------*/
+func (cas *Caster) accept() {
+	defer cas.wg.Done()
+
+	for {
+		conn, err := cas.ln.Accept()
+		if err != nil {
+			cas.lock.Lock()
+			state := cas.state
+			cas.lock.Unlock()
+			if state == 0 {
+				return
+			}
+			Tracet(2, "caster: accept error err=%s\n", err.Error())
+			Sleepms(100)
+			continue
+		}
+		c := &caster_con{conn: conn, quit: make(chan struct{})}
+		c.stat.Addr = conn.RemoteAddr().String()
+		c.stat.Tcon = TimeGet()
+
+		cas.lock.Lock()
+		if len(cas.cons) >= CASTER_MAXCON || cas.state == 0 {
+			cas.lock.Unlock()
+			Tracet(2, "caster: too many connections addr=%s\n", c.stat.Addr)
+			conn.Close()
+			continue
+		}
+		cas.cons[c] = struct{}{}
+		cas.lock.Unlock()
+
+		cas.wg.Add(1)
+		go cas.handle(c)
+	}
+}
+
+/* read request header -------------------------------------------------------*/
+func read_request(rd *bufio.Reader) (string, map[string]string, error) {
+	var (
+		req  string
+		nb   int
+		head map[string]string = make(map[string]string)
+	)
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		if nb += len(line); nb >= NTRIP_MAXRSP {
+			return "", nil, fmt.Errorf("request buffer overflow")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if len(req) == 0 {
+				continue
+			}
+			return req, head, nil
+		}
+		if len(req) == 0 {
+			req = line
+		} else if i := strings.Index(line, ":"); i > 0 {
+			head[strings.ToLower(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
+		}
+	}
+}
+
+/* decode basic authorization ------------------------------------------------*/
+func decode_auth(head map[string]string, user, passwd *string) {
+	auth, ok := head["authorization"]
+	if !ok || !strings.HasPrefix(auth, "Basic ") {
+		return
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[6:]))
+	if err != nil {
+		return
+	}
+	if i := strings.Index(string(b), ":"); i >= 0 {
+		*user, *passwd = string(b[:i]), string(b[i+1:])
+	} else {
+		*user = string(b)
+	}
+}
+
+/* http response header for ntrip 2.0 ----------------------------------------*/
+func caster_rsp2(status, ctype string, chunked bool) string {
+	buff := fmt.Sprintf("HTTP/1.1 %s\r\n", status)
+	buff += "Ntrip-Version: Ntrip/2.0\r\n"
+	buff += fmt.Sprintf("Server: NTRIP %s\r\n", NTRIP_AGENT)
+	buff += fmt.Sprintf("Date: %s GMT\r\n", time.Now().UTC().Format("Mon, 02 Jan 2006 15:04:05"))
+	buff += "Cache-Control: no-store, no-cache, max-age=0\r\n"
+	buff += "Pragma: no-cache\r\n"
+	buff += "Connection: close\r\n"
+	if len(ctype) > 0 {
+		buff += fmt.Sprintf("Content-Type: %s\r\n", ctype)
+	}
+	if chunked {
+		buff += "Transfer-Encoding: chunked\r\n"
+	}
+	return buff
+}
+
+/* set connection status ----------------------------------------------------*/
+func (cas *Caster) setstat(c *caster_con, ctype, ver int, agent string) {
+	cas.lock.Lock()
+	c.stat.Type, c.stat.Ver, c.stat.Agent = ctype, ver, agent
+	cas.lock.Unlock()
+}
+
+/* set connection mountpoint and user ----------------------------------------*/
+func (cas *Caster) setuser(c *caster_con, mntpnt, user string) {
+	cas.lock.Lock()
+	c.stat.Mntpnt, c.stat.User = mntpnt, user
+	cas.lock.Unlock()
+}
+
+/* send response -------------------------------------------------------------*/
+func (c *caster_con) send(buff string) bool {
+	c.conn.SetWriteDeadline(time.Now().Add(CASTER_TIMEOUT * time.Second))
+	n, err := c.conn.Write([]byte(buff))
+	atomic.AddUint64(&c.stat.OutBytes, uint64(n))
+	return err == nil
+}
+
+/* handle connection ---------------------------------------------------------*/
+func (cas *Caster) handle(c *caster_con) {
+	var method, url string
+
+	defer func() {
+		c.close()
+		cas.lock.Lock()
+		delete(cas.cons, c)
+		cas.lock.Unlock()
+		cas.wg.Done()
+	}()
+
+	/* read request */
+	c.conn.SetReadDeadline(time.Now().Add(CASTER_TIMEOUT * time.Second))
+	rd := bufio.NewReaderSize(c.conn, CASTER_BUFFSIZE)
+	req, head, err := read_request(rd)
+	if err != nil {
+		Tracet(2, "caster: request error addr=%s err=%s\n", c.stat.Addr, err.Error())
+		return
+	}
+	c.conn.SetReadDeadline(time.Time{})
+	Tracet(3, "caster: request addr=%s req=%s\n", c.stat.Addr, req)
+
+	v := strings.Fields(req)
+	if len(v) >= 1 {
+		method = v[0]
+	}
+	if len(v) >= 2 {
+		url = v[1]
+	}
+	ver, agent := 1, head["user-agent"]
+	if strings.Contains(head["ntrip-version"], "Ntrip/2.0") {
+		ver = 2
+	}
+	switch method {
+	case "GET":
+		cas.setstat(c, 1, ver, agent)
+		cas.handle_client(c, rd, url, head)
+	case "SOURCE": /* SOURCE passwd /mntpnt */
+		if len(v) < 3 {
+			c.send(CASTER_RSP_BADRQ + "\r\n")
+			return
+		}
+		cas.setstat(c, 0, 1, head["source-agent"])
+		cas.handle_source(c, rd, v[2], "", v[1], head["str"], false)
+	case "POST":
+		var user, passwd string
+		decode_auth(head, &user, &passwd)
+		cas.setstat(c, 0, 2, agent)
+		cas.handle_source(c, rd, url, user, passwd, head["ntrip-str"],
+			strings.EqualFold(head["transfer-encoding"], "chunked"))
+	default:
+		Tracet(2, "caster: bad request addr=%s req=%s\n", c.stat.Addr, req)
+		c.send(CASTER_RSP_BADRQ + "\r\n")
+	}
+}
+
+/* handle ntrip client -------------------------------------------------------*/
+func (cas *Caster) handle_client(c *caster_con, rd *bufio.Reader, url string,
+	head map[string]string) {
+	var (
+		user, passwd string
+		w            io.Writer = c.conn
+	)
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	mntpnt := strings.TrimPrefix(url, "/")
+	cas.setuser(c, mntpnt, "")
+
+	/* send sourcetable if no mountpoint or mountpoint offline */
+	cas.lock.Lock()
+	mnt, ok := cas.mnts[mntpnt]
+	online := ok && (mnt.src != nil || mnt.local == 1)
+	cas.lock.Unlock()
+
+	if len(mntpnt) == 0 || !online {
+		if len(mntpnt) > 0 {
+			Tracet(2, "caster: no mountpoint %s addr=%s\n", mntpnt, c.stat.Addr)
+		}
+		srctbl := cas.SrcTbl()
+		if c.stat.Ver == 2 {
+			c.send(caster_rsp2("200 OK", "gnss/sourcetable", false) +
+				fmt.Sprintf("Content-Length: %d\r\n\r\n", len(srctbl)) + srctbl)
+		} else {
+			c.send(NTRIP_RSP_SRCTBL +
+				fmt.Sprintf("Server: NTRIP %s\r\n", NTRIP_AGENT) +
+				"Content-Type: text/plain\r\n" +
+				fmt.Sprintf("Content-Length: %d\r\n\r\n", len(srctbl)) + srctbl)
+		}
+		return
+	}
+	/* authentication */
+	decode_auth(head, &user, &passwd)
+	cas.setuser(c, mntpnt, user)
+
+	if !cas.auth(1, user, passwd, mntpnt) {
+		Tracet(2, "caster: authorization error user=%s mntpnt=%s\n", user, mntpnt)
+		if c.stat.Ver == 2 {
+			c.send(caster_rsp2("401 Unauthorized", "text/plain", false) +
+				fmt.Sprintf("WWW-Authenticate: Basic realm=\"/%s\"\r\n\r\n", mntpnt))
+		} else {
+			c.send(NTRIP_RSP_UNAUTH +
+				fmt.Sprintf("WWW-Authenticate: Basic realm=\"/%s\"\r\n\r\n", mntpnt))
+		}
+		return
+	}
+	/* register client */
+	c.queue = make(chan []byte, CASTER_NQUEUE)
+	cas.lock.Lock()
+	if mnt, ok = cas.mnts[mntpnt]; !ok {
+		cas.lock.Unlock()
+		return
+	}
+	mnt.clis[c] = struct{}{}
+	cas.lock.Unlock()
+
+	defer func() {
+		cas.lock.Lock()
+		delete(mnt.clis, c)
+		cas.lock.Unlock()
+	}()
+
+	/* send OK response */
+	if c.stat.Ver == 2 {
+		if !c.send(caster_rsp2("200 OK", "gnss/data", true) + "\r\n") {
+			return
+		}
+		cw := httputil.NewChunkedWriter(c.conn)
+		defer cw.Close()
+		w = cw
+	} else if !c.send(NTRIP_RSP_OK_CLI) {
+		return
+	}
+	Tracet(3, "caster: client connected mntpnt=%s user=%s addr=%s\n", mntpnt, user,
+		c.stat.Addr)
+
+	/* nmea GGA in request header (ntrip 2.0) */
+	if gga := head["ntrip-gga"]; len(gga) > 0 {
+		cas.lock.Lock()
+		c.stat.Gga = gga
+		cas.lock.Unlock()
+	}
+
+	/* receive nmea GGA from client */
+	go func() {
+		sc := bufio.NewScanner(rd)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			atomic.AddUint64(&c.stat.InBytes, uint64(len(sc.Bytes())+1))
+			if i := strings.Index(line, "GGA,"); i >= 3 && line[0] == '$' {
+				cas.lock.Lock()
+				c.stat.Gga = line
+				cas.lock.Unlock()
+			}
+		}
+		c.close()
+	}()
+
+	/* send data to client */
+	for {
+		select {
+		case data := <-c.queue:
+			c.conn.SetWriteDeadline(time.Now().Add(CASTER_TIMEOUT * time.Second))
+			if _, err := w.Write(data); err != nil {
+				Tracet(2, "caster: send error addr=%s err=%s\n", c.stat.Addr, err.Error())
+				return
+			}
+			atomic.AddUint64(&c.stat.OutBytes, uint64(len(data)))
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+/* handle ntrip source -------------------------------------------------------*/
+func (cas *Caster) handle_source(c *caster_con, rd *bufio.Reader, url, user, passwd,
+	str string, chunked bool) {
+	var (
+		buff []byte    = make([]byte, CASTER_BUFFSIZE)
+		r    io.Reader = rd
+	)
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	mntpnt := strings.TrimPrefix(url, "/")
+	cas.setuser(c, mntpnt, user)
+
+	/* authentication */
+	if len(mntpnt) == 0 || !cas.auth(0, user, passwd, mntpnt) {
+		Tracet(2, "caster: source authorization error user=%s mntpnt=%s\n", user, mntpnt)
+		if c.stat.Ver == 2 {
+			c.send(caster_rsp2("401 Unauthorized", "text/plain", false) + "\r\n")
+		} else {
+			c.send(NTRIP_RSP_ERR_PWD)
+		}
+		return
+	}
+	/* register source */
+	cas.lock.Lock()
+	mnt := cas.getmnt(mntpnt)
+	if mnt.src != nil || mnt.local == 1 {
+		cas.lock.Unlock()
+		Tracet(2, "caster: mountpoint taken %s\n", mntpnt)
+		if c.stat.Ver == 2 {
+			c.send(caster_rsp2("409 Conflict", "text/plain", false) + "\r\n")
+		} else {
+			c.send(CASTER_RSP_TAKEN)
+		}
+		return
+	}
+	mnt.src = c
+	if strings.HasPrefix(str, "STR;") {
+		mnt.str = str
+	} else if len(str) > 0 { /* STR record after mountpoint */
+		mnt.str = fmt.Sprintf("STR;%s;%s", mntpnt, str)
+	}
+	cas.lock.Unlock()
+
+	defer func() {
+		cas.lock.Lock()
+		mnt.src = nil
+		if mnt.local == 0 {
+			cas.closemnt(mnt)
+		}
+		cas.lock.Unlock()
+		Tracet(3, "caster: source disconnected mntpnt=%s\n", mntpnt)
+	}()
+
+	/* send OK response */
+	if c.stat.Ver == 2 {
+		if !c.send(caster_rsp2("200 OK", "", false) + "\r\n") {
+			return
+		}
+	} else if !c.send(NTRIP_RSP_OK_CLI) {
+		return
+	}
+	if chunked {
+		r = httputil.NewChunkedReader(rd)
+	}
+	Tracet(3, "caster: source connected mntpnt=%s addr=%s\n", mntpnt, c.stat.Addr)
+
+	/* receive data from source and fan-out to clients */
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(CASTER_SRCTMO * time.Second))
+		n, err := r.Read(buff)
+		if n > 0 {
+			atomic.AddUint64(&c.stat.InBytes, uint64(n))
+			cas.lock.Lock()
+			cas.fanout(mnt, buff[:n])
+			cas.lock.Unlock()
+		}
+		if err != nil {
+			if err != io.EOF {
+				Tracet(2, "caster: source recv error mntpnt=%s err=%s\n", mntpnt,
+					err.Error())
+			}
+			return
+		}
+	}
+}
+
+/* set caster users and sourcetable files --------------------------------------
+* set ntrip caster users and sourcetable files for ntrip caster stream
+* args   : string userfile  I   caster users (credentials) file ("": no file)
+*          string srctbl    I   caster sourcetable file ("": no file)
+* return : none
+* notes  : the files are loaded at opening a ntrip caster stream
+*-----------------------------------------------------------------------------*/
+func StreamSetCaster(userfile, srctbl string) {
+	Tracet(3, "strsetcaster: userfile=%s srctbl=%s\n", userfile, srctbl)
+
+	casterlock.Lock()
+	casterfile[0], casterfile[1] = userfile, srctbl
+	casterlock.Unlock()
+}
+
+/* open shared caster by port ------------------------------------------------*/
+func opencaster_shared(port string, msg *string) *Caster {
+	casterlock.Lock()
+	defer casterlock.Unlock()
+
+	if cas, ok := casters[port]; ok {
+		cas.nref++
+		return cas
+	}
+	cas := OpenCaster(port, msg)
+	if cas == nil {
+		return nil
+	}
+	if len(casterfile[0]) > 0 {
+		cas.LoadUsers(casterfile[0])
+	}
+	if len(casterfile[1]) > 0 {
+		cas.LoadSrcTbl(casterfile[1])
+	}
+	cas.nref = 1
+	casters[port] = cas
+	return cas
+}
+
+/* close shared caster -------------------------------------------------------*/
+func closecaster_shared(cas *Caster) {
+	casterlock.Lock()
+	if cas.nref--; cas.nref > 0 {
+		casterlock.Unlock()
+		return
+	}
+	delete(casters, cas.port)
+	casterlock.Unlock()
+
+	cas.CloseCaster()
+}
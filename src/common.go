@@ -149,6 +149,9 @@
 *                           use integer types in stdint.h
 *                           surppress warnings
 *		    2022/05/31 1.0  rewrite rtkcmn.c with golang by fxb
+*           2026/10/16 1.1  add API Encode_Word()
+*           2026/10/16 1.2  add default partial ar options
+*           2026/10/16 1.3  add default ins options
 *-----------------------------------------------------------------------------*/
 // /* satellites, systems, codes functions --------------------------------------*/
 // EXPORT int  satno   (int sys, int prn);
@@ -251,7 +254,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -317,6 +319,7 @@ func DefaultProcOpt() PrcOpt {
 		Elmin: 15.0 * D2R, SnrMask: SnrMask{}, /* elmin,snrmask */
 		SatEph: 0, ModeAr: 1, GloModeAr: 1, BDSModeAr: 1, /* sateph,modear,glomodear,bdsmodear */
 		MaxOut: 5, MinLock: 0, MinFix: 10, ArMaxIter: 1, /* maxout,minlock,minfix,armaxiter */
+		ArPartial: 0, ArMinAmb: 4, /* arpartial,arminamb */
 		IonoOpt: 0, TropOpt: 0, Dynamics: 0, TideCorr: 0, /* estion,esttrop,dynamics,tidecorr */
 		NoIter: 1, CodeSmooth: 0, IntPref: 0, SbasCorr: 0, SbasSatSel: 0, /* niter,codesmooth,intpref,sbascorr,sbassatsel */
 		RovPos: 0, RefPos: 0, /*  */
@@ -328,7 +331,9 @@ func DefaultProcOpt() PrcOpt {
 		ThresAr:    [8]float64{3.0, 0.9999, 0.25, 0.1, 0.05},      /* thresar */
 		ElMaskAr:   0.0, ElMaskHold: 0.0, ThresSlip: 0.05,         /* elmaskar,elmaskhold,thresslip */
 		MaxTmDiff: 30.0, MaxInno: 30.0, MaxGdop: 30.0, /* maxtdiff,maxinno,maxgdop */
-		Baseline: [2]float64{0}, Ru: [3]float64{0}, Rb: [3]float64{0} /* baseline,ru,rb */}
+		Baseline: [2]float64{0}, Ru: [3]float64{0}, Rb: [3]float64{0}, /* baseline,ru,rb */
+		InsOpt: InsOpt{Mode: 0, AccNoise: 0.01, GyroNoise: 1e-3, AccBias: 1e-4, GyroBias: 1e-5,
+			MaxDr: 30.0} /* insopt */}
 }
 
 func DefaultSolOpt() SolOpt {
@@ -354,11 +359,13 @@ var FormatStrs [32]string = [32]string{ /* stream format strings */
 	"BINEX",          /* 10 */
 	"Trimble RT17",   /* 11 */
 	"Septentrio SBF", /* 12 */
-	"RINEX",          /* 13 */
-	"SP3",            /* 14 */
-	"RINEX CLK",      /* 15 */
-	"SBAS",           /* 16 */
-	"NMEA 0183",      /* 17 */
+	"Unicore",        /* 13 */
+	"RINEX",          /* 14 */
+	"SP3",            /* 15 */
+	"RINEX CLK",      /* 16 */
+	"SBAS",           /* 17 */
+	"NMEA 0183",      /* 18 */
+	"QZSS L6",        /* 19 */
 	""}
 
 var obscodes []string = []string{ /* observation code strings */
@@ -1330,6 +1337,37 @@ func Decode_Word(word uint32, data []uint8) int {
 	return 1
 }
 
+/* encode navigation data word -------------------------------------------------
+* generate parity and encode navigation data word
+* args   : uint32_t data    I   navigation data without parity (24bit)
+*          uint32_t *word   IO  navigation data word (30bit)
+*                               (input: previous word, output: current word)
+* return : none
+* notes  : inverse of Decode_Word(). data bits are complemented if D30* of the
+*          previous word is set.
+*-----------------------------------------------------------------------------*/
+func Encode_Word(data uint32, word *uint32) {
+	var hamming []uint32 = []uint32{
+		0xBB1F3480, 0x5D8F9A40, 0xAEC7CD00, 0x5763E680, 0x6BB1F340, 0x8B7A89C0}
+	var parity, w, b uint32 = 0, 0, 0
+
+	w = ((*word & 0x3) << 30) | ((data & 0xFFFFFF) << 6)
+
+	for i := 0; i < 6; i++ {
+		parity = parity << 1
+		for b = (w & hamming[i]) >> 6; b > 0; b = b >> 1 {
+			parity = parity ^ (b & 1)
+		}
+	}
+	w |= parity
+	if w&0x40000000 > 0 {
+		w = w ^ 0x3FFFFFC0
+	}
+	*word = w & 0x3FFFFFFF
+
+	Trace(5, "encodeword: word=%08x\n", *word)
+}
+
 /* new matrix ------------------------------------------------------------------
 * allocate memory of matrix
 * args   : int    n,m       I   number of rows and columns of matrix
@@ -4107,7 +4145,7 @@ func ExPath(path string, paths []string, nmax int) int {
 /* generate local directory recursively --------------------------------------*/
 func mkdir_r(dir string) int {
 
-	err := os.Mkdir(dir, os.ModeDir|os.ModePerm)
+	err := os.MkdirAll(dir, os.ModeDir|os.ModePerm)
 	if err != nil && !os.IsExist(err) {
 		return 0
 	}
@@ -4803,87 +4841,25 @@ func SunMoonPos(tutc Gtime, erpv, rsun, rmoon []float64, gmst *float64) {
 * args   : char   *file     I   input file
 *          char   *uncfile  O   uncompressed file
 * return : status (-1:error,0:not compressed file,1:uncompress completed)
-* note   : creates uncompressed file in tempolary directory
-*          gzip, tar and crx2rnx commands have to be installed in commands path
+* note   : creates uncompressed file in the directory of the input file
+*          tar archive is extracted into the directory of the input file
+*          formats are uncompressed in process without external commands
+*          (see NewUncompressReader())
 *-----------------------------------------------------------------------------*/
 func Rtk_Uncompress(file string, uncfile *string) int {
-	var stat = 0
-	var cmd, tmpfile, buff, fname, dir string
-	Trace(4, "rtk_uncompress: file=%s\n", file)
-	var index int
-	tmpfile = file
-	if index = strings.LastIndex(tmpfile, "."); index < 0 {
-		return 0
-	}
-	/* uncompress by gzip */
-	if strings.EqualFold(tmpfile[index:], ".z") || strings.EqualFold(tmpfile[index:], ".gz") ||
-		strings.EqualFold(tmpfile[index:], ".zip") {
-		*uncfile = tmpfile[:index]
-		cmd = fmt.Sprintf("gzip -f -d -c \"%s\" > \"%s\"", tmpfile, tmpfile[:index])
+	var stat int
 
-		if ExecCmd(cmd) > 0 {
+	Trace(4, "rtk_uncompress: file=%s\n", file)
 
-			os.Remove(*uncfile)
-			return -1
-		}
-		tmpfile = tmpfile[:index]
-		stat = 1
+	*uncfile = UncompressFileName(file)
+	if index := strings.LastIndex(*uncfile, "."); index >= 0 &&
+		strings.EqualFold((*uncfile)[index:], ".tar") {
+		*uncfile = (*uncfile)[:index]
 	}
-	/* extract tar file */
-	if strings.EqualFold(tmpfile[index:], ".tar") {
-		*uncfile = tmpfile[:index]
-		buff = tmpfile
-
-		if runtime.GOOS == "windows" {
-			if index = strings.Index(buff, "\\"); index > 0 {
-				dir = buff[:index]
-				fname = buff[index+1:]
-			}
-			cmd = fmt.Sprintf("set PATH=%%CD%%;%%PATH%% & cd /D \"%s\" & tar -xf \"%s\"", dir, fname)
-		} else {
-			if index = strings.Index(tmpfile, "/"); index > 0 {
-				dir = buff[:index]
-			}
-			cmd = fmt.Sprintf("tar -C \"%s\" -xf \"%s\"", dir, tmpfile)
-		}
-		if ExecCmd(cmd) > 0 {
-			if stat > 0 {
-				os.Remove(tmpfile)
-			}
-			return -1
-		}
-		if stat > 0 {
-			os.Remove(tmpfile)
-		}
-		stat = 1
-	} else if index > 0 {
-		buff = tmpfile[index:]
-		if (len(buff) > 3 && (buff[3] == 'd' || buff[3] == 'D')) ||
-			strings.EqualFold(buff, ".crx") {
-			/* extract hatanaka-compressed file by cnx2rnx */
-			tmp := []byte(tmpfile)
-			if buff[3] == 'D' {
-				tmp[3] = 'O'
-			} else {
-				tmp[3] = 'o'
-			}
-			*uncfile = string(tmp)
-
-			cmd = fmt.Sprintf("crx2rnx < \"%s\" > \"%s\"", tmpfile, *uncfile)
-
-			if ExecCmd(cmd) > 0 {
-				os.Remove(*uncfile)
-				if stat > 0 {
-					os.Remove(tmpfile)
-				}
-				return -1
-			}
-			if stat > 0 {
-				os.Remove(tmpfile)
-			}
-			stat = 1
-		}
+	if *uncfile == file {
+		*uncfile = file + "_"
 	}
+	stat = uncompressFile(file, *uncfile)
 
 	Trace(5, "rtk_uncompress: stat=%d\n", stat)
 	return stat
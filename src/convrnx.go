@@ -984,6 +984,10 @@ func CloseRenixFile(ofp []*os.File, opt *RnxOpt, nav *Nav) {
 
 	for i := 0; i < len(ofp); i++ {
 
+		/* append ION/STO/EOP records to RINEX ver.4 NAV */
+		if i == 1 && ofp[i] != nil && opt.RnxVer >= 400 {
+			OutRnxNavRecs(ofp[i], opt, nav)
+		}
 		/* rewrite RINEX header */
 		ofp[i].Seek(0, 0)
 		WriteRinexHeader(ofp, i, opt, nav)
@@ -0,0 +1,1300 @@
+/*------------------------------------------------------------------------------
+* cssr.go : qzss clas compact ssr functions
+*
+*          Copyright (C) 2026 by Feng Xuebin, All rights reserved.
+*
+* references :
+*     [1] Cabinet Office, Quasi-Zenith Satellite System Interface Specification
+*         Centimeter Level Augmentation Service (IS-QZSS-L6-003), 2020
+*     [2] Cabinet Office, Quasi-Zenith Satellite System Interface Specification
+*         Centimeter Level Augmentation Service Grid Definition (clas_grid.def)
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, decode compact ssr messages (subtype 1-12) by
+*                           rtcm 4073 and qzss L6D frames, stec and tropos
+*                           corrections interpolated by clas grid
+*           2026/10/16 1.1  store network ssr corrections (subtype 6,11) per
+*                           network and select network by receiver position,
+*                           reject L6 subframe with decode error
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	CSSR_MSGNO    = 4073       /* compact ssr message number */
+	CSSR_MAXSAT   = 64         /* max number of satellites in mask */
+	CSSR_MAXSIG   = 16         /* max number of signals in mask */
+	CSSR_MAXNET   = 32         /* max number of compact network id */
+	CSSR_MAXGRID  = 64         /* max number of grids in network */
+	CSSR_NOVAL    = -9999.0    /* no value of grid corrections */
+	CSSR_MAXAGE   = 120.0      /* max age of atmospheric corrections (s) */
+	CSSR_MAXDIST  = 100e3      /* max distance to grid for interpolation (m) */
+	CSSR_ERR_STEC = 0.2        /* default stec error std (TECU) */
+	CSSR_ERR_TROP = 0.03       /* tropos zenith delay error std (m) */
+	CSSR_TECU2M   = 40.3e16    /* ionos delay factor (m*Hz^2/TECU) */
+	L6_PREAMB     = 0x1ACFFC1D /* L6 frame preamble */
+	L6_FRMLEN     = 250        /* L6 frame length (bytes) */
+	L6_DATALEN    = 1695       /* L6 data part length (bits) */
+	L6_NFRM       = 5          /* number of L6 frames in subframe */
+	L6_VENDOR_CLS = 5          /* L6 vendor id of clas */
+)
+
+type CssrGrid struct { /* clas grid point type */
+	Net int        /* compact network id */
+	No  int        /* grid number */
+	Pos [3]float64 /* grid position {lat,lon,hgt} (rad,m) */
+}
+
+type CssrAtm struct { /* clas atmospheric corrections type (per network) */
+	T0     [2]Gtime                      /* epoch time {tropos,stec residual} (GPST) */
+	Udi    [2]float64                    /* update interval {tropos,stec residual} (s) */
+	Trpf   int                           /* tropos (0:none,1:hydro+wet,2:poly+residual) */
+	Ngrid  int                           /* number of grids */
+	Trp    [4]float64                    /* tropos wet polynomial {t00,t01,t10,t11} (m,m/deg,m/deg^2) */
+	Trph   [CSSR_MAXGRID]float64         /* hydrostatic vertical delay at grids (m) */
+	Trpw   [CSSR_MAXGRID]float64         /* wet vertical delay at grids (m) */
+	Ts     [MAXSAT]Gtime                 /* epoch time of stec polynomial (GPST) */
+	Stec   [MAXSAT][6]float64            /* stec polynomial {c00,c01,c10,c11,c02,c20} (TECU,TECU/deg,TECU/deg^2) */
+	StecQ  [MAXSAT]float64               /* stec quality (TECU) (0:unknown) */
+	Tr     [MAXSAT]Gtime                 /* epoch time of stec residuals (GPST) */
+	Stecr  [MAXSAT][CSSR_MAXGRID]float32 /* stec residuals at grids (TECU) */
+	Tn     Gtime                         /* epoch time of network ssr corrections (GPST) */
+	Ssr    []SSR                         /* network ssr corrections (subtype 6,11) (index:sat-1) */
+	Update uint8                         /* update flag (0:no update,1:update) */
+}
+
+type Cssr struct { /* compact ssr control type */
+	Tow0 float64                         /* GPS time of week of mask (s) (0:no mask) */
+	Iod  int                             /* iod ssr of mask */
+	Nsat int                             /* number of satellites in mask */
+	Sat  [CSSR_MAXSAT]int                /* satellite numbers in mask (0:not supported) */
+	Gnss [CSSR_MAXSAT]int                /* gnss id of satellites in mask */
+	Nsig [CSSR_MAXSAT]int                /* number of signals in cell mask */
+	Code [CSSR_MAXSAT][CSSR_MAXSIG]uint8 /* signal codes in cell mask (CODE_???) */
+	Prn  int                             /* L6 PRN number of subframe */
+	Nfrm int                             /* number of L6 frames in subframe (-1:no sync) */
+	Nbit int                             /* number of bits in subframe buffer */
+	Buff [L6_NFRM*L6_DATALEN/8 + 1]byte  /* L6 subframe data part buffer */
+	Satm map[int]CssrAtm                 /* atmospheric corrections saved in subframe (key:network id) */
+	Sssr map[int]SSR                     /* ssr corrections saved in subframe (key:sat) */
+}
+
+/* gnss id to system, prn offset and bits of iode ----------------------------*/
+var (
+	cssr_sys   = [...]int{SYS_GPS, SYS_GLO, SYS_GAL, SYS_CMP, SYS_QZS, SYS_SBS}
+	cssr_prn0  = [...]int{1, 1, 1, 1, 193, 120}
+	cssr_niode = [...]int{8, 7, 10, 8, 8, 9}
+	cssr_sigs  = [...][CSSR_MAXSIG]uint8{
+		{CODE_L1C, CODE_L1P, CODE_L1W, CODE_L1S, CODE_L1L, CODE_L1X, CODE_L2S, CODE_L2L,
+			CODE_L2X, CODE_L2P, CODE_L2W, CODE_L5I, CODE_L5Q, CODE_L5X, 0, 0}, /* GPS */
+		{CODE_L1C, CODE_L1P, CODE_L2C, CODE_L2P, CODE_L4A, CODE_L4B, CODE_L4X, CODE_L6A,
+			CODE_L6B, CODE_L6X, CODE_L3I, CODE_L3Q, CODE_L3X, 0, 0, 0}, /* GLO */
+		{CODE_L1B, CODE_L1C, CODE_L1X, CODE_L5I, CODE_L5Q, CODE_L5X, CODE_L7I, CODE_L7Q,
+			CODE_L7X, CODE_L8I, CODE_L8Q, CODE_L8X, CODE_L6B, CODE_L6C, CODE_L6X, 0}, /* GAL */
+		{CODE_L2I, CODE_L2Q, CODE_L2X, CODE_L6I, CODE_L6Q, CODE_L6X, CODE_L7I, CODE_L7Q,
+			CODE_L7X, CODE_L1D, CODE_L1P, CODE_L1X, CODE_L5D, CODE_L5P, CODE_L5X, 0}, /* BDS */
+		{CODE_L1C, CODE_L1S, CODE_L1L, CODE_L1X, CODE_L2S, CODE_L2L, CODE_L2X, CODE_L5I,
+			CODE_L5Q, CODE_L5X, CODE_L6S, CODE_L6L, CODE_L6X, 0, 0, 0}, /* QZS */
+		{CODE_L1C, CODE_L5I, CODE_L5Q, CODE_L5X, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}} /* SBS */
+)
+
+/* signed bits with invalid value (-2^(n-1)) ---------------------------------*/
+func cssr_getbits(buff []byte, pos, n int, valid *bool) float64 {
+	v := GetBits(buff, pos, n)
+	*valid = v != -(1 << (n - 1))
+	return float64(v)
+}
+
+/* ssr quality indicator to value (TECU) -------------------------------------*/
+func cssr_quality(q int) float64 {
+	if q == 0 || q == 63 {
+		return 0.0 /* undefined or unknown */
+	}
+	return math.Pow(3.0, float64(q>>3))*(1.0+float64(q&7)/4.0) - 1.0
+}
+
+/* network satellite mask ----------------------------------------------------*/
+func (cssr *Cssr) netmask(buff []byte, i, nb int, mask []bool) int {
+	if i+cssr.Nsat > nb {
+		return -1
+	}
+	for k := 0; k < cssr.Nsat; k++ {
+		mask[k] = GetBitU(buff, i+k, 1) == 1
+	}
+	return i + cssr.Nsat
+}
+
+/* copy atmospheric corrections with network ssr corrections ----------------*/
+func (atm *CssrAtm) copy() CssrAtm {
+	dst := *atm
+	if atm.Ssr != nil {
+		dst.Ssr = make([]SSR, MAXSAT)
+		copy(dst.Ssr, atm.Ssr)
+	}
+	return dst
+}
+
+/* atmospheric corrections of network ----------------------------------------*/
+func (rtcm *Rtcm) cssr_atm(net int) *CssrAtm {
+	if len(rtcm.NavData.Catm) < CSSR_MAXNET {
+		rtcm.NavData.Catm = make([]CssrAtm, CSSR_MAXNET)
+	}
+	atm := &rtcm.NavData.Catm[net]
+	if _, ok := rtcm.Cssr.Satm[net]; rtcm.Cssr.Satm != nil && !ok {
+		rtcm.Cssr.Satm[net] = atm.copy() /* save before update in subframe */
+	}
+	atm.Update = 1
+	return atm
+}
+
+/* ssr corrections of satellite (global or network) --------------------------*/
+func (rtcm *Rtcm) cssr_ssr(atm *CssrAtm, sat int) *SSR {
+	if atm != nil {
+		return &atm.Ssr[sat-1]
+	}
+	if _, ok := rtcm.Cssr.Sssr[sat]; rtcm.Cssr.Sssr != nil && !ok {
+		rtcm.Cssr.Sssr[sat] = rtcm.Ssr[sat-1] /* save before update in subframe */
+	}
+	return &rtcm.Ssr[sat-1]
+}
+
+/* decode compact ssr mask message (subtype 1) -------------------------------*/
+func (rtcm *Rtcm) decode_cssr_mask(buff []byte, i, nb int) int {
+	var (
+		cssr                                           = &rtcm.Cssr
+		sats                                           [40]int
+		sigs                                           [CSSR_MAXSIG]uint8
+		tow                                            float64
+		j, k, m, id, iod, ngnss, nsat, nsig, cell, sys int
+	)
+	if i+20+4+1+4+4 > nb {
+		return -1
+	}
+	tow = float64(GetBitU(buff, i, 20))
+	i += 20 + 4 + 1 /* tow, update interval, multiple message indicator */
+	iod = int(GetBitU(buff, i, 4))
+	i += 4
+	ngnss = int(GetBitU(buff, i, 4))
+	i += 4
+
+	cssr.Nsat = 0
+	for j = 0; j < ngnss; j++ {
+		if i+4+40+16+1 > nb {
+			return -1
+		}
+		id = int(GetBitU(buff, i, 4))
+		i += 4
+		for k, nsat = 0, 0; k < 40; k++ {
+			if GetBitU(buff, i+k, 1) == 1 {
+				sats[nsat] = k
+				nsat++
+			}
+		}
+		i += 40
+		for k, nsig = 0, 0; k < CSSR_MAXSIG; k++ {
+			if GetBitU(buff, i+k, 1) == 1 {
+				sigs[nsig] = 0
+				if id < len(cssr_sigs) {
+					sigs[nsig] = cssr_sigs[id][k]
+				}
+				nsig++
+			}
+		}
+		i += CSSR_MAXSIG
+		cell = int(GetBitU(buff, i, 1))
+		i += 1
+
+		for k = 0; k < nsat; k++ {
+			if cssr.Nsat >= CSSR_MAXSAT {
+				Trace(2, "cssr mask satellite overflow: nsat=%d\n", cssr.Nsat)
+				return -1
+			}
+			n := cssr.Nsat
+			cssr.Sat[n], cssr.Gnss[n], cssr.Nsig[n] = 0, id, 0
+			if id < len(cssr_sys) {
+				sys = cssr_sys[id]
+				cssr.Sat[n] = SatNo(sys, sats[k]+cssr_prn0[id])
+			}
+			if cell > 0 && i+nsig > nb {
+				return -1
+			}
+			for m = 0; m < nsig; m++ {
+				if cell == 0 || GetBitU(buff, i+m, 1) == 1 {
+					cssr.Code[n][cssr.Nsig[n]] = sigs[m]
+					cssr.Nsig[n]++
+				}
+			}
+			if cell > 0 {
+				i += nsig
+			}
+			cssr.Nsat++
+		}
+	}
+	cssr.Tow0 = tow
+	cssr.Iod = iod
+	rtcm.AdjWeek(tow)
+
+	Trace(4, "decode_cssr_mask: tow=%.0f iod=%d ngnss=%d nsat=%d\n", tow, iod,
+		ngnss, cssr.Nsat)
+	return i
+}
+
+/* decode compact ssr message header (subtype 2-12) --------------------------*/
+func (rtcm *Rtcm) decode_cssr_head(buff []byte, i, nb int, udint *float64) int {
+	var tow, hour float64
+
+	if i+12+4+1+4 > nb {
+		return -1
+	}
+	hour = float64(GetBitU(buff, i, 12))
+	*udint = ssrudint[GetBitU(buff, i+12, 4)]
+	iod := int(GetBitU(buff, i+17, 4))
+	i += 21
+
+	if rtcm.Cssr.Tow0 == 0.0 || iod != rtcm.Cssr.Iod {
+		Trace(3, "cssr mask not available: iod=%d mask iod=%d\n", iod, rtcm.Cssr.Iod)
+		return -1
+	}
+	/* time of week by hourly epoch time */
+	tow = rtcm.Cssr.Tow0 - math.Mod(rtcm.Cssr.Tow0, 3600.0) + hour
+	if tow < rtcm.Cssr.Tow0-1800.0 {
+		tow += 3600.0
+	} else if tow > rtcm.Cssr.Tow0+1800.0 {
+		tow -= 3600.0
+	}
+	rtcm.AdjWeek(tow)
+	return i
+}
+
+/* set orbit correction ------------------------------------------------------*/
+func (rtcm *Rtcm) cssr_setorbit(buff []byte, i, k int, udint float64, atm *CssrAtm) int {
+	var (
+		deph  [3]float64
+		valid [3]bool
+		ni    = 8
+	)
+	if rtcm.Cssr.Gnss[k] < len(cssr_niode) {
+		ni = cssr_niode[rtcm.Cssr.Gnss[k]]
+	}
+	iode := int(GetBitU(buff, i, ni))
+	i += ni
+	deph[0] = cssr_getbits(buff, i, 15, &valid[0]) * 0.0016
+	i += 15
+	deph[1] = cssr_getbits(buff, i, 13, &valid[1]) * 0.0064
+	i += 13
+	deph[2] = cssr_getbits(buff, i, 13, &valid[2]) * 0.0064
+	i += 13
+
+	if sat := rtcm.Cssr.Sat[k]; sat > 0 && valid[0] && valid[1] && valid[2] {
+		ssr := rtcm.cssr_ssr(atm, sat)
+		ssr.T0[0] = rtcm.Time
+		ssr.Udi[0] = udint
+		ssr.Iod[0] = rtcm.Cssr.Iod
+		ssr.Iode = iode
+		ssr.Refd = 0
+		for j := 0; j < 3; j++ {
+			ssr.Deph[j] = deph[j]
+			ssr.Ddeph[j] = 0.0
+		}
+		ssr.Update = 1
+	}
+	return i
+}
+
+/* set clock correction ------------------------------------------------------*/
+func (rtcm *Rtcm) cssr_setclock(buff []byte, i, k int, udint float64, atm *CssrAtm) int {
+	var valid bool
+
+	dclk := cssr_getbits(buff, i, 15, &valid) * 0.0016
+	i += 15
+
+	if sat := rtcm.Cssr.Sat[k]; sat > 0 && valid {
+		ssr := rtcm.cssr_ssr(atm, sat)
+		ssr.T0[1] = rtcm.Time
+		ssr.Udi[1] = udint
+		ssr.Iod[1] = rtcm.Cssr.Iod
+		ssr.Dclk[0], ssr.Dclk[1], ssr.Dclk[2] = dclk, 0.0, 0.0
+		ssr.Update = 1
+	}
+	return i
+}
+
+/* set code and phase biases -------------------------------------------------*/
+func (rtcm *Rtcm) cssr_setbias(buff []byte, i, k int, udint float64, cb, pb bool,
+	atm *CssrAtm) int {
+	var (
+		valid      bool
+		bias       float64
+		code       uint8
+		sat        = rtcm.Cssr.Sat[k]
+		ssr        *SSR
+		m, j, cdis int
+	)
+	if sat > 0 {
+		ssr = rtcm.cssr_ssr(atm, sat)
+		if cb {
+			ssr.T0[4], ssr.Udi[4], ssr.Iod[4] = rtcm.Time, udint, rtcm.Cssr.Iod
+			for j = 0; j < MAXCODE; j++ {
+				ssr.Cbias[j] = 0.0
+			}
+		}
+		if pb {
+			ssr.T0[5], ssr.Udi[5], ssr.Iod[5] = rtcm.Time, udint, rtcm.Cssr.Iod
+			for j = 0; j < MAXCODE; j++ {
+				ssr.Pbias[j] = 0.0
+			}
+		}
+		ssr.Update = 1
+	}
+	for m = 0; m < rtcm.Cssr.Nsig[k]; m++ {
+		code = rtcm.Cssr.Code[k][m]
+		if cb {
+			bias = cssr_getbits(buff, i, 11, &valid) * 0.02
+			i += 11
+			if ssr != nil && code > 0 && valid {
+				ssr.Cbias[code-1] = float32(bias)
+			}
+		}
+		if pb {
+			bias = cssr_getbits(buff, i, 15, &valid) * 0.001
+			cdis = int(GetBitU(buff, i+15, 2)) /* phase discontinuity indicator */
+			i += 17
+			if ssr != nil && code > 0 && valid {
+				ssr.Pbias[code-1] = bias
+			}
+			Trace(5, "cssr pbias: sat=%3d code=%2d bias=%.3f disc=%d\n", sat, code, bias, cdis)
+		}
+	}
+	return i
+}
+
+/* number of bits of biases --------------------------------------------------*/
+func (cssr *Cssr) nbitbias(k int, cb, pb bool) int {
+	n := 0
+	if cb {
+		n += 11
+	}
+	if pb {
+		n += 17
+	}
+	return n * cssr.Nsig[k]
+}
+
+/* set stec polynomial coefficients ------------------------------------------*/
+func (rtcm *Rtcm) cssr_setstec(buff []byte, i, nb, k, ctype int, atm *CssrAtm) int {
+	var (
+		nbit  = [6]int{14, 12, 12, 10, 8, 8}
+		lsb   = [6]float64{0.05, 0.02, 0.02, 0.02, 0.005, 0.005}
+		ncoef = [4]int{1, 3, 4, 6}
+		c     [6]float64
+		valid bool
+	)
+	for j := 0; j < ncoef[ctype]; j++ {
+		if i+nbit[j] > nb {
+			return -1
+		}
+		c[j] = cssr_getbits(buff, i, nbit[j], &valid) * lsb[j]
+		i += nbit[j]
+		if !valid {
+			c[j] = 0.0
+		}
+	}
+	if sat := rtcm.Cssr.Sat[k]; sat > 0 {
+		atm.Ts[sat-1] = rtcm.Time
+		atm.Stec[sat-1] = c
+	}
+	return i
+}
+
+/* decode compact ssr orbit/clock/bias/ura message (subtype 2-7,11) ----------*/
+func (rtcm *Rtcm) decode_cssr_sat(buff []byte, i, nb, subtype int) int {
+	var (
+		mask                  [CSSR_MAXSAT]bool
+		udint                 float64
+		cssr                  = &rtcm.Cssr
+		atm                   *CssrAtm
+		k, n, ni              int
+		orb, clk, cb, pb, net bool
+	)
+	if i = rtcm.decode_cssr_head(buff, i, nb, &udint); i < 0 {
+		return -1
+	}
+	for k = 0; k < cssr.Nsat; k++ {
+		mask[k] = true
+	}
+	switch subtype {
+	case 2:
+		orb = true
+	case 3:
+		clk = true
+	case 4:
+		cb = true
+	case 5:
+		pb = true
+	case 6, 11:
+		if i+3 > nb {
+			return -1
+		}
+		f1, f2 := GetBitU(buff, i, 1) == 1, GetBitU(buff, i+1, 1) == 1
+		net = GetBitU(buff, i+2, 1) == 1
+		i += 3
+		if subtype == 6 {
+			cb, pb = f1, f2
+		} else {
+			orb, clk = f1, f2
+		}
+		if net {
+			if i+5 > nb {
+				return -1
+			}
+			atm = rtcm.cssr_atm(int(GetBitU(buff, i, 5))) /* compact network id */
+			i += 5
+			if i = cssr.netmask(buff, i, nb, mask[:]); i < 0 {
+				return -1
+			}
+			if atm.Ssr == nil {
+				atm.Ssr = make([]SSR, MAXSAT)
+			}
+			atm.Tn = rtcm.Time
+		}
+	}
+	for k = 0; k < cssr.Nsat; k++ {
+		if !mask[k] {
+			continue
+		}
+		n = 0
+		ni = 8
+		if cssr.Gnss[k] < len(cssr_niode) {
+			ni = cssr_niode[cssr.Gnss[k]]
+		}
+		if orb {
+			n += ni + 41
+		}
+		if clk {
+			n += 15
+		}
+		if subtype == 7 {
+			n += 6
+		}
+		n += cssr.nbitbias(k, cb, pb)
+		if i+n > nb {
+			return -1
+		}
+		if orb {
+			i = rtcm.cssr_setorbit(buff, i, k, udint, atm)
+		}
+		if clk {
+			i = rtcm.cssr_setclock(buff, i, k, udint, atm)
+		}
+		if cb || pb {
+			i = rtcm.cssr_setbias(buff, i, k, udint, cb, pb, atm)
+		}
+		if subtype == 7 {
+			if sat := cssr.Sat[k]; sat > 0 {
+				ssr := rtcm.cssr_ssr(nil, sat)
+				ssr.T0[3] = rtcm.Time
+				ssr.Udi[3] = udint
+				ssr.Iod[3] = cssr.Iod
+				ssr.Ura = int(GetBitU(buff, i, 6))
+				ssr.Update = 1
+			}
+			i += 6
+		}
+	}
+	return i
+}
+
+/* decode compact ssr stec correction message (subtype 8) --------------------*/
+func (rtcm *Rtcm) decode_cssr_stec(buff []byte, i, nb int) int {
+	var (
+		mask         [CSSR_MAXSAT]bool
+		udint        float64
+		atm          *CssrAtm
+		k, ctype, qi int
+	)
+	if i = rtcm.decode_cssr_head(buff, i, nb, &udint); i < 0 || i+7 > nb {
+		return -1
+	}
+	ctype = int(GetBitU(buff, i, 2))
+	atm = rtcm.cssr_atm(int(GetBitU(buff, i+2, 5)))
+	i += 7
+	if i = rtcm.Cssr.netmask(buff, i, nb, mask[:]); i < 0 {
+		return -1
+	}
+	for k = 0; k < rtcm.Cssr.Nsat; k++ {
+		if !mask[k] {
+			continue
+		}
+		if i+6 > nb {
+			return -1
+		}
+		qi = int(GetBitU(buff, i, 6))
+		i += 6
+		if i = rtcm.cssr_setstec(buff, i, nb, k, ctype, atm); i < 0 {
+			return -1
+		}
+		if sat := rtcm.Cssr.Sat[k]; sat > 0 {
+			atm.StecQ[sat-1] = cssr_quality(qi)
+		}
+	}
+	return i
+}
+
+/* decode compact ssr gridded correction message (subtype 9) -----------------*/
+func (rtcm *Rtcm) decode_cssr_grid(buff []byte, i, nb int) int {
+	var (
+		mask                [CSSR_MAXSAT]bool
+		udint, v            float64
+		atm                 *CssrAtm
+		valid               bool
+		g, k, ttype, nr, ns int
+		ngrid, sat          int
+	)
+	if i = rtcm.decode_cssr_head(buff, i, nb, &udint); i < 0 || i+8 > nb {
+		return -1
+	}
+	ttype = int(GetBitU(buff, i, 2))
+	nr = 7
+	if GetBitU(buff, i+2, 1) == 1 {
+		nr = 16 /* stec residual correction range */
+	}
+	atm = rtcm.cssr_atm(int(GetBitU(buff, i+3, 5)))
+	i += 8
+	if i = rtcm.Cssr.netmask(buff, i, nb, mask[:]); i < 0 || i+12 > nb {
+		return -1
+	}
+	i += 6 /* tropos quality indicator */
+	ngrid = int(GetBitU(buff, i, 6))
+	i += 6
+
+	for k, ns = 0, 0; k < rtcm.Cssr.Nsat; k++ {
+		if mask[k] {
+			ns++
+		}
+	}
+	if ttype != 0 {
+		atm.T0[0], atm.Udi[0], atm.Trpf = rtcm.Time, udint, 1
+	}
+	atm.T0[1], atm.Udi[1], atm.Ngrid = rtcm.Time, udint, ngrid
+
+	for g = 0; g < ngrid; g++ {
+		if ttype != 0 {
+			if i+17 > nb {
+				return -1
+			}
+			if v = cssr_getbits(buff, i, 9, &valid); valid {
+				atm.Trph[g] = 2.3 + v*0.004
+			} else {
+				atm.Trph[g] = CSSR_NOVAL
+			}
+			if v = cssr_getbits(buff, i+9, 8, &valid); valid {
+				atm.Trpw[g] = 0.252 + v*0.004
+			} else {
+				atm.Trpw[g] = CSSR_NOVAL
+			}
+			i += 17
+		}
+		if i+nr*ns > nb {
+			return -1
+		}
+		for k = 0; k < rtcm.Cssr.Nsat; k++ {
+			if !mask[k] {
+				continue
+			}
+			v = cssr_getbits(buff, i, nr, &valid) * 0.04
+			i += nr
+			if sat = rtcm.Cssr.Sat[k]; sat == 0 || g >= CSSR_MAXGRID {
+				continue
+			}
+			atm.Tr[sat-1] = rtcm.Time
+			atm.Stecr[sat-1][g] = float32(v)
+			if !valid {
+				atm.Stecr[sat-1][g] = CSSR_NOVAL
+			}
+		}
+	}
+	return i
+}
+
+/* decode compact ssr atmospheric correction message (subtype 12) ------------*/
+func (rtcm *Rtcm) decode_cssr_atmos(buff []byte, i, nb int) int {
+	var (
+		mask                             [CSSR_MAXSAT]bool
+		nbit                             = [4]int{4, 4, 5, 7}
+		lsb                              = [4]float64{0.04, 0.12, 0.16, 0.24}
+		udint, v, ofst                   float64
+		atm                              *CssrAtm
+		valid                            bool
+		g, k, tavail, savail, ngrid, sat int
+		ttype, nr, ctype, sz             int
+	)
+	if i = rtcm.decode_cssr_head(buff, i, nb, &udint); i < 0 || i+15 > nb {
+		return -1
+	}
+	tavail = int(GetBitU(buff, i, 2))
+	savail = int(GetBitU(buff, i+2, 2))
+	atm = rtcm.cssr_atm(int(GetBitU(buff, i+4, 5)))
+	ngrid = int(GetBitU(buff, i+9, 6))
+	i += 15
+
+	if tavail != 0 {
+		atm.T0[0], atm.Udi[0], atm.Trpf, atm.Ngrid = rtcm.Time, udint, 2, ngrid
+		atm.Trp = [4]float64{}
+		i += 6 /* tropos quality indicator */
+		if tavail&1 != 0 {
+			if i+11 > nb {
+				return -1
+			}
+			ttype = int(GetBitU(buff, i, 2))
+			atm.Trp[0] = float64(GetBits(buff, i+2, 9)) * 0.004
+			i += 11
+			if ttype > 0 {
+				atm.Trp[1] = float64(GetBits(buff, i, 7)) * 0.002
+				atm.Trp[2] = float64(GetBits(buff, i+7, 7)) * 0.002
+				i += 14
+			}
+			if ttype > 1 {
+				atm.Trp[3] = float64(GetBits(buff, i, 7)) * 0.001
+				i += 7
+			}
+		}
+		for g = 0; g < ngrid && g < CSSR_MAXGRID; g++ {
+			atm.Trph[g], atm.Trpw[g] = CSSR_NOVAL, 0.0
+		}
+		if tavail&2 != 0 {
+			if i+5 > nb {
+				return -1
+			}
+			nr = 6
+			if GetBitU(buff, i, 1) == 1 {
+				nr = 8
+			}
+			ofst = float64(GetBitU(buff, i+1, 4)) * 0.02
+			i += 5
+			if i+nr*ngrid > nb {
+				return -1
+			}
+			for g = 0; g < ngrid; g++ {
+				v = cssr_getbits(buff, i, nr, &valid) * 0.004
+				i += nr
+				if g < CSSR_MAXGRID {
+					atm.Trpw[g] = ofst + v
+					if !valid {
+						atm.Trpw[g] = CSSR_NOVAL
+					}
+				}
+			}
+		}
+	}
+	if savail == 0 {
+		return i
+	}
+	atm.T0[1], atm.Udi[1], atm.Ngrid = rtcm.Time, udint, ngrid
+
+	if i = rtcm.Cssr.netmask(buff, i, nb, mask[:]); i < 0 {
+		return -1
+	}
+	for k = 0; k < rtcm.Cssr.Nsat; k++ {
+		if !mask[k] {
+			continue
+		}
+		if i+6 > nb {
+			return -1
+		}
+		sat = rtcm.Cssr.Sat[k]
+		if sat > 0 {
+			atm.StecQ[sat-1] = cssr_quality(int(GetBitU(buff, i, 6)))
+		}
+		i += 6
+		if savail&1 != 0 {
+			if i+2 > nb {
+				return -1
+			}
+			ctype = int(GetBitU(buff, i, 2))
+			if i = rtcm.cssr_setstec(buff, i+2, nb, k, ctype, atm); i < 0 {
+				return -1
+			}
+		}
+		if savail&2 != 0 {
+			if i+2 > nb {
+				return -1
+			}
+			sz = int(GetBitU(buff, i, 2))
+			i += 2
+			if i+nbit[sz]*ngrid > nb {
+				return -1
+			}
+			for g = 0; g < ngrid; g++ {
+				v = cssr_getbits(buff, i, nbit[sz], &valid) * lsb[sz]
+				i += nbit[sz]
+				if sat == 0 || g >= CSSR_MAXGRID {
+					continue
+				}
+				atm.Tr[sat-1] = rtcm.Time
+				atm.Stecr[sat-1][g] = float32(v)
+				if !valid {
+					atm.Stecr[sat-1][g] = CSSR_NOVAL
+				}
+			}
+		}
+	}
+	return i
+}
+
+/* decode compact ssr message --------------------------------------------------
+* decode a compact ssr message in bit buffer
+* args   : rtcm_t *rtcm     IO  rtcm control struct
+*          uint8  *buff     I   bit buffer
+*          int    i         I   start bit position of message number
+*          int    nb        I   number of bits in buffer
+*          int    *ret      O   status (0:no message,10:input ssr messages)
+* return : end bit position of message (-1:error or unknown length)
+* notes  : decoded corrections are output to rtcm.ssr[] and rtcm.nav.catm[].
+*          network ssr corrections (subtype 6,11 with network id) are output to
+*          rtcm.nav.catm[].ssr[]
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) decode_cssr(buff []byte, i, nb int, ret *int) int {
+	if i+16 > nb || int(GetBitU(buff, i, 12)) != CSSR_MSGNO {
+		return -1
+	}
+	subtype := int(GetBitU(buff, i+12, 4))
+	i += 16
+
+	if rtcm.OutType > 0 {
+		rtcm.MsgType += fmt.Sprintf(" subtype=%d", subtype)
+	}
+	Trace(4, "decode_cssr: subtype=%d\n", subtype)
+
+	switch subtype {
+	case 1:
+		i = rtcm.decode_cssr_mask(buff, i, nb)
+	case 2, 3, 4, 5, 6, 7, 11:
+		i = rtcm.decode_cssr_sat(buff, i, nb, subtype)
+	case 8:
+		i = rtcm.decode_cssr_stec(buff, i, nb)
+	case 9:
+		i = rtcm.decode_cssr_grid(buff, i, nb)
+	case 10: /* service information */
+		if i+6 > nb {
+			return -1
+		}
+		i += 6 + int(GetBitU(buff, i+4, 2)+1)*40
+	case 12:
+		i = rtcm.decode_cssr_atmos(buff, i, nb)
+	default:
+		Trace(2, "cssr unsupported message subtype=%d\n", subtype)
+		return -1
+	}
+	if i < 0 || i > nb {
+		Trace(2, "cssr message decode error: subtype=%d nb=%d\n", subtype, nb)
+		return -1
+	}
+	if subtype != 1 && subtype != 10 {
+		*ret = 10
+	}
+	return i
+}
+
+/* null bits until end of buffer -------------------------------------------*/
+func cssr_null(buff []byte, i, nb int) bool {
+	for n := 0; i < nb; i += n {
+		if n = nb - i; n > 32 {
+			n = 32
+		}
+		if GetBitU(buff, i, n) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/* restore corrections saved in subframe -------------------------------------*/
+func (rtcm *Rtcm) cssr_restore() {
+	for net, atm := range rtcm.Cssr.Satm {
+		rtcm.NavData.Catm[net] = atm
+	}
+	for sat, ssr := range rtcm.Cssr.Sssr {
+		rtcm.Ssr[sat-1] = ssr
+	}
+}
+
+/* decode L6 frame -----------------------------------------------------------*/
+func (rtcm *Rtcm) decode_l6frame() int {
+	var (
+		cssr                       = &rtcm.Cssr
+		prn, vendor, sf, i, j, ret int
+	)
+	prn = int(GetBitU(rtcm.Buff[:], 32, 8))
+	vendor = int(GetBitU(rtcm.Buff[:], 40, 3))
+	sf = int(GetBitU(rtcm.Buff[:], 47, 1)) /* subframe indicator */
+
+	Trace(4, "decode_l6frame: prn=%d vendor=%d sf=%d\n", prn, vendor, sf)
+
+	if vendor != L6_VENDOR_CLS {
+		Trace(3, "L6 frame vendor not supported: prn=%d vendor=%d\n", prn, vendor)
+		return 0
+	}
+	if sf == 1 {
+		cssr.Prn, cssr.Nfrm, cssr.Nbit = prn, 0, 0
+	} else if cssr.Nfrm < 0 || prn != cssr.Prn {
+		return 0
+	}
+	/* append data part of frame to subframe */
+	for i = 0; i < L6_DATALEN; i += j {
+		if j = L6_DATALEN - i; j > 32 {
+			j = 32
+		}
+		SetBitU(cssr.Buff[:], cssr.Nbit+i, j, GetBitU(rtcm.Buff[:], 49+i, j))
+	}
+	cssr.Nbit += L6_DATALEN
+	if cssr.Nfrm++; cssr.Nfrm < L6_NFRM {
+		return 0
+	}
+	cssr.Nfrm = -1
+
+	if rtcm.OutType > 0 {
+		rtcm.MsgType = fmt.Sprintf("QZSS L6 PRN=%3d", prn)
+	}
+	/* decode compact ssr messages in subframe until null bits */
+	mask, time := *cssr, rtcm.Time
+	cssr.Satm, cssr.Sssr = make(map[int]CssrAtm), make(map[int]SSR)
+	for i = 0; i+16 <= cssr.Nbit && !cssr_null(cssr.Buff[:], i, cssr.Nbit); {
+		if i = rtcm.decode_cssr(cssr.Buff[:], i, cssr.Nbit, &ret); i < 0 {
+			break
+		}
+	}
+	/* reject all messages in subframe by decode error */
+	if i < 0 {
+		Trace(2, "L6 subframe rejected by decode error: prn=%d\n", prn)
+		rtcm.cssr_restore()
+		cssr.Tow0, cssr.Iod, cssr.Nsat = mask.Tow0, mask.Iod, mask.Nsat
+		cssr.Sat, cssr.Gnss, cssr.Nsig, cssr.Code = mask.Sat, mask.Gnss, mask.Nsig, mask.Code
+		rtcm.Time = time
+		ret = 0
+	}
+	cssr.Satm, cssr.Sssr = nil, nil
+	return ret
+}
+
+/* input QZSS L6 frame ---------------------------------------------------------
+* fetch next QZSS L6 frame and input a compact ssr subframe from byte stream
+* args   : rtcm_t *rtcm     IO  rtcm control struct
+*          uint8  data      I   L6 frame data (1 byte)
+* return : status (0:no message,10:input ssr messages)
+* notes  : L6 frame (250 bytes) = preamble (4 bytes) + prn + message type id +
+*          alert flag + data part (1695 bits) + reed-solomon code (256 bits)
+*          only clas (vendor id=5) frames are decoded. reed-solomon code is not
+*          checked. the compact ssr messages are decoded after 5 frames of the
+*          subframe (5 s) received. all corrections of the subframe are
+*          rejected if a message is not decoded before null bits of the end.
+*          messages across subframes are not supported
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) InputL6(data uint8) int {
+	Trace(5, "input_l6: data=%02x\n", data)
+
+	/* synchronize frame */
+	if rtcm.Nbyte < 4 {
+		rtcm.Buff[rtcm.Nbyte] = data
+		rtcm.Nbyte++
+		if rtcm.Nbyte == 4 && GetBitU(rtcm.Buff[:], 0, 32) != L6_PREAMB {
+			copy(rtcm.Buff[:3], rtcm.Buff[1:4])
+			rtcm.Nbyte = 3
+		}
+		return 0
+	}
+	rtcm.Buff[rtcm.Nbyte] = data
+	rtcm.Nbyte++
+
+	if rtcm.Nbyte < L6_FRMLEN {
+		return 0
+	}
+	rtcm.Nbyte = 0
+	rtcm.MsgLen = L6_FRMLEN
+
+	return rtcm.decode_l6frame()
+}
+
+/* input QZSS L6 frame from file -----------------------------------------------
+* fetch next QZSS L6 frame and input a compact ssr subframe from file
+* args   : rtcm_t *rtcm     IO  rtcm control struct
+*          FILE  *fp        I   file pointer
+* return : status (-2: end of file, 0,10: same as above)
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) InputL6f(fp *os.File) int {
+	var (
+		c      [1]byte
+		i, ret int
+	)
+	Trace(4, "input_l6f:\n")
+
+	for i = 0; i < 4096; i++ {
+		if _, err := fp.Read(c[:]); err == io.EOF {
+			return -2
+		}
+		if ret = rtcm.InputL6(c[0]); ret > 0 {
+			return ret
+		}
+	}
+	return 0 /* return at every 4k bytes */
+}
+
+/* read clas grid definition file ----------------------------------------------
+* read clas grid definition file (clas_grid.def)
+* args   : char   *file     I   grid definition file
+*          nav_t  *nav      IO  navigation data
+*            nav.grid       O   grid points
+* return : number of grid points (0:error)
+* notes  : the record of grid point is:
+*          network_id grid_no latitude(deg) longitude(deg) height(m)
+*          the lines not starting with the numbers are skipped
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) ReadCssrGrid(file string) int {
+	var (
+		grid CssrGrid
+		lat  float64
+		lon  float64
+	)
+	Trace(3, "readcssrgrid: file=%s\n", file)
+
+	fp, err := os.OpenFile(file, os.O_RDONLY, 0666)
+	if err != nil {
+		Trace(2, "clas grid file open error: %s\n", file)
+		return 0
+	}
+	defer fp.Close()
+
+	nav.Grid = nil
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		buff := strings.TrimSpace(sc.Text())
+		if n, _ := fmt.Sscanf(buff, "%d %d %f %f %f", &grid.Net, &grid.No, &lat, &lon,
+			&grid.Pos[2]); n < 5 {
+			continue
+		}
+		if grid.Net < 0 || grid.Net >= CSSR_MAXNET || grid.No < 1 || grid.No > CSSR_MAXGRID {
+			continue
+		}
+		grid.Pos[0], grid.Pos[1] = lat*D2R, lon*D2R
+		nav.Grid = append(nav.Grid, grid)
+	}
+	Trace(3, "readcssrgrid: ngrid=%d\n", len(nav.Grid))
+	return len(nav.Grid)
+}
+
+/* select grids for interpolation --------------------------------------------*/
+func (nav *Nav) cssr_selgrid(pos []float64, valid func(net int) bool, net *int,
+	idx []int, w []float64) int {
+	var (
+		d                 [4]float64
+		dn, de, dist, sum float64
+		dmin              = CSSR_MAXDIST
+		i, j, k, n        int
+	)
+	*net = -1
+	dfn := func(g *CssrGrid) float64 {
+		dn = (g.Pos[0] - pos[0]) * RE_WGS84
+		de = (g.Pos[1] - pos[1]) * RE_WGS84 * math.Cos(pos[0])
+		return math.Sqrt(dn*dn + de*de)
+	}
+	/* network of nearest grid with corrections */
+	for i = range nav.Grid {
+		if dist = dfn(&nav.Grid[i]); dist < dmin && valid(nav.Grid[i].Net) {
+			dmin, *net = dist, nav.Grid[i].Net
+		}
+	}
+	if *net < 0 {
+		return 0
+	}
+	/* nearest 4 grids in network */
+	for i = range nav.Grid {
+		if nav.Grid[i].Net != *net {
+			continue
+		}
+		if dist = dfn(&nav.Grid[i]); dist > CSSR_MAXDIST {
+			continue
+		}
+		for j = 0; j < n && d[j] <= dist; j++ {
+		}
+		if j >= 4 {
+			continue
+		}
+		if n < 4 {
+			n++
+		}
+		for k = n - 1; k > j; k-- {
+			d[k], idx[k] = d[k-1], idx[k-1]
+		}
+		d[j], idx[j] = dist, i
+	}
+	/* inverse distance weights */
+	if d[0] < 1.0 {
+		w[0] = 1.0
+		return 1
+	}
+	for i, sum = 0, 0.0; i < n; i++ {
+		w[i] = 1.0 / d[i]
+		sum += w[i]
+	}
+	for i = 0; i < n; i++ {
+		w[i] /= sum
+	}
+	return n
+}
+
+/* interpolate grid values ---------------------------------------------------*/
+func cssr_interp(idx []int, w []float64, n int, val func(i int) float64, v *float64) int {
+	var sum, sumw float64
+
+	for i := 0; i < n; i++ {
+		if x := val(idx[i]); x != CSSR_NOVAL {
+			sum += w[i] * x
+			sumw += w[i]
+		}
+	}
+	if sumw <= 0.0 {
+		return 0
+	}
+	*v = sum / sumw
+	return 1
+}
+
+/* reference point of network polynomial (grid no.1) -------------------------*/
+func (nav *Nav) cssr_refpos(net int) []float64 {
+	for i := range nav.Grid {
+		if nav.Grid[i].Net == net && nav.Grid[i].No == 1 {
+			return nav.Grid[i].Pos[:]
+		}
+	}
+	return nil
+}
+
+/* clas stec ionospheric correction --------------------------------------------
+* compute ionospheric correction by clas stec polynomial and grid residuals
+* args   : gtime_t time     I   time (GPST)
+*          nav_t  *nav      I   navigation data
+*          int    sat       I   satellite number
+*          double *pos      I   receiver position {lat,lon,h} (rad|m)
+*          double *ion      O   slant ionospheric delay (L1) (m)
+*          double *var      O   slant ionospheric delay (L1) variance (m^2)
+* return : status (1:ok,0:error)
+* notes  : stec = c00+c01*dlat+c10*dlon+c11*dlat*dlon+c02*dlat^2+c20*dlon^2
+*          + residual interpolated by grids (dlat,dlon: deg from grid no.1)
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) CssrIonCorr(time Gtime, sat int, pos []float64, ion, vari *float64) int {
+	var (
+		idx          [4]int
+		w            [4]float64
+		stec, res, q float64
+		dlat, dlon   float64
+		net, n       int
+		ref          []float64
+		atm          *CssrAtm
+		fact         = CSSR_TECU2M / SQR(FREQ1)
+	)
+	Trace(4, "cssrioncorr: time=%s sat=%2d\n", TimeStr(time, 0), sat)
+
+	n = nav.cssr_selgrid(pos, func(net int) bool {
+		return net < len(nav.Catm) && nav.Catm[net].Ts[sat-1].Time != 0 &&
+			math.Abs(TimeDiff(time, nav.Catm[net].Ts[sat-1])) <= CSSR_MAXAGE
+	}, &net, idx[:], w[:])
+	if n <= 0 {
+		Trace(3, "cssrioncorr: no stec correction sat=%2d\n", sat)
+		return 0
+	}
+	atm = &nav.Catm[net]
+	if ref = nav.cssr_refpos(net); ref == nil {
+		ref = nav.Grid[idx[0]].Pos[:]
+	}
+	dlat, dlon = (pos[0]-ref[0])*R2D, (pos[1]-ref[1])*R2D
+	c := atm.Stec[sat-1]
+	stec = c[0] + c[1]*dlat + c[2]*dlon + c[3]*dlat*dlon + c[4]*dlat*dlat + c[5]*dlon*dlon
+
+	/* grid residual */
+	if atm.Tr[sat-1].Time != 0 && math.Abs(TimeDiff(time, atm.Tr[sat-1])) <= CSSR_MAXAGE &&
+		cssr_interp(idx[:], w[:], n, func(i int) float64 {
+			if no := nav.Grid[i].No - 1; no < atm.Ngrid {
+				return float64(atm.Stecr[sat-1][no])
+			}
+			return CSSR_NOVAL
+		}, &res) > 0 {
+		stec += res
+	}
+	if q = atm.StecQ[sat-1]; q <= 0.0 {
+		q = CSSR_ERR_STEC
+	}
+	*ion = fact * stec
+	*vari = SQR(fact * q)
+
+	Trace(4, "cssrioncorr: sat=%2d net=%2d stec=%.3f res=%.3f ion=%.3f\n", sat, net,
+		stec, res, *ion)
+	return 1
+}
+
+/* clas tropospheric correction ------------------------------------------------
+* compute tropospheric correction by clas grid corrections
+* args   : gtime_t time     I   time (GPST)
+*          nav_t  *nav      I   navigation data
+*          double *pos      I   receiver position {lat,lon,h} (rad|m)
+*          double *azel     I   azimuth/elevation angle {az,el} (rad)
+*          double *trp      O   slant tropospheric delay (m)
+*          double *var      O   slant tropospheric delay variance (m^2)
+* return : status (1:ok,0:error)
+* notes  : the differences of grid zenith delays to saastamoinen model are
+*          interpolated to the receiver position. the hydrostatic delay by the
+*          model is used for the polynomial corrections (subtype 12)
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) CssrTropCorr(time Gtime, pos, azel []float64, trp, vari *float64) int {
+	var (
+		idx                [4]int
+		w                  [4]float64
+		zazel              = []float64{0.0, PI / 2.0}
+		zhd, zwd, dh, dw   float64
+		mapfh, mapfw, dlat float64
+		dlon               float64
+		net, n             int
+		ref                []float64
+		atm                *CssrAtm
+	)
+	Trace(4, "cssrtropcorr: time=%s\n", TimeStr(time, 0))
+
+	n = nav.cssr_selgrid(pos, func(net int) bool {
+		return net < len(nav.Catm) && nav.Catm[net].Trpf > 0 &&
+			math.Abs(TimeDiff(time, nav.Catm[net].T0[0])) <= CSSR_MAXAGE
+	}, &net, idx[:], w[:])
+	if n <= 0 {
+		Trace(3, "cssrtropcorr: no tropos correction\n")
+		return 0
+	}
+	atm = &nav.Catm[net]
+
+	/* model zenith hydrostatic and wet delays */
+	zmodel := func(p []float64, h, wet *float64) {
+		*h = TropModel(time, p, zazel, 0.0)
+		*wet = TropModel(time, p, zazel, REL_HUMI) - *h
+	}
+	zmodel(pos, &zhd, &zwd)
+	gval := func(v []float64, hyd bool) func(i int) float64 {
+		return func(i int) float64 {
+			var h, wet float64
+			no := nav.Grid[i].No - 1
+			if no >= atm.Ngrid || v[no] == CSSR_NOVAL {
+				return CSSR_NOVAL
+			}
+			if atm.Trpf == 2 {
+				return v[no]
+			}
+			zmodel(nav.Grid[i].Pos[:], &h, &wet)
+			if hyd {
+				return v[no] - h
+			}
+			return v[no] - wet
+		}
+	}
+	if atm.Trpf == 1 {
+		if cssr_interp(idx[:], w[:], n, gval(atm.Trph[:], true), &dh) == 0 ||
+			cssr_interp(idx[:], w[:], n, gval(atm.Trpw[:], false), &dw) == 0 {
+			return 0
+		}
+		zhd += dh
+		zwd += dw
+	} else {
+		if ref = nav.cssr_refpos(net); ref == nil {
+			ref = nav.Grid[idx[0]].Pos[:]
+		}
+		dlat, dlon = (pos[0]-ref[0])*R2D, (pos[1]-ref[1])*R2D
+		zwd = atm.Trp[0] + atm.Trp[1]*dlat + atm.Trp[2]*dlon + atm.Trp[3]*dlat*dlon
+		if cssr_interp(idx[:], w[:], n, gval(atm.Trpw[:], false), &dw) > 0 {
+			zwd += dw
+		}
+	}
+	mapfh = TropMapFunc(time, pos, azel, &mapfw)
+	*trp = mapfh*zhd + mapfw*zwd
+	*vari = SQR(CSSR_ERR_TROP * mapfw)
+
+	Trace(4, "cssrtropcorr: net=%2d zhd=%.3f zwd=%.3f trp=%.3f\n", net, zhd, zwd, *trp)
+	return 1
+}
+
+/* update clas atmospheric corrections -----------------------------------------
+* update clas atmospheric corrections of networks by decoded corrections
+* args   : nav_t  *nav      IO  navigation data
+*          nav_t  *src      IO  navigation data with decoded corrections
+* return : none
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) UpdateCssrAtm(src *Nav) {
+	for i := range src.Catm {
+		if src.Catm[i].Update == 0 {
+			continue
+		}
+		if len(nav.Catm) < CSSR_MAXNET {
+			nav.Catm = make([]CssrAtm, CSSR_MAXNET)
+		}
+		src.Catm[i].Update = 0
+		nav.Catm[i] = src.Catm[i].copy()
+	}
+}
+
+/* select clas network ssr corrections -----------------------------------------
+* select clas network by receiver position and set network ssr corrections
+* args   : gtime_t time     I   time (GPST)
+*          nav_t  *nav      IO  navigation data
+*            nav.ssr[]      IO  ssr corrections
+*          double *rr       I   receiver position (ecef) (m)
+* return : compact network id (-1:no network)
+* notes  : the network of the nearest grid with the network corrections is
+*          selected same as the atmospheric corrections. the orbit, clock and
+*          bias corrections of nav.ssr[] are replaced by the network ones within
+*          CSSR_MAXAGE. the others are kept until the next global corrections
+*-----------------------------------------------------------------------------*/
+func (nav *Nav) CssrSelSsr(time Gtime, rr []float64) int {
+	var (
+		idx       [4]int
+		w         [4]float64
+		pos       [3]float64
+		net, i, j int
+	)
+	if Norm(rr, 3) <= 0.0 {
+		return -1
+	}
+	Ecef2Pos(rr, pos[:])
+
+	fresh := func(t Gtime) bool {
+		return t.Time != 0 && math.Abs(TimeDiff(time, t)) <= CSSR_MAXAGE
+	}
+	if nav.cssr_selgrid(pos[:], func(net int) bool {
+		return net < len(nav.Catm) && nav.Catm[net].Ssr != nil && fresh(nav.Catm[net].Tn)
+	}, &net, idx[:], w[:]) <= 0 {
+		return -1
+	}
+	for i = 0; i < MAXSAT; i++ {
+		src, dst := &nav.Catm[net].Ssr[i], &nav.Ssr[i]
+		if fresh(src.T0[0]) { /* orbit */
+			dst.T0[0], dst.Udi[0], dst.Iod[0] = src.T0[0], src.Udi[0], src.Iod[0]
+			dst.Iode, dst.Refd, dst.Deph, dst.Ddeph = src.Iode, src.Refd, src.Deph, src.Ddeph
+		}
+		if fresh(src.T0[1]) { /* clock */
+			dst.T0[1], dst.Udi[1], dst.Iod[1], dst.Dclk = src.T0[1], src.Udi[1], src.Iod[1], src.Dclk
+		}
+		for j = 4; j < 6; j++ { /* code and phase biases */
+			if !fresh(src.T0[j]) {
+				continue
+			}
+			dst.T0[j], dst.Udi[j], dst.Iod[j] = src.T0[j], src.Udi[j], src.Iod[j]
+			if j == 4 {
+				dst.Cbias = src.Cbias
+			} else {
+				dst.Pbias = src.Pbias
+			}
+		}
+	}
+	Trace(4, "cssrselssr: time=%s net=%2d\n", TimeStr(time, 0), net)
+	return net
+}
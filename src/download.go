@@ -3,6 +3,11 @@
 *
 *          Copyright (C) 2012-2020 by T.TAKASU, All rights reserved.
 *
+* references :
+*     [1] RFC 959, File Transfer Protocol (FTP), 1985
+*     [2] RFC 2428, FTP Extensions for IPv6 and NATs, 1998
+*     [3] RFC 4217, Securing FTP with TLS, 2005
+*
 * version : $Revision:$ $Date:$
 * history : 2012/12/28  1.0  new
 *           2013/06/02  1.1  replace S_IREAD by S_IRUSR
@@ -14,26 +19,43 @@
 *                            limit max number of download paths
 *                            use integer types in stdint.h
 *		    2022/05/31  1.0  rewrite download.c with golang by fxb
+*           2026/10/16  1.1  replace wget by native HTTP/FTP/FTPS client
+*                            keep remote file listings in memory
+*                            parallel downloads by worker pool
+*                            add DL_ExecN()
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
+	"net/url"
 	"os"
-	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-const FTP_CMD string = "wget"         /* FTP/HTTP command */
 const FTP_TIMEOUT int = 60            /* FTP/HTTP timeout (s) */
-const FTP_LISTING string = ".listing" /* FTP listing file */
-const FTP_NOFILE int = 2048           /* FTP error no file */
-const HTTP_NOFILE int = 1             /* HTTP error no file */
-const FTP_RETRY int = 3               /* FTP number of retry */
+const FTP_LISTING string = ".listing" /* FTP listing file (DLOPT_HOLDLST) */
+const FTP_RETRY int = 3               /* FTP/HTTP number of retry */
+const FTP_PORT string = "21"          /* FTP default port */
 const MAX_PATHS int = 131072          /* max number of download paths */
+const DL_NTHREAD int = 4              /* default number of download threads */
+const DL_MAXTHREAD int = 32           /* max number of download threads */
+
+var errDlNoFile = errors.New("no file") /* remote file not found */
 
 /* type definitions ----------------------------------------------------------*/
 
@@ -49,58 +71,107 @@ type Paths struct { /* download paths type */
 	n, nmax int    /* number and max number of paths */
 }
 
-/* execute command with test timeout -----------------------------------------*/
-func execcmd_to(cmd string) int {
-	return ExecCmd(cmd)
+type dllist struct { /* remote file list type */
+	once  sync.Once
+	files []string /* file names (nil: no list) */
+}
+
+type dlsess struct { /* download session type */
+	usr, pwd, proxy string             /* login user/password and proxy address */
+	opts            int                /* download options */
+	fp              *os.File           /* log file pointer (nil: no output log) */
+	n               [4]int             /* counts (ok,no file,skip,error) */
+	client          *http.Client       /* HTTP client */
+	lists           map[string]*dllist /* remote file lists by directory */
+	lock            sync.Mutex
+}
+
+type ftpcli struct { /* FTP client type */
+	conn  *textproto.Conn /* control connection */
+	host  string          /* host address (host:port) */
+	tlsc  *tls.Config     /* TLS config (nil: no TLS) */
+	nepsv int             /* EPSV not supported flag */
+}
+
+type dlconn struct { /* network connection with idle timeout type */
+	net.Conn
+	to time.Duration /* idle timeout */
+}
+
+/* read/write with idle timeout ----------------------------------------------*/
+func (c *dlconn) Read(b []byte) (int, error) {
+	c.SetReadDeadline(time.Now().Add(c.to))
+	return c.Conn.Read(b)
+}
+func (c *dlconn) Write(b []byte) (int, error) {
+	c.SetWriteDeadline(time.Now().Add(c.to))
+	return c.Conn.Write(b)
+}
+
+/* connect to host with timeout ----------------------------------------------*/
+func dl_dial(addr string) (net.Conn, error) {
+	to := time.Duration(FTP_TIMEOUT) * time.Second
+	conn, err := net.DialTimeout("tcp", addr, to)
+	if err != nil {
+		return nil, err
+	}
+	return &dlconn{Conn: conn, to: to}, nil
 }
 
 /* generate path by replacing keywords ---------------------------------------*/
 func GenPath(file, name string, time Gtime, seqno int, path *string) {
 	var (
 		buff           string
-		env            string
 		l_name, u_name string
-		i              int
+		i, j           int
 	)
 	l_name = strings.ToLower(name)
 	u_name = strings.ToUpper(name)
-	ss := strings.Split(file, "%")
-	for _, s := range ss {
-		for i = 0; i < len(s); i++ {
-
-			switch s[i] {
-			case 's', 'r':
-				buff += l_name
-			case 'S', 'R':
-				buff += u_name
-			case 'N':
-				buff += fmt.Sprintf("%d", seqno)
-			case '{':
-				if idx := strings.Index(s[1:], "}"); idx > 0 {
-					v := s[1:idx]
-					if env = os.Getenv(v); len(env) > 0 {
-						buff += env
-					}
-					i = idx
-				}
+
+	for i = 0; i < len(file); i++ {
+		if file[i] != '%' || i+1 >= len(file) {
+			buff += file[i : i+1]
+			continue
+		}
+		switch file[i+1] {
+		case 's', 'r':
+			buff += l_name
+			i++
+		case 'S', 'R':
+			buff += u_name
+			i++
+		case 'N':
+			buff += fmt.Sprintf("%d", seqno)
+			i++
+		case '{':
+			if j = strings.Index(file[i+2:], "}"); j >= 0 {
+				buff += os.Getenv(file[i+2 : i+2+j])
+				i += j + 2
+			} else {
+				buff += "%"
 			}
+		default: /* keywords replaced by RepPath() */
+			buff += "%"
 		}
 	}
-
 	RepPath(buff, path, time, "", "")
 }
 
 /* compare str1 and str2 with wildcards (*) ----------------------------------*/
 func cmp_str(str1, str2 string) int {
-	var idx int = 0
-	ss := strings.Split(str2, "*")
-	for _, s := range ss {
-		idx = strings.Index(str1[idx:], s)
-		if idx < 0 {
-			break
+	var s1, s2 string = "^" + str1 + "$", "^" + str2 + "$"
+	var p, idx int
+
+	for _, q := range strings.Split(s2, "*") {
+		if len(q) == 0 {
+			continue
+		}
+		if idx = strings.Index(s1[p:], q); idx < 0 {
+			return 0
 		}
+		p += idx + len(q)
 	}
-	return idx
+	return 1
 }
 
 /* remote to local file path -------------------------------------------------*/
@@ -108,9 +179,9 @@ func Remot2Local(remot, dir string, local *string) {
 	var p string
 	var idx int
 	if idx = strings.LastIndex(remot, "="); idx >= 0 {
-		p = remot[idx:]
+		p = remot[idx+1:]
 	} else if idx = strings.LastIndex(remot, "/"); idx >= 0 {
-		p = remot[idx:]
+		p = remot[idx+1:]
 	} else {
 		p = remot
 	}
@@ -120,11 +191,10 @@ func Remot2Local(remot, dir string, local *string) {
 
 /* test file existence -------------------------------------------------------*/
 func exist_file(local string) int {
-	_, err := os.Lstat(local)
-	if os.IsExist(err) {
-		return 1
+	if _, err := os.Stat(local); err != nil {
+		return 0
 	}
-	return 0
+	return 1
 }
 
 /* test local file existence -------------------------------------------------*/
@@ -167,18 +237,18 @@ func test_file(local string) int {
 	}
 
 	idx = strings.LastIndex(buff, ".")
-	if idx >= 0 {
-		if strings.EqualFold(buff[idx:], ".crx") {
-			buff = buff[:idx] + ".cro"
+	if idx >= 0 && strings.EqualFold(buff[idx:], ".crx") {
+		if buff[idx:] == ".crx" {
+			buff = buff[:idx] + ".rnx"
 		} else {
-			buff = buff[:idx] + ".CRO"
+			buff = buff[:idx] + ".RNX"
 		}
 		if exist_file(buff) > 0 {
 			return 1
 		}
 		comp = 1
 	}
-	if exist_file(buff) == 0 {
+	if exist_file(local) == 0 {
 		return 0
 	}
 	if comp > 0 {
@@ -302,280 +372,563 @@ func compact_paths(paths *Paths) {
 	}
 }
 
-/* get remote file list for FTP or FTPS --------------------------------------*/
-func get_list(path *Path, usr, pwd, proxy string) int {
+/* protocol of remote path (0:ftp,1:http/https,2:ftps,-1:invalid) -----------*/
+func dl_proto(remot string) int {
+	switch {
+	case strings.HasPrefix(remot, "ftp://"):
+		return 0
+	case strings.HasPrefix(remot, "ftps://"):
+		return 2
+	case strings.HasPrefix(remot, "http://"), strings.HasPrefix(remot, "https://"):
+		return 1
+	}
+	return -1
+}
+
+/* open FTP or FTPS connection and login (ref [1],[3]) -----------------------*/
+func ftp_open(u *url.URL, usr, pwd string) (*ftpcli, error) {
 	var (
-		fp                         *os.File
-		cmd, env, remot, opt, opt2 string //,*opt="",*opt2="",*p;
-		idx                        int
+		c    *ftpcli = new(ftpcli)
+		conn net.Conn
+		code int
+		err  error
 	)
-
-	// #ifndef WIN32
-	//     opt2=" -o /dev/null";
-	// #endif
-	if runtime.GOOS == "windows" {
-		opt2 = " -o /dev/null"
+	if c.host = u.Host; len(u.Port()) == 0 {
+		c.host = net.JoinHostPort(u.Hostname(), FTP_PORT)
+	}
+	if u.User != nil {
+		usr = u.User.Username()
+		pwd, _ = u.User.Password()
+	}
+	if len(usr) == 0 {
+		usr, pwd = "anonymous", "anonymous@"
+	}
+	if conn, err = dl_dial(c.host); err != nil {
+		return nil, err
+	}
+	c.conn = textproto.NewConn(conn)
+	if _, _, err = c.conn.ReadResponse(2); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	if u.Scheme == "ftps" { /* explicit FTPS (ref [3]) */
+		c.tlsc = &tls.Config{ServerName: u.Hostname(),
+			ClientSessionCache: tls.NewLRUClientSessionCache(0)}
+		if _, _, err = c.cmd(2, "AUTH TLS"); err != nil {
+			c.conn.Close()
+			return nil, err
+		}
+		c.conn = textproto.NewConn(tls.Client(conn, c.tlsc))
+	}
+	if code, _, err = c.cmd(0, "USER %s", usr); err == nil {
+		if code == 331 {
+			_, _, err = c.cmd(2, "PASS %s", pwd)
+		} else if code/100 != 2 {
+			err = fmt.Errorf("login error (%d)", code)
+		}
+	}
+	if err == nil && c.tlsc != nil {
+		if _, _, err = c.cmd(2, "PBSZ 0"); err == nil {
+			_, _, err = c.cmd(2, "PROT P")
+		}
 	}
-	os.Remove(FTP_LISTING)
+	if err == nil {
+		_, _, err = c.cmd(2, "TYPE I")
+	}
+	if err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
 
-	remot = path.remot
-	if idx = strings.LastIndex(remot, "/"); idx > 0 {
-		remot = remot[:1] + string("__REQUEST_LIST__")
-	} else {
-		return 0
+/* close FTP connection ------------------------------------------------------*/
+func (c *ftpcli) close() {
+	c.conn.PrintfLine("QUIT")
+	c.conn.Close()
+}
+
+/* send FTP command and read response ----------------------------------------*/
+func (c *ftpcli) cmd(expect int, format string, args ...interface{}) (int, string, error) {
+	if err := c.conn.PrintfLine(format, args...); err != nil {
+		return 0, "", err
 	}
+	return c.conn.ReadResponse(expect)
+}
 
-	if len(proxy) > 0 {
-		env = fmt.Sprintf("set ftp_proxy=http://%s & ", proxy)
-		opt = "--proxy=on "
+/* open FTP data connection in passive mode (ref [1],[2]) --------------------*/
+func (c *ftpcli) pasv() (net.Conn, error) {
+	var (
+		host, addr, msg string
+		h               [4]int
+		p1, p2, i, j    int
+		conn            net.Conn
+		err             error
+	)
+	host, _, _ = net.SplitHostPort(c.host)
+
+	if c.nepsv == 0 {
+		if _, msg, err = c.cmd(229, "EPSV"); err != nil {
+			c.nepsv = 1
+		} else if i = strings.Index(msg, "(|||"); i >= 0 {
+			if j = strings.Index(msg[i+4:], "|"); j > 0 {
+				addr = net.JoinHostPort(host, msg[i+4:i+4+j])
+			}
+		}
 	}
-	cmd = fmt.Sprintf("%s%s %s --ftp-user=%s --ftp-password=%s --glob=off --passive-ftp --no-remove-listing -N %s-t 1 -T %d%s\n",
-		env, FTP_CMD, remot, usr, pwd, opt, FTP_TIMEOUT, opt2)
-	execcmd_to(cmd)
-	if fp, _ = os.OpenFile(FTP_LISTING, os.O_RDONLY, 0666); fp == nil {
-		return 0
+	if len(addr) == 0 {
+		if _, msg, err = c.cmd(227, "PASV"); err != nil {
+			return nil, err
+		}
+		if i = strings.IndexAny(msg, "0123456789"); i < 0 {
+			return nil, fmt.Errorf("pasv response error: %s", msg)
+		}
+		if _, err = fmt.Sscanf(msg[i:], "%d,%d,%d,%d,%d,%d", &h[0], &h[1], &h[2], &h[3],
+			&p1, &p2); err != nil {
+			return nil, fmt.Errorf("pasv response error: %s", msg)
+		}
+		/* use control connection host instead of the address behind NAT */
+		addr = net.JoinHostPort(host, strconv.Itoa(p1*256+p2))
 	}
-	fp.Close()
-	return 1
+	if conn, err = dl_dial(addr); err != nil {
+		return nil, err
+	}
+	if c.tlsc != nil {
+		return tls.Client(conn, c.tlsc), nil
+	}
+	return conn, nil
 }
 
-/* replace wild-card (*) in the paths ----------------------------------------*/
-func rep_paths(path *Path, file string) int {
+/* transfer FTP data (LIST or RETR) ------------------------------------------*/
+func (c *ftpcli) transfer(cmd, file string, w io.Writer) error {
 	var (
-		buff1, buff2 string //,*p,*q,*remot,*local;
-		idx          int
+		conn net.Conn
+		code int
+		err  error
 	)
-	buff1 = path.remot
-	buff2 = path.local
+	if conn, err = c.pasv(); err != nil {
+		return err
+	}
+	defer conn.Close()
 
-	if idx = strings.LastIndex(buff1, "/"); idx > 0 {
-		buff1 = buff1[idx:]
+	if code, _, err = c.cmd(1, "%s %s", cmd, file); err != nil {
+		if code == 450 || code == 550 {
+			return errDlNoFile
+		}
+		return err
 	}
-	if idx = strings.LastIndex(buff2, FILEPATHSEP); idx > 0 {
-		buff2 = buff2[idx:]
+	if _, err = io.Copy(w, conn); err != nil {
+		return err
 	}
-	buff1 += file
-	buff2 += file
-	path.remot = buff1
-	path.local = buff2
-	return 1
+	conn.Close()
+	_, _, err = c.conn.ReadResponse(2)
+	return err
 }
 
-/* test file in remote file list ---------------------------------------------*/
-func test_list(path *Path) int {
+/* read HTTP response body ---------------------------------------------------*/
+func dl_body(resp *http.Response, w io.Writer) error {
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return errDlNoFile
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	_, err := io.Copy(w, resp.Body)
+	return err
+}
+
+/* new download session ------------------------------------------------------*/
+func new_dlsess(usr, pwd, proxy string, opts int, fp *os.File) *dlsess {
+	var s *dlsess = &dlsess{usr: usr, pwd: pwd, proxy: proxy, opts: opts, fp: fp}
+	to := time.Duration(FTP_TIMEOUT) * time.Second
+
+	tr := &http.Transport{
+		DialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			return dl_dial(addr)
+		},
+		TLSHandshakeTimeout:   to,
+		ResponseHeaderTimeout: to,
+	}
+	if len(proxy) > 0 {
+		if u, err := url.Parse("http://" + proxy); err == nil {
+			tr.Proxy = http.ProxyURL(u)
+		}
+	}
+	jar, _ := cookiejar.New(nil)
+	s.client = &http.Client{Transport: tr, Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("too many redirects")
+			}
+			/* keep login for redirect to https or the same host */
+			if usr, pwd, ok := via[0].BasicAuth(); ok &&
+				(req.URL.Scheme == "https" || req.URL.Host == via[0].URL.Host) {
+				req.SetBasicAuth(usr, pwd)
+			}
+			return nil
+		}}
+	s.lists = make(map[string]*dllist)
+	return s
+}
+
+/* output download status ----------------------------------------------------*/
+func (s *dlsess) status(stat string, n int, format string, v ...interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ShowMsg_Ptr("STAT=%s", stat)
+	if s.fp != nil {
+		s.fp.WriteString(fmt.Sprintf(format, v...))
+	}
+	if n >= 0 {
+		s.n[n]++
+	}
+}
+
+/* get remote file via HTTP or HTTPS -----------------------------------------*/
+func (s *dlsess) http_get(remot string, w io.Writer) error {
+	req, err := http.NewRequest("GET", remot, nil)
+	if err != nil {
+		return err
+	}
+	if len(s.pwd) > 0 {
+		req.SetBasicAuth(s.usr, s.pwd)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return dl_body(resp, w)
+}
+
+/* get remote file via HTTP proxy for FTP ------------------------------------*/
+func (s *dlsess) proxy_get(remot string, w io.Writer) error {
 	var (
-		fp               *os.File
-		buff, file, list string
-		idx              int
-		err              error
+		u    *url.URL
+		conn net.Conn
+		resp *http.Response
+		err  error
 	)
+	if u, err = url.Parse(remot); err != nil {
+		return err
+	}
+	if conn, err = dl_dial(s.proxy); err != nil {
+		return err
+	}
+	defer conn.Close()
 
-	if fp, err = os.OpenFile(FTP_LISTING, os.O_RDONLY, 0666); err != nil {
-		return 1
+	req := &http.Request{Method: "GET", URL: u, Proto: "HTTP/1.1", ProtoMajor: 1,
+		ProtoMinor: 1, Header: make(http.Header), Host: u.Host, Close: true}
+	if len(s.usr) > 0 {
+		req.SetBasicAuth(s.usr, s.pwd)
 	}
-	defer fp.Close()
+	if err = req.WriteProxy(conn); err != nil {
+		return err
+	}
+	if resp, err = http.ReadResponse(bufio.NewReader(conn), req); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return dl_body(resp, w)
+}
 
-	if idx = strings.LastIndex(path.remot, "/"); idx > 0 {
-		file = path.remot[idx:]
-	} else {
-		return 1
+/* get remote file or file list via FTP or FTPS ------------------------------*/
+func (s *dlsess) ftp_get(remot string, list int, w io.Writer, ftps map[string]*ftpcli) error {
+	var (
+		u   *url.URL
+		c   *ftpcli
+		cmd string = "RETR"
+		err error
+	)
+	if len(s.proxy) > 0 {
+		return s.proxy_get(remot, w)
+	}
+	if u, err = url.Parse(remot); err != nil {
+		return err
+	}
+	key := u.Scheme + "://" + u.Host
+	if c = ftps[key]; c == nil {
+		if c, err = ftp_open(u, s.usr, s.pwd); err != nil {
+			return err
+		}
+		ftps[key] = c
 	}
+	if list > 0 {
+		cmd = "LIST"
+	}
+	if err = c.transfer(cmd, u.Path, w); err != nil && err != errDlNoFile {
+		c.close()
+		delete(ftps, key)
+	}
+	return err
+}
 
-	/* search file in remote file list */
-	rd := bufio.NewReader(fp)
-	for {
-		buff, err = rd.ReadString('\n')
-		if err != nil {
-			break
+/* parse remote file list ----------------------------------------------------*/
+func dl_parselist(buff []byte) []string {
+	var (
+		files []string = []string{}
+		line  string
+		idx   int
+	)
+	for _, line = range strings.Split(string(buff), "\n") {
+
+		/* html listing via proxy */
+		if idx = strings.Index(line, "href=\""); idx >= 0 {
+			line = line[idx+6:]
+			if idx = strings.Index(line, "\""); idx >= 0 {
+				line = line[:idx]
+			}
+			line = strings.TrimSuffix(line, "/")
+			if idx = strings.LastIndex(line, "/"); idx >= 0 {
+				line = line[idx+1:]
+			}
+			if len(line) > 0 {
+				files = append(files, line)
+			}
+			continue
 		}
 		/* remove symbolic link */
-		if idx = strings.Index(buff, "->"); idx > 0 {
-			buff = buff[:idx]
+		if idx = strings.Index(line, "->"); idx > 0 {
+			line = line[:idx]
 		}
-		buff = strings.TrimRightFunc(buff, func(r rune) bool {
-			return (r == ' ' || r == '\r' || r == '\n')
-		})
+		line = strings.TrimRight(line, " \r\n")
 
 		/* file as last field */
-		if idx = strings.LastIndex(buff, " "); idx > 0 {
-			list = buff[idx:]
-		} else {
-			list = buff
+		if idx = strings.LastIndex(line, " "); idx >= 0 {
+			line = line[idx+1:]
+		}
+		if len(line) > 0 {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+/* get remote file list for FTP or FTPS --------------------------------------*/
+func (s *dlsess) get_list(path *Path, ftps map[string]*ftpcli) []string {
+	var (
+		list *dllist
+		dir  string
+		idx  int
+		ok   bool
+	)
+	if idx = strings.LastIndex(path.remot, "/"); idx < 0 {
+		return nil
+	}
+	dir = path.remot[:idx+1]
+
+	s.lock.Lock()
+	if list, ok = s.lists[dir]; !ok {
+		list = new(dllist)
+		s.lists[dir] = list
+	}
+	s.lock.Unlock()
+
+	list.once.Do(func() {
+		var buff bytes.Buffer
+		var err error
+
+		for i := 0; i < FTP_RETRY; i++ {
+			buff.Reset()
+			if err = s.ftp_get(dir, 1, &buff, ftps); err == nil || err == errDlNoFile {
+				break
+			}
+		}
+		if err == errDlNoFile {
+			list.files = []string{}
+			return
 		}
+		if err != nil {
+			Trace(2, "get_list: list error %s %v\n", dir, err)
+			return
+		}
+		list.files = dl_parselist(buff.Bytes())
+
+		if s.opts&DLOPT_HOLDLST != 0 {
+			local := path.local
+			if idx = strings.LastIndex(local, FILEPATHSEP); idx >= 0 {
+				local = local[:idx+1]
+			} else {
+				local = ""
+			}
+			if mkdir_r(local) > 0 {
+				os.WriteFile(local+FTP_LISTING, buff.Bytes(), 0666)
+			}
+		}
+	})
+	return list.files
+}
+
+/* replace wild-card (*) in the paths ----------------------------------------*/
+func rep_paths(path *Path, file string) int {
+	var (
+		buff1, buff2 string
+		idx          int
+	)
+	buff1 = path.remot
+	buff2 = path.local
 
-		if strings.Compare(file, list) == 0 {
+	if idx = strings.LastIndex(buff1, "/"); idx >= 0 {
+		buff1 = buff1[:idx+1]
+	} else {
+		buff1 = ""
+	}
+	if idx = strings.LastIndex(buff2, FILEPATHSEP); idx >= 0 {
+		buff2 = buff2[:idx+1]
+	} else {
+		buff2 = ""
+	}
+	path.remot = buff1 + file
+	path.local = buff2 + file
+	return 1
+}
+
+/* test file in remote file list ---------------------------------------------*/
+func test_list(path *Path, list []string) int {
+	var file string
+	var idx int
+
+	if idx = strings.LastIndex(path.remot, "/"); idx < 0 {
+		return 1
+	}
+	file = path.remot[idx+1:]
+
+	/* search file in remote file list */
+	for _, s := range list {
+		if strings.Compare(file, s) == 0 {
 			return 1
 		}
 		/* compare with wild-card (*) */
-		if cmp_str(list, file) > 0 {
+		if strings.Contains(file, "*") && cmp_str(s, file) > 0 {
 
 			/* replace wild-card (*) in the paths */
-			if rep_paths(path, list) == 0 {
-				return 0
-			}
-			return 1
+			return rep_paths(path, s)
 		}
 	}
 	return 0
 }
 
-/* execute download ----------------------------------------------------------*/
-func exec_down(path *Path, remot_p *string, usr, pwd, proxy string, opts int, n []int, fp *os.File) int {
+/* download remote file to local file ----------------------------------------*/
+func (s *dlsess) download(path *Path, proto int, ftps map[string]*ftpcli) error {
 	var (
-		dir, errfile, tmpfile, cmd, env, opt, opt2 string
-		ret, proto, idx                            int
+		fp      *os.File
+		tmpfile string = path.local + ".part"
+		err     error
 	)
-
-	if runtime.GOOS == "windows" {
-		opt2 = " 2> /dev/null"
+	if fp, err = os.Create(tmpfile); err != nil {
+		return err
+	}
+	if proto == 1 {
+		err = s.http_get(path.remot, fp)
+	} else {
+		err = s.ftp_get(path.remot, 0, fp, ftps)
+	}
+	if e := fp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmpfile, path.local)
+	}
+	if err != nil {
+		os.Remove(tmpfile)
 	}
+	return err
+}
+
+/* execute download ----------------------------------------------------------*/
+func (s *dlsess) exec_down(path *Path, ftps map[string]*ftpcli) {
+	var (
+		dir, errfile, tmpfile string
+		proto, idx, i         int
+		err                   error
+	)
 
 	dir = path.local
 	if idx = strings.LastIndex(dir, FILEPATHSEP); idx > 0 {
 		dir = dir[:idx]
 	}
 
-	switch {
-	case path.remot[:6] == "ftp://":
-		proto = 0
-	case path.remot[:7] == "ftps://":
-		proto = 2
-	case path.remot[:7] == "http://":
-		proto = 1
-	case path.remot[:8] == "https://":
-		proto = 1
-	default:
+	if proto = dl_proto(path.remot); proto < 0 {
 		Trace(2, "exec_down: invalid path %s\n", path.remot)
-		ShowMsg_Ptr("STAT=X")
-		if fp != nil {
-			fp.WriteString(fmt.Sprintf("%s ERROR (INVALID PATH)\n", path.remot))
-		}
-		n[1]++
-		return 0
+		s.status("X", 1, "%s ERROR (INVALID PATH)\n", path.remot)
+		return
 	}
 	/* test local file existence */
-	if opts&DLOPT_FORCE == 0 && test_file(path.local) > 0 {
-		ShowMsg_Ptr("STAT=.")
-		if fp != nil {
-			fp.WriteString(fmt.Sprintf("%s in %s\n", path.remot, dir))
-		}
-		n[2]++
-		return 0
+	if s.opts&DLOPT_FORCE == 0 && test_file(path.local) > 0 {
+		s.status(".", 2, "%s in %s\n", path.remot, dir)
+		return
 	}
-	ShowMsg_Ptr("STAT=_")
-
-	/* get remote file list for FTP or FTPS */
-	if idx = strings.LastIndex(path.remot, "/"); (proto == 0 || proto == 2) && idx >= 0 &&
-		strings.Compare(path.remot[:idx], *remot_p) != 0 {
+	s.status("_", -1, "")
 
-		if get_list(path, usr, pwd, proxy) > 0 {
-			*remot_p = path.remot
-		}
-	}
 	/* test file in listing for FTP or FTPS or extend wild-card in file path */
-	if (proto == 0 || proto == 2) && test_list(path) == 0 {
-		ShowMsg_Ptr("STAT=x")
-		if fp != nil {
-			fp.WriteString(fmt.Sprintf("%s NO_FILE\n", path.remot))
+	if proto == 0 || proto == 2 {
+		if list := s.get_list(path, ftps); list != nil && test_list(path, list) == 0 {
+			s.status("x", 1, "%s NO_FILE\n", path.remot)
+			return
 		}
-		n[1]++
-		return 0
 	}
 	/* generate local directory recursively */
 	if mkdir_r(dir) == 0 {
-		ShowMsg_Ptr("STAT=X")
-		if fp != nil {
-			fp.WriteString(fmt.Sprintf("%s . %s ERROR (LOCAL DIR)\n", path.remot, dir))
-		}
-		n[3]++
-		return 0
+		s.status("X", 3, "%s -> %s ERROR (LOCAL DIR)\n", path.remot, dir)
+		return
 	}
 	/* re-test local file existence for file with wild-card */
-	if (opts&DLOPT_FORCE) == 0 && test_file(path.local) > 0 {
-		ShowMsg_Ptr("STAT=.")
-		if fp != nil {
-			fp.WriteString(fmt.Sprintf("%s in %s\n", path.remot, dir))
-		}
-		n[2]++
-		return 0
+	if (s.opts&DLOPT_FORCE) == 0 && test_file(path.local) > 0 {
+		s.status(".", 2, "%s in %s\n", path.remot, dir)
+		return
 	}
-	/* proxy option */
-	if len(proxy) > 0 {
-		sproto := "http"
-		if proto == 0 || proto == 2 {
-			sproto = "ftp"
+	/* execute download with retry */
+	for i = 0; i < FTP_RETRY; i++ {
+		if i > 0 {
+			Sleepms(1000 * i)
+		}
+		if err = s.download(path, proto, ftps); err == nil || err == errDlNoFile {
+			break
 		}
-		env = fmt.Sprintf("set %s_proxy=http://%s & ", sproto, proxy)
-		opt = " --proxy=on "
+		Trace(2, "exec_down: download error %s %v\n", path.remot, err)
 	}
-	/* download command */
 	errfile = fmt.Sprintf("%s.err", path.local)
-	if proto == 0 || proto == 2 {
-		cmd = fmt.Sprintf("%s%s %s --ftp-user=%s --ftp-password=%s --glob=off --passive-ftp %s-t %d -T %d -O \"%s\" -o \"%s\"%s\n",
-			env, FTP_CMD, path.remot, usr, pwd, opt, FTP_RETRY, FTP_TIMEOUT,
-			path.local, errfile, opt2)
-	} else {
-		if len(pwd) > 0 {
-			opt += fmt.Sprintf(" --http-user=%s --http-password=%s ", usr, pwd)
+	if err != nil {
+		if (s.opts & DLOPT_HOLDERR) != 0 {
+			os.WriteFile(errfile, []byte(fmt.Sprintf("%s %v\n", path.remot, err)), 0666)
 		}
-		cmd = fmt.Sprintf("%s%s %s %s-t %d -T %d -O \"%s\" -o \"%s\"%s\n", env, FTP_CMD,
-			path.remot, opt, FTP_RETRY, FTP_TIMEOUT, path.local, errfile, opt2)
-	}
-	if fp != nil {
-		fp.WriteString(fmt.Sprintf("%s . %s", path.remot, dir))
-	}
-
-	/* execute download command */
-	if ret = execcmd_to(cmd); ret > 0 {
-		if (proto == 0 && ret == FTP_NOFILE) ||
-			(proto == 1 && ret == HTTP_NOFILE) {
-			ShowMsg_Ptr("STAT=x")
-			if fp != nil {
-				fp.WriteString(" NO_FILE\n")
-			}
-			n[1]++
+		if err == errDlNoFile {
+			s.status("x", 1, "%s -> %s NO_FILE\n", path.remot, dir)
 		} else {
-			Trace(2, "exec_down: error proto=%d %d\n", proto, ret)
-			ShowMsg_Ptr("STAT=X")
-			if fp != nil {
-				fp.WriteString(fmt.Sprintf(" ERROR (%d)\n", ret))
-			}
-			n[3]++
-		}
-		os.Remove(path.local)
-		if (opts & DLOPT_HOLDERR) == 0 {
-			os.Remove(errfile)
-		}
-		if ret == 2 {
-			return 1
+			Trace(2, "exec_down: error proto=%d %v\n", proto, err)
+			s.status("X", 3, "%s -> %s ERROR (%v)\n", path.remot, dir, err)
 		}
-		return 0
+		return
 	}
 	os.Remove(errfile)
 
 	/* uncompress download file */
-	if idx = strings.LastIndex(path.local, "."); (opts&DLOPT_KEEPCMP) == 0 && idx > 0 &&
-		strings.EqualFold(path.local[idx:], ".z") || strings.EqualFold(path.local[idx:], ".gz") ||
-		strings.EqualFold(path.local[idx:], ".zip") {
+	if idx = strings.LastIndex(path.local, "."); (s.opts&DLOPT_KEEPCMP) == 0 && idx > 0 &&
+		(strings.EqualFold(path.local[idx:], ".z") || strings.EqualFold(path.local[idx:], ".gz") ||
+			strings.EqualFold(path.local[idx:], ".zip")) {
 
 		if Rtk_Uncompress(path.local, &tmpfile) > 0 {
 			os.Remove(path.local)
 		} else {
 			Trace(2, "exec_down: uncompress error\n")
-			ShowMsg_Ptr("STAT=C")
-			if fp != nil {
-				fp.WriteString(" ERROR (UNCOMP)\n")
-			}
-			n[3]++
-			return 0
+			s.status("C", 3, "%s -> %s ERROR (UNCOMP)\n", path.remot, dir)
+			return
 		}
 	}
-	ShowMsg_Ptr("STAT=o")
-	if fp != nil {
-		fp.WriteString(" OK\n")
+	s.status("o", 0, "%s -> %s OK\n", path.remot, dir)
+}
+
+/* download worker -----------------------------------------------------------*/
+func (s *dlsess) worker(ch chan *Path, wg *sync.WaitGroup) {
+	var ftps map[string]*ftpcli = make(map[string]*ftpcli)
+
+	defer wg.Done()
+	for path := range ch {
+		s.exec_down(path, ftps)
+	}
+	for _, c := range ftps {
+		c.close()
 	}
-	n[0]++
-	return 0
 }
 
 /* test local file -----------------------------------------------------------*/
@@ -728,21 +1081,22 @@ func DL_ReadUrls(file string, types []string, ntype int, urls []Url, nmax int) i
 				buff = buff[:idx]
 			}
 			ss := strings.Fields(buff)
-			if len(ss) > 2 {
-				dir = ss[2]
+			if len(ss) < 1 {
+				continue
 			}
+			stype, path, dir = ss[0], "", ""
 			if len(ss) > 1 {
 				path = ss[1]
 			}
-			if len(ss) == 1 {
-				stype = ss[0]
-			}
-			if len(ss) < 1 {
-				continue
+			if len(ss) > 2 {
+				dir = ss[2]
 			}
 			if cmp_str(stype, types[i]) == 0 {
 				continue
 			}
+			if n >= nmax {
+				break
+			}
 			urls[n].dtype = stype
 			urls[n].path = path
 			urls[n].dir = dir
@@ -794,11 +1148,9 @@ func DL_ReadStas(file string, stas []string, nmax int) int {
 		if idx = strings.Index(buff, "#"); idx >= 0 {
 			buff = buff[:idx]
 		}
-		ss := strings.FieldsFunc(buff, func(r rune) bool {
-			return strings.Index(" \r\n", string(r)) == 0
-		})
+		ss := strings.Fields(buff)
 		for _, v := range ss {
-			if n > nmax {
+			if n >= nmax {
 				break
 			}
 			stas[n] = v
@@ -824,10 +1176,9 @@ func DL_ReadStas(file string, stas []string, nmax int) int {
 *          char   **stas    I   station list
 *          int    nsta      I   number of station list
 *          char   *dir      I   local directory
-*          char   *remote_p I   previous remote file path
-*          char   *usr      I   login user for FTP or FTPS
-*          char   *pwd      I   login password for FTP or FTPS
-*          char   *proxy    I   proxy server address
+*          char   *usr      I   login user for FTP, FTPS or HTTP
+*          char   *pwd      I   login password for FTP, FTPS or HTTP
+*          char   *proxy    I   proxy server address (host:port)
 *          int    opts      I   download options (or of the followings)
 *                                 DLOPT_FORCE = force download existing file
 *                                 DLOPT_KEEPCMP=keep compressed file
@@ -843,17 +1194,35 @@ func DL_ReadStas(file string, stas []string, nmax int) int {
 *          the remote directory and downloads the firstly matched file in the
 *          remote file-list. The secondary matched or the following files are
 *          not downloaded.
+*          The remote file-lists are kept in memory. With DLOPT_HOLDLST, they
+*          are saved as FTP_LISTING in the local directories.
+*          The files are downloaded by DL_NTHREAD threads in parallel.
 *-----------------------------------------------------------------------------*/
 func DL_Exec(ts, te Gtime, ti float64, seqnos, seqnoe int,
 	urls []Url, nurl int, stas []string, nsta int,
 	dir, usr, pwd, proxy string, opts int, msg *string, fp *os.File) int {
+	return DL_ExecN(ts, te, ti, seqnos, seqnoe, urls, nurl, stas, nsta, dir, usr, pwd,
+		proxy, opts, DL_NTHREAD, msg, fp)
+}
+
+/* execute download with number of threads -------------------------------------
+* execute download with number of download threads
+* args   : int    nthread   I   number of download threads (1-DL_MAXTHREAD)
+*          (others are same as DL_Exec())
+* return : status (1:ok,0:error,-1:aborted)
+*-----------------------------------------------------------------------------*/
+func DL_ExecN(ts, te Gtime, ti float64, seqnos, seqnoe int,
+	urls []Url, nurl int, stas []string, nsta int,
+	dir, usr, pwd, proxy string, opts, nthread int, msg *string, fp *os.File) int {
 	var (
-		paths        Paths
-		ts_p         Gtime
-		str, remot_p string
-		i            int
-		n            [4]int
-		tick         uint32 = uint32(TickGet())
+		paths Paths
+		ts_p  Gtime
+		sess  *dlsess
+		wg    sync.WaitGroup
+		ch    chan *Path
+		i     int
+		stat  int    = 1
+		tick  uint32 = uint32(TickGet())
 	)
 
 	ShowMsg_Ptr("STAT=_")
@@ -878,28 +1247,39 @@ func DL_Exec(ts, te Gtime, ti float64, seqnos, seqnoe int,
 		*msg = "no download data"
 		return 0
 	}
+	if nthread < 1 {
+		nthread = 1
+	} else if nthread > DL_MAXTHREAD {
+		nthread = DL_MAXTHREAD
+	}
+	sess = new_dlsess(usr, pwd, proxy, opts, fp)
+	ch = make(chan *Path)
+	for i = 0; i < nthread; i++ {
+		wg.Add(1)
+		go sess.worker(ch, &wg)
+	}
 	for i = 0; i < paths.n; i++ {
 
-		str = fmt.Sprintf("%s.%s (%d/%d)", paths.path[i].remot, paths.path[i].local, i+1,
-			paths.n)
-		if ShowMsg_Ptr(str) > 0 {
+		sess.lock.Lock()
+		abort := ShowMsg_Ptr("%s -> %s (%d/%d)", paths.path[i].remot, paths.path[i].local,
+			i+1, paths.n)
+		sess.lock.Unlock()
+		if abort > 0 {
+			stat = -1
 			break
 		}
-
 		/* execute download */
-		if exec_down(&paths.path[i], &remot_p, usr, pwd, proxy, opts, n[:], fp) > 0 {
-			break
-		}
+		ch <- &paths.path[i]
 	}
-	if (opts & DLOPT_HOLDLST) == 0 {
-		os.Remove(FTP_LISTING)
-	}
-	*msg = fmt.Sprintf("OK=%d No_File=%d Skip=%d Error=%d (Time=%.1f s)", n[0], n[1], n[2],
-		n[3], float64(uint32(TickGet())-tick)*0.001)
+	close(ch)
+	wg.Wait()
+
+	*msg = fmt.Sprintf("OK=%d No_File=%d Skip=%d Error=%d (Time=%.1f s)", sess.n[0],
+		sess.n[1], sess.n[2], sess.n[3], float64(uint32(TickGet())-tick)*0.001)
 
 	free_path(&paths)
 
-	return 1
+	return stat
 }
 
 /* execute local file test -----------------------------------------------------
@@ -939,10 +1319,10 @@ func DL_Test(ts, te Gtime, ti float64, urls []Url,
 		TimeStr(TimeGet(), 0), dir))
 
 	for i, n = 0, 0; i < nurl; i++ {
-		if strings.EqualFold(urls[i].path, "%s") {
+		if strings.Contains(urls[i].path, "%s") || strings.Contains(urls[i].path, "%S") {
 			n += nsta
 		} else {
-			n = 1
+			n++
 		}
 	}
 	nc = IMat(n, 1)
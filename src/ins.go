@@ -0,0 +1,554 @@
+/*------------------------------------------------------------------------------
+* ins.go : gnss/ins loosely coupled integration functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] P.D.Groves, Principles of GNSS, Inertial, and Multisensor Integrated
+*         Navigation Systems, 2nd ed., Artech House, 2013
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, ecef mechanization and error-state kalman
+*                           filter for loosely coupled integration
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	NX_INS       = 15             /* number of ins error states */
+	MU_GRAV      = 3.986004418e14 /* earth gravitational constant (m^3/s^2) */
+	J2_GRAV      = 1.082627e-3    /* earth second gravitational constant */
+	MAXDT_IMU    = 1.0            /* max interval of imu data (s) */
+	MAXDT_INSUPD = 1.0            /* max time difference of gnss and ins (s) */
+	MINVEL_ALIGN = 5.0            /* min horizontal velocity for alignment (m/s) */
+	MAXDT_ALIGN  = 5.0            /* max interval of positions for alignment (s) */
+	STD_INIATT   = 5.0 * D2R      /* initial std of attitude (rad) */
+	STD_INIVEL   = 1.0            /* initial std of velocity (m/s) */
+	STD_INIBA    = 0.1            /* initial std of accelerometer bias (m/s^2) */
+	STD_INIBG    = 1e-3           /* initial std of gyro bias (rad/s) */
+)
+
+/* skew symmetric matrix -----------------------------------------------------*/
+func skew3(a, A []float64) {
+	A[0], A[3], A[6] = 0.0, -a[2], a[1]
+	A[1], A[4], A[7] = a[2], 0.0, -a[0]
+	A[2], A[5], A[8] = -a[1], a[0], 0.0
+}
+
+/* set 3x3 block of matrix (n x n) -------------------------------------------*/
+func setblk3(P []float64, n, i, j int, A []float64, alpha float64) {
+	for k := 0; k < 3; k++ {
+		for l := 0; l < 3; l++ {
+			P[i+k+(j+l)*n] = alpha * A[k+l*3]
+		}
+	}
+}
+
+/* rotation matrix of rotation vector (rodrigues formula) --------------------*/
+func rotvec2dcm(a, C []float64) {
+	var A, AA [9]float64
+
+	na := Norm(a, 3)
+	skew3(a, A[:])
+	MatMul("NN", 3, 3, 3, 1.0, A[:], A[:], 0.0, AA[:])
+	s, c := 1.0, 0.5
+	if na > 1e-8 {
+		s, c = math.Sin(na)/na, (1.0-math.Cos(na))/(na*na)
+	}
+	for i := 0; i < 9; i++ {
+		C[i] = s*A[i] + c*AA[i]
+	}
+	C[0] += 1.0
+	C[4] += 1.0
+	C[8] += 1.0
+}
+
+/* gravity in ecef (gravitation with J2 + centrifugal) -----------------------*/
+func gravity_ecef(r, g []float64) {
+	var ri float64
+
+	if ri = Norm(r, 3); ri <= 0.0 {
+		g[0], g[1], g[2] = 0.0, 0.0, 0.0
+		return
+	}
+	zr := SQR(r[2] / ri)
+	a := 1.5 * J2_GRAV * SQR(RE_WGS84/ri)
+	f := -MU_GRAV / (ri * ri * ri)
+	g[0] = f*r[0]*(1.0+a*(1.0-5.0*zr)) + OMGE*OMGE*r[0]
+	g[1] = f*r[1]*(1.0+a*(1.0-5.0*zr)) + OMGE*OMGE*r[1]
+	g[2] = f * r[2] * (1.0 + a*(3.0-5.0*zr))
+}
+
+/* ecef to local (ned) rotation matrix ---------------------------------------
+* Cne: ned to ecef (3x3), columns are {north,east,down} in ecef
+*----------------------------------------------------------------------------*/
+func ned2ecef_dcm(r, Cne []float64) {
+	var pos [3]float64
+	var E [9]float64
+
+	Ecef2Pos(r, pos[:])
+	XYZ2Enu(pos[:], E[:])
+	for i := 0; i < 3; i++ {
+		Cne[i], Cne[i+3], Cne[i+6] = E[1+i*3], E[i*3], -E[2+i*3]
+	}
+}
+
+/* initialize ins states -------------------------------------------------------
+* initialize ins states to not aligned
+* args   : none
+* return : none
+*-----------------------------------------------------------------------------*/
+func (ins *Ins) InitIns() {
+	var ins0 Ins
+	*ins = ins0
+}
+
+/* ins mechanization -----------------------------------------------------------
+* update ins states and error covariance by an imu data record
+* args   : ImuD  *imu       I   imu data record
+*          InsOpt *opt      I   ins options
+* return : status (1:updated,0:not updated)
+* notes  : mechanization in ecef frame (ref [1] 5.3). error states are
+*          {attitude,velocity,position,accl bias,gyro bias}, defined as
+*          estimated - true for attitude/velocity/position and as
+*          true - estimated for biases (ref [1] 14.2).
+*-----------------------------------------------------------------------------*/
+func (ins *Ins) InsMech(imu *ImuD, opt *InsOpt) int {
+	var (
+		fb, wb, a, fe, ge, ve0 [3]float64
+		Cbb, Cei, C, W, FE, Rr [9]float64
+		F, Phi, Q, PF          []float64
+		dt, ri, sn, cs         float64
+		i                      int
+	)
+	Trace(5, "insmech : time=%s\n", TimeStr(imu.Time, 3))
+
+	if ins.Time.Time == 0 {
+		ins.Time = imu.Time
+		copy(ins.Fb[:], imu.Acc[:])
+		copy(ins.Wb[:], imu.Gyro[:])
+		return 0
+	}
+	if dt = TimeDiff(imu.Time, ins.Time); dt <= 0.0 {
+		return 0
+	}
+	if dt > MAXDT_IMU {
+		Trace(2, "imu data gap: time=%s dt=%.3f\n", TimeStr(imu.Time, 3), dt)
+		ins.Stat = 0
+	}
+	ins.Time = imu.Time
+
+	if ins.Stat == 0 { /* average specific force for leveling */
+		for i = 0; i < 3; i++ {
+			ins.Fb[i] += 0.1 * (imu.Acc[i] - ins.Fb[i])
+			ins.Wb[i] = imu.Gyro[i]
+		}
+		return 0
+	}
+	for i = 0; i < 3; i++ {
+		fb[i] = imu.Acc[i] - ins.Ba[i]
+		wb[i] = imu.Gyro[i] - ins.Bg[i]
+		a[i] = wb[i] * dt
+	}
+	/* attitude update */
+	rotvec2dcm(a[:], Cbb[:])
+	sn, cs = math.Sin(OMGE*dt), math.Cos(OMGE*dt)
+	Cei[0], Cei[3], Cei[6] = cs, sn, 0.0
+	Cei[1], Cei[4], Cei[7] = -sn, cs, 0.0
+	Cei[2], Cei[5], Cei[8] = 0.0, 0.0, 1.0
+	MatMul("NN", 3, 1, 3, 1.0, ins.Cbe[:], fb[:], 0.0, fe[:])
+	MatMul("NN", 3, 3, 3, 1.0, ins.Cbe[:], Cbb[:], 0.0, C[:])
+	MatMul("NN", 3, 3, 3, 1.0, Cei[:], C[:], 0.0, ins.Cbe[:])
+
+	/* velocity and position update */
+	gravity_ecef(ins.Re[:], ge[:])
+	copy(ve0[:], ins.Ve[:])
+	ins.Ve[0] += (fe[0] + ge[0] + 2.0*OMGE*ve0[1]) * dt
+	ins.Ve[1] += (fe[1] + ge[1] - 2.0*OMGE*ve0[0]) * dt
+	ins.Ve[2] += (fe[2] + ge[2]) * dt
+	for i = 0; i < 3; i++ {
+		ins.Re[i] += 0.5 * (ve0[i] + ins.Ve[i]) * dt
+	}
+	copy(ins.Fb[:], fb[:])
+	copy(ins.Wb[:], wb[:])
+
+	/* transition matrix (ref [1] 14.2.3) */
+	F = Zeros(NX_INS, NX_INS)
+	skew3([]float64{0.0, 0.0, OMGE}, W[:])
+	skew3(fe[:], FE[:])
+	ri = Norm(ins.Re[:], 3)
+	for i = 0; i < 9; i++ {
+		Rr[i] = 2.0 * Norm(ge[:], 3) / (ri * ri * ri) * ins.Re[i%3] * ins.Re[i/3]
+	}
+	setblk3(F, NX_INS, 0, 0, W[:], -1.0)
+	setblk3(F, NX_INS, 0, 12, ins.Cbe[:], 1.0)
+	setblk3(F, NX_INS, 3, 0, FE[:], -1.0)
+	setblk3(F, NX_INS, 3, 3, W[:], -2.0)
+	setblk3(F, NX_INS, 3, 6, Rr[:], 1.0)
+	setblk3(F, NX_INS, 3, 9, ins.Cbe[:], 1.0)
+	setblk3(F, NX_INS, 6, 3, Eye(3), 1.0)
+	Phi = Eye(NX_INS)
+	for i = 0; i < NX_INS*NX_INS; i++ {
+		Phi[i] += F[i] * dt
+	}
+	/* process noise */
+	Q = Zeros(NX_INS, NX_INS)
+	for i = 0; i < 3; i++ {
+		Q[i+i*NX_INS] = SQR(opt.GyroNoise) * dt
+		Q[3+i+(3+i)*NX_INS] = SQR(opt.AccNoise) * dt
+		Q[9+i+(9+i)*NX_INS] = SQR(opt.AccBias) * dt
+		Q[12+i+(12+i)*NX_INS] = SQR(opt.GyroBias) * dt
+	}
+	PF = Mat(NX_INS, NX_INS)
+	MatMul("NT", NX_INS, NX_INS, NX_INS, 1.0, ins.P[:], Phi, 0.0, PF)
+	MatMul("NN", NX_INS, NX_INS, NX_INS, 1.0, Phi, PF, 0.0, ins.P[:])
+	for i = 0; i < NX_INS*NX_INS; i++ {
+		ins.P[i] += Q[i]
+	}
+	return 1
+}
+
+/* lever arm in ecef and its velocity ----------------------------------------*/
+func (ins *Ins) leverarm(opt *InsOpt, dr, dv []float64) {
+	var wl, W [3]float64
+
+	MatMul("NN", 3, 1, 3, 1.0, ins.Cbe[:], opt.Lever[:], 0.0, dr)
+	Cross3(ins.Wb[:], opt.Lever[:], wl[:])
+	MatMul("NN", 3, 1, 3, 1.0, ins.Cbe[:], wl[:], 0.0, dv)
+	W[0], W[1] = -OMGE*dr[1], OMGE*dr[0] /* omega_ie x (Cbe*l) */
+	for i := 0; i < 3; i++ {
+		dv[i] -= W[i]
+	}
+}
+
+/* ins alignment ---------------------------------------------------------------
+* coarse alignment by gnss velocity (heading) and specific force (leveling)
+* args   : Sol    *sol      I   gnss solution
+*          InsOpt *opt      I   ins options
+* return : status (1:aligned,0:not aligned)
+* notes  : the vehicle is assumed to move forward without side slip and
+*          acceleration. if velocity is not included in the solution, it is
+*          derived from the difference of the positions.
+*-----------------------------------------------------------------------------*/
+func (ins *Ins) InsAlign(sol *Sol, opt *InsOpt) int {
+	var (
+		v, vn, dr, dv          [3]float64
+		Cne, Cbn               [9]float64
+		roll, pitch, yaw, tt   float64
+		cr, sr, cp, sp, cy, sy float64
+		i                      int
+	)
+	Trace(4, "insalign: time=%s\n", TimeStr(sol.Time, 3))
+
+	if Norm(sol.Rr[3:], 3) > 0.0 && sol.Qv[0] > 0.0 {
+		copy(v[:], sol.Rr[3:6])
+	} else if tt = TimeDiff(sol.Time, ins.Tp); ins.Tp.Time != 0 && tt > 0.0 && tt <= MAXDT_ALIGN {
+		for i = 0; i < 3; i++ {
+			v[i] = (sol.Rr[i] - ins.Rp[i]) / tt
+		}
+	}
+	copy(ins.Rp[:], sol.Rr[:3])
+	ins.Tp = sol.Time
+
+	ned2ecef_dcm(sol.Rr[:], Cne[:])
+	MatMul("TN", 3, 1, 3, 1.0, Cne[:], v[:], 0.0, vn[:])
+	if math.Sqrt(vn[0]*vn[0]+vn[1]*vn[1]) < MINVEL_ALIGN || ins.Time.Time == 0 {
+		return 0
+	}
+	yaw = math.Atan2(vn[1], vn[0])
+	roll = math.Atan2(-ins.Fb[1], -ins.Fb[2])
+	pitch = math.Atan2(ins.Fb[0], math.Sqrt(SQR(ins.Fb[1])+SQR(ins.Fb[2])))
+	cr, sr = math.Cos(roll), math.Sin(roll)
+	cp, sp = math.Cos(pitch), math.Sin(pitch)
+	cy, sy = math.Cos(yaw), math.Sin(yaw)
+	Cbn[0], Cbn[3], Cbn[6] = cp*cy, -cr*sy+sr*sp*cy, sr*sy+cr*sp*cy
+	Cbn[1], Cbn[4], Cbn[7] = cp*sy, cr*cy+sr*sp*sy, -sr*cy+cr*sp*sy
+	Cbn[2], Cbn[5], Cbn[8] = -sp, sr*cp, cr*cp
+	MatMul("NN", 3, 3, 3, 1.0, Cne[:], Cbn[:], 0.0, ins.Cbe[:])
+
+	/* imu position and velocity at ins time */
+	ins.Wb = [3]float64{}
+	ins.leverarm(opt, dr[:], dv[:])
+	tt = TimeDiff(ins.Time, sol.Time)
+	for i = 0; i < 3; i++ {
+		ins.Ve[i] = v[i]
+		ins.Re[i] = sol.Rr[i] - dr[i] + v[i]*tt
+		ins.Ba[i], ins.Bg[i] = 0.0, 0.0
+	}
+	for i = 0; i < NX_INS*NX_INS; i++ {
+		ins.P[i] = 0.0
+	}
+	for i = 0; i < 3; i++ {
+		ins.P[i+i*NX_INS] = SQR(STD_INIATT)
+		ins.P[3+i+(3+i)*NX_INS] = SQR(STD_INIVEL)
+		ins.P[6+i+(6+i)*NX_INS] = math.Max(float64(sol.Qr[i]), 1e-4)
+		ins.P[9+i+(9+i)*NX_INS] = SQR(STD_INIBA)
+		ins.P[12+i+(12+i)*NX_INS] = SQR(STD_INIBG)
+	}
+	ins.Stat = 1
+	ins.Tgnss = sol.Time
+
+	Trace(3, "insalign: time=%s roll=%.1f pitch=%.1f yaw=%.1f\n",
+		TimeStr(sol.Time, 3), roll*R2D, pitch*R2D, yaw*R2D)
+	return 1
+}
+
+/* ins measurement update ------------------------------------------------------
+* update ins states by gnss position (and velocity) solution
+* args   : Sol    *sol      I   gnss solution
+*          InsOpt *opt      I   ins options
+* return : status (1:ok,0:error)
+* notes  : gnss velocity is used if the solution includes velocity variance
+*-----------------------------------------------------------------------------*/
+func (ins *Ins) InsUpdate(sol *Sol, opt *InsOpt) int {
+	var (
+		dr, dv, rp, vp  [3]float64
+		A, L, CL        [9]float64
+		x, xp, Pp, H, v []float64
+		R               []float64
+		tt              float64
+		i, j, nv, nm    int
+	)
+	Trace(4, "insupdate: time=%s\n", TimeStr(sol.Time, 3))
+
+	if tt = TimeDiff(sol.Time, ins.Time); math.Abs(tt) > MAXDT_INSUPD {
+		Trace(2, "ins update time error: tt=%.3f\n", tt)
+		return 0
+	}
+	if sol.Qv[0] > 0.0 && sol.Qv[1] > 0.0 && sol.Qv[2] > 0.0 {
+		nv = 3
+	}
+	nm = 3 + nv
+	ins.leverarm(opt, dr[:], dv[:])
+	for i = 0; i < 3; i++ {
+		rp[i] = ins.Re[i] + ins.Ve[i]*tt + dr[i]
+		vp[i] = ins.Ve[i] + dv[i]
+	}
+	x = Zeros(NX_INS, 1)
+	xp = Mat(NX_INS, 1)
+	Pp = Mat(NX_INS, NX_INS)
+	H = Zeros(NX_INS, nm)
+	v = Mat(nm, 1)
+	R = Zeros(nm, nm)
+
+	/* position: v = r_gnss - (r_ins + Cbe*l) */
+	skew3(dr[:], A[:])
+	for i = 0; i < 3; i++ {
+		v[i] = sol.Rr[i] - rp[i]
+		for j = 0; j < 3; j++ {
+			H[j+i*NX_INS] = A[i+j*3]
+		}
+		H[6+i+i*NX_INS] = -1.0
+	}
+	R[0], R[1+nm], R[2+2*nm] = float64(sol.Qr[0]), float64(sol.Qr[1]), float64(sol.Qr[2])
+	R[1], R[nm] = float64(sol.Qr[3]), float64(sol.Qr[3])
+	R[2+nm], R[1+2*nm] = float64(sol.Qr[4]), float64(sol.Qr[4])
+	R[2], R[2*nm] = float64(sol.Qr[5]), float64(sol.Qr[5])
+
+	/* velocity: v = v_gnss - (v_ins + Cbe*(w x l) - Omega_ie*Cbe*l) */
+	if nv > 0 {
+		skew3(dv[:], A[:])
+		skew3(opt.Lever[:], L[:])
+		MatMul("NN", 3, 3, 3, 1.0, ins.Cbe[:], L[:], 0.0, CL[:])
+		for i = 0; i < 3; i++ {
+			v[3+i] = sol.Rr[3+i] - vp[i]
+			for j = 0; j < 3; j++ {
+				H[j+(3+i)*NX_INS] = A[i+j*3]
+				H[12+j+(3+i)*NX_INS] = CL[i+j*3]
+			}
+			H[3+i+(3+i)*NX_INS] = -1.0
+		}
+		R[3+3*nm], R[4+4*nm], R[5+5*nm] = float64(sol.Qv[0]), float64(sol.Qv[1]), float64(sol.Qv[2])
+		R[4+3*nm], R[3+4*nm] = float64(sol.Qv[3]), float64(sol.Qv[3])
+		R[5+4*nm], R[4+5*nm] = float64(sol.Qv[4]), float64(sol.Qv[4])
+		R[5+3*nm], R[3+5*nm] = float64(sol.Qv[5]), float64(sol.Qv[5])
+	}
+	for i = 0; i < nm; i++ {
+		if R[i+i*nm] <= 0.0 {
+			R[i+i*nm] = 1e-4
+		}
+	}
+	if filter_(x, ins.P[:], H, v, R, NX_INS, nm, xp, Pp) != 0 {
+		Trace(2, "ins update filter error: time=%s\n", TimeStr(sol.Time, 3))
+		return 0
+	}
+	/* closed-loop correction */
+	skew3(xp[:3], A[:])
+	for i = 0; i < 9; i++ {
+		A[i] = -A[i]
+	}
+	A[0] += 1.0
+	A[4] += 1.0
+	A[8] += 1.0
+	MatMul("NN", 3, 3, 3, 1.0, A[:], ins.Cbe[:], 0.0, L[:])
+	copy(ins.Cbe[:], L[:])
+	for i = 0; i < 3; i++ {
+		ins.Ve[i] -= xp[3+i]
+		ins.Re[i] -= xp[6+i]
+		ins.Ba[i] += xp[9+i]
+		ins.Bg[i] += xp[12+i]
+	}
+	copy(ins.P[:], Pp)
+	ins.Tgnss = sol.Time
+	return 1
+}
+
+/* ins solution ----------------------------------------------------------------
+* generate dead reckoning solution at antenna position
+* args   : Gtime  time      I   solution time (GPST)
+*          InsOpt *opt      I   ins options
+*          Sol    *sol      O   solution (stat=SOLQ_DR)
+* return : none
+*-----------------------------------------------------------------------------*/
+func (ins *Ins) InsSol(time Gtime, opt *InsOpt, sol *Sol) {
+	var dr, dv [3]float64
+
+	tt := TimeDiff(time, ins.Time)
+	ins.leverarm(opt, dr[:], dv[:])
+	for i := 0; i < 3; i++ {
+		sol.Rr[i] = ins.Re[i] + ins.Ve[i]*tt + dr[i]
+		sol.Rr[i+3] = ins.Ve[i] + dv[i]
+		sol.Qr[i] = float32(ins.P[6+i+(6+i)*NX_INS])
+		sol.Qv[i] = float32(ins.P[3+i+(3+i)*NX_INS])
+	}
+	sol.Qr[3] = float32(ins.P[6+7*NX_INS])
+	sol.Qr[4] = float32(ins.P[7+8*NX_INS])
+	sol.Qr[5] = float32(ins.P[8+6*NX_INS])
+	sol.Qv[3] = float32(ins.P[3+4*NX_INS])
+	sol.Qv[4] = float32(ins.P[4+5*NX_INS])
+	sol.Qv[5] = float32(ins.P[5+3*NX_INS])
+	sol.Time = time
+	sol.Type = 0
+	sol.Stat = SOLQ_DR
+	sol.Ns = 0
+	sol.Ratio = 0.0
+}
+
+/* input imu data to ins -------------------------------------------------------
+* update ins mechanization by imu data records up to the time
+* args   : ImuD   *data     I   imu data records (time ordered)
+*          Gtime  time      I   time (GPST)
+* return : number of imu data records used
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) InsInput(data []ImuD, time Gtime) int {
+	var i int
+
+	for i = 0; i < len(data); i++ {
+		if TimeDiff(data[i].Time, time) > DTTOL {
+			break
+		}
+		rtk.Ins.InsMech(&data[i], &rtk.Opt.InsOpt)
+	}
+	return i
+}
+
+/* gnss/ins integration ----------------------------------------------------------
+* integrate gnss solution and ins states. the rtk solution is used to update
+* the ins states if valid. if not, it is replaced by the dead reckoning
+* solution of the ins.
+* args   : Gtime  time      I   epoch time (GPST)
+*          int    stat      I   status of rtk positioning (0:no solution)
+* return : status (1:solution available,0:no solution)
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) InsIntegrate(time Gtime, stat int) int {
+	opt := &rtk.Opt.InsOpt
+	ins := &rtk.Ins
+
+	Trace(3, "insintegrate: time=%s stat=%d ins=%d\n", TimeStr(time, 3), stat, ins.Stat)
+
+	if opt.Mode == 0 {
+		return stat
+	}
+	if stat > 0 && rtk.RtkSol.Stat != SOLQ_NONE {
+		if ins.Stat == 0 {
+			ins.InsAlign(&rtk.RtkSol, opt)
+		} else if ins.InsUpdate(&rtk.RtkSol, opt) == 0 {
+			ins.Stat = 0
+		}
+		return stat
+	}
+	if ins.Stat == 0 {
+		return stat
+	}
+	if opt.MaxDr > 0.0 && TimeDiff(time, ins.Tgnss) > opt.MaxDr {
+		Trace(2, "ins dead reckoning timeout: time=%s\n", TimeStr(time, 3))
+		ins.Stat = 0
+		return stat
+	}
+	ins.InsSol(time, opt, &rtk.RtkSol)
+	return 1
+}
+
+/* add imu data record -------------------------------------------------------*/
+func (imu *Imu) AddImuData(data *ImuD) int {
+	imu.Data = append(imu.Data, *data)
+	return 1
+}
+
+/* read imu data file ----------------------------------------------------------
+* read imu data from csv file
+* args   : string file      I   imu data file path
+*          Imu    *imu      IO  imu data
+* return : number of imu data records read
+* notes  : record format (comma or space separated, '#' or '%': comment):
+*            week,tow,ax,ay,az,gx,gy,gz  or
+*            yyyy/mm/dd hh:mm:ss.sss,ax,ay,az,gx,gy,gz
+*          time in GPST, specific force in m/s^2 and angular rate in rad/s,
+*          body frame is {forward,right,down}
+*-----------------------------------------------------------------------------*/
+func ReadImuCsv(file string, imu *Imu) int {
+	var (
+		data ImuD
+		t    Gtime
+		fp   *os.File
+		err  error
+		v    []float64
+		n    int
+	)
+	Trace(3, "readimucsv: file=%s\n", file)
+
+	if fp, err = os.OpenFile(file, os.O_RDONLY, 0666); err != nil {
+		Trace(2, "imu data file open error: %s\n", file)
+		return 0
+	}
+	defer fp.Close()
+
+	sc := bufio.NewScanner(fp)
+	for sc.Scan() {
+		buff := strings.TrimSpace(sc.Text())
+		if len(buff) == 0 || buff[0] == '#' || buff[0] == '%' {
+			continue
+		}
+		fields := strings.FieldsFunc(buff, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+		if len(fields) < 8 {
+			continue
+		}
+		if strings.ContainsAny(fields[0], "/-") { /* yyyy/mm/dd hh:mm:ss */
+			s := strings.NewReplacer("/", " ", "-", " ", ":", " ").Replace(fields[0] + " " + fields[1])
+			if Str2Time(s, 0, len(s), &t) != 0 {
+				continue
+			}
+		} else {
+			t = GpsT2Time(int(Str2Num(fields[0], 0, len(fields[0]))), Str2Num(fields[1], 0, len(fields[1])))
+		}
+		v = v[:0]
+		for _, f := range fields[2:8] {
+			v = append(v, Str2Num(f, 0, len(f)))
+		}
+		data.Time = t
+		copy(data.Acc[:], v[:3])
+		copy(data.Gyro[:], v[3:6])
+		imu.AddImuData(&data)
+		n++
+	}
+	Trace(3, "readimucsv: n=%d\n", n)
+	return n
+}
@@ -319,7 +319,7 @@ func (nav *Nav) ReadTec(file string, opt int) {
 		dcb, rms         [MAXSAT]float64
 		i, n             int
 		efiles           [MAXEXFILE]string
-		err              error
+		rd               *bufio.Reader
 	)
 
 	Trace(4, "readtec : file=%s\n", file)
@@ -332,13 +332,11 @@ func (nav *Nav) ReadTec(file string, opt int) {
 	n = ExPath(file, efiles[:], MAXEXFILE)
 
 	for i = 0; i < n; i++ {
-		fp, err = os.OpenFile(efiles[i], os.O_RDONLY, 0666)
-		if err != nil {
+		if rd, fp = OpenUncompressFile(efiles[i], nil); rd == nil {
 			Trace(2, "ionex file open error %s\n", efiles[i])
 			continue
 		}
 		defer fp.Close()
-		rd := bufio.NewReader(fp)
 		/* read ionex header */
 		if ReadIonexHeader(rd, lats[:], lons[:], hgts[:], &rb, &nexp, dcb[:], rms[:]) <= 0.0 {
 			Trace(2, "ionex file format error %s\n", efiles[i])
@@ -0,0 +1,671 @@
+/*------------------------------------------------------------------------------
+* mqtt.go : mqtt stream functions
+*
+*          Copyright (C) 2026 by Feng Xuebin, All rights reserved.
+*
+* references :
+*     [1] MQTT Version 3.1.1, OASIS Standard, 29 October 2014
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, mqtt 3.1.1 client stream to publish and
+*                           subscribe by qos 0/1/2 with retained message
+*           2026/10/16 1.1  keep qos 1/2 messages until acknowledged and resend
+*                           them on reconnect
+*                           fix bug on busy loop without reconnect (tirecon=0)
+*           2026/10/16 1.2  discard duplicate qos 2 messages received before
+*                           pubrel (ref [1] 4.3.3)
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	MQTT_PORT      = 1883    /* default mqtt broker port */
+	MQTT_TLS_PORT  = 8883    /* default mqtt broker port for tls */
+	MQTT_KEEPALIVE = 60      /* default keep alive interval (s) */
+	MQTT_TIMEOUT   = 10      /* connect/send timeout (s) */
+	MQTT_BUFFSIZE  = 65536   /* max size of received data buffer (bytes) */
+	MQTT_MAXPACKET = 1048576 /* max size of received packet (bytes) */
+	MQTT_MAXFLIGHT = 1024    /* max number of in-flight qos 1/2 messages */
+
+	MQTT_CONNECT    = 1  /* mqtt control packet type: connect */
+	MQTT_CONNACK    = 2  /* mqtt control packet type: connect acknowledgment */
+	MQTT_PUBLISH    = 3  /* mqtt control packet type: publish message */
+	MQTT_PUBACK     = 4  /* mqtt control packet type: publish acknowledgment */
+	MQTT_PUBREC     = 5  /* mqtt control packet type: publish received */
+	MQTT_PUBREL     = 6  /* mqtt control packet type: publish release */
+	MQTT_PUBCOMP    = 7  /* mqtt control packet type: publish complete */
+	MQTT_SUBSCRIBE  = 8  /* mqtt control packet type: subscribe request */
+	MQTT_SUBACK     = 9  /* mqtt control packet type: subscribe acknowledgment */
+	MQTT_PINGREQ    = 12 /* mqtt control packet type: ping request */
+	MQTT_PINGRESP   = 13 /* mqtt control packet type: ping response */
+	MQTT_DISCONNECT = 14 /* mqtt control packet type: disconnect */
+)
+
+type mqttmsg struct { /* mqtt in-flight message type */
+	pid  uint16 /* packet identifier */
+	rel  bool   /* publish received (qos 2: waiting for pubcomp) */
+	body []byte /* variable header and payload of publish packet */
+}
+
+type MqttConn struct { /* mqtt control type */
+	state     int             /* state (0:close,1:wait,2:connect) */
+	mode      int             /* stream mode (STR_MODE_???) */
+	addr      string          /* broker address (addr:port) */
+	user      string          /* user */
+	passwd    string          /* password */
+	topic     string          /* topic to publish or subscribe */
+	clientid  string          /* client identifier */
+	qos       int             /* qos (0:at most once,1:at least once,2:exactly once) */
+	retain    int             /* retain flag of published message (0:off,1:on) */
+	keepalive int             /* keep alive interval (s) */
+	tirecon   int             /* reconnect interval (ms) (0:no reconnect) */
+	conf      *tls.Config     /* tls configuration (nil: no tls) */
+	sock      net.Conn        /* socket */
+	pid       uint16          /* last packet identifier */
+	buff      []byte          /* received data buffer */
+	inflight  []mqttmsg       /* published qos 1/2 messages not acknowledged */
+	recvpid   map[uint16]bool /* packet ids of received qos 2 messages not released */
+	ncon      int             /* number of connections */
+	nrecv     uint32          /* number of received messages */
+	nsend     uint32          /* number of sent messages */
+	ndrop     uint32          /* number of dropped bytes by buffer overflow */
+	lock      sync.Mutex      /* lock flag for state, socket and buffer */
+	wlock     sync.Mutex      /* lock flag for socket write */
+	done      chan struct{}   /* close request */
+}
+
+var mqtt_nclient uint32 = 0 /* number of generated client identifiers */
+
+/* connack return code message -----------------------------------------------*/
+var mqtt_connrc = []string{
+	"accepted", "unacceptable protocol version", "identifier rejected",
+	"server unavailable", "bad user name or password", "not authorized"}
+
+/* append mqtt string/uint16 to packet ---------------------------------------*/
+func mqtt_putstr(p []byte, s string) []byte {
+	p = append(p, byte(len(s)>>8), byte(len(s)))
+	return append(p, s...)
+}
+func mqtt_putu16(p []byte, v uint16) []byte {
+	return append(p, byte(v>>8), byte(v))
+}
+
+/* generate mqtt control packet ----------------------------------------------*/
+func mqtt_packet(ptype, flags byte, body []byte) []byte {
+	p := []byte{ptype<<4 | flags&0x0F}
+	n := len(body)
+	for {
+		b := byte(n & 0x7F)
+		if n >>= 7; n > 0 {
+			b |= 0x80
+		}
+		p = append(p, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(p, body...)
+}
+
+/* receive mqtt control packet -------------------------------------------------
+* receive mqtt control packet
+* args   : bufio.Reader *rd I  reader of socket
+*          byte  *flags     O  flags of fixed header
+*          []byte *body     O  variable header and payload
+* return : packet type (MQTT_???) (0: error)
+*-----------------------------------------------------------------------------*/
+func mqtt_recvpkt(rd *bufio.Reader, flags *byte, body *[]byte) (int, error) {
+	h, err := rd.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	n, mul := 0, 1
+	for i := 0; ; i++ {
+		b, err := rd.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n += int(b&0x7F) * mul
+		if b&0x80 == 0 {
+			break
+		}
+		if mul *= 128; i >= 3 {
+			return 0, errors.New("remaining length error")
+		}
+	}
+	if n > MQTT_MAXPACKET {
+		return 0, fmt.Errorf("packet size error (%d)", n)
+	}
+	*body = make([]byte, n)
+	if _, err = io.ReadFull(rd, *body); err != nil {
+		return 0, err
+	}
+	*flags = h & 0x0F
+	return int(h >> 4), nil
+}
+
+/* send mqtt control packet --------------------------------------------------*/
+func (mqtt *MqttConn) sendpkt(sock net.Conn, ptype, flags byte, body []byte) int {
+	p := mqtt_packet(ptype, flags, body)
+
+	mqtt.wlock.Lock()
+	defer mqtt.wlock.Unlock()
+
+	sock.SetWriteDeadline(time.Now().Add(MQTT_TIMEOUT * time.Second))
+	if _, err := sock.Write(p); err != nil {
+		Tracet(2, "mqtt: send error type=%d err=%s\n", ptype, err.Error())
+		sock.Close() /* request reconnect */
+		return 0
+	}
+	return 1
+}
+
+/* new packet identifier -----------------------------------------------------*/
+func (mqtt *MqttConn) newpid() uint16 {
+	if mqtt.pid++; mqtt.pid == 0 {
+		mqtt.pid = 1
+	}
+	return mqtt.pid
+}
+
+/* add in-flight message ----------------------------------------------------*/
+func (mqtt *MqttConn) addmsg(pid uint16, body []byte) {
+	if len(mqtt.inflight) >= MQTT_MAXFLIGHT { /* drop oldest */
+		Tracet(2, "mqtt: in-flight message dropped pid=%d\n", mqtt.inflight[0].pid)
+		mqtt.inflight = mqtt.inflight[1:]
+	}
+	mqtt.inflight = append(mqtt.inflight, mqttmsg{pid: pid, body: body})
+}
+
+/* acknowledge in-flight message -----------------------------------------------
+* update in-flight message by acknowledgment
+* args   : uint16 pid       I   packet identifier
+*          int    ptype     I   acknowledgment (MQTT_PUBACK,MQTT_PUBREC,
+*                                 MQTT_PUBCOMP)
+* return : none
+*-----------------------------------------------------------------------------*/
+func (mqtt *MqttConn) ackmsg(pid uint16, ptype int) {
+	mqtt.lock.Lock()
+	defer mqtt.lock.Unlock()
+
+	for i := range mqtt.inflight {
+		if mqtt.inflight[i].pid != pid {
+			continue
+		}
+		if ptype == MQTT_PUBREC {
+			mqtt.inflight[i].rel = true
+		} else {
+			mqtt.inflight = append(mqtt.inflight[:i], mqtt.inflight[i+1:]...)
+		}
+		return
+	}
+	Tracet(3, "mqtt: no in-flight message type=%d pid=%d\n", ptype, pid)
+}
+
+/* resend in-flight messages ---------------------------------------------------
+* resend publish (with dup flag) or pubrel of in-flight messages on reconnect
+* (ref [1] 4.4)
+*-----------------------------------------------------------------------------*/
+func (mqtt *MqttConn) resend(sock net.Conn) int {
+	mqtt.lock.Lock()
+	msgs := append([]mqttmsg(nil), mqtt.inflight...)
+	mqtt.lock.Unlock()
+
+	for _, m := range msgs {
+		Tracet(3, "mqtt: resend in-flight message pid=%d rel=%t\n", m.pid, m.rel)
+		if m.rel {
+			if mqtt.sendpkt(sock, MQTT_PUBREL, 0x02, mqtt_putu16(nil, m.pid)) == 0 {
+				return 0
+			}
+		} else if mqtt.sendpkt(sock, MQTT_PUBLISH, byte(0x08|mqtt.qos<<1|mqtt.retain),
+			m.body) == 0 {
+			return 0
+		}
+	}
+	return 1
+}
+
+/* decode mqtt path ------------------------------------------------------------
+* decode mqtt path [user[:passwd]@]addr[:port]/topic[::opt...]
+*   options: ::qos={0|1|2}  qos to publish and subscribe (default: 0)
+*            ::retain       publish message as retained message
+*            ::id=clientid  client identifier (default: gnssgo-<pid>-<n>)
+*            ::keep=sec     keep alive interval (s) (default: 60)
+*            ::tls[...]     tls options (see decodetls())
+*-----------------------------------------------------------------------------*/
+func (mqtt *MqttConn) decodepath(path string, msg *string) int {
+	var port, topts string
+
+	mqtt.qos, mqtt.retain, mqtt.keepalive = 0, 0, MQTT_KEEPALIVE
+	mqtt.clientid = ""
+
+	if i := strings.Index(path, "::"); i >= 0 {
+		for _, opt := range strings.Split(path[i+2:], "::") {
+			switch {
+			case strings.HasPrefix(opt, "qos="):
+				mqtt.qos, _ = strconv.Atoi(opt[4:])
+			case opt == "retain":
+				mqtt.retain = 1
+			case strings.HasPrefix(opt, "id="):
+				mqtt.clientid = opt[3:]
+			case strings.HasPrefix(opt, "keep="):
+				mqtt.keepalive, _ = strconv.Atoi(opt[5:])
+			default:
+				topts += "::" + opt
+			}
+		}
+		path = path[:i]
+	}
+	if mqtt.qos < 0 || mqtt.qos > 2 {
+		*msg = fmt.Sprintf("qos error: %d", mqtt.qos)
+		Tracet(2, "openmqtt: qos error qos=%d\n", mqtt.qos)
+		return 0
+	}
+	if mqtt.keepalive <= 0 || mqtt.keepalive > 65535 {
+		mqtt.keepalive = MQTT_KEEPALIVE
+	}
+	topts = path + topts
+	if decodetls(&topts, 1, &mqtt.conf, msg) == 0 {
+		return 0
+	}
+	DecodeTcpPath(path, &mqtt.addr, &port, &mqtt.user, &mqtt.passwd, &mqtt.topic, nil)
+
+	if len(mqtt.topic) == 0 {
+		*msg = "no topic"
+		Tracet(2, "openmqtt: no topic path=%s\n", path)
+		return 0
+	}
+	if len(port) == 0 {
+		if mqtt.conf != nil {
+			port = strconv.Itoa(MQTT_TLS_PORT)
+		} else {
+			port = strconv.Itoa(MQTT_PORT)
+		}
+	}
+	if mqtt.conf != nil && len(mqtt.conf.ServerName) == 0 {
+		mqtt.conf.ServerName = mqtt.addr
+	}
+	mqtt.addr = net.JoinHostPort(mqtt.addr, port)
+
+	if len(mqtt.clientid) == 0 {
+		mqtt.clientid = fmt.Sprintf("gnssgo-%d-%d", os.Getpid(),
+			atomic.AddUint32(&mqtt_nclient, 1))
+	}
+	return 1
+}
+
+/* connect to mqtt broker ----------------------------------------------------*/
+func (mqtt *MqttConn) connect(rd **bufio.Reader) net.Conn {
+	var (
+		flags byte
+		body  []byte
+	)
+	Tracet(3, "mqtt connect: addr=%s clientid=%s\n", mqtt.addr, mqtt.clientid)
+
+	sock, err := net.DialTimeout("tcp", mqtt.addr, MQTT_TIMEOUT*time.Second)
+	if err != nil {
+		Tracet(2, "mqtt connect: connect error addr=%s err=%s\n", mqtt.addr, err.Error())
+		return nil
+	}
+	if mqtt.conf != nil { /* tls handshake */
+		c := tls.Client(sock, mqtt.conf)
+		c.SetDeadline(time.Now().Add(TLS_TIMEOUT * time.Second))
+		if err = c.Handshake(); err != nil {
+			Tracet(2, "mqtt connect: tls handshake error addr=%s err=%s\n", mqtt.addr,
+				err.Error())
+			sock.Close()
+			return nil
+		}
+		c.SetDeadline(time.Time{})
+		sock = c
+	}
+	/* connect request (clean session only for qos 0) */
+	cflag := byte(0x00)
+	if mqtt.qos == 0 {
+		cflag |= 0x02
+	}
+	if len(mqtt.user) > 0 {
+		cflag |= 0x80
+	}
+	if len(mqtt.passwd) > 0 {
+		cflag |= 0x40
+	}
+	p := mqtt_putstr(nil, "MQTT")
+	p = append(p, 4, cflag) /* protocol level 4 (3.1.1) */
+	p = mqtt_putu16(p, uint16(mqtt.keepalive))
+	p = mqtt_putstr(p, mqtt.clientid)
+	if len(mqtt.user) > 0 {
+		p = mqtt_putstr(p, mqtt.user)
+	}
+	if len(mqtt.passwd) > 0 {
+		p = mqtt_putstr(p, mqtt.passwd)
+	}
+	if mqtt.sendpkt(sock, MQTT_CONNECT, 0, p) == 0 {
+		return nil
+	}
+	*rd = bufio.NewReader(sock)
+
+	sock.SetReadDeadline(time.Now().Add(MQTT_TIMEOUT * time.Second))
+	ptype, err := mqtt_recvpkt(*rd, &flags, &body)
+	if err != nil || ptype != MQTT_CONNACK || len(body) < 2 {
+		Tracet(2, "mqtt connect: no connack addr=%s\n", mqtt.addr)
+		sock.Close()
+		return nil
+	}
+	if body[1] != 0 {
+		rc := fmt.Sprintf("return code %d", body[1])
+		if int(body[1]) < len(mqtt_connrc) {
+			rc = mqtt_connrc[body[1]]
+		}
+		Tracet(2, "mqtt connect: connection refused addr=%s (%s)\n", mqtt.addr, rc)
+		sock.Close()
+		return nil
+	}
+	/* subscribe topic for input */
+	if mqtt.mode&STR_MODE_R != 0 {
+		mqtt.lock.Lock()
+		pid := mqtt.newpid()
+		mqtt.lock.Unlock()
+		p = mqtt_putu16(nil, pid)
+		p = mqtt_putstr(p, mqtt.topic)
+		p = append(p, byte(mqtt.qos))
+		if mqtt.sendpkt(sock, MQTT_SUBSCRIBE, 0x02, p) == 0 {
+			return nil
+		}
+	}
+	if mqtt.resend(sock) == 0 {
+		return nil
+	}
+	sock.SetReadDeadline(time.Time{})
+	return sock
+}
+
+/* receive mqtt packets until disconnected -----------------------------------*/
+func (mqtt *MqttConn) recvloop(sock net.Conn, rd *bufio.Reader) {
+	var (
+		flags byte
+		body  []byte
+	)
+	for {
+		sock.SetReadDeadline(time.Now().Add(time.Duration(mqtt.keepalive) * 1500 *
+			time.Millisecond))
+		ptype, err := mqtt_recvpkt(rd, &flags, &body)
+		if err != nil {
+			Tracet(2, "mqtt: receive error addr=%s err=%s\n", mqtt.addr, err.Error())
+			return
+		}
+		Tracet(4, "mqtt: receive type=%d len=%d\n", ptype, len(body))
+
+		switch ptype {
+		case MQTT_PUBLISH:
+			qos := int(flags>>1) & 3
+			if len(body) < 2 {
+				return
+			}
+			i := 2 + (int(body[0])<<8 | int(body[1]))
+			if qos > 0 {
+				i += 2
+			}
+			if i > len(body) {
+				Tracet(2, "mqtt: publish length error len=%d\n", len(body))
+				return
+			}
+			pid := uint16(body[i-2])<<8 | uint16(body[i-1])
+			mqtt.lock.Lock()
+			if qos == 2 && mqtt.recvpid[pid] { /* duplicate before pubrel */
+				Tracet(3, "mqtt: duplicate message discarded pid=%d dup=%d\n", pid,
+					flags>>3&1)
+			} else {
+				if qos == 2 {
+					mqtt.recvpid[pid] = true
+				}
+				mqtt.buff = append(mqtt.buff, body[i:]...)
+				if n := len(mqtt.buff) - MQTT_BUFFSIZE; n > 0 { /* drop oldest */
+					mqtt.buff = mqtt.buff[n:]
+					mqtt.ndrop += uint32(n)
+				}
+				mqtt.nrecv++
+			}
+			mqtt.lock.Unlock()
+
+			if qos == 1 {
+				mqtt.sendpkt(sock, MQTT_PUBACK, 0, body[i-2:i])
+			} else if qos == 2 {
+				mqtt.sendpkt(sock, MQTT_PUBREC, 0, body[i-2:i])
+			}
+		case MQTT_PUBACK, MQTT_PUBCOMP: /* publish qos 1/2 */
+			if len(body) >= 2 {
+				mqtt.ackmsg(uint16(body[0])<<8|uint16(body[1]), ptype)
+			}
+		case MQTT_PUBREC: /* publish qos 2 */
+			if len(body) >= 2 {
+				mqtt.ackmsg(uint16(body[0])<<8|uint16(body[1]), ptype)
+				mqtt.sendpkt(sock, MQTT_PUBREL, 0x02, body[:2])
+			}
+		case MQTT_PUBREL: /* subscribe qos 2 */
+			if len(body) >= 2 {
+				mqtt.lock.Lock()
+				delete(mqtt.recvpid, uint16(body[0])<<8|uint16(body[1]))
+				mqtt.lock.Unlock()
+				mqtt.sendpkt(sock, MQTT_PUBCOMP, 0, body[:2])
+			}
+		case MQTT_SUBACK:
+			if len(body) >= 3 && body[2] == 0x80 {
+				Tracet(2, "mqtt: subscribe failure topic=%s\n", mqtt.topic)
+			}
+		}
+	}
+}
+
+/* mqtt thread ---------------------------------------------------------------*/
+func mqttthread(mqtt *MqttConn) {
+	var rd *bufio.Reader
+
+	Tracet(3, "mqttthread: addr=%s\n", mqtt.addr)
+
+	for {
+		sock := mqtt.connect(&rd)
+		if sock != nil {
+			mqtt.lock.Lock()
+			if mqtt.state == 0 { /* closed while connecting */
+				mqtt.lock.Unlock()
+				mqtt.sendpkt(sock, MQTT_DISCONNECT, 0, nil)
+				sock.Close()
+				return
+			}
+			mqtt.sock = sock
+			mqtt.state = 2
+			mqtt.ncon++
+			mqtt.lock.Unlock()
+
+			stop := make(chan struct{})
+			go func() { /* keep alive */
+				tick := time.NewTicker(time.Duration(mqtt.keepalive) * 500 * time.Millisecond)
+				defer tick.Stop()
+				for {
+					select {
+					case <-tick.C:
+						mqtt.sendpkt(sock, MQTT_PINGREQ, 0, nil)
+					case <-stop:
+						return
+					}
+				}
+			}()
+			mqtt.recvloop(sock, rd)
+			close(stop)
+
+			mqtt.lock.Lock()
+			mqtt.sock = nil
+			if mqtt.state > 0 {
+				mqtt.state = 1
+			}
+			mqtt.lock.Unlock()
+			sock.Close()
+		}
+		mqtt.lock.Lock()
+		tirecon := mqtt.tirecon
+		if tirecon <= 0 && mqtt.state > 0 { /* no reconnect */
+			mqtt.state = -1
+		}
+		mqtt.lock.Unlock()
+		if tirecon <= 0 {
+			Tracet(2, "mqttthread: disconnected without reconnect addr=%s\n", mqtt.addr)
+			return
+		}
+		select {
+		case <-mqtt.done:
+			return
+		case <-time.After(time.Duration(tirecon) * time.Millisecond):
+		}
+	}
+}
+
+/* open mqtt -------------------------------------------------------------------
+* open mqtt stream
+* args   : string path      I   mqtt path
+*                                 [user[:passwd]@]addr[:port]/topic[::opt...]
+*          int    mode      I   stream mode (STR_MODE_R: subscribe,
+*                                 STR_MODE_W: publish)
+*          string *msg      O   error message
+* return : mqtt stream (nil: error)
+* notes  : connection to the broker is established and re-established after
+*          the reconnect interval by the mqtt thread. with reconnect interval
+*          0, the thread exits after disconnection and the state is set to -1.
+*          published qos 1/2 messages are kept until acknowledged and resent on
+*          reconnect with a persistent session (clean session flag off). up to
+*          MQTT_MAXFLIGHT messages are kept and the oldest ones are dropped.
+*          received qos 2 messages are delivered once. the resent ones with the
+*          same packet identifier are discarded until pubrel received.
+*-----------------------------------------------------------------------------*/
+func OpenMqtt(path string, mode int, msg *string) *MqttConn {
+	mqtt := new(MqttConn)
+	if mqtt.Open(path, mode, msg) == 0 {
+		return nil
+	}
+	return mqtt
+}
+
+/* open mqtt port ------------------------------------------------------------*/
+func (mqtt *MqttConn) Open(path string, mode int, msg *string) int {
+	Tracet(3, "openmqtt: path=%s mode=%d\n", path, mode)
+
+	if mqtt.decodepath(path, msg) == 0 {
+		return 0
+	}
+	mqtt.mode = mode
+	mqtt.tirecon = ticonnect
+	mqtt.state = 1
+	mqtt.recvpid = make(map[uint16]bool)
+	mqtt.done = make(chan struct{})
+
+	go mqttthread(mqtt)
+	return 1
+}
+
+/* close mqtt ----------------------------------------------------------------*/
+func (mqtt *MqttConn) Close() {
+	mqtt.lock.Lock()
+	Tracet(3, "closemqtt: state=%d\n", mqtt.state)
+	sock := mqtt.sock
+	mqtt.state = 0
+	mqtt.lock.Unlock()
+
+	close(mqtt.done)
+	if sock != nil {
+		mqtt.sendpkt(sock, MQTT_DISCONNECT, 0, nil)
+		sock.Close()
+	}
+}
+
+/* read mqtt -----------------------------------------------------------------*/
+func (mqtt *MqttConn) Read(buff []byte, n int, msg *string) int {
+	Tracet(4, "readmqtt: n=%d\n", n)
+
+	mqtt.lock.Lock()
+	defer mqtt.lock.Unlock()
+
+	nr := copy(buff[:n], mqtt.buff)
+	mqtt.buff = mqtt.buff[nr:]
+	return nr
+}
+
+/* write mqtt (publish message) ----------------------------------------------*/
+func (mqtt *MqttConn) Write(buff []byte, n int, msg *string) int {
+	Tracet(4, "writemqtt: n=%d\n", n)
+
+	mqtt.lock.Lock()
+	sock := mqtt.sock
+	if sock == nil {
+		mqtt.lock.Unlock()
+		return 0
+	}
+	p := mqtt_putstr(nil, mqtt.topic)
+	if mqtt.qos > 0 {
+		p = mqtt_putu16(p, mqtt.newpid())
+	}
+	p = append(p, buff[:n]...)
+	if mqtt.qos > 0 {
+		mqtt.addmsg(mqtt.pid, p)
+	}
+	mqtt.nsend++
+	mqtt.lock.Unlock()
+
+	if mqtt.sendpkt(sock, MQTT_PUBLISH, byte(mqtt.qos<<1|mqtt.retain), p) == 0 {
+		*msg = "mqtt send error"
+		return 0
+	}
+	return n
+}
+
+/* get state mqtt ------------------------------------------------------------*/
+func (mqtt *MqttConn) State() int {
+	if mqtt == nil {
+		return 0
+	}
+	mqtt.lock.Lock()
+	defer mqtt.lock.Unlock()
+	return mqtt.state
+}
+
+/* get extended state mqtt ---------------------------------------------------*/
+func (mqtt *MqttConn) StateX(msg *string) int {
+	state := mqtt.State()
+
+	*msg += "mqtt:\n"
+	*msg += fmt.Sprintf("  state   = %d\n", state)
+	if state == 0 {
+		return 0
+	}
+	mqtt.lock.Lock()
+	defer mqtt.lock.Unlock()
+
+	*msg += fmt.Sprintf("  mode    = %d\n", mqtt.mode)
+	*msg += fmt.Sprintf("  addr    = %s\n", mqtt.addr)
+	*msg += fmt.Sprintf("  topic   = %s\n", mqtt.topic)
+	*msg += fmt.Sprintf("  id      = %s\n", mqtt.clientid)
+	*msg += fmt.Sprintf("  user    = %s\n", mqtt.user)
+	*msg += fmt.Sprintf("  qos     = %d\n", mqtt.qos)
+	*msg += fmt.Sprintf("  retain  = %d\n", mqtt.retain)
+	*msg += fmt.Sprintf("  tls     = %t\n", mqtt.conf != nil)
+	*msg += fmt.Sprintf("  ncon    = %d\n", mqtt.ncon)
+	*msg += fmt.Sprintf("  nrecv   = %d\n", mqtt.nrecv)
+	*msg += fmt.Sprintf("  nsend   = %d\n", mqtt.nsend)
+	*msg += fmt.Sprintf("  ndrop   = %d\n", mqtt.ndrop)
+	*msg += fmt.Sprintf("  buff    = %d\n", len(mqtt.buff))
+	*msg += fmt.Sprintf("  flight  = %d\n", len(mqtt.inflight))
+	return state
+}
@@ -75,6 +75,10 @@
 *                           use API Code2Idx() to get freq-index
 *                           use integer types in stdint.h
 *           2022/09/21 1.19 rewrite the file with golang
+*           2026/10/16 1.20 support short binary header
+*                           support message RAWIMUB, RAWIMUXB, RAWIMUSB and
+*                            RAWIMUSXB
+*                           add receiver option -IMUTYPE=type,-IMURATE=rate
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -90,10 +94,12 @@ const (
 	OEM4SYNC1 = 0xAA /* oem7/6/4 message start sync code 1 */
 	OEM4SYNC2 = 0x44 /* oem7/6/4 message start sync code 2 */
 	OEM4SYNC3 = 0x12 /* oem7/6/4 message start sync code 3 */
+	OEM4SYNCS = 0x13 /* oem7/6/4 short header message sync code 3 */
 	OEM3SYNC1 = 0xAA /* oem3 message start sync code 1 */
 	OEM3SYNC2 = 0x44 /* oem3 message start sync code 2 */
 	OEM3SYNC3 = 0x11 /* oem3 message start sync code 3 */
 	OEM4HLEN  = 28   /* oem7/6/4 message header length (bytes) */
+	OEM4SHLEN = 12   /* oem7/6/4 short message header length (bytes) */
 	OEM3HLEN  = 12   /* oem3 message header length (bytes) */
 
 	/* message IDs */
@@ -112,6 +118,10 @@ const (
 	ID_QZSSIONUTC      = 1347 /* oem7/6 qzss ion/utc parameters */
 	ID_BDSEPHEMERIS    = 1696 /* oem7/6 decoded bds ephemeris */
 	ID_NAVICEPHEMERIS  = 2123 /* oem7 decoded navic ephemeris */
+	ID_RAWIMU          = 268  /* oem7/6 raw imu data */
+	ID_RAWIMUS         = 325  /* oem7/6 raw imu data (short header) */
+	ID_RAWIMUX         = 1461 /* oem7/6 raw imu data extended */
+	ID_RAWIMUSX        = 1462 /* oem7/6 raw imu data extended (short header) */
 
 	ID_ALMB = 18 /* oem3 decoded almanac */
 	ID_IONB = 16 /* oem3 iono parameters */
@@ -1707,6 +1717,101 @@ func decode_utcb(raw *Raw) int {
 	return 9
 }
 
+/* imu parameters table ------------------------------------------------------*/
+var imutbl = []struct {
+	name        string  /* imu type name */
+	id          int     /* imu type id in RAWIMUX */
+	rate        float64 /* data rate (Hz) */
+	sacc, sgyro float64 /* scale factors of acc (m/s/LSB) and gyro (rad/LSB) */
+}{
+	{"HG1700AG58", 11, 100.0, 0.3048 / 134217728.0, 1.0 / 8589934592.0},
+	{"HG1700AG62", 12, 100.0, 0.3048 / 67108864.0, 1.0 / 8589934592.0},
+	{"LN200", 8, 200.0, 1.0 / 16384.0, 1.0 / 524288.0},
+	{"ISA100C", 26, 200.0, 2.0e-8, 1.0e-9},
+	{"ADIS16488", 31, 200.0, 200.0 / 2147483648.0, 720.0 / 2147483648.0 * D2R},
+	{"STIM300", 32, 125.0, 1.0 / 4194304.0, 1.0 / 2097152.0 * D2R},
+	{"KVH1750", 33, 200.0, 0.05 / 32768.0, 0.1 / (3600.0 * 256.0) * D2R},
+}
+
+/* decode RAWIMUB, RAWIMUXB, RAWIMUSB, RAWIMUSXB -----------------------------*/
+func decode_rawimub(raw *Raw, hlen int, ext bool) int {
+	var (
+		data           ImuD
+		inc            [6]float64
+		imutype        string
+		rate           float64
+		idx            = hlen
+		i, k, week, id int
+	)
+	if raw.Len < hlen+40 {
+		Trace(2, "oem4 rawimub length error: len=%d\n", raw.Len)
+		return -1
+	}
+	if ext {
+		id = int(U1(raw.Buff[idx+1:]))
+		week = int(U2L(raw.Buff[idx+2:]))
+	} else {
+		week = int(U4L(raw.Buff[idx:]))
+	}
+	if week == 0 {
+		return 0
+	}
+	data.Time = GpsT2Time(AdjGpsWeek(week), R8L(raw.Buff[idx+4:]))
+	data.Stat = int(U4L(raw.Buff[idx+12:]))
+
+	/* imu type and data rate */
+	if q := strings.Index(raw.Opt, "-IMUTYPE="); q >= 0 {
+		fmt.Sscanf(raw.Opt[q:], "-IMUTYPE=%s", &imutype)
+	}
+	for i, k = 0, 0; i < len(imutbl); i++ {
+		if strings.EqualFold(imutbl[i].name, imutype) || (len(imutype) == 0 && imutbl[i].id == id) {
+			k = i
+			break
+		}
+	}
+	rate = imutbl[k].rate
+	if q := strings.Index(raw.Opt, "-IMURATE="); q >= 0 {
+		fmt.Sscanf(raw.Opt[q:], "-IMURATE=%f", &rate)
+	}
+	/* {z,-y,x} increments in imu frame */
+	for i = 0; i < 6; i++ {
+		inc[i] = float64(I4L(raw.Buff[idx+16+i*4:]))
+	}
+	/* imu frame {right,forward,up} to body frame {forward,right,down} */
+	data.Acc[0] = -inc[1] * imutbl[k].sacc * rate
+	data.Acc[1] = inc[2] * imutbl[k].sacc * rate
+	data.Acc[2] = -inc[0] * imutbl[k].sacc * rate
+	data.Gyro[0] = -inc[4] * imutbl[k].sgyro * rate
+	data.Gyro[1] = inc[5] * imutbl[k].sgyro * rate
+	data.Gyro[2] = -inc[3] * imutbl[k].sgyro * rate
+
+	raw.ImuData.Data = append(raw.ImuData.Data[:0], data)
+	return 11
+}
+
+/* decode NovAtel OEM4/V/6/7 message with short header -----------------------*/
+func decode_oem4s(raw *Raw) int {
+	ctype := U2L(raw.Buff[4:])
+
+	Trace(3, "decode_oem4s: type=%3d len=%d\n", ctype, raw.Len)
+
+	/* check crc32 */
+	if Rtk_CRC32(raw.Buff[:], raw.Len) != U4L(raw.Buff[raw.Len:]) {
+		Trace(2, "oem4 crc error: type=%3d len=%d\n", ctype, raw.Len)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("OEM4 %4d (%4d):", ctype, raw.Len)))
+	}
+	switch ctype {
+	case ID_RAWIMUS:
+		return decode_rawimub(raw, OEM4SHLEN, false)
+	case ID_RAWIMUSX:
+		return decode_rawimub(raw, OEM4SHLEN, true)
+	}
+	return 0
+}
+
 /* decode NovAtel OEM4/V/6/7 message -----------------------------------------*/
 func decode_oem4(raw *Raw) int {
 	var (
@@ -1714,6 +1819,9 @@ func decode_oem4(raw *Raw) int {
 		tstr            string
 		msg, stat, week int
 	)
+	if raw.Buff[2] == OEM4SYNCS {
+		return decode_oem4s(raw)
+	}
 	ctype := U2L(raw.Buff[4:])
 
 	Trace(3, "decode_oem4: type=%3d len=%d\n", ctype, raw.Len)
@@ -1774,6 +1882,10 @@ func decode_oem4(raw *Raw) int {
 		return decode_bdsephemerisb(raw)
 	case ID_NAVICEPHEMERIS:
 		return decode_navicephemerisb(raw)
+	case ID_RAWIMU:
+		return decode_rawimub(raw, OEM4HLEN, false)
+	case ID_RAWIMUX:
+		return decode_rawimub(raw, OEM4HLEN, true)
 	}
 	return 0
 }
@@ -1814,7 +1926,8 @@ func sync_oem4(buff []uint8, data uint8) int {
 	buff[0] = buff[1]
 	buff[1] = buff[2]
 	buff[2] = data
-	if buff[0] == OEM4SYNC1 && buff[1] == OEM4SYNC2 && buff[2] == OEM4SYNC3 {
+	if buff[0] == OEM4SYNC1 && buff[1] == OEM4SYNC2 && (buff[2] == OEM4SYNC3 ||
+		buff[2] == OEM4SYNCS) {
 		return 1
 	}
 	return 0
@@ -1835,7 +1948,7 @@ func sync_oem3(buff []uint8, data uint8) int {
 *          uint8_t data     I   stream data (1 byte)
 * return : status (-1: error message, 0: no message, 1: input observation data,
 *                  2: input ephemeris, 3: input sbas message,
-*                  9: input ion/utc parameter)
+*                  9: input ion/utc parameter, 11: input imu data)
 *
 * notes  : to specify input options for oem4, set raw.Opt to the following
 *          option strings separated by spaces.
@@ -1853,6 +1966,13 @@ func sync_oem3(buff []uint8, data uint8) int {
 *          -GALINAV: select I/NAV for Galileo ephemeris (default: all)
 *          -GALFNAV: select F/NAV for Galileo ephemeris (default: all)
 *          -GLOBIAS=bias: GLONASS code-phase bias (m)
+*          -IMUTYPE=type: imu type of RAWIMU (HG1700AG58,HG1700AG62,LN200,
+*                         ISA100C,ADIS16488,STIM300,KVH1750)
+*                         (default: by RAWIMUX imu type or HG1700AG58)
+*          -IMURATE=rate: imu data rate (Hz) (default: by imu type)
+*
+*          imu data are converted from the imu frame {right,forward,up} to
+*          the body frame {forward,right,down}.
 *-----------------------------------------------------------------------------*/
 func Input_oem4(raw *Raw, data uint8) int {
 	Trace(5, "input_oem4: data=%02x\n", data)
@@ -1866,7 +1986,11 @@ func Input_oem4(raw *Raw, data uint8) int {
 	}
 	raw.Buff[raw.NumByte] = data
 	raw.NumByte++
-	raw.Len = int(U2L(raw.Buff[8:])) + OEM4HLEN
+	if raw.Buff[2] == OEM4SYNCS {
+		raw.Len = int(U1(raw.Buff[3:])) + OEM4SHLEN
+	} else {
+		raw.Len = int(U2L(raw.Buff[8:])) + OEM4HLEN
+	}
 	if raw.NumByte == 10 && raw.Len > MAXRAWLEN-4 {
 		Trace(2, "oem4 length error: len=%d\n", raw.Len)
 		raw.NumByte = 0
@@ -1946,7 +2070,12 @@ func input_oem4f(raw *Raw, fp *os.File) int {
 	}
 	raw.NumByte = 10
 
-	if raw.Len = int(U2L(raw.Buff[8:])) + OEM4HLEN; raw.Len > MAXRAWLEN-4 {
+	if raw.Buff[2] == OEM4SYNCS {
+		raw.Len = int(U1(raw.Buff[3:])) + OEM4SHLEN
+	} else {
+		raw.Len = int(U2L(raw.Buff[8:])) + OEM4HLEN
+	}
+	if raw.Len > MAXRAWLEN-4 {
 		Trace(2, "oem4 length error: len=%d\n", raw.Len)
 		raw.NumByte = 0
 		return -1
@@ -28,6 +28,14 @@
 *                             pos1-tropopt, pos1-sateph, pos1-navsys,
 *                             pos2-gloarmode,
 *		    2022/05/31 1.0  rewrite options.c with golang by fxb
+*           2026/10/16  1.1  add pos2-armode=wlnl,tcar
+*                            add pos2-arpartial, pos2-arminamb
+*                            add ins-mode, ins-lever*, ins-*noise, ins-*bias,
+*                                ins-maxdr, file-imufile
+*                            add out-outatt, out-nmeasen
+*                            add pos2-codesmooth
+*                            add pos1-ionoopt=stec, pos1-tropopt=ztd,
+*                                file-gridfile
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -56,10 +64,11 @@ var (
 	MODOPT  string = "0:single,1:dgps,2:kinematic,3:static,4:movingbase,5:fixed,6:ppp-kine,7:ppp-static,8:ppp-fixed"
 	FRQOPT  string = "1:l1,2:l1+l2,3:l1+l2+l5,4:l1+l5"
 	TYPOPT  string = "0:forward,1:backward,2:combined"
-	IONOPT  string = "0:off,1:brdc,2:sbas,3:dual-freq,4:est-stec,5:ionex-tec,6:qzs-brdc"
-	TRPOPT  string = "0:off,1:saas,2:sbas,3:est-ztd,4:est-ztdgrad"
+	IONOPT  string = "0:off,1:brdc,2:sbas,3:dual-freq,4:est-stec,5:ionex-tec,6:qzs-brdc,8:stec"
+	TRPOPT  string = "0:off,1:saas,2:sbas,3:est-ztd,4:est-ztdgrad,5:ztd"
 	EPHOPT  string = "0:brdc,1:precise,2:brdc+sbas,3:brdc+ssrapc,4:brdc+ssrcom"
 	NAVOPT  string = "1:gps+2:sbas+4:glo+8:gal+16:qzs+32:bds+64:navic"
+	NMEAOPT string = "0:default,1:rmc+2:gga+4:gsa+8:gsv+16:gst+32:vtg+64:zda+128:gns+256:gbs+512:hdt+1024:pashr"
 	GAROPT  string = "0:off,1:on"
 	SOLOPT  string = "0:llh,1:xyz,2:enu,3:nmea"
 	TSYOPT  string = "0:gpst,1:utc,2:jst"
@@ -69,10 +78,12 @@ var (
 	GEOOPT  string = "0:internal,1:egm96,2:egm08_2.5,3:egm08_1,4:gsi2000"
 	STAOPT  string = "0:all,1:single"
 	STSOPT  string = "0:off,1:state,2:residual"
-	ARMOPT  string = "0:off,1:continuous,2:instantaneous,3:fix-and-hold"
+	ARMOPT  string = "0:off,1:continuous,2:instantaneous,3:fix-and-hold,4:wlnl,5:tcar"
+	PAROPT  string = "0:off,1:elevation,2:lock"
 	POSOPT  string = "0:llh,1:xyz,2:single,3:posfile,4:rinexhead,5:rtcm,6:raw"
 	TIDEOPT string = "0:off,1:on,2:otl"
 	PHWOPT  string = "0:off,1:on,2:precise"
+	INSOPT  string = "0:off,1:lc"
 )
 
 var SysOpts map[string]*Opt = map[string]*Opt{
@@ -110,6 +121,8 @@ var SysOpts map[string]*Opt = map[string]*Opt{
 	"pos2-arelmask":    {"pos2-arelmask", 1, nil, &elmaskar_, nil, "deg"},
 	"pos2-arminfix":    {"pos2-arminfix", 0, &prcopt_.MinFix, nil, nil, ""},
 	"pos2-armaxiter":   {"pos2-armaxiter", 0, &prcopt_.ArMaxIter, nil, nil, ""},
+	"pos2-arpartial":   {"pos2-arpartial", 3, &prcopt_.ArPartial, nil, nil, PAROPT},
+	"pos2-arminamb":    {"pos2-arminamb", 0, &prcopt_.ArMinAmb, nil, nil, ""},
 	"pos2-elmaskhold":  {"pos2-elmaskhold", 1, nil, &elmaskhold_, nil, "deg"},
 	"pos2-aroutcnt":    {"pos2-aroutcnt", 0, &prcopt_.MaxOut, nil, nil, ""},
 	"pos2-maxage":      {"pos2-maxage", 1, nil, &prcopt_.MaxTmDiff, nil, "s"},
@@ -118,12 +131,14 @@ var SysOpts map[string]*Opt = map[string]*Opt{
 	"pos2-rejionno":    {"pos2-rejionno", 1, nil, &prcopt_.MaxInno, nil, "m"},
 	"pos2-rejgdop":     {"pos2-rejgdop", 1, nil, &prcopt_.MaxGdop, nil, ""},
 	"pos2-niter":       {"pos2-niter", 0, &prcopt_.NoIter, nil, nil, ""},
+	"pos2-codesmooth":  {"pos2-codesmooth", 0, &prcopt_.CodeSmooth, nil, nil, ""},
 	"pos2-baselen":     {"pos2-baselen", 1, nil, &prcopt_.Baseline[0], nil, "m"},
 	"pos2-basesig":     {"pos2-basesig", 1, nil, &prcopt_.Baseline[1], nil, "m"},
 	"out-solformat":    {"out-solformat", 3, &solopt_.Posf, nil, nil, SOLOPT},
 	"out-outhead":      {"out-outhead", 3, &solopt_.OutHead, nil, nil, SWTOPT},
 	"out-outopt":       {"out-outopt", 3, &solopt_.OutOpt, nil, nil, SWTOPT},
 	"out-outvel":       {"out-outvel", 3, &solopt_.OutVel, nil, nil, SWTOPT},
+	"out-outatt":       {"out-outatt", 3, &solopt_.OutAtt, nil, nil, SWTOPT},
 	"out-timesys":      {"out-timesys", 3, &solopt_.TimeS, nil, nil, TSYOPT},
 	"out-timeform":     {"out-timeform", 3, &solopt_.TimeF, nil, nil, TFTOPT},
 	"out-timendec":     {"out-timendec", 0, &solopt_.TimeU, nil, nil, ""},
@@ -136,6 +151,7 @@ var SysOpts map[string]*Opt = map[string]*Opt{
 	"out-solstatic":    {"out-solstatic", 3, &solopt_.SolStatic, nil, nil, STAOPT},
 	"out-nmeaintv1":    {"out-nmeaintv1", 1, nil, &solopt_.NmeaIntv[0], nil, "s"},
 	"out-nmeaintv2":    {"out-nmeaintv2", 1, nil, &solopt_.NmeaIntv[1], nil, "s"},
+	"out-nmeasen":      {"out-nmeasen", 0, &solopt_.NmeaSen, nil, nil, NMEAOPT},
 	"out-outstat":      {"out-outstat", 3, &solopt_.SStat, nil, nil, STSOPT},
 	"stats-eratio1":    {"stats-eratio1", 1, nil, &prcopt_.eratio[0], nil, ""},
 	"stats-eratio2":    {"stats-eratio2", 1, nil, &prcopt_.eratio[1], nil, ""},
@@ -176,6 +192,15 @@ var SysOpts map[string]*Opt = map[string]*Opt{
 	"misc-rnxopt1":     {"misc-rnxopt1", 2, nil, nil, &prcopt_.RnxOpt[0], ""},
 	"misc-rnxopt2":     {"misc-rnxopt2", 2, nil, nil, &prcopt_.RnxOpt[1], ""},
 	"misc-pppopt":      {"misc-pppopt", 2, nil, nil, &prcopt_.PPPOpt, ""},
+	"ins-mode":         {"ins-mode", 3, &prcopt_.InsOpt.Mode, nil, nil, INSOPT},
+	"ins-leverx":       {"ins-leverx", 1, nil, &prcopt_.InsOpt.Lever[0], nil, "m"},
+	"ins-levery":       {"ins-levery", 1, nil, &prcopt_.InsOpt.Lever[1], nil, "m"},
+	"ins-leverz":       {"ins-leverz", 1, nil, &prcopt_.InsOpt.Lever[2], nil, "m"},
+	"ins-accnoise":     {"ins-accnoise", 1, nil, &prcopt_.InsOpt.AccNoise, nil, "m/s^2/sqrt(Hz)"},
+	"ins-gyronoise":    {"ins-gyronoise", 1, nil, &prcopt_.InsOpt.GyroNoise, nil, "rad/s/sqrt(Hz)"},
+	"ins-accbias":      {"ins-accbias", 1, nil, &prcopt_.InsOpt.AccBias, nil, "m/s^3/sqrt(Hz)"},
+	"ins-gyrobias":     {"ins-gyrobias", 1, nil, &prcopt_.InsOpt.GyroBias, nil, "rad/s^2/sqrt(Hz)"},
+	"ins-maxdr":        {"ins-maxdr", 1, nil, &prcopt_.InsOpt.MaxDr, nil, "s"},
 	"file-satantfile":  {"file-satantfile", 2, nil, nil, &filopt_.SatAntPara, ""},
 	"file-rcvantfile":  {"file-rcvantfile", 2, nil, nil, &filopt_.RcvAntPara, ""},
 	"file-staposfile":  {"file-staposfile", 2, nil, nil, &filopt_.StaPos, ""},
@@ -187,7 +212,9 @@ var SysOpts map[string]*Opt = map[string]*Opt{
 	"file-tempdir":     {"file-tempdir", 2, nil, nil, &filopt_.TempDir, ""},
 	"file-geexefile":   {"file-geexefile", 2, nil, nil, &filopt_.GeExe, ""},
 	"file-solstatfile": {"file-solstatfile", 2, nil, nil, &filopt_.SolStat, ""},
-	"file-tracefile":   {"file-tracefile", 2, nil, nil, &filopt_.Trace, ""}}
+	"file-tracefile":   {"file-tracefile", 2, nil, nil, &filopt_.Trace, ""},
+	"file-imufile":     {"file-imufile", 2, nil, nil, &filopt_.Imu, ""},
+	"file-gridfile":    {"file-gridfile", 2, nil, nil, &filopt_.Grid, ""}}
 
 /* discard space characters at tail ------------------------------------------*/
 func options_chop(buff *string) {
@@ -586,6 +613,7 @@ func ResetSysOpts() {
 	filopt_.Blq = ""
 	filopt_.SolStat = ""
 	filopt_.Trace = ""
+	filopt_.Imu = ""
 	for i := 0; i < 2; i++ {
 		antpostype_[i] = 0
 	}
@@ -24,6 +24,8 @@
 *                           use API sat2freq() to get carrier frequency
 *                           add output of velocity estimation error in estvel()
 *		    2022/05/31 1.0  rewrite pntpos.c with golang by fxb
+*           2026/10/16 1.1  add signal bias (SINEX-BIAS) correction in prange()
+*           2026/10/16 1.2  add clas stec and tropos corrections
 *-----------------------------------------------------------------------------*/
 
 package gnssgo
@@ -115,7 +117,8 @@ func snrmask(obs *ObsD, azel []float64, opt *PrcOpt) int {
 func Prange(obs *ObsD, nav *Nav, opt *PrcOpt, vari *float64) float64 {
 	var (
 		P1, P2, gamma, b1, b2 float64
-		sat, sys              int
+		f1, f2                float64
+		sat, sys, i2          int
 		code1, code2          uint8
 	)
 	sat = int(obs.Sat)
@@ -126,9 +129,11 @@ func Prange(obs *ObsD, nav *Nav, opt *PrcOpt, vari *float64) float64 {
 	case obs.Code[1] != 0:
 		P2 = obs.P[1]
 		code2 = obs.Code[1]
+		i2 = 1
 	case obs.Code[2] != 0:
 		P2 = obs.P[2]
 		code2 = obs.Code[2]
+		i2 = 2
 	}
 	//P2 = obs.P[1]
 	*vari = 0.0
@@ -137,6 +142,21 @@ func Prange(obs *ObsD, nav *Nav, opt *PrcOpt, vari *float64) float64 {
 		return 0.0
 	}
 
+	/* signal bias correction (SINEX-BIAS) instead of TGD/BGD and DCB */
+	if nav.ObsBias(obs, 0, 0, &b1) > 0 &&
+		(opt.IonoOpt != IONOOPT_IFLC || nav.ObsBias(obs, i2, 0, &b2) > 0) {
+		if opt.IonoOpt != IONOOPT_IFLC {
+			return P1 - b1
+		}
+		f1 = Sat2Freq(sat, code1, nav)
+		f2 = Sat2Freq(sat, code2, nav)
+		if f1 == 0.0 || f2 == 0.0 {
+			return 0.0
+		}
+		gamma = SQR(f1 / f2)
+		return ((P2 - b2) - gamma*(P1-b1)) / (1.0 - gamma)
+	}
+
 	/* P1-C1,P2-C2 DCB correction */
 	if sys == SYS_GPS || sys == SYS_GLO {
 		if code1 == CODE_L1C {
@@ -250,6 +270,10 @@ func (nav *Nav) IonoCorr(time Gtime, sat int, pos, azel []float64, ionoopt int,
 	if ionoopt == IONOOPT_TEC {
 		return nav.IonTec(time, pos, azel, 1, ion, vari)
 	}
+	/* CLAS STEC model */
+	if ionoopt == IONOOPT_STEC {
+		return nav.CssrIonCorr(time, sat, pos, ion, vari)
+	}
 	/* QZSS broadcast ionosphere model */
 	if ionoopt == IONOOPT_QZS && Norm(nav.Ion_qzs[:], 8) > 0.0 {
 		*ion = IonModel(time, nav.Ion_qzs[:], pos, azel)
@@ -292,6 +316,10 @@ func (nav *Nav) TropCorr(time Gtime, pos, azel []float64, tropopt int, trp, vari
 		*trp = SbsTropCorr(time, pos, azel, vari)
 		return 1
 	}
+	/* CLAS tropos grid correction */
+	if tropopt == TROPOPT_ZTD {
+		return nav.CssrTropCorr(time, pos, azel, trp, vari)
+	}
 	/* no correction */
 	*trp = 0.0
 	*vari = 0.0
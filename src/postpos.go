@@ -44,6 +44,14 @@
 *                            delete function to use L2 instead of L5 PCV
 *                            writing solution file in binary mode
 *		    2022/05/31 1.0  rewrite postpos.c with golang by fxb
+*           2026/10/16 1.1  support gnss/ins integration with imu data file
+*           2026/10/16 1.2  support QZSS L6 file (*.l6) as ssr corrections and
+*                           clas grid definition file
+*           2026/10/16 1.3  output solution status file by each session
+*                           open debug trace and geoid by one session at a time
+*           2026/10/16 1.4  reject ins with backward/combined solution
+*           2026/10/16 1.5  select clas network ssr corrections by rover
+*                           position
 *-----------------------------------------------------------------------------*/
 
 package gnssgo
@@ -54,54 +62,73 @@ import (
 	"math"
 	"os"
 	"strings"
+	"sync"
 )
 
 const MAXPRCDAYS int = 100 /* max days of continuous processing */
 const MAXINFILE int = 1000 /* max number of input files */
 
-/* constants/global variables ------------------------------------------------*/
-
-var (
-	pcvss     Pcvs            /* receiver antenna parameters */
-	pcvsr     Pcvs            /* satellite antenna parameters */
-	obss      Obs             /* observation data */
-	navs      Nav             /* navigation data */
-	sbss      Sbs             /* sbas messages */
-	stas      [MAXRCV]Sta     /* station infomation */
-	nepoch    int         = 0 /* number of observation epochs */
-	iobsu     int         = 0 /* current rover observation data index */
-	iobsr     int         = 0 /* current reference observation data index */
-	isbs      int         = 0 /* current sbas message index */
-	revs      int         = 0 /* analysis direction (0:forward,1:backward) */
-	aborts    int         = 0 /* abort status */
-	solf      []Sol           /* forward solutions */
-	solb      []Sol           /* backward solutions */
-	rbf       []float64       /* forward base positions */
-	rbb       []float64       /* backward base positions */
-	isolf     int         = 0 /* current forward solutions index */
-	isolb     int         = 0 /* current backward solutions index */
-	proc_rov  string          /* rover for current processing */
-	proc_base string          /* base station for current processing */
-	rtcm_file string          /* rtcm data file */
-	rtcm_path string          /* rtcm data path */
-	rtcm      *Rtcm           /* rtcm control struct */
-	fp_rtcm   *os.File    = nil /* rtcm data file pointer */)
+var postshared struct { /* debug trace and geoid data shared by sessions */
+	lock         sync.Mutex
+	trace, geoid *PostProcessor /* sessions opening debug trace and geoid data */
+}
+
+/* new post-processing session ------------------------------------------------
+* generate new post-processing session
+* args   : none
+* return : post-processing session
+* notes  : messages are shown by ShowMsg_Ptr. set PostProcessor.ShowMsg to show
+*          messages and check break of the session by another function.
+*-----------------------------------------------------------------------------*/
+func NewPostProcessor() *PostProcessor {
+	return new(PostProcessor)
+}
+
+/* acquire debug trace or geoid data shared in the process -------------------*/
+func (p *PostProcessor) acquire(owner **PostProcessor) int {
+	postshared.lock.Lock()
+	defer postshared.lock.Unlock()
+
+	if *owner != nil && *owner != p {
+		return 0
+	}
+	*owner = p
+	return 1
+}
+
+/* release debug trace or geoid data shared in the process -------------------*/
+func (p *PostProcessor) release(owner **PostProcessor) {
+	postshared.lock.Lock()
+	defer postshared.lock.Unlock()
+
+	if *owner == p {
+		*owner = nil
+	}
+}
+
+/* show message --------------------------------------------------------------*/
+func (p *PostProcessor) showmsg(format string, v ...interface{}) int {
+	if p.ShowMsg != nil {
+		return p.ShowMsg(format, v...)
+	}
+	return ShowMsg_Ptr(format, v...)
+}
 
 /* show message and check break ----------------------------------------------*/
-func checkbrk(format string, v ...interface{}) int {
-	buff := fmt.Sprintf(format, v...)
+func (p *PostProcessor) checkbrk(format string, v ...interface{}) int {
 	if len(format) == 0 {
-		ShowMsg_Ptr("")
+		return p.showmsg("")
 	}
+	buff := fmt.Sprintf(format, v...)
 	switch {
-	case len(proc_rov) > 0 && len(proc_base) > 0:
-		buff += fmt.Sprintf(" (%s-%s)", proc_rov, proc_base)
-	case len(proc_rov) > 0:
-		buff += fmt.Sprintf(" (%s)", proc_rov)
-	case len(proc_base) > 0:
-		buff += fmt.Sprintf(" (%s)", proc_base)
-	}
-	return ShowMsg_Ptr(buff)
+	case len(p.ProcRov) > 0 && len(p.ProcBase) > 0:
+		buff += fmt.Sprintf(" (%s-%s)", p.ProcRov, p.ProcBase)
+	case len(p.ProcRov) > 0:
+		buff += fmt.Sprintf(" (%s)", p.ProcRov)
+	case len(p.ProcBase) > 0:
+		buff += fmt.Sprintf(" (%s)", p.ProcBase)
+	}
+	return p.showmsg("%s", buff)
 }
 
 /* output reference position -------------------------------------------------*/
@@ -130,7 +157,7 @@ func OutRefPos(fp *os.File, r []float64, opt *SolOpt) {
 }
 
 /* output header -------------------------------------------------------------*/
-func OutHeader(fp *os.File, file []string, n int, popt *PrcOpt, sopt *SolOpt) {
+func (p *PostProcessor) OutHeader(fp *os.File, file []string, n int, popt *PrcOpt, sopt *SolOpt) {
 	var (
 		s1           []string = []string{"GPST", "UTC", "JST"}
 		ts, te       Gtime
@@ -153,13 +180,13 @@ func OutHeader(fp *os.File, file []string, n int, popt *PrcOpt, sopt *SolOpt) {
 		for i = 0; i < n; i++ {
 			fmt.Fprintf(fp, "%s inp file  : %s\n", COMMENTH, file[i])
 		}
-		for i = 0; i < obss.N(); i++ {
-			if obss.Data[i].Rcv == 1 {
+		for i = 0; i < p.ObsData.N(); i++ {
+			if p.ObsData.Data[i].Rcv == 1 {
 				break
 			}
 		}
-		for j = obss.N() - 1; j >= 0; j-- {
-			if obss.Data[j].Rcv == 1 {
+		for j = p.ObsData.N() - 1; j >= 0; j-- {
+			if p.ObsData.Data[j].Rcv == 1 {
 				break
 			}
 		}
@@ -167,8 +194,8 @@ func OutHeader(fp *os.File, file []string, n int, popt *PrcOpt, sopt *SolOpt) {
 			fmt.Fprintf(fp, "\n%s no rover obs data\n", COMMENTH)
 			return
 		}
-		ts = obss.Data[i].Time
-		te = obss.Data[j].Time
+		ts = p.ObsData.Data[i].Time
+		te = p.ObsData.Data[j].Time
 		t1 = Time2GpsT(ts, &w1)
 		t2 = Time2GpsT(te, &w2)
 		if sopt.TimeS >= 1 {
@@ -235,169 +262,178 @@ func (obs *Obs) NextObsb(i *int, rcv int) int {
 }
 
 /* update rtcm ssr correction ------------------------------------------------*/
-func UpdateRtcmSsr(time Gtime) {
+func (p *PostProcessor) UpdateRtcmSsr(time Gtime, rr []float64) {
 	var path string
 
 	/* open or swap rtcm file */
-	RepPath(rtcm_file, &path, time, "", "")
+	RepPath(p.RtcmFile, &path, time, "", "")
+
+	/* QZSS L6 frames (*.l6) or rtcm 3 messages */
+	input := p.RtcmCtrl.InputRtcm3f
+	if strings.HasSuffix(strings.ToLower(path), ".l6") {
+		input = p.RtcmCtrl.InputL6f
+	}
 
-	if strings.Compare(path, rtcm_path) != 0 {
-		rtcm_path = path
+	if strings.Compare(path, p.RtcmPath) != 0 {
+		p.RtcmPath = path
 
-		if fp_rtcm != nil {
-			fp_rtcm.Close()
+		if p.FpRtcm != nil {
+			p.FpRtcm.Close()
 		}
-		fp_rtcm, _ = os.OpenFile(path, os.O_RDONLY, 0666)
-		if fp_rtcm != nil {
-			rtcm.Time = time
-			rtcm.InputRtcm3f(fp_rtcm)
+		p.FpRtcm, _ = os.OpenFile(path, os.O_RDONLY, 0666)
+		if p.FpRtcm != nil {
+			p.RtcmCtrl.Time = time
+			input(p.FpRtcm)
 			Trace(2, "rtcm file open: %s\n", path)
 		}
 	}
-	if fp_rtcm == nil {
+	if p.FpRtcm == nil {
 		return
 	}
 
 	/* read rtcm file until current time */
-	for TimeDiff(rtcm.Time, time) < 1e-3 {
+	for TimeDiff(p.RtcmCtrl.Time, time) < 1e-3 {
 
-		if rtcm.InputRtcm3f(fp_rtcm) < -1 {
+		if input(p.FpRtcm) < -1 {
 			break
 		}
+		p.NavData.UpdateCssrAtm(&p.RtcmCtrl.NavData)
 
 		/* update ssr corrections */
 		for i := 0; i < MAXSAT; i++ {
-			if rtcm.Ssr[i].Update == 0 ||
-				rtcm.Ssr[i].Iod[0] != rtcm.Ssr[i].Iod[1] ||
-				TimeDiff(time, rtcm.Ssr[i].T0[0]) < -1e-3 {
+			if p.RtcmCtrl.Ssr[i].Update == 0 ||
+				p.RtcmCtrl.Ssr[i].Iod[0] != p.RtcmCtrl.Ssr[i].Iod[1] ||
+				TimeDiff(time, p.RtcmCtrl.Ssr[i].T0[0]) < -1e-3 {
 				continue
 			}
-			navs.Ssr[i] = rtcm.Ssr[i]
-			rtcm.Ssr[i].Update = 0
+			p.NavData.Ssr[i] = p.RtcmCtrl.Ssr[i]
+			p.RtcmCtrl.Ssr[i].Update = 0
 		}
 	}
+	/* clas network ssr corrections by rover position */
+	p.NavData.CssrSelSsr(time, rr)
 }
 
 /* input obs data, navigation messages and sbas correction -------------------*/
-func InputObs(obs []ObsD, solq int, popt *PrcOpt) int {
+func (p *PostProcessor) InputObs(obs []ObsD, solq int, rr []float64, popt *PrcOpt) int {
 	var (
 		time         Gtime
 		i, nu, nr, n int
 	)
 
-	Trace(4, "infunc  : revs=%d iobsu=%d iobsr=%d isbs=%d\n", revs, iobsu, iobsr, isbs)
+	Trace(4, "infunc  : p.Revs=%d p.IObsU=%d p.IObsR=%d p.ISbs=%d\n", p.Revs, p.IObsU, p.IObsR, p.ISbs)
 
-	if 0 <= iobsu && iobsu < obss.N() {
-		time = obss.Data[iobsu].Time
+	if 0 <= p.IObsU && p.IObsU < p.ObsData.N() {
+		time = p.ObsData.Data[p.IObsU].Time
 		// settime(time);
-		if checkbrk("processing : %s Q=%d", TimeStr(time, 0), solq) > 0 {
-			aborts = 1
-			ShowMsg_Ptr("aborted")
+		if p.checkbrk("processing : %s Q=%d", TimeStr(time, 0), solq) > 0 {
+			p.Aborts = 1
+			p.showmsg("aborted")
 			return -1
 		}
 	}
-	if revs == 0 { /* input forward data */
-		if nu = obss.NextObsf(&iobsu, 1); nu <= 0 {
+	if p.Revs == 0 { /* input forward data */
+		if nu = p.ObsData.NextObsf(&p.IObsU, 1); nu <= 0 {
 			return -1
 		}
 		if popt.IntPref > 0 {
-			for nr = obss.NextObsf(&iobsr, 2); nr > 0; {
-				if TimeDiff(obss.Data[iobsr].Time, obss.Data[iobsu].Time) > -float64(DTTOL) {
+			for nr = p.ObsData.NextObsf(&p.IObsR, 2); nr > 0; {
+				if TimeDiff(p.ObsData.Data[p.IObsR].Time, p.ObsData.Data[p.IObsU].Time) > -float64(DTTOL) {
 					break
 				}
-				iobsr += nr
-				nr = obss.NextObsf(&iobsr, 2)
+				p.IObsR += nr
+				nr = p.ObsData.NextObsf(&p.IObsR, 2)
 			}
 		} else {
-			i = iobsr
-			nr = obss.NextObsf(&i, 2)
+			i = p.IObsR
+			nr = p.ObsData.NextObsf(&i, 2)
 			for nr > 0 {
 				{
-					if TimeDiff(obss.Data[i].Time, obss.Data[iobsu].Time) > float64(DTTOL) {
+					if TimeDiff(p.ObsData.Data[i].Time, p.ObsData.Data[p.IObsU].Time) > float64(DTTOL) {
 						break
 					}
-					iobsr = i
+					p.IObsR = i
 					i += nr
-					nr = obss.NextObsf(&i, 2)
+					nr = p.ObsData.NextObsf(&i, 2)
 				}
 			}
 		}
-		nr = obss.NextObsf(&iobsr, 2)
+		nr = p.ObsData.NextObsf(&p.IObsR, 2)
 		if nr <= 0 {
-			nr = obss.NextObsf(&iobsr, 2)
+			nr = p.ObsData.NextObsf(&p.IObsR, 2)
 		}
 		for i = 0; i < nu && n < MAXOBS*2; i++ {
-			obs[n] = obss.Data[iobsu+i]
+			obs[n] = p.ObsData.Data[p.IObsU+i]
 			n++
 		}
 		for i = 0; i < nr && n < MAXOBS*2; i++ {
-			obs[n] = obss.Data[iobsr+i]
+			obs[n] = p.ObsData.Data[p.IObsR+i]
 			n++
 		}
-		iobsu += nu
+		p.IObsU += nu
 
 		/* update sbas corrections */
-		for isbs < sbss.N() {
-			time = GpsT2Time(sbss.Msgs[isbs].Week, float64(sbss.Msgs[isbs].Tow))
+		for p.ISbs < p.SbsData.N() {
+			time = GpsT2Time(p.SbsData.Msgs[p.ISbs].Week, float64(p.SbsData.Msgs[p.ISbs].Tow))
 
-			if GetBitU(sbss.Msgs[isbs].Msg[:], 8, 6) != 9 { /* except for geo nav */
-				SbsUpdateCorr(&sbss.Msgs[isbs], &navs)
+			if GetBitU(p.SbsData.Msgs[p.ISbs].Msg[:], 8, 6) != 9 { /* except for geo nav */
+				SbsUpdateCorr(&p.SbsData.Msgs[p.ISbs], &p.NavData)
 			}
 			if TimeDiff(time, obs[0].Time) > -1.0-float64(DTTOL) {
 				break
 			}
-			isbs++
+			p.ISbs++
 		}
 		/* update rtcm ssr corrections */
-		if len(rtcm_file) > 0 {
-			UpdateRtcmSsr(obs[0].Time)
+		if len(p.RtcmFile) > 0 {
+			p.UpdateRtcmSsr(obs[0].Time, rr)
 		}
 	} else { /* input backward data */
-		if nu = obss.NextObsb(&iobsu, 1); nu <= 0 {
+		if nu = p.ObsData.NextObsb(&p.IObsU, 1); nu <= 0 {
 			return -1
 		}
 		if popt.IntPref > 0 {
-			for nr = obss.NextObsb(&iobsr, 2); nr > 0; {
-				if TimeDiff(obss.Data[iobsr].Time, obss.Data[iobsu].Time) < float64(DTTOL) {
+			for nr = p.ObsData.NextObsb(&p.IObsR, 2); nr > 0; {
+				if TimeDiff(p.ObsData.Data[p.IObsR].Time, p.ObsData.Data[p.IObsU].Time) < float64(DTTOL) {
 					break
 				}
-				iobsr -= nr
-				nr = obss.NextObsb(&iobsr, 2)
+				p.IObsR -= nr
+				nr = p.ObsData.NextObsb(&p.IObsR, 2)
 			}
 		} else {
-			i = iobsr
-			nr = obss.NextObsb(&i, 2)
+			i = p.IObsR
+			nr = p.ObsData.NextObsb(&i, 2)
 			for nr > 0 {
-				if TimeDiff(obss.Data[i].Time, obss.Data[iobsu].Time) < -float64(DTTOL) {
+				if TimeDiff(p.ObsData.Data[i].Time, p.ObsData.Data[p.IObsU].Time) < -float64(DTTOL) {
 					break
 				}
-				iobsr = i
+				p.IObsR = i
 				i -= nr
-				nr = obss.NextObsb(&i, 2)
+				nr = p.ObsData.NextObsb(&i, 2)
 			}
 		}
-		nr = obss.NextObsb(&iobsr, 2)
+		nr = p.ObsData.NextObsb(&p.IObsR, 2)
 		for i = 0; i < nu && n < MAXOBS*2; i++ {
-			obs[n] = obss.Data[iobsu-nu+1+i]
+			obs[n] = p.ObsData.Data[p.IObsU-nu+1+i]
 			n++
 		}
 		for i = 0; i < nr && n < MAXOBS*2; i++ {
-			obs[n] = obss.Data[iobsr-nr+1+i]
+			obs[n] = p.ObsData.Data[p.IObsR-nr+1+i]
 			n++
 		}
-		iobsu -= nu
+		p.IObsU -= nu
 
 		/* update sbas corrections */
-		for isbs >= 0 {
-			time = GpsT2Time(sbss.Msgs[isbs].Week, float64(sbss.Msgs[isbs].Tow))
+		for p.ISbs >= 0 {
+			time = GpsT2Time(p.SbsData.Msgs[p.ISbs].Week, float64(p.SbsData.Msgs[p.ISbs].Tow))
 
-			if GetBitU(sbss.Msgs[isbs].Msg[:], 8, 6) != 9 { /* except for geo nav */
-				SbsUpdateCorr(&sbss.Msgs[isbs], &navs)
+			if GetBitU(p.SbsData.Msgs[p.ISbs].Msg[:], 8, 6) != 9 { /* except for geo nav */
+				SbsUpdateCorr(&p.SbsData.Msgs[p.ISbs], &p.NavData)
 			}
 			if TimeDiff(time, obs[0].Time) < 1.0+float64(DTTOL) {
 				break
 			}
-			isbs--
+			p.ISbs--
 		}
 	}
 	return n
@@ -405,13 +441,17 @@ func InputObs(obs []ObsD, solq int, popt *PrcOpt) int {
 
 /* carrier-phase bias correction by ssr --------------------------------------*/
 func CorrPhaseBiasSsr(obs []ObsD, n int, nav *Nav) {
-	var freq float64
+	var freq, bias float64
 
 	for i := 0; i < n; i++ {
 		for j := 0; j < NFREQ; j++ {
 			code := obs[i].Code[j]
 
-			if freq = Sat2Freq(int(obs[i].Sat), code, nav); freq == 0.0 {
+			if freq = Sat2Freq(int(obs[i].Sat), code, nav); freq == 0.0 || obs[i].L[j] == 0.0 {
+				continue
+			}
+			/* phase OSB (SINEX-BIAS) is corrected in CorrMeas() instead */
+			if nav.ObsBias(&obs[i], j, 1, &bias) > 0 {
 				continue
 			}
 
@@ -422,15 +462,15 @@ func CorrPhaseBiasSsr(obs []ObsD, n int, nav *Nav) {
 }
 
 /* process positioning -------------------------------------------------------*/
-func ProcPos(fp *os.File, popt *PrcOpt, sopt *SolOpt, mode int) {
+func (p *PostProcessor) ProcPos(fp *os.File, popt *PrcOpt, sopt *SolOpt, mode int) {
 	var (
-		time                  Gtime
-		sol                   Sol
-		rtk                   Rtk
-		obs                   [MAXOBS * 2]ObsD /* for rover and base */
-		rb                    [3]float64
-		i, nobs, n, solstatic int
-		pri                   []int = []int{6, 1, 2, 3, 4, 5, 1, 6}
+		time                        Gtime
+		sol                         Sol
+		rtk                         Rtk
+		obs                         [MAXOBS * 2]ObsD /* for rover and base */
+		rb                          [3]float64
+		i, nobs, n, solstatic, stat int
+		pri                         []int = []int{6, 1, 2, 3, 4, 5, 1, 6}
 	)
 
 	Trace(4, "procpos : mode=%d\n", mode)
@@ -442,10 +482,12 @@ func ProcPos(fp *os.File, popt *PrcOpt, sopt *SolOpt, mode int) {
 	}
 
 	rtk.InitRtk(popt)
-	rtcm_path = ""
+	rtk.Stat = &p.stat
+	p.RtcmPath = ""
+	p.IImu = 0
 
 	for {
-		nobs = InputObs(obs[:], int(rtk.RtkSol.Stat), popt)
+		nobs = p.InputObs(obs[:], int(rtk.RtkSol.Stat), rtk.RtkSol.Rr[:], popt)
 		if nobs < 0 {
 			break
 		}
@@ -462,9 +504,19 @@ func ProcPos(fp *os.File, popt *PrcOpt, sopt *SolOpt, mode int) {
 
 		/* carrier-phase bias correction */
 		if !strings.Contains(string(popt.PPPOpt[:]), "-ENA_FCB") {
-			CorrPhaseBiasSsr(obs[:], n, &navs)
+			CorrPhaseBiasSsr(obs[:], n, &p.NavData)
+		}
+		/* input imu data (forward only) */
+		if popt.InsOpt.Mode > 0 && p.Revs == 0 && p.IImu < p.ImuData.N() {
+			p.IImu += rtk.InsInput(p.ImuData.Data[p.IImu:], obs[0].Time)
 		}
-		if rtk.RtkPos(obs[:], n, &navs) == 0 {
+		stat = rtk.RtkPos(obs[:], n, &p.NavData)
+
+		/* gnss/ins integration */
+		if popt.InsOpt.Mode > 0 && p.Revs == 0 {
+			stat = rtk.InsIntegrate(obs[0].Time, stat)
+		}
+		if stat == 0 {
 			continue
 		}
 
@@ -480,24 +532,24 @@ func ProcPos(fp *os.File, popt *PrcOpt, sopt *SolOpt, mode int) {
 					time = rtk.RtkSol.Time
 				}
 			}
-		} else if revs == 0 { /* combined-forward */
-			if isolf >= nepoch {
+		} else if p.Revs == 0 { /* combined-forward */
+			if p.ISolF >= p.NEpoch {
 				return
 			}
-			solf[isolf] = rtk.RtkSol
+			p.SolF[p.ISolF] = rtk.RtkSol
 			for i = 0; i < 3; i++ {
-				rbf[i+isolf*3] = rtk.Rb[i]
+				p.RbF[i+p.ISolF*3] = rtk.Rb[i]
 			}
-			isolf++
+			p.ISolF++
 		} else { /* combined-backward */
-			if isolb >= nepoch {
+			if p.ISolB >= p.NEpoch {
 				return
 			}
-			solb[isolb] = rtk.RtkSol
+			p.SolB[p.ISolB] = rtk.RtkSol
 			for i = 0; i < 3; i++ {
-				rbb[i+isolb*3] = rtk.Rb[i]
+				p.RbB[i+p.ISolB*3] = rtk.Rb[i]
 			}
-			isolb++
+			p.ISolB++
 		}
 	}
 	if mode == 0 && solstatic > 0 && time.Time != 0.0 {
@@ -535,7 +587,7 @@ func ValComb(solf *Sol, solb *Sol) int {
 }
 
 /* combine forward/backward solutions and output results ---------------------*/
-func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
+func (p *PostProcessor) CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 	var (
 		time                      Gtime
 		sols, sol                 Sol
@@ -546,7 +598,7 @@ func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 		pri                       []int = []int{0, 1, 2, 3, 4, 5, 1, 6}
 	)
 
-	Trace(4, "combres : isolf=%d isolb=%d\n", isolf, isolb)
+	Trace(4, "combres : p.ISolF=%d p.ISolB=%d\n", p.ISolF, p.ISolB)
 
 	if popt.Mode == PMODE_STATIC || popt.Mode == PMODE_PPP_STATIC {
 		solstatic = sopt.SolStatic
@@ -555,59 +607,59 @@ func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 
 	}
 
-	for i, j = 0, isolb-1; i < isolf && j >= 0; i, j = i+1, j-1 {
-		tt = TimeDiff(solf[i].Time, solb[j].Time)
+	for i, j = 0, p.ISolB-1; i < p.ISolF && j >= 0; i, j = i+1, j-1 {
+		tt = TimeDiff(p.SolF[i].Time, p.SolB[j].Time)
 		switch {
 		case tt < float64(-DTTOL):
-			sols = solf[i]
+			sols = p.SolF[i]
 			for k = 0; k < 3; k++ {
-				rbs[k] = rbf[k+i*3]
+				rbs[k] = p.RbF[k+i*3]
 			}
 			j++
 		case tt > float64(DTTOL):
-			sols = solb[j]
+			sols = p.SolB[j]
 			for k = 0; k < 3; k++ {
-				rbs[k] = rbb[k+j*3]
+				rbs[k] = p.RbB[k+j*3]
 			}
 			i--
-		case solf[i].Stat < solb[j].Stat:
-			sols = solf[i]
+		case p.SolF[i].Stat < p.SolB[j].Stat:
+			sols = p.SolF[i]
 			for k = 0; k < 3; k++ {
-				rbs[k] = rbf[k+i*3]
+				rbs[k] = p.RbF[k+i*3]
 			}
-		case solf[i].Stat > solb[j].Stat:
-			sols = solb[j]
+		case p.SolF[i].Stat > p.SolB[j].Stat:
+			sols = p.SolB[j]
 			for k = 0; k < 3; k++ {
-				rbs[k] = rbb[k+j*3]
+				rbs[k] = p.RbB[k+j*3]
 			}
 		default:
-			sols = solf[i]
+			sols = p.SolF[i]
 			sols.Time = TimeAdd(sols.Time, -tt/2.0)
 
 			if (popt.Mode == PMODE_KINEMA || popt.Mode == PMODE_MOVEB) && sols.Stat == SOLQ_FIX {
 
 				/* degrade fix to float if validation failed */
-				if ValComb(&solf[i], &solb[j]) == 0 {
+				if ValComb(&p.SolF[i], &p.SolB[j]) == 0 {
 					sols.Stat = SOLQ_FLOAT
 				}
 			}
 			for k = 0; k < 3; k++ {
-				Qf[k+k*3] = float64(solf[i].Qr[k])
-				Qb[k+k*3] = float64(solb[j].Qr[k])
+				Qf[k+k*3] = float64(p.SolF[i].Qr[k])
+				Qb[k+k*3] = float64(p.SolB[j].Qr[k])
 			}
-			Qf[1], Qf[3] = float64(solf[i].Qr[3]), float64(solf[i].Qr[3])
-			Qf[5], Qf[7] = float64(solf[i].Qr[4]), float64(solf[i].Qr[4])
-			Qf[2], Qf[6] = float64(solf[i].Qr[5]), float64(solf[i].Qr[5])
-			Qb[1], Qb[3] = float64(solb[j].Qr[3]), float64(solb[j].Qr[3])
-			Qb[5], Qb[7] = float64(solb[j].Qr[4]), float64(solb[j].Qr[4])
-			Qb[2], Qb[6] = float64(solb[j].Qr[5]), float64(solb[j].Qr[5])
+			Qf[1], Qf[3] = float64(p.SolF[i].Qr[3]), float64(p.SolF[i].Qr[3])
+			Qf[5], Qf[7] = float64(p.SolF[i].Qr[4]), float64(p.SolF[i].Qr[4])
+			Qf[2], Qf[6] = float64(p.SolF[i].Qr[5]), float64(p.SolF[i].Qr[5])
+			Qb[1], Qb[3] = float64(p.SolB[j].Qr[3]), float64(p.SolB[j].Qr[3])
+			Qb[5], Qb[7] = float64(p.SolB[j].Qr[4]), float64(p.SolB[j].Qr[4])
+			Qb[2], Qb[6] = float64(p.SolB[j].Qr[5]), float64(p.SolB[j].Qr[5])
 
 			if popt.Mode == int(PMODE_MOVEB) {
 				for k = 0; k < 3; k++ {
-					rr_f[k] = solf[i].Rr[k] - rbf[k+i*3]
+					rr_f[k] = p.SolF[i].Rr[k] - p.RbF[k+i*3]
 				}
 				for k = 0; k < 3; k++ {
-					rr_b[k] = solb[j].Rr[k] - rbb[k+j*3]
+					rr_b[k] = p.SolB[j].Rr[k] - p.RbB[k+j*3]
 				}
 				if Smoother(rr_f[:], Qf[:], rr_b[:], Qb[:], 3, rr_s[:], Qs[:]) > 0 {
 					continue
@@ -616,7 +668,7 @@ func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 					sols.Rr[k] = rbs[k] + rr_s[k]
 				}
 			} else {
-				if Smoother(solf[i].Rr[:], Qf[:], solb[j].Rr[:], Qb[:], 3, sols.Rr[:], Qs[:]) > 0 {
+				if Smoother(p.SolF[i].Rr[:], Qf[:], p.SolB[j].Rr[:], Qb[:], 3, sols.Rr[:], Qs[:]) > 0 {
 					continue
 				}
 			}
@@ -630,16 +682,16 @@ func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 			/* smoother for velocity solution */
 			if popt.Dynamics > 0 {
 				for k = 0; k < 3; k++ {
-					Qf[k+k*3] = float64(solf[i].Qv[k])
-					Qb[k+k*3] = float64(solb[j].Qv[k])
+					Qf[k+k*3] = float64(p.SolF[i].Qv[k])
+					Qb[k+k*3] = float64(p.SolB[j].Qv[k])
 				}
-				Qf[1], Qf[3] = float64(solf[i].Qv[3]), float64(solf[i].Qv[3])
-				Qf[5], Qf[7] = float64(solf[i].Qv[4]), float64(solf[i].Qv[4])
-				Qf[2], Qf[6] = float64(solf[i].Qv[5]), float64(solf[i].Qv[5])
-				Qb[1], Qb[3] = float64(solb[j].Qv[3]), float64(solb[j].Qv[3])
-				Qb[5], Qb[7] = float64(solb[j].Qv[4]), float64(solb[j].Qv[4])
-				Qb[2], Qb[6] = float64(solb[j].Qv[5]), float64(solb[j].Qv[5])
-				if Smoother(solf[i].Rr[3:], Qf[:], solb[j].Rr[3:], Qb[:], 3, sols.Rr[3:], Qs[:]) > 0 {
+				Qf[1], Qf[3] = float64(p.SolF[i].Qv[3]), float64(p.SolF[i].Qv[3])
+				Qf[5], Qf[7] = float64(p.SolF[i].Qv[4]), float64(p.SolF[i].Qv[4])
+				Qf[2], Qf[6] = float64(p.SolF[i].Qv[5]), float64(p.SolF[i].Qv[5])
+				Qb[1], Qb[3] = float64(p.SolB[j].Qv[3]), float64(p.SolB[j].Qv[3])
+				Qb[5], Qb[7] = float64(p.SolB[j].Qv[4]), float64(p.SolB[j].Qv[4])
+				Qb[2], Qb[6] = float64(p.SolB[j].Qv[5]), float64(p.SolB[j].Qv[5])
+				if Smoother(p.SolF[i].Rr[3:], Qf[:], p.SolB[j].Rr[3:], Qb[:], 3, sols.Rr[3:], Qs[:]) > 0 {
 					continue
 				}
 				sols.Qv[0] = float32(Qs[0])
@@ -669,8 +721,12 @@ func CombResult(fp *os.File, popt *PrcOpt, sopt *SolOpt) {
 }
 
 /* read prec ephemeris, sbas data, tec grid and open rtcm --------------------*/
-func (nav *Nav) ReadPrecEph(infile []string, n int, prcopt *PrcOpt, sbs *Sbs) {
-	var i int
+func (p *PostProcessor) ReadPrecEph(infile []string, n int, prcopt *PrcOpt) {
+	var (
+		nav *Nav = &p.NavData
+		sbs *Sbs = &p.SbsData
+		i   int
+	)
 
 	Trace(4, "readpreceph: n=%d\n", n)
 
@@ -699,23 +755,26 @@ func (nav *Nav) ReadPrecEph(infile []string, n int, prcopt *PrcOpt, sbs *Sbs) {
 	}
 
 	/* set rtcm file and initialize rtcm struct */
-	rtcm_file, rtcm_path = "", ""
-	fp_rtcm = nil
+	p.RtcmFile, p.RtcmPath = "", ""
+	p.FpRtcm = nil
 
 	for i = 0; i < n; i++ {
 		index := strings.LastIndex(infile[i], ".")
 		if index > 0 && strings.EqualFold(infile[i][index:], ".rtcm3") {
-			rtcm_file = infile[i]
+			p.RtcmFile = infile[i]
 
-			rtcm.InitRtcm()
+			p.RtcmCtrl.InitRtcm()
 			break
 		}
 	}
 }
 
 /* free prec ephemeris and sbas data -----------------------------------------*/
-func (nav *Nav) FreePrecEph(sbs *Sbs) {
-
+func (p *PostProcessor) FreePrecEph() {
+	var (
+		nav *Nav = &p.NavData
+		sbs *Sbs = &p.SbsData
+	)
 	Trace(4, "freepreceph:\n")
 
 	nav.Peph = nil
@@ -725,17 +784,15 @@ func (nav *Nav) FreePrecEph(sbs *Sbs) {
 
 	nav.Tec = nil
 
-	if fp_rtcm != nil {
-		fp_rtcm.Close()
-	}
-
-	if rtcm != nil {
-		rtcm.FreeRtcm()
+	if p.FpRtcm != nil {
+		p.FpRtcm.Close()
+		p.FpRtcm = nil
 	}
+	p.RtcmCtrl.FreeRtcm()
 }
 
 /* read obs and nav data -----------------------------------------------------*/
-func ReadObsNav(ts, te Gtime, ti float64, infile []string,
+func (p *PostProcessor) ReadObsNav(ts, te Gtime, ti float64, infile []string,
 	index []int, n int, prcopt *PrcOpt, obs *Obs, nav *Nav, sta []Sta) int {
 	var (
 		i, j, ind, nobs int
@@ -748,10 +805,10 @@ func ReadObsNav(ts, te Gtime, ti float64, infile []string,
 	nav.Ephs = nil
 	nav.Geph = nil
 	nav.Seph = nil
-	nepoch = 0
+	p.NEpoch = 0
 
 	for i = 0; i < n; i++ {
-		if checkbrk("") > 0 {
+		if p.checkbrk("") > 0 {
 			return 0
 		}
 
@@ -772,23 +829,23 @@ func ReadObsNav(ts, te Gtime, ti float64, infile []string,
 			staid = &sta[rcv-1]
 		}
 		if ReadRnxT(infile[i], rcv, ts, te, ti, prcopt.RnxOpt[rcvid], obs, nav, staid) < 0 {
-			checkbrk("error : insufficient memory")
+			p.checkbrk("error : insufficient memory")
 			Trace(2, "insufficient memory\n")
 			return 0
 		}
 	}
 	if obs.N() <= 0 {
-		checkbrk("error : no obs data")
+		p.checkbrk("error : no obs data")
 		Trace(2, "\n")
 		return 0
 	}
 	if nav.N() <= 0 && nav.Ng() <= 0 && nav.Ns() <= 0 {
-		checkbrk("error : no nav data")
+		p.checkbrk("error : no nav data")
 		Trace(2, "\n")
 		return 0
 	}
 	/* sort observation data */
-	nepoch = obs.SortObs()
+	p.NEpoch = obs.SortObs()
 
 	/* delete duplicated ephemeris */
 	nav.UniqNav()
@@ -948,81 +1005,100 @@ func AntPos(opt *PrcOpt, rcvno int, obs *Obs, nav *Nav,
 		}
 	case POSOPT_FILE: /* read from position file */
 
-		name = stas[rcvnoid].Name
+		name = sta[rcvnoid].Name
 		if GetStationPos(posfile, name, rr) == 0 {
 			ShowMsg_Ptr("error : no position of %s in %s", name, posfile)
 			return 0
 		}
 	case POSOPT_RINEX: /* get from rinex header */
-		if Norm(stas[rcvnoid].Pos[:], 3) <= 0.0 {
+		if Norm(sta[rcvnoid].Pos[:], 3) <= 0.0 {
 			ShowMsg_Ptr("error : no position in rinex header")
 			Trace(3, "no position position in rinex header\n")
 			return 0
 		}
 		/* antenna delta */
-		if stas[rcvnoid].DelType == 0 { /* enu */
+		if sta[rcvnoid].DelType == 0 { /* enu */
 			for i = 0; i < 3; i++ {
-				del[i] = stas[rcvnoid].Del[i]
+				del[i] = sta[rcvnoid].Del[i]
 			}
-			del[2] += stas[rcvnoid].Hgt
-			Ecef2Pos(stas[rcvnoid].Pos[:], pos[:])
+			del[2] += sta[rcvnoid].Hgt
+			Ecef2Pos(sta[rcvnoid].Pos[:], pos[:])
 			Enu2Ecef(pos[:], del[:], dr[:])
 		} else { /* xyz */
 			for i = 0; i < 3; i++ {
-				dr[i] = stas[rcvnoid].Del[i]
+				dr[i] = sta[rcvnoid].Del[i]
 			}
 		}
 		for i = 0; i < 3; i++ {
-			rr[i] = stas[rcvnoid].Pos[i] + dr[i]
+			rr[i] = sta[rcvnoid].Pos[i] + dr[i]
 		}
 	}
 	return 1
 }
 
 /* open procssing session ----------------------------------------------------*/
-func OpenSession(popt *PrcOpt, sopt *SolOpt, fopt *FilOpt, nav *Nav, pcvs, pcvr *Pcvs) int {
+func (p *PostProcessor) OpenSession(popt *PrcOpt, sopt *SolOpt, fopt *FilOpt) int {
 	Trace(4, "openses :\n")
 
+	/* ins mechanization only in forward time */
+	if popt.InsOpt.Mode > 0 && popt.Mode != PMODE_SINGLE && popt.SolType != 0 {
+		p.showmsg("error : ins not supported for backward/combined solution")
+		Trace(2, "ins not supported for soltype=%d\n", popt.SolType)
+		return 0
+	}
 	/* read satellite antenna parameters */
-	if len(fopt.SatAntPara) > 0 && ReadPcv(fopt.SatAntPara, pcvs) == 0 {
-		ShowMsg_Ptr("error : no sat ant pcv in %s", fopt.SatAntPara)
+	if len(fopt.SatAntPara) > 0 && ReadPcv(fopt.SatAntPara, &p.PcvSat) == 0 {
+		p.showmsg("error : no sat ant pcv in %s", fopt.SatAntPara)
 		Trace(3, "sat antenna pcv read error: %s\n", fopt.SatAntPara)
 		return 0
 	}
 	/* read receiver antenna parameters */
-	if len(fopt.RcvAntPara) > 0 && ReadPcv(fopt.RcvAntPara, pcvr) == 0 {
-		ShowMsg_Ptr("error : no rec ant pcv in %s", fopt.RcvAntPara)
+	if len(fopt.RcvAntPara) > 0 && ReadPcv(fopt.RcvAntPara, &p.PcvRcv) == 0 {
+		p.showmsg("error : no rec ant pcv in %s", fopt.RcvAntPara)
 		Trace(3, "rec antenna pcv read error: %s\n", fopt.RcvAntPara)
 		return 0
 	}
 	/* open geoid data */
 	if sopt.Geoid > 0 && len(fopt.Geoid) > 0 {
-		if OpenGeoid(sopt.Geoid, fopt.Geoid) == 0 {
-			ShowMsg_Ptr("error : no geoid data %s", fopt.Geoid)
+		if p.acquire(&postshared.geoid) == 0 {
+			p.showmsg("warning : geoid data used by another session")
+			Trace(2, "geoid data used by another session\n")
+		} else if OpenGeoid(sopt.Geoid, fopt.Geoid) == 0 {
+			p.release(&postshared.geoid)
+			p.showmsg("error : no geoid data %s", fopt.Geoid)
 			Trace(3, "no geoid data %s\n", fopt.Geoid)
+		} else {
+			p.geoid = 1
 		}
 	}
 	return 1
 }
 
 /* close procssing session ---------------------------------------------------*/
-func CloseSession(nav *Nav, pcvs, pcvr *Pcvs) {
+func (p *PostProcessor) CloseSession() {
 	Trace(4, "closeses:\n")
 
 	/* free antenna parameters */
-	pcvs.Pcv = nil
-	pcvr.Pcv = nil
+	p.PcvSat.Pcv = nil
+	p.PcvRcv.Pcv = nil
 
 	/* close geoid data */
-	CloseGeoid()
-
+	if p.geoid > 0 {
+		CloseGeoid()
+		p.geoid = 0
+		p.release(&postshared.geoid)
+	}
 	/* free erp data */
-	nav.Erp.Data = nil
-	// nav.Erp.N, nav.Erp.Nmax = 0, 0
+	p.NavData.Erp.Data = nil
 
-	/* close solution statistics and debug trace */
-	RtkCloseStat()
-	TraceClose()
+	/* close solution statistics and debug trace opened by the session */
+	p.stat.CloseStat()
+
+	if p.trace > 0 {
+		TraceClose()
+		p.trace = 0
+		p.release(&postshared.trace)
+	}
 }
 
 /* set antenna parameters ----------------------------------------------------*/
@@ -1067,7 +1143,7 @@ func SetPcv(time Gtime, popt *PrcOpt, nav *Nav, pcvs, pcvr *Pcvs, sta []Sta) {
 				}
 			} else { /* enu */
 				for j = 0; j < 3; j++ {
-					popt.AntDel[i][j] = float64(stas[i].Del[j])
+					popt.AntDel[i][j] = float64(sta[i].Del[j])
 				}
 			}
 		}
@@ -1094,7 +1170,7 @@ func ReadOtl(popt *PrcOpt, file string, sta []Sta) {
 }
 
 /* write header to output file -----------------------------------------------*/
-func OutPostHead(outfile string, infile []string, n int, popt *PrcOpt, sopt *SolOpt) int {
+func (p *PostProcessor) OutPostHead(outfile string, infile []string, n int, popt *PrcOpt, sopt *SolOpt) int {
 	var (
 		fp  *os.File = os.Stdout
 		err error
@@ -1106,13 +1182,13 @@ func OutPostHead(outfile string, infile []string, n int, popt *PrcOpt, sopt *Sol
 		CreateDir(outfile)
 		fp, _ = os.OpenFile(outfile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModeAppend|os.ModePerm)
 		if err != nil {
-			ShowMsg_Ptr("error : open output file %s", outfile)
+			p.showmsg("error : open output file %s", outfile)
 			return 0
 		}
 		defer fp.Close()
 	}
 	/* output header */
-	OutHeader(fp, infile, n, popt, sopt)
+	p.OutHeader(fp, infile, n, popt, sopt)
 
 	return 1
 }
@@ -1129,7 +1205,7 @@ func OpenPostFile(outfile string) *os.File {
 }
 
 /* execute processing session ------------------------------------------------*/
-func execses(ts, te Gtime, ti float64, popt *PrcOpt,
+func (p *PostProcessor) execses(ts, te Gtime, ti float64, popt *PrcOpt,
 	sopt *SolOpt, fopt *FilOpt, flag int,
 	infile []string, index []int, n int, outfile string) int {
 	var (
@@ -1148,116 +1224,137 @@ func execses(ts, te Gtime, ti float64, popt *PrcOpt,
 		} else {
 			tracefile = fopt.Trace
 		}
-		TraceClose()
-		TraceOpen(tracefile)
-		TraceLevel(sopt.Trace)
+		if p.acquire(&postshared.trace) > 0 {
+			TraceClose()
+			TraceOpen(tracefile)
+			TraceLevel(sopt.Trace)
+			p.trace = 1
+		} else {
+			p.showmsg("warning : debug trace used by another session")
+		}
 	}
 	/* read ionosphere data file */
 	if id = strings.LastIndex(fopt.Iono, "."); len(fopt.Iono) > 0 && id > 0 {
 		if len(fopt.Iono[id:]) == 4 && (fopt.Iono[id+3] == 'i' || fopt.Iono[id+3] == 'I') {
 			RepPath(fopt.Iono, &path, ts, "", "")
-			navs.ReadTec(path, 1)
+			p.NavData.ReadTec(path, 1)
 		}
 	}
 
 	/* read erp data */
 	if len(fopt.Eop) > 0 {
-		navs.Erp.Data = nil
-		// navs.Erp.N, navs.Erp.Nmax = 0, 0
+		p.NavData.Erp.Data = nil
+		// p.NavData.Erp.N, p.NavData.Erp.Nmax = 0, 0
 		RepPath(fopt.Eop, &path, ts, "", "")
-		if ReadErp(path, &navs.Erp) == 0 {
-			ShowMsg_Ptr("error : no erp data %s", path)
+		if ReadErp(path, &p.NavData.Erp) == 0 {
+			p.showmsg("error : no erp data %s", path)
 			Trace(3, "no erp data %s\n", path)
 		}
 	}
 
 	/* read obs and nav data */
-	if ReadObsNav(ts, te, ti, infile, index, n, &popt_, &obss, &navs, stas[:]) == 0 {
+	if p.ReadObsNav(ts, te, ti, infile, index, n, &popt_, &p.ObsData, &p.NavData, p.StaData[:]) == 0 {
 		return 0
 	}
+	/* read imu data */
+	p.ImuData.Data = nil
+	if popt_.InsOpt.Mode > 0 && len(fopt.Imu) > 0 {
+		RepPath(fopt.Imu, &path, ts, "", "")
+		if ReadImuCsv(path, &p.ImuData) == 0 {
+			p.showmsg("error : no imu data %s", path)
+			Trace(2, "no imu data %s\n", path)
+		}
+	}
 
 	/* read dcb parameters */
 	if len(fopt.Dcb) > 0 {
 		RepPath(fopt.Dcb, &path, ts, "", "")
-		navs.ReadDcb(path, stas[:])
+		p.NavData.ReadDcb(path, p.StaData[:])
+	}
+	/* read clas grid definition */
+	p.NavData.Grid, p.NavData.Catm = nil, nil
+	if len(fopt.Grid) > 0 && p.NavData.ReadCssrGrid(fopt.Grid) == 0 {
+		p.showmsg("error : no clas grid data %s", fopt.Grid)
+		Trace(2, "no clas grid data %s\n", fopt.Grid)
 	}
 	/* set antenna paramters */
 	if popt_.Mode != PMODE_SINGLE {
-		if obss.N() > 0 {
-			SetPcv(obss.Data[0].Time, &popt_, &navs, &pcvss, &pcvsr, stas[:])
+		if p.ObsData.N() > 0 {
+			SetPcv(p.ObsData.Data[0].Time, &popt_, &p.NavData, &p.PcvSat, &p.PcvRcv, p.StaData[:])
 		} else {
-			SetPcv(TimeGet(), &popt_, &navs, &pcvss, &pcvsr, stas[:])
+			SetPcv(TimeGet(), &popt_, &p.NavData, &p.PcvSat, &p.PcvRcv, p.StaData[:])
 		}
 	}
 	/* read ocean tide loading parameters */
 	if popt_.Mode > PMODE_SINGLE && len(fopt.Blq) > 0 {
-		ReadOtl(&popt_, fopt.Blq, stas[:])
+		ReadOtl(&popt_, fopt.Blq, p.StaData[:])
 	}
 	/* rover/reference fixed position */
 	if popt_.Mode == PMODE_FIXED {
-		if AntPos(&popt_, 1, &obss, &navs, stas[:], fopt.StaPos) == 0 {
-			FreeObsNav(&obss, &navs)
+		if AntPos(&popt_, 1, &p.ObsData, &p.NavData, p.StaData[:], fopt.StaPos) == 0 {
+			FreeObsNav(&p.ObsData, &p.NavData)
 			return 0
 		}
 	} else if PMODE_DGPS <= popt_.Mode && popt_.Mode <= PMODE_STATIC {
-		if AntPos(&popt_, 2, &obss, &navs, stas[:], fopt.StaPos) == 0 {
-			FreeObsNav(&obss, &navs)
+		if AntPos(&popt_, 2, &p.ObsData, &p.NavData, p.StaData[:], fopt.StaPos) == 0 {
+			FreeObsNav(&p.ObsData, &p.NavData)
 			return 0
 		}
 	}
 	/* open solution statistics */
 	if flag > 0 && sopt.SStat > 0 {
 		statfile = outfile + ".stat"
-		RtkCloseStat()
-		RtkOpenStat(statfile, sopt.SStat)
+		p.stat.CloseStat()
+		p.stat.OpenStat(statfile, sopt.SStat)
 	}
 	/* write header to output file */
-	if flag > 0 && OutPostHead(outfile, infile, n, &popt_, sopt) == 0 {
-		FreeObsNav(&obss, &navs)
+	if flag > 0 && p.OutPostHead(outfile, infile, n, &popt_, sopt) == 0 {
+		FreeObsNav(&p.ObsData, &p.NavData)
 		return 0
 	}
-	iobsu, iobsr, isbs, revs, aborts = 0, 0, 0, 0, 0
+	p.IObsU, p.IObsR, p.ISbs, p.Revs, p.Aborts = 0, 0, 0, 0, 0
 
 	switch {
 	case popt_.Mode == PMODE_SINGLE || popt_.SolType == 0:
 		if fp = OpenPostFile(outfile); fp != nil {
-			ProcPos(fp, &popt_, sopt, 0) /* forward */
+			p.ProcPos(fp, &popt_, sopt, 0) /* forward */
 			fp.Close()
 		}
 	case popt_.SolType == 1:
 		if fp = OpenPostFile(outfile); fp != nil {
-			revs = 1
-			iobsu, iobsr, isbs = obss.N()-1, obss.N()-1, sbss.N()-1
-			ProcPos(fp, &popt_, sopt, 0) /* backward */
+			p.Revs = 1
+			p.IObsU, p.IObsR, p.ISbs = p.ObsData.N()-1, p.ObsData.N()-1, p.SbsData.N()-1
+			p.ProcPos(fp, &popt_, sopt, 0) /* backward */
 			fp.Close()
 		}
 	default: /* combined */
-		solf = make([]Sol, nepoch)
-		solb = make([]Sol, nepoch)
-		rbf = make([]float64, nepoch*3)
-		rbb = make([]float64, nepoch*3)
+		p.SolF = make([]Sol, p.NEpoch)
+		p.SolB = make([]Sol, p.NEpoch)
+		p.RbF = make([]float64, p.NEpoch*3)
+		p.RbB = make([]float64, p.NEpoch*3)
 
-		isolf, isolb = 0, 0
-		ProcPos(nil, &popt_, sopt, 1) /* forward */
-		revs = 1
-		iobsu, iobsr, isbs = obss.N()-1, sbss.N()-1, sbss.N()-1
-		ProcPos(nil, &popt_, sopt, 1) /* backward */
+		p.ISolF, p.ISolB = 0, 0
+		p.ProcPos(nil, &popt_, sopt, 1) /* forward */
+		p.Revs = 1
+		p.IObsU, p.IObsR, p.ISbs = p.ObsData.N()-1, p.ObsData.N()-1, p.SbsData.N()-1
+		p.ProcPos(nil, &popt_, sopt, 1) /* backward */
 
 		/* combine forward/backward solutions */
 		fp = OpenPostFile(outfile)
-		if aborts == 0 && fp != nil {
-			CombResult(fp, &popt_, sopt)
+		if p.Aborts == 0 && fp != nil {
+			p.CombResult(fp, &popt_, sopt)
 			fp.Close()
 		}
 	}
 	/* free obs and nav data */
-	FreeObsNav(&obss, &navs)
+	FreeObsNav(&p.ObsData, &p.NavData)
+	p.ImuData.Data = nil
 
-	return aborts
+	return p.Aborts
 }
 
 /* execute processing session for each rover ---------------------------------*/
-func execses_r(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOpt, flag int,
+func (p *PostProcessor) execses_r(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOpt, flag int,
 	infile []string, index []int, n int, outfile string, rov string) int {
 	var (
 		t0             Gtime
@@ -1279,15 +1376,15 @@ func execses_r(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 		rov_ = rov
 
 		ifile = make([]string, n)
-		p := strings.Fields(rov_)
-		for _, q := range p {
-			proc_rov = q
+		ss := strings.Fields(rov_)
+		for _, q := range ss {
+			p.ProcRov = q
 			if ts.Time > 0 {
 				Time2Str(ts, &s, 0)
 			} else {
 				s = ""
 			}
-			if checkbrk("reading    : %s", s) > 0 {
+			if p.checkbrk("reading    : %s", s) > 0 {
 				stat = 1
 				break
 			}
@@ -1297,7 +1394,7 @@ func execses_r(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 			RepPath(outfile, &ofile, t0, q, "")
 
 			/* execute processing session */
-			stat = execses(ts, te, ti, popt, sopt, fopt, flag, ifile, index, n, ofile)
+			stat = p.execses(ts, te, ti, popt, sopt, fopt, flag, ifile, index, n, ofile)
 			if stat == 1 {
 				break
 			}
@@ -1305,13 +1402,13 @@ func execses_r(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 
 	} else {
 		/* execute processing session */
-		stat = execses(ts, te, ti, popt, sopt, fopt, flag, infile, index, n, outfile)
+		stat = p.execses(ts, te, ti, popt, sopt, fopt, flag, infile, index, n, outfile)
 	}
 	return stat
 }
 
 /* execute processing session for each rover ---------------------------------*/
-func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOpt, flag int,
+func (p *PostProcessor) execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOpt, flag int,
 	infile []string, index []int, n int, outfile string, rov, base string) int {
 	var (
 		t0              Gtime
@@ -1323,7 +1420,7 @@ func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 	Trace(4, "execses_b: n=%d outfile=%s\n", n, outfile)
 
 	/* read prec ephemeris and sbas data */
-	navs.ReadPrecEph(infile, n, popt, &sbss)
+	p.ReadPrecEph(infile, n, popt)
 
 	for i = 0; i < n; i++ {
 		if strings.Contains(infile[i], "%b") {
@@ -1336,15 +1433,15 @@ func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 		base_ = base
 
 		ifile = make([]string, MAXINFILE)
-		p := strings.Fields(base_)
-		for _, q := range p {
-			proc_base = q
+		ss := strings.Fields(base_)
+		for _, q := range ss {
+			p.ProcBase = q
 			if ts.Time > 0 {
 				Time2Str(ts, &s, 0)
 			} else {
 				s = ""
 			}
-			if checkbrk("reading    : %s", s) > 0 {
+			if p.checkbrk("reading    : %s", s) > 0 {
 				stat = 1
 				break
 			}
@@ -1354,7 +1451,7 @@ func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 			RepPath(outfile, &ofile, t0, "", q)
 
 			/* execute processing session */
-			stat = execses_r(ts, te, ti, popt, sopt, fopt, flag, ifile, index, n, ofile, rov)
+			stat = p.execses_r(ts, te, ti, popt, sopt, fopt, flag, ifile, index, n, ofile, rov)
 			if stat == 1 {
 				break
 			}
@@ -1362,9 +1459,9 @@ func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 
 	} else {
 		/* execute processing session */
-		stat = execses_r(ts, te, ti, popt, sopt, fopt, flag, infile, index, n, outfile, rov)
+		stat = p.execses_r(ts, te, ti, popt, sopt, fopt, flag, infile, index, n, outfile, rov)
 	}
-	navs.FreePrecEph(&sbss)
+	p.FreePrecEph()
 	return stat
 }
 
@@ -1410,8 +1507,31 @@ func execses_b(ts, te Gtime, ti float64, popt *PrcOpt, sopt *SolOpt, fopt *FilOp
 *          are output to a single output file.
 *
 *          ssr corrections are valid only for forward estimation.
+*
+*          gnss/ins integration (popt->insopt.mode) is valid only for forward
+*          estimation. backward or combined solution type with ins is
+*          rejected as error.
+*
+*          PostPos() runs a new post-processing session by PostProcessor. To
+*          run sessions in parallel goroutines, use a PostProcessor for each
+*          goroutine. solution status files are written by each session.
+*          debug trace and geoid data are shared in the process and only one
+*          session at a time can open them. the other sessions run without
+*          them (debug trace off and embedded geoid model).
 *-----------------------------------------------------------------------------*/
 func PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
+	fopt *FilOpt, infile []string, n int, outfile *string, rov, base string) int {
+	return NewPostProcessor().PostPos(ts, te, ti, tu, popt, sopt, fopt, infile, n, outfile,
+		rov, base)
+}
+
+/* post-processing positioning by session --------------------------------------
+* post-processing positioning by post-processing session
+* args   : (same as PostPos())
+* return : status (0:ok,0>:error,1:aborted)
+* notes  : a PostProcessor can not run multiple sessions at the same time.
+*-----------------------------------------------------------------------------*/
+func (p *PostProcessor) PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
 	fopt *FilOpt, infile []string, n int, outfile *string, rov, base string) int {
 	var (
 		tts, tte, ttte          Gtime
@@ -1425,14 +1545,14 @@ func PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
 	Trace(4, "postpos : ti=%.0f tu=%.0f n=%d outfile=%s\n", ti, tu, n, *outfile)
 
 	/* open processing session */
-	if OpenSession(popt, sopt, fopt, &navs, &pcvss, &pcvsr) == 0 {
+	if p.OpenSession(popt, sopt, fopt) == 0 {
 		return -1
 	}
 
 	if ts.Time != 0 && te.Time != 0 && tu >= 0.0 {
 		if TimeDiff(te, ts) < 0.0 {
-			ShowMsg_Ptr("error : no period")
-			CloseSession(&navs, &pcvss, &pcvsr)
+			p.showmsg("error : no period")
+			p.CloseSession()
 			return 0
 		}
 
@@ -1460,9 +1580,9 @@ func PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
 				tte = te
 			}
 
-			proc_rov = ""
-			proc_base = ""
-			if checkbrk("reading    : %s", TimeStr(tts, 0)) > 0 {
+			p.ProcRov = ""
+			p.ProcBase = ""
+			if p.checkbrk("reading    : %s", TimeStr(tts, 0)) > 0 {
 				stat = 1
 				break
 			}
@@ -1499,7 +1619,7 @@ func PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
 			}
 
 			/* execute processing session */
-			stat = execses_b(tts, tte, ti, popt, sopt, fopt, flag, ifile, index, nf, ofile,
+			stat = p.execses_b(tts, tte, ti, popt, sopt, fopt, flag, ifile, index, nf, ofile,
 				rov, base)
 
 			if stat == 1 {
@@ -1516,17 +1636,17 @@ func PostPos(ts, te Gtime, ti, tu float64, popt *PrcOpt, sopt *SolOpt,
 		RepPath(*outfile, &ofile, ts, "", "")
 
 		/* execute processing session */
-		stat = execses_b(ts, te, ti, popt, sopt, fopt, 1, ifile, index, n, ofile, rov, base)
+		stat = p.execses_b(ts, te, ti, popt, sopt, fopt, 1, ifile, index, n, ofile, rov, base)
 	} else {
 		for i = 0; i < n; i++ {
 			index[i] = i
 		}
 
 		/* execute processing session */
-		stat = execses_b(ts, te, ti, popt, sopt, fopt, 1, infile, index, n, *outfile, rov, base)
+		stat = p.execses_b(ts, te, ti, popt, sopt, fopt, 1, infile, index, n, *outfile, rov, base)
 	}
 	/* close processing session */
-	CloseSession(&navs, &pcvss, &pcvsr)
+	p.CloseSession()
 
 	return stat
 }
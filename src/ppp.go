@@ -27,6 +27,11 @@
 *    [10] F.Dilssner, The GLONASS-M satellite yaw-attitude model, Advances in
 *        Space Research, 2010
 *    [11] IGS MGEX (http://igs.org/mgex)
+*    [12] M.Ge et al., Resolution of GPS carrier-phase ambiguities in Precise
+*        Point Positioning (PPP) with daily observations, J.Geodesy, 2008
+*    [13] D.Laurichesse et al., Integer ambiguity resolution on undifferenced
+*        GPS phase measurements and its application to PPP and satellite
+*        precise orbit determination, Navigation, 2009
 *
 * version : $Revision:$ $Date:$
 * history : 2010/07/20 1.0  new
@@ -60,6 +65,13 @@
 *           2020/11/30 1.14 use sat2freq() to get carrier frequency
 *                           use E1-E5b for Galileo iono-free LC
 *		    2022/05/31 1.0  rewrite ppp.c with golang by fxb
+*           2026/10/16 1.1  add ppp-ar by wide-lane and narrow-lane fixing
+*                           add ssr code bias correction
+*                           fix bug on initial phase-bias of uncombined model
+*                           add signal bias (SINEX-BIAS) correction
+*                           fix bug on satellite index of P1-C1,P2-C2 dcb
+*           2026/10/16 1.2  add clas stec and tropos grid corrections
+*                           (pos1-ionoopt=stec,pos1-tropopt=ztd)
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -75,6 +87,9 @@ const (
 	MIN_NSAT_SOL = 4    /* min satellite number for solution */
 	THRES_REJECT = 4.0  /* reject threshold of posfit-res (sigma) */
 
+	MIN_NAMB_AR  = 4  /* min number of sd-ambiguities for ppp-ar */
+	MIN_EPOCH_WL = 10 /* min number of epochs to average MW-LC */
+
 	THRES_MW_JUMP         = 10.0
 	GAP_RESION    int     = 120           /* default gap to reset ionos parameters (ep) */
 	EFACT_GPS_L5  float64 = 10.0          /* error factor of GPS/QZS L5 */
@@ -95,7 +110,8 @@ var (
 	VAR_DCB     float64 = SQR(30.0) /* init variance dcb (m^2) */
 	VAR_BIAS    float64 = SQR(60.0) /* init variance phase-bias (m^2) */
 	VAR_IONO    float64 = SQR(60.0) /* init variance iono-delay */
-	VAR_GLO_IFB float64 = SQR(0.6) /* variance of glonass ifb */)
+	VAR_GLO_IFB float64 = SQR(0.6)  /* variance of glonass ifb */
+	VAR_FIXAMB  float64 = SQR(0.001) /* variance of fixed ambiguity constraint (m^2) */)
 
 // const ERR_SAAS float64 = 0.3  /* saastamoinen model error std (m) */
 // const ERR_BRDCI float64 = 0.5 /* broadcast iono model error factor */
@@ -119,7 +135,7 @@ func NP(opt *PrcOpt) int {
 }
 func NC(opt *PrcOpt) int { return NSYS }
 func NT(opt *PrcOpt) int {
-	if opt.TropOpt < TROPOPT_EST {
+	if opt.TropOpt < TROPOPT_EST || opt.TropOpt > TROPOPT_ESTG {
 		return 0
 	} else if opt.TropOpt == TROPOPT_EST {
 		return 1
@@ -491,9 +507,9 @@ func CorrMeas(obs *ObsD, nav *Nav, azel []float64,
 	opt *PrcOpt, dantr, dants []float64, phw float64, L, P []float64,
 	Lc, Pc *float64) {
 	var (
-		freq   [NFREQ]float64
-		C1, C2 float64
-		i, sys int = 0, SatSys(obs.Sat, nil)
+		freq                [NFREQ]float64
+		C1, C2, bias, cbias float64
+		i, sys              int = 0, SatSys(obs.Sat, nil)
 	)
 
 	for i = 0; i < NFREQ; i++ {
@@ -510,16 +526,27 @@ func CorrMeas(obs *ObsD, nav *Nav, azel []float64,
 		L[i] = obs.L[i]*CLIGHT/freq[i] - dants[i] - dantr[i] - phw*CLIGHT/freq[i]
 		P[i] = obs.P[i] - dants[i] - dantr[i]
 
-		/* P1-C1,P2-C2 dcb correction (C1.P1,C2.P2) */
-		if sys == SYS_GPS || sys == SYS_GLO {
+		/* signal bias correction (SINEX-BIAS) */
+		if nav.ObsBias(obs, i, 0, &bias) > 0 {
+			P[i] -= bias
+		} else if cbias = float64(nav.Ssr[obs.Sat-1].Cbias[obs.Code[i]-1]); cbias != 0.0 {
+			/* ssr code bias correction (same convention as phase bias) */
+			P[i] -= cbias
+		} else if sys == SYS_GPS || sys == SYS_GLO {
+			/* P1-C1,P2-C2 dcb correction (C1.P1,C2.P2) */
 			switch obs.Code[i] {
 			case CODE_L1C:
-				P[i] += nav.CBias[obs.Sat][1]
+				P[i] += nav.CBias[obs.Sat-1][1]
 
 			case CODE_L2C:
-				P[i] += nav.CBias[obs.Sat][2]
+				P[i] += nav.CBias[obs.Sat-1][2]
 			}
 		}
+		/* phase OSB correction (SSR phase bias not applied for the signal, see
+		   CorrPhaseBiasSsr()) */
+		if nav.ObsBias(obs, i, 1, &bias) > 0 {
+			L[i] -= bias
+		}
 	}
 	/* iono-free LC */
 	*Lc, *Pc = 0.0, 0.0
@@ -846,6 +873,7 @@ func (rtk *Rtk) UpdateBiasPPP(obs []ObsD, n int, nav *Nav) {
 	var (
 		L, P, dantr, dants                [NFREQ]float64
 		Lc, Pc, offset, freq1, freq2, ion float64
+		freqf                             float64
 		bias                              [MAXOBS]float64
 		pos                               [3]float64
 
@@ -906,13 +934,15 @@ func (rtk *Rtk) UpdateBiasPPP(obs []ObsD, n int, nav *Nav) {
 				}
 			} else if L[f] != 0.0 && P[f] != 0.0 {
 				freq1 = Sat2Freq(sat, obs[i].Code[0], nav)
-				freq2 = Sat2Freq(sat, obs[i].Code[f], nav)
+				freq2 = Sat2Freq(sat, obs[i].Code[1], nav)
+				freqf = Sat2Freq(sat, obs[i].Code[f], nav)
 				slip[i] = int(rtk.Ssat[sat-1].Slip[f])
-				if obs[i].P[0] == 0.0 || obs[i].P[1] == 0.0 || freq1 == 0.0 || freq2 == 0.0 {
+				if obs[i].P[0] == 0.0 || obs[i].P[1] == 0.0 || freq1 == 0.0 || freq2 == 0.0 ||
+					freqf == 0.0 {
 					continue
 				}
-				ion = (obs[i].P[0] - obs[i].P[f]) / (1.0 - SQR(freq1/freq2))
-				bias[i] = L[f] - P[f] + 2.0*ion*SQR(freq1/freq2)
+				ion = (obs[i].P[0] - obs[i].P[1]) / (1.0 - SQR(freq1/freq2))
+				bias[i] = L[f] - P[f] + 2.0*ion*SQR(freq1/freqf)
 			}
 			if rtk.X[j] == 0.0 || slip[i] > 0 || bias[i] == 0.0 {
 				continue
@@ -1053,6 +1083,10 @@ func ModelTrop(time Gtime, pos, azel []float64, opt *PrcOpt, x, dtdx []float64,
 			*dtrp = SbsTropCorr(time, pos[:], azel, vari)
 			return 1
 		}
+	case TROPOPT_ZTD:
+		{
+			return nav.CssrTropCorr(time, pos, azel, dtrp, vari)
+		}
 	case TROPOPT_EST, TROPOPT_ESTG:
 		{
 			if opt.TropOpt == TROPOPT_EST {
@@ -1096,6 +1130,17 @@ func ModelIono(time Gtime, pos, azel []float64, opt *PrcOpt, sat int, x []float6
 			*dion, *vari = 0.0, 0.0
 			return 1
 		}
+	case IONOOPT_STEC:
+		{
+			/* slant delay to vertical as mapped by ionmapf() in residuals */
+			if nav.CssrIonCorr(time, sat, pos, dion, vari) == 0 {
+				return 0
+			}
+			mapf := IonMapf(pos, azel)
+			*dion /= mapf
+			*vari /= SQR(mapf)
+			return 1
+		}
 	}
 	return 0
 }
@@ -1342,10 +1387,270 @@ func PPPResidual(post int, obs []ObsD, n int, rs, dts, var_rs []float64, svh []i
 
 }
 
-/* ambiguity resolution in ppp -----------------------------------------------*/
+/* Melbourne-Wubbena LC of corrected measurements (cycle) --------------------*/
+func mwmeas_corr(L, P []float64, freq1, freq2 float64) float64 {
+	if freq1 == 0.0 || freq2 == 0.0 || L[0] == 0.0 || L[1] == 0.0 || P[0] == 0.0 ||
+		P[1] == 0.0 {
+		return 0.0
+	}
+	return ((freq1*L[0]-freq2*L[1])/(freq1-freq2) -
+		(freq1*P[0]+freq2*P[1])/(freq1+freq2)) * (freq1 - freq2) / CLIGHT
+}
+
+/* update wide-lane ambiguity averages ----------------------------------------
+* average Melbourne-Wubbena LC of bias corrected measurements by satellite. the
+* average is reset by cycle-slip or by expiring the obs outage counter.
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) UpdateWlPPP(obs []ObsD, n int, nav *Nav) {
+	var (
+		L, P, dantr, dants [NFREQ]float64
+		Lc, Pc, mw, d      float64
+		i, sat             int
+	)
+	Trace(4, "udwl_ppp: n=%d\n", n)
+
+	for i = 0; i < n && i < len(obs); i++ {
+		sat = obs[i].Sat
+		ambc := &rtk.Ambc[sat-1]
+
+		CorrMeas(&obs[i], nav, rtk.Ssat[sat-1].Azel[:], &rtk.Opt, dantr[:], dants[:],
+			0.0, L[:], P[:], &Lc, &Pc)
+
+		if mw = mwmeas_corr(L[:], P[:], Sat2Freq(sat, obs[i].Code[0], nav),
+			Sat2Freq(sat, obs[i].Code[1], nav)); mw == 0.0 {
+			continue
+		}
+		if ambc.n[0] == 0 || rtk.Ssat[sat-1].Slip[0] > 0 || rtk.Ssat[sat-1].Slip[1] > 0 ||
+			int(rtk.Ssat[sat-1].Outc[0]) > rtk.Opt.MaxOut {
+			ambc.n[0], ambc.LC[0], ambc.LCv[0] = 0, 0.0, 0.0
+		}
+		ambc.n[0]++
+		d = mw - ambc.LC[0]
+		ambc.LC[0] += d / float64(ambc.n[0])
+		ambc.LCv[0] += (d*(mw-ambc.LC[0]) - ambc.LCv[0]) / float64(ambc.n[0])
+		ambc.epoch[0] = obs[i].Time
+
+		Trace(4, "udwl_ppp: sat=%2d n=%4d mw=%10.3f avg=%10.3f std=%6.3f\n", sat,
+			ambc.n[0], mw, ambc.LC[0], math.Sqrt(ambc.LCv[0]))
+	}
+}
+
+/* confidence function of integer ambiguity ----------------------------------*/
+func conffunc(N int, B, sig float64) float64 {
+	x, p := math.Abs(B-float64(N)), 1.0
+
+	for i := 1; i < 8; i++ {
+		p -= math.Erfc((float64(i)-x)/(math.Sqrt2*sig)) - math.Erfc((float64(i)+x)/(math.Sqrt2*sig))
+	}
+	return p
+}
+
+/* fix wide-lane ambiguity by rounding of averaged MW-LC ----------------------
+* opt.thresar[1] and [2] are used as the min confidence and the max fraction of
+* the wide-lane ambiguity
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) FixWlPPP(sat1, sat2 int, nwl *int) int {
+	a1, a2 := &rtk.Ambc[sat1-1], &rtk.Ambc[sat2-1]
+
+	if a1.n[0] < MIN_EPOCH_WL || a2.n[0] < MIN_EPOCH_WL ||
+		a1.n[0] < rtk.Opt.MinLock || a2.n[0] < rtk.Opt.MinLock {
+		return 0
+	}
+	wl := a1.LC[0] - a2.LC[0]
+	std := math.Sqrt(a1.LCv[0]/float64(a1.n[0]) + a2.LCv[0]/float64(a2.n[0]))
+	*nwl = ROUND_I(wl)
+
+	Trace(3, "fixwl_ppp: sat=%2d-%2d wl=%8.3f std=%6.3f\n", sat1, sat2, wl, std)
+
+	if math.Abs(wl-float64(*nwl)) > rtk.Opt.ThresAr[2] ||
+		conffunc(*nwl, wl, math.Max(std, 1e-3)) < rtk.Opt.ThresAr[1] {
+		return 0
+	}
+	return 1
+}
+
+/* ambiguity resolution in ppp ------------------------------------------------
+* resolve integer ambiguities of single-differences between satellites (ref
+* [12],[13]). the phase biases of satellites should be corrected in advance by
+* SSR phase biases (CorrPhaseBiasSsr()) or by observable-specific biases. the
+* wide-lane ambiguities are fixed by rounding of averaged MW-LC, and then the
+* narrow-lane (iono-free LC) or L1 (uncombined) ambiguities are fixed by LAMBDA
+* with the ratio-test. the fixed ambiguities are applied to states as
+* constraints.
+* args   : rtk_t  *rtk      IO  rtk control/result struct
+*          obsd_t *obs      I   observation data
+*          int    n         I   number of observation data
+*          int    *exc      I   excluded satellite flags
+*          nav_t  *nav      I   navigation data
+*          double *azel     I   azimuth/elevation angle (rad)
+*          double *x        IO  states (fixed states on return)
+*          double *P        IO  covariance of states
+* return : status (1:fixed,0:not fixed)
+*-----------------------------------------------------------------------------*/
 func PPPAmbiguity(rtk *Rtk, obs []ObsD, n int, exc []int, nav *Nav, azel, x, P []float64) int {
-	//"the function(ppp_ar) is truncated in 2016"
-	return 0
+	var (
+		opt                                 *PrcOpt = &rtk.Opt
+		D, DP, a, Qa, F, H, v, R, y, lam, c []float64
+		s                                   [2]float64
+		lam1, lam2                          [MAXOBS]float64
+		freq1, freq2, el, C1, C2            float64
+		i, j, k, m, nb, nx, nf, ref, info   int
+		nwl, sat, iref                      [MAXOBS]int
+		ssys                                []int = []int{SYS_GPS, SYS_GAL, SYS_QZS, SYS_CMP}
+		dual                                bool  = opt.IonoOpt == IONOOPT_IFLC || opt.Nf >= 2
+	)
+	nx = rtk.Nx
+	nf = NF(opt)
+
+	Trace(3, "pppamb  : n=%d\n", n)
+
+	rtk.RtkSol.Ratio = 0.0
+
+	if opt.ModeAr == ARMODE_OFF || opt.ThresAr[0] < 1.0 {
+		return 0
+	}
+	D = Zeros(nx, MAXOBS)
+	a = Mat(MAXOBS, 1)
+	lam = Mat(MAXOBS, 1)
+	c = Zeros(MAXOBS, 1)
+
+	/* single-differences between satellites by system */
+	for _, s0 := range ssys {
+		ref = -1
+		for i = 0; i < n && i < MAXOBS; i++ {
+			if exc[i] > 0 || SatSys(obs[i].Sat, nil) != s0 || x[IB(obs[i].Sat, 0, opt)] == 0.0 ||
+				azel[1+i*2] < opt.ElMaskAr {
+				continue
+			}
+			if dual && (nf >= 2 && x[IB(obs[i].Sat, 1, opt)] == 0.0 ||
+				Sat2Freq(obs[i].Sat, obs[i].Code[1], nav) == 0.0) {
+				continue
+			}
+			for j = 0; j < nf; j++ {
+				rtk.Ssat[obs[i].Sat-1].Fix[j] = 1
+			}
+			if ref < 0 || azel[1+i*2] > el {
+				ref, el = i, azel[1+i*2]
+			}
+		}
+		if ref < 0 {
+			continue
+		}
+		for i = 0; i < n && i < MAXOBS && nb < MAXOBS; i++ {
+			if i == ref || rtk.Ssat[obs[i].Sat-1].Fix[0] != 1 || SatSys(obs[i].Sat, nil) != s0 {
+				continue
+			}
+			freq1 = Sat2Freq(obs[i].Sat, obs[i].Code[0], nav)
+			freq2 = Sat2Freq(obs[i].Sat, obs[i].Code[1], nav)
+
+			/* wide-lane ambiguity */
+			if dual && rtk.FixWlPPP(obs[i].Sat, obs[ref].Sat, &nwl[nb]) == 0 {
+				continue
+			}
+			if dual {
+
+				/* narrow-lane ambiguity: B_IF=lam_NL*N1+c*f2/(f1^2-f2^2)*Nwl */
+				lam[nb] = CLIGHT / (freq1 + freq2)
+				c[nb] = CLIGHT * freq2 / (SQR(freq1) - SQR(freq2)) * float64(nwl[nb])
+				lam1[nb], lam2[nb] = CLIGHT/freq1, CLIGHT/freq2
+			} else {
+				lam[nb] = CLIGHT / freq1
+			}
+			if nf >= 2 {
+
+				/* iono-free LC of uncombined phase-biases */
+				C1 = SQR(freq1) / (SQR(freq1) - SQR(freq2))
+				C2 = -SQR(freq2) / (SQR(freq1) - SQR(freq2))
+				D[IB(obs[i].Sat, 0, opt)+nb*nx], D[IB(obs[ref].Sat, 0, opt)+nb*nx] = C1, -C1
+				D[IB(obs[i].Sat, 1, opt)+nb*nx], D[IB(obs[ref].Sat, 1, opt)+nb*nx] = C2, -C2
+			} else {
+				D[IB(obs[i].Sat, 0, opt)+nb*nx], D[IB(obs[ref].Sat, 0, opt)+nb*nx] = 1.0, -1.0
+			}
+			a[nb] = -c[nb]
+			for j = 0; j < nx; j++ {
+				a[nb] += D[j+nb*nx] * x[j]
+			}
+			a[nb] /= lam[nb]
+			sat[nb], iref[nb] = obs[i].Sat, obs[ref].Sat
+			nb++
+		}
+	}
+	if nb < MIN_NAMB_AR {
+		Trace(3, "pppamb  : no enough ambiguities nb=%d\n", nb)
+		return 0
+	}
+	/* covariance of float ambiguities (cycle^2) */
+	DP = Mat(nb, nx)
+	Qa = Mat(nb, nb)
+	F = Mat(nb, 2)
+	MatMul("TN", nb, nx, nx, 1.0, D, P, 0.0, DP)
+	MatMul("NN", nb, nb, nx, 1.0, DP, D, 0.0, Qa)
+	for i = 0; i < nb; i++ {
+		for j = 0; j < nb; j++ {
+			Qa[i+j*nb] /= lam[i] * lam[j]
+		}
+	}
+	/* LAMBDA/MLAMBDA ILS (integer least-square) estimation */
+	if info = Lambda(nb, 2, a, Qa, F, s[:]); info != 0 {
+		Trace(2, "pppamb  : lambda error (info=%d)\n", info)
+		return 0
+	}
+	if s[0] > 0.0 {
+		rtk.RtkSol.Ratio = float32(math.Min(s[1]/s[0], 999.9))
+	}
+	/* validation by ratio-test */
+	if s[0] > 0.0 && s[1]/s[0] < opt.ThresAr[0] {
+		Trace(2, "pppamb  : validation failed (nb=%d ratio=%.2f s=%.2f/%.2f)\n", nb,
+			s[1]/s[0], s[0], s[1])
+		return 0
+	}
+	/* constrain states to fixed ambiguities */
+	m = nb
+	if nf >= 2 {
+		m = nb * 2
+	}
+	H = Zeros(nx, m)
+	v = Mat(m, 1)
+	R = Zeros(m, m)
+	y = Mat(m, 1)
+	for i, k = 0, 0; i < nb; i++ {
+		if m > nb {
+
+			/* uncombined: B1=lam1*N1, B2=lam2*(N1-Nwl) */
+			y[k], y[k+1] = lam1[i]*F[i], lam2[i]*(F[i]-float64(nwl[i]))
+			for j = 0; j < 2; j++ {
+				H[IB(sat[i], j, opt)+(k+j)*nx] = 1.0
+				H[IB(iref[i], j, opt)+(k+j)*nx] = -1.0
+			}
+			k += 2
+		} else {
+			y[k] = lam[i]*F[i] + c[i]
+			H[IB(sat[i], 0, opt)+k*nx] = 1.0
+			H[IB(iref[i], 0, opt)+k*nx] = -1.0
+			k++
+		}
+	}
+	for i = 0; i < m; i++ {
+		v[i] = y[i]
+		for j = 0; j < nx; j++ {
+			v[i] -= H[j+i*nx] * x[j]
+		}
+		R[i+i*m] = VAR_FIXAMB
+	}
+	if info = Filter(x, P, H, v, R, nx, m); info != 0 {
+		Trace(2, "pppamb  : filter error (info=%d)\n", info)
+		return 0
+	}
+	/* set fix flags */
+	for i = 0; i < nb; i++ {
+		for j = 0; j < nf; j++ {
+			rtk.Ssat[sat[i]-1].Fix[j] = 2
+			rtk.Ssat[iref[i]-1].Fix[j] = 2
+		}
+	}
+	Trace(3, "pppamb  : validation ok (nb=%d ratio=%.2f s=%.2f/%.2f)\n", nb,
+		s[1]/s[0], s[0], s[1])
+	return 1
 }
 
 /* number of estimated states ------------------------------------------------*/
@@ -1479,6 +1784,11 @@ func (rtk *Rtk) PPPos(obs []ObsD, n int, nav *Nav) {
 	/* temporal update of ekf states */
 	rtk.UpdateStatePPP(obs, n, nav)
 
+	/* update wide-lane ambiguity averages for ppp-ar */
+	if opt.ModeAr != ARMODE_OFF {
+		rtk.UpdateWlPPP(obs, n, nav)
+	}
+
 	/* satellite positions and clocks */
 	nav.SatPoss(obs[0].Time, obs, n, rtk.Opt.SatEph, rs, dts, vari, svh[:])
 
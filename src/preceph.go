@@ -49,6 +49,8 @@
 *                            BDS B1I-B2I and IRN L5-S for API satantoff()
 *                           fix bug on reading SP3 file extension
 *		    2022/05/31 1.0  rewrite preceph.c with golang by fxb
+*           2026/10/16 1.1  support SINEX-BIAS file in api ReadDcb()
+*                           fix bug on reading DCB types and clearing DCB
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -397,7 +399,7 @@ func (nav *Nav) ReadSp3(file string, opt int) {
 		sats               []int    = make([]int, MAXSAT)
 		efiles             []string = make([]string, MAXEXFILE)
 		ctype, tsys        string
-		err                error
+		rd                 *bufio.Reader
 	)
 
 	Trace(4, "readpephs: file=%s\n", file)
@@ -406,21 +408,21 @@ func (nav *Nav) ReadSp3(file string, opt int) {
 	n = ExPath(file, efiles, MAXEXFILE)
 
 	for i, j = 0, 0; i < n; i++ {
-		if index = strings.LastIndex(efiles[i], "."); index < 0 {
+		name := UncompressFileName(efiles[i])
+		if index = strings.LastIndex(name, "."); index < 0 {
 			continue
 		}
-		ext := efiles[i][index:]
+		ext := name[index:]
 		if !strings.EqualFold(ext, ".sp3") && !strings.EqualFold(ext, ".phh") {
 			continue
 		}
 
-		if fp, err = os.OpenFile(efiles[i], os.O_RDONLY, 0666); err != nil {
+		if rd, fp = OpenUncompressFile(efiles[i], nil); rd == nil {
 			Trace(2, "sp3 file open error %s\n", efiles[i])
 			continue
 		}
 		defer fp.Close()
 
-		rd := bufio.NewReader(fp)
 		/* read sp3 header */
 		ns = ReadSp3Header(rd, &time, &ctype, sats, bfact[:], &tsys)
 
@@ -469,7 +471,14 @@ func (nav *Nav) ReadSAP(file string, time Gtime) int {
 	return 1
 }
 
-/* read DCB parameters file --------------------------------------------------*/
+/* read DCB parameters file ----------------------------------------------------
+* read DCB parameters file (CODE DCB or SINEX-BIAS)
+* args   : char   *file     I   DCB parameters file path
+*          sta_t  *sta      I   station info data to read receiver DCB
+*                               (NULL: no use)
+* return : status (1:ok,0:error)
+* notes  : SINEX-BIAS file is read by ReadBiasF() into the signal bias table
+*-----------------------------------------------------------------------------*/
 func (nav *Nav) ReadDcbF(file string, sta []Sta) int {
 	var (
 		fp                  *os.File
@@ -493,6 +502,9 @@ func (nav *Nav) ReadDcbF(file string, sta []Sta) int {
 		if err != nil {
 			break
 		}
+		if strings.HasPrefix(buff, "%=BIA") { /* SINEX-BIAS */
+			return nav.ReadBiasF(file, sta)
+		}
 		if strings.Contains(buff, "DIFFERENTIAL (P1-P2) CODE BIASES") {
 			ctype = 1
 		} else if strings.Contains(buff, "DIFFERENTIAL (P1-C1) CODE BIASES") {
@@ -502,7 +514,7 @@ func (nav *Nav) ReadDcbF(file string, sta []Sta) int {
 		}
 
 		n, _ = fmt.Sscanf(buff, "%s %s", &str1, &str2)
-		if ctype > 0 || n < 1 {
+		if ctype == 0 || n < 1 {
 			continue
 		}
 
@@ -548,11 +560,12 @@ func (nav *Nav) ReadDcb(file string, sta []Sta) int {
 
 	Trace(4, "readdcb : file=%s\n", file)
 
-	for _, v := range nav.CBias {
+	for i = range nav.CBias {
 		for j = 0; j < 3; j++ {
-			v[j] = 0.0
+			nav.CBias[i][j] = 0.0
 		}
 	}
+	nav.Bias = nil
 
 	n = ExPath(file, efiles, MAXEXFILE)
 
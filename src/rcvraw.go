@@ -49,6 +49,7 @@
 *                           add reference [6]
 *                           use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite rcvraw.c with golang by fxb
+*           2026/10/16 1.1  add imu data in raw control struct
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -1731,6 +1732,8 @@ func (raw *Raw) InitRaw(format int) int {
 	raw.NavData.Geph = make([]GEph, NSATGLO)
 	raw.NavData.Seph = make([]SEph, NSATSBS*2)
 	raw.RcvData = nil
+	raw.sbf, raw.rt17 = nil, nil
+	raw.ImuData = Imu{}
 
 	for i = 0; i < MAXOBS; i++ {
 		raw.ObsData.Data[i] = data0
@@ -1762,7 +1765,12 @@ func (raw *Raw) InitRaw(format int) int {
 	/* initialize receiver dependent data */
 	raw.Format = format
 	switch byte(format) {
-	// case STRFMT_RT17: ret=init_rt17(raw); break;
+	case STRFMT_RT17:
+		ret = init_rt17(raw)
+	case STRFMT_SEPT:
+		ret = init_sbf(raw)
+	case STRFMT_UNICORE:
+		ret = 1
 	}
 	if ret == 0 {
 		raw = nil
@@ -1802,6 +1810,8 @@ type InputFileRaw interface {
 	input_rt17f(fp *os.File) int
 	// case STRFMT_SEPT:
 	input_sbff(fp *os.File) int
+	// case STRFMT_UNICORE:
+	input_unicoref(fp *os.File) int
 }
 
 /* input receiver raw data from stream -----------------------------------------
@@ -1811,7 +1821,7 @@ type InputFileRaw interface {
 *          uint8_t data     I   stream data (1 byte)
 * return : status (-1: error message, 0: no message, 1: input observation data,
 *                  2: input ephemeris, 3: input sbas message,
-*                  9: input ion/utc parameter)
+*                  9: input ion/utc parameter, 11: input imu data)
 *-----------------------------------------------------------------------------*/
 func (raw *Raw) InputRaw(format int, data uint8) int {
 	Trace(4, "input_raw: format=%d data=0x%02x\n", format, data)
@@ -1835,10 +1845,12 @@ func (raw *Raw) InputRaw(format int, data uint8) int {
 		return Input_nvs(raw, data)
 	case STRFMT_BINEX:
 		return Input_bnx(raw, data)
-		// case STRFMT_RT17:
-		// 	return input_rt17(raw, data)
-		// case STRFMT_SEPT:
-		// 	return input_sbf(raw, data)
+	case STRFMT_RT17:
+		return input_rt17(raw, data)
+	case STRFMT_SEPT:
+		return input_sbf(raw, data)
+	case STRFMT_UNICORE:
+		return input_unicore(raw, data)
 	}
 	return 0
 }
@@ -1872,10 +1884,12 @@ func (raw *Raw) InputRawF(format int, fp *os.File) int {
 		return Input_nvsf(raw, fp)
 	case STRFMT_BINEX:
 		return Input_bnxf(raw, fp)
-		// case STRFMT_RT17:
-		//	return input_rt17f(raw, fp)
-		// case STRFMT_SEPT:
-		//		return input_sbff(raw, fp)
+	case STRFMT_RT17:
+		return input_rt17f(raw, fp)
+	case STRFMT_SEPT:
+		return input_sbff(raw, fp)
+	case STRFMT_UNICORE:
+		return input_unicoref(raw, fp)
 	}
 	return -2
 }
@@ -28,6 +28,10 @@
 *         International GNSS Service (IGS), RINEX Working Group and Radio
 *         Technical Commission for Maritime Services Special Committee 104
 *         (RTCM-SC104), November 23, 2018
+*     [10] RINEX The Receiver Independent Exchange Format Version 4.00,
+*         International GNSS Service (IGS), RINEX Working Group and Radio
+*         Technical Commission for Maritime Services Special Committee 104
+*         (RTCM-SC104), December 1, 2021
 *
 * version : $Revision:$
 * history : 2006/01/16 1.0  new
@@ -171,6 +175,17 @@ func setstr(dst *string, src string, n int) {
 	})
 }
 
+/* substring with bounds check -----------------------------------------------*/
+func substr(s string, i, n int) string {
+	if i >= len(s) {
+		return ""
+	}
+	if i+n > len(s) {
+		n = len(s) - i
+	}
+	return s[i : i+n]
+}
+
 /* adjust time considering week handover -------------------------------------*/
 func AdjWeek(t, t0 Gtime) Gtime {
 	tt := TimeDiff(t, t0)
@@ -1635,9 +1650,363 @@ func (seph *SEph) DecodeSEph(ver float64, sat int, toc Gtime, data []float64) in
 	return 1
 }
 
+/* decode GPS/QZS CNAV/CNAV-2 ephemeris (ver.4) ------------------------------*/
+func (eph *Eph) DecodeCnavEph(ver float64, sat int, toc Gtime, data []float64, n int) int {
+	var (
+		eph0 Eph
+		tow  float64
+		week int
+	)
+
+	Trace(4, "decode_cnaveph: ver=%.2f sat=%2d\n", ver, sat)
+
+	if SatSys(sat, nil)&(SYS_GPS|SYS_QZS) == 0 {
+		Trace(2, "cnav ephemeris error: invalid satellite sat=%2d\n", sat)
+		return 0
+	}
+	*eph = eph0
+
+	eph.Sat = sat
+	eph.Toc = toc
+
+	eph.F0 = data[0]
+	eph.F1 = data[1]
+	eph.F2 = data[2]
+
+	eph.Adot = data[3]
+	eph.Crs = data[4]
+	eph.Deln = data[5]
+	eph.M0 = data[6]
+	eph.Cuc = data[7]
+	eph.E = data[8]
+	eph.Cus = data[9]
+	eph.A = SQR(data[10])
+	eph.Cic = data[12]
+	eph.OMG0 = data[13]
+	eph.Cis = data[14]
+	eph.I0 = data[15]
+	eph.Crc = data[16]
+	eph.Omg = data[17]
+	eph.OMGd = data[18]
+	eph.Idot = data[19]
+	eph.Ndot = data[20]
+
+	/* CNAV: toe = toc */
+	tow = Time2GpsT(toc, &week)
+	eph.Toe = toc
+	eph.Toes = tow
+	eph.Week = week
+
+	if data[21] > 0.0 {
+		eph.Sva = int(data[21]) /* URAI_NED0 */
+	}
+	eph.Svh = int(data[24])
+	eph.Tgd[0] = data[25] /* TGD */
+	switch {
+	case n >= 39: /* CNAV-2 */
+		eph.Tgd[1] = data[31] /* ISC_L1Cd */
+		eph.Tgd[2] = data[32] /* ISC_L1Cp */
+	default:
+		eph.Tgd[1] = data[27] /* ISC_L1C/A */
+		eph.Tgd[2] = data[28] /* ISC_L2C */
+		eph.Tgd[3] = data[29] /* ISC_L5I5 */
+		eph.Tgd[4] = data[30] /* ISC_L5Q5 */
+	}
+	/* t_tm in the last record line */
+	eph.Ttr = AdjWeek(GpsT2Time(week, data[n-4]), toc)
+	eph.Fit = 3.0
+	return 1
+}
+
+/* decode RINEX ver.4 system time offset record ------------------------------*/
+func (nav *Nav) DecodeStoRec(lines []string) {
+	var (
+		t    Gtime
+		utc  []float64
+		tot  float64
+		week int
+	)
+
+	if len(lines) < 2 {
+		return
+	}
+	if Str2Time(lines[0], 4, 19, &t) < 0 {
+		Trace(2, "rinex sto epoch error: %s\n", lines[0])
+		return
+	}
+	id := fmt.Sprintf("%-4.4s", substr(lines[0], 24, 4))
+	a0 := Str2Num(lines[1], 23, 19)
+	a1 := Str2Num(lines[1], 42, 19)
+	a2 := Str2Num(lines[1], 61, 19)
+
+	switch id {
+	case "GPUT":
+		utc = nav.Utc_gps[:]
+	case "GAUT":
+		utc = nav.Utc_gal[:]
+	case "QZUT":
+		utc = nav.Utc_qzs[:]
+	case "BDUT":
+		utc = nav.Utc_cmp[:]
+	case "SBUT":
+		utc = nav.Utc_sbs[:]
+	case "IRUT":
+		utc = nav.Utc_irn[:]
+		nav.Utc_irn[8] = a2
+	case "GLUT":
+		nav.Utc_glo[0] = -a0 /* tau_C */
+		return
+	case "GLGP":
+		nav.Utc_glo[1] = a0 /* tau_GPS */
+		return
+	default:
+		Trace(3, "rinex sto unsupported: id=%s\n", id)
+		return
+	}
+	/* reference time t_ot in system time */
+	if id == "BDUT" {
+		tot = Time2BDT(t, &week)
+	} else {
+		tot = Time2GpsT(t, &week)
+	}
+	utc[0] = a0
+	utc[1] = a1
+	utc[2] = tot
+	utc[3] = float64(week)
+}
+
+/* decode RINEX ver.4 earth orientation parameter record ---------------------*/
+func (nav *Nav) DecodeEopRec(lines []string) {
+	var (
+		t    Gtime
+		ep   = []float64{2000, 1, 1, 12, 0, 0}
+		erp  ErpD
+		i, n int
+	)
+
+	if len(lines) < 3 {
+		return
+	}
+	if Str2Time(lines[0], 4, 19, &t) < 0 {
+		Trace(2, "rinex eop epoch error: %s\n", lines[0])
+		return
+	}
+	erp.Mjd = 51544.5 + TimeDiff(GpsT2Utc(t), Epoch2Time(ep))/86400.0
+	erp.Xp = Str2Num(lines[0], 23, 19) * AS2R
+	erp.Xpr = Str2Num(lines[0], 42, 19) * AS2R
+	erp.Yp = Str2Num(lines[1], 23, 19) * AS2R
+	erp.Ypr = Str2Num(lines[1], 42, 19) * AS2R
+	erp.Ut1_utc = Str2Num(lines[2], 23, 19)
+	erp.Lod = -Str2Num(lines[2], 42, 19) /* lod = -d(ut1-utc)/dt */
+
+	/* keep erp data sorted by mjd */
+	n = nav.Erp.N()
+	for i = 0; i < n && nav.Erp.Data[i].Mjd < erp.Mjd; i++ {
+	}
+	if i < n && math.Abs(nav.Erp.Data[i].Mjd-erp.Mjd) < 1e-6 {
+		nav.Erp.Data[i] = erp
+		return
+	}
+	nav.Erp.Data = append(nav.Erp.Data, ErpD{})
+	copy(nav.Erp.Data[i+1:], nav.Erp.Data[i:])
+	nav.Erp.Data[i] = erp
+}
+
+/* decode RINEX ver.4 ionosphere model record --------------------------------*/
+func (nav *Nav) DecodeIonRec(sys int, mtype string, lines []string) {
+	var (
+		ion    []float64
+		i, j   int
+		values [12]float64
+	)
+
+	if len(lines) < 2 {
+		return
+	}
+	for i = 0; i < len(lines) && i < 3; i++ {
+		for j = 0; j < 4; j++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			values[i*4+j] = Str2Num(lines[i], 4+19*j, 19)
+		}
+	}
+	switch {
+	case sys == SYS_GAL && mtype == "IFNV": /* NeQuick-G: ai0,ai1,ai2,flags */
+		nav.Ion_gal[0] = values[1]
+		nav.Ion_gal[1] = values[2]
+		nav.Ion_gal[2] = values[3]
+		nav.Ion_gal[3] = values[4]
+		return
+	case sys == SYS_GPS && mtype == "LNAV":
+		ion = nav.Ion_gps[:]
+	case sys == SYS_QZS && mtype == "LNAV":
+		ion = nav.Ion_qzs[:]
+	case sys == SYS_IRN && mtype == "LNAV":
+		ion = nav.Ion_irn[:]
+	case sys == SYS_CMP && mtype == "D1D2":
+		ion = nav.Ion_cmp[:]
+	default:
+		Trace(3, "rinex ion unsupported: sys=%d type=%s\n", sys, mtype)
+		return
+	}
+	if len(lines) < 3 {
+		return
+	}
+	/* Klobuchar: alpha0-3,beta0-3 */
+	for i = 0; i < 8; i++ {
+		ion[i] = values[i+1]
+	}
+}
+
+/* read RINEX ver.4 navigation record lines ----------------------------------*/
+func ReadRnxNavRec(rd *bufio.Reader) []string {
+	var lines []string
+
+	for {
+		if p, err := rd.Peek(1); err != nil || p[0] == '>' {
+			break
+		}
+		buff, err := rd.ReadString('\n')
+		if len(buff) > 0 {
+			lines = append(lines, buff)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return lines
+}
+
+/* read RINEX ver.4 navigation data body -------------------------------------*/
+func ReadRnxNavBody4(rd *bufio.Reader, opt string, ver float64, ctype *int,
+	eph *Eph, geph *GEph, seph *SEph, nav *Nav) int {
+	var (
+		toc                     Gtime
+		data                    [64]float64
+		i, j, k, sat, sys, mask int
+		buff, rtype, id, mtype  string
+		lines                   []string
+		err                     error
+	)
+
+	Trace(4, "readrnxnavb4: ver=%.2f\n", ver)
+
+	/* set system mask */
+	mask = SetSysMask(opt)
+
+	for {
+		if buff, err = rd.ReadString('\n'); len(buff) == 0 && err != nil {
+			break
+		}
+		if !strings.HasPrefix(buff, "> ") {
+			continue
+		}
+		rtype = strings.TrimSpace(substr(buff, 2, 3))
+		id = substr(buff, 6, 3)
+		mtype = strings.TrimSpace(substr(buff, 10, 4))
+		lines = ReadRnxNavRec(rd)
+
+		sat = SatId2No(id)
+		if sys = SatSys(sat, nil); sys == SYS_NONE {
+			Trace(2, "rinex nav invalid satellite: %s\n", id)
+			continue
+		}
+		if mask&sys == 0 {
+			continue
+		}
+		switch rtype {
+		case "STO":
+			if nav != nil {
+				nav.DecodeStoRec(lines)
+			}
+			continue
+		case "EOP":
+			if nav != nil {
+				nav.DecodeEopRec(lines)
+			}
+			continue
+		case "ION":
+			if nav != nil {
+				nav.DecodeIonRec(sys, mtype, lines)
+			}
+			continue
+		case "EPH":
+		default:
+			Trace(3, "rinex nav unsupported record: %s\n", rtype)
+			continue
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		/* decode Toc and data fields (same layout as ver.3) */
+		if Str2Time(lines[0], 4, 19, &toc) < 0 {
+			Trace(2, "rinex nav toc error: %23.23s\n", lines[0])
+			continue
+		}
+		for i, k = 0, 0; k < len(lines) && i+4 <= len(data); k++ {
+			if k == 0 {
+				for j = 0; j < 3; i, j = i+1, j+1 {
+					data[i] = Str2Num(lines[k], 23+19*j, 19)
+				}
+			} else {
+				for j = 0; j < 4; i, j = i+1, j+1 {
+					data[i] = Str2Num(lines[k], 4+19*j, 19)
+				}
+			}
+		}
+		/* decode ephemeris */
+		switch {
+		case sys == SYS_GLO && mtype == "FDMA" && i >= 15:
+			*ctype = 1
+			if geph.DecodeGEph(ver, sat, toc, data[:]) == 0 {
+				continue
+			}
+			if i >= 19 {
+				geph.DTaun = data[16] /* L1/L2 group delay difference */
+				geph.Sva = int(data[17])
+			}
+			return 1
+		case sys == SYS_SBS && mtype == "SBAS" && i >= 15:
+			*ctype = 2
+			if seph.DecodeSEph(ver, sat, toc, data[:]) > 0 {
+				return 1
+			}
+		case (sys&(SYS_GPS|SYS_QZS|SYS_IRN) != 0 && mtype == "LNAV") ||
+			(sys == SYS_GAL && (mtype == "INAV" || mtype == "FNAV")) ||
+			(sys == SYS_CMP && (mtype == "D1" || mtype == "D2")):
+			if i < 31 {
+				break
+			}
+			*ctype = 0
+			if eph.DecodeEph(ver, sat, toc, data[:]) == 0 {
+				continue
+			}
+			if sys == SYS_CMP {
+				eph.Flag = 1 /* IGSO/MEO */
+				if mtype == "D2" {
+					eph.Flag = 2 /* GEO */
+				}
+			}
+			return 1
+		case sys&(SYS_GPS|SYS_QZS) != 0 && (mtype == "CNAV" || mtype == "CNV2"):
+			if i < 35 {
+				break
+			}
+			*ctype = 0
+			if eph.DecodeCnavEph(ver, sat, toc, data[:], i) > 0 {
+				return 1
+			}
+		default:
+			Trace(3, "rinex nav unsupported message: %s %s\n", id, mtype)
+		}
+	}
+	return -1
+}
+
 /* read RINEX navigation data body -------------------------------------------*/
 func ReadRnxNavBody(rd *bufio.Reader, opt string, ver float64, sys int,
-	ctype *int, eph *Eph, geph *GEph, seph *SEph) int {
+	ctype *int, eph *Eph, geph *GEph, seph *SEph, nav *Nav) int {
 	var (
 		toc                         Gtime
 		data                        [64]float64
@@ -1648,6 +2017,9 @@ func ReadRnxNavBody(rd *bufio.Reader, opt string, ver float64, sys int,
 
 	Trace(4, "readrnxnavb: ver=%.2f sys=%d\n", ver, sys)
 
+	if ver >= 4.0 { /* ver.4 */
+		return ReadRnxNavBody4(rd, opt, ver, ctype, eph, geph, seph, nav)
+	}
 	/* set system mask */
 	mask = SetSysMask(opt)
 
@@ -1764,10 +2136,13 @@ func (nav *Nav) ReadRnxNav(rd *bufio.Reader, opt string, ver float64, sys int) i
 	/* read RINEX navigation data body */
 
 	for {
-		stat = ReadRnxNavBody(rd, opt, ver, sys, &ctype, &eph, &geph, &seph)
+		stat = ReadRnxNavBody(rd, opt, ver, sys, &ctype, &eph, &geph, &seph, nav)
 		if stat < 0 {
 			break
 		}
+		if stat == 0 {
+			continue
+		}
 		/* add ephemeris to navigation data */
 		switch ctype {
 		case 1:
@@ -1897,9 +2272,8 @@ func ReadRnxFile(file string, ts, te Gtime, tint float64,
 	obs *Obs, nav *Nav, sta *Sta) int {
 	var (
 		fp          *os.File
+		rd          *bufio.Reader
 		cstat, stat int
-		tmpfile     string
-		err         error
 	)
 
 	Trace(4, "readrnxfile: file=%s flag=%d index=%d\n", file, flag, index)
@@ -1908,33 +2282,20 @@ func ReadRnxFile(file string, ts, te Gtime, tint float64,
 		sta.InitSta()
 	}
 
-	/* uncompress file */
-	if cstat = Rtk_Uncompress(file, &tmpfile); cstat < 0 {
-		Trace(2, "rinex file uncompact error: %s\n", file)
-		return 0
-	}
-	if cstat > 0 {
-		fp, err = os.OpenFile(tmpfile, os.O_RDONLY, 0666)
-	} else {
-		fp, err = os.OpenFile(file, os.O_RDONLY, 0666)
-	}
-	if err != nil {
-		if cstat == 0 {
-			tmpfile = file
+	/* open and uncompress file */
+	if rd, fp = OpenUncompressFile(file, &cstat); rd == nil {
+		if cstat < 0 {
+			Trace(2, "rinex file uncompact error: %s\n", file)
+		} else {
+			Trace(2, "rinex file open error: %s\n", file)
 		}
-		Trace(2, "rinex file open error: %s\n", tmpfile)
 		return 0
 	}
 	defer fp.Close()
+
 	/* read RINEX file */
-	rd := bufio.NewReader(fp)
 	stat = ReadRnxFp(rd, ts, te, tint, opt, flag, index, ctype, obs, nav, sta)
 
-	/* delete temporary file */
-	if cstat > 0 {
-		os.Remove(tmpfile)
-	}
-
 	return stat
 }
 
@@ -2268,7 +2629,7 @@ func (rnx *RnxCtr) InputRnxCtr(rd *bufio.Reader) int {
 	default:
 		return 0
 	}
-	if stat = ReadRnxNavBody(rd, rnx.opt, rnx.ver, sys, &itype, &eph, &geph, &seph); stat <= 0 {
+	if stat = ReadRnxNavBody(rd, rnx.opt, rnx.ver, sys, &itype, &eph, &geph, &seph, &rnx.nav); stat <= 0 {
 		if stat < 0 {
 			return -2
 		} else {
@@ -2277,13 +2638,13 @@ func (rnx *RnxCtr) InputRnxCtr(rd *bufio.Reader) int {
 	}
 	switch itype {
 	case 1: /* GLONASS ephemeris */
-		// sys = SatSys(geph.Sat, &prn)
+		SatSys(geph.Sat, &prn)
 		rnx.nav.Geph[prn-1] = geph
 		rnx.time = geph.Tof
 		rnx.ephsat = geph.Sat
 		rnx.ephset = 0
 	case 2: /* SBAS ephemeris */
-		// sys = SatSys(seph.Sat, &prn)
+		SatSys(seph.Sat, &prn)
 		rnx.nav.Seph[prn-MINPRNSBS] = seph
 		rnx.time = seph.Tof
 		rnx.ephsat = seph.Sat
@@ -2861,7 +3222,7 @@ func OutIonoSys(fp *os.File, sys string, ion []float64, n int) {
 
 /* output iono corrections --------------------------------------------------*/
 func OutIono(fp *os.File, opt *RnxOpt, sys int, nav *Nav) {
-	if opt.Outiono == 0 {
+	if opt.Outiono == 0 || opt.RnxVer >= 400 { /* ver.4: ION records */
 		return
 	}
 
@@ -2914,7 +3275,7 @@ func OutTimeSys(fp *os.File, sys string, utc []float64) {
 func OutTime(fp *os.File, opt *RnxOpt, sys int, nav *Nav) {
 	var utc [8]float64
 
-	if opt.OutputTime == 0 {
+	if opt.OutputTime == 0 || opt.RnxVer >= 400 { /* ver.4: STO records */
 		return
 	}
 
@@ -3062,6 +3423,155 @@ func OutRnxNavHeader(fp *os.File, opt *RnxOpt, nav *Nav) int {
 	return 0
 }
 
+/* RINEX ver.4 navigation message type of ephemeris --------------------------*/
+func RnxNavType(sys, prn int, eph *Eph) string {
+	switch sys {
+	case SYS_GAL:
+		if eph.Code&(1<<1) != 0 { /* F/NAV E5a-I */
+			return "FNAV"
+		}
+		return "INAV"
+	case SYS_CMP:
+		if prn <= 5 || prn >= 59 { /* GEO */
+			return "D2"
+		}
+		return "D1"
+	}
+	return "LNAV"
+}
+
+/* output RINEX ver.4 record epoch -------------------------------------------*/
+func OutRnxRecEpoch(fp *os.File, sys int, time Gtime) {
+	var ep [6]float64
+
+	if sys == SYS_CMP {
+		time = GpsT2BDT(time) /* gpst . bdt */
+	}
+	Time2Epoch(time, ep[:])
+	fp.WriteString(fmt.Sprintf("    %04.0f %02.0f %02.0f %02.0f %02.0f %02.0f", ep[0], ep[1],
+		ep[2], ep[3], ep[4], ep[5]))
+}
+
+/* output RINEX ver.4 EOP record --------------------------------------------*/
+func OutRnxEopRec(fp *os.File, code string, erp *ErpD, ttr Gtime) {
+	ep := []float64{2000, 1, 1, 12, 0, 0}
+
+	t := TimeAdd(Epoch2Time(ep), (erp.Mjd-51544.5)*86400.0)
+	fp.WriteString(fmt.Sprintf("> EOP %-3s CNVX\n", code))
+	OutRnxRecEpoch(fp, SYS_GPS, Utc2GpsT(t))
+	OutNavf(fp, erp.Xp/AS2R)
+	OutNavf(fp, erp.Xpr/AS2R)
+	OutNavf(fp, 0.0)
+	fp.WriteString(fmt.Sprintf("\n%23s", ""))
+	OutNavf(fp, erp.Yp/AS2R)
+	OutNavf(fp, erp.Ypr/AS2R)
+	OutNavf(fp, 0.0)
+	fp.WriteString("\n    ")
+	OutNavf(fp, Time2GpsT(ttr, nil))
+	OutNavf(fp, erp.Ut1_utc)
+	OutNavf(fp, -erp.Lod) /* d(ut1-utc)/dt = -lod */
+	OutNavf(fp, 0.0)
+	fp.WriteString("\n")
+}
+
+/* output RINEX ver.4 ION, STO and EOP records ---------------------------------
+* output RINEX ver.4 ionosphere, system time offset and earth orientation
+* parameter records
+* args   : FILE   *fp       I   output file pointer
+*          rnxopt_t *opt    I   RINEX options
+*          nav_t  *nav      I   navigation data
+* return : none
+* notes  : the records are attributed to the latest ephemeris of each system in
+*          nav, a system without ephemeris is not output. EOP records are
+*          output with GPS
+*-----------------------------------------------------------------------------*/
+func OutRnxNavRecs(fp *os.File, opt *RnxOpt, nav *Nav) {
+	var (
+		syss      = []int{SYS_GPS, SYS_GAL, SYS_QZS, SYS_CMP, SYS_IRN}
+		ions      = [][]float64{nav.Ion_gps[:], nav.Ion_gal[:3], nav.Ion_qzs[:], nav.Ion_cmp[:], nav.Ion_irn[:]}
+		utcs      = [][]float64{nav.Utc_gps[:], nav.Utc_gal[:], nav.Utc_qzs[:], nav.Utc_cmp[:], nav.Utc_irn[:]}
+		ionid     = []string{"LNAV", "IFNV", "LNAV", "D1D2", "LNAV"}
+		stoid     = []string{"GPUT", "GAUT", "QZUT", "BDUT", "IRUT"}
+		utcid     = []string{"UTC(USNO)", "UTCGAL", "UTC(NICT)", "UTC(NTSC)", "UTCIRN"}
+		eph       *Eph
+		t         Gtime
+		code      string
+		ttm, a2   float64
+		i, j, prn int
+	)
+
+	Trace(4, "outrnxnavrecs:\n")
+
+	if opt.RnxVer < 400 {
+		return
+	}
+	for i = 0; i < len(syss); i++ {
+		if syss[i]&opt.NavSys == 0 || (opt.Sep_Nav > 0 && syss[i] != SYS_GPS) {
+			continue
+		}
+		/* latest ephemeris of the system */
+		for j, eph = 0, nil; j < nav.N(); j++ {
+			if SatSys(nav.Ephs[j].Sat, &prn) != syss[i] {
+				continue
+			}
+			if eph == nil || TimeDiff(nav.Ephs[j].Ttr, eph.Ttr) > 0.0 {
+				eph = &nav.Ephs[j]
+			}
+		}
+		if eph == nil || Sat2Code(eph.Sat, &code) == 0 {
+			continue
+		}
+		SatSys(eph.Sat, &prn)
+
+		if opt.Outiono > 0 && Norm(ions[i], len(ions[i])) > 0.0 {
+			fp.WriteString(fmt.Sprintf("> ION %-3s %s\n", code, ionid[i]))
+			OutRnxRecEpoch(fp, syss[i], eph.Ttr)
+			for j = 0; j < len(ions[i]); j++ {
+				if j%4 == 3 {
+					fp.WriteString("\n    ")
+				}
+				OutNavf(fp, ions[i][j])
+			}
+			if syss[i] == SYS_GAL {
+				fp.WriteString("\n    ")
+				OutNavf(fp, nav.Ion_gal[3]) /* disturbance flags */
+			} else {
+				OutNavf(fp, 0.0) /* region code */
+			}
+			fp.WriteString("\n")
+		}
+		if opt.OutputTime > 0 && Norm(utcs[i], 3) > 0.0 {
+			if syss[i] == SYS_CMP {
+				t = BDT2GpsT(BDT2Time(int(utcs[i][3]), utcs[i][2])) /* bdt . gpst */
+			} else {
+				t = GpsT2Time(int(utcs[i][3]), utcs[i][2])
+			}
+			fp.WriteString(fmt.Sprintf("> STO %-3s %s\n", code, RnxNavType(syss[i], prn, eph)))
+			OutRnxRecEpoch(fp, syss[i], t)
+			fp.WriteString(fmt.Sprintf(" %-18s%-18s%-18s\n    ", stoid[i], "", utcid[i]))
+			if syss[i] == SYS_CMP {
+				ttm = Time2BDT(GpsT2BDT(eph.Ttr), nil)
+			} else {
+				ttm = Time2GpsT(eph.Ttr, nil)
+			}
+			a2 = 0.0
+			if syss[i] == SYS_IRN {
+				a2 = nav.Utc_irn[8]
+			}
+			OutNavf(fp, ttm)
+			OutNavf(fp, utcs[i][0])
+			OutNavf(fp, utcs[i][1])
+			OutNavf(fp, a2)
+			fp.WriteString("\n")
+		}
+		if opt.OutputTime > 0 && syss[i] == SYS_GPS {
+			for j = 0; j < nav.Erp.N(); j++ {
+				OutRnxEopRec(fp, code, &nav.Erp.Data[j], eph.Ttr)
+			}
+		}
+	}
+}
+
 /* output RINEX navigation data file body --------------------------------------
 * output RINEX navigation data file body
 * args   : FILE   *fp       I   output file pointer
@@ -3083,6 +3593,12 @@ func OutRnxNavBody(fp *os.File, opt *RnxOpt, eph *Eph) int {
 		return 0
 	}
 
+	if opt.RnxVer >= 400 { /* ver.4 */
+		if Sat2Code(eph.Sat, &code) == 0 {
+			return 0
+		}
+		fp.WriteString(fmt.Sprintf("> EPH %-3s %s\n", code, RnxNavType(sys, prn, eph)))
+	}
 	if sys != SYS_CMP {
 		Time2Epoch(eph.Toc, ep[:])
 	} else {
@@ -3267,6 +3783,9 @@ func OutRnxGnavBody(fp *os.File, opt *RnxOpt, geph *GEph) int {
 		if Sat2Code(geph.Sat, &code) == 0 {
 			return 0
 		}
+		if opt.RnxVer >= 400 { /* ver.4 */
+			fp.WriteString(fmt.Sprintf("> EPH %-3s FDMA\n", code))
+		}
 		fp.WriteString(fmt.Sprintf("%-3s %04.0f %02.0f %02.0f %02.0f %02.0f %02.0f", code, ep[0],
 			ep[1], ep[2], ep[3], ep[4], ep[5]))
 		sep = "    "
@@ -3293,6 +3812,13 @@ func OutRnxGnavBody(fp *os.File, opt *RnxOpt, geph *GEph) int {
 	OutNavf(fp, geph.Acc[2]/1e3)
 
 	OutNavf(fp, float64(geph.Age))
+	if opt.RnxVer >= 400 { /* ver.4 */
+		fp.WriteString(fmt.Sprintf("\n%s", sep))
+		OutNavf(fp, 0.0)                 /* status flags */
+		OutNavf(fp, geph.DTaun)          /* L1/L2 group delay difference */
+		OutNavf(fp, float64(geph.Sva))   /* URAI */
+		OutNavf(fp, float64(geph.Svh&1)) /* health flags */
+	}
 	_, err := fp.WriteString("\n")
 	if err != io.EOF {
 		return 1
@@ -3369,6 +3895,9 @@ func OutRnxHnavBody(fp *os.File, opt *RnxOpt, seph *SEph) int {
 		if Sat2Code(seph.Sat, &code) == 0 {
 			return 0
 		}
+		if opt.RnxVer >= 400 { /* ver.4 */
+			fp.WriteString(fmt.Sprintf("> EPH %-3s SBAS\n", code))
+		}
 		fp.WriteString(fmt.Sprintf("%-3s %04.0f %2.0f %2.0f %2.0f %2.0f %2.0f", code, ep[0], ep[1],
 			ep[2], ep[3], ep[4], ep[5]))
 		sep = "    "
@@ -0,0 +1,671 @@
+/*------------------------------------------------------------------------------
+* rt17.go : Trimble RT17 receiver dependent functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] Trimble, Trimble OEM BD9xx GNSS Receiver Family ICD, Version 4.82,
+*         Revision A, 2013
+*     [2] Trimble, Data Collector Format Packets, General Serial Interface
+*         Specification, RAWDATA (57h) and RETSVDATA (55h) reports
+*     [3] Trimble, GSOF Messages, General Serial Output Format, GENOUT (40h)
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, support RAWDATA real-time survey data
+*                           record (type 17, concise/expanded/enhanced),
+*                           RETSVDATA GPS ephemeris, ionosphere and utc
+*                           reports and GENOUT GSOF position time record
+*
+* notes  : RT17 measurement records only carry the time of week. The GPS week
+*          is taken from -WEEK=n option, GSOF position time records, GPS
+*          ephemeris/utc reports or the approximate time raw.Time in this
+*          order. Observation data are not output until the week is known.
+*
+*          Multi-page RAWDATA and GENOUT packets are reassembled into the
+*          record buffer before decoding. Enhanced real-time survey data
+*          (RT27, record type 6) are not supported and skipped.
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	RT17_STX = 0x02 /* Trimble packet start of transmission */
+	RT17_ETX = 0x03 /* Trimble packet end of transmission */
+
+	RT17_GENOUT    = 0x40 /* general serial output format (GSOF) */
+	RT17_RETSVDATA = 0x55 /* satellite information reports */
+	RT17_RAWDATA   = 0x57 /* position or real-time survey data report */
+
+	RT17_HLEN    = 4     /* packet header length (stx,status,type,length) */
+	RT17_MAXPAGE = 16    /* max number of pages of a record */
+	RT17_MAXREC  = 16384 /* max length of reassembled record */
+
+	RT17_RAW_SURVEY = 0 /* RAWDATA record: real-time survey data (RT17) */
+	RT17_RAW_RT27   = 6 /* RAWDATA record: enhanced survey data (RT27) */
+
+	RT17_SV_EPH = 1 /* RETSVDATA subtype: GPS ephemeris */
+	RT17_SV_UTC = 3 /* RETSVDATA subtype: utc data */
+	RT17_SV_ION = 4 /* RETSVDATA subtype: ionosphere data */
+
+	RT17_GSOF_TIME = 1 /* GSOF record: position time */
+
+	RT17_RIF_CONCISE  = 0x01 /* record interpretation flag: concise format */
+	RT17_RIF_ENHANCED = 0x02 /* record interpretation flag: enhanced record */
+)
+
+type rt17_t struct { /* RT17 receiver dependent data type */
+	week    int                /* GPS week number (0:unknown) */
+	rec     [RT17_MAXREC]uint8 /* reassembled record buffer */
+	reclen  int                /* length of reassembled record */
+	rectype int                /* record type (RAWDATA) */
+	rif     int                /* record interpretation flags (RAWDATA) */
+	reply   int                /* reply/transmission number of record */
+	page    int                /* last page number of record (0:none) */
+}
+
+/* initialize RT17 receiver dependent data -----------------------------------*/
+func init_rt17(raw *Raw) int {
+	var rt17 rt17_t
+
+	raw.rt17 = &rt17
+	return 1
+}
+
+/* get RT17 receiver dependent data ------------------------------------------*/
+func rt17_data(raw *Raw) *rt17_t {
+	if raw.rt17 == nil {
+		init_rt17(raw)
+	}
+	return raw.rt17
+}
+
+/* set GPS week number -------------------------------------------------------*/
+func rt17_setweek(raw *Raw, week int) {
+	rt17 := rt17_data(raw)
+
+	if week <= 0 {
+		return
+	}
+	if rt17.week == 0 {
+		Trace(3, "rt17 gps week set: week=%d\n", week)
+	}
+	rt17.week = week
+}
+
+/* get GPS week number -------------------------------------------------------*/
+func rt17_getweek(raw *Raw) int {
+	var (
+		rt17 = rt17_data(raw)
+		week int
+	)
+	if p := strings.Index(raw.Opt, "-WEEK="); p >= 0 {
+		if n, _ := fmt.Sscanf(raw.Opt[p+6:], "%d", &week); n == 1 && week > 0 {
+			return week
+		}
+	}
+	if rt17.week > 0 {
+		return rt17.week
+	}
+	if raw.Time.Time != 0 {
+		Time2GpsT(raw.Time, &week)
+	}
+	return week
+}
+
+/* append page of multi-page record ------------------------------------------
+* args   : raw *Raw        IO  receiver raw data control struct
+*          int reply       I   reply/transmission number
+*          int page        I   page number (1-)
+*          int npage       I   number of pages
+*          []uint8 data    I   page data without page header
+* return : status (-1: error, 0: record incomplete, 1: record complete)
+*-----------------------------------------------------------------------------*/
+func rt17_addpage(raw *Raw, reply, page, npage int, data []uint8) int {
+	rt17 := rt17_data(raw)
+
+	if page < 1 || npage < 1 || page > npage || npage > RT17_MAXPAGE {
+		Trace(2, "rt17 page error: page=%d npage=%d\n", page, npage)
+		rt17.page = 0
+		return -1
+	}
+	if page == 1 {
+		rt17.reclen = 0
+		rt17.reply = reply
+	} else if rt17.page != page-1 || rt17.reply != reply {
+		Trace(2, "rt17 page missing: page=%d last=%d reply=%d %d\n", page, rt17.page,
+			reply, rt17.reply)
+		rt17.page = 0
+		return -1
+	}
+	if rt17.reclen+len(data) > RT17_MAXREC {
+		Trace(2, "rt17 record length error: len=%d\n", rt17.reclen+len(data))
+		rt17.page = 0
+		return -1
+	}
+	copy(rt17.rec[rt17.reclen:], data)
+	rt17.reclen += len(data)
+	rt17.page = page
+
+	if page < npage {
+		return 0
+	}
+	rt17.page = 0
+	return 1
+}
+
+/* decode RAWDATA real-time survey data record (type 17) ---------------------*/
+func decode_rt17_survey(raw *Raw) int {
+	var (
+		rt17                                       = rt17_data(raw)
+		p                                          = rt17.rec[:rt17.reclen]
+		i, j, n, nsat, prn, sat, week, flags1      int
+		flags2, status, off, concise, enhanced, sz int
+		tow, clkoff                                float64
+		time                                       Gtime
+		tstr                                       string
+	)
+	if len(p) < 17 {
+		Trace(2, "rt17 survey data length error: len=%d\n", len(p))
+		return -1
+	}
+	tow = R8(p) * 0.001        /* receive time (ms) */
+	clkoff = R8(p[8:]) * 0.001 /* clock offset (ms) */
+	nsat = int(U1(p[16:]))
+	off = 17
+	if rt17.rif&RT17_RIF_CONCISE != 0 {
+		concise = 1
+	}
+	if rt17.rif&RT17_RIF_ENHANCED != 0 {
+		enhanced = 1
+		off += 2 /* epoch flags, reserved */
+	}
+	if week = rt17_getweek(raw); week == 0 {
+		Trace(2, "rt17 gps week unknown: tow=%.3f\n", tow)
+		return 0
+	}
+	time = GpsT2Time(week, tow-clkoff)
+	if raw.Time.Time != 0 {
+		time = adjweek(raw.Time, tow-clkoff)
+	}
+	raw.Time = time
+
+	if raw.OutType > 0 {
+		Time2Str(time, &tstr, 2)
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RAWDATA SURVEY(%4d): time=%s nsat=%d rif=%02X",
+			len(p), tstr, nsat, rt17.rif)))
+	}
+	for i = 0; i < nsat && n < MAXOBS; i++ {
+		if off+8 > len(p) {
+			Trace(2, "rt17 survey data length error: len=%d nsat=%d\n", len(p), nsat)
+			return -1
+		}
+		prn = int(U1(p[off:]))
+		flags1 = int(U1(p[off+1:]))
+		flags2 = int(U1(p[off+2:]))
+		status = int(U1(p[off+3:])) /* channel (concise) or flag status (expanded) */
+		off += 8                    /* prn,flags1,flags2,channel/status,elevation,azimuth */
+
+		/* length of measurement block */
+		sz = 0
+		if concise > 0 {
+			if flags1&0x40 != 0 {
+				sz += 21
+			}
+			if flags1&0x01 != 0 {
+				sz += 13
+			}
+			if enhanced > 0 {
+				sz += 3
+			}
+		} else {
+			if flags1&0x40 != 0 {
+				sz += 40
+			}
+			if flags1&0x01 != 0 {
+				sz += 24
+			}
+			if enhanced > 0 {
+				sz += 12
+			}
+		}
+		if off+sz > len(p) {
+			Trace(2, "rt17 survey data length error: len=%d nsat=%d\n", len(p), nsat)
+			return -1
+		}
+		if concise == 0 && status&0x01 == 0 {
+			flags2 = 0 /* flags2 undefined */
+		}
+		if sat = SatNo(SYS_GPS, prn); sat == 0 {
+			Trace(2, "rt17 satellite number error: prn=%d\n", prn)
+			off += sz
+			continue
+		}
+		data := &raw.ObsData.Data[n]
+		data.Time = time
+		data.Sat = sat
+		data.Rcv = 0
+		for j = 0; j < NFREQ+NEXOBS; j++ {
+			data.L[j], data.P[j], data.D[j] = 0.0, 0.0, 0.0
+			data.SNR[j], data.LLI[j], data.Code[j] = 0, 0, CODE_NONE
+		}
+		if flags1&0x40 != 0 { /* L1 data valid */
+			if concise > 0 {
+				data.SNR[0] = uint16(float64(U1(p[off:]))*0.25/SNR_UNIT + 0.5)
+				data.P[0] = R8(p[off+1:])
+				if flags1&0x10 != 0 { /* L1 phase valid */
+					data.L[0] = -R8(p[off+9:])
+				}
+				data.D[0] = float64(R4(p[off+17:]))
+				off += 21
+			} else {
+				data.SNR[0] = uint16(R8(p[off:])/SNR_UNIT + 0.5)
+				data.P[0] = R8(p[off+8:])
+				if flags1&0x10 != 0 {
+					data.L[0] = -R8(p[off+16:])
+				}
+				data.D[0] = R8(p[off+24:])
+				off += 40 /* including reserved 8 bytes */
+			}
+			if flags2&0x01 != 0 { /* L1 P-code */
+				data.Code[0] = CODE_L1P
+			} else {
+				data.Code[0] = CODE_L1C
+			}
+			if flags1&0x02 != 0 {
+				data.LLI[0] = LLI_SLIP
+			}
+		}
+		if flags1&0x01 != 0 { /* L2 data loaded */
+			if concise > 0 {
+				data.SNR[1] = uint16(float64(U1(p[off:]))*0.25/SNR_UNIT + 0.5)
+				if flags1&0x20 != 0 { /* L2 range/phase valid */
+					data.L[1] = -R8(p[off+1:])
+					if data.P[0] != 0.0 {
+						data.P[1] = data.P[0] + float64(R4(p[off+9:]))
+					}
+				}
+				off += 13
+			} else {
+				data.SNR[1] = uint16(R8(p[off:])/SNR_UNIT + 0.5)
+				if flags1&0x20 != 0 {
+					data.L[1] = -R8(p[off+8:])
+					if data.P[0] != 0.0 {
+						data.P[1] = data.P[0] + R8(p[off+16:])
+					}
+				}
+				off += 24
+			}
+			if flags2&0x04 != 0 { /* L2 encrypted code */
+				data.Code[1] = CODE_L2W
+			} else if flags2&0x02 != 0 { /* L2 P-code */
+				data.Code[1] = CODE_L2P
+			} else {
+				data.Code[1] = CODE_L2X
+			}
+			if flags1&0x04 != 0 {
+				data.LLI[1] = LLI_SLIP
+			}
+		}
+		if enhanced > 0 {
+			if concise > 0 {
+				off += 3 /* iode, L1/L2 slip roll-over counters */
+			} else {
+				off += 4 /* iode, L1/L2 slip roll-over counters, reserved */
+				if flags1&0x01 != 0 {
+					data.D[1] = R8(p[off:]) /* L2 doppler */
+				}
+				off += 8
+			}
+		}
+		n++
+	}
+	raw.ObsData.n = n
+	return 1
+}
+
+/* decode RAWDATA packet -----------------------------------------------------*/
+func decode_rt17_rawdata(raw *Raw) int {
+	var (
+		rt17                        = rt17_data(raw)
+		p                           = raw.Buff[RT17_HLEN:]
+		length                      = int(U1(raw.Buff[3:]))
+		rectype, page, npage, reply int
+		ret                         int
+	)
+	if length < 4 {
+		Trace(2, "rt17 rawdata length error: len=%d\n", length)
+		return -1
+	}
+	rectype = int(U1(p))
+	page = int(U1(p[1:]) >> 4)
+	npage = int(U1(p[1:]) & 0x0F)
+	reply = int(U1(p[2:]))
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RAWDATA (%4d): type=%d page=%d/%d", raw.Len,
+			rectype, page, npage)))
+	}
+	if ret = rt17_addpage(raw, reply, page, npage, p[4:length]); ret <= 0 {
+		return ret
+	}
+	rt17.rectype = rectype
+	rt17.rif = int(U1(p[3:]))
+
+	switch rectype {
+	case RT17_RAW_SURVEY:
+		return decode_rt17_survey(raw)
+	case RT17_RAW_RT27:
+		Trace(3, "rt17 rawdata enhanced survey data (rt27) not supported\n")
+		return 0
+	}
+	return 0
+}
+
+/* decode RETSVDATA GPS ephemeris report -------------------------------------*/
+func decode_rt17_eph(raw *Raw, p []uint8) int {
+	var (
+		eph      Eph
+		prn, sat int
+		toc, tow float64
+		flags    uint32
+	)
+	if len(p) < 176 {
+		Trace(2, "rt17 ephemeris length error: len=%d\n", len(p))
+		return -1
+	}
+	prn = int(U1(p[1:]))
+	if sat = SatNo(SYS_GPS, prn); sat == 0 {
+		Trace(2, "rt17 ephemeris prn error: prn=%d\n", prn)
+		return -1
+	}
+	eph.Week = int(U2(p[2:]))
+	eph.Iodc = int(U2(p[4:]))
+	eph.Iode = int(U1(p[7:]))
+	tow = float64(U4(p[8:]))
+	toc = float64(U4(p[12:]))
+	eph.Toes = float64(U4(p[16:]))
+	eph.Tgd[0] = R8(p[20:])
+	eph.F2 = R8(p[28:])
+	eph.F1 = R8(p[36:])
+	eph.F0 = R8(p[44:])
+	eph.Crs = R8(p[52:])
+	eph.Deln = R8(p[60:]) * SC2RAD
+	eph.M0 = R8(p[68:]) * SC2RAD
+	eph.Cuc = R8(p[76:])
+	eph.E = R8(p[84:])
+	eph.Cus = R8(p[92:])
+	eph.A = SQR(R8(p[100:]))
+	eph.Cic = R8(p[108:])
+	eph.OMG0 = R8(p[116:]) * SC2RAD
+	eph.Cis = R8(p[124:])
+	eph.I0 = R8(p[132:]) * SC2RAD
+	eph.Crc = R8(p[140:])
+	eph.Omg = R8(p[148:]) * SC2RAD
+	eph.OMGd = R8(p[156:]) * SC2RAD
+	eph.Idot = R8(p[164:]) * SC2RAD
+	flags = U4(p[172:])
+
+	eph.Flag = int(flags & 1)        /* L2 P data flag */
+	eph.Code = int((flags >> 1) & 3) /* codes on L2 channel */
+	eph.Svh = int((flags >> 3) & 0x3F)
+	eph.Sva = int((flags >> 9) & 0xF)
+	if (flags>>13)&1 != 0 {
+		eph.Fit = 0.0
+	} else {
+		eph.Fit = 4.0 /* 0:4hr,1:>4hr */
+	}
+	eph.Sat = sat
+	eph.Toe = GpsT2Time(eph.Week, eph.Toes)
+	eph.Toc = GpsT2Time(eph.Week, toc)
+	eph.Ttr = GpsT2Time(eph.Week, tow)
+
+	rt17_setweek(raw, eph.Week)
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RETSVDATA EPH (%4d): prn=%d iode=%d",
+			raw.Len, prn, eph.Iode)))
+	}
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if eph.Iode == raw.NavData.Ephs[sat-1].Iode &&
+			eph.Iodc == raw.NavData.Ephs[sat-1].Iodc &&
+			TimeDiff(eph.Toe, raw.NavData.Ephs[sat-1].Toe) == 0.0 {
+			return 0 /* unchanged */
+		}
+	}
+	raw.NavData.Ephs[sat-1] = eph
+	raw.EphSat = sat
+	raw.EphSet = 0
+	return 2
+}
+
+/* decode RETSVDATA utc report -----------------------------------------------*/
+func decode_rt17_utc(raw *Raw, p []uint8) int {
+	if len(p) < 29 {
+		Trace(2, "rt17 utc length error: len=%d\n", len(p))
+		return -1
+	}
+	raw.NavData.Utc_gps[0] = R8(p[1:])                  /* A0 */
+	raw.NavData.Utc_gps[1] = R8(p[9:])                  /* A1 */
+	raw.NavData.Utc_gps[2] = float64(U4(p[17:]))        /* tot */
+	raw.NavData.Utc_gps[3] = float64(U2(p[21:]))        /* WNt */
+	raw.NavData.Utc_gps[4] = float64(int16(U2(p[23:]))) /* dt_LS */
+	raw.NavData.Utc_gps[5] = float64(U2(p[25:]))        /* WN_LSF */
+	raw.NavData.Utc_gps[6] = float64(U2(p[27:]))        /* DN */
+	if len(p) >= 31 {
+		raw.NavData.Utc_gps[7] = float64(int16(U2(p[29:]))) /* dt_LSF */
+	}
+	rt17_setweek(raw, int(raw.NavData.Utc_gps[3]))
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RETSVDATA UTC (%4d):", raw.Len)))
+	}
+	return 9
+}
+
+/* decode RETSVDATA ionosphere report ----------------------------------------*/
+func decode_rt17_ion(raw *Raw, p []uint8) int {
+	if len(p) < 65 {
+		Trace(2, "rt17 ion length error: len=%d\n", len(p))
+		return -1
+	}
+	for i := 0; i < 8; i++ {
+		raw.NavData.Ion_gps[i] = R8(p[1+i*8:])
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RETSVDATA ION (%4d):", raw.Len)))
+	}
+	return 9
+}
+
+/* decode RETSVDATA packet ---------------------------------------------------*/
+func decode_rt17_retsvdata(raw *Raw) int {
+	var (
+		length = int(U1(raw.Buff[3:]))
+		p      = raw.Buff[RT17_HLEN : RT17_HLEN+length]
+	)
+	if length < 1 {
+		Trace(2, "rt17 retsvdata length error: len=%d\n", length)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 RETSVDATA (%4d): subtype=%d", raw.Len, U1(p))))
+	}
+	switch U1(p) {
+	case RT17_SV_EPH:
+		return decode_rt17_eph(raw, p)
+	case RT17_SV_UTC:
+		return decode_rt17_utc(raw, p)
+	case RT17_SV_ION:
+		return decode_rt17_ion(raw, p)
+	}
+	return 0
+}
+
+/* decode GENOUT (GSOF) packet -----------------------------------------------*/
+func decode_rt17_genout(raw *Raw) int {
+	var (
+		rt17                 = rt17_data(raw)
+		length               = int(U1(raw.Buff[3:]))
+		p                    = raw.Buff[RT17_HLEN:]
+		trans, page, maxpage int
+		i, rectype, reclen   int
+		ret                  int
+	)
+	if length < 3 {
+		Trace(2, "rt17 genout length error: len=%d\n", length)
+		return -1
+	}
+	trans = int(U1(p))
+	page = int(U1(p[1:]))
+	maxpage = int(U1(p[2:]))
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("RT17 GENOUT  (%4d): page=%d/%d", raw.Len,
+			page, maxpage)))
+	}
+	/* page index and max page index are 0-origin */
+	if ret = rt17_addpage(raw, trans, page+1, maxpage+1, p[3:length]); ret <= 0 {
+		return ret
+	}
+	for i = 0; i+2 <= rt17.reclen; i += 2 + reclen {
+		rectype = int(rt17.rec[i])
+		reclen = int(rt17.rec[i+1])
+		if i+2+reclen > rt17.reclen {
+			Trace(2, "rt17 gsof record length error: type=%d len=%d\n", rectype, reclen)
+			return -1
+		}
+		if rectype == RT17_GSOF_TIME && reclen >= 6 {
+			rt17_setweek(raw, int(U2(rt17.rec[i+6:])))
+		}
+	}
+	return 0
+}
+
+/* decode Trimble packet -----------------------------------------------------*/
+func decode_rt17(raw *Raw) int {
+	var (
+		length = int(U1(raw.Buff[3:]))
+		cs     uint8
+	)
+	Trace(3, "decode_rt17: type=%02X len=%d\n", raw.Buff[2], raw.Len)
+
+	/* check checksum (status+type+length+data) and etx */
+	for i := 1; i < RT17_HLEN+length; i++ {
+		cs += raw.Buff[i]
+	}
+	if cs != raw.Buff[RT17_HLEN+length] || raw.Buff[RT17_HLEN+length+1] != RT17_ETX {
+		Trace(2, "rt17 checksum error: type=%02X len=%d\n", raw.Buff[2], length)
+		return -1
+	}
+	switch raw.Buff[2] {
+	case RT17_RAWDATA:
+		return decode_rt17_rawdata(raw)
+	case RT17_RETSVDATA:
+		return decode_rt17_retsvdata(raw)
+	case RT17_GENOUT:
+		return decode_rt17_genout(raw)
+	}
+	return 0
+}
+
+/* check packet type ---------------------------------------------------------*/
+func rt17_type(ctype uint8) bool {
+	return ctype == RT17_RAWDATA || ctype == RT17_RETSVDATA || ctype == RT17_GENOUT
+}
+
+/* input Trimble RT17 raw data from stream -------------------------------------
+* fetch next Trimble RT17 raw data and input a message from stream
+* args   : raw *Raw       IO  receiver raw data control struct
+*          uint8 data     I   stream data (1 byte)
+* return : status (-1: error message, 0: no message, 1: input observation data,
+*                  2: input ephemeris, 9: input ion/utc parameter)
+*
+* notes  : to specify input options, set raw.Opt to the following option
+*          strings separated by spaces.
+*
+*          -EPHALL    : input all ephemerides
+*          -WEEK=n    : GPS week number of RT17 measurement records
+*-----------------------------------------------------------------------------*/
+func input_rt17(raw *Raw, data uint8) int {
+	Trace(5, "input_rt17: data=%02x\n", data)
+
+	/* synchronize packet */
+	if raw.NumByte == 0 {
+		if data == RT17_STX {
+			raw.Buff[0] = data
+			raw.NumByte = 1
+		}
+		return 0
+	}
+	raw.Buff[raw.NumByte] = data
+	raw.NumByte++
+
+	if raw.NumByte == 3 && !rt17_type(raw.Buff[2]) {
+		raw.NumByte = 0
+		return 0
+	}
+	if raw.NumByte == RT17_HLEN {
+		raw.Len = int(U1(raw.Buff[3:])) + RT17_HLEN + 2 /* checksum, etx */
+	}
+	if raw.NumByte < RT17_HLEN || raw.NumByte < raw.Len {
+		return 0
+	}
+	raw.NumByte = 0
+
+	/* decode Trimble packet */
+	return decode_rt17(raw)
+}
+
+/* input Trimble RT17 raw data from file ---------------------------------------
+* fetch next Trimble RT17 raw data and input a message from file
+* args   : raw *Raw       IO  receiver raw data control struct
+*          fp *os.File    I   file pointer
+* return : status(-2: end of file, -1...9: same as above)
+*-----------------------------------------------------------------------------*/
+func input_rt17f(raw *Raw, fp *os.File) int {
+	var c [1]byte
+
+	Trace(4, "input_rt17f:\n")
+
+	/* synchronize packet */
+	for i := 0; ; i++ {
+		if _, err := fp.Read(c[:]); err == io.EOF {
+			return -2
+		}
+		if raw.NumByte == 0 {
+			if c[0] == RT17_STX {
+				raw.Buff[0] = c[0]
+				raw.NumByte = 1
+			}
+		} else {
+			raw.Buff[raw.NumByte] = c[0]
+			raw.NumByte++
+			if raw.NumByte == 3 {
+				if rt17_type(raw.Buff[2]) {
+					break
+				}
+				raw.NumByte = 0
+			}
+		}
+		if i >= 4096 {
+			return 0
+		}
+	}
+	if _, err := fp.Read(raw.Buff[3:4]); err == io.EOF {
+		return -2
+	}
+	raw.Len = int(U1(raw.Buff[3:])) + RT17_HLEN + 2
+	if n, _ := io.ReadFull(fp, raw.Buff[RT17_HLEN:raw.Len]); n < raw.Len-RT17_HLEN {
+		return -2
+	}
+	raw.NumByte = 0
+
+	/* decode Trimble packet */
+	return decode_rt17(raw)
+}
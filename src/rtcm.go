@@ -45,6 +45,7 @@
 *                           update reference [17]
 *                           use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite rtcm.c with golang by fxb
+*           2026/10/16 1.1  implement GenRtcm2() (ref [1])
 *-----------------------------------------------------------------------------*/
 
 package gnssgo
@@ -111,7 +112,7 @@ func (rtcm *Rtcm) InitRtcm() int {
 	rtcm.ObsFlag, rtcm.EphSat = 0, 0
 	for i = 0; i < MAXSAT; i++ {
 		for j = 0; j < NFREQ+NEXOBS; j++ {
-			rtcm.Cp[i][j] = 0.0
+			rtcm.Cp[i][j], rtcm.Cpc[i][j] = 0.0, 0.0
 			rtcm.Lock[i][j], rtcm.Loss[i][j] = 0, 0
 			rtcm.Lltime[i][j] = time0
 		}
@@ -392,15 +393,68 @@ func (rtcm *Rtcm) InputRtcm3f(fp *os.File) int {
 *          int    type      I   message type
 *          int    sync      I   sync flag (1:another message follows)
 * return : status (1:ok,0:error)
+* notes  : the message is output to rtcm.Buff[0:rtcm.Nbyte] in 6-of-8 form.
+*          if the data does not fit in a message (max 31 data words), multiple
+*          messages are concatenated in the buffer.
+*          supported msgs RTCM ver.2: 1,3,9,18,19,20,21,22,24,31
 *-----------------------------------------------------------------------------*/
-func (rtcm *Rtcm) gen_rtcm2(ctype, sync int) int {
+func (rtcm *Rtcm) GenRtcm2(ctype, sync int) int {
+	var (
+		buff              []uint8
+		word              uint32
+		i, j, k, nw, next int
+		b                 uint8
+	)
 	Trace(3, "gen_rtcm2: type=%d sync=%d\n", ctype, sync)
 
 	rtcm.Nbit, rtcm.MsgLen, rtcm.Nbyte = 0, 0, 0
 
-	/* not yet implemented */
+	for {
+		/* encode rtcm 2 message header and body */
+		if rtcm.EncodeRtcm2(ctype, sync, &next) == 0 {
+			break
+		}
+		/* padding to align 24 bit word boundary */
+		for i = rtcm.Nbit; (i-48)%24 > 0; i++ {
+			SetBitU(rtcm.Buff[:], i, 1, 0)
+		}
+		/* message length (number of data words) */
+		if nw = (i - 48) / 24; nw > 31 {
+			Trace(2, "generate rtcm 2 message length error len=%d\n", nw)
+			break
+		}
+		SetBitU(rtcm.Buff[:], 40, 5, uint32(nw))
+		rtcm.MsgLen = nw*3 + 6
 
-	return 0
+		if len(buff)+rtcm.MsgLen/3*5 > len(rtcm.Buff) {
+			Trace(2, "generate rtcm 2 buffer overflow type=%d\n", ctype)
+			break
+		}
+		/* generate parity and encode 30 bit words to 6-of-8 form */
+		for i = 0; i < rtcm.MsgLen; i += 3 {
+			word = rtcm.Word
+			Encode_Word(GetBitU(rtcm.Buff[:], i*8, 24), &word)
+			rtcm.Word = word
+
+			for j = 0; j < 5; j++ {
+				b = 0x40
+				for k = 0; k < 6; k++ {
+					b |= uint8((word>>(29-j*6-k))&1) << k
+				}
+				buff = append(buff, b)
+			}
+		}
+		rtcm.SeqNo = (rtcm.SeqNo + 1) % 8
+
+		if next <= 0 {
+			break /* single message */
+		}
+	}
+	if len(buff) <= 0 {
+		return 0
+	}
+	rtcm.Nbyte = copy(rtcm.Buff[:], buff)
+	return 1
 }
 
 /* generate RTCM 3 message -----------------------------------------------------
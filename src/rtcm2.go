@@ -10,6 +10,8 @@
 * history : 2011/11/28 1.0  separated from rtcm.c
 *           2014/10/21 1.1  fix problem on week rollover in rtcm 2 type 14
 *		    2022/05/31 1.0  rewrite rtcm2.c with golang by fxb
+*           2026/10/16 1.1  support type 23, 24, 31, 32, 34, 36 and 37
+*           2026/10/16 1.2  fix time of type 31/34 corrections (glonass time)
 *-----------------------------------------------------------------------------*/
 
 package gnssgo
@@ -41,6 +43,28 @@ func (rtcm *Rtcm) AdjHour(zcnt float64) {
 	rtcm.Time = GpsT2Time(week, hour*3600+zcnt)
 }
 
+/* time of glonass message (gpst) ---------------------------------------------
+* notes  : modified z-count of glonass messages is referenced to glonass time
+*          (utc(su)+3h), so the hour is taken from utc and converted to gpst
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) glotime() Gtime {
+	var (
+		tow, hour, sec float64
+		week           int
+	)
+	zcnt := float64(GetBitU(rtcm.Buff[:], 24, 13)) * 0.6
+
+	tow = Time2GpsT(GpsT2Utc(rtcm.Time), &week)
+	hour = math.Floor(tow / 3600.0)
+	sec = tow - hour*3600.0
+	if zcnt < sec-1800.0 {
+		zcnt += 3600.0
+	} else if zcnt > sec+1800.0 {
+		zcnt -= 3600.0
+	}
+	return Utc2GpsT(GpsT2Time(week, hour*3600+zcnt))
+}
+
 /* get observation data index ------------------------------------------------*/
 func (obs *Obs) ObsIndex(time Gtime, sat int) int {
 	var i, j int
@@ -99,15 +123,15 @@ func (rtcm *Rtcm) decode_type1() int {
 		}
 
 		sat = SatNo(SYS_GPS, prn)
-		rtcm.Dgps[sat-1].t0 = rtcm.Time
-		rtcm.Dgps[sat-1].prc = prc * 0.02
-		rtcm.Dgps[sat-1].rrc = rrc * 0.002
+		rtcm.Dgps[sat-1].T0 = rtcm.Time
+		rtcm.Dgps[sat-1].Prc = prc * 0.02
+		rtcm.Dgps[sat-1].Rrc = rrc * 0.002
 		if fact > 0 {
-			rtcm.Dgps[sat-1].prc = prc * 0.32
-			rtcm.Dgps[sat-1].rrc = rrc * 0.032
+			rtcm.Dgps[sat-1].Prc = prc * 0.32
+			rtcm.Dgps[sat-1].Rrc = rrc * 0.032
 		}
-		rtcm.Dgps[sat-1].iod = iod
-		rtcm.Dgps[sat-1].udre = float64(udre)
+		rtcm.Dgps[sat-1].Iod = iod
+		rtcm.Dgps[sat-1].Udre = float64(udre)
 
 	}
 	return 7
@@ -158,7 +182,7 @@ func (rtcm *Rtcm) decode_type14() int {
 	return 6
 }
 
-/* decode type 16: gps special message ---------------------------------------*/
+/* decode type 16/36: gps/glonass special message ----------------------------*/
 func (rtcm *Rtcm) decode_type16() int {
 	var (
 		i, n int = 48, 0
@@ -473,37 +497,189 @@ func (rtcm *Rtcm) decode_type22() int {
 
 /* decode type 23: antenna type definition record ----------------------------*/
 func (rtcm *Rtcm) decode_type23() int {
-	return 0
+	var (
+		des, sno               [32]byte
+		i                      int = 48
+		j, ar, sf, n, m, setup int
+	)
+	Trace(4, "decode_type23: len=%d\n", rtcm.MsgLen)
+
+	if i+8 > rtcm.MsgLen*8 {
+		Trace(2, "rtcm2 23 length error: len=%d\n", rtcm.MsgLen)
+		return -1
+	}
+	i += 1 /* reserved */
+	ar = int(GetBitU(rtcm.Buff[:], i, 1))
+	i += 1
+	sf = int(GetBitU(rtcm.Buff[:], i, 1))
+	i += 1
+	n = int(GetBitU(rtcm.Buff[:], i, 5))
+	i += 5
+	if i+8*n+8*ar > rtcm.MsgLen*8 {
+		Trace(2, "rtcm2 23 length error: len=%d n=%d\n", rtcm.MsgLen, n)
+		return -1
+	}
+	for j = 0; j < n; j++ {
+		des[j] = byte(GetBitU(rtcm.Buff[:], i, 8))
+		i += 8
+	}
+	if ar > 0 {
+		setup = int(GetBitU(rtcm.Buff[:], i, 8))
+		i += 8
+	}
+	if sf > 0 && i+8 <= rtcm.MsgLen*8 {
+		i += 3 /* reserved */
+		m = int(GetBitU(rtcm.Buff[:], i, 5))
+		i += 5
+		if i+8*m > rtcm.MsgLen*8 {
+			Trace(2, "rtcm2 23 length error: len=%d m=%d\n", rtcm.MsgLen, m)
+			return -1
+		}
+		for j = 0; j < m; j++ {
+			sno[j] = byte(GetBitU(rtcm.Buff[:], i, 8))
+			i += 8
+		}
+	}
+	rtcm.StaPara.AntDes = string(des[:n])
+	rtcm.StaPara.AntSetup = setup
+	rtcm.StaPara.AntSno = string(sno[:m])
+	return 5
 }
 
 /* decode type 24: antenna reference point (arp) -----------------------------*/
 func (rtcm *Rtcm) decode_type24() int {
-	return 0
+	var (
+		rr        [3]float64
+		anth      float64
+		i         int = 48
+		j, gs, ah int
+	)
+	Trace(4, "decode_type24: len=%d\n", rtcm.MsgLen)
+
+	if i+120 <= rtcm.MsgLen*8 {
+		rr[0] = getbits_38(rtcm.Buff[:], i)
+		i += 38 + 2
+		rr[1] = getbits_38(rtcm.Buff[:], i)
+		i += 38 + 2
+		rr[2] = getbits_38(rtcm.Buff[:], i)
+		i += 38
+		gs = int(GetBitU(rtcm.Buff[:], i, 1))
+		i += 1
+		ah = int(GetBitU(rtcm.Buff[:], i, 1))
+		i += 1
+	} else {
+		Trace(2, "rtcm2 24 length error: len=%d\n", rtcm.MsgLen)
+		return -1
+	}
+	if ah > 0 {
+		if i+24 > rtcm.MsgLen*8 {
+			Trace(2, "rtcm2 24 length error: len=%d\n", rtcm.MsgLen)
+			return -1
+		}
+		anth = float64(GetBitU(rtcm.Buff[:], i, 18))
+	}
+	Trace(4, "rtcm2 24 arp: gs=%d anth=%.4f\n", gs, anth*0.0001)
+
+	rtcm.StaPara.DelType = 1 /* xyz */
+	for j = 0; j < 3; j++ {
+		rtcm.StaPara.Pos[j] = rr[j] * 0.0001
+		rtcm.StaPara.Del[j] = 0.0
+	}
+	rtcm.StaPara.Hgt = anth * 0.0001
+	return 5
 }
 
-/* decode type 31: differential glonass correction ---------------------------*/
+/* decode type 31/34: differential glonass correction/partial correction set -*/
 func (rtcm *Rtcm) decode_type31() int {
-	return 0
+	var (
+		i, fact, udre, prn, sat, tb int
+		prc, rrc                    float64
+	)
+
+	Trace(4, "decode_type31: len=%d\n", rtcm.MsgLen)
+
+	t0 := rtcm.glotime()
+
+	for i = 48; i+40 <= rtcm.MsgLen*8; {
+		fact = int(GetBitU(rtcm.Buff[:], i, 1))
+		i += 1
+		udre = int(GetBitU(rtcm.Buff[:], i, 2))
+		i += 2
+		prn = int(GetBitU(rtcm.Buff[:], i, 5))
+		i += 5
+		prc = float64(GetBits(rtcm.Buff[:], i, 16))
+		i += 16
+		rrc = float64(GetBits(rtcm.Buff[:], i, 8))
+		i += 8
+		i += 1 /* change-of-ephemeris flag */
+		tb = int(GetBitU(rtcm.Buff[:], i, 7))
+		i += 7
+		if prc == -32768 || rrc == -128 {
+			Trace(2, "rtcm2 31 prc/rrc indicates satellite problem: prn=%d\n", prn)
+			continue
+		}
+		if sat = SatNo(SYS_GLO, prn); sat == 0 {
+			Trace(2, "rtcm2 31 satellite number error: prn=%d\n", prn)
+			continue
+		}
+		rtcm.Dgps[sat-1].T0 = t0
+		rtcm.Dgps[sat-1].Prc = prc * 0.02
+		rtcm.Dgps[sat-1].Rrc = rrc * 0.002
+		if fact > 0 {
+			rtcm.Dgps[sat-1].Prc = prc * 0.32
+			rtcm.Dgps[sat-1].Rrc = rrc * 0.032
+		}
+		rtcm.Dgps[sat-1].Iod = tb
+		rtcm.Dgps[sat-1].Udre = float64(udre)
+	}
+	return 7
 }
 
 /* decode type 32: differential glonass reference station parameters ---------*/
 func (rtcm *Rtcm) decode_type32() int {
-	return 0
-}
+	var i int = 48
 
-/* decode type 34: glonass partial differential correction set ---------------*/
-func (rtcm *Rtcm) decode_type34() int {
-	return 0
-}
+	Trace(4, "decode_type32: len=%d\n", rtcm.MsgLen)
 
-/* decode type 36: glonass special message -----------------------------------*/
-func (rtcm *Rtcm) decode_type36() int {
-	return 0
+	if i+96 <= rtcm.MsgLen*8 {
+		rtcm.StaPara.Pos[0] = float64(GetBits(rtcm.Buff[:], i, 32)) * 0.01
+		i += 32
+		rtcm.StaPara.Pos[1] = float64(GetBits(rtcm.Buff[:], i, 32)) * 0.01
+		i += 32
+		rtcm.StaPara.Pos[2] = float64(GetBits(rtcm.Buff[:], i, 32)) * 0.01
+	} else {
+		Trace(2, "rtcm2 32 length error: len=%d\n", rtcm.MsgLen)
+		return -1
+	}
+	return 5
 }
 
-/* decode type 37: gnss system time offset -----------------------------------*/
+/* decode type 37: gnss system time offset -------------------------------------
+* notes  : GS (1 bit, 0:GPS-GLONASS), reserved (7 bits) and time offset
+*          t_GPS-t_GLONASS except for integer seconds (32 bits, 1 ns)
+*-----------------------------------------------------------------------------*/
 func (rtcm *Rtcm) decode_type37() int {
-	return 0
+	var (
+		i  int = 48
+		gs int
+		dt float64
+	)
+	Trace(4, "decode_type37: len=%d\n", rtcm.MsgLen)
+
+	if i+40 <= rtcm.MsgLen*8 {
+		gs = int(GetBitU(rtcm.Buff[:], i, 1))
+		i += 1 + 7
+		dt = float64(GetBits(rtcm.Buff[:], i, 32)) * 1e-9
+	} else {
+		Trace(2, "rtcm2 37 length error: len=%d\n", rtcm.MsgLen)
+		return -1
+	}
+	if gs != 0 {
+		Trace(2, "rtcm2 37 unsupported system: gs=%d\n", gs)
+		return 0
+	}
+	rtcm.NavData.Utc_glo[1] = dt /* tau_GPS */
+	return 9
 }
 
 /* decode type 59: proprietary message ---------------------------------------*/
@@ -539,7 +715,7 @@ func (rtcm *Rtcm) DecodeRtcm2() int {
 		rtcm.MsgType = fmt.Sprintf("RTCM %2d (%4d) zcnt=%7.1f staid=%3d seqno=%d",
 			ctype, rtcm.MsgLen, zcnt, staid, seqno)
 	}
-	if ctype == 3 || ctype == 22 || ctype == 23 || ctype == 24 {
+	if ctype == 3 || ctype == 22 || ctype == 23 || ctype == 24 || ctype == 32 {
 		if rtcm.StaId != 0 && staid != rtcm.StaId {
 			Trace(2, "rtcm2 station id changed: %d.%d\n", rtcm.StaId, staid)
 		}
@@ -570,25 +746,18 @@ func (rtcm *Rtcm) DecodeRtcm2() int {
 		ret = rtcm.decode_type22()
 	case 23:
 		ret = rtcm.decode_type23()
-		/* not supported */
 	case 24:
 		ret = rtcm.decode_type24()
-		/* not supported */
 	case 31:
 		ret = rtcm.decode_type31()
-		/* not supported */
 	case 32:
 		ret = rtcm.decode_type32()
-		/* not supported */
 	case 34:
-		ret = rtcm.decode_type34()
-		/* not supported */
+		ret = rtcm.decode_type31()
 	case 36:
-		ret = rtcm.decode_type36()
-		/* not supported */
+		ret = rtcm.decode_type16()
 	case 37:
 		ret = rtcm.decode_type37()
-		/* not supported */
 	case 59:
 		ret = rtcm.decode_type59()
 		/* not supported */
@@ -0,0 +1,583 @@
+/*------------------------------------------------------------------------------
+* rtcm2e.go : rtcm ver.2 message encoder functions
+*
+*          Copyright (C) 2026 by feng xuebin, All rights reserved.
+*
+* references :
+*     see rtcm.c
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new
+*                           support type 1,3,9,18,19,20,21,22,24,31
+*           2026/10/16 1.1  fix time tag of type 31 (glonass time)
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"math"
+)
+
+/* constants -----------------------------------------------------------------*/
+
+const (
+	MAXSATRTCM2 = 15 /* max number of satellites in rtcm 2 type 18-21 */
+	MAXPRCRTCM2 = 18 /* max number of satellites in rtcm 2 type 1/31 */
+)
+
+/* encode rtcm 2 header --------------------------------------------------------
+* args   : int    ctype     I   message type
+*          gtime_t time     I   time of the message
+*          double *dt       O   time offset of time to modified z-count (s)
+* return : bit position of message body
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) encode_head2(ctype int, time Gtime, dt *float64) int {
+	var (
+		tow, sec float64
+		zcnt     int
+	)
+	i := 0
+	Trace(4, "encode_head2: type=%d time=%s\n", ctype, TimeStr(time, 1))
+
+	tow = Time2GpsT(time, nil)
+	sec = tow - math.Floor(tow/3600.0)*3600.0
+	if zcnt = int(math.Floor(sec/0.6 + 1e-6)); zcnt >= 6000 {
+		zcnt = 5999
+	}
+	if *dt = sec - float64(zcnt)*0.6; *dt < 0.0 {
+		*dt = 0.0
+	}
+	SetBitU(rtcm.Buff[:], i, 8, RTCM2PREAMB)
+	i += 8 /* preamble */
+	SetBitU(rtcm.Buff[:], i, 6, uint32(ctype))
+	i += 6 /* message type */
+	SetBitU(rtcm.Buff[:], i, 10, uint32(rtcm.StaId))
+	i += 10 /* station id */
+	SetBitU(rtcm.Buff[:], i, 13, uint32(zcnt))
+	i += 13 /* modified z-count */
+	SetBitU(rtcm.Buff[:], i, 3, uint32(rtcm.SeqNo))
+	i += 3 /* sequence number */
+	SetBitU(rtcm.Buff[:], i, 5, 0)
+	i += 5 /* length of frame (set later) */
+	SetBitU(rtcm.Buff[:], i, 3, uint32(rtcm.StaHealth))
+	i += 3 /* station health */
+	return i
+}
+
+/* satellite ranges corrected by satellite clocks ------------------------------
+* args   : obsd_t *data     I   observation data
+*          int    n         I   number of observation data
+*          double *rng      O   geometric range - satellite clock (m)
+*          double *rate     O   range rate - satellite clock drift (m/s)
+* return : status (1:ok,0:no station position)
+* notes  : rng[i]=0 if no ephemeris or unhealthy satellite
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) satrange(data []ObsD, n int, rng, rate []float64) int {
+	var (
+		rs   []float64 = make([]float64, 6*n)
+		dts  []float64 = make([]float64, 2*n)
+		vari []float64 = make([]float64, n)
+		svh  []int     = make([]int, n)
+		e    [3]float64
+		r    float64
+		i    int
+	)
+	if Norm(rtcm.StaPara.Pos[:], 3) <= 0.0 {
+		Trace(2, "rtcm2 no station position\n")
+		return 0
+	}
+	rtcm.NavData.SatPoss(data[0].Time, data, n, EPHOPT_BRDC, rs, dts, vari, svh)
+
+	for i = 0; i < n; i++ {
+		rng[i], rate[i] = 0.0, 0.0
+		if svh[i] != 0 {
+			continue
+		}
+		if r = GeoDist(rs[i*6:], rtcm.StaPara.Pos[:], e[:]); r <= 0.0 {
+			continue
+		}
+		rng[i] = r - CLIGHT*dts[i*2]
+		rate[i] = Dot(rs[3+i*6:], e[:], 3) - CLIGHT*dts[1+i*2]
+	}
+	return 1
+}
+
+/* receiver clock bias and drift -----------------------------------------------
+* estimate receiver clock bias and drift as mean of range residuals
+* args   : obsd_t *data     I   observation data
+*          int    n         I   number of observation data
+*          int    sys       I   navigation system
+*          double *rng,*rate I  satellite ranges and range rates (satrange())
+*          double *clk      O   receiver clock bias and drift {m,m/s}
+* return : none
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) rcvclk2(data []ObsD, n, sys int, rng, rate []float64, clk []float64) {
+	var (
+		freq      float64
+		i, nb, nd int
+	)
+	clk[0], clk[1] = 0.0, 0.0
+
+	for i = 0; i < n; i++ {
+		if SatSys(data[i].Sat, nil) != sys || rng[i] == 0.0 || data[i].P[0] == 0.0 {
+			continue
+		}
+		clk[0] += rng[i] - data[i].P[0]
+		nb++
+		if freq = Sat2Freq(data[i].Sat, data[i].Code[0], &rtcm.NavData); data[i].D[0] == 0.0 || freq <= 0.0 {
+			continue
+		}
+		clk[1] += rate[i] + CLIGHT/freq*data[i].D[0]
+		nd++
+	}
+	if nb > 0 {
+		clk[0] /= float64(nb)
+	}
+	if nd > 0 {
+		clk[1] /= float64(nd)
+	}
+}
+
+/* issue of data of ephemeris ------------------------------------------------*/
+func (rtcm *Rtcm) iod2(time Gtime, sat int) int {
+	if SatSys(sat, nil) == SYS_GLO {
+		if geph := rtcm.NavData.SelGEph(time, sat, -1); geph != nil {
+			return geph.Iode & 0x7F
+		}
+		return 0
+	}
+	if eph := rtcm.NavData.SelEph(time, sat, -1); eph != nil {
+		return eph.Iode & 0xFF
+	}
+	return 0
+}
+
+/* pseudorange and range-rate corrections with scale factor ------------------*/
+func scaleprc(prc, rrc float64, fact, iprc, irrc *int) int {
+	*fact = 0
+	if math.Abs(prc) > 655.34 || math.Abs(rrc) > 0.254 {
+		*fact = 1
+	}
+	if *fact > 0 {
+		*iprc = ROUND_I(prc / 0.32)
+		*irrc = ROUND_I(rrc / 0.032)
+	} else {
+		*iprc = ROUND_I(prc / 0.02)
+		*irrc = ROUND_I(rrc / 0.002)
+	}
+	if *iprc < -32767 || *iprc > 32767 || *irrc < -127 || *irrc > 127 {
+		return 0
+	}
+	return 1
+}
+
+/* encode type 1/9/31: differential gps/glonass corrections --------------------
+* notes  : corrections are computed from the observation data by the station
+*          position and broadcast ephemerides. the receiver clock bias and
+*          drift are removed as mean of the corrections.
+*          type 9 message contains max 3 satellites.
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) encode_type1(ctype int, next *int) int {
+	var (
+		data                                             []ObsD = rtcm.ObsData.Data
+		rng, rate                                        []float64
+		clk                                              [2]float64
+		idx                                              [MAXPRCRTCM2]int
+		prc, rrc, dt, freq                               float64
+		i, j, m, n, ns, nmax, sys, prn, fact, iprc, irrc int
+	)
+	Trace(3, "encode_type1: type=%d next=%d\n", ctype, *next)
+
+	sys, nmax = SYS_GPS, MAXPRCRTCM2
+	if ctype == 31 {
+		sys = SYS_GLO
+	} else if ctype == 9 {
+		nmax = 3
+	}
+	if n = rtcm.ObsData.N(); n > MAXOBS {
+		n = MAXOBS
+	}
+	if n <= 0 {
+		return 0
+	}
+	rng, rate = make([]float64, n), make([]float64, n)
+	if rtcm.satrange(data, n, rng, rate) == 0 {
+		return 0
+	}
+	rtcm.rcvclk2(data, n, sys, rng, rate, clk[:])
+
+	for i = 0; i < n; i++ {
+		if SatSys(data[i].Sat, nil) != sys || rng[i] == 0.0 || data[i].P[0] == 0.0 {
+			continue
+		}
+		if m++; m > *next && ns < nmax {
+			idx[ns] = i
+			ns++
+		}
+	}
+	if ns <= 0 {
+		return 0
+	}
+	*next += ns
+
+	time := data[idx[0]].Time
+	if sys == SYS_GLO {
+		time = GpsT2Utc(time) /* glonass time tag in utc */
+	}
+	i = rtcm.encode_head2(ctype, time, &dt)
+
+	for j = 0; j < ns; j++ {
+		d := &data[idx[j]]
+		SatSys(d.Sat, &prn)
+
+		prc = rng[idx[j]] - d.P[0] - clk[0]
+		rrc = 0.0
+		if freq = Sat2Freq(d.Sat, d.Code[0], &rtcm.NavData); d.D[0] != 0.0 && freq > 0.0 {
+			rrc = rate[idx[j]] + CLIGHT/freq*d.D[0] - clk[1]
+		}
+		prc -= rrc * dt /* correction at modified z-count */
+
+		if scaleprc(prc, rrc, &fact, &iprc, &irrc) == 0 {
+			Trace(2, "rtcm2 %d prc/rrc overflow: sat=%2d prc=%.2f rrc=%.3f\n", ctype,
+				d.Sat, prc, rrc)
+			fact, iprc, irrc = 1, -32768, -128 /* satellite problem */
+		}
+		SetBitU(rtcm.Buff[:], i, 1, uint32(fact))
+		i += 1 /* scale factor */
+		SetBitU(rtcm.Buff[:], i, 2, 0)
+		i += 2 /* udre */
+		SetBitU(rtcm.Buff[:], i, 5, uint32(prn&0x1F))
+		i += 5 /* satellite id */
+		SetBits(rtcm.Buff[:], i, 16, int32(iprc))
+		i += 16 /* pseudorange correction */
+		SetBits(rtcm.Buff[:], i, 8, int32(irrc))
+		i += 8 /* range-rate correction */
+		if sys == SYS_GLO {
+			SetBitU(rtcm.Buff[:], i, 1, 0)
+			i += 1 /* change-of-ephemeris flag */
+			SetBitU(rtcm.Buff[:], i, 7, uint32(rtcm.iod2(d.Time, d.Sat)))
+			i += 7 /* time of day (tb) */
+		} else {
+			SetBitU(rtcm.Buff[:], i, 8, uint32(rtcm.iod2(d.Time, d.Sat)))
+			i += 8 /* issue of data */
+		}
+	}
+	rtcm.Nbit = i
+	return 1
+}
+
+/* encode type 3: reference station parameter --------------------------------*/
+func (rtcm *Rtcm) encode_type3() int {
+	var (
+		p  []float64 = rtcm.StaPara.Pos[:]
+		dt float64
+		j  int
+	)
+	Trace(3, "encode_type3:\n")
+
+	if Norm(p, 3) <= 0.0 {
+		return 0
+	}
+	i := rtcm.encode_head2(3, rtcm.Time, &dt)
+
+	for j = 0; j < 3; j++ {
+		SetBits(rtcm.Buff[:], i, 32, int32(ROUND_I(p[j]/0.01)))
+		i += 32 /* ecef-x,y,z */
+	}
+	rtcm.Nbit = i
+	return 1
+}
+
+/* encode type 18-21: rtk observables and corrections --------------------------
+* notes  : observables are separated to messages by frequency (L1,L2) and
+*          system (GPS,GLONASS). the time tag of GLONASS is in UTC.
+*          the carrier-phase is adjusted by the integer cycles at lock.
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) encode_type18(ctype, sync int, next *int) int {
+	var (
+		data                                       []ObsD = rtcm.ObsData.Data
+		rng, rate                                  []float64
+		clk                                        [2]float64
+		idx                                        [MAXSATRTCM2]int
+		time                                       Gtime
+		dt, lam, cp, cpc, prc, rrc, freq           float64
+		i, j, f, s, m, n, ns, f0, sys, sys0, prn   int
+		fact, iprc, irrc, pcode, more, iod, lli, k int
+		glo                                        int
+		syss                                       = [2]int{SYS_GPS, SYS_GLO}
+	)
+	Trace(3, "encode_type18: type=%d sync=%d next=%d\n", ctype, sync, *next)
+
+	if n = rtcm.ObsData.N(); n > MAXOBS {
+		n = MAXOBS
+	}
+	if n <= 0 {
+		return 0
+	}
+	if ctype == 20 || ctype == 21 {
+		rng, rate = make([]float64, n), make([]float64, n)
+		if rtcm.satrange(data, n, rng, rate) == 0 {
+			return 0
+		}
+	}
+	/* select observables sorted by frequency and system */
+	for f = 0; f < 2; f++ {
+		for s = 0; s < 2; s++ {
+			for i = 0; i < n; i++ {
+				if SatSys(data[i].Sat, nil) != syss[s] {
+					continue
+				}
+				if ctype == 18 || ctype == 20 {
+					if data[i].L[f] == 0.0 {
+						continue
+					}
+				} else if data[i].P[f] == 0.0 {
+					continue
+				}
+				if ctype == 20 || ctype == 21 {
+					if rng[i] == 0.0 || Sat2Freq(data[i].Sat, data[i].Code[f], &rtcm.NavData) <= 0.0 {
+						continue
+					}
+				}
+				if m++; m <= *next {
+					continue
+				}
+				if ns == 0 {
+					f0, sys0 = f, syss[s]
+				} else if f != f0 || syss[s] != sys0 || ns >= MAXSATRTCM2 {
+					continue
+				}
+				idx[ns] = i
+				ns++
+			}
+		}
+	}
+	if ns <= 0 {
+		return 0
+	}
+	if *next += ns; m > *next || sync > 0 {
+		more = 1 /* multiple message */
+	}
+	if ctype == 20 || ctype == 21 {
+		rtcm.rcvclk2(data, n, sys0, rng, rate, clk[:])
+	}
+	time = data[idx[0]].Time
+	if sys0 == SYS_GLO {
+		time = GpsT2Utc(time) /* glonass time tag in utc */
+		glo = 1
+	}
+	i = rtcm.encode_head2(ctype, time, &dt)
+
+	SetBitU(rtcm.Buff[:], i, 2, uint32(f0<<1))
+	i += 2 /* frequency indicator (00:L1,10:L2) */
+	SetBitU(rtcm.Buff[:], i, 2, 0)
+	i += 2 /* reserved/smoothing interval */
+	SetBitU(rtcm.Buff[:], i, 20, ROUND_U(dt*1e6))
+	i += 20 /* gnss time of measurement (us) */
+
+	for j = 0; j < ns; j++ {
+		d := &data[idx[j]]
+		sys = SatSys(d.Sat, &prn)
+		if sys == SYS_GPS && prn == 32 {
+			prn = 0
+		}
+		pcode = 0
+		switch d.Code[f0] {
+		case CODE_L1P, CODE_L1W, CODE_L1Y, CODE_L2P, CODE_L2W, CODE_L2Y, CODE_L2D:
+			pcode = 1
+		}
+		SetBitU(rtcm.Buff[:], i, 1, uint32(more))
+		i += 1 /* multiple message indicator */
+		SetBitU(rtcm.Buff[:], i, 1, uint32(pcode))
+		i += 1 /* p-code indicator */
+		SetBitU(rtcm.Buff[:], i, 1, uint32(glo))
+		i += 1 /* gps/glonass */
+		SetBitU(rtcm.Buff[:], i, 5, uint32(prn&0x1F))
+		i += 5 /* satellite id */
+
+		lli = int(d.LLI[f0] & 1)
+		if ctype != 19 && ctype != 21 {
+			freq = Sat2Freq(d.Sat, d.Code[f0], &rtcm.NavData)
+		}
+		switch ctype {
+		case 18: /* carrier-phase adjusted by integer cycles */
+			if k = d.Sat - 1; lli > 0 || rtcm.Cp[k][f0] == 0.0 ||
+				math.Abs(d.L[f0]-rtcm.Cp[k][f0]) > 8388607.0 {
+				rtcm.Cp[k][f0] = math.Floor(d.L[f0])
+				rtcm.Loss[k][f0] = (rtcm.Loss[k][f0] + 1) % 32
+			}
+			cp = d.L[f0] - rtcm.Cp[k][f0]
+			SetBitU(rtcm.Buff[:], i, 3, 0)
+			i += 3 /* data quality */
+			SetBitU(rtcm.Buff[:], i, 5, uint32(rtcm.Loss[k][f0]))
+			i += 5 /* cumulative loss of continuity */
+			SetBits(rtcm.Buff[:], i, 32, int32(ROUND_I(-cp*256.0)))
+			i += 32 /* carrier-phase (1/256 cycle) */
+		case 19:
+			SetBitU(rtcm.Buff[:], i, 4, 0)
+			i += 4 /* data quality */
+			SetBitU(rtcm.Buff[:], i, 4, 0xF)
+			i += 4 /* multipath error (not determined) */
+			SetBitU(rtcm.Buff[:], i, 32, ROUND_U(d.P[f0]/0.02))
+			i += 32 /* pseudorange (0.02 m) */
+		case 20: /* carrier-phase correction adjusted by integer cycles */
+			lam = CLIGHT / freq
+			cpc = (rng[idx[j]]-clk[0])/lam - d.L[f0]
+			if k = d.Sat - 1; lli > 0 || rtcm.Cpc[k][f0] == 0.0 ||
+				math.Abs(cpc-rtcm.Cpc[k][f0]) > 32767.0 {
+				rtcm.Cpc[k][f0] = float64(ROUND_I(cpc))
+				rtcm.Loss[k][f0] = (rtcm.Loss[k][f0] + 1) % 32
+			}
+			cpc -= rtcm.Cpc[k][f0]
+			iod = rtcm.iod2(d.Time, d.Sat)
+			SetBitU(rtcm.Buff[:], i, 3, 0)
+			i += 3 /* data quality */
+			SetBitU(rtcm.Buff[:], i, 5, uint32(rtcm.Loss[k][f0]))
+			i += 5 /* cumulative loss of continuity */
+			SetBitU(rtcm.Buff[:], i, 8, uint32(iod))
+			i += 8 /* issue of data */
+			SetBits(rtcm.Buff[:], i, 24, int32(ROUND_I(cpc*256.0)))
+			i += 24 /* carrier-phase correction (1/256 cycle) */
+		case 21:
+			prc = rng[idx[j]] - d.P[f0] - clk[0]
+			rrc = 0.0
+			if freq = Sat2Freq(d.Sat, d.Code[f0], &rtcm.NavData); d.D[f0] != 0.0 && freq > 0.0 {
+				rrc = rate[idx[j]] + CLIGHT/freq*d.D[f0] - clk[1]
+			}
+			if scaleprc(prc, rrc, &fact, &iprc, &irrc) == 0 {
+				Trace(2, "rtcm2 21 prc/rrc overflow: sat=%2d prc=%.2f rrc=%.3f\n", d.Sat,
+					prc, rrc)
+				fact, iprc, irrc = 1, -32768, -128 /* satellite problem */
+			}
+			iod = rtcm.iod2(d.Time, d.Sat)
+			SetBitU(rtcm.Buff[:], i, 1, uint32(fact))
+			i += 1 /* scale factor */
+			SetBitU(rtcm.Buff[:], i, 3, 0)
+			i += 3 /* data quality */
+			SetBitU(rtcm.Buff[:], i, 4, 0xF)
+			i += 4 /* multipath error (not determined) */
+			SetBitU(rtcm.Buff[:], i, 8, uint32(iod))
+			i += 8 /* issue of data */
+			SetBits(rtcm.Buff[:], i, 16, int32(iprc))
+			i += 16 /* pseudorange correction */
+			SetBits(rtcm.Buff[:], i, 8, int32(irrc))
+			i += 8 /* range-rate correction */
+		}
+	}
+	rtcm.Nbit = i
+	return 1
+}
+
+/* encode type 22: extended reference station parameter ------------------------
+* notes  : the ecef deltas are the residuals of the position in type 3 (1 cm).
+*          the antenna height is output if it is set in station parameters.
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) encode_type22() int {
+	var (
+		p      []float64 = rtcm.StaPara.Pos[:]
+		dt, dp float64
+		j, del int
+	)
+	Trace(3, "encode_type22:\n")
+
+	if Norm(p, 3) <= 0.0 {
+		return 0
+	}
+	i := rtcm.encode_head2(22, rtcm.Time, &dt)
+
+	for j = 0; j < 3; j++ {
+		dp = p[j] - float64(ROUND_I(p[j]/0.01))*0.01
+		if del = ROUND_I(dp * 25600.0); del > 127 {
+			del = 127
+		} else if del < -127 {
+			del = -127
+		}
+		SetBits(rtcm.Buff[:], i, 8, int32(del))
+		i += 8 /* L1 ecef delta-x,y,z (1/256 cm) */
+	}
+	if hgt := ROUND_U(rtcm.StaPara.Hgt * 25600.0); rtcm.StaPara.Hgt > 0.0 && hgt < 1<<18 {
+		SetBitU(rtcm.Buff[:], i, 5, 0)
+		i += 5 /* reserved */
+		SetBitU(rtcm.Buff[:], i, 1, 0)
+		i += 1 /* no height flag */
+		SetBitU(rtcm.Buff[:], i, 18, hgt)
+		i += 18 /* antenna height (1/256 cm) */
+	}
+	rtcm.Nbit = i
+	return 1
+}
+
+/* encode type 24: antenna reference point (arp) -----------------------------*/
+func (rtcm *Rtcm) encode_type24() int {
+	var (
+		p   []float64 = rtcm.StaPara.Pos[:]
+		dt  float64
+		hgt uint32
+		ah  int
+	)
+	Trace(3, "encode_type24:\n")
+
+	if Norm(p, 3) <= 0.0 {
+		return 0
+	}
+	if hgt = ROUND_U(rtcm.StaPara.Hgt / 0.0001); rtcm.StaPara.Hgt > 0.0 && hgt < 1<<18 {
+		ah = 1
+	}
+	i := rtcm.encode_head2(24, rtcm.Time, &dt)
+
+	set38bits(rtcm.Buff[:], i, p[0]/0.0001)
+	i += 38 /* ecef-x */
+	SetBitU(rtcm.Buff[:], i, 2, 0)
+	i += 2 /* reserved */
+	set38bits(rtcm.Buff[:], i, p[1]/0.0001)
+	i += 38 /* ecef-y */
+	SetBitU(rtcm.Buff[:], i, 2, 0)
+	i += 2 /* reserved */
+	set38bits(rtcm.Buff[:], i, p[2]/0.0001)
+	i += 38 /* ecef-z */
+	SetBitU(rtcm.Buff[:], i, 1, 0)
+	i += 1 /* gps/glonass */
+	SetBitU(rtcm.Buff[:], i, 1, uint32(ah))
+	i += 1 /* antenna height flag */
+	if ah > 0 {
+		SetBitU(rtcm.Buff[:], i, 18, hgt)
+		i += 18 /* antenna height (0.0001 m) */
+		SetBitU(rtcm.Buff[:], i, 6, 0)
+		i += 6 /* reserved */
+	}
+	rtcm.Nbit = i
+	return 1
+}
+
+/* encode rtcm ver.2 message -----------------------------------------------------
+* args   : int    ctype     I   message type
+*          int    sync      I   sync flag (1:another message follows)
+*          int    *next     IO  number of satellites already encoded
+*                               (0: first message, not changed for single msg)
+* return : status (1:ok,0:error or no more message)
+*-----------------------------------------------------------------------------*/
+func (rtcm *Rtcm) EncodeRtcm2(ctype, sync int, next *int) int {
+	var ret int = 0
+
+	Trace(4, "encode_rtcm2: type=%d sync=%d next=%d\n", ctype, sync, *next)
+
+	switch ctype {
+	case 1, 9, 31:
+		ret = rtcm.encode_type1(ctype, next)
+	case 3:
+		ret = rtcm.encode_type3()
+	case 18, 19, 20, 21:
+		ret = rtcm.encode_type18(ctype, sync, next)
+	case 22:
+		ret = rtcm.encode_type22()
+	case 24:
+		ret = rtcm.encode_type24()
+	}
+	if ret > 0 {
+		if 1 <= ctype && ctype <= 99 {
+			rtcm.Nmsg2[ctype]++
+		} else {
+			rtcm.Nmsg2[0]++
+		}
+	}
+	return ret
+}
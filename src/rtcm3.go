@@ -3289,15 +3289,13 @@ func (rtcm *Rtcm) decode_type1230() int {
 
 /* decode type 4073: proprietary message Mitsubishi Electric -----------------*/
 func (rtcm *Rtcm) decode_type4073() int {
-	i := 24 + 12
-	subtype := int(GetBitU(rtcm.Buff[:], i, 4))
-	i += 4
+	var ret int
 
-	if rtcm.OutType > 0 {
-		rtcm.MsgType += fmt.Sprintf(" subtype=%d", subtype)
+	/* compact ssr (QZSS CLAS) */
+	if rtcm.decode_cssr(rtcm.Buff[:], 24, rtcm.MsgLen*8, &ret) < 0 {
+		return -1
 	}
-	Trace(5, "rtcm3 4073: unsupported message subtype=%d\n", subtype)
-	return 0
+	return ret
 }
 
 /* decode type 4076: proprietary message IGS ---------------------------------*/
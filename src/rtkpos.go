@@ -47,6 +47,21 @@
 *                           delete GLONASS IFB correction in ddres()
 *                           use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite rtkpos.c with golang by fxb
+*           2026/10/16 1.1  add signal bias (SINEX-BIAS) correction in zdres()
+*           2026/10/16 1.2  support wide-lane/narrow-lane and triple carrier ar
+*                           (pos2-armode=wlnl,tcar) with partial ar
+*           2026/10/16 1.3  add partial ar by excluding satellites in
+*                           ResolveAmb_LAMBDA() (pos2-arpartial,pos2-arminamb)
+*           2026/10/16 1.4  initialize ins states in InitRtk()
+*           2026/10/16 1.5  add attitude (heading/pitch) of moving-base vector
+*           2026/10/16 1.6  add carrier-smoothed code (prcopt.codesmooth) for
+*                           single point positioning and dgps
+*           2026/10/16 1.7  add solution status file by rtk control (rtk.Stat)
+*           2026/10/16 1.8  fix bug on partial ar of cascaded ar without
+*                           opt.ArPartial
+*           2026/10/16 1.9  detect slip by phase-rate with doppler for
+*                           carrier-smoothed code
+*                           skip carrier-smoothed code of same epoch
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -54,6 +69,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 )
 
 /* constants/macros ----------------------------------------------------------*/
@@ -125,14 +141,18 @@ const (
 	VAR_HOLDAMB float64 = 0.001 /* constraint to hold ambiguity (cycle^2) */
 	TTOL_MOVEB  float64 = float64(1.0 + 2*DTTOL)
 	/* time sync tolerance for moving-baseline (s) */
-	INIT_ZWD float64 = 0.15 /* initial zwd (m) */)
+	INIT_ZWD   float64 = 0.15 /* initial zwd (m) */
+	MINAMB_PAR int     = 4    /* min number of DD ambiguities for partial ar */
+	MAXVAR_PAR float64 = 0.04 /* max variance of DD L1 ambiguity for partial ar (cycle^2) */
+	MINBL_ATT  float64 = 0.1  /* min horizontal baseline for moving-base attitude (m) */
+	THRES_ATT  float64 = 4.0  /* threshold of baseline length test for attitude (sigma) */
+	MAXDT_CSM  float64 = 30.0 /* max data gap to continue code smoothing (s) */
+	THRES_CSM  float64 = 30.0 /* threshold of code-carrier jump to reset smoothing (m) */
+	THRES_CSMD float64 = 1.0  /* threshold of phase-rate jump by doppler to reset smoothing (m/s) */
+)
 
 /* global variables ----------------------------------------------------------*/
-var (
-	statlevel int      = 0   /* rtk status output level (0:off) */
-	fp_stat   *os.File = nil /* rtk status file pointer */
-	file_stat string   = ""  /* rtk status file original path */
-	time_stat Gtime /* rtk status file time */)
+var solstat RtkStat /* rtk status file opened by RtkOpenStat() */
 
 /* open solution status file ---------------------------------------------------
 * open solution status file and set output level
@@ -204,6 +224,17 @@ var (
 *
 *-----------------------------------------------------------------------------*/
 func RtkOpenStat(file string, level int) int {
+	return solstat.OpenStat(file, level)
+}
+
+/* open solution status file by status file type -------------------------------
+* open solution status file of RtkStat. set Rtk.Stat to the RtkStat to output
+* solution status of the rtk control to the file instead of RtkOpenStat() one.
+* args   : char     *file   I   rtk status file
+*          int      level   I   rtk status level (0: off)
+* return : status (1:ok,0:error)
+*-----------------------------------------------------------------------------*/
+func (stat *RtkStat) OpenStat(file string, level int) int {
 	var (
 		path string
 		err  error
@@ -218,14 +249,15 @@ func RtkOpenStat(file string, level int) int {
 
 	RepPath(file, &path, time, "", "")
 
-	fp_stat, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModeAppend|os.ModePerm)
-	if fp_stat == nil || err != nil {
+	stat.fp, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModeAppend|os.ModePerm)
+	if stat.fp == nil || err != nil {
 		Trace(2, "rtkopenstat: file open error path=%s\n", path)
+		stat.fp = nil
 		return 0
 	}
-	file_stat = file
-	time_stat = time
-	statlevel = level
+	stat.file = file
+	stat.time = time
+	stat.level = level
 	return 1
 }
 
@@ -235,14 +267,19 @@ func RtkOpenStat(file string, level int) int {
 * return : none
 *-----------------------------------------------------------------------------*/
 func RtkCloseStat() {
+	solstat.CloseStat()
+}
+
+/* close solution status file by status file type ----------------------------*/
+func (stat *RtkStat) CloseStat() {
 	Trace(4, "rtkclosestat:\n")
 
-	if fp_stat != nil {
-		fp_stat.Close()
+	if stat.fp != nil {
+		stat.fp.Close()
 	}
-	fp_stat = nil
-	file_stat = ""
-	statlevel = 0
+	stat.fp = nil
+	stat.file = ""
+	stat.level = 0
 }
 
 /* write solution status to buffer -------------------------------------------*/
@@ -358,7 +395,7 @@ func (rtk *Rtk) RtkOutStat(buff *string) int {
 }
 
 /* swap solution status file -------------------------------------------------*/
-func swapsolstat() {
+func (stat *RtkStat) swap() {
 	var (
 		path string
 		err  error
@@ -366,21 +403,22 @@ func swapsolstat() {
 	time := Utc2GpsT(TimeGet())
 
 	if Time2GpsT(time, nil)/float64(INT_SWAP_STAT) ==
-		Time2GpsT(time_stat, nil)/float64(INT_SWAP_STAT) {
+		Time2GpsT(stat.time, nil)/float64(INT_SWAP_STAT) {
 		return
 	}
-	time_stat = time
+	stat.time = time
 
-	if RepPath(file_stat, &path, time, "", "") == 0 {
+	if RepPath(stat.file, &path, time, "", "") == 0 {
 		return
 	}
-	if fp_stat != nil {
-		fp_stat.Close()
+	if stat.fp != nil {
+		stat.fp.Close()
 	}
 
-	fp_stat, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModeAppend|os.ModePerm)
-	if fp_stat == nil || err != nil {
+	stat.fp, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModeAppend|os.ModePerm)
+	if stat.fp == nil || err != nil {
 		Trace(2, "swapsolstat: file open error path=%s\n", path)
+		stat.fp = nil
 		return
 	}
 	Trace(5, "swapsolstat: path=%s\n", path)
@@ -396,22 +434,26 @@ func (rtk *Rtk) OutSolStat() {
 	)
 	nf = RNF(&rtk.Opt)
 
-	if statlevel <= 0 || fp_stat == nil || rtk.RtkSol.Stat == 0 {
+	stat := rtk.Stat
+	if stat == nil {
+		stat = &solstat
+	}
+	if stat.level <= 0 || stat.fp == nil || rtk.RtkSol.Stat == 0 {
 		return
 	}
 
 	Trace(4, "outsolstat:\n")
 
 	/* swap solution status file */
-	swapsolstat()
+	stat.swap()
 
 	/* write solution status */
 	n = rtk.RtkOutStat(&buff)
 	buff = buff[:n]
 
-	fp_stat.WriteString(buff)
+	stat.fp.WriteString(buff)
 
-	if rtk.RtkSol.Stat == SOLQ_NONE || statlevel <= 1 {
+	if rtk.RtkSol.Stat == SOLQ_NONE || stat.level <= 1 {
 		return
 	}
 
@@ -429,7 +471,7 @@ func (rtk *Rtk) OutSolStat() {
 		}
 		SatNo2Id(i+1, &id)
 		for j = 0; j < nfreq; j++ {
-			fmt.Fprintf(fp_stat, "$SAT,%d,%.3f,%s,%d,%.1f,%.1f,%.4f,%.4f,%d,%.1f,%d,%d,%d,%d,%d,%d\n",
+			fmt.Fprintf(stat.fp, "$SAT,%d,%.3f,%s,%d,%.1f,%.1f,%.4f,%.4f,%d,%.1f,%d,%d,%d,%d,%d,%d\n",
 				week, tow, id, j+1, ssat.Azel[0]*R2D, ssat.Azel[1]*R2D,
 				ssat.Resp[j], ssat.Resc[j], ssat.Vsat[j],
 				float32(ssat.Snr[j])*SNR_UNIT, ssat.Fix[j], ssat.Slip[j]&3,
@@ -1032,6 +1074,7 @@ func ZdResSat(base int, r float64, obs *ObsD, nav *Nav,
 	azel, dant []float64, opt *PrcOpt, y, freq []float64) {
 	var (
 		freq1, freq2, C1, C2, dant_if float64
+		bl, bp                        [NFREQ]float64
 		i, nf                         int
 	)
 	nf = RNF(opt)
@@ -1052,11 +1095,17 @@ func ZdResSat(base int, r float64, obs *ObsD, nav *Nav,
 		C2 = -SQR(freq2) / (SQR(freq1) - SQR(freq2))
 		dant_if = C1*dant[0] + C2*dant[1]
 
+		/* signal bias correction (SINEX-BIAS) */
+		nav.ObsBias(obs, 0, 1, &bl[0])
+		nav.ObsBias(obs, 1, 1, &bl[1])
+		nav.ObsBias(obs, 0, 0, &bp[0])
+		nav.ObsBias(obs, 1, 0, &bp[1])
+
 		if obs.L[0] != 0.0 && obs.L[1] != 0.0 {
-			y[0] = C1*obs.L[0]*CLIGHT/freq1 + C2*obs.L[1]*CLIGHT/freq2 - r - dant_if
+			y[0] = C1*(obs.L[0]*CLIGHT/freq1-bl[0]) + C2*(obs.L[1]*CLIGHT/freq2-bl[1]) - r - dant_if
 		}
 		if obs.P[0] != 0.0 && obs.P[1] != 0.0 {
-			y[1] = C1*obs.P[0] + C2*obs.P[1] - r - dant_if
+			y[1] = C1*(obs.P[0]-bp[0]) + C2*(obs.P[1]-bp[1]) - r - dant_if
 		}
 		freq[0] = 1.0
 	} else {
@@ -1069,12 +1118,16 @@ func ZdResSat(base int, r float64, obs *ObsD, nav *Nav,
 			if TestSnr(base, i, azel[1], float64(obs.SNR[i])*float64(SNR_UNIT), &opt.SnrMask) != 0 {
 				continue
 			}
+			/* signal bias correction (SINEX-BIAS) */
+			nav.ObsBias(obs, i, 1, &bl[i])
+			nav.ObsBias(obs, i, 0, &bp[i])
+
 			/* residuals = observable - pseudorange */
 			if obs.L[i] != 0.0 {
-				y[i] = obs.L[i]*CLIGHT/freq[i] - r - dant[i]
+				y[i] = obs.L[i]*CLIGHT/freq[i] - bl[i] - r - dant[i]
 			}
 			if obs.P[i] != 0.0 {
-				y[i+nf] = obs.P[i] - r - dant[i]
+				y[i+nf] = obs.P[i] - bp[i] - r - dant[i]
 			}
 		}
 	}
@@ -1721,14 +1774,132 @@ func (rtk *Rtk) HoldAmb(xa []float64) {
 	}
 }
 
+/* DD ambiguities and covariance by SD to DD transformation -------------------
+* y=D*xc, Qb=D*Qc*D', Qab=Qac*D' (Qab not computed if Qab==nil)
+*-----------------------------------------------------------------------------*/
+func ddcov(x, P []float64, nx, na int, ix []int, nb int, y, Qb, Qab []float64) {
+	var i, j int
+	DP := Mat(nb, nx-na)
+
+	for i = 0; i < nb; i++ {
+		y[i] = x[ix[i*2]] - x[ix[i*2+1]]
+	}
+	for j = 0; j < nx-na; j++ {
+		for i = 0; i < nb; i++ {
+			DP[i+j*nb] = P[ix[i*2]+(na+j)*nx] - P[ix[i*2+1]+(na+j)*nx]
+		}
+	}
+	for j = 0; j < nb; j++ {
+		for i = 0; i < nb; i++ {
+			Qb[i+j*nb] = DP[i+(ix[j*2]-na)*nb] - DP[i+(ix[j*2+1]-na)*nb]
+		}
+	}
+	if Qab == nil {
+		return
+	}
+	for j = 0; j < nb; j++ {
+		for i = 0; i < na; i++ {
+			Qab[i+j*na] = P[i+ix[j*2]*nx] - P[i+ix[j*2+1]*nx]
+		}
+	}
+}
+
+/* transform float to fixed solution -------------------------------------------
+* xa=xa-Qab*Qb\(y-b), Qa=Qa-Qab*Qb^-1*Qab'
+* args   : double *y      IO  float DD ambiguities (overwritten)
+*          double *Qb     IO  covariance of DD ambiguities (overwritten)
+*          double *Qab    I   covariance of states and DD ambiguities
+*          double *b      I   fixed DD ambiguities
+*          int    nb      I   number of DD ambiguities
+* return : status (1:ok,0:error)
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) FixedSol(y, Qb, Qab, b []float64, nb int) int {
+	var i, j int
+	nx, na := rtk.Nx, rtk.Na
+
+	for i = 0; i < na; i++ {
+		rtk.Xa[i] = rtk.X[i]
+		for j = 0; j < na; j++ {
+			rtk.Pa[i+j*na] = rtk.P[i+j*nx]
+		}
+	}
+	for i = 0; i < nb; i++ {
+		y[i] -= b[i]
+	}
+	if MatInv(Qb, nb) != 0 {
+		return 0
+	}
+	db := Mat(nb, 1)
+	QQ := Mat(na, nb)
+	MatMul("NN", nb, 1, nb, 1.0, Qb, y, 0.0, db)
+	MatMul("NN", na, 1, nb, -1.0, Qab, db, 1.0, rtk.Xa)
+
+	/* covariance of fixed solution (Qa=Qa-Qab*Qb^-1*Qab') */
+	MatMul("NN", na, nb, nb, 1.0, Qab, Qb, 0.0, QQ)
+	MatMul("NT", na, na, nb, -1.0, QQ, Qab, 1.0, rtk.Pa)
+	return 1
+}
+
+/* exclude ambiguities of a satellite for partial ar -------------------------
+* exclude DD ambiguities of a target satellite from index of SD to DD
+* transformation matrix. the satellite is selected by opt.arpartial
+* (1:lowest elevation,2:least lock count, i.e. most recently slipped). the fix
+* flags of the satellite are cleared, so the partial set is also held by
+* fix-and-hold.
+* args   : int    *ix     IO  index of SD to DD transformation matrix
+*          int    nb      I   number of DD ambiguities
+* return : number of remaining DD ambiguities (0:no exclusion)
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) ExcludeAmb(ix []int, nb int) int {
+	var i, j, n, f, sat, exc, fexc int
+	na := rtk.Na
+	minamb := rtk.Opt.ArMinAmb
+	if minamb <= 0 {
+		minamb = MINAMB_PAR
+	}
+	/* select satellite to be excluded */
+	for i = 0; i < nb; i++ {
+		sat, f = (ix[i*2+1]-na)%MAXSAT+1, (ix[i*2+1]-na)/MAXSAT
+		if exc > 0 && rtk.Opt.ArPartial == 2 &&
+			rtk.Ssat[sat-1].Lock[f] != rtk.Ssat[exc-1].Lock[fexc] {
+			if rtk.Ssat[sat-1].Lock[f] < rtk.Ssat[exc-1].Lock[fexc] {
+				exc, fexc = sat, f
+			}
+			continue
+		}
+		if exc == 0 || rtk.Ssat[sat-1].Azel[1] < rtk.Ssat[exc-1].Azel[1] {
+			exc, fexc = sat, f
+		}
+	}
+	for i = 0; i < nb; i++ {
+		if (ix[i*2+1]-na)%MAXSAT+1 == exc {
+			n++
+		}
+	}
+	if exc == 0 || nb-n < minamb {
+		return 0
+	}
+	for i, j = 0, 0; i < nb; i++ {
+		if (ix[i*2+1]-na)%MAXSAT+1 == exc {
+			rtk.Ssat[exc-1].Fix[(ix[i*2+1]-na)/MAXSAT] = 1
+			continue
+		}
+		ix[j*2], ix[j*2+1] = ix[i*2], ix[i*2+1]
+		j++
+	}
+	Trace(2, "resamb : exclude sat=%3d el=%4.1f lock=%d nb=%d\n", exc,
+		rtk.Ssat[exc-1].Azel[1]*R2D, rtk.Ssat[exc-1].Lock[fexc], j)
+	return j
+}
+
 /* resolve integer ambiguity by LAMBDA ---------------------------------------*/
 func (rtk *Rtk) ResolveAmb_LAMBDA(bias, xa []float64) int {
 	var (
-		opt                       *PrcOpt = &rtk.Opt
-		i, j, nb, info, nx, na    int
-		DP, y, b, db, Qb, Qab, QQ []float64
-		s                         [2]float64
-		ix                        []int
+		opt                 *PrcOpt = &rtk.Opt
+		i, nb, info, nx, na int
+		y, b, Qb, Qab       []float64
+		s                   [2]float64
+		ix                  []int
 	)
 	nx = rtk.Nx
 	na = rtk.Na
@@ -1746,92 +1917,405 @@ func (rtk *Rtk) ResolveAmb_LAMBDA(bias, xa []float64) int {
 		rtk.errmsg("no valid double-difference\n")
 		return 0
 	}
-	y = Mat(nb, 1)
-	DP = Mat(nb, nx-na)
-	b = Mat(nb, 2)
-	db = Mat(nb, 1)
-	Qb = Mat(nb, nb)
-	Qab = Mat(na, nb)
-	QQ = Mat(na, nb)
+	for {
+		y = Mat(nb, 1)
+		b = Mat(nb, 2)
+		Qb = Mat(nb, nb)
+		Qab = Mat(na, nb)
+
+		/* y=D*xc, Qb=D*Qc*D', Qab=Qac*D' */
+		ddcov(rtk.X, rtk.P, nx, na, ix, nb, y, Qb, Qab)
+
+		/* LAMBDA/MLAMBDA ILS (integer least-square) estimation */
+		if info = Lambda(nb, 2, y, Qb, b, s[:]); info == 0 {
+			Trace(2, "N(1)=")
+			tracemat(4, b, 1, nb, 10, 3)
+			Trace(2, "N(2)=")
+			tracemat(4, b[nb:], 1, nb, 10, 3)
+
+			rtk.RtkSol.Ratio = 0.0
+			if s[0] > 0 {
+				rtk.RtkSol.Ratio = float32(s[1] / s[0])
+			}
+			if rtk.RtkSol.Ratio > 999.9 {
+				rtk.RtkSol.Ratio = 999.9
+			}
 
-	/* y=D*xc, Qb=D*Qc*D', Qab=Qac*D' */
-	for i = 0; i < nb; i++ {
-		y[i] = rtk.X[ix[i*2]] - rtk.X[ix[i*2+1]]
-	}
-	for j = 0; j < nx-na; j++ {
-		for i = 0; i < nb; i++ {
-			DP[i+j*nb] = rtk.P[ix[i*2]+(na+j)*nx] - rtk.P[ix[i*2+1]+(na+j)*nx]
+			/* validation by popular ratio-test */
+			if s[0] <= 0.0 || s[1]/s[0] >= opt.ThresAr[0] {
+
+				/* transform float to fixed solution (xa=xa-Qab*Qb\(b0-b)) */
+				for i = 0; i < nb; i++ {
+					bias[i] = b[i]
+				}
+				if rtk.FixedSol(y, Qb, Qab, bias, nb) > 0 {
+
+					cs := 0.0
+					if s[0] != 0.0 {
+						cs = s[1] / s[0]
+					}
+					Trace(2, "resamb : validation ok (nb=%d ratio=%.2f s=%.2f/%.2f)\n",
+						nb, cs, s[0], s[1])
+
+					/* restore SD ambiguity */
+					rtk.RestoreAmb(bias, nb, xa)
+				} else {
+					nb = 0
+				}
+			} else { /* validation failed */
+				rtk.errmsg("ambiguity validation failed (nb=%d ratio=%.2f s=%.2f/%.2f)\n",
+					nb, s[1]/s[0], s[0], s[1])
+
+				/* partial ambiguity resolution by excluding a satellite */
+				if opt.ArPartial > 0 {
+					if nb = rtk.ExcludeAmb(ix, nb); nb > 0 {
+						continue
+					}
+				}
+				nb = 0
+			}
+		} else {
+			rtk.errmsg("lambda error (info=%d)\n", info)
+			nb = 0
 		}
+		return nb /* number of ambiguities */
 	}
-	for j = 0; j < nb; j++ {
-		for i = 0; i < nb; i++ {
-			Qb[i+j*nb] = DP[i+(ix[j*2]-na)*nb] - DP[i+(ix[j*2+1]-na)*nb]
+}
+
+/* update wide-lane ambiguity averages of SD ---------------------------------
+* average single-differenced Melbourne-Wubbena LC between rover and base by
+* satellite (Ambc[].LC[0]:L1-L2 wide-lane, LC[1]:L2-L5 extra-wide-lane). the
+* average is reset by cycle-slip or by reset of the phase-bias.
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) UpdateWlRtk(obs []ObsD, sat, iu, ir []int, ns int, nav *Nav) {
+	var (
+		L, P          [2]float64
+		mw, d, f1, f2 float64
+		i, k, s       int
+	)
+	Trace(4, "udwl_rtk: ns=%d\n", ns)
+
+	for i = 0; i < ns; i++ {
+		s = sat[i]
+		ambc := &rtk.Ambc[s-1]
+
+		for k = 0; k < 2 && k+1 < RNF(&rtk.Opt); k++ {
+			f1 = Sat2Freq(s, obs[iu[i]].Code[k], nav)
+			f2 = Sat2Freq(s, obs[iu[i]].Code[k+1], nav)
+			if f1 == 0.0 || f2 == 0.0 || f1 == f2 {
+				continue
+			}
+			L[0] = SingleDifferencedObs(obs, iu[i], ir[i], k) * CLIGHT / f1
+			L[1] = SingleDifferencedObs(obs, iu[i], ir[i], k+1) * CLIGHT / f2
+			P[0] = SingleDifferencedObs(obs, iu[i], ir[i], k+NFREQ)
+			P[1] = SingleDifferencedObs(obs, iu[i], ir[i], k+1+NFREQ)
+
+			if mw = mwmeas_corr(L[:], P[:], f1, f2); mw == 0.0 {
+				continue
+			}
+			if ambc.n[k] == 0 || rtk.Ssat[s-1].Slip[k]&1 > 0 || rtk.Ssat[s-1].Slip[k+1]&1 > 0 ||
+				rtk.Ssat[s-1].Lock[k] < 0 || rtk.Ssat[s-1].Lock[k+1] < 0 {
+				ambc.n[k], ambc.LC[k], ambc.LCv[k] = 0, 0.0, 0.0
+			}
+			ambc.n[k]++
+			d = mw - ambc.LC[k]
+			ambc.LC[k] += d / float64(ambc.n[k])
+			ambc.LCv[k] += (d*(mw-ambc.LC[k]) - ambc.LCv[k]) / float64(ambc.n[k])
+			ambc.epoch[k] = obs[iu[i]].Time
+
+			Trace(4, "udwl_rtk: sat=%2d L%d-L%d n=%4d mw=%10.3f avg=%10.3f std=%6.3f\n", s,
+				k+1, k+2, ambc.n[k], mw, ambc.LC[k], math.Sqrt(ambc.LCv[k]))
 		}
 	}
-	for j = 0; j < nb; j++ {
-		for i = 0; i < na; i++ {
-			Qab[i+j*na] = rtk.P[i+ix[j*2]*nx] - rtk.P[i+ix[j*2+1]*nx]
-		}
+}
+
+/* fix DD wide-lane ambiguity by rounding of averaged SD MW-LC ----------------
+* k=0:L1-L2 wide-lane, k=1:L2-L5 extra-wide-lane. opt.thresar[1] and [2] are
+* used as the min confidence and the max fraction of the wide-lane ambiguity
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) FixWlRtk(sat1, sat2, k int, nwl *int) int {
+	a1, a2 := &rtk.Ambc[sat1-1], &rtk.Ambc[sat2-1]
+
+	if a1.n[k] < MIN_EPOCH_WL || a2.n[k] < MIN_EPOCH_WL {
+		return 0
 	}
-	/* LAMBDA/MLAMBDA ILS (integer least-square) estimation */
-	if info = Lambda(nb, 2, y, Qb, b, s[:]); info == 0 {
-		Trace(2, "N(1)=")
-		tracemat(4, b, 1, nb, 10, 3)
-		Trace(2, "N(2)=")
-		tracemat(4, b[nb:], 1, nb, 10, 3)
+	wl := a1.LC[k] - a2.LC[k]
+	std := math.Sqrt(a1.LCv[k]/float64(a1.n[k]) + a2.LCv[k]/float64(a2.n[k]))
+	*nwl = ROUND_I(wl)
 
-		rtk.RtkSol.Ratio = 0.0
-		if s[0] > 0 {
-			rtk.RtkSol.Ratio = float32(s[1] / s[0])
-		}
-		if rtk.RtkSol.Ratio > 999.9 {
-			rtk.RtkSol.Ratio = 999.9
+	Trace(3, "fixwl_rtk: sat=%2d-%2d L%d-L%d wl=%8.3f std=%6.3f\n", sat1, sat2, k+1, k+2,
+		wl, std)
+
+	if math.Abs(wl-float64(*nwl)) > rtk.Opt.ThresAr[2] ||
+		conffunc(*nwl, wl, math.Max(std, 1e-3)) < rtk.Opt.ThresAr[1] {
+		return 0
+	}
+	return 1
+}
+
+/* resolve narrow-lane ambiguities of selected DD pairs by LAMBDA ------------*/
+func (rtk *Rtk) resamb_nl(xc, Pc []float64, ref, tgt []int, use []bool, np int,
+	b []float64) int {
+	var (
+		i, nb int
+		s     [2]float64
+	)
+	ix := IMat(np, 2)
+	for i = 0; i < np; i++ {
+		if !use[i] {
+			continue
 		}
+		ix[nb*2] = RIB(ref[i], 0, &rtk.Opt)
+		ix[nb*2+1] = RIB(tgt[i], 0, &rtk.Opt)
+		nb++
+	}
+	if nb <= 0 {
+		return 0
+	}
+	y := Mat(nb, 1)
+	Qb := Mat(nb, nb)
+	bb := Mat(nb, 2)
+	ddcov(xc, Pc, rtk.Nx, rtk.Na, ix, nb, y, Qb, nil)
 
-		/* validation by popular ratio-test */
-		if s[0] <= 0.0 || s[1]/s[0] >= opt.ThresAr[0] {
+	if info := Lambda(nb, 2, y, Qb, bb, s[:]); info != 0 {
+		rtk.errmsg("lambda error (info=%d)\n", info)
+		return 0
+	}
+	rtk.RtkSol.Ratio = 0.0
+	if s[0] > 0 {
+		rtk.RtkSol.Ratio = float32(math.Min(s[1]/s[0], 999.9))
+	}
+	/* validation by popular ratio-test */
+	if s[0] > 0.0 && s[1]/s[0] < rtk.Opt.ThresAr[0] {
+		Trace(3, "resamb_nl: validation failed (nb=%d ratio=%.2f s=%.2f/%.2f)\n",
+			nb, s[1]/s[0], s[0], s[1])
+		return 0
+	}
+	MatCpy(b, bb, nb, 1)
+	return nb
+}
+
+/* cascaded ambiguity resolution ------------------------------------------------
+* resolve integer ambiguities of DD by cascading extra-wide-lane (nc=3), wide-
+* lane and narrow-lane for GPS, Galileo and BeiDou. the (extra-)wide-lane
+* ambiguities are fixed by rounding of averaged SD MW-LC. the wide-lane
+* constraints are applied to the float states and then the L1 (narrow-lane)
+* ambiguities are fixed by LAMBDA with the ratio-test. if the full set fails,
+* partial AR (opt.ArPartial>0) is tried by excluding ambiguities with large
+* variance and then the lowest elevation satellites one by one.
+* args   : int    nc      I   number of carriers (2:WL-NL,3:TCAR)
+*          double *bias   O   fixed DD ambiguities
+*          double *xa     O   fixed states
+* return : number of fixed DD ambiguities (0:no fix)
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) resamb_cascade(nc int, bias, xa []float64) int {
+	var (
+		opt                                  *PrcOpt = &rtk.Opt
+		i, j, k, m, f, n, nb, np, nv, nw, r  int
+		info, nx, na, i1, i2, i3, i4, minamb int
+		ref, tgt, sats                       [MAXSAT]int
+		nwl                                  [MAXSAT][2]int
+		use                                  [MAXSAT]bool
+		xc, Pc, H, v, R, y, Qb, Qab, b       []float64
+		ix                                   []int
+	)
+	nx = rtk.Nx
+	na = rtk.Na
+
+	Trace(4, "resamb_cascade : nc=%d nx=%d\n", nc, nx)
+
+	rtk.RtkSol.Ratio = 0.0
 
-			/* transform float to fixed solution (xa=xa-Qab*Qb\(b0-b)) */
-			for i = 0; i < na; i++ {
-				rtk.Xa[i] = rtk.X[i]
-				for j = 0; j < na; j++ {
-					rtk.Pa[i+j*na] = rtk.P[i+j*nx]
+	if opt.Mode <= PMODE_DGPS || opt.ThresAr[0] < 1.0 {
+		return 0
+	}
+	if RNF(opt) < nc {
+		rtk.errmsg("no %d frequencies for cascaded ar\n", nc)
+		return 0
+	}
+	ix = IMat(nx, 2)
+	if nb = rtk.DDIndex(ix); nb <= 0 {
+		rtk.errmsg("no valid double-difference\n")
+		return 0
+	}
+	/* select DD pairs with fixed (extra-)wide-lane ambiguities */
+	for m = 0; m < 4; m++ { /* m=0:GPS/SBS,2:GAL,3:BDS */
+		if m == 1 {
+			continue
+		}
+		for n, r, i = 0, 0, 0; i < MAXSAT; i++ {
+			if test_sys(int(rtk.Ssat[i].Sys), m) == 0 {
+				continue
+			}
+			for f = 0; f < nc && rtk.Ssat[i].Fix[f] == 2; f++ {
+			}
+			if f < nc {
+				continue
+			}
+			sats[n] = i + 1
+			n++
+			if r == 0 || rtk.Ssat[i].Azel[1] > rtk.Ssat[r-1].Azel[1] {
+				r = i + 1 /* reference satellite: highest elevation */
+			}
+		}
+		for i = 0; i < n; i++ {
+			if sats[i] == r {
+				continue
+			}
+			for k = 0; k < nc-1; k++ {
+				if rtk.FixWlRtk(r, sats[i], k, &nwl[np][k]) == 0 {
+					break
 				}
 			}
-			for i = 0; i < nb; i++ {
-				bias[i] = b[i]
-				y[i] -= b[i]
+			if k < nc-1 {
+				continue
 			}
-			if MatInv(Qb, nb) == 0 {
-				MatMul("NN", nb, 1, nb, 1.0, Qb, y, 0.0, db)
-				MatMul("NN", na, 1, nb, -1.0, Qab, db, 1.0, rtk.Xa)
+			ref[np], tgt[np] = r, sats[i]
+			np++
+		}
+	}
+	if np <= 0 {
+		rtk.errmsg("no fixed wide-lane ambiguity\n")
+		return 0
+	}
+	/* apply (extra-)wide-lane constraints to float states */
+	xc = Mat(nx, 1)
+	Pc = Mat(nx, nx)
+	MatCpy(xc, rtk.X, nx, 1)
+	MatCpy(Pc, rtk.P, nx, nx)
+	nw = np * (nc - 1)
+	H = Zeros(nx, nw)
+	v = Mat(nw, 1)
+	R = Zeros(nw, nw)
+
+	for i, nv = 0, 0; i < np; i++ {
+		for k = 0; k < nc-1; k++ {
+			i1, i2 = RIB(ref[i], k, opt), RIB(tgt[i], k, opt)
+			i3, i4 = RIB(ref[i], k+1, opt), RIB(tgt[i], k+1, opt)
+			v[nv] = float64(nwl[i][k]) - ((xc[i1] - xc[i2]) - (xc[i3] - xc[i4]))
+			H[i1+nv*nx] = 1.0
+			H[i2+nv*nx] = -1.0
+			H[i3+nv*nx] = -1.0
+			H[i4+nv*nx] = 1.0
+			R[nv+nv*nw] = VAR_HOLDAMB
+			nv++
+		}
+	}
+	if info = Filter(xc, Pc, H, v, R, nx, nv); info > 0 {
+		rtk.errmsg("filter error (info=%d)\n", info)
+		return 0
+	}
+	/* resolve narrow-lane ambiguities of full set */
+	b = Mat(np, 1)
+	for i = 0; i < np; i++ {
+		use[i] = true
+	}
+	n = rtk.resamb_nl(xc, Pc, ref[:], tgt[:], use[:], np, b)
 
-				/* covariance of fixed solution (Qa=Qa-Qab*Qb^-1*Qab') */
-				MatMul("NN", na, nb, nb, 1.0, Qab, Qb, 0.0, QQ)
-				MatMul("NT", na, na, nb, -1.0, QQ, Qab, 1.0, rtk.Pa)
+	/* partial ambiguity resolution */
+	if minamb = opt.ArMinAmb; minamb <= 0 {
+		minamb = MINAMB_PAR
+	}
+	if n <= 0 && opt.ArPartial > 0 && np > minamb {
 
-				cs := 0.0
-				if s[0] != 0.0 {
-					cs = s[1] / s[0]
+		/* exclude ambiguities with large variance */
+		for i = 0; i < np; i++ {
+			i1, i2 = RIB(ref[i], 0, opt), RIB(tgt[i], 0, opt)
+			if Pc[i1+i1*nx]+Pc[i2+i2*nx]-2.0*Pc[i1+i2*nx] > MAXVAR_PAR {
+				use[i] = false
+			}
+		}
+		for {
+			for i, k = 0, 0; i < np; i++ {
+				if use[i] {
+					k++
+				}
+			}
+			if k < minamb {
+				break
+			}
+			if n = rtk.resamb_nl(xc, Pc, ref[:], tgt[:], use[:], np, b); n > 0 {
+				Trace(3, "resamb_cascade: partial ar ok (nb=%d/%d)\n", n, np)
+				break
+			}
+			/* exclude the lowest elevation satellite */
+			for i, j = 0, -1; i < np; i++ {
+				if use[i] && (j < 0 || rtk.Ssat[tgt[i]-1].Azel[1] < rtk.Ssat[tgt[j]-1].Azel[1]) {
+					j = i
 				}
-				Trace(2, "resamb : validation ok (nb=%d ratio=%.2f s=%.2f/%.2f)\n",
-					nb, cs, s[0], s[1])
+			}
+			use[j] = false
+			Trace(3, "resamb_cascade: exclude sat=%2d el=%4.1f\n", tgt[j],
+				rtk.Ssat[tgt[j]-1].Azel[1]*R2D)
+		}
+	}
+	if n <= 0 {
+		rtk.errmsg("ambiguity validation failed (nb=%d ratio=%.2f)\n", np,
+			rtk.RtkSol.Ratio)
+		return 0
+	}
+	/* fixed DD ambiguities of all carriers (N2=N1-Nwl,N5=N2-Newl) */
+	for i, j, nb = 0, 0, 0; i < np; i++ {
+		if !use[i] {
+			continue
+		}
+		N := b[j]
+		j++
+		for f = 0; f < nc; f++ {
+			ix[nb*2] = RIB(ref[i], f, opt)
+			ix[nb*2+1] = RIB(tgt[i], f, opt)
+			bias[nb] = N
+			nb++
+			if f < nc-1 {
+				N -= float64(nwl[i][f])
+			}
+		}
+	}
+	y = Mat(nb, 1)
+	Qb = Mat(nb, nb)
+	Qab = Mat(na, nb)
+	ddcov(rtk.X, rtk.P, nx, na, ix, nb, y, Qb, Qab)
 
-				/* restore SD ambiguity */
-				rtk.RestoreAmb(bias, nb, xa)
-			} else {
-				nb = 0
+	if rtk.FixedSol(y, Qb, Qab, bias, nb) == 0 {
+		return 0
+	}
+	Trace(2, "resamb_cascade : validation ok (nb=%d ratio=%.2f)\n", nb, rtk.RtkSol.Ratio)
+
+	/* update fix flags to the selected DD pairs */
+	for i = 0; i < MAXSAT; i++ {
+		for f = 0; f < NFREQ; f++ {
+			if rtk.Ssat[i].Fix[f] == 2 {
+				rtk.Ssat[i].Fix[f] = 1
 			}
-		} else { /* validation failed */
-			rtk.errmsg("ambiguity validation failed (nb=%d ratio=%.2f s=%.2f/%.2f)\n",
-				nb, s[1]/s[0], s[0], s[1])
-			nb = 0
 		}
-	} else {
-		rtk.errmsg("lambda error (info=%d)\n", info)
-		nb = 0
 	}
-	return nb /* number of ambiguities */
+	for i = 0; i < np; i++ {
+		for f = 0; use[i] && f < nc; f++ {
+			rtk.Ssat[ref[i]-1].Fix[f] = 2
+			rtk.Ssat[tgt[i]-1].Fix[f] = 2
+		}
+	}
+	/* restore SD ambiguity */
+	for i = 0; i < nx; i++ {
+		xa[i] = rtk.X[i]
+	}
+	for i = 0; i < na; i++ {
+		xa[i] = rtk.Xa[i]
+	}
+	for i = 0; i < nb; i++ {
+		xa[ix[i*2+1]] = rtk.X[ix[i*2]] - bias[i]
+	}
+	return nb
+}
+
+/* resolve integer ambiguity by wide-lane/narrow-lane ---------------------------*/
+func (rtk *Rtk) ResolveAmb_WLNL(bias, xa []float64) int {
+	return rtk.resamb_cascade(2, bias, xa)
+}
+
+/* resolve integer ambiguity by triple carrier ar (TCAR) ----------------------*/
+func (rtk *Rtk) ResolveAmb_TCAR(bias, xa []float64) int {
+	return rtk.resamb_cascade(3, bias, xa)
 }
 
 /* validation of solution ----------------------------------------------------*/
@@ -1865,6 +2349,17 @@ func (rtk *Rtk) ValidPos(v, R []float64, vflg []int, nv int, thres float64) int
 	return stat
 }
 
+/* resolve integer ambiguity by ar mode ---------------------------------------*/
+func (rtk *Rtk) resamb(bias, xa []float64) int {
+	switch rtk.Opt.ModeAr {
+	case ARMODE_WLNL:
+		return rtk.ResolveAmb_WLNL(bias, xa)
+	case ARMODE_TCAR:
+		return rtk.ResolveAmb_TCAR(bias, xa)
+	}
+	return rtk.ResolveAmb_LAMBDA(bias, xa)
+}
+
 /* relative positioning ------------------------------------------------------*/
 func (rtk *Rtk) RelativePos(obs []ObsD, nu, nr int, nav *Nav) int {
 	var (
@@ -1929,6 +2424,11 @@ func (rtk *Rtk) RelativePos(obs []ObsD, nu, nr int, nav *Nav) int {
 	/* temporal update of states */
 	rtk.UpdateState(obs, sat[:], iu[:], ir[:], ns, nav)
 
+	/* update wide-lane ambiguity averages for cascaded ar */
+	if opt.ModeAr == ARMODE_WLNL || opt.ModeAr == ARMODE_TCAR {
+		rtk.UpdateWlRtk(obs, sat[:], iu[:], ir[:], ns, nav)
+	}
+
 	Trace(4, "x(0)=")
 	tracemat(4, rtk.X, 1, RNR(opt), 13, 4)
 
@@ -2006,8 +2506,8 @@ func (rtk *Rtk) RelativePos(obs []ObsD, nu, nr int, nav *Nav) int {
 			stat = SOLQ_NONE
 		}
 	}
-	/* resolve integer ambiguity by LAMBDA */
-	if stat != SOLQ_NONE && rtk.ResolveAmb_LAMBDA(bias, xa) > 1 {
+	/* resolve integer ambiguity by LAMBDA or cascaded ar */
+	if stat != SOLQ_NONE && rtk.resamb(bias, xa) > 1 {
 
 		if ZDRes(0, obs, nu, rs, dts, fvar, svh[:], nav, xa, opt, 0, y, e, azel, freq) > 0 {
 
@@ -2100,6 +2600,279 @@ func (rtk *Rtk) RelativePos(obs []ObsD, nu, nr int, nav *Nav) int {
 	return 0
 }
 
+/* attitude of moving-base vector ----------------------------------------------
+* compute heading and pitch of the vector from base (primary) antenna to rover
+* (secondary) antenna with variances propagated from the baseline covariance
+* args   : rtk_t    *rtk    IO  rtk control/result struct
+*                               (I:rtk.sol.rr,qr,stat,rtk.rb,O:rtk.sol.att,
+*                                qatt,attstat)
+* return : status (1:ok,0:no attitude)
+* notes  : heading is clockwise from true north (0 to 2pi), pitch is positive
+*          upward. attitude status is same as solution status (fix or float).
+*          if baseline length constraint (pos2-baselen) is set, the attitude
+*          is rejected when the baseline length is inconsistent with it
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) MoveBaseAtt() int {
+	var (
+		pos, dr, enu, dh, dp [3]float64
+		P, Q                 [9]float64
+		h2, h, l2, l, sig    float64
+		qh, qp               float64
+		i, j                 int
+	)
+	sol := &rtk.RtkSol
+	opt := &rtk.Opt
+
+	sol.AttStat = SOLQ_NONE
+	sol.Att[0], sol.Att[1] = 0.0, 0.0
+	sol.Qatt[0], sol.Qatt[1] = 0.0, 0.0
+
+	if sol.Stat != SOLQ_FIX && sol.Stat != SOLQ_FLOAT {
+		return 0
+	}
+	for i = 0; i < 3; i++ {
+		dr[i] = sol.Rr[i] - rtk.Rb[i]
+	}
+	Ecef2Pos(rtk.Rb[:], pos[:])
+	Ecef2Enu(pos[:], dr[:], enu[:])
+	sol.Sol2Cov(P[:])
+	Cov2Enu(pos[:], P[:], Q[:])
+
+	h2 = SQR(enu[0]) + SQR(enu[1])
+	l2 = h2 + SQR(enu[2])
+	h, l = math.Sqrt(h2), math.Sqrt(l2)
+
+	if h < MINBL_ATT {
+		rtk.errmsg("moving-base vector too short for attitude (bl=%.3f m)\n", h)
+		return 0
+	}
+	if opt.Baseline[0] > 0.0 {
+		sig = math.Max(opt.Baseline[1], 0.01)
+		if math.Abs(l-opt.Baseline[0]) > THRES_ATT*sig {
+			rtk.errmsg("moving-base length error for attitude (bl=%.3f m)\n", l)
+			return 0
+		}
+	}
+	/* heading/pitch and partial derivatives by {e,n,u} */
+	sol.Att[0] = math.Atan2(enu[0], enu[1])
+	if sol.Att[0] < 0.0 {
+		sol.Att[0] += 2.0 * PI
+	}
+	sol.Att[1] = math.Atan2(enu[2], h)
+	dh[0], dh[1], dh[2] = enu[1]/h2, -enu[0]/h2, 0.0
+	dp[0] = -enu[2] * enu[0] / (l2 * h)
+	dp[1] = -enu[2] * enu[1] / (l2 * h)
+	dp[2] = h / l2
+
+	for i = 0; i < 3; i++ {
+		for j = 0; j < 3; j++ {
+			qh += dh[i] * Q[i+j*3] * dh[j]
+			qp += dp[i] * Q[i+j*3] * dp[j]
+		}
+	}
+	/* use diagonal terms if covariance is not positive definite */
+	if qh <= 0.0 || qp <= 0.0 {
+		qh, qp = 0.0, 0.0
+		for i = 0; i < 3; i++ {
+			qh += SQR(dh[i]) * Q[i+i*3]
+			qp += SQR(dp[i]) * Q[i+i*3]
+		}
+	}
+	sol.Qatt[0], sol.Qatt[1] = float32(qh), float32(qp)
+	sol.AttStat = sol.Stat
+
+	Trace(3, "movebatt: hdg=%.3f pitch=%.3f bl=%.3f std=%.3f %.3f deg stat=%d\n",
+		sol.Att[0]*R2D, sol.Att[1]*R2D, l, SQRT(float64(sol.Qatt[0]))*R2D,
+		SQRT(float64(sol.Qatt[1]))*R2D, sol.AttStat)
+	return 1
+}
+
+/* detect slip by phase-rate for carrier-smoothed code --------------------------
+* detect cycle slip by phase difference between epochs and doppler integrated
+* by trapezoid. the phase-rate residual common to all satellites (receiver
+* clock jump) is removed by the median if 3 or more residuals available.
+* args   : obsd_t *obs      I   observation data
+*          int    n         I   number of observation data
+*          int    rcv       I   receiver (1:rover,2:base)
+*          nav_t  *nav      I   navigation messages
+* return : slip flags of observation data (bit f: frequency f+1)
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) DetectSlp_csm(obs []ObsD, n, rcv int, nav *Nav) []uint8 {
+	var (
+		csm           *CSmooth
+		freq, tt, med float64
+		i, f, sat     int
+		idx           []int
+		res, sres     []float64
+	)
+	slip := make([]uint8, n)
+
+	for i = 0; i < n; i++ {
+		sat = obs[i].Sat
+		csm = &rtk.Csm[rcv-1][sat-1]
+
+		for f = 0; f < NFREQ; f++ {
+			if csm.Cnt[f] <= 0 || csm.Dop[f] == 0.0 || obs[i].L[f] == 0.0 ||
+				obs[i].D[f] == 0.0 {
+				continue
+			}
+			tt = TimeDiff(obs[i].Time, csm.Time[f])
+			if math.Abs(tt) < DTTOL || math.Abs(tt) > MAXDT_CSM {
+				continue
+			}
+			if freq = Sat2Freq(sat, obs[i].Code[f], nav); freq == 0.0 {
+				continue
+			}
+			/* phase-rate residual: (dph + (D+Dp)/2*lam*tt)/tt (m/s) */
+			res = append(res, (obs[i].L[f]*CLIGHT/freq-csm.Ph[f])/tt+
+				(obs[i].D[f]+csm.Dop[f])/2.0*CLIGHT/freq)
+			idx = append(idx, i*NFREQ+f)
+		}
+	}
+	if len(res) >= 3 {
+		sres = append(sres, res...)
+		sort.Float64s(sres)
+		med = sres[len(sres)/2]
+	}
+	for i = 0; i < len(res); i++ {
+		if math.Abs(res[i]-med) <= THRES_CSMD {
+			continue
+		}
+		slip[idx[i]/NFREQ] |= 1 << (idx[i] % NFREQ)
+		Trace(3, "smoothcode: phase-rate jump (sat=%2d rcv=%d F=%d res=%.3f med=%.3f)\n",
+			obs[idx[i]/NFREQ].Sat, rcv, idx[i]%NFREQ+1, res[i], med)
+	}
+	return slip
+}
+
+/* carrier-smoothed code -------------------------------------------------------
+* smooth pseudoranges by hatch filter with carrier-phase. divergence-free
+* smoothing with ionosphere delay change by geometry-free phase is applied if
+* the pair frequency phase (L1 for Lx, L2 for L1) is available
+* args   : rtk_t  *rtk      IO  rtk control/result struct
+*            rtk.csm[r]     IO  carrier-smoothed code status (r=0:rover,1:base)
+*          obsd_t *obs      IO  observation data (pseudorange replaced)
+*          int    n         I   number of observation data
+*          int    rcv       I   receiver (1:rover,2:base)
+*          nav_t  *nav      I   navigation messages
+* return : none
+* notes  : smoothing is reset by slip flag or parity unknown flag transition
+*          in LLI, geometry-free phase jump over prcopt.thresslip, phase-rate
+*          jump by doppler over THRES_CSMD, data gap over MAXDT_CSM or
+*          code-carrier jump over THRES_CSM.
+*          observation data of the last smoothed epoch (base station data not
+*          updated) are replaced by the smoothed code without update.
+*-----------------------------------------------------------------------------*/
+func (rtk *Rtk) SmoothCode(obs []ObsD, n, rcv int, nav *Nav) {
+	var (
+		csm                                *CSmooth
+		freq, freqk, ph, gf, dph, pred, nn float64
+		i, f, k, sat                       int
+		df                                 uint8
+		reset                              bool
+	)
+	Trace(4, "smoothcode: n=%d rcv=%d\n", n, rcv)
+
+	slip := rtk.DetectSlp_csm(obs, n, rcv, nav)
+
+	for i = 0; i < n; i++ {
+		sat = obs[i].Sat
+		csm = &rtk.Csm[rcv-1][sat-1]
+
+		for f = 0; f < NFREQ; f++ {
+			/* same epoch as last smoothed */
+			if csm.Cnt[f] > 0 && obs[i].P[f] != 0.0 && TimeDiff(obs[i].Time, csm.Time[f]) == 0.0 {
+				obs[i].P[f] = csm.Ps[f]
+				continue
+			}
+			freq = Sat2Freq(sat, obs[i].Code[f], nav)
+
+			if obs[i].P[f] == 0.0 || obs[i].L[f] == 0.0 || freq == 0.0 {
+				csm.Cnt[f] = 0
+				continue
+			}
+			ph = obs[i].L[f] * CLIGHT / freq
+
+			/* geometry-free phase to pair frequency */
+			k, gf, df = 1, 0.0, 0
+			if f > 0 {
+				k = 0
+			}
+			if k < NFREQ && obs[i].L[k] != 0.0 {
+				if freqk = Sat2Freq(sat, obs[i].Code[k], nav); freqk > 0.0 && freqk != freq {
+					gf = ph - obs[i].L[k]*CLIGHT/freqk
+					df = 1
+				}
+			}
+			reset = csm.Cnt[f] <= 0 || df != csm.Df[f]
+
+			/* slip flag or parity unknown flag transition in LLI */
+			if obs[i].LLI[f]&1 != 0 || (obs[i].LLI[f]&2) != (csm.LLI[f]&2) {
+				reset = true
+			}
+			/* phase-rate jump by doppler */
+			if slip[i]&(1<<f) != 0 {
+				reset = true
+			}
+			/* data gap */
+			if math.Abs(TimeDiff(obs[i].Time, csm.Time[f])) > MAXDT_CSM {
+				reset = true
+			}
+			/* geometry-free phase jump */
+			if df != 0 && math.Abs(gf-csm.Gf[f]) > rtk.Opt.ThresSlip {
+				reset = true
+			}
+			dph = ph - csm.Ph[f]
+			if df != 0 {
+				/* add twice of ionosphere delay change: dgf/((fi/fk)^2-1) */
+				dph += 2.0 * (gf - csm.Gf[f]) / (SQR(freq/freqk) - 1.0)
+			}
+			pred = csm.Ps[f] + dph
+
+			/* code-carrier jump */
+			if !reset && math.Abs(obs[i].P[f]-pred) > THRES_CSM {
+				Trace(3, "smoothcode: code-carrier jump (sat=%2d rcv=%d F=%d dp=%.3f)\n",
+					sat, rcv, f+1, obs[i].P[f]-pred)
+				reset = true
+			}
+			if reset {
+				csm.Ps[f] = obs[i].P[f]
+				csm.Cnt[f] = 1
+			} else {
+				if csm.Cnt[f] < rtk.Opt.CodeSmooth {
+					csm.Cnt[f]++
+				}
+				nn = float64(csm.Cnt[f])
+				csm.Ps[f] = obs[i].P[f]/nn + (nn-1.0)/nn*pred
+			}
+			csm.Time[f] = obs[i].Time
+			csm.Ph[f] = ph
+			csm.Gf[f] = gf
+			csm.LLI[f] = obs[i].LLI[f]
+			csm.Df[f] = df
+			csm.Dop[f] = obs[i].D[f]
+
+			obs[i].P[f] = csm.Ps[f]
+		}
+	}
+}
+
+/* reset carrier-smoothed code by slip detected ------------------------------*/
+func (rtk *Rtk) ResetSmoothCode(obs []ObsD, n int) {
+	var i, f, sat int
+
+	for i = 0; i < n; i++ {
+		sat = obs[i].Sat
+		for f = 0; f < NFREQ; f++ {
+			if rtk.Ssat[sat-1].Vsat[f] == 0 || rtk.Ssat[sat-1].Slip[f]&1 == 0 {
+				continue
+			}
+			Trace(4, "resetsmoothcode: sat=%2d rcv=%d F=%d\n", sat, obs[i].Rcv, f+1)
+			rtk.Csm[obs[i].Rcv-1][sat-1].Cnt[f] = 0
+		}
+	}
+}
+
 /* initialize RTK control ------------------------------------------------------
 * initialize RTK control struct
 * args   : rtk_t    *rtk    IO  TKk control/result struct
@@ -2111,6 +2884,7 @@ func (rtk *Rtk) InitRtk(opt *PrcOpt) {
 		sol0  Sol
 		ambc0 AmbC
 		ssat0 SSat
+		csm0  CSmooth
 		i     int
 	)
 
@@ -2136,9 +2910,11 @@ func (rtk *Rtk) InitRtk(opt *PrcOpt) {
 	for i = 0; i < MAXSAT; i++ {
 		rtk.Ambc[i] = ambc0
 		rtk.Ssat[i] = ssat0
+		rtk.Csm[0][i], rtk.Csm[1][i] = csm0, csm0
 	}
 	rtk.ErrBuf = ""
 	rtk.Opt = *opt
+	rtk.Ins.InitIns()
 }
 
 /* free rtk control ------------------------------------------------------------
@@ -2200,6 +2976,8 @@ func (rtk *Rtk) FreeRtk() {
 *                .rejc [f]  IO  freq(f+1) data reject count
 *                .gf        IO  geometry-free phase (L1-L2) (m)
 *                .gf2       IO  geometry-free phase (L1-L5) (m)
+*            rtk.csm[r][s] IO  satellite {s+1} carrier-smoothed code status
+*                               (r=0:rover,1:base) (prcopt.codesmooth>0)
 *            rtk.nfix      IO  number of continuous fixes of ambiguity
 *            rtk.neb       IO  bytes of error message buffer
 *            rtk.errbuf    IO  error message buffer
@@ -2227,6 +3005,8 @@ func (rtk *Rtk) RtkPos(obs []ObsD, n int, nav *Nav) int {
 	Trace(4, "obs=\n")
 	traceobs(4, obs, n)
 
+	rtk.RtkSol.AttStat = SOLQ_NONE
+
 	/* set base staion position */
 	if opt.RefPos <= POSOPT_RINEX && opt.Mode != PMODE_SINGLE &&
 		opt.Mode != PMODE_MOVEB {
@@ -2245,8 +3025,16 @@ func (rtk *Rtk) RtkPos(obs []ObsD, n int, nav *Nav) int {
 
 	time = rtk.RtkSol.Time /* previous epoch */
 
+	/* carrier-smoothed code for single point positioning and dgps */
+	obsc := obs
+	if opt.CodeSmooth > 0 {
+		obsc = make([]ObsD, n)
+		copy(obsc, obs[:n])
+		rtk.SmoothCode(obsc, nu, 1, nav)
+		rtk.SmoothCode(obsc[nu:], nr, 2, nav)
+	}
 	/* rover position by single point positioning */
-	if PntPos(obs, nu, nav, &rtk.Opt, &rtk.RtkSol, nil, rtk.Ssat[:], &msg) == 0 {
+	if PntPos(obsc, nu, nav, &rtk.Opt, &rtk.RtkSol, nil, rtk.Ssat[:], &msg) == 0 {
 		rtk.errmsg("point pos error (%s)\n", msg)
 
 		if rtk.Opt.Dynamics == 0 {
@@ -2270,6 +3058,9 @@ func (rtk *Rtk) RtkPos(obs []ObsD, n int, nav *Nav) int {
 	/* precise point positioning */
 	if opt.Mode >= PMODE_PPP_KINEMA {
 		rtk.PPPos(obs, nu, nav)
+		if opt.CodeSmooth > 0 {
+			rtk.ResetSmoothCode(obs, nu)
+		}
 		rtk.OutSolStat()
 		return 1
 	}
@@ -2282,7 +3073,7 @@ func (rtk *Rtk) RtkPos(obs []ObsD, n int, nav *Nav) int {
 	if opt.Mode == PMODE_MOVEB { /*  moving baseline */
 
 		/* estimate position/velocity of base station */
-		if PntPos(obs[+nu:], nr, nav, &rtk.Opt, &solb, nil, nil, &msg) == 0 {
+		if PntPos(obsc[nu:], nr, nav, &rtk.Opt, &solb, nil, nil, &msg) == 0 {
 			rtk.errmsg("base station position error (%s)\n", msg)
 			return 0
 		}
@@ -2309,8 +3100,20 @@ func (rtk *Rtk) RtkPos(obs []ObsD, n int, nav *Nav) int {
 			return 1
 		}
 	}
-	/* relative potitioning */
-	rtk.RelativePos(obs, nu, nr, nav)
+	/* relative potitioning (smoothed code for dgps) */
+	if opt.Mode == PMODE_DGPS {
+		rtk.RelativePos(obsc, nu, nr, nav)
+	} else {
+		rtk.RelativePos(obs, nu, nr, nav)
+		if opt.CodeSmooth > 0 {
+			rtk.ResetSmoothCode(obs, nu+nr)
+		}
+	}
+
+	/* attitude of moving-base vector */
+	if opt.Mode == PMODE_MOVEB {
+		rtk.MoveBaseAtt()
+	}
 	rtk.OutSolStat()
 
 	return 1
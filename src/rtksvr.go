@@ -43,22 +43,176 @@
 *                            use API sat2freq() to get carrier frequency
 *                            use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite rtksvr.c with golang by fxb
+*           2026/10/16  1.1  add api Subscribe(),Unsubscribe() for server events
+*                            delete global ObsChannel and RbSolChannel
+*           2026/10/16  1.2  support imu data input for gnss/ins integration
+*           2026/10/16  1.3  support QZSS L6 (CLAS) input and clas atmospheric
+*                            corrections
+*           2026/10/16  1.4  select clas network ssr corrections by rover
+*                            position
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 )
 
 const MIN_INT_RESET int = 30000 /* mininum interval of reset command (ms) */
-type RBSol struct {
-	Rb  [6]float64
-	Sol Sol
+
+const (
+	SVREV_OBS   = 0x01  /* rtk server event: observation data */
+	SVREV_EPH   = 0x02  /* rtk server event: ephemeris */
+	SVREV_SOL   = 0x04  /* rtk server event: solution */
+	SVREV_STAT  = 0x08  /* rtk server event: stream status */
+	SVREV_ERR   = 0x10  /* rtk server event: error */
+	SVREV_ALL   = 0x1F  /* rtk server event: all */
+	SVREV_BLOCK = 0x100 /* subscribe option: block server if buffer full */
+)
+
+/* subscribe rtk server events -------------------------------------------------
+* subscribe rtk server events
+* args   : svr *RtkSvr    IO rtk server
+*          int     kinds    I  event kinds (or of the followings)
+*                              SVREV_OBS  : observation data
+*                              SVREV_EPH  : ephemeris
+*                              SVREV_SOL  : solution
+*                              SVREV_STAT : stream status
+*                              SVREV_ERR  : error
+*                              SVREV_BLOCK: block server if buffer full
+*                                           (default: drop the event)
+*          int     bufsize  I  event buffer size
+* return : subscriber (events are received by sub.C)
+* notes  : the event channel is closed by RtkSvrStop() or Unsubscribe().
+*          a blocking subscriber must read events not to stop the server.
+*-----------------------------------------------------------------------------*/
+func (svr *RtkSvr) Subscribe(kinds, bufsize int) *SvrSubscriber {
+	if bufsize < 0 {
+		bufsize = 0
+	}
+	sub := &SvrSubscriber{Kinds: kinds, ch: make(chan SvrEvent, bufsize),
+		quit: make(chan struct{})}
+	sub.C = sub.ch
+
+	svr.SubLock.Lock()
+	svr.Subs = append(svr.Subs, sub)
+	svr.SubLock.Unlock()
+	return sub
+}
+
+/* unsubscribe rtk server events -----------------------------------------------
+* unsubscribe rtk server events and close event channel
+* args   : svr *RtkSvr    IO rtk server
+*          sub *SvrSubscriber I subscriber
+* return : none
+*-----------------------------------------------------------------------------*/
+func (svr *RtkSvr) Unsubscribe(sub *SvrSubscriber) {
+	sub.once.Do(func() { close(sub.quit) }) /* release blocking server */
+
+	svr.SubLock.Lock()
+	defer svr.SubLock.Unlock()
+	for i, v := range svr.Subs {
+		if v == sub {
+			svr.Subs = append(svr.Subs[:i], svr.Subs[i+1:]...)
+			close(sub.ch)
+			break
+		}
+	}
+}
+
+/* number of dropped events --------------------------------------------------*/
+func (sub *SvrSubscriber) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.Drops)
+}
+
+/* publish event to subscribers ----------------------------------------------*/
+func (svr *RtkSvr) PublishEvent(ev *SvrEvent) {
+	svr.SubLock.Lock()
+	defer svr.SubLock.Unlock()
+
+	for _, sub := range svr.Subs {
+		if sub.Kinds&ev.Kind == 0 {
+			continue
+		}
+		if sub.Kinds&SVREV_BLOCK != 0 {
+			select {
+			case sub.ch <- *ev:
+			case <-sub.quit:
+			case <-svr.StopC:
+			}
+			continue
+		}
+		select {
+		case sub.ch <- *ev:
+		default:
+			atomic.AddUint64(&sub.Drops, 1)
+		}
+	}
+}
+
+/* number of subscribers ----------------------------------------------------*/
+func (svr *RtkSvr) nsubs() int {
+	svr.SubLock.Lock()
+	defer svr.SubLock.Unlock()
+	return len(svr.Subs)
+}
+
+/* queue event under lock ----------------------------------------------------*/
+func (svr *RtkSvr) queueevent(ev SvrEvent) {
+	if svr.nsubs() == 0 {
+		return
+	}
+	svr.EvQueue = append(svr.EvQueue, ev)
+}
+
+/* publish queued events -----------------------------------------------------*/
+func (svr *RtkSvr) flushevents() {
+	svr.RtkSvrLock()
+	evs := svr.EvQueue
+	svr.EvQueue = nil
+	svr.RtkSvrUnlock()
+
+	for i := range evs {
+		svr.PublishEvent(&evs[i])
+	}
+}
+
+/* publish stream status changes ---------------------------------------------*/
+func (svr *RtkSvr) pubstreamstat() {
+	var msg string
+
+	if svr.nsubs() == 0 {
+		return
+	}
+	for i := range svr.Stream {
+		state := svr.Stream[i].StreamStat(&msg)
+		if state == svr.StrStat[i] {
+			continue
+		}
+		svr.StrStat[i] = state
+		ev := SvrEvent{Kind: SVREV_STAT, Time: Utc2GpsT(TimeGet()), Index: i,
+			State: state, Msg: msg}
+		svr.PublishEvent(&ev)
+		if state < 0 {
+			ev.Kind = SVREV_ERR
+			ev.Msg = fmt.Sprintf("stream error: str%d %s", i+1, msg)
+			svr.PublishEvent(&ev)
+		}
+	}
 }
 
-var ObsChannel chan ObsD
-var RbSolChannel chan RBSol
+/* close all subscribers -----------------------------------------------------*/
+func (svr *RtkSvr) closesubs() {
+	svr.SubLock.Lock()
+	defer svr.SubLock.Unlock()
+
+	for _, sub := range svr.Subs {
+		sub.once.Do(func() { close(sub.quit) })
+		close(sub.ch)
+	}
+	svr.Subs = nil
+}
 
 /* write solution header to output stream ------------------------------------*/
 func writesolhead(stream *Stream, solopt *SolOpt) {
@@ -206,6 +360,10 @@ func (svr *RtkSvr) UpdateEph(nav *Nav, ephsat, ephset, index int) {
 					TimeDiff(eph1.Toc, eph2.Toc) != 0.0) {
 				*eph3 = *eph2 /* current .previous */
 				*eph2 = *eph1 /* received.current */
+
+				eph := *eph1
+				svr.queueevent(SvrEvent{Kind: SVREV_EPH, Time: eph.Ttr, Index: index,
+					Eph: &eph})
 			}
 		}
 		svr.InputMsg[index][1]++
@@ -219,6 +377,10 @@ func (svr *RtkSvr) UpdateEph(nav *Nav, ephsat, ephset, index int) {
 				*geph3 = *geph2
 				*geph2 = *geph1
 				svr.UpdateGloFcn()
+
+				geph := *geph1
+				svr.queueevent(SvrEvent{Kind: SVREV_EPH, Time: geph.Tof, Index: index,
+					Geph: &geph})
 			}
 		}
 		svr.InputMsg[index][6]++
@@ -245,6 +407,21 @@ func (svr *RtkSvr) UpdateSbs(sbsmsg *SbsMsg, index int) {
 	svr.InputMsg[index][3]++
 }
 
+/* update imu data ----------------------------------------------------------*/
+func (svr *RtkSvr) UpdateImu(index int) {
+	if index != 0 || svr.RtkCtrl.Opt.InsOpt.Mode == 0 {
+		return
+	}
+	imu := &svr.RawCtrl[index].ImuData
+	for i := 0; i < imu.N(); i++ {
+		if svr.ImuData.N() >= MAXIMUBUF {
+			svr.ImuData.Data = svr.ImuData.Data[1:]
+		}
+		svr.ImuData.AddImuData(&imu.Data[i])
+	}
+	svr.InputMsg[index][8]++
+}
+
 /* update ion/utc parameters -------------------------------------------------*/
 func (svr *RtkSvr) UpdateIonUtc(nav *Nav, index int) {
 	if svr.NavSel == 0 || svr.NavSel == index+1 {
@@ -333,6 +510,10 @@ func (svr *RtkSvr) UpdateSsr(index int) {
 		}
 		svr.NavData.Ssr[i] = svr.RtcmCtrl[index].Ssr[i]
 	}
+	svr.NavData.UpdateCssrAtm(&svr.RtcmCtrl[index].NavData)
+
+	/* clas network ssr corrections by rover position */
+	svr.NavData.CssrSelSsr(svr.RtcmCtrl[index].Time, svr.RtkCtrl.RtkSol.Rr[:])
 	svr.InputMsg[index][7]++
 }
 
@@ -357,8 +538,12 @@ func (svr *RtkSvr) UpdateSvr(ret int, obs *Obs, nav *Nav, ephsat,
 		svr.InputMsg[index][5]++
 	case 10: /* ssr message */
 		svr.UpdateSsr(index)
+	case 11: /* imu data */
+		svr.UpdateImu(index)
 	case -1: /* error */
 		svr.InputMsg[index][9]++
+		svr.queueevent(SvrEvent{Kind: SVREV_ERR, Time: Utc2GpsT(TimeGet()), Index: index,
+			Msg: fmt.Sprintf("input data error: str%d", index+1)})
 	}
 }
 
@@ -391,6 +576,10 @@ func (svr *RtkSvr) DecodeRaw(index int) int {
 			nav = &svr.RtcmCtrl[index].NavData
 			ephsat = svr.RtcmCtrl[index].EphSat
 			ephset = svr.RtcmCtrl[index].EphSet
+		case STRFMT_L6:
+			ret = svr.RtcmCtrl[index].InputL6(svr.Buff[index][i])
+			obs = &svr.RtcmCtrl[index].ObsData
+			nav = &svr.RtcmCtrl[index].NavData
 		default:
 			ret = svr.RawCtrl[index].InputRaw(svr.Format[index], svr.Buff[index][i])
 			obs = &svr.RawCtrl[index].ObsData
@@ -493,15 +682,19 @@ func (svr *RtkSvr) DecodeFile(index int) {
 /* carrier-phase bias (fcb) correction ---------------------------------------*/
 func CorrPhaseBias(obs []ObsD, n int, nav *Nav) {
 	var (
-		freq float64
-		code uint8
-		i, j int
+		freq, bias float64
+		code       uint8
+		i, j       int
 	)
 
 	for i = 0; i < n; i++ {
 		for j = 0; j < NFREQ; j++ {
 			code = obs[i].Code[j]
-			if freq = Sat2Freq(obs[i].Sat, code, nav); freq == 0.0 {
+			if freq = Sat2Freq(obs[i].Sat, code, nav); freq == 0.0 || obs[i].L[j] == 0.0 {
+				continue
+			}
+			/* phase OSB (SINEX-BIAS) is corrected in CorrMeas() instead */
+			if nav.ObsBias(&obs[i], j, 1, &bias) > 0 {
 				continue
 			}
 
@@ -686,6 +879,10 @@ func rtksvrthread(svr *RtkSvr) int {
 				fobs[i] = svr.DecodeRaw(i)
 			}
 		}
+		/* publish ephemeris/error and stream status events */
+		svr.flushevents()
+		svr.pubstreamstat()
+
 		/* averaging single base pos */
 		if fobs[1] > 0 && svr.RtkCtrl.Opt.RefPos == POSOPT_SINGLE {
 			if (svr.RtkCtrl.Opt.MaxAveEp <= 0 || svr.NAve < svr.RtkCtrl.Opt.MaxAveEp) &&
@@ -709,21 +906,26 @@ func rtksvrthread(svr *RtkSvr) int {
 				obs.AddObsData(&svr.ObsData[1][0].Data[j])
 			}
 			/* carrier phase bias correction */
-			if strings.Contains(svr.RtkCtrl.Opt.PPPOpt, "-DIS_FCB") {
+			if !strings.Contains(svr.RtkCtrl.Opt.PPPOpt, "-DIS_FCB") {
 				CorrPhaseBias(obs.Data, obs.N(), &svr.NavData)
 			}
 
-			/* send obs data to channel */
-			for i = 0; i < obs.N(); i++ {
-				select {
-				case ObsChannel <- obs.Data[i]:
-				default:
-				}
+			/* publish observation data event */
+			if obs.N() > 0 {
+				svr.PublishEvent(&SvrEvent{Kind: SVREV_OBS, Time: obs.Data[0].Time,
+					Obs: append([]ObsD(nil), obs.Data...)})
 			}
 
 			/* rtk positioning */
 			svr.RtkSvrLock()
-			svr.RtkCtrl.RtkPos(obs.Data, obs.N(), &svr.NavData)
+			if svr.RtkCtrl.Opt.InsOpt.Mode > 0 && obs.N() > 0 {
+				k := svr.RtkCtrl.InsInput(svr.ImuData.Data, obs.Data[0].Time)
+				svr.ImuData.Data = svr.ImuData.Data[k:]
+			}
+			stat := svr.RtkCtrl.RtkPos(obs.Data, obs.N(), &svr.NavData)
+			if svr.RtkCtrl.Opt.InsOpt.Mode > 0 && obs.N() > 0 {
+				svr.RtkCtrl.InsIntegrate(obs.Data[0].Time, stat)
+			}
 			svr.RtkSvrUnlock()
 
 			if svr.RtkCtrl.RtkSol.Stat != SOLQ_NONE {
@@ -736,11 +938,13 @@ func rtksvrthread(svr *RtkSvr) int {
 				svr.WriteSol(i)
 			}
 
-			/* send sol to channel */
-			select {
-			case RbSolChannel <- RBSol{svr.RtkCtrl.Rb, svr.RtkCtrl.RtkSol}:
-			default:
-			}
+			/* publish solution event */
+			svr.RtkSvrLock()
+			ev := SvrEvent{Kind: SVREV_SOL, Time: svr.RtkCtrl.RtkSol.Time,
+				Sol: svr.RtkCtrl.RtkSol, Rb: svr.RtkCtrl.Rb}
+			svr.RtkSvrUnlock()
+			svr.PublishEvent(&ev)
+
 			/* if cpu overload, inclement obs outage counter and break */
 			if int(TickGet()-int64(tick)) >= svr.Cycle {
 				svr.PrcOut += fobs[0] - i - 1
@@ -1004,6 +1208,7 @@ func (svr *RtkSvr) RtkSvrStart(cycle, buffsize int, strs []int,
 	}
 	svr.NavSel = navsel
 	svr.NoSbs = 0
+	svr.ImuData.Data = nil
 	svr.NoSol = 0
 	svr.PrcOut = 0
 	svr.RtkCtrl.FreeRtk()
@@ -1118,9 +1323,12 @@ func (svr *RtkSvr) RtkSvrStart(cycle, buffsize int, strs []int,
 	for i = 3; i < 5; i++ {
 		writesolhead(&svr.Stream[i], &svr.Solopt[i-3])
 	}
-	/* initialize channel */
-	ObsChannel = make(chan ObsD, MAXOBSBUF)
-	RbSolChannel = make(chan RBSol, 10)
+	/* initialize stream status for events */
+	for i = 0; i < len(svr.StrStat); i++ {
+		svr.StrStat[i] = 0
+	}
+	svr.EvQueue = nil
+	svr.StopC = make(chan struct{})
 
 	/* create rtk server thread */
 	svr.Wg.Add(1)
@@ -1156,12 +1364,22 @@ func (svr *RtkSvr) RtkSvrStop(cmds []string) {
 	}
 	svr.RtkSvrUnlock()
 
-	close(ObsChannel)
-	close(RbSolChannel)
-
 	/* stop rtk server */
 	svr.State = 0
+
+	/* release server blocked by subscribers */
+	if svr.StopC != nil {
+		select {
+		case <-svr.StopC:
+		default:
+			close(svr.StopC)
+		}
+	}
+
 	svr.Wg.Wait() // wait for thread exit
+
+	/* close event channels */
+	svr.closesubs()
 }
 
 /* open output/log stream ------------------------------------------------------
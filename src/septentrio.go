@@ -0,0 +1,1515 @@
+/*------------------------------------------------------------------------------
+* septentrio.go : Septentrio SBF receiver dependent functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] Septentrio, mosaic-X5 Reference Guide, Applicable to version 4.14.0
+*         of the Firmware, 2023
+*     [2] Septentrio, PolaRx5 Reference Guide, Applicable to version 5.5.0
+*         of the Firmware, 2022
+*     [3] Septentrio, SBF Reference Guide, Applicable to version 4.x/5.x
+*         of the Firmware
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, support MeasEpoch, MeasExtra, EndOfMeas,
+*                           GPS/QZS/GLO/GAL/BDS/SBAS raw navigation pages,
+*                           GPS/QZS/GLO/GAL/BDS decoded ephemeris,
+*                           GPS/GAL/BDS iono and utc parameters and
+*                           ReceiverSetup
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	SBFSYNC1 = 0x24 /* SBF message header sync field 1 ('$') */
+	SBFSYNC2 = 0x40 /* SBF message header sync field 2 ('@') */
+	SBFHLEN  = 8    /* SBF message header length (bytes) */
+
+	/* SBF block IDs */
+	ID_SBF_MEASEPOCH     = 4027 /* measurement set of one epoch */
+	ID_SBF_MEASEXTRA     = 4000 /* additional info such as observable variance */
+	ID_SBF_ENDOFMEAS     = 5922 /* measurement epoch marker */
+	ID_SBF_GPSRAWCA      = 4017 /* GPS CA navigation subframe */
+	ID_SBF_QZSRAWL1CA    = 4066 /* QZSS L1 CA navigation subframe */
+	ID_SBF_GLORAWCA      = 4026 /* GLONASS CA navigation string */
+	ID_SBF_GALRAWINAV    = 4023 /* Galileo I/NAV navigation page */
+	ID_SBF_GALRAWFNAV    = 4022 /* Galileo F/NAV navigation page */
+	ID_SBF_BDSRAW        = 4047 /* BeiDou navigation page */
+	ID_SBF_GEORAWL1      = 4020 /* SBAS L1 navigation message */
+	ID_SBF_GPSNAV        = 5891 /* GPS ephemeris and clock */
+	ID_SBF_QZSNAV        = 4095 /* QZSS ephemeris and clock */
+	ID_SBF_GLONAV        = 4004 /* GLONASS ephemeris and clock */
+	ID_SBF_GALNAV        = 4002 /* Galileo ephemeris, clock, health and BGD */
+	ID_SBF_BDSNAV        = 4081 /* BeiDou ephemeris and clock */
+	ID_SBF_GPSION        = 5893 /* ionosphere data from the GPS subframe 5 */
+	ID_SBF_GPSUTC        = 5894 /* GPS-UTC data from GPS subframe 5 */
+	ID_SBF_GALION        = 4030 /* NeQuick ionosphere parameters */
+	ID_SBF_GALUTC        = 4031 /* GST-UTC data */
+	ID_SBF_BDSION        = 4120 /* BeiDou ionospheric delay model parameters */
+	ID_SBF_BDSUTC        = 4121 /* BDT-UTC data */
+	ID_SBF_RECEIVERSETUP = 5902 /* general information about the receiver set-up */
+
+	SBF_MAXCHAN = 256 /* max number of receiver channels */
+	SBF_MAXSIG  = 40  /* max number of SBF signal types */
+)
+
+type sbf_t struct { /* SBF receiver dependent data type */
+	chsat   [SBF_MAXCHAN]int              /* satellite tracked by channel */
+	lossc   [MAXSAT][NFREQ + NEXOBS]int   /* cumulative loss of continuity counter */
+	slip    [MAXSAT][NFREQ + NEXOBS]uint8 /* pending slip flag by MeasExtra */
+	meas2   int                           /* MeasExtra received (0:no,1:yes) */
+	pending int                           /* measurement epoch pending (0:no,1:yes) */
+	tobs    Gtime                         /* time of pending measurement epoch */
+}
+
+/* initialize SBF receiver dependent data ------------------------------------*/
+func init_sbf(raw *Raw) int {
+	var sbf sbf_t
+
+	for i := 0; i < MAXSAT; i++ {
+		for j := 0; j < NFREQ+NEXOBS; j++ {
+			sbf.lossc[i][j] = -1
+		}
+	}
+	raw.sbf = &sbf
+	return 1
+}
+
+/* get SBF receiver dependent data -------------------------------------------*/
+func sbf_data(raw *Raw) *sbf_t {
+	if raw.sbf == nil {
+		init_sbf(raw)
+	}
+	return raw.sbf
+}
+
+/* SBF SVID to satellite system and prn (ref [3] 4.1.9) ----------------------*/
+func sbf_svid2prn(svid int, prn *int) int {
+	switch {
+	case svid >= 1 && svid <= 37:
+		*prn = svid
+		return SYS_GPS
+	case svid >= 38 && svid <= 61:
+		*prn = svid - 37
+		return SYS_GLO
+	case svid >= 63 && svid <= 68:
+		*prn = svid - 38
+		return SYS_GLO
+	case svid >= 71 && svid <= 106:
+		*prn = svid - 70
+		return SYS_GAL
+	case svid >= 120 && svid <= 140:
+		*prn = svid
+		return SYS_SBS
+	case svid >= 141 && svid <= 180:
+		*prn = svid - 140
+		return SYS_CMP
+	case svid >= 181 && svid <= 190:
+		*prn = svid - 180 + MINPRNQZS - 1
+		return SYS_QZS
+	case svid >= 191 && svid <= 197:
+		*prn = svid - 190
+		return SYS_IRN
+	case svid >= 198 && svid <= 215:
+		*prn = svid - 57
+		return SYS_SBS
+	case svid >= 216 && svid <= 222:
+		*prn = svid - 208
+		return SYS_IRN
+	case svid >= 223 && svid <= 245:
+		*prn = svid - 182
+		return SYS_CMP
+	}
+	return SYS_NONE
+}
+
+/* SBF SVID to satellite number ----------------------------------------------*/
+func sbf_svid2sat(svid int) int {
+	var prn int
+	sys := sbf_svid2prn(svid, &prn)
+	if sys == SYS_NONE {
+		return 0
+	}
+	return SatNo(sys, prn)
+}
+
+/* SBF signal type to system and obs code (ref [3] 4.1.10) -------------------*/
+func sbf_sig2code(sig int, sys *int) int {
+	switch sig {
+	case 0:
+		*sys = SYS_GPS
+		return CODE_L1C /* GPS L1C/A */
+	case 1:
+		*sys = SYS_GPS
+		return CODE_L1W /* GPS L1P(Y) */
+	case 2:
+		*sys = SYS_GPS
+		return CODE_L2W /* GPS L2P(Y) */
+	case 3:
+		*sys = SYS_GPS
+		return CODE_L2L /* GPS L2C */
+	case 4:
+		*sys = SYS_GPS
+		return CODE_L5Q /* GPS L5 */
+	case 5:
+		*sys = SYS_GPS
+		return CODE_L1L /* GPS L1C */
+	case 6:
+		*sys = SYS_QZS
+		return CODE_L1C /* QZS L1C/A */
+	case 7:
+		*sys = SYS_QZS
+		return CODE_L2L /* QZS L2C */
+	case 8:
+		*sys = SYS_GLO
+		return CODE_L1C /* GLO L1C/A */
+	case 9:
+		*sys = SYS_GLO
+		return CODE_L1P /* GLO L1P */
+	case 10:
+		*sys = SYS_GLO
+		return CODE_L2P /* GLO L2P */
+	case 11:
+		*sys = SYS_GLO
+		return CODE_L2C /* GLO L2C/A */
+	case 12:
+		*sys = SYS_GLO
+		return CODE_L3Q /* GLO L3 */
+	case 13:
+		*sys = SYS_CMP
+		return CODE_L1P /* BDS B1C */
+	case 14:
+		*sys = SYS_CMP
+		return CODE_L5P /* BDS B2a */
+	case 15:
+		*sys = SYS_IRN
+		return CODE_L5A /* NavIC L5 */
+	case 17:
+		*sys = SYS_GAL
+		return CODE_L1C /* GAL E1(L1BC) */
+	case 19:
+		*sys = SYS_GAL
+		return CODE_L6C /* GAL E6(E6BC) */
+	case 20:
+		*sys = SYS_GAL
+		return CODE_L5Q /* GAL E5a */
+	case 21:
+		*sys = SYS_GAL
+		return CODE_L7Q /* GAL E5b */
+	case 22:
+		*sys = SYS_GAL
+		return CODE_L8Q /* GAL E5 AltBOC */
+	case 24:
+		*sys = SYS_SBS
+		return CODE_L1C /* SBAS L1C/A */
+	case 25:
+		*sys = SYS_SBS
+		return CODE_L5I /* SBAS L5 */
+	case 26:
+		*sys = SYS_QZS
+		return CODE_L5Q /* QZS L5 */
+	case 27:
+		*sys = SYS_QZS
+		return CODE_L6L /* QZS L6 */
+	case 28:
+		*sys = SYS_CMP
+		return CODE_L2I /* BDS B1I */
+	case 29:
+		*sys = SYS_CMP
+		return CODE_L7I /* BDS B2I */
+	case 30:
+		*sys = SYS_CMP
+		return CODE_L6I /* BDS B3I */
+	case 32:
+		*sys = SYS_QZS
+		return CODE_L1L /* QZS L1C */
+	case 33:
+		*sys = SYS_QZS
+		return CODE_L1Z /* QZS L1S */
+	case 34:
+		*sys = SYS_CMP
+		return CODE_L7D /* BDS B2b */
+	case 38:
+		*sys = SYS_QZS
+		return CODE_L5P /* QZS L5S */
+	}
+	*sys = SYS_NONE
+	return CODE_NONE
+}
+
+/* get observation data slot of satellite ------------------------------------*/
+func sbf_obsindex(obs *Obs, time Gtime, sat int) int {
+	var i, j int
+
+	for i = 0; i < obs.n; i++ {
+		if obs.Data[i].Sat == sat {
+			return i
+		}
+	}
+	if obs.n >= MAXOBS {
+		return -1
+	}
+	obs.Data[i].Time = time
+	obs.Data[i].Sat = sat
+	obs.Data[i].Rcv = 0
+	for j = 0; j < NFREQ+NEXOBS; j++ {
+		obs.Data[i].L[j], obs.Data[i].P[j], obs.Data[i].D[j] = 0.0, 0.0, 0.0
+		obs.Data[i].SNR[j], obs.Data[i].LLI[j] = 0, 0
+		obs.Data[i].Code[j] = CODE_NONE
+	}
+	obs.n++
+	return i
+}
+
+/* set observables of a signal -----------------------------------------------*/
+func sbf_setobs(raw *Raw, sat, sig, code, idx, lli int, P, L, D, cn0, lockt float64) {
+	var (
+		sys = SatSys(sat, nil)
+		i   int
+	)
+	if i = sbf_obsindex(&raw.ObsBuf, raw.Time, sat); i < 0 {
+		return
+	}
+	data := &raw.ObsBuf.Data[i]
+
+	/* select signal by code priority if frequency slot is already filled */
+	if data.Code[idx] != CODE_NONE && data.Code[idx] != uint8(code) &&
+		GetCodePri(sys, data.Code[idx], raw.Opt) >= GetCodePri(sys, uint8(code), raw.Opt) {
+		return
+	}
+	if lockt == 0.0 || lockt < raw.LockTime[sat-1][idx] {
+		lli |= LLI_SLIP
+	}
+	raw.LockTime[sat-1][idx] = lockt
+	raw.Tobs[sat-1][idx] = raw.Time
+
+	data.P[idx] = P
+	data.L[idx] = L
+	data.D[idx] = D
+	data.SNR[idx] = uint16(cn0/SNR_UNIT + 0.5)
+	data.LLI[idx] = uint8(lli)
+	data.Code[idx] = uint8(code)
+}
+
+/* output pending measurement epoch ------------------------------------------*/
+func sbf_flushobs(raw *Raw) int {
+	var (
+		sbf  = sbf_data(raw)
+		i, j int
+		n    int
+	)
+	if sbf.pending == 0 {
+		return 0
+	}
+	sbf.pending = 0
+
+	for i = 0; i < raw.ObsBuf.n && n < MAXOBS; i++ {
+		data := raw.ObsBuf.Data[i]
+		for j = 0; j < NFREQ+NEXOBS; j++ {
+			if data.Code[j] == CODE_NONE {
+				continue
+			}
+			data.LLI[j] |= sbf.slip[data.Sat-1][j]
+			sbf.slip[data.Sat-1][j] = 0
+		}
+		raw.ObsData.Data[n] = data
+		n++
+	}
+	raw.ObsData.n = n
+	raw.ObsBuf.n = 0
+	return 1
+}
+
+/* decode SBF MeasEpoch: measurement set of one epoch (ref [3] 4.2.2) --------*/
+func decode_sbf_measepoch(raw *Raw) int {
+	var (
+		sbf                                        = sbf_data(raw)
+		p                                          = 14
+		n1, sb1len, sb2len, n2, ch, svid, sig, ant int
+		sat, sys, sys2, code, idx, i, j, fcn, prn  int
+		obsinfo, lli, codemsb, mant, k, ret        int
+		P1, L1, D1, P2, L2, D2, freq1, freq2, cn0  float64
+		lockt                                      float64
+		codeoff, dopoff, carrier                   int32
+		antsel                                     int
+		tstr                                       string
+	)
+	if raw.Len < 20 {
+		Trace(2, "sbf measepoch length error: len=%d\n", raw.Len)
+		return -1
+	}
+	n1 = int(U1(raw.Buff[p:]))
+	sb1len = int(U1(raw.Buff[p+1:]))
+	sb2len = int(U1(raw.Buff[p+2:]))
+
+	if raw.OutType > 0 {
+		Time2Str(raw.Time, &tstr, 2)
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF MeasEpoch   (%4d): time=%s nsig=%d", raw.Len,
+			tstr, n1)))
+	}
+	if sb1len < 20 || sb2len < 12 {
+		Trace(2, "sbf measepoch sub-block length error: sb1=%d sb2=%d\n", sb1len, sb2len)
+		return -1
+	}
+	/* antenna selection option (-AUX1,-AUX2) */
+	if strings.Contains(raw.Opt, "-AUX1") {
+		antsel = 1
+	} else if strings.Contains(raw.Opt, "-AUX2") {
+		antsel = 2
+	}
+	/* output previous epoch if MeasExtra/EndOfMeas did not close it */
+	if sbf.pending > 0 && TimeDiff(raw.Time, sbf.tobs) != 0.0 {
+		ret = sbf_flushobs(raw)
+	}
+	raw.ObsBuf.n = 0
+
+	for i, p = 0, p+6; i < n1 && p+sb1len <= raw.Len; i++ {
+		ch = int(U1(raw.Buff[p:]))
+		sig = int(U1(raw.Buff[p+1:]) & 0x1F)
+		ant = int(U1(raw.Buff[p+1:]) >> 5)
+		svid = int(U1(raw.Buff[p+2:]))
+		codemsb = int(U1(raw.Buff[p+3:]) & 0x0F)
+		obsinfo = int(U1(raw.Buff[p+18:]))
+		n2 = int(U1(raw.Buff[p+19:]))
+		if sig == 31 {
+			sig = 32 + (obsinfo >> 3)
+		}
+		sat = sbf_svid2sat(svid)
+		sys = sbf_svid2prn(svid, &prn)
+		code = sbf_sig2code(sig, &sys2)
+
+		if ch < SBF_MAXCHAN {
+			sbf.chsat[ch] = sat
+		}
+		P1, L1, D1, freq1, fcn = 0.0, 0.0, 0.0, 0.0, 0
+		idx = -1
+		if sat == 0 || code == CODE_NONE || sys != sys2 {
+			Trace(3, "sbf measepoch signal error: svid=%d sig=%d\n", svid, sig)
+		} else if ant == antsel {
+			if sys == SYS_GLO {
+				fcn = (obsinfo >> 3) - 8
+				if raw.NavData.Glo_fcn[prn-1] == 0 {
+					raw.NavData.Glo_fcn[prn-1] = fcn + 8
+				}
+			}
+			freq1 = Code2Freq(sys, uint8(code), fcn)
+			idx = Code2Idx(sys, uint8(code))
+
+			mant = int(U4L(raw.Buff[p+4:]))
+			if codemsb != 0 || mant != 0 {
+				P1 = (float64(codemsb)*4294967296.0 + float64(uint32(mant))) * 0.001
+			}
+			if dop := I4L(raw.Buff[p+8:]); dop != -2147483648 {
+				D1 = float64(dop) * 0.0001
+			}
+			carrier = int32(I1(raw.Buff[p+14:]))*65536 + int32(U2L(raw.Buff[p+12:]))
+			if P1 != 0.0 && freq1 > 0.0 && !(I1(raw.Buff[p+14:]) == -128 && U2L(raw.Buff[p+12:]) == 0) {
+				L1 = P1*freq1/CLIGHT + float64(carrier)*0.001
+			}
+			cn0 = 0.0
+			if k = int(U1(raw.Buff[p+15:])); k != 255 {
+				cn0 = float64(k) * 0.25
+				if sig != 1 && sig != 2 {
+					cn0 += 10.0
+				}
+			}
+			lockt = float64(U2L(raw.Buff[p+16:]))
+			lli = 0
+			if obsinfo&4 != 0 {
+				lli |= LLI_HALFC
+			}
+			if idx >= 0 && idx < NFREQ+NEXOBS {
+				sbf_setobs(raw, sat, sig, code, idx, lli, P1, L1, D1, cn0, lockt)
+			}
+		}
+		/* type-2 sub-blocks of the same satellite */
+		for j, p = 0, p+sb1len; j < n2 && p+sb2len <= raw.Len; j, p = j+1, p+sb2len {
+			if P1 == 0.0 || freq1 == 0.0 {
+				continue
+			}
+			sig = int(U1(raw.Buff[p:]) & 0x1F)
+			ant = int(U1(raw.Buff[p:]) >> 5)
+			obsinfo = int(U1(raw.Buff[p+5:]))
+			if sig == 31 {
+				sig = 32 + (obsinfo >> 3)
+			}
+			if ant != antsel {
+				continue
+			}
+			if code = sbf_sig2code(sig, &sys2); code == CODE_NONE || sys2 != sys {
+				Trace(3, "sbf measepoch signal error: svid=%d sig=%d\n", svid, sig)
+				continue
+			}
+			freq2 = Code2Freq(sys, uint8(code), fcn)
+			if idx = Code2Idx(sys, uint8(code)); idx < 0 || idx >= NFREQ+NEXOBS || freq2 == 0.0 {
+				continue
+			}
+			P2, L2, D2 = 0.0, 0.0, 0.0
+
+			offmsb := int32(U1(raw.Buff[p+3:]))
+			codeoff = ((offmsb & 7) ^ 4 - 4) /* 3 bits signed */
+			if !(codeoff == -4 && U2L(raw.Buff[p+6:]) == 0) {
+				P2 = P1 + float64(codeoff*65536+int32(U2L(raw.Buff[p+6:])))*0.001
+			}
+			carrier = int32(I1(raw.Buff[p+4:]))*65536 + int32(U2L(raw.Buff[p+8:]))
+			if P2 != 0.0 && !(I1(raw.Buff[p+4:]) == -128 && U2L(raw.Buff[p+8:]) == 0) {
+				L2 = P2*freq2/CLIGHT + float64(carrier)*0.001
+			}
+			dopoff = ((offmsb >> 3) ^ 16 - 16) /* 5 bits signed */
+			if D1 != 0.0 && !(dopoff == -16 && U2L(raw.Buff[p+10:]) == 0) {
+				D2 = D1*freq2/freq1 + float64(dopoff*65536+int32(U2L(raw.Buff[p+10:])))*0.0001
+			}
+			cn0 = 0.0
+			if k = int(U1(raw.Buff[p+2:])); k != 255 {
+				cn0 = float64(k) * 0.25
+				if sig != 1 && sig != 2 {
+					cn0 += 10.0
+				}
+			}
+			lockt = float64(U1(raw.Buff[p+1:]))
+			lli = 0
+			if obsinfo&4 != 0 {
+				lli |= LLI_HALFC
+			}
+			sbf_setobs(raw, sat, sig, code, idx, lli, P2, L2, D2, cn0, lockt)
+		}
+	}
+	sbf.tobs = raw.Time
+	sbf.pending = 1
+
+	/* without MeasExtra output the epoch immediately */
+	if sbf.meas2 == 0 {
+		return sbf_flushobs(raw)
+	}
+	return ret
+}
+
+/* decode SBF MeasExtra: additional info such as observable variance ---------*/
+func decode_sbf_measextra(raw *Raw) int {
+	var (
+		sbf                                 = sbf_data(raw)
+		p                                   = 14
+		n, sblen, i, ch, sig, sat, sys      int
+		sys2, code, idx, lossc, ant, antsel int
+	)
+	if raw.Len < 20 {
+		Trace(2, "sbf measextra length error: len=%d\n", raw.Len)
+		return -1
+	}
+	n = int(U1(raw.Buff[p:]))
+	sblen = int(U1(raw.Buff[p+1:]))
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF MeasExtra   (%4d): nsig=%d", raw.Len, n)))
+	}
+	if sblen < 16 {
+		Trace(2, "sbf measextra sub-block length error: sb=%d\n", sblen)
+		return -1
+	}
+	if strings.Contains(raw.Opt, "-AUX1") {
+		antsel = 1
+	} else if strings.Contains(raw.Opt, "-AUX2") {
+		antsel = 2
+	}
+	sbf.meas2 = 1
+
+	for i, p = 0, p+6; i < n && p+sblen <= raw.Len; i, p = i+1, p+sblen {
+		ch = int(U1(raw.Buff[p:]))
+		sig = int(U1(raw.Buff[p+1:]) & 0x1F)
+		ant = int(U1(raw.Buff[p+1:]) >> 5)
+		lossc = int(U1(raw.Buff[p+12:]))
+		if sig == 31 && sblen > 15 {
+			sig = 32 + int(U1(raw.Buff[p+15:])>>3)
+		}
+		if ch >= SBF_MAXCHAN || ant != antsel {
+			continue
+		}
+		if sat = sbf.chsat[ch]; sat == 0 {
+			continue
+		}
+		sys = SatSys(sat, nil)
+		if code = sbf_sig2code(sig, &sys2); code == CODE_NONE || sys2 != sys {
+			continue
+		}
+		if idx = Code2Idx(sys, uint8(code)); idx < 0 || idx >= NFREQ+NEXOBS {
+			continue
+		}
+		/* cumulative loss of continuity counter changed: cycle slip */
+		if sbf.lossc[sat-1][idx] >= 0 && sbf.lossc[sat-1][idx] != lossc {
+			sbf.slip[sat-1][idx] = LLI_SLIP
+		}
+		sbf.lossc[sat-1][idx] = lossc
+	}
+	if sbf.pending > 0 && TimeDiff(raw.Time, sbf.tobs) == 0.0 {
+		return sbf_flushobs(raw)
+	}
+	return 0
+}
+
+/* decode SBF EndOfMeas: measurement epoch marker ----------------------------*/
+func decode_sbf_endofmeas(raw *Raw) int {
+	sbf := sbf_data(raw)
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF EndOfMeas   (%4d):", raw.Len)))
+	}
+	if sbf.pending > 0 && TimeDiff(raw.Time, sbf.tobs) == 0.0 {
+		return sbf_flushobs(raw)
+	}
+	return 0
+}
+
+/* check navigation page header of raw navigation blocks ---------------------*/
+func sbf_navhead(raw *Raw, name string, minlen int, sat *int) int {
+	svid := int(U1(raw.Buff[14:]))
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF %-12s(%4d): svid=%d", name, raw.Len, svid)))
+	}
+	if raw.Len < minlen {
+		Trace(2, "sbf %s length error: len=%d\n", name, raw.Len)
+		return -1
+	}
+	if U1(raw.Buff[15:]) == 0 { /* CRCPassed */
+		Trace(3, "sbf %s crc error: svid=%d\n", name, svid)
+		return 0
+	}
+	if *sat = sbf_svid2sat(svid); *sat == 0 {
+		Trace(2, "sbf %s svid error: svid=%d\n", name, svid)
+		return -1
+	}
+	return 1
+}
+
+/* update ephemeris of raw control struct ------------------------------------*/
+func sbf_update_eph(raw *Raw, eph *Eph, set int) int {
+	sat := eph.Sat
+
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if eph.Iode == raw.NavData.Ephs[sat-1+MAXSAT*set].Iode &&
+			TimeDiff(eph.Toe, raw.NavData.Ephs[sat-1+MAXSAT*set].Toe) == 0.0 &&
+			TimeDiff(eph.Toc, raw.NavData.Ephs[sat-1+MAXSAT*set].Toc) == 0.0 {
+			return 0 /* unchanged */
+		}
+	}
+	raw.NavData.Ephs[sat-1+MAXSAT*set] = *eph
+	raw.EphSat = sat
+	raw.EphSet = set
+	return 2
+}
+
+/* decode SBF GPSRawCA/QZSRawL1CA: GPS/QZSS CA navigation subframe -----------*/
+func decode_sbf_rawca(raw *Raw, name string) int {
+	var (
+		eph        Eph
+		ion, utc   [8]float64
+		buff       [30]uint8
+		i, id, sat int
+		p          = 20
+		ret        int
+	)
+	if ret = sbf_navhead(raw, name, 60, &sat); ret <= 0 {
+		return ret
+	}
+	for i = 0; i < 10; i, p = i+1, p+4 { /* 24 x 10 bits w/o parity */
+		SetBitU(buff[:], 24*i, 24, U4L(raw.Buff[p:])>>6)
+	}
+	id = int(GetBitU(buff[:], 43, 3))
+	if id < 1 || id > 5 {
+		Trace(2, "sbf %s subframe id error: sat=%d id=%d\n", name, sat, id)
+		return -1
+	}
+	copy(raw.SubFrm[sat-1][(id-1)*30:], buff[:])
+
+	if id == 3 {
+		if DecodeFrame(raw.SubFrm[sat-1][:], &eph, nil, nil, nil) == 0 {
+			return 0
+		}
+		if !strings.Contains(raw.Opt, "-EPHALL") {
+			if eph.Iode == raw.NavData.Ephs[sat-1].Iode &&
+				eph.Iodc == raw.NavData.Ephs[sat-1].Iodc &&
+				TimeDiff(eph.Toe, raw.NavData.Ephs[sat-1].Toe) == 0.0 {
+				return 0
+			}
+		}
+		eph.Sat = sat
+		raw.NavData.Ephs[sat-1] = eph
+		raw.EphSat = sat
+		raw.EphSet = 0
+		return 2
+	}
+	if id == 4 || id == 5 {
+		if DecodeFrame(raw.SubFrm[sat-1][:], nil, nil, ion[:], utc[:]) == 0 {
+			return 0
+		}
+		adj_utcweek(raw.Time, utc[:])
+		if SatSys(sat, nil) == SYS_QZS {
+			MatCpy(raw.NavData.Ion_qzs[:], ion[:], 8, 1)
+			MatCpy(raw.NavData.Utc_qzs[:], utc[:], 8, 1)
+		} else {
+			MatCpy(raw.NavData.Ion_gps[:], ion[:], 8, 1)
+			MatCpy(raw.NavData.Utc_gps[:], utc[:], 8, 1)
+		}
+		return 9
+	}
+	return 0
+}
+
+/* decode SBF GLORawCA: GLONASS CA navigation string -------------------------*/
+func decode_sbf_glorawca(raw *Raw) int {
+	var (
+		geph           GEph
+		utc            [8]float64
+		buff           [12]uint8
+		i, m, sat, prn int
+		p              = 20
+		ret            int
+	)
+	if ret = sbf_navhead(raw, "GLORawCA", 32, &sat); ret <= 0 {
+		return ret
+	}
+	SatSys(sat, &prn)
+	for i = 0; i < 3; i, p = i+1, p+4 {
+		SetBitU(buff[:], 32*i, 32, U4L(raw.Buff[p:]))
+	}
+	buff[10] &= 0xF8 /* 85 bits string */
+	buff[11] = 0
+
+	/* test hamming of GLONASS string */
+	if test_glostr(buff[:]) == 0 {
+		Trace(2, "sbf glorawca hamming error: sat=%2d\n", sat)
+		return -1
+	}
+	m = int(GetBitU(buff[:], 1, 4))
+	if m < 1 || 15 < m {
+		Trace(2, "sbf glorawca string no error: sat=%2d\n", sat)
+		return -1
+	}
+	copy(raw.SubFrm[sat-1][(m-1)*10:], buff[:10])
+
+	if m == 4 {
+		/* decode GLONASS ephemeris strings */
+		geph.Tof = raw.Time
+		if Decode_Glostr(raw.SubFrm[sat-1][:], &geph, nil) == 0 || geph.Sat != sat {
+			return 0
+		}
+		geph.Frq = int(U1(raw.Buff[18:])) - 8
+
+		if !strings.Contains(raw.Opt, "-EPHALL") {
+			if geph.Iode == raw.NavData.Geph[prn-1].Iode &&
+				TimeDiff(geph.Toe, raw.NavData.Geph[prn-1].Toe) == 0.0 {
+				return 0
+			}
+		}
+		raw.NavData.Geph[prn-1] = geph
+		raw.EphSat = sat
+		raw.EphSet = 0
+		return 2
+	} else if m == 5 {
+		if Decode_Glostr(raw.SubFrm[sat-1][:], nil, utc[:]) == 0 {
+			return 0
+		}
+		MatCpy(raw.NavData.Utc_glo[:], utc[:], 8, 1)
+		return 9
+	}
+	return 0
+}
+
+/* decode SBF GALRawINAV: Galileo I/NAV navigation page ----------------------*/
+func decode_sbf_galrawinav(raw *Raw) int {
+	var (
+		eph                                     Eph
+		ion                                     [4]float64
+		utc                                     [8]float64
+		buff                                    [32]uint8
+		crc_buff                                [26]uint8
+		i, j, part1, page1, part2, page2, ctype int
+		sat, ret                                int
+		p                                       = 20
+	)
+	if ret = sbf_navhead(raw, "GALRawINAV", 52, &sat); ret <= 0 {
+		return ret
+	}
+	if strings.Contains(raw.Opt, "-GALFNAV") {
+		return 0
+	}
+	for i = 0; i < 8; i, p = i+1, p+4 {
+		SetBitU(buff[:], 32*i, 32, U4L(raw.Buff[p:]))
+	}
+	part1 = int(GetBitU(buff[:], 0, 1))
+	page1 = int(GetBitU(buff[:], 1, 1))
+	part2 = int(GetBitU(buff[:], 114, 1))
+	page2 = int(GetBitU(buff[:], 115, 1))
+
+	if part1 != 0 || part2 != 1 {
+		Trace(3, "sbf galrawinav page even/odd error: sat=%d\n", sat)
+		return -1
+	}
+	if page1 == 1 || page2 == 1 {
+		return 0 /* alert page */
+	}
+	/* test crc (4(pad) + 114 + 82 bits) */
+	for i, j = 0, 4; i < 15; i, j = i+1, j+8 {
+		SetBitU(crc_buff[:], j, 8, GetBitU(buff[:], i*8, 8))
+	}
+	for i, j = 0, 118; i < 11; i, j = i+1, j+8 {
+		SetBitU(crc_buff[:], j, 8, GetBitU(buff[:], i*8+114, 8))
+	}
+	if Rtk_CRC24q(crc_buff[:], 25) != GetBitU(buff[:], 114+82, 24) {
+		Trace(2, "sbf galrawinav crc error: sat=%d\n", sat)
+		return -1
+	}
+	ctype = int(GetBitU(buff[:], 2, 6)) /* word type */
+	if ctype > 6 {
+		return 0
+	}
+	/* save 128 (112:even+16:odd) bits word */
+	for i, j = 0, 2; i < 14; i, j = i+1, j+8 {
+		raw.SubFrm[sat-1][ctype*16+i] = uint8(GetBitU(buff[:], j, 8))
+	}
+	for i, j = 14, 116; i < 16; i, j = i+1, j+8 {
+		raw.SubFrm[sat-1][ctype*16+i] = uint8(GetBitU(buff[:], j, 8))
+	}
+	if ctype != 5 {
+		return 0
+	}
+	if DecodeGalInav(raw.SubFrm[sat-1][:], &eph, ion[:], utc[:]) == 0 {
+		return 0
+	}
+	if eph.Sat != sat {
+		Trace(2, "sbf galrawinav satellite error: sat=%d %d\n", sat, eph.Sat)
+		return -1
+	}
+	eph.Code |= (1 << 0) /* data source: E1 */
+
+	adj_utcweek(raw.Time, utc[:])
+	MatCpy(raw.NavData.Ion_gal[:], ion[:], 4, 1)
+	MatCpy(raw.NavData.Utc_gal[:], utc[:], 8, 1)
+
+	return sbf_update_eph(raw, &eph, 0) /* 0:I/NAV */
+}
+
+/* decode SBF GALRawFNAV: Galileo F/NAV navigation page ----------------------*/
+func decode_sbf_galrawfnav(raw *Raw) int {
+	var (
+		eph              Eph
+		ion              [4]float64
+		utc              [8]float64
+		buff             [32]uint8
+		crc_buff         [28]uint8
+		i, j, ctype, sat int
+		ret              int
+		p                = 20
+		off              = 128 /* F/NAV pages stored after I/NAV words */
+	)
+	if ret = sbf_navhead(raw, "GALRawFNAV", 52, &sat); ret <= 0 {
+		return ret
+	}
+	if strings.Contains(raw.Opt, "-GALINAV") {
+		return 0
+	}
+	for i = 0; i < 8; i, p = i+1, p+4 {
+		SetBitU(buff[:], 32*i, 32, U4L(raw.Buff[p:]))
+	}
+	/* test crc (2(pad) + 214 bits) */
+	for i, j = 0, 2; i < 27; i, j = i+1, j+8 {
+		SetBitU(crc_buff[:], j, 8, GetBitU(buff[:], i*8, 8))
+	}
+	if Rtk_CRC24q(crc_buff[:], 27) != GetBitU(buff[:], 214, 24) {
+		Trace(2, "sbf galrawfnav crc error: sat=%d\n", sat)
+		return -1
+	}
+	ctype = int(GetBitU(buff[:], 0, 6)) /* page type */
+	if ctype < 1 || ctype > 4 {
+		return 0
+	}
+	copy(raw.SubFrm[sat-1][off+(ctype-1)*31:off+ctype*31], buff[:31])
+
+	if ctype != 4 {
+		return 0
+	}
+	if DecodeGalFnav(raw.SubFrm[sat-1][off:], &eph, ion[:], utc[:]) == 0 {
+		return 0
+	}
+	if eph.Sat != sat {
+		Trace(2, "sbf galrawfnav satellite error: sat=%d %d\n", sat, eph.Sat)
+		return -1
+	}
+	eph.Code |= (1 << 1) /* data source: E5a */
+
+	adj_utcweek(raw.Time, utc[:])
+	MatCpy(raw.NavData.Ion_gal[:], ion[:], 4, 1)
+	MatCpy(raw.NavData.Utc_gal[:], utc[:], 8, 1)
+
+	return sbf_update_eph(raw, &eph, 1) /* 1:F/NAV */
+}
+
+/* decode SBF BDSRaw: BeiDou D1/D2 navigation subframe -----------------------*/
+func decode_sbf_bdsraw(raw *Raw) int {
+	var (
+		eph                  Eph
+		ion, utc             [8]float64
+		buff                 [38]uint8
+		i, id, pgn, prn, sat int
+		ret                  int
+		p                    = 20
+	)
+	if ret = sbf_navhead(raw, "BDSRaw", 60, &sat); ret <= 0 {
+		return ret
+	}
+	for i = 0; i < 10; i, p = i+1, p+4 {
+		SetBitU(buff[:], 30*i, 30, U4L(raw.Buff[p:]))
+	}
+	id = int(GetBitU(buff[:], 15, 3)) /* subframe ID */
+	if id < 1 || 5 < id {
+		Trace(2, "sbf bdsraw subframe id error: sat=%2d\n", sat)
+		return -1
+	}
+	SatSys(sat, &prn)
+
+	if prn >= 6 && prn <= 58 { /* IGSO/MEO */
+		copy(raw.SubFrm[sat-1][(id-1)*38:], buff[:38])
+
+		if id == 3 {
+			if DecodeBDSD1(raw.SubFrm[sat-1][:], &eph, nil, nil) == 0 {
+				return 0
+			}
+		} else if id == 5 {
+			if DecodeBDSD1(raw.SubFrm[sat-1][:], nil, ion[:], utc[:]) == 0 {
+				return 0
+			}
+			MatCpy(raw.NavData.Ion_cmp[:], ion[:], 8, 1)
+			MatCpy(raw.NavData.Utc_cmp[:], utc[:], 8, 1)
+			return 9
+		} else {
+			return 0
+		}
+	} else { /* GEO */
+		pgn = int(GetBitU(buff[:], 42, 4)) /* page numuber */
+
+		if id == 1 && pgn >= 1 && pgn <= 10 {
+			copy(raw.SubFrm[sat-1][(pgn-1)*38:], buff[:38])
+			if pgn != 10 {
+				return 0
+			}
+			if DecodeBDSD2(raw.SubFrm[sat-1][:], &eph, nil) == 0 {
+				return 0
+			}
+		} else if id == 5 && pgn == 102 {
+			copy(raw.SubFrm[sat-1][10*38:], buff[:38])
+			if DecodeBDSD2(raw.SubFrm[sat-1][:], nil, utc[:]) == 0 {
+				return 0
+			}
+			MatCpy(raw.NavData.Utc_cmp[:], utc[:], 8, 1)
+			return 9
+		} else {
+			return 0
+		}
+	}
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if TimeDiff(eph.Toe, raw.NavData.Ephs[sat-1].Toe) == 0.0 {
+			return 0
+		}
+	}
+	eph.Sat = sat
+	raw.NavData.Ephs[sat-1] = eph
+	raw.EphSat = sat
+	raw.EphSet = 0
+	return 2
+}
+
+/* decode SBF GEORawL1: SBAS L1 navigation message ---------------------------*/
+func decode_sbf_georawl1(raw *Raw) int {
+	var (
+		words       [10]uint32
+		i, prn, sat int
+		ret         int
+		p           = 20
+	)
+	if ret = sbf_navhead(raw, "GEORawL1", 52, &sat); ret <= 0 {
+		return ret
+	}
+	if SatSys(sat, &prn) != SYS_SBS {
+		return 0
+	}
+	for i = 0; i < 8; i, p = i+1, p+4 {
+		words[i] = U4L(raw.Buff[p:])
+	}
+	words[7] >>= 6 /* 250 bits message */
+
+	if SbsDecodeMsg(TimeAdd(raw.Time, -1.0), prn, words[:], &raw.Sbsmsg) == 0 {
+		Trace(2, "sbf georawl1 crc error: prn=%d\n", prn)
+		return -1
+	}
+	return 3
+}
+
+/* decode SBF GPSNav/QZSNav: GPS/QZSS ephemeris and clock (ref [3] 4.2.4) ----*/
+func decode_sbf_gpsnav(raw *Raw, sys int, name string) int {
+	var (
+		eph             Eph
+		prn, sat, iode3 int
+		p               = 14
+		week            int
+	)
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF %-12s(%4d): prn=%d", name, raw.Len, U1(raw.Buff[p:]))))
+	}
+	if raw.Len < 140 {
+		Trace(2, "sbf %s length error: len=%d\n", name, raw.Len)
+		return -1
+	}
+	prn = int(U1(raw.Buff[p:]))
+	if sys == SYS_QZS && prn < MINPRNQZS {
+		prn += MINPRNQZS - 181 /* svid 181-190 */
+	}
+	if sat = SatNo(sys, prn); sat == 0 {
+		Trace(2, "sbf %s prn error: prn=%d\n", name, prn)
+		return -1
+	}
+	eph.Code = int(U1(raw.Buff[p+4:]))
+	eph.Sva = int(U1(raw.Buff[p+5:]))
+	eph.Svh = int(U1(raw.Buff[p+6:]))
+	eph.Flag = int(U1(raw.Buff[p+7:]))
+	eph.Iodc = int(U2L(raw.Buff[p+8:]))
+	eph.Iode = int(U1(raw.Buff[p+10:]))
+	iode3 = int(U1(raw.Buff[p+11:]))
+	if U1(raw.Buff[p+12:]) != 0 {
+		eph.Fit = 0.0
+	} else {
+		eph.Fit = 4.0 /* 0:4hr,1:>4hr */
+	}
+	eph.Tgd[0] = float64(R4L(raw.Buff[p+14:]))
+	toc := float64(U4L(raw.Buff[p+18:]))
+	eph.F2 = float64(R4L(raw.Buff[p+22:]))
+	eph.F1 = float64(R4L(raw.Buff[p+26:]))
+	eph.F0 = float64(R4L(raw.Buff[p+30:]))
+	eph.Crs = float64(R4L(raw.Buff[p+34:]))
+	eph.Deln = float64(R4L(raw.Buff[p+38:])) * SC2RAD
+	eph.M0 = R8L(raw.Buff[p+42:]) * SC2RAD
+	eph.Cuc = float64(R4L(raw.Buff[p+50:]))
+	eph.E = R8L(raw.Buff[p+54:])
+	eph.Cus = float64(R4L(raw.Buff[p+62:]))
+	eph.A = SQR(R8L(raw.Buff[p+66:]))
+	eph.Toes = float64(U4L(raw.Buff[p+74:]))
+	eph.Cic = float64(R4L(raw.Buff[p+78:]))
+	eph.OMG0 = R8L(raw.Buff[p+82:]) * SC2RAD
+	eph.Cis = float64(R4L(raw.Buff[p+90:]))
+	eph.I0 = R8L(raw.Buff[p+94:]) * SC2RAD
+	eph.Crc = float64(R4L(raw.Buff[p+102:]))
+	eph.Omg = R8L(raw.Buff[p+106:]) * SC2RAD
+	eph.OMGd = float64(R4L(raw.Buff[p+114:])) * SC2RAD
+	eph.Idot = float64(R4L(raw.Buff[p+118:])) * SC2RAD
+
+	if eph.Iode != iode3 || eph.Iode != (eph.Iodc&0xFF) {
+		Trace(2, "sbf %s iode error: prn=%d iode=%d %d iodc=%d\n", name, prn, eph.Iode,
+			iode3, eph.Iodc)
+		return -1
+	}
+	if raw.Time.Time == 0 {
+		return 0
+	}
+	eph.Sat = sat
+	eph.Toe = adjweek(raw.Time, eph.Toes)
+	eph.Toc = adjweek(raw.Time, toc)
+	eph.Ttr = raw.Time
+	Time2GpsT(eph.Toe, &week)
+	eph.Week = week
+
+	return sbf_update_eph(raw, &eph, 0)
+}
+
+/* decode SBF GALNav: Galileo ephemeris, clock, health and BGD ---------------*/
+func decode_sbf_galnav(raw *Raw) int {
+	var (
+		eph                             Eph
+		prn, sat, source, set, hs, week int
+		toc                             float64
+		p                               = 14
+	)
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF GALNav      (%4d): svid=%d", raw.Len, U1(raw.Buff[p:]))))
+	}
+	if raw.Len < 149 {
+		Trace(2, "sbf galnav length error: len=%d\n", raw.Len)
+		return -1
+	}
+	prn = int(U1(raw.Buff[p:])) - 70
+	if sat = SatNo(SYS_GAL, prn); sat == 0 {
+		Trace(2, "sbf galnav svid error: svid=%d\n", prn+70)
+		return -1
+	}
+	source = int(U1(raw.Buff[p+1:]))
+	if source == 16 { /* 2:I/NAV,16:F/NAV */
+		set = 1
+	}
+	if (strings.Contains(raw.Opt, "-GALINAV") && set == 1) ||
+		(strings.Contains(raw.Opt, "-GALFNAV") && set == 0) {
+		return 0
+	}
+	eph.A = SQR(R8L(raw.Buff[p+2:]))
+	eph.M0 = R8L(raw.Buff[p+10:]) * SC2RAD
+	eph.E = R8L(raw.Buff[p+18:])
+	eph.I0 = R8L(raw.Buff[p+26:]) * SC2RAD
+	eph.Omg = R8L(raw.Buff[p+34:]) * SC2RAD
+	eph.OMG0 = R8L(raw.Buff[p+42:]) * SC2RAD
+	eph.OMGd = float64(R4L(raw.Buff[p+50:])) * SC2RAD
+	eph.Idot = float64(R4L(raw.Buff[p+54:])) * SC2RAD
+	eph.Deln = float64(R4L(raw.Buff[p+58:])) * SC2RAD
+	eph.Cuc = float64(R4L(raw.Buff[p+62:]))
+	eph.Cus = float64(R4L(raw.Buff[p+66:]))
+	eph.Crc = float64(R4L(raw.Buff[p+70:]))
+	eph.Crs = float64(R4L(raw.Buff[p+74:]))
+	eph.Cic = float64(R4L(raw.Buff[p+78:]))
+	eph.Cis = float64(R4L(raw.Buff[p+82:]))
+	eph.Toes = float64(U4L(raw.Buff[p+86:]))
+	toc = float64(U4L(raw.Buff[p+90:]))
+	eph.F2 = float64(R4L(raw.Buff[p+94:]))
+	eph.F1 = float64(R4L(raw.Buff[p+98:]))
+	eph.F0 = R8L(raw.Buff[p+102:])
+	eph.Iode = int(U2L(raw.Buff[p+114:]))
+	eph.Iodc = eph.Iode
+	hs = int(U2L(raw.Buff[p+116:]))
+	if set == 0 {
+		eph.Sva = int(U1(raw.Buff[p+121:])) /* SISA E1,E5b */
+	} else {
+		eph.Sva = int(U1(raw.Buff[p+120:])) /* SISA E1,E5a */
+	}
+	eph.Tgd[0] = float64(R4L(raw.Buff[p+123:])) /* BGD E1-E5a */
+	eph.Tgd[1] = float64(R4L(raw.Buff[p+127:])) /* BGD E1-E5b */
+	for i := 0; i < 2; i++ {
+		if math.IsNaN(eph.Tgd[i]) {
+			eph.Tgd[i] = 0.0 /* do-not-use value */
+		}
+	}
+	/* health: E1B DVS/HS, E5a DVS/HS and E5b DVS/HS (rinex 3.03) */
+	eph.Svh = ((hs>>3)&1)<<0 | ((hs>>1)&3)<<1 | ((hs>>11)&1)<<3 | ((hs>>9)&3)<<4 |
+		((hs>>7)&1)<<6 | ((hs>>5)&3)<<7
+	if set == 0 {
+		eph.Code = (1 << 0) + (1 << 2) + (1 << 9)
+	} else {
+		eph.Code = (1 << 1) + (1 << 8)
+	}
+	if raw.Time.Time == 0 {
+		return 0
+	}
+	eph.Sat = sat
+	eph.Toe = adjweek(raw.Time, eph.Toes)
+	eph.Toc = adjweek(raw.Time, toc)
+	eph.Ttr = raw.Time
+	Time2GpsT(eph.Toe, &week)
+	eph.Week = week /* gps-week = gal-week */
+
+	return sbf_update_eph(raw, &eph, set)
+}
+
+/* decode SBF BDSNav: BeiDou ephemeris and clock -----------------------------*/
+func decode_sbf_bdsnav(raw *Raw) int {
+	var (
+		eph            Eph
+		prn, sat, week int
+		toc, tow       float64
+		p              = 14
+	)
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF BDSNav      (%4d): prn=%d", raw.Len, U1(raw.Buff[p:]))))
+	}
+	if raw.Len < 140 {
+		Trace(2, "sbf bdsnav length error: len=%d\n", raw.Len)
+		return -1
+	}
+	prn = int(U1(raw.Buff[p:]))
+	if sat = SatNo(SYS_CMP, prn); sat == 0 {
+		Trace(2, "sbf bdsnav prn error: prn=%d\n", prn)
+		return -1
+	}
+	eph.Sva = int(U1(raw.Buff[p+4:]))
+	eph.Svh = int(U1(raw.Buff[p+5:]) & 1)
+	eph.Iodc = int(U1(raw.Buff[p+6:]))
+	eph.Iode = int(U1(raw.Buff[p+7:]))
+	eph.Tgd[0] = float64(R4L(raw.Buff[p+10:]))
+	eph.Tgd[1] = float64(R4L(raw.Buff[p+14:]))
+	if math.IsNaN(eph.Tgd[1]) {
+		eph.Tgd[1] = 0.0
+	}
+	toc = float64(U4L(raw.Buff[p+18:]))
+	eph.F2 = float64(R4L(raw.Buff[p+22:]))
+	eph.F1 = float64(R4L(raw.Buff[p+26:]))
+	eph.F0 = float64(R4L(raw.Buff[p+30:]))
+	eph.Crs = float64(R4L(raw.Buff[p+34:]))
+	eph.Deln = float64(R4L(raw.Buff[p+38:])) * SC2RAD
+	eph.M0 = R8L(raw.Buff[p+42:]) * SC2RAD
+	eph.Cuc = float64(R4L(raw.Buff[p+50:]))
+	eph.E = R8L(raw.Buff[p+54:])
+	eph.Cus = float64(R4L(raw.Buff[p+62:]))
+	eph.A = SQR(R8L(raw.Buff[p+66:]))
+	eph.Toes = float64(U4L(raw.Buff[p+74:]))
+	eph.Cic = float64(R4L(raw.Buff[p+78:]))
+	eph.OMG0 = R8L(raw.Buff[p+82:]) * SC2RAD
+	eph.Cis = float64(R4L(raw.Buff[p+90:]))
+	eph.I0 = R8L(raw.Buff[p+94:]) * SC2RAD
+	eph.Crc = float64(R4L(raw.Buff[p+102:]))
+	eph.Omg = R8L(raw.Buff[p+106:]) * SC2RAD
+	eph.OMGd = float64(R4L(raw.Buff[p+114:])) * SC2RAD
+	eph.Idot = float64(R4L(raw.Buff[p+118:])) * SC2RAD
+
+	if raw.Time.Time == 0 {
+		return 0
+	}
+	/* adjust week in BDT time frame */
+	tow = Time2BDT(GpsT2BDT(raw.Time), &week)
+	if eph.Toes < tow-302400.0 {
+		week++
+	} else if eph.Toes > tow+302400.0 {
+		week--
+	}
+	eph.Sat = sat
+	eph.Week = week
+	eph.Toe = BDT2GpsT(BDT2Time(week, eph.Toes))
+	eph.Toc = BDT2GpsT(BDT2Time(week, toc))
+	eph.Ttr = raw.Time
+	if prn <= 5 || prn >= 59 {
+		eph.Flag = 2 /* nav type: GEO */
+	} else {
+		eph.Flag = 1 /* nav type: IGSO/MEO */
+	}
+	return sbf_update_eph(raw, &eph, 0)
+}
+
+/* decode SBF GLONav: GLONASS ephemeris and clock ----------------------------*/
+func decode_sbf_glonav(raw *Raw) int {
+	var (
+		geph           GEph
+		prn, sat, week int
+		p              = 14
+		i              int
+	)
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF GLONav      (%4d): svid=%d", raw.Len, U1(raw.Buff[p:]))))
+	}
+	if raw.Len < 96 {
+		Trace(2, "sbf glonav length error: len=%d\n", raw.Len)
+		return -1
+	}
+	if sat = sbf_svid2sat(int(U1(raw.Buff[p:]))); sat == 0 ||
+		SatSys(sat, &prn) != SYS_GLO {
+		Trace(2, "sbf glonav svid error: svid=%d\n", U1(raw.Buff[p:]))
+		return -1
+	}
+	geph.Frq = int(U1(raw.Buff[p+1:])) - 8
+	for i = 0; i < 3; i++ {
+		geph.Pos[i] = R8L(raw.Buff[p+2+i*8:]) * 1e3
+		geph.Vel[i] = float64(R4L(raw.Buff[p+26+i*4:])) * 1e3
+		geph.Acc[i] = float64(R4L(raw.Buff[p+38+i*4:])) * 1e3
+	}
+	geph.Gamn = float64(R4L(raw.Buff[p+50:]))
+	geph.Taun = float64(R4L(raw.Buff[p+54:]))
+	geph.DTaun = float64(R4L(raw.Buff[p+58:]))
+	week = int(U2L(raw.Buff[p+66:]))
+	geph.Toe = GpsT2Time(week, float64(U4L(raw.Buff[p+62:])))
+	geph.Age = int(U1(raw.Buff[p+70:]))                 /* E */
+	geph.Svh = int(U1(raw.Buff[p+71:])>>2) & 1          /* B:MSB */
+	geph.Iode = (int(U2L(raw.Buff[p+72:])) / 15) & 0x7F /* tb (min) */
+	geph.Tof = raw.Time
+	if raw.Time.Time == 0 {
+		geph.Tof = geph.Toe
+	}
+	geph.Sat = sat
+
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if math.Abs(TimeDiff(geph.Toe, raw.NavData.Geph[prn-1].Toe)) < 1.0 &&
+			geph.Svh == raw.NavData.Geph[prn-1].Svh {
+			return 0 /* unchanged */
+		}
+	}
+	if raw.NavData.Glo_fcn[prn-1] == 0 {
+		raw.NavData.Glo_fcn[prn-1] = geph.Frq + 8
+	}
+	raw.NavData.Geph[prn-1] = geph
+	raw.EphSat = sat
+	raw.EphSet = 0
+	return 2
+}
+
+/* decode SBF GPSIon/BDSIon: klobuchar ionosphere parameters -----------------*/
+func decode_sbf_ion(raw *Raw, ion []float64, off int, name string) int {
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF %-12s(%4d):", name, raw.Len)))
+	}
+	if raw.Len < off+32 {
+		Trace(2, "sbf %s length error: len=%d\n", name, raw.Len)
+		return -1
+	}
+	for i := 0; i < 8; i++ {
+		ion[i] = float64(R4L(raw.Buff[off+i*4:]))
+	}
+	return 9
+}
+
+/* decode SBF GPSUtc/GALUtc/BDSUtc: utc parameters ---------------------------*/
+func decode_sbf_utc(raw *Raw, utc []float64, off int, tot int, name string) int {
+	var week int
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF %-12s(%4d):", name, raw.Len)))
+	}
+	if raw.Len < off+21 {
+		Trace(2, "sbf %s length error: len=%d\n", name, raw.Len)
+		return -1
+	}
+	utc[1] = float64(R4L(raw.Buff[off:])) /* A1 */
+	utc[0] = R8L(raw.Buff[off+4:])        /* A0 */
+	if tot > 0 {
+		utc[2] = float64(U4L(raw.Buff[off+12:])) /* tot */
+		utc[3] = float64(U1(raw.Buff[off+16:]))  /* WNt */
+		utc[4] = float64(I1(raw.Buff[off+17:]))  /* dt_LS */
+		utc[5] = float64(U1(raw.Buff[off+18:]))  /* WN_LSF */
+		utc[6] = float64(U1(raw.Buff[off+19:]))  /* DN */
+		utc[7] = float64(I1(raw.Buff[off+20:]))  /* dt_LSF */
+		adj_utcweek(raw.Time, utc)
+	} else { /* BDT-UTC without tot/WNt */
+		utc[4] = float64(I1(raw.Buff[off+12:]))
+		utc[5] = float64(U1(raw.Buff[off+13:]))
+		utc[6] = float64(U1(raw.Buff[off+14:]))
+		utc[7] = float64(I1(raw.Buff[off+15:]))
+		if raw.Time.Time != 0 {
+			Time2BDT(GpsT2BDT(raw.Time), &week)
+			utc[3] = float64(week)
+		}
+	}
+	return 9
+}
+
+/* decode SBF GALIon: NeQuick ionosphere parameters --------------------------*/
+func decode_sbf_galion(raw *Raw) int {
+	p := 16
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF GALIon      (%4d):", raw.Len)))
+	}
+	if raw.Len < 29 {
+		Trace(2, "sbf galion length error: len=%d\n", raw.Len)
+		return -1
+	}
+	for i := 0; i < 3; i++ {
+		raw.NavData.Ion_gal[i] = float64(R4L(raw.Buff[p+i*4:]))
+	}
+	raw.NavData.Ion_gal[3] = float64(U1(raw.Buff[p+12:])) /* storm flags */
+	return 9
+}
+
+/* decode SBF ReceiverSetup: receiver and antenna information ----------------*/
+func decode_sbf_rcvsetup(raw *Raw) int {
+	p := 14
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF ReceiverSetup(%4d):", raw.Len)))
+	}
+	if raw.Len < 268 {
+		Trace(2, "sbf receiversetup length error: len=%d\n", raw.Len)
+		return -1
+	}
+	sbf_str := func(off, n int) string {
+		return strings.TrimSpace(strings.TrimRight(string(raw.Buff[p+off:p+off+n]), "\x00"))
+	}
+	raw.StaData.Name = sbf_str(2, 60)
+	raw.StaData.Marker = sbf_str(62, 20)
+	raw.StaData.RecSN = sbf_str(142, 20)
+	raw.StaData.Type = sbf_str(162, 20)
+	raw.StaData.RecVer = sbf_str(182, 20)
+	raw.StaData.AntSno = sbf_str(202, 20)
+	raw.StaData.AntDes = sbf_str(222, 20)
+	raw.StaData.DelType = 0 /* enu */
+	raw.StaData.Del[2] = float64(R4L(raw.Buff[p+242:]))
+	raw.StaData.Del[0] = float64(R4L(raw.Buff[p+246:]))
+	raw.StaData.Del[1] = float64(R4L(raw.Buff[p+250:]))
+	return 5
+}
+
+/* decode SBF block ----------------------------------------------------------*/
+func decode_sbf(raw *Raw) int {
+	var (
+		tow      uint32
+		week, id int
+	)
+	id = int(U2L(raw.Buff[4:]) & 0x1FFF)
+
+	Trace(3, "decode_sbf: id=%4d len=%d\n", id, raw.Len)
+
+	/* check crc-16 (ccitt) */
+	if Rtk_CRC16(raw.Buff[4:], raw.Len-4) != U2L(raw.Buff[2:]) {
+		Trace(2, "sbf crc error: id=%4d len=%d\n", id, raw.Len)
+		return -1
+	}
+	tow = U4L(raw.Buff[8:])
+	week = int(U2L(raw.Buff[12:]))
+	if tow != 4294967295 && week != 65535 {
+		raw.Time = GpsT2Time(week, float64(tow)*0.001)
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("SBF %4d (%4d):", id, raw.Len)))
+	}
+	switch id {
+	case ID_SBF_MEASEPOCH:
+		return decode_sbf_measepoch(raw)
+	case ID_SBF_MEASEXTRA:
+		return decode_sbf_measextra(raw)
+	case ID_SBF_ENDOFMEAS:
+		return decode_sbf_endofmeas(raw)
+	case ID_SBF_GPSRAWCA:
+		return decode_sbf_rawca(raw, "GPSRawCA")
+	case ID_SBF_QZSRAWL1CA:
+		return decode_sbf_rawca(raw, "QZSRawL1CA")
+	case ID_SBF_GLORAWCA:
+		return decode_sbf_glorawca(raw)
+	case ID_SBF_GALRAWINAV:
+		return decode_sbf_galrawinav(raw)
+	case ID_SBF_GALRAWFNAV:
+		return decode_sbf_galrawfnav(raw)
+	case ID_SBF_BDSRAW:
+		return decode_sbf_bdsraw(raw)
+	case ID_SBF_GEORAWL1:
+		return decode_sbf_georawl1(raw)
+	case ID_SBF_GPSNAV:
+		return decode_sbf_gpsnav(raw, SYS_GPS, "GPSNav")
+	case ID_SBF_QZSNAV:
+		return decode_sbf_gpsnav(raw, SYS_QZS, "QZSNav")
+	case ID_SBF_GLONAV:
+		return decode_sbf_glonav(raw)
+	case ID_SBF_GALNAV:
+		return decode_sbf_galnav(raw)
+	case ID_SBF_BDSNAV:
+		return decode_sbf_bdsnav(raw)
+	case ID_SBF_GPSION:
+		return decode_sbf_ion(raw, raw.NavData.Ion_gps[:], 15, "GPSIon")
+	case ID_SBF_GPSUTC:
+		return decode_sbf_utc(raw, raw.NavData.Utc_gps[:], 15, 1, "GPSUtc")
+	case ID_SBF_GALION:
+		return decode_sbf_galion(raw)
+	case ID_SBF_GALUTC:
+		return decode_sbf_utc(raw, raw.NavData.Utc_gal[:], 16, 1, "GALUtc")
+	case ID_SBF_BDSION:
+		return decode_sbf_ion(raw, raw.NavData.Ion_cmp[:], 16, "BDSIon")
+	case ID_SBF_BDSUTC:
+		return decode_sbf_utc(raw, raw.NavData.Utc_cmp[:], 16, 0, "BDSUtc")
+	case ID_SBF_RECEIVERSETUP:
+		return decode_sbf_rcvsetup(raw)
+	}
+	return 0
+}
+
+/* sync header ---------------------------------------------------------------*/
+func sync_sbf(buff []uint8, data uint8) int {
+	buff[0] = buff[1]
+	buff[1] = data
+	if buff[0] == SBFSYNC1 && buff[1] == SBFSYNC2 {
+		return 1
+	}
+	return 0
+}
+
+/* input Septentrio SBF raw data from stream -----------------------------------
+* fetch next Septentrio SBF raw data and input a mesasge from stream
+* args   : raw *Raw       IO  receiver raw data control struct
+*          uint8 data     I   stream data (1 byte)
+* return : status (-1: error message, 0: no message, 1: input observation data,
+*                  2: input ephemeris, 3: input sbas message,
+*                  5: input station pos/ant parameters,
+*                  9: input ion/utc parameter)
+*
+* notes  : to specify input options, set raw.Opt to the following option
+*          strings separated by spaces.
+*
+*          -EPHALL    : input all ephemerides
+*          -AUX1      : select measurements of antenna AUX1 (default: main)
+*          -AUX2      : select measurements of antenna AUX2 (default: main)
+*          -GALINAV   : select I/NAV for Galileo ephemeris (default: all)
+*          -GALFNAV   : select F/NAV for Galileo ephemeris (default: all)
+*          -GLxx,-RLxx,...: select signal by code priority (see GetCodePri())
+*
+*          If MeasExtra blocks are logged, observation data of an epoch are
+*          output after the MeasExtra (or EndOfMeas) block of the epoch and
+*          the cumulative loss-of-continuity counters are used to set LLI.
+*-----------------------------------------------------------------------------*/
+func input_sbf(raw *Raw, data uint8) int {
+	Trace(5, "input_sbf: data=%02x\n", data)
+
+	/* synchronize frame */
+	if raw.NumByte == 0 {
+		if sync_sbf(raw.Buff[:], data) > 0 {
+			raw.NumByte = 2
+		}
+		return 0
+	}
+	raw.Buff[raw.NumByte] = data
+	raw.NumByte++
+
+	if raw.NumByte == SBFHLEN {
+		if raw.Len = int(U2L(raw.Buff[6:])); raw.Len < SBFHLEN || raw.Len > MAXRAWLEN ||
+			raw.Len%4 != 0 {
+			Trace(2, "sbf length error: len=%d\n", raw.Len)
+			raw.NumByte = 0
+			return -1
+		}
+	}
+	if raw.NumByte < SBFHLEN || raw.NumByte < raw.Len {
+		return 0
+	}
+	raw.NumByte = 0
+
+	/* decode SBF block */
+	return decode_sbf(raw)
+}
+
+/* input Septentrio SBF raw data from file -------------------------------------
+* fetch next Septentrio SBF raw data and input a message from file
+* args   : raw_t  *raw      IO  receiver raw data control struct
+*          FILE   *fp       I   file pointer
+* return : status(-2: end of file, -1...9: same as above)
+*-----------------------------------------------------------------------------*/
+func input_sbff(raw *Raw, fp *os.File) int {
+	var c [1]byte
+
+	Trace(4, "input_sbff:\n")
+
+	/* synchronize frame */
+	if raw.NumByte == 0 {
+		for i := 0; ; i++ {
+			if _, err := fp.Read(c[:]); err == io.EOF {
+				return -2
+			}
+			if sync_sbf(raw.Buff[:], c[0]) > 0 {
+				break
+			}
+			if i >= 4096 {
+				return 0
+			}
+		}
+	}
+	if n, _ := io.ReadFull(fp, raw.Buff[2:SBFHLEN]); n < SBFHLEN-2 {
+		return -2
+	}
+	raw.NumByte = SBFHLEN
+
+	if raw.Len = int(U2L(raw.Buff[6:])); raw.Len < SBFHLEN || raw.Len > MAXRAWLEN ||
+		raw.Len%4 != 0 {
+		Trace(2, "sbf length error: len=%d\n", raw.Len)
+		raw.NumByte = 0
+		return -1
+	}
+	if n, _ := io.ReadFull(fp, raw.Buff[SBFHLEN:raw.Len]); n < raw.Len-SBFHLEN {
+		return -2
+	}
+	raw.NumByte = 0
+
+	/* decode SBF block */
+	return decode_sbf(raw)
+}
@@ -54,6 +54,19 @@
 *                            use integer types in stdint.h
 *                            suppress warnings
 *		    2022/05/31 1.0  rewrite solution.c with golang by fxb
+*           2026/10/16  1.1  support output/input of moving-base attitude
+*                            (out-outatt) and NMEA HDT/PASHR sentences
+*                            fix bug on checksum of NMEA sentences after
+*                            the first one in the buffer
+*           2026/10/16  1.2  add NMEA GST/VTG/ZDA/GNS/GBS output selectable by
+*                            solopt.nmeasen (out-nmeasen)
+*                            support reading NMEA GST/VTG/GNS/GBS/HDT/PASHR
+*                            fix bug on decoding NMEA sentence type
+*                            fix bug on checksum of NMEA GSA/GSV sentences
+*                            fix bug on adding solution to cyclic buffer
+*                            add GST to default NMEA sentences
+*           2026/10/16  1.3  decode velocity and attitude fields by header or
+*                            options instead of number of fields
 *-----------------------------------------------------------------------------*/
 
 package gnssgo
@@ -71,10 +84,25 @@ import (
 )
 
 const (
-	NMEA_TID = "GN" /* NMEA talker ID for RMC and GGA sentences */
-	MAXFIELD = 64   /* max number of fields in a record */
-	MAXNMEA  = 256  /* max length of nmea sentence */
-	KNOT2M   = 0.514444444 /* m/knot */)
+	NMEA_TID  = "GN"        /* NMEA talker ID for RMC and GGA sentences */
+	NMEA_TIDH = "GP"        /* NMEA talker ID for HDT sentence */
+	MAXFIELD  = 64          /* max number of fields in a record */
+	MAXNMEA   = 256         /* max length of nmea sentence */
+	KNOT2M    = 0.514444444 /* m/knot */
+
+	NMEA_RMC   = 0x001 /* NMEA sentence: RMC */
+	NMEA_GGA   = 0x002 /* NMEA sentence: GGA */
+	NMEA_GSA   = 0x004 /* NMEA sentence: GSA */
+	NMEA_GSV   = 0x008 /* NMEA sentence: GSV */
+	NMEA_GST   = 0x010 /* NMEA sentence: GST */
+	NMEA_VTG   = 0x020 /* NMEA sentence: VTG */
+	NMEA_ZDA   = 0x040 /* NMEA sentence: ZDA */
+	NMEA_GNS   = 0x080 /* NMEA sentence: GNS */
+	NMEA_GBS   = 0x100 /* NMEA sentence: GBS */
+	NMEA_HDT   = 0x200 /* NMEA sentence: HDT */
+	NMEA_PASHR = 0x400 /* NMEA sentence: PASHR */
+	NMEA_DEF   = NMEA_RMC | NMEA_GGA | NMEA_GSA | NMEA_GSV | NMEA_GST | NMEA_HDT | NMEA_PASHR
+	/* default NMEA sentences (solopt.nmeasen=0) */)
 
 var (
 	nmea_sys []int = []int{ /* NMEA systems */
@@ -390,6 +418,385 @@ func (sol *Sol) DecodeNmeaGga(val []string, n int) int {
 	return 1
 }
 
+/* decode NMEA GNS (GNSS Fix Data) sentence ----------------------------------*/
+func (sol *Sol) DecodeNmeaGns(val []string, n int) int {
+	var (
+		tod, lat, lon, hdop, alt, msl, age float64
+		pos                                [3]float64
+		ns, ew, mode                       string = "N", "E", "N"
+		i, nrcv                            int
+	)
+	Trace(4, "decode_nmeagns: n=%d\n", n)
+
+	for i = 0; i < n; i++ {
+		switch i {
+		case 0:
+			tod, _ = strconv.ParseFloat(val[i], 64) /* UTC of position (hhmmss) */
+		case 1:
+			lat, _ = strconv.ParseFloat(val[i], 64) /* Latitude (ddmm.mmm) */
+		case 2:
+			ns = val[i] /* N=north,S=south */
+		case 3:
+			lon, _ = strconv.ParseFloat(val[i], 64) /* Longitude (dddmm.mmm) */
+		case 4:
+			ew = val[i] /* E=east,W=west */
+		case 5:
+			mode = val[i] /* mode indicator for each system */
+		case 6:
+			si, _ := strconv.ParseInt(val[i], 10, 0)
+			nrcv = int(si) /* # of satellites in use */
+		case 7:
+			hdop, _ = strconv.ParseFloat(val[i], 64) /* HDOP */
+		case 8:
+			alt, _ = strconv.ParseFloat(val[i], 64) /* Altitude MSL */
+		case 9:
+			msl, _ = strconv.ParseFloat(val[i], 64) /* Geoid separation */
+		case 10:
+			age, _ = strconv.ParseFloat(val[i], 64) /* Age of differential */
+		}
+	}
+	if (ns != "N" && ns != "S") || (ew != "E" && ew != "W") {
+		Trace(2, "invalid nmea gns format\n")
+		return 0
+	}
+	if sol.Time.Time == 0 {
+		Trace(2, "no date info for nmea gns\n")
+		return 0
+	}
+	pos[0] = dmm2deg(lat) * D2R
+	if ns == "S" {
+		pos[0] = -pos[0]
+	}
+	pos[1] = dmm2deg(lon) * D2R
+	if ew == "W" {
+		pos[1] = -pos[1]
+	}
+	pos[2] = alt + msl
+
+	sol.Time = nmea_tod2time(tod, sol.Time)
+	Pos2Ecef(pos[:], sol.Rr[:])
+	sol.Stat = SOLQ_NONE
+	for i = 0; i < len(mode); i++ { /* best mode of systems */
+		if stat := nmea_mode2stat(mode[i]); stat != SOLQ_NONE &&
+			(sol.Stat == SOLQ_NONE || stat < sol.Stat) {
+			sol.Stat = stat
+		}
+	}
+	sol.Ns = uint8(nrcv)
+	sol.Age = float32(age)
+
+	sol.Type = 0 /* postion type = xyz */
+
+	Trace(5, "decode_nmeagns: %s rr=%.3f %.3f %.3f stat=%d ns=%d hdop=%.1f mode=%s\n",
+		TimeStr(sol.Time, 0), sol.Rr[0], sol.Rr[1], sol.Rr[2], sol.Stat, sol.Ns,
+		hdop, mode)
+
+	return 1
+}
+
+/* solution status to NMEA mode indicator ------------------------------------*/
+func nmea_stat2mode(stat uint8) string {
+	switch stat {
+	case SOLQ_FIX:
+		return "R" /* RTK fixed */
+	case SOLQ_FLOAT:
+		return "F" /* RTK float */
+	case SOLQ_SBAS, SOLQ_DGPS:
+		return "D" /* differential */
+	case SOLQ_SINGLE:
+		return "A" /* autonomous */
+	case SOLQ_PPP:
+		return "P" /* precise */
+	case SOLQ_DR:
+		return "E" /* estimated (dead reckoning) */
+	}
+	return "N" /* no fix */
+}
+
+/* NMEA mode indicator to solution status ------------------------------------*/
+func nmea_mode2stat(mode byte) uint8 {
+	switch mode {
+	case 'R':
+		return SOLQ_FIX
+	case 'F':
+		return SOLQ_FLOAT
+	case 'D':
+		return SOLQ_DGPS
+	case 'A':
+		return SOLQ_SINGLE
+	case 'P':
+		return SOLQ_PPP
+	case 'E':
+		return SOLQ_DR
+	}
+	return SOLQ_NONE
+}
+
+/* NMEA satellite id and system index ----------------------------------------*/
+func nmea_satid(sat int, idx *int) int {
+	var prn int
+
+	sys := SatSys(sat, &prn)
+	switch sys {
+	case SYS_SBS:
+		prn -= 87 /* SBS: 33-64 */
+	case SYS_GLO:
+		prn += 64 /* GLO: 65-99 */
+	case SYS_QZS:
+		prn -= 192 /* QZS: 01-10 */
+	}
+	for *idx = 0; nmea_sys[*idx] > 0 && nmea_sys[*idx]&sys == 0; (*idx)++ {
+	}
+	return prn
+}
+
+/* NMEA time of day to time near solution time -------------------------------*/
+func nmea_tod2time(tod float64, t Gtime) Gtime {
+	var ep [6]float64
+
+	Time2Epoch(GpsT2Utc(t), ep[:])
+	septime(tod, &ep[3], &ep[4], &ep[5])
+	time := Utc2GpsT(Epoch2Time(ep[:]))
+	tt := TimeDiff(time, t)
+	switch {
+	case tt < -43200.0:
+		return TimeAdd(time, 86400.0)
+	case tt > 43200.0:
+		return TimeAdd(time, -86400.0)
+	}
+	return time
+}
+
+/* decode NMEA GST (GNSS Pseudorange Error Statistics) sentence --------------*/
+func (sol *Sol) DecodeNmeaGst(val []string, n int) int {
+	var (
+		tod, rms, smaj, smin, orient, a2, b2 float64
+		sd                                   [3]float64
+		pos                                  [3]float64
+		P, Q                                 [9]float64
+		i                                    int
+	)
+	Trace(4, "decode_nmeagst: n=%d\n", n)
+
+	if n < 8 || val[5] == "" || val[6] == "" || val[7] == "" {
+		Trace(2, "invalid nmea gst format\n")
+		return 0
+	}
+	for i = 0; i < n; i++ {
+		switch i {
+		case 0:
+			tod, _ = strconv.ParseFloat(val[i], 64) /* UTC of position (hhmmss) */
+		case 1:
+			rms, _ = strconv.ParseFloat(val[i], 64) /* RMS of range residuals */
+		case 2:
+			smaj, _ = strconv.ParseFloat(val[i], 64) /* std of semi-major axis */
+		case 3:
+			smin, _ = strconv.ParseFloat(val[i], 64) /* std of semi-minor axis */
+		case 4:
+			orient, _ = strconv.ParseFloat(val[i], 64) /* orientation of semi-major */
+		case 5:
+			sd[1], _ = strconv.ParseFloat(val[i], 64) /* std of latitude (m) */
+		case 6:
+			sd[0], _ = strconv.ParseFloat(val[i], 64) /* std of longitude (m) */
+		case 7:
+			sd[2], _ = strconv.ParseFloat(val[i], 64) /* std of altitude (m) */
+		}
+	}
+	if Norm(sol.Rr[:], 3) <= 0.0 {
+		Trace(2, "no position for nmea gst\n")
+		return 0
+	}
+	if math.Abs(TimeDiff(nmea_tod2time(tod, sol.Time), sol.Time)) > DTTOL {
+		Trace(2, "time mismatch of nmea gst\n")
+		return 0
+	}
+	Q[0], Q[4], Q[8] = SQR(sd[0]), SQR(sd[1]), SQR(sd[2])
+
+	/* e-n covariance by error ellipse */
+	if smaj > 0.0 {
+		a2, b2 = SQR(smaj), SQR(smin)
+		Q[1] = (a2 - b2) * math.Sin(orient*D2R) * math.Cos(orient*D2R)
+		Q[3] = Q[1]
+	}
+	Ecef2Pos(sol.Rr[:], pos[:])
+	Cov2Ecef(pos[:], Q[:], P[:])
+	sol.Cov2Sol(P[:])
+
+	Trace(5, "decode_nmeagst: %s rms=%.3f sd=%.3f %.3f %.3f\n", TimeStr(sol.Time, 0),
+		rms, sd[0], sd[1], sd[2])
+
+	return 3 /* update attributes */
+}
+
+/* decode NMEA VTG (Course Over Ground and Ground Speed) sentence ------------*/
+func (sol *Sol) DecodeNmeaVtg(val []string, n int) int {
+	var (
+		dir, vel, velk float64
+		pos, enuv      [3]float64
+		i              int
+	)
+	Trace(4, "decode_nmeavtg: n=%d\n", n)
+
+	if n < 7 || val[1] != "T" || val[5] != "N" {
+		Trace(2, "invalid nmea vtg format\n")
+		return 0
+	}
+	for i = 0; i < n; i++ {
+		switch i {
+		case 0:
+			dir, _ = strconv.ParseFloat(val[i], 64) /* course over ground (deg) */
+		case 4:
+			vel, _ = strconv.ParseFloat(val[i], 64) /* speed (knots) */
+		case 6:
+			velk, _ = strconv.ParseFloat(val[i], 64) /* speed (km/h) */
+		}
+	}
+	if Norm(sol.Rr[:], 3) <= 0.0 {
+		Trace(2, "no position for nmea vtg\n")
+		return 0
+	}
+	if val[4] == "" {
+		vel = velk / 3.6
+	} else {
+		vel *= KNOT2M
+	}
+	enuv[0] = vel * math.Sin(dir*D2R)
+	enuv[1] = vel * math.Cos(dir*D2R)
+	Ecef2Pos(sol.Rr[:], pos[:])
+	Enu2Ecef(pos[:], enuv[:], sol.Rr[3:])
+
+	Trace(5, "decode_nmeavtg: %s vel=%.3f dir=%.2f\n", TimeStr(sol.Time, 0), vel, dir)
+
+	return 3 /* update attributes */
+}
+
+/* decode NMEA GBS (GNSS Satellite Fault Detection) sentence -----------------*/
+func (sol *Sol) DecodeNmeaGbs(val []string, n int) int {
+	var (
+		tod, bias float64
+		err, pos  [3]float64
+		P, Q      [9]float64
+		i, svid   int
+	)
+	Trace(4, "decode_nmeagbs: n=%d\n", n)
+
+	if n < 8 || val[1] == "" || val[2] == "" || val[3] == "" {
+		Trace(2, "invalid nmea gbs format\n")
+		return 0
+	}
+	for i = 0; i < n; i++ {
+		switch i {
+		case 0:
+			tod, _ = strconv.ParseFloat(val[i], 64) /* UTC of position (hhmmss) */
+		case 1:
+			err[1], _ = strconv.ParseFloat(val[i], 64) /* error in latitude (m) */
+		case 2:
+			err[0], _ = strconv.ParseFloat(val[i], 64) /* error in longitude (m) */
+		case 3:
+			err[2], _ = strconv.ParseFloat(val[i], 64) /* error in altitude (m) */
+		case 4:
+			si, _ := strconv.ParseInt(val[i], 10, 0)
+			svid = int(si) /* id of most likely failed satellite */
+		case 6:
+			bias, _ = strconv.ParseFloat(val[i], 64) /* estimate of bias (m) */
+		}
+	}
+	if Norm(sol.Rr[:], 3) <= 0.0 {
+		Trace(2, "no position for nmea gbs\n")
+		return 0
+	}
+	if math.Abs(TimeDiff(nmea_tod2time(tod, sol.Time), sol.Time)) > DTTOL {
+		Trace(2, "time mismatch of nmea gbs\n")
+		return 0
+	}
+	/* expected errors used only without covariance by GST */
+	if sol.Qr[0] == 0.0 && sol.Qr[1] == 0.0 && sol.Qr[2] == 0.0 {
+		Q[0], Q[4], Q[8] = SQR(err[0]), SQR(err[1]), SQR(err[2])
+		Ecef2Pos(sol.Rr[:], pos[:])
+		Cov2Ecef(pos[:], Q[:], P[:])
+		sol.Cov2Sol(P[:])
+	}
+	Trace(5, "decode_nmeagbs: %s err=%.3f %.3f %.3f svid=%d bias=%.3f\n",
+		TimeStr(sol.Time, 0), err[0], err[1], err[2], svid, bias)
+
+	return 3 /* update attributes */
+}
+
+/* decode NMEA HDT (Heading True) sentence -----------------------------------*/
+func (sol *Sol) DecodeNmeaHdt(val []string, n int) int {
+	Trace(4, "decode_nmeahdt: n=%d\n", n)
+
+	if n < 2 || val[0] == "" || val[1] != "T" {
+		Trace(2, "invalid nmea hdt format\n")
+		return 0
+	}
+	if sol.Stat <= SOLQ_NONE {
+		Trace(2, "no solution for nmea hdt\n")
+		return 0
+	}
+	hdg, _ := strconv.ParseFloat(val[0], 64) /* heading true (deg) */
+	sol.Att[0] = hdg * D2R
+	if sol.AttStat == SOLQ_NONE {
+		sol.AttStat = sol.Stat
+	}
+	Trace(5, "decode_nmeahdt: %s hdg=%.3f\n", TimeStr(sol.Time, 0), hdg)
+
+	return 3 /* update attributes */
+}
+
+/* decode NMEA PASHR (Attitude) sentence -------------------------------------*/
+func (sol *Sol) DecodeNmeaPashr(val []string, n int) int {
+	var (
+		tod, hdg, pitch, sdp, sdh float64
+		i, q                      int
+	)
+	Trace(4, "decode_nmeapashr: n=%d\n", n)
+
+	if n < 10 || val[1] == "" || val[2] != "T" {
+		Trace(2, "invalid nmea pashr format\n")
+		return 0
+	}
+	for i = 0; i < n; i++ {
+		switch i {
+		case 0:
+			tod, _ = strconv.ParseFloat(val[i], 64) /* UTC of attitude (hhmmss) */
+		case 1:
+			hdg, _ = strconv.ParseFloat(val[i], 64) /* heading true (deg) */
+		case 4:
+			pitch, _ = strconv.ParseFloat(val[i], 64) /* pitch (deg) */
+		case 7:
+			sdp, _ = strconv.ParseFloat(val[i], 64) /* std of pitch (deg) */
+		case 8:
+			sdh, _ = strconv.ParseFloat(val[i], 64) /* std of heading (deg) */
+		case 9:
+			si, _ := strconv.ParseInt(val[i], 10, 0)
+			q = int(si) /* gnss quality (0:none,1:float,2:fix) */
+		}
+	}
+	if sol.Stat <= SOLQ_NONE {
+		Trace(2, "no solution for nmea pashr\n")
+		return 0
+	}
+	if math.Abs(TimeDiff(nmea_tod2time(tod, sol.Time), sol.Time)) > DTTOL {
+		Trace(2, "time mismatch of nmea pashr\n")
+		return 0
+	}
+	sol.Att[0], sol.Att[1] = hdg*D2R, pitch*D2R
+	sol.Qatt[0], sol.Qatt[1] = float32(SQR(sdh*D2R)), float32(SQR(sdp*D2R))
+	switch q {
+	case 2:
+		sol.AttStat = SOLQ_FIX
+	case 1:
+		sol.AttStat = SOLQ_FLOAT
+	default:
+		sol.AttStat = SOLQ_NONE
+	}
+	Trace(5, "decode_nmeapashr: %s hdg=%.3f pitch=%.3f q=%d\n", TimeStr(sol.Time, 0),
+		hdg, pitch, q)
+
+	return 3 /* update attributes */
+}
+
 /* test NMEA sentence header -------------------------------------------------*/
 func TestNmea(buff []byte) int {
 	if len(buff) < 6 || buff[0] != '$' {
@@ -402,6 +809,9 @@ func TestNmea(buff []byte) int {
 		string(buff[1:3]) == "BD" || string(buff[1:3]) == "QZ" /* extension */ {
 		return 1
 	}
+	if len(buff) >= 6 && string(buff[1:6]) == "PASHR" { /* proprietary */
+		return 1
+	}
 	return 0
 }
 
@@ -420,25 +830,51 @@ func TestSolStat(buff []byte) int {
 	return 0
 }
 
-/* decode NMEA sentence ------------------------------------------------------*/
+/* decode NMEA sentence --------------------------------------------------------
+* decode NMEA sentence
+* args   : sol_t  *sol      IO  solution (I:time and last solution for
+*                               sentences updating solution attributes)
+*          uint8_t *buff    I   NMEA sentence
+* return : status (0:no data,1:solution,2:time update,
+*                  3:update attributes (covariance,velocity,attitude) of sol)
+*-----------------------------------------------------------------------------*/
 func (sol *Sol) DecodeNmea(buff []byte) int {
-	//char *p,*q,*val[MAXFIELD];
 	var n int = 0
 
 	Trace(4, "decode_nmea: buff=%s\n", buff)
 
-	val := strings.FieldsFunc(string(buff), func(r rune) bool { return r == ',' || r == '*' })
+	if i := strings.IndexByte(string(buff), '*'); i >= 0 {
+		buff = buff[:i]
+	}
+	val := strings.Split(string(buff), ",")
 
-	if n = len(val); n < 1 {
+	if n = len(val); n < 1 || len(val[0]) < 6 {
 		return 0
 	}
-	switch val[3] {
+	switch val[0][3:] {
+	case "RMC", "ZDA", "GGA", "GNS": /* clear solution except time */
+		*sol = Sol{Time: sol.Time}
+	}
+	switch val[0][3:] {
 	case "RMC": /* $xxRMC */
 		return sol.DecodeNmeaRmc(val[1:], n-1)
 	case "ZDA": /* $xxZDA */
 		return sol.DecodeNmeaZda(val[1:], n-1)
 	case "GGA": /* $xxGGA */
 		return sol.DecodeNmeaGga(val[1:], n-1)
+	case "GNS": /* $xxGNS */
+		return sol.DecodeNmeaGns(val[1:], n-1)
+	case "GST": /* $xxGST */
+		return sol.DecodeNmeaGst(val[1:], n-1)
+	case "VTG": /* $xxVTG */
+		return sol.DecodeNmeaVtg(val[1:], n-1)
+	case "GBS": /* $xxGBS */
+		return sol.DecodeNmeaGbs(val[1:], n-1)
+	case "HDT": /* $xxHDT */
+		return sol.DecodeNmeaHdt(val[1:], n-1)
+	}
+	if val[0] == "$PASHR" {
+		return sol.DecodeNmeaPashr(val[1:], n-1)
 	}
 	return 0
 }
@@ -594,13 +1030,15 @@ func (sol *Sol) DecodeSolXyz(buff string, opt *SolOpt) int {
 		i++
 	}
 
-	if i+3 <= n { /* velocity */
+	outv := opt.OutVel > 0 || opt.OutAtt == 0 /* velocity by header or options */
+
+	if outv && i+3 <= n { /* velocity */
 		for j = 0; j < 3; j++ {
 			sol.Rr[j+3] = val[i] /* xyz */
 			i++
 		}
 	}
-	if i+3 <= n {
+	if outv && i+3 <= n {
 		for j = 0; j < 9; j++ {
 			P[j] = 0.0
 		}
@@ -623,6 +1061,10 @@ func (sol *Sol) DecodeSolXyz(buff string, opt *SolOpt) int {
 		}
 		sol.CovarianceVel2Sol(P[:])
 	}
+	if opt.OutAtt > 0 {
+		decode_solatt(sol, val[:], i, n)
+	}
+
 	sol.Type = 0 /* postion type = xyz */
 
 	if MAXSOLQ < sol.Stat {
@@ -703,7 +1145,9 @@ func (sol *Sol) DecodeSolLatLonHeight(buff string, opt *SolOpt) int {
 		i++
 	}
 
-	if i+3 <= n { /* velocity */
+	outv := opt.OutVel > 0 || opt.OutAtt == 0 /* velocity by header or options */
+
+	if outv && i+3 <= n { /* velocity */
 		vel[1] = val[i]
 		i++ /* vel-n */
 		vel[0] = val[i]
@@ -712,7 +1156,7 @@ func (sol *Sol) DecodeSolLatLonHeight(buff string, opt *SolOpt) int {
 		i++ /* vel-u */
 		Enu2Ecef(pos[:], vel[:], sol.Rr[3:])
 	}
-	if i+3 <= n {
+	if outv && i+3 <= n {
 		for j = 0; j < 9; j++ {
 			Q[j] = 0.0
 		}
@@ -736,6 +1180,10 @@ func (sol *Sol) DecodeSolLatLonHeight(buff string, opt *SolOpt) int {
 		Cov2Ecef(pos[:], Q[:], P[:])
 		sol.CovarianceVel2Sol(P[:])
 	}
+	if opt.OutAtt > 0 {
+		decode_solatt(sol, val[:], i, n)
+	}
+
 	sol.Type = 0 /* postion type = xyz */
 
 	if MAXSOLQ < sol.Stat {
@@ -744,6 +1192,21 @@ func (sol *Sol) DecodeSolLatLonHeight(buff string, opt *SolOpt) int {
 	return 1
 }
 
+/* decode attitude of moving-base vector -------------------------------------*/
+func decode_solatt(sol *Sol, val []float64, i, n int) {
+	if i+5 > n {
+		return
+	}
+	sol.Att[0] = val[i] * D2R                   /* heading */
+	sol.Att[1] = val[i+1] * D2R                 /* pitch */
+	sol.Qatt[0] = float32(SQRS(val[i+2] * D2R)) /* sdhdg */
+	sol.Qatt[1] = float32(SQRS(val[i+3] * D2R)) /* sdpitch */
+	sol.AttStat = uint8(val[i+4])               /* Qa */
+	if MAXSOLQ < sol.AttStat {
+		sol.AttStat = SOLQ_NONE
+	}
+}
+
 /* decode e/n/u-baseline -----------------------------------------------------*/
 func (sol *Sol) DecodeSolEnu(buff string, opt *SolOpt) int {
 	var (
@@ -799,6 +1262,9 @@ func (sol *Sol) DecodeSolEnu(buff string, opt *SolOpt) int {
 		sol.Ratio = float32(val[i])
 		i++
 	}
+	if opt.OutAtt > 0 {
+		decode_solatt(sol, val[:], i, n)
+	}
 
 	sol.Type = 1 /* postion type = enu */
 
@@ -989,6 +1455,16 @@ func DecodeSolOpt(buff string, opt *SolOpt) {
 		opt.DegF = 0
 		opt.Sep = " "
 	}
+	/* velocity and attitude fields of position record */
+	if index >= 0 && opt.Posf <= SOLF_ENU {
+		opt.OutVel, opt.OutAtt = 0, 0
+		if strings.Contains(buff, "(m/s)") {
+			opt.OutVel = 1
+		}
+		if strings.Contains(buff, "hdg(deg)") {
+			opt.OutAtt = 1
+		}
+	}
 }
 
 /* read solution option ------------------------------------------------------*/
@@ -1052,9 +1528,19 @@ func InputSol(data uint8, ts, te Gtime, tint float64,
 		Trace(2, "disconnect received\n")
 		return -1
 	}
+	/* last solution for NMEA sentences updating solution attributes */
+	last := GetSol(solbuf, solbuf.N-1)
+	if last != nil && TestNmea(solbuf.buff[:idx]) > 0 {
+		sol = last[0]
+	}
 	/* decode solution */
 	sol.Time = solbuf.Time
-	if stat = sol.DecodeSol(solbuf.buff[:idx], opt, solbuf.Rb[:]); stat > 0 {
+	if stat = sol.DecodeSol(solbuf.buff[:idx], opt, solbuf.Rb[:]); stat == 3 {
+		if last != nil {
+			last[0] = sol
+		}
+		return 0
+	} else if stat > 0 {
 		if stat > 0 {
 			solbuf.Time = sol.Time
 		} /* update current time */
@@ -1065,6 +1551,12 @@ func InputSol(data uint8, ts, te Gtime, tint float64,
 	if stat != 1 || ScreenTime(sol.Time, ts, te, tint) == 0 || (qflag > 0 && int(sol.Stat) != qflag) {
 		return 0
 	}
+	/* replace last solution by NMEA GGA and GNS at same time */
+	if last != nil && TestNmea(solbuf.buff[:idx]) > 0 &&
+		math.Abs(TimeDiff(sol.Time, last[0].Time)) < DTTOL {
+		last[0] = sol
+		return 0
+	}
 	/* add solution to solution buffer */
 	return sol.AddSol(solbuf)
 }
@@ -1169,7 +1661,7 @@ func (sol *Sol) AddSol(solbuf *SolBuf) int {
 		if solbuf.Nmax <= 1 {
 			return 0
 		}
-		solbuf.Data = append(solbuf.Data, *sol)
+		solbuf.Data[solbuf.End] = *sol
 
 		if solbuf.End++; solbuf.End >= solbuf.Nmax {
 			solbuf.End = 0
@@ -1448,6 +1940,9 @@ func OutEcef(buff *string, s string, sol *Sol, opt *SolOpt) int {
 			sep, sqvar(float64(sol.Qv[3])), sep, sqvar(float64(sol.Qv[4])), sep,
 			sqvar(float64(sol.Qv[5])))
 	}
+	if opt.OutAtt > 0 { /* output attitude */
+		sol.outsolatt(&p, sep)
+	}
 	p += "\r\n"
 
 	n := len(p) - len(*buff)
@@ -1495,12 +1990,23 @@ func (sol *Sol) OutSolPos(buff *string, s string, opt *SolOpt) int {
 			SQRT(Q[0]), sep, SQRT(Q[8]), sep, sqvar(Q[1]), sep, sqvar(Q[2]),
 			sep, sqvar(Q[5]))
 	}
+	if opt.OutAtt > 0 { /* output attitude */
+		sol.outsolatt(&p, sep)
+	}
 	p += "\r\n"
 	n := len(p) - len(*buff)
 	*buff = p
 	return n
 }
 
+/* output attitude of moving-base vector -------------------------------------*/
+func (sol *Sol) outsolatt(buff *string, sep string) {
+	*buff += fmt.Sprintf("%s%9.4f%s%8.4f%s%7.4f%s%7.4f%s%3d",
+		sep, sol.Att[0]*R2D, sep, sol.Att[1]*R2D, sep,
+		SQRT(float64(sol.Qatt[0]))*R2D, sep, SQRT(float64(sol.Qatt[1]))*R2D,
+		sep, sol.AttStat)
+}
+
 /* output solution as the form of e/n/u-baseline -----------------------------*/
 func (sol *Sol) OutSolEnu(buff *string, s string, rb []float64, opt *SolOpt) int {
 	var (
@@ -1519,15 +2025,29 @@ func (sol *Sol) OutSolEnu(buff *string, s string, rb []float64, opt *SolOpt) int
 	sol.Sol2Cov(P[:])
 	Cov2Enu(pos[:], P[:], Q[:])
 	Ecef2Enu(pos[:], rr[:], enu[:])
-	p += fmt.Sprintf("%s%s%14.4f%s%14.4f%s%14.4f%s%3d%s%3d%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%6.2f%s%6.1f\r\n",
+	p += fmt.Sprintf("%s%s%14.4f%s%14.4f%s%14.4f%s%3d%s%3d%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%8.4f%s%6.2f%s%6.1f",
 		s, sep, enu[0], sep, enu[1], sep, enu[2], sep, sol.Stat, sep, sol.Ns, sep,
 		SQRT(Q[0]), sep, SQRT(Q[4]), sep, SQRT(Q[8]), sep, sqvar(Q[1]),
 		sep, sqvar(Q[5]), sep, sqvar(Q[2]), sep, sol.Age, sep, sol.Ratio)
+
+	if opt.OutAtt > 0 { /* output attitude */
+		sol.outsolatt(&p, sep)
+	}
+	p += "\r\n"
 	n := len(p) - len(*buff)
 	*buff = p
 	return n
 }
 
+/* checksum of NMEA sentence (between '$' and '*') ---------------------------*/
+func nmea_checksum(s string) uint8 {
+	var sum uint8
+	for i := 1; i < len(s); i++ {
+		sum ^= s[i]
+	}
+	return sum
+}
+
 /* output solution in the form of NMEA RMC sentence --------------------------*/
 func (sol *Sol) OutSolNmeaRmc(buff *string) int {
 	dirp := 0.0
@@ -1536,19 +2056,17 @@ func (sol *Sol) OutSolNmeaRmc(buff *string) int {
 		ep                    [6]float64
 		pos, enuv, dms1, dms2 [3]float64
 		vel, dir, amag        float64
-		i                     int
 		sum                   uint8
 		emag, mode, status    string = "E", "A", "V"
 	)
 	p := *buff
+	q := len(p)
 
 	Trace(4, "outnmea_rmc:\n")
 
 	if sol.Stat <= SOLQ_NONE {
 		p += fmt.Sprintf("$%sRMC,,,,,,,,,,,,,", NMEA_TID)
-		for i = 1; i < len(p); i++ {
-			sum ^= p[i]
-		}
+		sum = nmea_checksum(p[q:])
 
 		p += fmt.Sprintf("*%02X%c%c", sum, 0x0D, 0x0A)
 		n := len(p) - len(*buff)
@@ -1598,9 +2116,7 @@ func (sol *Sol) OutSolNmeaRmc(buff *string) int {
 		NMEA_TID, ep[3], ep[4], ep[5], dms1[0], dms1[1]+dms1[2]/60.0,
 		pos1, dms2[0], dms2[1]+dms2[2]/60.0, pos2,
 		vel/KNOT2M, dir, ep[2], ep[1], int(math.Mod(ep[0], 100.0)), amag, emag, mode, status)
-	for i = 1; i < len(p); i++ {
-		sum ^= p[i]
-	}
+	sum = nmea_checksum(p[q:])
 
 	p += fmt.Sprintf("*%02X\r\n", sum)
 	n := len(p) - len(*buff)
@@ -1617,17 +2133,15 @@ func (sol *Sol) OutSolNmeaGga(buff *string) int {
 		pos, dms1, dms2 [3]float64
 		solq, refid     int = 0, 0
 		sum             uint8
-		i               int
 	)
 	p := *buff
+	q := len(p)
 
 	Trace(4, "outnmea_gga:\n")
 
 	if sol.Stat <= SOLQ_NONE {
 		p += fmt.Sprintf("$%sGGA,,,,,,,,,,,,,,", NMEA_TID)
-		for i = 1; i < len(p); i++ {
-			sum ^= p[i]
-		}
+		sum = nmea_checksum(p[q:])
 		p += fmt.Sprintf("*%02X%c%c", sum, 0x0D, 0x0A)
 		n := len(p) - len(*buff)
 		*buff = p
@@ -1666,9 +2180,251 @@ func (sol *Sol) OutSolNmeaGga(buff *string) int {
 		NMEA_TID, ep[3], ep[4], ep[5], dms1[0], dms1[1]+dms1[2]/60.0,
 		pos1, dms2[0], dms2[1]+dms2[2]/60.0, pos2,
 		solq, sol.Ns, dop, pos[2]-h, h, sol.Age, refid)
-	for i = 1; i < len(p); i++ {
-		sum ^= p[i]
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA GST sentence ----------------------------
+* rms of range residuals is not output as it is not kept in solution
+*-----------------------------------------------------------------------------*/
+func (sol *Sol) OutSolNmeaGst(buff *string) int {
+	var (
+		time           Gtime
+		ep             [6]float64
+		pos            [3]float64
+		P, Q           [9]float64
+		a, b, d, angle float64
+		sum            uint8
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_gst:\n")
+
+	if sol.Stat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sGST,,,,,,,,", NMEA_TID)
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
+	}
+	time = GpsT2Utc(sol.Time)
+	if time.Sec >= 0.995 {
+		time.Time++
+		time.Sec = 0.0
+	}
+	Time2Epoch(time, ep[:])
+	Ecef2Pos(sol.Rr[:], pos[:])
+	sol.Sol2Cov(P[:])
+	Cov2Enu(pos[:], P[:], Q[:])
+
+	/* error ellipse of horizontal position */
+	d = math.Sqrt(SQR((Q[4]-Q[0])/2.0) + SQR(Q[1]))
+	a = SQRT((Q[0]+Q[4])/2.0 + d)
+	b = SQRT((Q[0]+Q[4])/2.0 - d)
+	angle = 0.5 * math.Atan2(2.0*Q[1], Q[4]-Q[0]) * R2D
+	if angle < 0.0 {
+		angle += 180.0
+	}
+	p += fmt.Sprintf("$%sGST,%02.0f%02.0f%05.2f,,%.3f,%.3f,%.1f,%.3f,%.3f,%.3f",
+		NMEA_TID, ep[3], ep[4], ep[5], a, b, angle, SQRT(Q[4]), SQRT(Q[0]),
+		SQRT(Q[8]))
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA VTG sentence --------------------------*/
+func (sol *Sol) OutSolNmeaVtg(buff *string) int {
+	var (
+		pos, enuv [3]float64
+		vel, dir  float64
+		sum       uint8
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_vtg:\n")
+
+	if sol.Stat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sVTG,,T,,M,,N,,K,N", NMEA_TID)
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
+	}
+	Ecef2Pos(sol.Rr[:], pos[:])
+	Ecef2Enu(pos[:], sol.Rr[3:], enuv[:])
+	vel = Norm(enuv[:], 2)
+	if vel >= 1.0 {
+		dir = math.Atan2(enuv[0], enuv[1]) * R2D
+		if dir < 0.0 {
+			dir += 360.0
+		}
+	}
+	p += fmt.Sprintf("$%sVTG,%.2f,T,,M,%.2f,N,%.2f,K,%s", NMEA_TID, dir,
+		vel/KNOT2M, vel*3.6, nmea_stat2mode(sol.Stat))
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA ZDA sentence --------------------------*/
+func (sol *Sol) OutSolNmeaZda(buff *string) int {
+	var (
+		time Gtime
+		ep   [6]float64
+		sum  uint8
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_zda:\n")
+
+	if sol.Stat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sZDA,,,,,,", NMEA_TID)
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
 	}
+	time = GpsT2Utc(sol.Time)
+	if time.Sec >= 0.995 {
+		time.Time++
+		time.Sec = 0.0
+	}
+	Time2Epoch(time, ep[:])
+	p += fmt.Sprintf("$%sZDA,%02.0f%02.0f%05.2f,%02.0f,%02.0f,%04.0f,00,00",
+		NMEA_TID, ep[3], ep[4], ep[5], ep[2], ep[1], ep[0])
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA GNS sentence --------------------------*/
+func (sol *Sol) OutSolNmeaGns(buff *string) int {
+	var (
+		time            Gtime
+		h, dop          float64 = 0.0, 1.0
+		ep              [6]float64
+		pos, dms1, dms2 [3]float64
+		refid           int = 0
+		sum             uint8
+		pos1, pos2      string = "N", "E"
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_gns:\n")
+
+	if sol.Stat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sGNS,,,,,,N,,,,,,,V", NMEA_TID)
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
+	}
+	time = GpsT2Utc(sol.Time)
+	if time.Sec >= 0.995 {
+		time.Time++
+		time.Sec = 0.0
+	}
+	Time2Epoch(time, ep[:])
+	Ecef2Pos(sol.Rr[:], pos[:])
+	h = GeoidH(pos[:])
+	Deg2Dms(math.Abs(pos[0])*R2D, dms1[:], 7)
+	Deg2Dms(math.Abs(pos[1])*R2D, dms2[:], 7)
+	if pos[0] < 0 {
+		pos1 = "S"
+	}
+	if pos[1] < 0 {
+		pos2 = "W"
+	}
+	p += fmt.Sprintf("$%sGNS,%02.0f%02.0f%05.2f,%02.0f%010.7f,%s,%03.0f%010.7f,%s,%s,%02d,%.1f,%.3f,%.3f,%.1f,%04d,S",
+		NMEA_TID, ep[3], ep[4], ep[5], dms1[0], dms1[1]+dms1[2]/60.0,
+		pos1, dms2[0], dms2[1]+dms2[2]/60.0, pos2, nmea_stat2mode(sol.Stat),
+		sol.Ns, dop, pos[2]-h, h, sol.Age, refid)
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA HDT sentence --------------------------*/
+func (sol *Sol) OutSolNmeaHdt(buff *string) int {
+	var sum uint8
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_hdt:\n")
+
+	if sol.AttStat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sHDT,,T", NMEA_TIDH)
+	} else {
+		p += fmt.Sprintf("$%sHDT,%.3f,T", NMEA_TIDH, sol.Att[0]*R2D)
+	}
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
+/* output solution in the form of NMEA PASHR sentence --------------------------
+* $PASHR,hhmmss.ss,heading,T,roll,pitch,heave,sdroll,sdpitch,sdheading,
+*        gnss quality (0:none,1:float,2:fix),ins status (0:no ins)
+* roll and heave are not observable by dual-antenna and are output as 0.0
+*-----------------------------------------------------------------------------*/
+func (sol *Sol) OutSolNmeaPashr(buff *string) int {
+	var (
+		time  Gtime
+		ep    [6]float64
+		sum   uint8
+		gnssq int
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_pashr:\n")
+
+	if sol.AttStat <= SOLQ_NONE {
+		p += "$PASHR,,,,,,,,,,,"
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
+	}
+	time = GpsT2Utc(sol.Time)
+	if time.Sec >= 0.995 {
+		time.Time++
+		time.Sec = 0.0
+	}
+	Time2Epoch(time, ep[:])
+	switch sol.AttStat {
+	case SOLQ_FIX:
+		gnssq = 2
+	case SOLQ_FLOAT:
+		gnssq = 1
+	}
+	p += fmt.Sprintf("$PASHR,%02.0f%02.0f%06.3f,%.2f,T,%.2f,%.2f,%.2f,%.3f,%.3f,%.3f,%d,%d",
+		ep[3], ep[4], ep[5], sol.Att[0]*R2D, 0.0, sol.Att[1]*R2D, 0.0, 0.0,
+		SQRT(float64(sol.Qatt[1]))*R2D, SQRT(float64(sol.Qatt[0]))*R2D, gnssq, 0)
+	sum = nmea_checksum(p[q:])
 	p += fmt.Sprintf("*%02X\r\n", sum)
 	n := len(p) - len(*buff)
 	*buff = p
@@ -1685,6 +2441,7 @@ func (sol *Sol) OutSolNmeaGsa(buff *string, ssat []SSat) int {
 		sats                          [MAXSAT]int
 	)
 	p := *buff
+	q := len(p)
 
 	Trace(4, "outnmea_gsa:\n")
 
@@ -1728,6 +2485,7 @@ func (sol *Sol) OutSolNmeaGsa(buff *string, ssat []SSat) int {
 			} else {
 				d1 = 1
 			}
+			q = len(p)
 			p += fmt.Sprintf("$%sGSA,A,%d", s1, d1)
 			for j = 0; j < 12; j++ {
 				sys = SatSys(sats[j], &prn)
@@ -1747,9 +2505,7 @@ func (sol *Sol) OutSolNmeaGsa(buff *string, ssat []SSat) int {
 			}
 			p += fmt.Sprintf(",%3.1f,%3.1f,%3.1f,%d", dop[1], dop[2], dop[3],
 				nmea_sid[i])
-			for k := 1; k < len(p); k++ {
-				sum ^= p[k]
-			}
+			sum = nmea_checksum(p[q:])
 			p += fmt.Sprintf("*%02X\r\n", sum)
 		}
 	}
@@ -1758,6 +2514,65 @@ func (sol *Sol) OutSolNmeaGsa(buff *string, ssat []SSat) int {
 	return n
 }
 
+/* output solution in the form of NMEA GBS sentence ----------------------------
+* most likely failed satellite is the one with max pseudorange residual
+*-----------------------------------------------------------------------------*/
+func (sol *Sol) OutSolNmeaGbs(buff *string, ssat []SSat) int {
+	var (
+		time              Gtime
+		ep                [6]float64
+		pos               [3]float64
+		P, Q              [9]float64
+		resp              float64
+		i, sat, prn, idx  int
+		sum               uint8
+		svid, bias, sysid string
+	)
+	p := *buff
+	q := len(p)
+
+	Trace(4, "outnmea_gbs:\n")
+
+	if sol.Stat <= SOLQ_NONE {
+		p += fmt.Sprintf("$%sGBS,,,,,,,,", NMEA_TID)
+		sum = nmea_checksum(p[q:])
+		p += fmt.Sprintf("*%02X\r\n", sum)
+		n := len(p) - len(*buff)
+		*buff = p
+		return n
+	}
+	for i = 0; i < MAXSAT; i++ {
+		if ssat[i].Vs == 0 || math.Abs(float64(ssat[i].Resp[0])) <= resp {
+			continue
+		}
+		resp = math.Abs(float64(ssat[i].Resp[0]))
+		sat = i + 1
+	}
+	if sat > 0 {
+		prn = nmea_satid(sat, &idx)
+		svid = fmt.Sprintf("%02d", prn)
+		bias = fmt.Sprintf("%.3f", ssat[sat-1].Resp[0])
+		sysid = fmt.Sprintf("%d", nmea_sid[idx])
+	}
+	time = GpsT2Utc(sol.Time)
+	if time.Sec >= 0.995 {
+		time.Time++
+		time.Sec = 0.0
+	}
+	Time2Epoch(time, ep[:])
+	Ecef2Pos(sol.Rr[:], pos[:])
+	sol.Sol2Cov(P[:])
+	Cov2Enu(pos[:], P[:], Q[:])
+	p += fmt.Sprintf("$%sGBS,%02.0f%02.0f%05.2f,%.3f,%.3f,%.3f,%s,,%s,,%s",
+		NMEA_TID, ep[3], ep[4], ep[5], SQRT(Q[4]), SQRT(Q[0]), SQRT(Q[8]), svid,
+		bias, sysid)
+	sum = nmea_checksum(p[q:])
+	p += fmt.Sprintf("*%02X\r\n", sum)
+	n := len(p) - len(*buff)
+	*buff = p
+	return n
+}
+
 /* output solution in the form of NMEA GSV sentences -------------------------*/
 func (sol *Sol) OutSolNmeaGsv(buff *string, ssat []SSat) int {
 	var (
@@ -1767,6 +2582,7 @@ func (sol *Sol) OutSolNmeaGsv(buff *string, ssat []SSat) int {
 		sum                              uint8
 	)
 	p := *buff
+	q := len(p)
 
 	Trace(4, "outnmea_gsv:\n")
 
@@ -1784,6 +2600,7 @@ func (sol *Sol) OutSolNmeaGsv(buff *string, ssat []SSat) int {
 
 		for j, n = 0, 0; j < nmsg; j++ {
 
+			q = len(p)
 			p += fmt.Sprintf("$%sGSV,%d,%d,%02d", nmea_tid[i], nmsg, j+1, nsat)
 			for k = 0; k < 4; k++ {
 				if n < nsat {
@@ -1809,9 +2626,7 @@ func (sol *Sol) OutSolNmeaGsv(buff *string, ssat []SSat) int {
 				n++
 			}
 			p += ",0" /* all signals */
-			for k := 1; k < len(p); k++ {
-				sum ^= p[k]
-			}
+			sum = nmea_checksum(p[q:])
 			p += fmt.Sprintf("*%02X\r\n", sum)
 		}
 	}
@@ -2007,12 +2822,24 @@ func OutSolHeader(buff *string, opt *SolOpt) int {
 			"sden(m)", sep, "sdnu(m)", sep, "sdue(m)", sep, "age(s)", sep,
 			"ratio")
 	}
+	if opt.OutAtt > 0 && opt.Posf <= SOLF_ENU {
+		p += fmt.Sprintf("%s%9s%s%8s%s%7s%s%7s%s%3s", sep, "hdg(deg)", sep,
+			"pitch(d)", sep, "sdhdg", sep, "sdpit", sep, "Qa")
+	}
 	p += "\r\n"
 	n := len(p) - len(*buff)
 	*buff = p
 	return n
 }
 
+/* NMEA sentences to output --------------------------------------------------*/
+func nmea_sen(opt *SolOpt) int {
+	if opt.NmeaSen == 0 {
+		return NMEA_DEF
+	}
+	return opt.NmeaSen
+}
+
 /* std-dev of soltuion -------------------------------------------------------*/
 func (sol *Sol) SolStd() float64 {
 	/* approximate as max std-dev of 3-axis std-devs */
@@ -2105,9 +2932,31 @@ func (sol *Sol) OutSols(buff *string, rb []float64, opt *SolOpt) int {
 		sol.OutSolEnu(&p, s, rb, opt)
 
 	case SOLF_NMEA:
-		sol.OutSolNmeaRmc(&p)
-		sol.OutSolNmeaGga(&p)
-
+		sen := nmea_sen(opt)
+		if sen&NMEA_RMC != 0 {
+			sol.OutSolNmeaRmc(&p)
+		}
+		if sen&NMEA_GGA != 0 {
+			sol.OutSolNmeaGga(&p)
+		}
+		if sen&NMEA_GNS != 0 {
+			sol.OutSolNmeaGns(&p)
+		}
+		if sen&NMEA_GST != 0 {
+			sol.OutSolNmeaGst(&p)
+		}
+		if sen&NMEA_VTG != 0 {
+			sol.OutSolNmeaVtg(&p)
+		}
+		if sen&NMEA_ZDA != 0 {
+			sol.OutSolNmeaZda(&p)
+		}
+		if sen&NMEA_HDT != 0 && sol.AttStat > SOLQ_NONE {
+			sol.OutSolNmeaHdt(&p)
+		}
+		if sen&NMEA_PASHR != 0 && sol.AttStat > SOLQ_NONE {
+			sol.OutSolNmeaPashr(&p)
+		}
 	}
 	n := len(p) - len(*buff)
 	*buff = p
@@ -2142,8 +2991,16 @@ func (sol *Sol) OutSolExs(buff *string, ssat []SSat, opt *SolOpt) int {
 		}
 	}
 	if opt.Posf == SOLF_NMEA {
-		sol.OutSolNmeaGsa(&p, ssat)
-		sol.OutSolNmeaGsv(&p, ssat)
+		sen := nmea_sen(opt)
+		if sen&NMEA_GSA != 0 {
+			sol.OutSolNmeaGsa(&p, ssat)
+		}
+		if sen&NMEA_GSV != 0 {
+			sol.OutSolNmeaGsv(&p, ssat)
+		}
+		if sen&NMEA_GBS != 0 {
+			sol.OutSolNmeaGbs(&p, ssat)
+		}
 	}
 	n := len(p) - len(*buff)
 	*buff = p
@@ -75,10 +75,28 @@
 *                           suppress warning for buffer overflow by sprintf()
 *                           use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite stream.c with golang by fxb
+*           2026/10/16 1.1  ntrip caster stream on multi-mountpoint caster
+*                           support input ntrip caster stream from server
+*           2026/10/16 1.2  support ntrip 2.0 (http/1.1 chunked) for ntrip
+*                           server and client, add ntrip{1|2}:// path prefix
+*                           fix bug on decoding str in tcp/ntrip path
+*           2026/10/16 1.3  support tls for tcp server/client and ntrip
+*                           server/client by ::tls options in path
+*                           fix bug on accepting connections by tcp server
+*                           fix bug on open error of stream not detected
+*           2026/10/16 1.4  add stream port interface StreamPort and api
+*                           RegisterStreamType(),StreamType(),StreamScheme()
+*           2026/10/16 1.5  support mqtt stream (STR_MQTT)
+*           2026/10/16 1.6  support websocket stream (STR_WSSVR,STR_WSCLI)
+*           2026/10/16 1.7  fix bug on default port of ntrip without tls
+*           2026/10/16 1.8  download ftp/http stream by native client
+*                           instead of wget
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
 	"fmt"
 
@@ -122,6 +140,8 @@ const (
 	NTRIP_RSP_UNAUTH    = "HTTP/1.0 401 Unauthorized\r\n"
 	NTRIP_RSP_ERR_PWD   = "ERROR - Bad Pasword\r\n"
 	NTRIP_RSP_ERR_MNTP  = "ERROR - Bad Mountpoint\r\n"
+	NTRIP_TLS_PORT      = 443 /* default ntrip connection port for tls */
+	TLS_TIMEOUT         = 10  /* tls handshake timeout (s) */
 )
 
 var errno error = nil
@@ -155,14 +175,17 @@ type FileType struct { /* file control type */
 }
 
 type TcpConn struct { /* tcp control type */
-	state int      /* state (0:close,1:wait,2:connect) */
-	saddr string   /* address string */
-	port  int      /* port */
-	addr  net.Addr /* address resolved */
-	sock  net.Conn /* socket descriptor */
-	tcon  int      /* reconnect time (ms) (-1:never,0:now) */
-	tact  int64    /* data active tick */
-	tdis  int64    /* disconnect tick */
+	state int           /* state (0:close,1:wait,2:connect) */
+	saddr string        /* address string */
+	port  int           /* port */
+	addr  net.Addr      /* address resolved */
+	sock  net.Conn      /* socket descriptor */
+	tcon  int           /* reconnect time (ms) (-1:never,0:now) */
+	tact  int64         /* data active tick */
+	tdis  int64         /* disconnect tick */
+	ls    net.Listener  /* listener (tcp server) */
+	accq  chan net.Conn /* accepted connections (tcp server) */
+	conf  *tls.Config   /* tls configuration (nil: no tls) */
 }
 
 func (conn *TcpConn) ResolveAddr() string {
@@ -191,33 +214,31 @@ type SerialComm struct { /* serial control type */
 type NTrip struct { /* ntrip control type */
 	state  int        /* state (0:close,1:wait,2:connect) */
 	ctype  int        /* type (0:server,1:client) */
+	ver    int        /* ntrip version (0:auto,1:ntrip 1.0,2:ntrip 2.0) */
+	rver   int        /* ntrip version of connection (1:ntrip 1.0,2:ntrip 2.0) */
+	chunk  int        /* chunked transfer encoding (0:off,1:on) */
+	cst    int        /* chunk decoding state (0:size,1:data,2:end,3:last,4:ext) */
+	cnb    int        /* chunk size or remaining bytes in chunk */
 	nb     int        /* response buffer size */
 	url    string     /* url for proxy */
+	host   string     /* host address (addr:port) */
 	mntpnt string     /* mountpoint */
 	user   string     /* user */
 	passwd string     /* password */
 	str    string     /* mountpoint string for server */
+	gga    string     /* nmea GGA for Ntrip-GGA header */
 	buff   string     /* response buffer */
 	tcp    *TcpClient /* tcp client */
 }
 
-type NTripc_con struct { /* ntrip client/server connection type */
-	state  int    /* state (0:close,1:connect) */
-	mntpnt string /* mountpoint */
-	//	str    string /* mountpoint string for server */
-	nb   int    /* request buffer size */
-	buff string /* request buffer */
-}
-
 type NTripc struct { /* ntrip caster control type */
-	state  int          /* state (0:close,1:wait,2:connect) */
-	ctype  int          /* type (0:server,1:client) */
-	mntpnt string       /* mountpoint */
-	user   string       /* user */
-	passwd string       /* password */
-	srctbl string       /* source table */
-	tcp    *TcpSvr      /* tcp server */
-	con    []NTripc_con /* ntrip client/server connections */
+	state  int     /* state (0:close,1:wait,2:connect) */
+	ctype  int     /* type (0:server,1:client) */
+	mntpnt string  /* mountpoint */
+	user   string  /* user */
+	passwd string  /* password */
+	srctbl string  /* source table */
+	cas    *Caster /* shared ntrip caster */
 }
 
 type UdpConn struct { /* udp type */
@@ -232,7 +253,7 @@ type UdpConn struct { /* udp type */
 type FtpConn struct { /* ftp download control type */
 	state int /* state (0:close,1:download,2:complete,3:error) */
 	proto int /* protocol (0:ftp,1:http) */
-	error int /* error code (0:no error,1-10:wget error, */
+	error int /* error code (0:no error,1:download error, */
 	/*            11:no temp dir,12:uncompact error) */
 	addr   string /* download address */
 	file   string /* download file path */
@@ -251,6 +272,32 @@ type MemBuf struct { /* memory buffer type */
 	buf           []byte     /* write buffer */
 }
 
+/* stream port interface -------------------------------------------------------
+* interface of stream port implementations (serial,file,tcp,ntrip,udp,...)
+*   Open()   open port by path and mode (STR_MODE_???) (1:ok,0:error)
+*   Read()   read data from port (unblocked) (read data length)
+*   Write()  write data to port (unblocked) (written data length)
+*   State()  get port state (-1:error,0:close,1:wait,2:connect,3:active)
+*   StateX() get port state with extended status appended to message
+*   Close()  close port
+*-----------------------------------------------------------------------------*/
+type StreamPort interface {
+	Open(path string, mode int, msg *string) int
+	Read(buff []byte, n int, msg *string) int
+	Write(buff []byte, n int, msg *string) int
+	State() int
+	StateX(msg *string) int
+	Close()
+}
+
+type StreamFactory func() StreamPort /* generate new (unopened) stream port */
+
+type stream_type struct { /* registered stream type */
+	scheme  string        /* url scheme of stream path (scheme://...) */
+	ctype   int           /* stream type (STR_???) */
+	factory StreamFactory /* stream port factory */
+}
+
 /* proto types for static functions ------------------------------------------*/
 
 /* global options ------------------------------------------------------------*/
@@ -265,17 +312,46 @@ var (
 	tick_master uint32 = 0  /* time tick master for replay */
 	fswapmargin int    = 30 /* file swap margin (s) */)
 
+var (
+	lock_streamtype sync.Mutex                     /* lock flag for registered stream types */
+	streamtypes     []stream_type = []stream_type{ /* registered stream types */
+		{"serial", STR_SERIAL, func() StreamPort { return new(SerialComm) }},
+		{"file", STR_FILE, func() StreamPort { return new(FileType) }},
+		{"tcpsvr", STR_TCPSVR, func() StreamPort { return new(TcpSvr) }},
+		{"tcpcli", STR_TCPCLI, func() StreamPort { return new(TcpClient) }},
+		{"ntrips", STR_NTRIPSVR, func() StreamPort { return &NTrip{ctype: 0} }},
+		{"ntrip", STR_NTRIPCLI, func() StreamPort { return &NTrip{ctype: 1} }},
+		{"ftp", STR_FTP, func() StreamPort { return &FtpConn{proto: 0} }},
+		{"http", STR_HTTP, func() StreamPort { return &FtpConn{proto: 1} }},
+		{"ntripc", STR_NTRIPCAS, func() StreamPort { return new(NTripc) }},
+		{"udpsvr", STR_UDPSVR, func() StreamPort { return &UdpConn{ctype: 0} }},
+		{"udpcli", STR_UDPCLI, func() StreamPort { return &UdpConn{ctype: 1} }},
+		{"membuf", STR_MEMBUF, func() StreamPort { return new(MemBuf) }},
+		{"mqtt", STR_MQTT, func() StreamPort { return new(MqttConn) }},
+		{"wssvr", STR_WSSVR, func() StreamPort { return new(WsSvr) }},
+		{"wscli", STR_WSCLI, func() StreamPort { return new(WsClient) }},
+	}
+)
+
 /* read/write serial buffer --------------------------------------------------*/
 
 /* open serial ---------------------------------------------------------------*/
 func OpenSerial(path string, mode int, msg *string) *SerialComm {
+	seri := new(SerialComm)
+	if seri.Open(path, mode, msg) == 0 {
+		return nil
+	}
+	return seri
+}
+
+/* open serial port ----------------------------------------------------------*/
+func (seri *SerialComm) Open(path string, mode int, msg *string) int {
 	var (
 		br []int = []int{
 			300, 600, 1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200, 230400, 460800,
 			921600}
-		seri                             *SerialComm = new(SerialComm)
-		i, brate, bsize, stopb, tcp_port int         = 0, 9600, 8, 1, 0
-		parity                           rune        = 'N'
+		i, brate, bsize, stopb, tcp_port int  = 0, 9600, 8, 1, 0
+		parity                           rune = 'N'
 		port, fctr, path_tcp, msg_tcp    string
 	)
 	index := strings.Index(path, ":")
@@ -295,7 +371,7 @@ func OpenSerial(path string, mode int, msg *string) *SerialComm {
 	if i >= 14 {
 		*msg = fmt.Sprintf("bitrate error (%d)", brate)
 		Tracet(1, "openserial: %s path=%s\n", *msg, path)
-		return nil
+		return 0
 	}
 	parity = unicode.ToUpper(parity)
 
@@ -315,7 +391,7 @@ func OpenSerial(path string, mode int, msg *string) *SerialComm {
 		seri.tcpsvr = OpenTcpSvr(path_tcp, &msg_tcp)
 	}
 	Tracet(3, "openserial: dev=%d\n", seri.dev)
-	return seri
+	return 1
 }
 
 /* close serial --------------------------------------------------------------*/
@@ -397,6 +473,17 @@ func (seri *SerialComm) StatExSerial(msg *string) int {
 	return state
 }
 
+/* stream port interface of serial -------------------------------------------*/
+func (seri *SerialComm) Read(buff []byte, n int, msg *string) int {
+	return seri.ReadSerial(buff, n, msg)
+}
+func (seri *SerialComm) Write(buff []byte, n int, msg *string) int {
+	return seri.WriteSerial(buff, n, msg)
+}
+func (seri *SerialComm) State() int             { return seri.StateSerial() }
+func (seri *SerialComm) StateX(msg *string) int { return seri.StatExSerial(msg) }
+func (seri *SerialComm) Close()                 { seri.CloseSerial() }
+
 /* open file -----------------------------------------------------------------*/
 func openfile_(file *FileType, time Gtime, msg *string) int {
 	var (
@@ -520,8 +607,16 @@ func closefile_(file *FileType) {
 
 /* open file (path=filepath[::T[::+<off>][::x<speed>]][::S=swapintv][::P={4|8}] */
 func OpenStreamFile(path string, mode int, msg *string) *FileType {
+	file := new(FileType)
+	if file.Open(path, mode, msg) == 0 {
+		return nil
+	}
+	return file
+}
+
+/* open file port ------------------------------------------------------------*/
+func (file *FileType) Open(path string, mode int, msg *string) int {
 	var (
-		file                   *FileType = new(FileType)
 		time, time0            Gtime
 		speed, start, swapintv float64 = 1.0, 0.0, 0.0
 		timetag, size_fpos     int     = 0, 4 /* default 4B */)
@@ -529,7 +624,7 @@ func OpenStreamFile(path string, mode int, msg *string) *FileType {
 	Tracet(3, "openfile: path=%s mode=%d\n", path, mode)
 
 	if mode&(STR_MODE_R|STR_MODE_W) == 0 {
-		return nil
+		return 0
 	}
 
 	/* file options */
@@ -579,10 +674,9 @@ func OpenStreamFile(path string, mode int, msg *string) *FileType {
 
 	/* open new file */
 	if openfile_(file, time, msg) == 0 {
-		file = nil
-		return nil
+		return 0
 	}
-	return file
+	return 1
 }
 
 /* close file ----------------------------------------------------------------*/
@@ -828,6 +922,15 @@ func (file *FileType) WriteFile(buff []uint8, n int, msg *string) int {
 	return ns
 }
 
+/* stream port interface of file ---------------------------------------------*/
+func (file *FileType) Read(buff []byte, n int, msg *string) int {
+	return file.ReadFile(buff, int64(n), msg)
+}
+func (file *FileType) Write(buff []byte, n int, msg *string) int { return file.WriteFile(buff, n, msg) }
+func (file *FileType) State() int                                { return file.StateFile() }
+func (file *FileType) StateX(msg *string) int                    { return file.StatExFile(msg) }
+func (file *FileType) Close()                                    { file.CloseStreamFile() }
+
 /* sync files by time-tag ----------------------------------------------------*/
 func syncfile(file1, file2 *FileType) {
 	if file1.fp_tag == nil || file2.fp_tag == nil {
@@ -883,7 +986,7 @@ func DecodeTcpPath(path string, addr, port, user, passwd, mntpnt, str *string) {
 	if index = strings.Index(buff, "/"); index >= 0 {
 		p := buff[index+1:]
 		if idx := strings.Index(p, ":"); idx >= 0 {
-			q := p[idx:]
+			q := p[idx+1:]
 			if str != nil {
 				*str = fmt.Sprintf("%.*s", NTRIP_MAXSTR-1, q)
 			}
@@ -919,17 +1022,152 @@ func setsock(conn net.Conn, msg *string) int {
 }
 
 /* non-block accept ----------------------------------------------------------*/
-func Accept_nb(addr net.Addr) net.Conn {
-	listerner, err := net.Listen(addr.Network(), addr.String())
-	if err == nil {
-		sock, err1 := listerner.Accept()
-		seterrsock(err1)
+func Accept_nb(tcp *TcpConn) net.Conn {
+	select {
+	case sock := <-tcp.accq:
 		return sock
+	default:
 	}
-	seterrsock(err)
 	return nil
 }
 
+/* accept tcp connections ----------------------------------------------------*/
+func (tcp *TcpConn) accepttcp(ls net.Listener, accq chan net.Conn, conf *tls.Config) {
+	for {
+		sock, err := ls.Accept()
+		if err != nil {
+			Tracet(3, "accepttcp: listener closed port=%d err=%s\n", tcp.port, err.Error())
+			return
+		}
+		go func() {
+			if conf != nil { /* tls handshake */
+				c := tls.Server(sock, conf)
+				c.SetDeadline(time.Now().Add(TLS_TIMEOUT * time.Second))
+				if err := c.Handshake(); err != nil {
+					Tracet(2, "accepttcp: tls handshake error addr=%s err=%s\n",
+						sock.RemoteAddr().String(), err.Error())
+					sock.Close()
+					return
+				}
+				c.SetDeadline(time.Time{})
+				sock = c
+			}
+			select {
+			case accq <- sock:
+			default:
+				Tracet(2, "accepttcp: too many connections port=%d\n", tcp.port)
+				sock.Close()
+			}
+		}()
+	}
+}
+
+/* tls version string --------------------------------------------------------*/
+func tlsver(ver uint16) string {
+	switch ver {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	}
+	return fmt.Sprintf("0x%04X", ver)
+}
+
+/* decode tls options ----------------------------------------------------------
+* decode tls options in tcp/ntrip path and generate tls configuration
+* args   : string *path     IO  tcp/ntrip path (tls options removed)
+*          int    ctype     I   type (0:server,1:client)
+*          tls.Config **conf O  tls configuration (nil: no tls)
+*          string *msg      O   error message
+* return : status (1:ok,0:error)
+* notes  : tls options are appended to the path as:
+*            path::tls[::ca=file][::cert=file][::key=file][::sni=name][::noverify]
+*          ca      : CA certificates bundle file (PEM) to verify the server
+*                    (client) or to request and verify client certificate
+*                    (server)
+*          cert    : certificate file (PEM) of the client or the server
+*                    (required for server)
+*          key     : private key file (PEM) (default: same as cert)
+*          sni     : server name for SNI and verification (client)
+*                    (default: address in the path)
+*          noverify: skip verification of server certificate (client)
+*-----------------------------------------------------------------------------*/
+func decodetls(path *string, ctype int, conf **tls.Config, msg *string) int {
+	var (
+		ca, cert, key, sni string
+		enable, noverify   bool
+	)
+	*conf = nil
+
+	i := strings.Index(*path, "::")
+	if i < 0 {
+		return 1
+	}
+	opts := strings.Split((*path)[i+2:], "::")
+	*path = (*path)[:i]
+
+	for _, opt := range opts {
+		switch {
+		case opt == "tls":
+			enable = true
+		case opt == "noverify":
+			enable, noverify = true, true
+		case strings.HasPrefix(opt, "ca="):
+			enable, ca = true, opt[3:]
+		case strings.HasPrefix(opt, "cert="):
+			enable, cert = true, opt[5:]
+		case strings.HasPrefix(opt, "key="):
+			enable, key = true, opt[4:]
+		case strings.HasPrefix(opt, "sni="):
+			enable, sni = true, opt[4:]
+		default:
+			Tracet(2, "decodetls: unknown option %s\n", opt)
+		}
+	}
+	if !enable {
+		return 1
+	}
+	c := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: sni,
+		InsecureSkipVerify: noverify}
+
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if buff, err := os.ReadFile(ca); err != nil || !pool.AppendCertsFromPEM(buff) {
+			*msg = fmt.Sprintf("ca file error: %s", ca)
+			Tracet(2, "decodetls: ca file error file=%s\n", ca)
+			return 0
+		}
+		if ctype == 0 {
+			c.ClientCAs = pool
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			c.RootCAs = pool
+		}
+	}
+	if len(cert) > 0 {
+		if len(key) == 0 {
+			key = cert
+		}
+		crt, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			*msg = fmt.Sprintf("certificate error: %s", cert)
+			Tracet(2, "decodetls: certificate error file=%s err=%s\n", cert, err.Error())
+			return 0
+		}
+		c.Certificates = []tls.Certificate{crt}
+	} else if ctype == 0 {
+		*msg = "no server certificate"
+		Tracet(2, "decodetls: no server certificate\n")
+		return 0
+	}
+	*conf = c
+	return 1
+}
+
 /* non-block connect ---------------------------------------------------------*/
 func Connect_nb(sock net.Conn, addr net.Addr) int {
 	return 1
@@ -937,13 +1175,11 @@ func Connect_nb(sock net.Conn, addr net.Addr) int {
 
 /* non-block receive ---------------------------------------------------------*/
 func Recv_nb(sock net.Conn, buff []byte, n int) int {
-	var p [NTRIP_MAXSTR]byte
-	nr, err := sock.Read(p[:])
+	nr, err := sock.Read(buff[:n])
 	seterrsock(err)
 	if nr <= 0 {
 		return -1
 	}
-	copy(buff, p[:nr])
 	return nr
 }
 
@@ -962,13 +1198,18 @@ func Send_nb(sock net.Conn, buff []byte, n int) int {
 func (tcp *TcpConn) GenTcp(ctype int, msg *string) int {
 
 	if ctype == 0 { /* server socket */
-		tcp.sock = Accept_nb(tcp.addr)
-		if tcp.sock == nil {
+		ls, err := net.Listen("tcp", fmt.Sprintf(":%d", tcp.port))
+		seterrsock(err)
+		if err != nil {
 			*msg = "bind error"
+			Tracet(1, "gentcp: bind error port=%d err=%s\n", tcp.port, err.Error())
 			tcp.state = -1
 			return 0
 		}
-		tcp.state = 1
+		tcp.ls = ls
+		tcp.addr = ls.Addr()
+		tcp.accq = make(chan net.Conn, MAXCLI)
+		go tcp.accepttcp(ls, tcp.accq, tcp.conf)
 	} else {
 		var err error
 
@@ -979,6 +1220,25 @@ func (tcp *TcpConn) GenTcp(ctype int, msg *string) int {
 			tcp.state = -1
 			return 0
 		}
+		if tcp.conf != nil { /* tls handshake */
+			conf := tcp.conf
+			if len(conf.ServerName) == 0 {
+				conf = conf.Clone()
+				conf.ServerName = tcp.saddr
+			}
+			c := tls.Client(tcp.sock, conf)
+			c.SetDeadline(time.Now().Add(TLS_TIMEOUT * time.Second))
+			if err = c.Handshake(); err != nil {
+				*msg = fmt.Sprintf("tls error: %s", err)
+				Tracet(2, "gentcp: tls handshake error addr=%s err=%s\n", tcp.saddr,
+					err.Error())
+				tcp.sock.Close()
+				tcp.state = -1
+				return 0
+			}
+			c.SetDeadline(time.Time{})
+			tcp.sock = c
+		}
 	}
 	tcp.state = 1
 	tcp.tact = TickGet()
@@ -999,25 +1259,34 @@ func (tcp *TcpConn) DisconnectTcp(tcon int) {
 
 /* open tcp server -----------------------------------------------------------*/
 func OpenTcpSvr(path string, msg *string) *TcpSvr {
+	tcpsvr := new(TcpSvr)
+	if tcpsvr.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return tcpsvr
+}
+
+/* open tcp server port ------------------------------------------------------*/
+func (tcpsvr *TcpSvr) Open(path string, mode int, msg *string) int {
 	var (
-		tcpsvr *TcpSvr = new(TcpSvr)
-		port   string
+		port string
 	)
 	Tracet(3, "opentcpsvr: path=%s\n", path)
 
+	if decodetls(&path, 0, &tcpsvr.svr.conf, msg) == 0 {
+		return 0
+	}
 	DecodeTcpPath(path, &tcpsvr.svr.saddr, &port, nil, nil, nil, nil)
 	if n, _ := fmt.Sscanf(port, "%d", &tcpsvr.svr.port); n < 1 {
 		*msg = fmt.Sprintf("port error: %s", port)
 		Tracet(1, "opentcpsvr: port error port=%s\n", port)
-		tcpsvr = nil
-		return nil
+		return 0
 	}
 	if tcpsvr.svr.GenTcp(0, msg) == 0 {
-		tcpsvr = nil
-		return nil
+		return 0
 	}
 	tcpsvr.svr.tcon = 0
-	return tcpsvr
+	return 1
 }
 
 /* close tcp server ----------------------------------------------------------*/
@@ -1030,7 +1299,9 @@ func (tcpsvr *TcpSvr) CloseTcpSvr() {
 			tcpsvr.cli[i].state = 0
 		}
 	}
-	tcpsvr.svr.sock.Close()
+	if tcpsvr.svr.ls != nil {
+		tcpsvr.svr.ls.Close()
+	}
 	tcpsvr.svr.state = 0
 	tcpsvr = nil
 }
@@ -1086,16 +1357,7 @@ func (tcpsvr *TcpSvr) AccSock(msg *string) int {
 		Tracet(2, "accsock: too many clients sock=%d\n", tcpsvr.svr.sock)
 		return 0
 	}
-	if sock = Accept_nb(tcpsvr.svr.addr); sock == nil {
-		if err := errsock(); err != nil {
-			*msg = fmt.Sprintf("accept error (%s)", err.Error())
-			Tracet(1, "accsock: accept error sock=%d err=%s\n", tcpsvr.svr.sock, err.Error())
-		}
-		tcpsvr.svr.sock.Close()
-		tcpsvr.svr.state = 0
-		return 0
-	}
-	if sock == nil {
+	if sock = Accept_nb(&tcpsvr.svr); sock == nil {
 		return 0
 	}
 	if setsock(sock, msg) == 0 {
@@ -1104,7 +1366,7 @@ func (tcpsvr *TcpSvr) AccSock(msg *string) int {
 
 	tcpsvr.cli[i].sock = sock
 	tcpsvr.cli[i].addr = sock.RemoteAddr()
-	tcpsvr.cli[i].saddr = tcpsvr.svr.addr.String()
+	tcpsvr.cli[i].saddr = sock.RemoteAddr().String()
 	*msg = tcpsvr.cli[i].saddr
 	Tracet(3, "accsock: connected sock=%d addr=%s i=%d\n",
 		tcpsvr.cli[i].sock, tcpsvr.cli[i].saddr, i)
@@ -1210,8 +1472,21 @@ func (tcp *TcpConn) StatExTcp(msg *string) int {
 	*msg += fmt.Sprintf("    state = %d\n", tcp.state)
 	*msg += fmt.Sprintf("    saddr = %s\n", tcp.saddr)
 	*msg += fmt.Sprintf("    port  = %d\n", tcp.port)
-	*msg += fmt.Sprintf("    sock  = %d\n", tcp.sock)
-
+	if c, ok := tcp.sock.(*tls.Conn); ok {
+		*msg += fmt.Sprintf("    sock  = %d\n", c.NetConn())
+		st := c.ConnectionState()
+		*msg += fmt.Sprintf("    tls   = %s %s\n", tlsver(st.Version),
+			tls.CipherSuiteName(st.CipherSuite))
+		*msg += fmt.Sprintf("    sni   = %s\n", st.ServerName)
+		if len(st.PeerCertificates) > 0 {
+			*msg += fmt.Sprintf("    peer  = %s\n", st.PeerCertificates[0].Subject.String())
+		}
+	} else {
+		*msg += fmt.Sprintf("    sock  = %d\n", tcp.sock)
+		if tcp.conf != nil {
+			*msg += "    tls   = on\n"
+		}
+	}
 	return len(*msg) - n
 }
 
@@ -1239,6 +1514,17 @@ func (tcpsvr *TcpSvr) StatExTcpSvr(msg *string) int {
 	return state
 }
 
+/* stream port interface of tcp server ---------------------------------------*/
+func (tcpsvr *TcpSvr) Read(buff []byte, n int, msg *string) int {
+	return tcpsvr.ReadTcpSvr(buff, n, msg)
+}
+func (tcpsvr *TcpSvr) Write(buff []byte, n int, msg *string) int {
+	return tcpsvr.WriteTcpSvr(buff, n, msg)
+}
+func (tcpsvr *TcpSvr) State() int             { return tcpsvr.StateTcpSvr() }
+func (tcpsvr *TcpSvr) StateX(msg *string) int { return tcpsvr.StatExTcpSvr(msg) }
+func (tcpsvr *TcpSvr) Close()                 { tcpsvr.CloseTcpSvr() }
+
 /* connect server ------------------------------------------------------------*/
 func (tcpcli *TcpClient) ConnectSock(msg *string) int {
 	var stat int
@@ -1273,26 +1559,37 @@ func (tcpcli *TcpClient) ConnectSock(msg *string) int {
 
 /* open tcp client -----------------------------------------------------------*/
 func OpenTcpClient(path string, msg *string) *TcpClient {
+	tcpcli := new(TcpClient)
+	if tcpcli.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return tcpcli
+}
+
+/* open tcp client port ------------------------------------------------------*/
+func (tcpcli *TcpClient) Open(path string, mode int, msg *string) int {
 	var (
-		tcpcli *TcpClient = new(TcpClient)
-		port   string
-		err    error
+		port string
+		err  error
 	)
 
 	Tracet(3, "opentcpcli: path=%s\n", path)
 
+	if decodetls(&path, 1, &tcpcli.svr.conf, msg) == 0 {
+		return 0
+	}
 	DecodeTcpPath(path, &tcpcli.svr.saddr, &port, nil, nil, nil, nil)
 	tcpcli.svr.port, err = strconv.Atoi(port)
 	if err != nil {
 		*msg = fmt.Sprintf("port error: %s", port)
 		Tracet(2, "opentcp: port error port=%s\n", port)
-		return nil
+		return 0
 	}
 
 	tcpcli.svr.tcon = 0
 	tcpcli.toinact = toinact
 	tcpcli.tirecon = ticonnect
-	return tcpcli
+	return 1
 }
 
 /* close tcp client ----------------------------------------------------------*/
@@ -1404,6 +1701,17 @@ func (tcpcli *TcpClient) StatExTcpClient(msg *string) int {
 	return 0
 }
 
+/* stream port interface of tcp client ---------------------------------------*/
+func (tcpcli *TcpClient) Read(buff []byte, n int, msg *string) int {
+	return tcpcli.ReadTcpClient(buff, n, msg)
+}
+func (tcpcli *TcpClient) Write(buff []byte, n int, msg *string) int {
+	return tcpcli.WriteTcpClient(buff, n, msg)
+}
+func (tcpcli *TcpClient) State() int             { return tcpcli.StateTcpCli() }
+func (tcpcli *TcpClient) StateX(msg *string) int { return tcpcli.StatExTcpClient(msg) }
+func (tcpcli *TcpClient) Close()                 { tcpcli.CloseTcpClient() }
+
 /* base64 encoder ------------------------------------------------------------*/
 func encbase64(str []rune, bytes []uint8, n int) string {
 	var (
@@ -1431,14 +1739,31 @@ func encbase64(str []rune, bytes []uint8, n int) string {
 
 /* send ntrip server request -------------------------------------------------*/
 func (ntrip *NTrip) RequestNtrip_s(msg *string) int {
-	//var buff[]rune = make(rune, 1024+NTRIP_MAXSTR)
-	var p string
+	var user, p string
 
-	Tracet(3, "reqntrip_s: state=%d\n", ntrip.state)
+	Tracet(3, "reqntrip_s: state=%d ver=%d\n", ntrip.state, ntrip.ver)
 
-	p += fmt.Sprintf("SOURCE %s %s\r\n", ntrip.passwd, ntrip.mntpnt)
-	p += fmt.Sprintf("Source-Agent: NTRIP %s\r\n", NTRIP_AGENT)
-	p += fmt.Sprintf("STR: %s\r\n", ntrip.str)
+	if ntrip.ver == 2 || (ntrip.ver == 0 && len(ntrip.user) > 0) { /* ntrip 2.0 */
+		p += fmt.Sprintf("POST %s/%s HTTP/1.1\r\n", ntrip.url, ntrip.mntpnt)
+		p += fmt.Sprintf("Host: %s\r\n", ntrip.host)
+		p += "Ntrip-Version: Ntrip/2.0\r\n"
+		user = fmt.Sprintf("%s:%s", ntrip.user, ntrip.passwd)
+		p += "Authorization: Basic "
+		p += encbase64(make([]rune, (len(user)+2)/3*4), []uint8(user), len(user))
+		p += "\r\n"
+		p += fmt.Sprintf("User-Agent: NTRIP %s\r\n", NTRIP_AGENT)
+		if len(ntrip.str) > 0 {
+			p += fmt.Sprintf("Ntrip-STR: %s\r\n", ntrip.str)
+		}
+		p += "Connection: close\r\n"
+		p += "Transfer-Encoding: chunked\r\n"
+		ntrip.rver = 2
+	} else {
+		p += fmt.Sprintf("SOURCE %s %s\r\n", ntrip.passwd, ntrip.mntpnt)
+		p += fmt.Sprintf("Source-Agent: NTRIP %s\r\n", NTRIP_AGENT)
+		p += fmt.Sprintf("STR: %s\r\n", ntrip.str)
+		ntrip.rver = 1
+	}
 	p += "\r\n"
 	if ntrip.tcp.WriteTcpClient([]uint8(p), len(p), msg) != len(p) {
 		return 0
@@ -1452,22 +1777,35 @@ func (ntrip *NTrip) RequestNtrip_s(msg *string) int {
 
 /* send ntrip client request -------------------------------------------------*/
 func (ntrip *NTrip) RequestNtrip_c(msg *string) int {
-	//char buff[MAXSTRPATH+1024],user[514],*p=buff;
 	var user, p string
-	Tracet(3, "reqntrip_c: state=%d\n", ntrip.state)
 
-	p += fmt.Sprintf("GET %s/%s HTTP/1.0\r\n", ntrip.url, ntrip.mntpnt)
+	Tracet(3, "reqntrip_c: state=%d ver=%d\n", ntrip.state, ntrip.ver)
+
+	ntrip.rver, ntrip.chunk, ntrip.cst, ntrip.cnb = 1, 0, 0, 0
+
+	if ntrip.ver == 1 {
+		p += fmt.Sprintf("GET %s/%s HTTP/1.0\r\n", ntrip.url, ntrip.mntpnt)
+	} else { /* ntrip 2.0 request also accepted by ntrip 1.0 caster */
+		p += fmt.Sprintf("GET %s/%s HTTP/1.1\r\n", ntrip.url, ntrip.mntpnt)
+		p += fmt.Sprintf("Host: %s\r\n", ntrip.host)
+		p += "Ntrip-Version: Ntrip/2.0\r\n"
+	}
 	p += fmt.Sprintf("User-Agent: NTRIP %s\r\n", NTRIP_AGENT)
 
+	if ntrip.ver != 1 && len(ntrip.gga) > 0 {
+		p += fmt.Sprintf("Ntrip-GGA: %s\r\n", ntrip.gga)
+	}
 	if len(ntrip.user) == 0 {
 		p += "Accept: */*\r\n"
 		p += "Connection: close\r\n"
 	} else {
 		user = fmt.Sprintf("%s:%s", ntrip.user, ntrip.passwd)
 		p += "Authorization: Basic "
-		q := []rune(p)
-		p += encbase64(q, []uint8(user), len(user))
+		p += encbase64(make([]rune, (len(user)+2)/3*4), []uint8(user), len(user))
 		p += "\r\n"
+		if ntrip.ver != 1 {
+			p += "Connection: close\r\n"
+		}
 	}
 	p += "\r\n"
 
@@ -1481,52 +1819,134 @@ func (ntrip *NTrip) RequestNtrip_c(msg *string) int {
 	return 1
 }
 
+/* decode http response header -------------------------------------------------
+* decode http response header (status line and header fields)
+* args   : string buff      I   response buffer
+*          string *status   O   status line
+*          map    head      O   header fields (key in lower case)
+*          int    *nh       O   header length (bytes)
+* return : status code (-1: incomplete header, 0: error)
+*-----------------------------------------------------------------------------*/
+func decodehttp(buff string, status *string, head map[string]string, nh *int) int {
+	var code int
+
+	i := strings.Index(buff, "\r\n\r\n")
+	if i < 0 {
+		return -1
+	}
+	*nh = i + 4
+	lines := strings.Split(buff[:i], "\r\n")
+	*status = lines[0]
+	if v := strings.Fields(lines[0]); len(v) < 2 || !strings.HasPrefix(v[0], NTRIP_RSP_HTTP) {
+		return 0
+	} else if c, err := strconv.Atoi(v[1]); err == nil {
+		code = c
+	}
+	for _, line := range lines[1:] {
+		if j := strings.Index(line, ":"); j > 0 {
+			head[strings.ToLower(strings.TrimSpace(line[:j]))] = strings.TrimSpace(line[j+1:])
+		}
+	}
+	return code
+}
+
+/* handle ntrip error response -----------------------------------------------*/
+func (ntrip *NTrip) errorNtrip(str string, msg *string) {
+	if len(str) > MAXSTATMSG {
+		str = str[:MAXSTATMSG]
+	}
+	*msg = str
+	ntrip.nb = 0
+	ntrip.buff = ""
+	ntrip.state = 0
+	ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
+}
+
+/* test ntrip 2.0 (http) response --------------------------------------------*/
+func (ntrip *NTrip) ResponseNtrip_http(idx int, msg *string) int {
+	var status string
+	var nh int
+	head := make(map[string]string)
+
+	code := decodehttp(ntrip.buff[idx:], &status, head, &nh)
+	if code < 0 { /* wait header */
+		if ntrip.nb >= NTRIP_MAXRSP {
+			Tracet(2, "rspntrip: response overflow nb=%d\n", ntrip.nb)
+			ntrip.errorNtrip("response overflow", msg)
+		}
+		return 0
+	}
+	if code != 200 {
+		Tracet(2, "rspntrip: %s nb=%d\n", status, ntrip.nb)
+
+		/* fall back to ntrip 1.0 for caster not supporting ntrip 2.0 */
+		if ntrip.ver == 0 && ntrip.ctype == 0 && (code == 400 || code == 405 ||
+			code == 501 || code == 505) && len(ntrip.passwd) > 0 {
+			ntrip.ver = 1
+		}
+		ntrip.errorNtrip(status, msg)
+		return 0
+	}
+	ntrip.rver = 2
+	if strings.EqualFold(head["transfer-encoding"], "chunked") {
+		ntrip.chunk = 1
+	}
+	ntrip.buff = ntrip.buff[idx+nh:]
+	ntrip.nb = len(ntrip.buff)
+
+	if ntrip.ctype == 1 && strings.Contains(head["content-type"], "gnss/sourcetable") {
+		if len(ntrip.mntpnt) > 0 {
+			Tracet(2, "rspntrip_c: no mount point nb=%d\n", ntrip.nb)
+			ntrip.errorNtrip("no mountp. reconnect...", msg)
+			return 0
+		}
+		ntrip.state = 2
+		*msg = "source table received"
+		Tracet(3, "rspntrip_c: receive source table nb=%d\n", ntrip.nb)
+		return 1
+	}
+	ntrip.state = 2
+	*msg = fmt.Sprintf("%s/%s", ntrip.tcp.svr.saddr, ntrip.mntpnt)
+	Tracet(3, "rspntrip: response ok (ntrip 2.0) nb=%d chunk=%d\n", ntrip.nb, ntrip.chunk)
+	return 1
+}
+
 /* test ntrip server response ------------------------------------------------*/
 func (ntrip *NTrip) ResponseNtrip_s(msg *string) int {
-	var i, nb, idx int
-	//char *p,*q;
+	var nb, idx int
 
 	Tracet(3, "rspntrip_s: state=%d nb=%d\n", ntrip.state, ntrip.nb)
 	ntrip.buff = ntrip.buff[:ntrip.nb]
 	Tracet(5, "rspntrip_s: n=%d buff=\n%s\n", ntrip.nb, ntrip.buff)
 
-	if idx = strings.Index(string(ntrip.buff[:]), NTRIP_RSP_OK_SVR); idx >= 0 { /* ok */
-		// q=(char *)ntrip.buff;
-		// p+=strlen(NTRIP_RSP_OK_SVR);
+	if ntrip.rver == 2 {
+		if idx = strings.Index(ntrip.buff, NTRIP_RSP_HTTP); idx >= 0 { /* http response */
+			return ntrip.ResponseNtrip_http(idx, msg)
+		}
+	} else if idx = strings.Index(ntrip.buff, NTRIP_RSP_OK_SVR); idx >= 0 { /* ok */
 		idx += len(NTRIP_RSP_OK_SVR)
-		//ntrip.nb-=p-q;
 		ntrip.nb -= idx
-		var p = []rune(ntrip.buff)
-		for i = 0; i < ntrip.nb; i++ {
-			//ntrip.buff[i] = ntrip.buff[i+idx]
-			p[i] = p[i+idx]
-		}
-		ntrip.buff = string(p)
+		ntrip.buff = ntrip.buff[idx:]
 		ntrip.state = 2
 		*msg = fmt.Sprintf("%s/%s", ntrip.tcp.svr.saddr, ntrip.mntpnt)
 		Tracet(3, "rspntrip_s: response ok nb=%d\n", ntrip.nb)
 		return 1
-	} else if idx = strings.Index(string(ntrip.buff[:]), NTRIP_RSP_ERROR); idx >= 0 { /* error */
+	} else if idx = strings.Index(ntrip.buff, NTRIP_RSP_ERROR); idx >= 0 { /* error */
 		nb = MAXSTATMSG
 		if ntrip.nb < MAXSTATMSG {
 			nb = ntrip.nb
 		}
-		*msg = fmt.Sprintf("%.*s", nb, string(ntrip.buff[:]))
-		//if ((p=strchr(msg,'\r'))) *p='\0';
-		p := *msg
-		*msg = p[:strings.Index(*msg, "\r")]
+		*msg = fmt.Sprintf("%.*s", nb, ntrip.buff)
+		if i := strings.Index(*msg, "\r"); i >= 0 {
+			*msg = (*msg)[:i]
+		}
 		Tracet(3, "rspntrip_s: %s nb=%d\n", *msg, ntrip.nb)
-		ntrip.nb = 0
-		ntrip.buff = ""
-		ntrip.state = 0
-		ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
-	} else if ntrip.nb >= NTRIP_MAXRSP { /* buffer overflow */
-		*msg = "response overflow"
+		ntrip.errorNtrip(*msg, msg)
+		return 0
+	}
+	if ntrip.nb >= NTRIP_MAXRSP { /* buffer overflow */
 		Tracet(3, "rspntrip_s: response overflow nb=%d\n", ntrip.nb)
-		ntrip.nb = 0
-		ntrip.buff = ""
-		ntrip.state = 0
-		ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
+		ntrip.errorNtrip("response overflow", msg)
 	}
 	Tracet(5, "rspntrip_s: exit state=%d nb=%d\n", ntrip.state, ntrip.nb)
 	return 0
@@ -1535,13 +1955,12 @@ func (ntrip *NTrip) ResponseNtrip_s(msg *string) int {
 /* test ntrip client response ------------------------------------------------*/
 func (ntrip *NTrip) ResponseNtrip_c(msg *string) int {
 	var idx int
-	//char *p,*q;
 
 	Tracet(3, "rspntrip_c: state=%d nb=%d\n", ntrip.state, ntrip.nb)
 	ntrip.buff = ntrip.buff[:ntrip.nb]
 	Tracet(5, "rspntrip_c: n=%d buff=\n%s\n", ntrip.nb, ntrip.buff)
 
-	if idx = strings.Index(string(ntrip.buff[:]), NTRIP_RSP_OK_CLI); idx >= 0 { /* ok */
+	if idx = strings.Index(ntrip.buff, NTRIP_RSP_OK_CLI); idx >= 0 { /* ok */
 		idx += len(NTRIP_RSP_OK_CLI)
 		ntrip.nb -= idx
 		ntrip.buff = ntrip.buff[idx:]
@@ -1550,38 +1969,20 @@ func (ntrip *NTrip) ResponseNtrip_c(msg *string) int {
 		Tracet(3, "rspntrip_c: response ok nb=%d\n", ntrip.nb)
 		return 1
 	}
-	if idx = strings.Index(string(ntrip.buff[:]), NTRIP_RSP_SRCTBL); idx >= 0 { /* source table */
+	if idx = strings.Index(ntrip.buff, NTRIP_RSP_SRCTBL); idx >= 0 { /* source table */
 		if len(ntrip.mntpnt) == 0 { /* source table request */
 			ntrip.state = 2
 			*msg = "source table received"
 			Tracet(3, "rspntrip_c: receive source table nb=%d\n", ntrip.nb)
 			return 1
 		}
-		*msg = "no mountp. reconnect..."
 		Tracet(2, "rspntrip_c: no mount point nb=%d\n", ntrip.nb)
-		ntrip.nb = 0
-		ntrip.buff = ""
-		ntrip.state = 0
-		ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
-	} else if idx = strings.Index(string(ntrip.buff[:]), NTRIP_RSP_HTTP); idx >= 0 { /* http response */
-		if q := strings.Index(ntrip.buff[idx:], "\r"); q >= 0 {
-			ntrip.buff = ntrip.buff[:q]
-		} else if len(ntrip.buff) > 128 {
-			ntrip.buff = ntrip.buff[:128]
-		}
-		*msg = ntrip.buff[idx:]
-		Tracet(3, "rspntrip_s: %s nb=%d\n", *msg, ntrip.nb)
-		ntrip.nb = 0
-		ntrip.buff = ""
-		ntrip.state = 0
-		ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
+		ntrip.errorNtrip("no mountp. reconnect...", msg)
+	} else if idx = strings.Index(ntrip.buff, NTRIP_RSP_HTTP); idx >= 0 { /* http response */
+		return ntrip.ResponseNtrip_http(idx, msg)
 	} else if ntrip.nb >= NTRIP_MAXRSP { /* buffer overflow */
-		*msg = "response overflow"
-		Tracet(2, "rspntrip_s: response overflow nb=%d\n", ntrip.nb)
-		ntrip.nb = 0
-		ntrip.buff = ""
-		ntrip.state = 0
-		ntrip.tcp.svr.DisconnectTcp(ntrip.tcp.tirecon)
+		Tracet(2, "rspntrip_c: response overflow nb=%d\n", ntrip.nb)
+		ntrip.errorNtrip("response overflow", msg)
 	}
 	Tracet(5, "rspntrip_c: exit state=%d nb=%d\n", ntrip.state, ntrip.nb)
 	return 0
@@ -1590,7 +1991,6 @@ func (ntrip *NTrip) ResponseNtrip_c(msg *string) int {
 /* wait ntrip request/response -----------------------------------------------*/
 func (ntrip *NTrip) WaitNtrip(msg *string) int {
 	var n, ret int
-	//char *p;
 
 	Tracet(4, "waitntrip: state=%d nb=%d\n", ntrip.state, ntrip.nb)
 
@@ -1614,15 +2014,13 @@ func (ntrip *NTrip) WaitNtrip(msg *string) int {
 		Tracet(3, "waitntrip: state=%d nb=%d\n", ntrip.state, ntrip.nb)
 	}
 	if ntrip.state == 1 { /* read response */
-		//p := []uint8(ntrip.buff[ntrip.nb:])
 		p := make([]uint8, NTRIP_MAXRSP)
-		if n = ntrip.tcp.ReadTcpClient(p, NTRIP_MAXRSP-ntrip.nb-1, msg); n == 0 {
+		if n = ntrip.tcp.ReadTcpClient(p, NTRIP_MAXRSP-ntrip.nb-1, msg); n <= 0 {
 			Tracet(5, "waitntrip: readtcp n=%d\n", n)
 			return 0
 		}
-		ntrip.buff += string(p)
+		ntrip.buff += string(p[:n])
 		ntrip.nb += n
-		//		ntrip.buff = ntrip.buff[:ntrip.nb]
 
 		/* wait response */
 		if ntrip.ctype == 0 {
@@ -1633,30 +2031,116 @@ func (ntrip *NTrip) WaitNtrip(msg *string) int {
 	return 1
 }
 
-/* open ntrip ----------------------------------------------------------------*/
+/* decode chunked transfer encoding ------------------------------------------*/
+func (ntrip *NTrip) dechunk(buff []uint8, n int) int {
+	var i, nb int
+
+	for i = 0; i < n; i++ {
+		c := buff[i]
+		switch ntrip.cst {
+		case 0: /* chunk size */
+			switch {
+			case c >= '0' && c <= '9':
+				ntrip.cnb = ntrip.cnb<<4 + int(c-'0')
+			case c >= 'A' && c <= 'F':
+				ntrip.cnb = ntrip.cnb<<4 + int(c-'A'+10)
+			case c >= 'a' && c <= 'f':
+				ntrip.cnb = ntrip.cnb<<4 + int(c-'a'+10)
+			case c == ';':
+				ntrip.cst = 4 /* chunk extension */
+			case c == '\n':
+				ntrip.cst = 1
+				if ntrip.cnb == 0 {
+					ntrip.cst = 3 /* last chunk */
+				}
+			}
+		case 1: /* chunk data */
+			buff[nb] = c
+			nb++
+			if ntrip.cnb--; ntrip.cnb <= 0 {
+				ntrip.cst = 2
+			}
+		case 2: /* end of chunk data */
+			if c == '\n' {
+				ntrip.cst, ntrip.cnb = 0, 0
+			}
+		case 4: /* chunk extension */
+			if c == '\n' {
+				ntrip.cst = 1
+				if ntrip.cnb == 0 {
+					ntrip.cst = 3
+				}
+			}
+		}
+	}
+	return nb
+}
+
+/* open ntrip ------------------------------------------------------------------
+* open ntrip server or client
+* args   : string path      I   ntrip path
+*                               [ntrip{1|2}://][user[:passwd]@]addr[:port][/mntpnt[:str]]
+*                               [::tls[::...]]
+*          int    ctype     I   type (0:server,1:client)
+*          string *msg      O   error message
+* return : ntrip control (nil: error)
+* notes  : with prefix ntrip1:// or ntrip2://, ntrip version is fixed to 1.0 or
+*          2.0. without prefix, the version is negotiated. ntrip client sends
+*          ntrip 2.0 request and accepts both 1.0 and 2.0 responses. ntrip
+*          server uses ntrip 2.0 (POST) if user specified, otherwise 1.0
+*          (SOURCE)
+*          tls options are same as tcp client (see decodetls()). with tls
+*          options, default port is 443. via proxy server, tls is used for
+*          the connection to the proxy server.
+*-----------------------------------------------------------------------------*/
 func OpenNtrip(path string, ctype int, msg *string) *NTrip {
+	ntrip := &NTrip{ctype: ctype} /* 0:server,1:client */
+	if ntrip.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return ntrip
+}
+
+/* open ntrip port (type set by caller) --------------------------------------*/
+func (ntrip *NTrip) Open(path string, mode int, msg *string) int {
 	var (
-		ntrip             = new(NTrip)
-		addr, port, tpath string
+		addr, port, tpath, opts string
+		conf                    *tls.Config
 	)
 
-	Tracet(3, "openntrip: path=%s type=%d\n", path, ctype)
+	Tracet(3, "openntrip: path=%s type=%d\n", path, ntrip.ctype)
 
 	ntrip.state = 0
-	ntrip.ctype = ctype /* 0:server,1:client */
 	ntrip.nb = 0
+
+	/* ntrip version by path prefix */
+	if strings.HasPrefix(path, "ntrip1://") {
+		ntrip.ver, path = 1, path[9:]
+	} else if strings.HasPrefix(path, "ntrip2://") {
+		ntrip.ver, path = 2, path[9:]
+	}
+	/* tls options */
+	if i := strings.Index(path, "::"); i >= 0 {
+		path, opts = path[:i], path[i:]
+	}
+	if topts := opts; decodetls(&topts, 1, &conf, msg) == 0 {
+		return 0
+	}
 	/* decode tcp/ntrip path */
 	DecodeTcpPath(path, &addr, &port, &ntrip.user, &ntrip.passwd, &ntrip.mntpnt, &ntrip.str)
 
 	/* use default port if no port specified */
 	if len(port) == 0 {
-		if ctype > 0 {
+		if conf != nil {
+			port = strconv.Itoa(NTRIP_TLS_PORT)
+		} else if ntrip.ctype > 0 {
 			port = strconv.Itoa(NTRIP_CLI_PORT)
 		} else {
 			port = strconv.Itoa(NTRIP_SVR_PORT)
 		}
 	}
 	tpath = fmt.Sprintf("%s:%s", addr, port)
+	ntrip.host = tpath
 
 	/* ntrip access via proxy server */
 	if len(proxyaddr) > 0 {
@@ -1664,12 +2148,11 @@ func OpenNtrip(path string, ctype int, msg *string) *NTrip {
 		tpath = fmt.Sprintf("%.*s", MAXSTRPATH-1, proxyaddr)
 	}
 	/* open tcp client stream */
-	if ntrip.tcp = OpenTcpClient(tpath, msg); ntrip.tcp == nil {
+	if ntrip.tcp = OpenTcpClient(tpath+opts, msg); ntrip.tcp == nil {
 		Tracet(2, "openntrip: opentcp error\n")
-		ntrip = nil
-		return nil
+		return 0
 	}
-	return ntrip
+	return 1
 }
 
 /* close ntrip ---------------------------------------------------------------*/
@@ -1695,22 +2178,41 @@ func (ntrip *NTrip) ReadNtrip(buff []uint8, n int, msg *string) int {
 		if ntrip.nb <= n {
 			nb = ntrip.nb
 		}
-		copy(buff, []uint8(ntrip.buff)[ntrip.nb-nb:ntrip.nb])
-		ntrip.nb = 0
-		ntrip.buff = ""
-		return nb
+		copy(buff, ntrip.buff[:nb])
+		ntrip.buff = ntrip.buff[nb:]
+		ntrip.nb -= nb
+	} else if nb = ntrip.tcp.ReadTcpClient(buff, n, msg); nb <= 0 {
+		return 0
 	}
-	return ntrip.tcp.ReadTcpClient(buff, n, msg)
+	if ntrip.chunk != 0 { /* ntrip 2.0 chunked transfer encoding */
+		nb = ntrip.dechunk(buff, nb)
+	}
+	return nb
 }
 
 /* write ntrip ---------------------------------------------------------------*/
 func (ntrip *NTrip) WriteNtrip(buff []uint8, n int, msg *string) int {
 	Tracet(3, "writentrip: n=%d\n", n)
 
+	/* save nmea GGA for Ntrip-GGA header */
+	if ntrip.ctype == 1 && n > 6 && buff[0] == '$' && string(buff[3:6]) == "GGA" {
+		gga := string(buff[:n])
+		if i := strings.IndexAny(gga, "\r\n"); i >= 0 {
+			gga = gga[:i]
+		}
+		ntrip.gga = gga
+	}
 	if ntrip.WaitNtrip(msg) == 0 {
 		return 0
 	}
-
+	if ntrip.ctype == 0 && ntrip.rver == 2 { /* ntrip 2.0 chunked transfer encoding */
+		p := []uint8(fmt.Sprintf("%X\r\n", n))
+		p = append(append(p, buff[:n]...), '\r', '\n')
+		if ntrip.tcp.WriteTcpClient(p, len(p), msg) != len(p) {
+			return 0
+		}
+		return n
+	}
 	return ntrip.tcp.WriteTcpClient(buff, n, msg)
 }
 
@@ -1727,7 +2229,6 @@ func (ntrip *NTrip) StateNtrip() int {
 
 /* get extended state ntrip --------------------------------------------------*/
 func (ntrip *NTrip) StatExNtrip(msg *string) int {
-	//char *p=msg;
 	state := ntrip.StateNtrip()
 
 	*msg += "ntrip:\n"
@@ -1737,6 +2238,8 @@ func (ntrip *NTrip) StatExNtrip(msg *string) int {
 	}
 	*msg += fmt.Sprintf("  state   = %d\n", state)
 	*msg += fmt.Sprintf("  type    = %d\n", ntrip.ctype)
+	*msg += fmt.Sprintf("  ver     = %d (%d)\n", ntrip.ver, ntrip.rver)
+	*msg += fmt.Sprintf("  chunk   = %d\n", ntrip.chunk)
 	*msg += fmt.Sprintf("  nb      = %d\n", ntrip.nb)
 	*msg += fmt.Sprintf("  url     = %s\n", ntrip.url)
 	*msg += fmt.Sprintf("  mntpnt  = %s\n", ntrip.mntpnt)
@@ -1748,15 +2251,37 @@ func (ntrip *NTrip) StatExNtrip(msg *string) int {
 	return state
 }
 
+/* stream port interface of ntrip --------------------------------------------*/
+func (ntrip *NTrip) Read(buff []byte, n int, msg *string) int  { return ntrip.ReadNtrip(buff, n, msg) }
+func (ntrip *NTrip) Write(buff []byte, n int, msg *string) int { return ntrip.WriteNtrip(buff, n, msg) }
+func (ntrip *NTrip) State() int                                { return ntrip.StateNtrip() }
+func (ntrip *NTrip) StateX(msg *string) int                    { return ntrip.StatExNtrip(msg) }
+func (ntrip *NTrip) Close()                                    { ntrip.CloseNtrip() }
+
 /* open ntrip-caster ---------------------------------------------------------*/
-func OpenNtripc(path string, msg *string) *NTripc {
+func OpenNtripc(path string, ctype int, msg *string) *NTripc {
+	ntripc := new(NTripc)
+	mode := STR_MODE_R
+	if ctype > 0 {
+		mode = STR_MODE_W
+	}
+	if ntripc.Open(path, mode, msg) == 0 {
+		return nil
+	}
+	return ntripc
+}
+
+/* open ntrip-caster port (type by stream mode) ------------------------------*/
+func (ntripc *NTripc) Open(path string, mode int, msg *string) int {
 	var (
-		ntripc      *NTripc = new(NTripc)
-		port, tpath string
+		port string
 	)
-	ntripc.con = make([]NTripc_con, MAXCLI)
+	Tracet(3, "openntripc: path=%s mode=%d\n", path, mode)
 
-	Tracet(3, "openntripc: path=%s\n", path)
+	ntripc.ctype = 0 /* 0:server,1:client */
+	if mode&STR_MODE_W != 0 {
+		ntripc.ctype = 1
+	}
 
 	/* decode tcp/ntrip path */
 	DecodeTcpPath(path, nil, &port, &ntripc.user, &ntripc.passwd, &ntripc.mntpnt,
@@ -1764,280 +2289,108 @@ func OpenNtripc(path string, msg *string) *NTripc {
 
 	if len(ntripc.mntpnt) == 0 {
 		Tracet(2, "openntripc: no mountpoint path=%s\n", path)
-		ntripc = nil
-		return nil
+		return 0
 	}
 	/* use default port if no port specified */
 	if len(port) == 0 {
 		port = strconv.Itoa(NTRIP_CLI_PORT)
 	}
-	tpath = fmt.Sprintf(":%s", port)
-
-	/* open tcp server stream */
-	if ntripc.tcp = OpenTcpSvr(tpath, msg); ntripc.tcp == nil {
-		Tracet(2, "openntripc: opentcpsvr error port=%s\n", port)
-		ntripc = nil
-		return nil
+	/* open caster shared by streams with the same port */
+	if ntripc.cas = opencaster_shared(port, msg); ntripc.cas == nil {
+		Tracet(2, "openntripc: caster open error port=%s\n", port)
+		return 0
 	}
-	return ntripc
+	if ntripc.cas.AddMount(ntripc.mntpnt, ntripc.srctbl, 2-ntripc.ctype) == 0 {
+		*msg = fmt.Sprintf("mountpoint taken (%s)", ntripc.mntpnt)
+		closecaster_shared(ntripc.cas)
+		return 0
+	}
+	/* add user to accept client (client) or source (server) */
+	if len(ntripc.passwd) > 0 {
+		ntripc.cas.AddUser(ntripc.ctype, ntripc.user, ntripc.passwd, ntripc.mntpnt)
+	}
+	ntripc.state = 1
+	return 1
 }
 
 /* close ntrip-caster --------------------------------------------------------*/
 func (ntripc *NTripc) CloseNtripc() {
 	Tracet(3, "closentripc: state=%d\n", ntripc.state)
 
-	ntripc.tcp.CloseTcpSvr()
-	ntripc = nil
+	if len(ntripc.passwd) > 0 {
+		ntripc.cas.DelUser(ntripc.ctype, ntripc.user, ntripc.passwd, ntripc.mntpnt)
+	}
+	ntripc.cas.DelMount(ntripc.mntpnt)
+	closecaster_shared(ntripc.cas)
+	ntripc.state = 0
 }
 
-/* disconnect ntrip-caster connection ----------------------------------------*/
-func (ntripc *NTripc) DisconnectNtripc(i int) {
-	Tracet(3, "discon_ntripc: i=%d\n", i)
+/* read ntrip-caster ---------------------------------------------------------*/
+func (ntripc *NTripc) ReadNtripc(buff []byte, n int, msg *string) int {
+	Tracet(4, "readntripc:\n")
 
-	ntripc.tcp.cli[i].DisconnectTcp(ticonnect)
-	ntripc.con[i].nb = 0
-	ntripc.con[i].buff = ""
-	ntripc.con[i].state = 0
+	return ntripc.cas.ReadCaster(ntripc.mntpnt, buff, n)
 }
 
-/* send ntrip source table ---------------------------------------------------*/
-func (ntripc *NTripc) Send_SrcTbl(sock net.Conn) {
-	var srctbl, buff string
+/* write ntrip-caster --------------------------------------------------------*/
+func (ntripc *NTripc) WriteNtripc(buff []byte, n int, msg *string) int {
+	Tracet(4, "writentripc: n=%d\n", n)
 
-	srctbl = fmt.Sprintf("STR;%s;%s\r\n%s\r\n", ntripc.mntpnt, ntripc.srctbl,
-		NTRIP_RSP_TBLEND)
-	buff += NTRIP_RSP_SRCTBL
-	buff += fmt.Sprintf("Server: %s %s %s\r\n", "RTKLIB", VER_GNSSGO, PATCH_LEVEL)
-	buff += fmt.Sprintf("Date: %s UTC\r\n", TimeStr(TimeGet(), 0))
-	buff += "Connection: close\r\n"
-	buff += "Content-Type: text/plain\r\n"
-	buff += fmt.Sprintf("Content-Length: %d\r\n\r\n", len(srctbl))
-	Send_nb(sock, []byte(buff), len(buff))
-	Send_nb(sock, []byte(srctbl), len(srctbl))
+	return ntripc.cas.WriteCaster(ntripc.mntpnt, buff, n)
 }
 
-/* test ntrip client request -------------------------------------------------*/
-func (ntripc *NTripc) RequestNtripc(i int) {
-	rsp1 := NTRIP_RSP_UNAUTH
-	rsp2 := NTRIP_RSP_OK_CLI
-	con := &ntripc.con[i]
-	var url, mntpnt, proto, user, user_pwd string
-
-	Tracet(3, "rspntripc_c i=%d\n", i)
-	con.buff = con.buff[:con.nb]
-	Tracet(5, "rspntripc_c: n=%d,buff=\n%s\n", con.nb, con.buff)
-
-	if con.nb >= NTRIP_MAXRSP-1 { /* buffer overflow */
-		Tracet(2, "rsp_ntripc_c: request buffer overflow\n")
-		ntripc.DisconnectNtripc(i)
-		return
-	}
-	headers := ParseHttp(con.buff)
-	_, ok1 := headers.GetHead("GET")
-	_, ok2 := headers.GetHead("User-Agent")
-	/* test GET and User-Agent */
-	// idx1 = strings.Index(con.buff, "GET")
-	// if idx1 >= 0 {
-	// 	idx2 = strings.Index(con.buff[idx1:], "\r\n")
-	// }
-	if !ok1 || !ok2 {
-		Tracet(2, "rsp_ntripc_c: NTRIP request error\n")
-		ntripc.DisconnectNtripc(i)
-		return
+/* get state ntrip-caster ----------------------------------------------------*/
+func (ntripc *NTripc) StateNtripc() int {
+	if ntripc == nil {
+		return 0
 	}
-	// if idx1,idx2 = strings.Index(con.buff, "GET"), strings.Index(con.buff, "User-Agent:");idx1 == -1 || idx2 == -1 {
-	// // if (!(p=strstr((char *)con.buff,"GET"))||!(q=strstr(p,"\r\n"))||
-	// //     !(q=strstr(q,"User-Agent:"))||!strstr(q,"\r\n")) {
-	//     tracet(2,"rsp_ntripc_c: NTRIP request error\n");
-	//     discon_ntripc(ntripc,i);
-	//     return;
-	// }
-	/* test protocol */
-	if headers.ParseBody("GET", &url, &proto) != nil || strings.Compare(proto, "HTTP/1.0") != 0 ||
-		strings.Compare(proto, "HTTP/1.1") != 0 {
-		// if (sscanf(p,"GET %255s %255s",url,proto)<2||
-		//     (strcmp(proto,"HTTP/1.0")&&strcmp(proto,"HTTP/1.1"))) {
-		//     tracet(2,"rsp_ntripc_c: NTRIP request error proto=%s\n",proto);
-		//     discon_ntripc(ntripc,i);
-		return
+	if ntripc.state > 0 {
+		ntripc.state = ntripc.cas.StateCaster()
 	}
-	mntpnt = strings.TrimPrefix(url, "/")
-	//   if ((p=strchr(url,'/'))) strcpy(mntpnt,p+1);
+	return ntripc.state
+}
 
-	/* test mountpoint */
-	if len(mntpnt) == 0 || strings.Compare(mntpnt, ntripc.mntpnt) != 0 {
-		//   if (!*mntpnt||strcmp(mntpnt,ntripc.mntpnt)) {
-		Tracet(2, "rsp_ntripc_c: no mountpoint %s\n", mntpnt)
-
-		/* send source table */
-		ntripc.Send_SrcTbl(ntripc.tcp.cli[i].sock)
-		ntripc.DisconnectNtripc(i)
-		return
-	}
-	/* test authentication */
-	if len(ntripc.passwd) > 0 {
-		user = fmt.Sprintf("%s:%s", ntripc.user, ntripc.passwd)
-		q := user_pwd
-		q += "Authorization: Basic "
-		q += encbase64([]rune(q), []byte(user), len(user))
-		var pwd string
-		if headers.ParseBody("Authorization", &pwd) != nil || strings.Compare(pwd, q) != 0 {
-			// if (!(p=strstr((char *)con.buff,"Authorization:"))||
-			//     strncmp(p,user_pwd,strlen(user_pwd))) {
-			Tracet(2, "rsp_ntripc_c: authroziation error\n")
-			Send_nb(ntripc.tcp.cli[i].sock, []byte(rsp1), len(rsp1))
-			ntripc.DisconnectNtripc(i)
-			return
-		}
-	}
-	/* send OK response */
-	Send_nb(ntripc.tcp.cli[i].sock, []byte(rsp2), len(rsp2))
-
-	con.state = 1
-	con.mntpnt = mntpnt
-}
-
-/* handle ntrip client connect request ---------------------------------------*/
-func (ntripc *NTripc) WaitNtripc(msg *string) {
-	var (
-		buff       []byte
-		i, n, nmax int
-	)
-
-	Tracet(4, "wait_ntripc\n")
-
-	ntripc.state = ntripc.tcp.svr.state
-
-	if ntripc.tcp.WaitTcpSvr(msg) == 0 {
-		return
-	}
-
-	for i = 0; i < len(ntripc.tcp.cli); i++ {
-		if ntripc.tcp.cli[i].state != 2 || ntripc.con[i].state > 0 {
-			continue
-		}
-
-		/* receive ntrip client request */
-		buff = []byte(ntripc.con[i].buff[ntripc.con[i].nb:])
-		nmax = NTRIP_MAXRSP - ntripc.con[i].nb - 1
-
-		if n = Recv_nb(ntripc.tcp.cli[i].sock, buff, nmax); n == -1 {
-			if err := errsock(); err != nil {
-				Tracet(2, "wait_ntripc: recv error sock=%d err=%s\n",
-					ntripc.tcp.cli[i].sock, err.Error())
-			}
-			ntripc.DisconnectNtripc(i)
-			continue
-		}
-		if n <= 0 {
-			continue
-		}
-
-		/* test ntrip client request */
-		ntripc.con[i].nb += n
-		ntripc.RequestNtripc(i)
-	}
-}
-
-/* read ntrip-caster ---------------------------------------------------------*/
-func (ntripc *NTripc) ReadNtripc(buff []byte, n int, msg *string) int {
-	var i, nr int
-
-	Tracet(4, "readntripc:\n")
-
-	ntripc.WaitNtripc(msg)
-
-	for i = 0; i < len(ntripc.con); i++ {
-		if ntripc.con[i].state == 0 {
-			continue
-		}
-
-		nr = Recv_nb(ntripc.tcp.cli[i].sock, buff, n)
-
-		if nr < 0 {
-			if err := errsock(); err != nil {
-				Tracet(2, "readntripc: recv error i=%d sock=%d err=%s\n", i,
-					ntripc.tcp.cli[i].sock, err.Error())
-			}
-			ntripc.DisconnectNtripc(i)
-		} else if nr > 0 {
-			ntripc.tcp.cli[i].tact = TickGet()
-			return nr
-		}
-	}
-	return 0
-}
-
-/* write ntrip-caster --------------------------------------------------------*/
-func (ntripc *NTripc) WriteNtripc(buff []byte, n int, msg *string) int {
-	var i, ns int
-
-	Tracet(4, "writentripc: n=%d\n", n)
-
-	ntripc.WaitNtripc(msg)
-
-	for i = 0; i < len(ntripc.con); i++ {
-		if ntripc.con[i].state == 0 {
-			continue
-		}
-
-		ns = Send_nb(ntripc.tcp.cli[i].sock, buff, n)
-
-		if ns < n {
-			if err := errsock(); err != nil {
-				Tracet(2, "writentripc: send error i=%d sock=%d err=%s\n", i,
-					ntripc.tcp.cli[i].sock, err.Error())
-			}
-			ntripc.DisconnectNtripc(i)
-		} else {
-			ntripc.tcp.cli[i].tact = TickGet()
-		}
-	}
-	return ns
-}
-
-/* get state ntrip-caster ----------------------------------------------------*/
-func (ntripc *NTripc) StateNtripc() int {
-	if ntripc == nil {
-		return 0
-	}
-	return ntripc.state
-}
-
-/* get extended state ntrip-caster -------------------------------------------*/
-func (ntripc *NTripc) StatExNtripc(msg *string) int {
-	var i int
-	state := ntripc.StateNtripc()
+/* get extended state ntrip-caster -------------------------------------------*/
+func (ntripc *NTripc) StatExNtripc(msg *string) int {
+	state := ntripc.StateNtripc()
 
 	*msg += "ntripc:\n"
-	*msg += fmt.Sprintf("  state   = %d\n", ntripc.state)
+	*msg += fmt.Sprintf("  state   = %d\n", state)
 	if state == 0 {
 		return 0
 	}
-
 	*msg += fmt.Sprintf("  type    = %d\n", ntripc.ctype)
 	*msg += fmt.Sprintf("  mntpnt  = %s\n", ntripc.mntpnt)
 	*msg += fmt.Sprintf("  user    = %s\n", ntripc.user)
 	*msg += fmt.Sprintf("  passwd  = %s\n", ntripc.passwd)
 	*msg += fmt.Sprintf("  srctbl  = %s\n", ntripc.srctbl)
-	*msg += "  svr:\n"
-	ntripc.tcp.svr.StatExTcp(msg)
-	for i = 0; i < len(ntripc.tcp.cli); i++ {
-		if ntripc.tcp.cli[i].state == 0 {
-			continue
-		}
-		*msg += fmt.Sprintf("  cli#%d:\n", i)
-		ntripc.tcp.cli[i].StatExTcp(msg)
-		*msg += fmt.Sprintf("    mntpnt= %s\n", ntripc.con[i].mntpnt)
-		*msg += fmt.Sprintf("    nb    = %d\n", ntripc.con[i].nb)
-	}
+	ntripc.cas.StatExCaster(msg)
 	return state
 }
 
+/* stream port interface of ntrip-caster -------------------------------------*/
+func (ntripc *NTripc) Read(buff []byte, n int, msg *string) int {
+	return ntripc.ReadNtripc(buff, n, msg)
+}
+func (ntripc *NTripc) Write(buff []byte, n int, msg *string) int {
+	return ntripc.WriteNtripc(buff, n, msg)
+}
+func (ntripc *NTripc) State() int             { return ntripc.StateNtripc() }
+func (ntripc *NTripc) StateX(msg *string) int { return ntripc.StatExNtripc(msg) }
+func (ntripc *NTripc) Close()                 { ntripc.CloseNtripc() }
+
 /* generate udp socket -------------------------------------------------------*/
 func GenUdp(ctype, port int, saddr string, msg *string) *UdpConn {
+	udp := new(UdpConn)
+	if udp.genudp(ctype, port, saddr, msg) == 0 {
+		return nil
+	}
+	return udp
+}
+
+/* generate udp socket in place ----------------------------------------------*/
+func (udp *UdpConn) genudp(ctype, port int, saddr string, msg *string) int {
 	var (
-		udp *UdpConn = new(UdpConn)
 		err error
 	)
 	//  struct hostent *hp;
@@ -2076,7 +2429,7 @@ func GenUdp(ctype, port int, saddr string, msg *string) *UdpConn {
 			udp.sock.Close()
 			udp.state = 0
 			//		udp.sock = nil
-			return nil
+			return 0
 		}
 
 		// #ifdef SVR_REUSEADDR
@@ -2096,7 +2449,7 @@ func GenUdp(ctype, port int, saddr string, msg *string) *UdpConn {
 			udp.sock.Close()
 			udp.state = 0
 			//		udp.sock = nil
-			return nil
+			return 0
 		}
 		// if (!strcmp(saddr,"255.255.255.255")&&
 		//     setsockopt(udp.sock,SOL_SOCKET,SO_BROADCAST,(const char *)&opt,
@@ -2112,27 +2465,43 @@ func GenUdp(ctype, port int, saddr string, msg *string) *UdpConn {
 		// }
 		// memcpy(&udp.addr.sin_addr,hp.h_addr,hp.h_length);
 	}
-	return udp
+	return 1
 }
 
-/* open udp server -----------------------------------------------------------*/
-func OpenUdpSvr(path string, msg *string) *UdpConn {
+/* open udp port (type set by caller) ----------------------------------------*/
+func (udp *UdpConn) Open(path string, mode int, msg *string) int {
 	var (
-		sport string
-		port  int
+		sport, saddr string
+		port         int
+		err          error
 	)
 
-	Tracet(3, "openudpsvr: path=%s\n", path)
+	Tracet(3, "openudp: path=%s type=%d\n", path, udp.ctype)
 
-	DecodeTcpPath(path, nil, &sport, nil, nil, nil, nil)
+	if udp.ctype == 0 { /* udp server */
+		DecodeTcpPath(path, nil, &sport, nil, nil, nil, nil)
 
-	port, _ = strconv.Atoi(sport)
-	// if (sscanf(sport,"%d",&port)<1) {
-	//     sprintf(msg,"port error: %s",sport);
-	//     tracet(2,"openudpsvr: port error port=%s\n",port);
-	//     return NULL;
-	// }
-	return GenUdp(0, port, "localhost", msg)
+		port, _ = strconv.Atoi(sport)
+		return udp.genudp(0, port, "localhost", msg)
+	}
+	/* udp client */
+	DecodeTcpPath(path, &saddr, &sport, nil, nil, nil, nil)
+
+	if port, err = strconv.Atoi(sport); err != nil {
+		*msg += fmt.Sprintf("port error: %s", sport)
+		Tracet(2, "openudpcli: port error port=%s\n", sport)
+		return 0
+	}
+	return udp.genudp(1, port, saddr, msg)
+}
+
+/* open udp server -----------------------------------------------------------*/
+func OpenUdpSvr(path string, msg *string) *UdpConn {
+	udpsvr := &UdpConn{ctype: 0}
+	if udpsvr.Open(path, STR_MODE_R, msg) == 0 {
+		return nil
+	}
+	return udpsvr
 }
 
 /* close udp server ----------------------------------------------------------*/
@@ -2192,23 +2561,11 @@ func (udpsvr *UdpConn) StatExUdpSvr(msg *string) int {
 
 /* open udp client -----------------------------------------------------------*/
 func OpenUdpClient(path string, msg *string) *UdpConn {
-	var (
-		sport, saddr string
-		port         int
-		err          error
-	)
-
-	Tracet(3, "openudpsvr: path=%s\n", path)
-
-	DecodeTcpPath(path, &saddr, &sport, nil, nil, nil, nil)
-
-	if port, err = strconv.Atoi(sport); err != nil {
-		//if (sscanf(sport,"%d",&port)<1) {
-		*msg += fmt.Sprintf("port error: %s", sport)
-		Tracet(2, "openudpcli: port error port=%s\n", sport)
+	udpcli := &UdpConn{ctype: 1}
+	if udpcli.Open(path, STR_MODE_W, msg) == 0 {
 		return nil
 	}
-	return GenUdp(1, port, saddr, msg)
+	return udpcli
 }
 
 /* close udp client ----------------------------------------------------------*/
@@ -2254,6 +2611,36 @@ func (udpcli *UdpConn) StateXUdpClient(msg *string) int {
 	return state
 }
 
+/* stream port interface of udp ----------------------------------------------*/
+func (udp *UdpConn) Read(buff []byte, n int, msg *string) int {
+	if udp.ctype != 0 {
+		return 0
+	}
+	return udp.ReadUdpSvr(buff, n, msg)
+}
+func (udp *UdpConn) Write(buff []byte, n int, msg *string) int {
+	if udp.ctype != 1 {
+		return 0
+	}
+	return udp.WriteUdpClient(buff, n, msg)
+}
+func (udp *UdpConn) State() int {
+	return udp.StateUdpSvr()
+}
+func (udp *UdpConn) StateX(msg *string) int {
+	if udp.ctype == 0 {
+		return udp.StatExUdpSvr(msg)
+	}
+	return udp.StateXUdpClient(msg)
+}
+func (udp *UdpConn) Close() {
+	if udp.ctype == 0 {
+		udp.CloseUdpSvr()
+	} else {
+		udp.CloseUdpClient()
+	}
+}
+
 /* decode ftp path -----------------------------------------------------------*/
 func DecodeFtpPath(path string, addr, file, user, passwd *string, topts []int) {
 	var (
@@ -2400,13 +2787,12 @@ func ftpthread(ftp *FtpConn) {
 
 	//   ftp_t *ftp=(ftp_t *)arg;
 	var (
-		fp                              *os.File
-		time                            Gtime
-		remote, local, tmpfile, errfile string
-		cmd, env, opt                   string
-		proxyopt, proto                 string
-		ret, idx                        int
-		p                               string
+		fp                     *os.File
+		time                   Gtime
+		remote, local, tmpfile string
+		proto                  string = "ftp"
+		idx                    int
+		p                      string
 	)
 
 	Tracet(3, "ftpthread:\n")
@@ -2429,7 +2815,6 @@ func ftpthread(ftp *FtpConn) {
 	}
 	//    if ((p=strrchr(remote,'/'))) p++; else p=remote;
 	local = fmt.Sprintf("%.768s%s%.254s", localdir, FILEPATHSEP, p)
-	errfile = fmt.Sprintf("%.1019s.err", local)
 
 	/* if local file exist, skip download */
 	tmpfile = local
@@ -2448,38 +2833,23 @@ func ftpthread(ftp *FtpConn) {
 		ftp.state = 2
 		return
 	}
-
-	if len(proxyaddr) > 0 {
-		if ftp.proto > 0 {
-			proto = "http"
-		} else {
-			proto = "ftp"
-		}
-
-		env = fmt.Sprintf("set %.4s_proxy=http://%.998s & ", proto, proxyaddr)
-		proxyopt = "--proxy=on "
-	}
-	/* download command (ref [2]) */
-	if ftp.proto == 0 { /* ftp */
-		opt = fmt.Sprintf("--ftp-user=%.32s --ftp-password=%.32s --glob=off --passive-ftp %.32s -t 1 -T %d -O \"%.768s\"",
-			ftp.user, ftp.passwd, proxyopt, FTP_TIMEOUT, local)
-		cmd = fmt.Sprintf("%s%s %s \"ftp://%s/%s\" 2> \"%.768s\"\n", env, FTP_CMD, opt,
-			ftp.addr, remote, errfile)
-	} else { /* http */
-		opt = fmt.Sprintf("%.32s -t 1 -T %d -O \"%.768s\"", proxyopt, FTP_TIMEOUT,
-			local)
-		cmd = fmt.Sprintf("%s%s %s \"http://%s/%s\" 2> \"%.768s\"\n", env, FTP_CMD,
-			opt, ftp.addr, remote, errfile)
-	}
-	/* execute download command */
-	if ret = ExecCmd(cmd); ret > 0 {
-		os.Remove(local)
-		Tracet(2, "execcmd error: cmd=%s ret=%d\n", cmd, ret)
-		ftp.error = ret
+	if ftp.proto > 0 {
+		proto = "http"
+	}
+	/* download file by native ftp/http client */
+	path := Path{remot: fmt.Sprintf("%s://%s/%s", proto, ftp.addr, remote), local: local}
+	sess := new_dlsess(ftp.user, ftp.passwd, proxyaddr, 0, nil)
+	ftps := make(map[string]*ftpcli)
+	err := sess.download(&path, ftp.proto, ftps)
+	for _, c := range ftps {
+		c.close()
+	}
+	if err != nil {
+		Tracet(2, "ftpthread: download error %s %v\n", path.remot, err)
+		ftp.error = 1
 		ftp.state = 3
 		return
 	}
-	os.Remove(errfile)
 
 	/* uncompress downloaded file */
 	idx = strings.LastIndex(local, ".")
@@ -2501,19 +2871,25 @@ func ftpthread(ftp *FtpConn) {
 	ftp.local = local
 	ftp.state = 2 /* ftp completed */
 
-	Tracet(3, "ftpthread: complete cmd=%s\n", cmd)
+	Tracet(3, "ftpthread: complete %s\n", path.remot)
 }
 
 /* open ftp ------------------------------------------------------------------*/
 func OpenFtp(path string, ctype int, msg *string) *FtpConn {
-	var ftp *FtpConn = new(FtpConn)
+	ftp := &FtpConn{proto: ctype} /* 0:ftp,1:http */
+	if ftp.Open(path, STR_MODE_R, msg) == 0 {
+		return nil
+	}
+	return ftp
+}
 
-	Tracet(3, "openftp: path=%s type=%d\n", path, ctype)
+/* open ftp port (protocol set by caller) ------------------------------------*/
+func (ftp *FtpConn) Open(path string, mode int, msg *string) int {
+	Tracet(3, "openftp: path=%s type=%d\n", path, ftp.proto)
 
 	*msg = ""
 
 	ftp.state = 0
-	ftp.proto = ctype
 	ftp.error = 0
 	ftp.thread = 0
 	ftp.local = ""
@@ -2524,7 +2900,7 @@ func OpenFtp(path string, ctype int, msg *string) *FtpConn {
 	/* set first download time */
 	ftp.tnext = TimeAdd(TimeGet(), 10.0)
 
-	return ftp
+	return 1
 }
 
 /* close ftp -----------------------------------------------------------------*/
@@ -2613,12 +2989,25 @@ func (ftp *FtpConn) StateXFtp(msg *string) int {
 	return ftp.StateFtp()
 }
 
+/* stream port interface of ftp/http -----------------------------------------*/
+func (ftp *FtpConn) Read(buff []byte, n int, msg *string) int  { return ftp.ReadFtp(buff, n, msg) }
+func (ftp *FtpConn) Write(buff []byte, n int, msg *string) int { return 0 }
+func (ftp *FtpConn) State() int                                { return ftp.StateFtp() }
+func (ftp *FtpConn) StateX(msg *string) int                    { return ftp.StateXFtp(msg) }
+func (ftp *FtpConn) Close()                                    { ftp.CloseFtp() }
+
 /* open memory buffer --------------------------------------------------------*/
 func OpenMemBuf(path string, msg *string) *MemBuf {
-	var (
-		membuf  *MemBuf = new(MemBuf)
-		bufsize int     = DEFAULT_MEMBUF_SIZE
-	)
+	membuf := new(MemBuf)
+	if membuf.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return membuf
+}
+
+/* open memory buffer port ---------------------------------------------------*/
+func (membuf *MemBuf) Open(path string, mode int, msg *string) int {
+	bufsize := DEFAULT_MEMBUF_SIZE
 
 	Tracet(3, "openmembuf: path=%s\n", path)
 
@@ -2633,7 +3022,7 @@ func OpenMemBuf(path string, msg *string) *MemBuf {
 	membuf.buf = make([]byte, bufsize)
 	*msg = fmt.Sprintf("membuf sizebuf=%d", bufsize)
 
-	return membuf
+	return 1
 }
 
 /* close memory buffer -------------------------------------------------------*/
@@ -2719,6 +3108,109 @@ func (membuf *MemBuf) StateXMemBuf(msg *string) int {
 	return state
 }
 
+/* stream port interface of memory buffer ------------------------------------*/
+func (membuf *MemBuf) Read(buff []byte, n int, msg *string) int {
+	return membuf.ReadMemBuf(buff, n, msg)
+}
+func (membuf *MemBuf) Write(buff []byte, n int, msg *string) int {
+	return membuf.WriteMemBuf(buff, n, msg)
+}
+func (membuf *MemBuf) State() int             { return membuf.StateMemBuf() }
+func (membuf *MemBuf) StateX(msg *string) int { return membuf.StateXMemBuf(msg) }
+func (membuf *MemBuf) Close()                 { membuf.CloseMemBuf() }
+
+/* register stream type --------------------------------------------------------
+* register stream port implementation for url scheme
+* args   : string scheme    I   url scheme of stream path (scheme://...)
+*          StreamFactory factory I function to generate new stream port
+* return : stream type (STR_???) (STR_NONE: error)
+* notes  : if the scheme has been registered, the factory is replaced and the
+*          same stream type is returned. the stream type for a new scheme is
+*          allocated as STR_USER+n in order of registration.
+*          the stream path without "scheme://" is passed to Open() of the
+*          stream port.
+*-----------------------------------------------------------------------------*/
+func RegisterStreamType(scheme string, factory StreamFactory) int {
+	Tracet(3, "registerstreamtype: scheme=%s\n", scheme)
+
+	scheme = strings.ToLower(scheme)
+	if len(scheme) == 0 || strings.ContainsAny(scheme, ":/ ") || factory == nil {
+		Tracet(2, "registerstreamtype: invalid scheme=%s\n", scheme)
+		return STR_NONE
+	}
+	lock_streamtype.Lock()
+	defer lock_streamtype.Unlock()
+
+	nuser := 0
+	for i := range streamtypes {
+		if streamtypes[i].scheme == scheme {
+			streamtypes[i].factory = factory
+			return streamtypes[i].ctype
+		}
+		if streamtypes[i].ctype >= STR_USER {
+			nuser++
+		}
+	}
+	ctype := STR_USER + nuser
+	streamtypes = append(streamtypes, stream_type{scheme, ctype, factory})
+	return ctype
+}
+
+/* get stream type by scheme ---------------------------------------------------
+* get stream type registered for url scheme
+* args   : string scheme    I   url scheme of stream path
+* return : stream type (STR_???) (STR_NONE: not registered)
+*-----------------------------------------------------------------------------*/
+func StreamType(scheme string) int {
+	scheme = strings.ToLower(scheme)
+
+	lock_streamtype.Lock()
+	defer lock_streamtype.Unlock()
+
+	for i := range streamtypes {
+		if streamtypes[i].scheme == scheme {
+			return streamtypes[i].ctype
+		}
+	}
+	return STR_NONE
+}
+
+/* get scheme by stream type ---------------------------------------------------
+* get url scheme registered for stream type
+* args   : int    ctype     I   stream type (STR_???)
+* return : url scheme ("": not registered)
+*-----------------------------------------------------------------------------*/
+func StreamScheme(ctype int) string {
+	lock_streamtype.Lock()
+	defer lock_streamtype.Unlock()
+
+	for i := range streamtypes {
+		if streamtypes[i].ctype == ctype {
+			return streamtypes[i].scheme
+		}
+	}
+	return ""
+}
+
+/* generate new stream port by stream type -----------------------------------*/
+func newstreamport(ctype int) StreamPort {
+	var factory StreamFactory
+
+	lock_streamtype.Lock()
+	for i := range streamtypes {
+		if streamtypes[i].ctype == ctype {
+			factory = streamtypes[i].factory
+			break
+		}
+	}
+	lock_streamtype.Unlock()
+
+	if factory == nil {
+		return nil
+	}
+	return factory()
+}
+
 /* initialize stream environment -----------------------------------------------
 * initialize stream environment
 * args   : none
@@ -2782,7 +3274,6 @@ func (stream *Stream) InitStream() {
 * return : status (0:error,1:ok)
 *
 * notes  : see reference [1] for NTRIP
-*          STR_FTP/HTTP needs "wget" in command search paths
 *
 * stream path ([] options):
 *
@@ -2827,10 +3318,15 @@ func (stream *Stream) InitStream() {
 *                    mpoint= NTRIP mountpoint
 *
 *   STR_NTRIPCAS [user[:passwd]@][:port]/mpoint[:srctbl]
-*                    port  = NTRIP caster client port to accept connection
-*                    user  = NTRIP caster client user to accept connection
-*                    passwd= NTRIP caster client password to accept connection
+*                    port  = NTRIP caster port to accept connection
+*                    user  = NTRIP caster client (write) or server (read)
+*                            user to accept connection
+*                    passwd= NTRIP caster client (write) or server (read)
+*                            password to accept connection
 *                    mpoint= NTRIP mountpoint
+*                    (streams with the same port share one caster which
+*                     accepts ntrip servers and clients for other mountpoints.
+*                     see StreamSetCaster() for users and sourcetable files)
 *                    srctbl= NTRIP source table entry (STR) (ref [3] 6.3)
 *                      (ID;format;format-details;carrier;nav-system;network;
 *                       country;latitude;longitude;nmea;solution;generator;
@@ -2864,6 +3360,39 @@ func (stream *Stream) InitStream() {
 *                    toff  = download time offset (s)
 *                    tret  = download retry interval (s) (0:no retry)
 *
+*   STR_MQTT     [user[:passwd]@]addr[:port]/topic[::qos=n][::retain][::id=id]
+*                [::keep=sec][::tls...]
+*                    addr  = MQTT broker address to connect
+*                    port  = MQTT broker port (default: 1883, 8883 for tls)
+*                    user  = MQTT broker user
+*                    passwd= MQTT broker password
+*                    topic = topic to subscribe (read) or publish (write)
+*                    qos   = QoS level 0, 1 or 2 (default: 0)
+*                    retain= publish message as retained message
+*                    id    = client identifier (default: gnssgo-<pid>-<n>)
+*                    sec   = keep alive interval (s) (default: 60)
+*                    (reconnect to the broker by the reconnect interval.
+*                     tls options: see decodetls())
+*
+*   STR_WSSVR    [ws[s]://][addr]:port[/path][::text][::tls...]
+*                    addr  = local address to listen (default: any)
+*                    port  = WebSocket server port to accept
+*                    path  = request path to accept (default: any)
+*                    text  = send data as text frames (default: binary frames)
+*                    (written data is broadcast to all connected clients.
+*                     wss:// or tls options for tls: see decodetls())
+*
+*   STR_WSCLI    [ws[s]://][user[:passwd]@]addr[:port][/path][::text][::tls...]
+*                    addr  = WebSocket server address to connect
+*                    port  = WebSocket server port (default: 80, 443 for tls)
+*                    user  = user for basic authentication
+*                    passwd= password for basic authentication
+*                    path  = request path (default: /)
+*                    (data frames from the server are read as input.
+*                     reconnect to the server by the reconnect interval)
+*
+*   STR_USER+n   path of stream type registered by RegisterStreamType()
+*
 *-----------------------------------------------------------------------------*/
 func (stream *Stream) OpenStream(ctype, mode int, path string) int {
 	Tracet(3, "stropen: type=%d mode=%d path=%s\n", ctype, mode, path)
@@ -2876,49 +3405,12 @@ func (stream *Stream) OpenStream(ctype, mode int, path string) int {
 	stream.TickOutput = stream.TickInput
 	stream.InByeTick, stream.OutByteTick = 0, 0
 	stream.Msg = ""
-	stream.Port = nil
-	switch byte(ctype) {
-	case STR_SERIAL:
-		stream.Port = OpenSerial(path, mode, &stream.Msg)
-
-	case STR_FILE:
-		stream.Port = OpenStreamFile(path, mode, &stream.Msg)
-
-	case STR_TCPSVR:
-		stream.Port = OpenTcpSvr(path, &stream.Msg)
-
-	case STR_TCPCLI:
-		stream.Port = OpenTcpClient(path, &stream.Msg)
-
-	case STR_NTRIPSVR:
-		stream.Port = OpenNtrip(path, 0, &stream.Msg)
-
-	case STR_NTRIPCLI:
-		stream.Port = OpenNtrip(path, 1, &stream.Msg)
-
-	case STR_NTRIPCAS:
-		stream.Port = OpenNtripc(path, &stream.Msg)
-
-	case STR_UDPSVR:
-		stream.Port = OpenUdpSvr(path, &stream.Msg)
-
-	case STR_UDPCLI:
-		stream.Port = OpenUdpClient(path, &stream.Msg)
-
-	case STR_MEMBUF:
-		stream.Port = OpenMemBuf(path, &stream.Msg)
-
-	case STR_FTP:
-		stream.Port = OpenFtp(path, 0, &stream.Msg)
-
-	case STR_HTTP:
-		stream.Port = OpenFtp(path, 1, &stream.Msg)
-
-	default:
+	if stream.Port = newstreamport(ctype); stream.Port == nil {
 		stream.State = 0
 		return 1
 	}
-	if stream.Port == nil {
+	if stream.Port.Open(path, mode, &stream.Msg) == 0 {
+		stream.Port = nil
 		stream.State = -1
 		return 0
 	}
@@ -2937,44 +3429,7 @@ func (stream *Stream) StreamClose() {
 	streamlock(stream)
 
 	if stream.Port != nil {
-		switch byte(stream.Type) {
-		case STR_SERIAL:
-			stream.Port.(*SerialComm).CloseSerial()
-
-		case STR_FILE:
-			stream.Port.(*FileType).CloseStreamFile()
-
-		case STR_TCPSVR:
-			stream.Port.(*TcpSvr).CloseTcpSvr()
-
-		case STR_TCPCLI:
-			stream.Port.(*TcpClient).CloseTcpClient()
-
-		case STR_NTRIPSVR:
-			stream.Port.(*NTrip).CloseNtrip()
-
-		case STR_NTRIPCLI:
-			stream.Port.(*NTrip).CloseNtrip()
-
-		case STR_NTRIPCAS:
-			stream.Port.(*NTripc).CloseNtripc()
-
-		case STR_UDPSVR:
-			stream.Port.(*UdpConn).CloseUdpSvr()
-
-		case STR_UDPCLI:
-			stream.Port.(*UdpConn).CloseUdpClient()
-
-		case STR_MEMBUF:
-			stream.Port.(*MemBuf).CloseMemBuf()
-
-		case STR_FTP:
-			stream.Port.(*FtpConn).CloseFtp()
-
-		case STR_HTTP:
-			stream.Port.(*FtpConn).CloseFtp()
-
-		}
+		stream.Port.Close()
 	} else {
 		Trace(2, "no port to close stream: type=%d\n", stream.Type)
 	}
@@ -3000,9 +3455,9 @@ func strsync(stream1, stream2 *Stream) {
 	if stream1.Type != STR_FILE || stream2.Type != STR_FILE {
 		return
 	}
-	file1 := stream1.Port.(*FileType)
-	file2 := stream2.Port.(*FileType)
-	if file1 != nil && file2 != nil {
+	file1, ok1 := stream1.Port.(*FileType)
+	file2, ok2 := stream2.Port.(*FileType)
+	if ok1 && ok2 {
 		syncfile(file1, file2)
 	}
 }
@@ -3036,41 +3491,8 @@ func (stream *Stream) StreamRead(buff []byte, n int) int {
 
 	streamlock(stream)
 
-	switch byte(stream.Type) {
-	case STR_SERIAL:
-		nr = stream.Port.(*SerialComm).ReadSerial(buff, n, msg)
-
-	case STR_FILE:
-		nr = stream.Port.(*FileType).ReadFile(buff, int64(n), msg)
-
-	case STR_TCPSVR:
-		nr = stream.Port.(*TcpSvr).ReadTcpSvr(buff, n, msg)
-
-	case STR_TCPCLI:
-		nr = stream.Port.(*TcpClient).ReadTcpClient(buff, n, msg)
-
-	case STR_NTRIPSVR, STR_NTRIPCLI:
-		nr = stream.Port.(*NTrip).ReadNtrip(buff, n, msg)
+	nr = stream.Port.Read(buff, n, msg)
 
-	case STR_NTRIPCAS:
-		nr = stream.Port.(*NTripc).ReadNtripc(buff, n, msg)
-
-	case STR_UDPSVR:
-		nr = stream.Port.(*UdpConn).ReadUdpSvr(buff, n, msg)
-
-	case STR_MEMBUF:
-		nr = stream.Port.(*MemBuf).ReadMemBuf(buff, n, msg)
-
-	case STR_FTP:
-		nr = stream.Port.(*FtpConn).ReadFtp(buff, n, msg)
-
-	case STR_HTTP:
-		nr = stream.Port.(*FtpConn).ReadFtp(buff, n, msg)
-
-	default:
-		streamunlock(stream)
-		return 0
-	}
 	//	stream.Msg = msg
 	if nr > 0 {
 		stream.InBytes += uint32(nr)
@@ -3108,38 +3530,8 @@ func (stream *Stream) StreamWrite(buff []byte, n int) int {
 
 	streamlock(stream)
 
-	switch byte(stream.Type) {
-	case STR_SERIAL:
-		ns = stream.Port.(*SerialComm).WriteSerial(buff, n, msg)
+	ns = stream.Port.Write(buff, n, msg)
 
-	case STR_FILE:
-		ns = stream.Port.(*FileType).WriteFile(buff, n, msg)
-
-	case STR_TCPSVR:
-		ns = stream.Port.(*TcpSvr).WriteTcpSvr(buff, n, msg)
-
-	case STR_TCPCLI:
-		ns = stream.Port.(*TcpClient).WriteTcpClient(buff, n, msg)
-
-	case STR_NTRIPSVR, STR_NTRIPCLI:
-		ns = stream.Port.(*NTrip).WriteNtrip(buff, n, msg)
-
-	case STR_NTRIPCAS:
-		ns = stream.Port.(*NTripc).WriteNtripc(buff, n, msg)
-
-	case STR_UDPCLI:
-		ns = stream.Port.(*UdpConn).WriteUdpClient(buff, n, msg)
-
-	case STR_MEMBUF:
-		ns = stream.Port.(*MemBuf).WriteMemBuf(buff, n, msg)
-
-	case STR_FTP, STR_HTTP:
-		streamunlock(stream)
-		return 0
-	default:
-		streamunlock(stream)
-		return 0
-	}
 	if ns > 0 {
 		stream.OutBytes += uint32(ns)
 		stream.TickActive = tick
@@ -3174,44 +3566,8 @@ func (stream *Stream) StreamStat(msg *string) int {
 		streamunlock(stream)
 		return stream.State
 	}
-	switch byte(stream.Type) {
-	case STR_SERIAL:
-		state = stream.Port.(*SerialComm).StateSerial()
-
-	case STR_FILE:
-		state = stream.Port.(*FileType).StateFile()
-
-	case STR_TCPSVR:
-		state = stream.Port.(*TcpSvr).StateTcpSvr()
-
-	case STR_TCPCLI:
-		state = stream.Port.(*TcpClient).StateTcpCli()
-
-	case STR_NTRIPSVR, STR_NTRIPCLI:
-		state = stream.Port.(*NTrip).StateNtrip()
-
-	case STR_NTRIPCAS:
-		state = stream.Port.(*NTripc).StateNtripc()
+	state = stream.Port.State()
 
-	case STR_UDPSVR:
-		state = stream.Port.(*UdpConn).StateUdpSvr()
-
-	case STR_UDPCLI:
-		state = stream.Port.(*UdpConn).StateUdpClient()
-
-	case STR_MEMBUF:
-		state = stream.Port.(*MemBuf).StateMemBuf()
-
-	case STR_FTP:
-		state = stream.Port.(*FtpConn).StateFtp()
-
-	case STR_HTTP:
-		state = stream.Port.(*FtpConn).StateFtp()
-
-	default:
-		streamunlock(stream)
-		return 0
-	}
 	if state == 2 && int(TickGet()-stream.TickActive) <= TINTACT {
 		state = 3
 	}
@@ -3236,45 +3592,8 @@ func (stream *Stream) StreamStateX(msg *string) int {
 		streamunlock(stream)
 		return stream.State
 	}
-	switch byte(stream.Type) {
-	case STR_SERIAL:
-		state = stream.Port.(*SerialComm).StatExSerial(msg)
+	state = stream.Port.StateX(msg)
 
-	case STR_FILE:
-		state = stream.Port.(*FileType).StatExFile(msg)
-
-	case STR_TCPSVR:
-		state = stream.Port.(*TcpSvr).StatExTcpSvr(msg)
-
-	case STR_TCPCLI:
-		state = stream.Port.(*TcpClient).StatExTcpClient(msg)
-
-	case STR_NTRIPSVR, STR_NTRIPCLI:
-		state = stream.Port.(*NTrip).StatExNtrip(msg)
-
-	case STR_NTRIPCAS:
-		state = stream.Port.(*NTripc).StatExNtripc(msg)
-
-	case STR_UDPSVR:
-		state = stream.Port.(*UdpConn).StatExUdpSvr(msg)
-
-	case STR_UDPCLI:
-		state = stream.Port.(*UdpConn).StateXUdpClient(msg)
-
-	case STR_MEMBUF:
-		state = stream.Port.(*MemBuf).StateXMemBuf(msg)
-
-	case STR_FTP:
-		state = stream.Port.(*FtpConn).StateXFtp(msg)
-
-	case STR_HTTP:
-		state = stream.Port.(*FtpConn).StateXFtp(msg)
-
-	default:
-		*msg = ""
-		streamunlock(stream)
-		return 0
-	}
 	if state == 2 && int(TickGet()-stream.TickActive) <= TINTACT {
 		state = 3
 	}
@@ -3354,7 +3673,8 @@ func StreamSetOpt(opt []int) {
 
 /* set timeout time ------------------------------------------------------------
 * set timeout time
-* args   : stream_t *stream I   stream (STR_TCPCLI,STR_NTRIPCLI,STR_NTRIPSVR)
+* args   : stream_t *stream I   stream (STR_TCPCLI,STR_NTRIPCLI,STR_NTRIPSVR,
+*                                 STR_MQTT,STR_WSCLI)
 *          int     toinact  I   inactive timeout (ms) (0: no timeout)
 *          int     tirecon  I   reconnect interval (ms) (0: no reconnect)
 * return : none
@@ -3364,11 +3684,20 @@ func (stream *Stream) StreamSetTimeout(toinact, tirecon int) {
 
 	Tracet(3, "strsettimeout: toinact=%d tirecon=%d\n", toinact, tirecon)
 
-	if stream.Type == STR_TCPCLI {
-		tcpcli = stream.Port.(*TcpClient)
-	} else if stream.Type == STR_NTRIPCLI || stream.Type == STR_NTRIPSVR {
-		tcpcli = stream.Port.(*NTrip).tcp
-	} else {
+	switch port := stream.Port.(type) {
+	case *TcpClient:
+		tcpcli = port
+	case *NTrip:
+		tcpcli = port.tcp
+	case *MqttConn:
+		port.lock.Lock()
+		port.tirecon = tirecon
+		port.lock.Unlock()
+		return
+	case *WsClient:
+		port.tirecon = tirecon
+		return
+	default:
 		return
 	}
 
@@ -3404,9 +3733,8 @@ func StreamSetProxy(addr string) {
 * return : current time or replay time for playback file
 *-----------------------------------------------------------------------------*/
 func StreamGetTime(stream *Stream) Gtime {
-	var file *FileType
 	if stream.Type == STR_FILE && (stream.Mode&STR_MODE_R) > 0 {
-		if file = stream.Port.(*FileType); file != nil {
+		if file, ok := stream.Port.(*FileType); ok {
 			return TimeAdd(file.time, file.start) /* replay start time */
 		}
 	}
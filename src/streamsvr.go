@@ -32,6 +32,8 @@
 *                           delete API strsvrsetsrctbl()
 *                           use integer types in stdint.h
 *		    2022/05/31 1.0  rewrite streamsvr.c with golang by fxb
+*           2026/10/16 1.1  support rtcm 2 output (type 1,3,9,18-22,24,31)
+*                           fix bug on accumulated obs data in output rtcm
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -47,7 +49,8 @@ func is_obsmsg(msg int) int {
 		(1071 <= msg && msg <= 1077) || (1081 <= msg && msg <= 1087) ||
 		(1091 <= msg && msg <= 1097) || (1101 <= msg && msg <= 1107) ||
 		(1111 <= msg && msg <= 1117) || (1121 <= msg && msg <= 1127) ||
-		(1131 <= msg && msg <= 1137) {
+		(1131 <= msg && msg <= 1137) || msg == 1 || msg == 9 ||
+		(18 <= msg && msg <= 21) || msg == 31 {
 		return 1
 	}
 	return 0
@@ -64,7 +67,8 @@ func is_navmsg(msg int) int {
 
 /* test station info message -------------------------------------------------*/
 func is_stamsg(msg int) int {
-	if msg == 1005 || msg == 1006 || msg == 1007 || msg == 1008 || msg == 1033 || msg == 1230 {
+	if msg == 1005 || msg == 1006 || msg == 1007 || msg == 1008 || msg == 1033 || msg == 1230 ||
+		msg == 3 || msg == 22 || msg == 24 {
 		return 1
 	}
 	return 0
@@ -164,6 +168,7 @@ func (raw *Raw) Raw2Rtcm(out *Rtcm, ret int) {
 
 	switch ret {
 	case 1:
+		out.ObsData.Data = nil
 		for i = 0; i < raw.ObsData.N(); i++ {
 			out.Time = raw.ObsData.Data[i].Time
 			out.ObsData.AddObsData(&raw.ObsData.Data[i])
@@ -217,6 +222,7 @@ func (rtcm *Rtcm) Rtcm2Rtcm(out *Rtcm, ret, stasel int) {
 
 	switch ret {
 	case 1:
+		out.ObsData.Data = nil
 		for i = 0; i < rtcm.ObsData.N(); i++ {
 			out.ObsData.AddObsData(&rtcm.ObsData.Data[i])
 
@@ -347,7 +353,7 @@ func (str *Stream) WriteObs(time Gtime, conv *StrConv) {
 		/* generate messages */
 		switch conv.OutputType {
 		case STRFMT_RTCM2:
-			if conv.RtcmOutput.gen_rtcm2(conv.MsgType[i], k) == 0 {
+			if conv.RtcmOutput.GenRtcm2(conv.MsgType[i], k) == 0 {
 				continue
 			}
 
@@ -376,7 +382,7 @@ func (str *Stream) WriteNav(time Gtime, conv *StrConv) {
 		/* generate messages */
 		switch conv.OutputType {
 		case STRFMT_RTCM2:
-			if conv.RtcmOutput.gen_rtcm2(conv.MsgType[i], 0) == 0 {
+			if conv.RtcmOutput.GenRtcm2(conv.MsgType[i], 0) == 0 {
 				continue
 			}
 		case STRFMT_RTCM3:
@@ -492,7 +498,7 @@ func (str *Stream) WriteNavCycle(conv *StrConv) {
 		/* generate messages */
 		switch conv.OutputType {
 		case STRFMT_RTCM2:
-			if (conv.RtcmOutput.gen_rtcm2(conv.MsgType[i], 0)) == 0 {
+			if (conv.RtcmOutput.GenRtcm2(conv.MsgType[i], 0)) == 0 {
 				continue
 			}
 		case STRFMT_RTCM3:
@@ -532,7 +538,7 @@ func (str *Stream) WriteStaCycle(conv *StrConv) {
 		/* generate messages */
 		switch conv.OutputType {
 		case STRFMT_RTCM2:
-			if conv.RtcmOutput.gen_rtcm2(conv.MsgType[i], 0) == 0 {
+			if conv.RtcmOutput.GenRtcm2(conv.MsgType[i], 0) == 0 {
 				continue
 			}
 		case STRFMT_RTCM3:
@@ -1,6 +1,7 @@
 package gnssgo
 
 import (
+	"os"
 	"sync"
 )
 
@@ -201,6 +202,7 @@ const (
 	MAXANT            = 64                        /* max length of station name/antenna type */
 	MAXSOLBUF         = 256                       /* max number of solution buffer */
 	MAXOBSBUF         = 128                       /* max number of observation data buffer */
+	MAXIMUBUF         = 4096                      /* max number of imu data buffer */
 	MAXNRPOS          = 16                        /* max number of reference positions */
 	MAXLEAPS          = 64                        /* max number of leap seconds table */
 	MAXGISLAYER       = 32                        /* max number of GIS data layers */
@@ -362,6 +364,10 @@ const (
 	STR_UDPSVR        = 10                        /* stream type: UDP server */
 	STR_UDPCLI        = 11                        /* stream type: UDP server */
 	STR_MEMBUF        = 12                        /* stream type: memory buffer */
+	STR_MQTT          = 13                        /* stream type: MQTT client */
+	STR_WSSVR         = 14                        /* stream type: WebSocket server */
+	STR_WSCLI         = 15                        /* stream type: WebSocket client */
+	STR_USER          = 32                        /* stream type: user registered (STR_USER+n) */
 	STRFMT_RTCM2      = 0                         /* stream format: RTCM 2 */
 	STRFMT_RTCM3      = 1                         /* stream format: RTCM 3 */
 	STRFMT_OEM4       = 2                         /* stream format: NovAtel OEMV/4 */
@@ -375,12 +381,14 @@ const (
 	STRFMT_BINEX      = 10                        /* stream format: BINEX */
 	STRFMT_RT17       = 11                        /* stream format: Trimble RT17 */
 	STRFMT_SEPT       = 12                        /* stream format: Septentrio */
-	STRFMT_RINEX      = 13                        /* stream format: RINEX */
-	STRFMT_SP3        = 14                        /* stream format: SP3 */
-	STRFMT_RNXCLK     = 15                        /* stream format: RINEX CLK */
-	STRFMT_SBAS       = 16                        /* stream format: SBAS messages */
-	STRFMT_NMEA       = 17                        /* stream format: NMEA 0183 */
-	MAXRCVFMT         = 12                        /* max number of receiver format */
+	STRFMT_UNICORE    = 13                        /* stream format: Unicore (STRFMT_RINEX- renumbered +1) */
+	STRFMT_RINEX      = 14                        /* stream format: RINEX */
+	STRFMT_SP3        = 15                        /* stream format: SP3 */
+	STRFMT_RNXCLK     = 16                        /* stream format: RINEX CLK */
+	STRFMT_SBAS       = 17                        /* stream format: SBAS messages */
+	STRFMT_NMEA       = 18                        /* stream format: NMEA 0183 */
+	STRFMT_L6         = 19                        /* stream format: QZSS L6 frames (CLAS) */
+	MAXRCVFMT         = 13                        /* max number of receiver format */
 	STR_MODE_R        = 0x1                       /* stream mode: read */
 	STR_MODE_W        = 0x2                       /* stream mode: write */
 	STR_MODE_RW       = 0x3                       /* stream mode: read/write */
@@ -406,6 +414,7 @@ const (
 	P2_6              = 0.015625              /* 2^-6 */
 	P2_10             = 0.0009765625          /* 2^-10 */
 	P2_11             = 4.882812500000000e-04 /* 2^-11 */
+	P2_12             = 2.441406250000000e-04 /* 2^-12 */
 	P2_15             = 3.051757812500000e-05 /* 2^-15 */
 	P2_17             = 7.629394531250000e-06 /* 2^-17 */
 	P2_19             = 1.907348632812500e-06 /* 2^-19 */
@@ -691,11 +700,11 @@ type SbsIon struct { /* SBAS ionospheric corrections type */
 }
 
 type DGps struct { /* DGPS/GNSS correction type */
-	t0   Gtime   /* correction time */
-	prc  float64 /* pseudorange correction (PRC) (m) */
-	rrc  float64 /* range rate correction (RRC) (m/s) */
-	iod  int     /* issue of data (IOD) */
-	udre float64 /* UDRE */
+	T0   Gtime   /* correction time */
+	Prc  float64 /* pseudorange correction (PRC) (m) */
+	Rrc  float64 /* range rate correction (RRC) (m/s) */
+	Iod  int     /* issue of data (IOD) */
+	Udre float64 /* UDRE */
 }
 
 type SSR struct { /* SSR correction type */
@@ -750,11 +759,14 @@ type Nav struct { /* navigation data type */
 	Glo_fcn [32]int               /* GLONASS FCN + 8 */
 	CBias   [MAXSAT][3]float64    /* satellite DCB (0:P1-P2,1:P1-C1,2:P2-C2) (m) */
 	RBias   [MAXRCV][2][3]float64 /* receiver DCB (0:P1-P2,1:P1-C1,2:P2-C2) (m) */
+	Bias    map[BiasKey][]Bias    /* signal biases (SINEX-BIAS) */
 	Pcvs    [MAXSAT]Pcv           /* satellite antenna pcv */
 	SbasSat SbsSat                /* SBAS satellite corrections */
 	SbasIon [MAXBAND + 1]SbsIon   /* SBAS ionosphere corrections */
 	Dgps    [MAXSAT]DGps          /* DGPS corrections */
 	Ssr     [MAXSAT]SSR           /* SSR corrections */
+	Catm    []CssrAtm             /* CLAS atmospheric corrections (index:network id) */
+	Grid    []CssrGrid            /* CLAS grid points */
 }
 
 func (nav *Nav) N() int {
@@ -781,6 +793,18 @@ func (nav *Nav) Nt() int {
 	return len(nav.Tec)
 }
 
+type BiasKey struct { /* signal bias key type */
+	Sat, Rcv, Sys int   /* satellite/receiver number (0:none)/system (receiver) */
+	Phase         int   /* observable (0:code,1:phase) */
+	Code1, Code2  uint8 /* obs codes (CODE_???) (code2=CODE_NONE: OSB) */
+}
+
+type Bias struct { /* signal bias type */
+	Ts, Te Gtime   /* valid time start/end (te.time=0: no end) */
+	Val    float64 /* bias value (m) */
+	Std    float64 /* bias std-dev (m) */
+}
+
 type Sta struct { /* station parameter type */
 	Name         string     /* marker name */
 	Marker       string     /* marker number */
@@ -806,14 +830,60 @@ type Sol struct { /* solution type */
 	Qr [6]float32 /* position variance/covariance (m^2) */
 	/* {c_xx,c_yy,c_zz,c_xy,c_yz,c_zx} or */
 	/* {c_ee,c_nn,c_uu,c_en,c_nu,c_ue} */
-	Qv    [6]float32 /* velocity variance/covariance (m^2/s^2) */
-	Dtr   [6]float64 /* receiver clock bias to time systems (s) */
-	Type  uint8      /* type (0:xyz-ecef,1:enu-baseline) */
-	Stat  uint8      /* solution status (SOLQ_???) */
-	Ns    uint8      /* number of valid satellites */
-	Age   float32    /* age of differential (s) */
-	Ratio float32    /* AR ratio factor for valiation */
-	Thres float32    /* AR ratio threshold for valiation */
+	Qv      [6]float32 /* velocity variance/covariance (m^2/s^2) */
+	Dtr     [6]float64 /* receiver clock bias to time systems (s) */
+	Type    uint8      /* type (0:xyz-ecef,1:enu-baseline) */
+	Stat    uint8      /* solution status (SOLQ_???) */
+	Ns      uint8      /* number of valid satellites */
+	Age     float32    /* age of differential (s) */
+	Ratio   float32    /* AR ratio factor for valiation */
+	Thres   float32    /* AR ratio threshold for valiation */
+	Att     [2]float64 /* attitude of moving-base vector {heading,pitch} (rad) */
+	Qatt    [2]float32 /* attitude variance {heading,pitch} (rad^2) */
+	AttStat uint8      /* attitude status (SOLQ_???) */
+}
+
+type ImuD struct { /* imu data record type */
+	Time Gtime      /* sampling time (GPST) */
+	Stat int        /* imu status */
+	Acc  [3]float64 /* specific force {x,y,z} (body frame: fwd,right,down) (m/s^2) */
+	Gyro [3]float64 /* angular rate {x,y,z} (body frame: fwd,right,down) (rad/s) */
+}
+
+type Imu struct { /* imu data type */
+	Data []ImuD /* imu data records */
+	T0   Gtime  /* time of sensor time tag anchor (GPST) */
+	Tag0 uint32 /* sensor time tag at anchor */
+}
+
+func (imu *Imu) N() int {
+	return len(imu.Data)
+}
+
+type InsOpt struct { /* ins options type */
+	Mode      int        /* ins mode (0:off,1:loosely coupled) */
+	Lever     [3]float64 /* lever arm imu to antenna {x,y,z} (body frame) (m) */
+	AccNoise  float64    /* accelerometer white noise (m/s^2/sqrt(Hz)) */
+	GyroNoise float64    /* gyro white noise (rad/s/sqrt(Hz)) */
+	AccBias   float64    /* accelerometer bias random walk (m/s^3/sqrt(Hz)) */
+	GyroBias  float64    /* gyro bias random walk (rad/s^2/sqrt(Hz)) */
+	MaxDr     float64    /* max dead reckoning time (s) (0:no limit) */
+}
+
+type Ins struct { /* ins states type */
+	Time  Gtime            /* time of states (GPST) */
+	Stat  int              /* status (0:not aligned,1:aligned) */
+	Re    [3]float64       /* imu position (ecef) (m) */
+	Ve    [3]float64       /* imu velocity (ecef) (m/s) */
+	Cbe   [9]float64       /* attitude (body to ecef dcm) */
+	Ba    [3]float64       /* accelerometer bias (m/s^2) */
+	Bg    [3]float64       /* gyro bias (rad/s) */
+	P     [15 * 15]float64 /* error states covariance {att,vel,pos,ba,bg} */
+	Fb    [3]float64       /* last specific force (bias corrected) (m/s^2) */
+	Wb    [3]float64       /* last angular rate (bias corrected) (rad/s) */
+	Tgnss Gtime            /* time of last gnss update (GPST) */
+	Rp    [3]float64       /* previous gnss position for alignment (ecef) (m) */
+	Tp    Gtime            /* time of previous gnss position (GPST) */
 }
 
 type SolBuf struct { /* solution buffer type */
@@ -858,6 +928,7 @@ type Rtcm struct { /* RTCM control struct type */
 	StaPara   Sta                             /* station parameters */
 	Dgps      [MAXSAT]DGps                    /* output of dgps corrections */
 	Ssr       [MAXSAT]SSR                     /* output of ssr corrections */
+	Cssr      Cssr                            /* compact ssr control */
 	Msg       string                          /* special message */
 	MsgType   string                          /* last message type */
 	MsmType   [7]string                       /* msm signal types */
@@ -868,6 +939,7 @@ type Rtcm struct { /* RTCM control struct type */
 	Lock      [MAXSAT][NFREQ + NEXOBS]uint16  /* lock time */
 	Loss      [MAXSAT][NFREQ + NEXOBS]uint16  /* loss of lock count */
 	Lltime    [MAXSAT][NFREQ + NEXOBS]Gtime   /* last lock time */
+	Cpc       [MAXSAT][NFREQ + NEXOBS]float64 /* carrier-phase correction offset (cycle) */
 	Nbyte     int                             /* number of bytes in message buffer */
 	Nbit      int                             /* number of bits in word buffer */
 	MsgLen    int                             /* message length (bytes) */
@@ -923,13 +995,15 @@ type PrcOpt struct { /* processing options type */
 	Elmin      float64        /* elevation mask angle (rad) */
 	SnrMask    SnrMask        /* SNR mask */
 	SatEph     int            /* satellite ephemeris/clock (EPHOPT_???) */
-	ModeAr     int            /* AR mode (0:off,1:continuous,2:instantaneous,3:fix and hold,4:ppp-ar) */
+	ModeAr     int            /* AR mode (0:off,1:continuous,2:instantaneous,3:fix and hold,4:wlnl,5:tcar) */
 	GloModeAr  int            /* GLONASS AR mode (0:off,1:on,2:auto cal,3:ext cal) */
 	BDSModeAr  int            /* BeiDou AR mode (0:off,1:on) */
 	MaxOut     int            /* obs outage count to reset bias */
 	MinLock    int            /* min lock count to fix ambiguity */
 	MinFix     int            /* min fix count to hold ambiguity */
 	ArMaxIter  int            /* max iteration to resolve ambiguity */
+	ArPartial  int            /* partial AR (0:off,1:exclude low elevation,2:exclude recent slip) */
+	ArMinAmb   int            /* min number of fixed ambiguities for partial AR */
 	IonoOpt    int            /* ionosphere option (IONOOPT_???) */
 	TropOpt    int            /* troposphere option (TROPOPT_???) */
 	Dynamics   int            /* dynamics model (0:none,1:velociy,2:accel) */
@@ -973,6 +1047,7 @@ type PrcOpt struct { /* processing options type */
 	Odisp      [2][6 * 11]float64 /* ocean tide loading parameters {rov,base} */
 	FreqOpt    int                /* disable L2-AR */
 	PPPOpt     string             /* ppp option */
+	InsOpt     InsOpt             /* ins options */
 }
 
 type SolOpt struct { /* solution options type */
@@ -984,6 +1059,7 @@ type SolOpt struct { /* solution options type */
 	OutHead   int        /* output header (0:no,1:yes) */
 	OutOpt    int        /* output processing options (0:no,1:yes) */
 	OutVel    int        /* output velocity options (0:no,1:yes) */
+	OutAtt    int        /* output moving-base attitude (0:no,1:yes) */
 	Datum     int        /* datum (0:WGS84,1:Tokyo) */
 	Height    int        /* height (0:ellipsoidal,1:geodetic) */
 	Geoid     int        /* geoid model (0:EGM96,1:JGD2000) */
@@ -992,6 +1068,7 @@ type SolOpt struct { /* solution options type */
 	Trace     int        /* debug trace level (0:off,1-5:debug) */
 	NmeaIntv  [2]float64 /* nmea output interval (s) (<0:no,0:all) */
 	/* nmeaintv[0]:gprmc,gpgga,nmeaintv[1]:gpgsv */
+	NmeaSen   int     /* nmea output sentences (NMEA_??? or'ed) (0:default) */
 	Sep       string  /* field separator */
 	Prog      string  /* program name */
 	MaxSolStd float64 /* max std-dev for solution output (m) (0:all) */
@@ -1010,6 +1087,8 @@ type FilOpt struct { /* file options type */
 	GeExe      string /* google earth exec file */
 	SolStat    string /* solution statistics file */
 	Trace      string /* debug trace file */
+	Imu        string /* imu data file */
+	Grid       string /* CLAS grid definition file */
 }
 
 type SSat struct { /* satellite status type */
@@ -1034,6 +1113,17 @@ type SSat struct { /* satellite status type */
 	Ph    [2][NFREQ]float64  /* previous carrier-phase observable (cycle) */
 }
 
+type CSmooth struct { /* carrier-smoothed code control type */
+	Time [NFREQ]Gtime   /* time of last smoothed code */
+	Ps   [NFREQ]float64 /* smoothed pseudorange (m) */
+	Ph   [NFREQ]float64 /* previous carrier-phase (m) */
+	Gf   [NFREQ]float64 /* previous geometry-free phase to pair frequency (m) */
+	Cnt  [NFREQ]int     /* number of smoothed epochs (0:reset) */
+	LLI  [NFREQ]uint8   /* previous LLI */
+	Df   [NFREQ]uint8   /* divergence-free smoothing flag */
+	Dop  [NFREQ]float64 /* previous doppler (Hz) */
+}
+
 type AmbC struct { /* ambiguity control type */
 	epoch  [4]Gtime     /* last epoch */
 	n      [4]int       /* number of epochs */
@@ -1044,33 +1134,43 @@ type AmbC struct { /* ambiguity control type */
 }
 
 type Rtk struct { /* RTK control/result type */
-	RtkSol Sol          /* RTK solution */
-	Rb     [6]float64   /* base position/velocity (ecef) (m|m/s) */
-	Nx, Na int          /* number of float states/fixed states */
-	Tt     float64      /* time difference between current and previous (s) */
-	X, P   []float64    /* float states and their covariance */
-	Xa, Pa []float64    /* fixed states and their covariance */
-	Nfix   int          /* number of continuous fixes of ambiguity */
-	Ambc   [MAXSAT]AmbC /* ambibuity control */
-	Ssat   [MAXSAT]SSat /* satellite status */
+	RtkSol Sol                /* RTK solution */
+	Rb     [6]float64         /* base position/velocity (ecef) (m|m/s) */
+	Nx, Na int                /* number of float states/fixed states */
+	Tt     float64            /* time difference between current and previous (s) */
+	X, P   []float64          /* float states and their covariance */
+	Xa, Pa []float64          /* fixed states and their covariance */
+	Nfix   int                /* number of continuous fixes of ambiguity */
+	Ambc   [MAXSAT]AmbC       /* ambibuity control */
+	Ssat   [MAXSAT]SSat       /* satellite status */
+	Csm    [2][MAXSAT]CSmooth /* carrier-smoothed code (0:rover,1:base) */
 	//neb    int             /* bytes in error message buffer, abandon in go */
-	ErrBuf string /* error message buffer */
-	Opt    PrcOpt /* processing options */
+	ErrBuf string   /* error message buffer */
+	Opt    PrcOpt   /* processing options */
+	Ins    Ins      /* ins states */
+	Stat   *RtkStat /* solution status file (nil: RtkOpenStat()) */
+}
+
+type RtkStat struct { /* solution status file type */
+	level int      /* rtk status output level (0:off) */
+	fp    *os.File /* rtk status file pointer */
+	file  string   /* rtk status file original path */
+	time  Gtime    /* rtk status file time */
 }
 type Stream struct { /* stream type */
-	Type                   int         /* type (STR_???) */
-	Mode                   int         /* mode (STR_MODE_?) */
-	State                  int         /* state (-1:error,0:close,1:open) */
-	InBytes, InRate        uint32      /* input bytes/rate */
-	OutBytes, OutRate      uint32      /* output bytes/rate */
-	TickInput              int64       /* input tick tick */
-	TickOutput             int64       /* output tick */
-	TickActive             int64       /* active tick */
-	InByeTick, OutByteTick uint32      /* input/output bytes at tick */
-	Lock                   sync.Mutex  /* lock flag */
-	Port                   interface{} /* type dependent port control struct */
-	Path                   string      /* stream path */
-	Msg                    string      /* stream message */
+	Type                   int        /* type (STR_???) */
+	Mode                   int        /* mode (STR_MODE_?) */
+	State                  int        /* state (-1:error,0:close,1:open) */
+	InBytes, InRate        uint32     /* input bytes/rate */
+	OutBytes, OutRate      uint32     /* output bytes/rate */
+	TickInput              int64      /* input tick tick */
+	TickOutput             int64      /* output tick */
+	TickActive             int64      /* active tick */
+	InByeTick, OutByteTick uint32     /* input/output bytes at tick */
+	Lock                   sync.Mutex /* lock flag */
+	Port                   StreamPort /* type dependent port control struct */
+	Path                   string     /* stream path */
+	Msg                    string     /* stream message */
 }
 
 type Raw struct { /* receiver raw data control type */
@@ -1102,6 +1202,9 @@ type Raw struct { /* receiver raw data control type */
 	Opt        string                      /* receiver dependent options */
 	Format     int                         /* receiver stream format */
 	RcvData    []byte                      /* receiver dependent data */
+	ImuData    Imu                         /* imu data */
+	sbf        *sbf_t                      /* Septentrio SBF dependent data */
+	rt17       *rt17_t                     /* Trimble RT17 dependent data */
 }
 
 type StrConv struct { /* stream converter type */
@@ -1163,6 +1266,7 @@ type RtkSvr struct { /* RTK server type */
 	DownloadTime [3]Gtime          /* download time {rov,base,corr} */
 	Files        [3]string         /* download paths {rov,base,corr} */
 	ObsData      [3][MAXOBSBUF]Obs /* observation data {rov,base,corr} */
+	ImuData      Imu               /* imu data buffer of rover */
 	NavData      Nav               /* navigation data */
 	SbsMsg       [MAXSBSMSG]SbsMsg /* SBAS message buffer */
 	Stream       [8]Stream         /* streams {rov,base,corr,sol1,sol2,logr,logb,logc} */
@@ -1178,6 +1282,66 @@ type RtkSvr struct { /* RTK server type */
 	BaseLenReset float64           /* baseline length to reset (km) */
 	Lock         sync.Mutex        /* lock flag */
 	Wg           sync.WaitGroup    /* thread conter is used to indicate thread exit */
+	StrStat      [8]int            /* stream status at last event */
+	EvQueue      []SvrEvent        /* events queued under lock */
+	Subs         []*SvrSubscriber  /* event subscribers */
+	SubLock      sync.Mutex        /* lock flag of subscribers */
+	StopC        chan struct{}     /* server stop signal for subscribers */
+}
+
+type SvrEvent struct { /* rtk server event type */
+	Kind  int        /* event kind (SVREV_???) */
+	Time  Gtime      /* event time (GPST) */
+	Index int        /* stream index (0:rover,1:base,2:corr,...) */
+	Obs   []ObsD     /* observation data {rover,base} (SVREV_OBS) */
+	Eph   *Eph       /* ephemeris (SVREV_EPH, nil: GLONASS) */
+	Geph  *GEph      /* GLONASS ephemeris (SVREV_EPH) */
+	Sol   Sol        /* solution (SVREV_SOL) */
+	Rb    [6]float64 /* base position/velocity (SVREV_SOL) */
+	State int        /* stream status (SVREV_STAT) (see StreamStat()) */
+	Msg   string     /* message (SVREV_STAT,SVREV_ERR) */
+}
+
+type SvrSubscriber struct { /* rtk server event subscriber type */
+	Drops uint64          /* number of dropped events (atomic) */
+	C     <-chan SvrEvent /* event channel (closed by server stop or unsubscribe) */
+	Kinds int             /* subscribed event kinds (SVREV_???) */
+	ch    chan SvrEvent   /* event channel */
+	quit  chan struct{}   /* unsubscribe signal */
+	once  sync.Once       /* unsubscribe once */
+}
+
+type PostProcessor struct { /* post-processing session type */
+	PcvSat   Pcvs                                      /* satellite antenna parameters */
+	PcvRcv   Pcvs                                      /* receiver antenna parameters */
+	ObsData  Obs                                       /* observation data */
+	NavData  Nav                                       /* navigation data */
+	SbsData  Sbs                                       /* sbas messages */
+	ImuData  Imu                                       /* imu data */
+	StaData  [MAXRCV]Sta                               /* station infomation */
+	NEpoch   int                                       /* number of observation epochs */
+	IObsU    int                                       /* current rover observation data index */
+	IObsR    int                                       /* current reference observation data index */
+	ISbs     int                                       /* current sbas message index */
+	IImu     int                                       /* current imu data index */
+	Revs     int                                       /* analysis direction (0:forward,1:backward) */
+	Aborts   int                                       /* abort status */
+	SolF     []Sol                                     /* forward solutions */
+	SolB     []Sol                                     /* backward solutions */
+	RbF      []float64                                 /* forward base positions */
+	RbB      []float64                                 /* backward base positions */
+	ISolF    int                                       /* current forward solutions index */
+	ISolB    int                                       /* current backward solutions index */
+	ProcRov  string                                    /* rover for current processing */
+	ProcBase string                                    /* base station for current processing */
+	RtcmFile string                                    /* rtcm data file */
+	RtcmPath string                                    /* rtcm data path */
+	RtcmCtrl Rtcm                                      /* rtcm control struct */
+	FpRtcm   *os.File                                  /* rtcm data file pointer */
+	ShowMsg  func(format string, v ...interface{}) int /* show message (nil: ShowMsg_Ptr) */
+	trace    int                                       /* debug trace opened by session */
+	geoid    int                                       /* geoid data opened by session */
+	stat     RtkStat                                   /* solution status file of session */
 }
 
 type RnxOpt struct { /* RINEX options type */
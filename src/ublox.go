@@ -77,6 +77,8 @@
 *                           CODE_L1I . CODE_L2I for BDS B1I (RINEX 3.04)
 *                           use integer types in stdint.h
 *           2022/09/26 1.29 rewrite with golang
+*           2026/10/16 1.30 support message ESF-RAW
+*                           add option -ESFTTAG=unit
 *-----------------------------------------------------------------------------*/
 package gnssgo
 
@@ -105,6 +107,7 @@ const (
 	ID_TRKD5    = 0x030A /* ubx message id: Trace mesurement data */
 	ID_TRKMEAS  = 0x0310 /* ubx message id: Trace mesurement data */
 	ID_TRKSFRBX = 0x030F /* ubx message id: Trace subframe buffer */
+	ID_ESFRAW   = 0x1003 /* ubx message id: raw sensor measurements */
 	FU1         = 1      /* ubx message field types */
 	FU2         = 2
 	FU4         = 3
@@ -636,6 +639,77 @@ func decode_navtime(raw *Raw) int {
 	return 0
 }
 
+/* decode UBX-ESF-RAW: raw sensor measurements ------------------------------*/
+func decode_esfraw(raw *Raw) int {
+	var (
+		data        ImuD
+		time        Gtime
+		unit        float64 = 1e-3
+		dt          float64
+		ttag, tprev uint32
+		dtype, nd   int
+		val         int32
+		p           = 6
+	)
+	Trace(4, "decode_esfraw: len=%d\n", raw.Len)
+
+	if raw.OutType > 0 {
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("UBX ESF-RAW   (%4d):", raw.Len)))
+	}
+	if q := strings.Index(raw.Opt, "-ESFTTAG="); q >= 0 {
+		fmt.Sscanf(raw.Opt[q:], "-ESFTTAG=%f", &unit)
+	}
+	raw.ImuData.Data = raw.ImuData.Data[:0]
+	if raw.Time.Time == 0 || raw.Len < 20 {
+		return 0
+	}
+	for p += 4; p+8 <= raw.Len-2; p += 8 {
+		val = int32(U4L(raw.Buff[p:])<<8) >> 8 /* 24 bit signed */
+		dtype = int(U1(raw.Buff[p+3:]) & 0x3F)
+		ttag = U4L(raw.Buff[p+4:])
+
+		if nd > 0 && ttag != tprev {
+			raw.ImuData.AddImuData(&data)
+			nd = 0
+		}
+		if nd == 0 {
+			/* anchor sensor time tag to receiver time */
+			dt = float64(int32(ttag-raw.ImuData.Tag0)) * unit
+			time = TimeAdd(raw.ImuData.T0, dt)
+			if raw.ImuData.T0.Time == 0 || math.Abs(TimeDiff(time, raw.Time)) > 2.0 {
+				raw.ImuData.T0, raw.ImuData.Tag0 = raw.Time, ttag
+				time = raw.Time
+			}
+			data = ImuD{Time: time}
+			tprev = ttag
+		}
+		switch dtype {
+		case 14: /* gyro x (2^-12 deg/s) */
+			data.Gyro[0] = float64(val) * P2_12 * D2R
+		case 13: /* gyro y */
+			data.Gyro[1] = float64(val) * P2_12 * D2R
+		case 5: /* gyro z */
+			data.Gyro[2] = float64(val) * P2_12 * D2R
+		case 16: /* acc x (2^-10 m/s^2) */
+			data.Acc[0] = float64(val) * P2_10
+		case 17: /* acc y */
+			data.Acc[1] = float64(val) * P2_10
+		case 18: /* acc z */
+			data.Acc[2] = float64(val) * P2_10
+		default:
+			continue
+		}
+		nd++
+	}
+	if nd > 0 {
+		raw.ImuData.AddImuData(&data)
+	}
+	if raw.ImuData.N() == 0 {
+		return 0
+	}
+	return 11
+}
+
 /* decode UBX-TRK-MEAS: Trace measurement data (unofficial) ------------------*/
 func decode_trkmeas(raw *Raw) int {
 	var (
@@ -1506,6 +1580,8 @@ func decode_ubx(raw *Raw) int {
 		return decode_trkd5(raw)
 	case ID_TRKSFRBX:
 		return decode_trksfrbx(raw)
+	case ID_ESFRAW:
+		return decode_esfraw(raw)
 	}
 	if raw.OutType > 0 {
 		copy(raw.MsgType[:], []byte(fmt.Sprintf("UBX 0x%02X 0x%02X (%4d)", ctype>>8, ctype&0xF,
@@ -1530,7 +1606,7 @@ func sync_ubx(buff []uint8, data uint8) int {
 *          uint8 data     I   stream data (1 byte)
 * return : status (-1: error message, 0: no message, 1: input observation data,
 *                  2: input ephemeris, 3: input sbas message,
-*                  9: input ion/utc parameter)
+*                  9: input ion/utc parameter, 11: input imu data)
 *
 * notes  : to specify input options, set raw.Opt to the following option
 *          strings separated by spaces.
@@ -1539,6 +1615,7 @@ func sync_ubx(buff []uint8, data uint8) int {
 *          -INVCP     : invert polarity of carrier-phase
 *          -TADJ=tint : adjust time tags to multiples of tint (sec)
 *          -STD_SLIP=std: slip by std-dev of carrier phase under std
+*          -ESFTTAG=unit: unit of sensor time tag of ESF-RAW (sec) (1e-3)
 *
 *          The supported messages are as follows.
 *
@@ -1546,6 +1623,10 @@ func sync_ubx(buff []uint8, data uint8) int {
 *          UBX-RXM-RAWX : multi-gnss measurement data
 *          UBX-RXM-SFRB : subframe buffer
 *          UBX-RXM-SFRBX: subframe buffer extension
+*          UBX-ESF-RAW  : raw sensor measurements (imu)
+*
+*          The sensor time tags of UBX-ESF-RAW are anchored to the receiver
+*          time by the last RXM-RAWX or NAV-TIMEGPS.
 *
 *          UBX-TRK-MEAS and UBX-TRK-SFRBX are based on NEO-M8N (F/W 2.01).
 *          UBX-TRK-D5 is based on NEO-7N (F/W 1.00). They are not formally
@@ -0,0 +1,792 @@
+/*------------------------------------------------------------------------------
+* uncompress.go : uncompress and uncompact functions without external commands
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] Y.Hatanaka, A Compression Format and Tools for GNSS Observation
+*         Data, Bulletin of the Geographical Survey Institute, 55, 21-30, 2008
+*     [2] Y.Hatanaka, Compact RINEX format version 3.0, 2009
+*     [3] RFC 1952, GZIP file format specification version 4.3, 1996
+*     [4] ncompress 4.2.4, (N)compress - LZW file compression, 2006
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, gzip, unix compress (.Z), zip, tar and
+*                           compact RINEX 1.0/3.0 (hatanaka) readers
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	UNCMP_NONE     = 0 /* uncompress format: not compressed */
+	UNCMP_GZIP     = 1 /* uncompress format: gzip (.gz) */
+	UNCMP_COMPRESS = 2 /* uncompress format: unix compress (.Z) */
+	UNCMP_ZIP      = 3 /* uncompress format: zip (.zip) */
+	UNCMP_TAR      = 4 /* uncompress format: tar (.tar) */
+	UNCMP_CRX      = 5 /* uncompress format: compact RINEX (.crx,.??d) */
+
+	MAXUNCMPLEVEL = 4  /* max nested level of compressed formats */
+	MAXCRXORDER   = 5  /* max order of differences in compact RINEX */
+	LZWMAXBITS    = 16 /* max code bits of unix compress */
+)
+
+var errCrxFormat = errors.New("compact rinex format error")
+
+/* detect compressed format by magic number ----------------------------------*/
+func UncompressFormat(rd *bufio.Reader) int {
+	if p, _ := rd.Peek(4); len(p) >= 2 {
+		switch {
+		case p[0] == 0x1F && p[1] == 0x8B:
+			return UNCMP_GZIP
+		case p[0] == 0x1F && p[1] == 0x9D:
+			return UNCMP_COMPRESS
+		case len(p) >= 4 && bytes.Equal(p, []byte("PK\x03\x04")):
+			return UNCMP_ZIP
+		}
+	}
+	if p, _ := rd.Peek(262); len(p) >= 262 && bytes.Equal(p[257:262], []byte("ustar")) {
+		return UNCMP_TAR
+	}
+	if p, _ := rd.Peek(80); len(p) >= 80 && strings.Contains(string(p[60:80]), "CRINEX VERS") {
+		return UNCMP_CRX
+	}
+	return UNCMP_NONE
+}
+
+/* uncompressed file name ------------------------------------------------------
+* get file name without compression extensions
+* args   : string file      I   file path
+* return : file path without .gz,.z,.Z,.zip and with .crx->.rnx, .??d->.??o
+*-----------------------------------------------------------------------------*/
+func UncompressFileName(file string) string {
+	var ext string
+
+	if idx := strings.LastIndex(file, "."); idx >= 0 {
+		ext = file[idx:]
+		switch {
+		case strings.EqualFold(ext, ".gz") || strings.EqualFold(ext, ".z") ||
+			strings.EqualFold(ext, ".zip"):
+			file = file[:idx]
+		case strings.EqualFold(ext, ".tgz"):
+			return file[:idx] + ".tar"
+		}
+	}
+	if idx := strings.LastIndex(file, "."); idx >= 0 {
+		ext = file[idx:]
+		switch {
+		case ext == ".crx":
+			file = file[:idx] + ".rnx"
+		case ext == ".CRX":
+			file = file[:idx] + ".RNX"
+		case len(ext) == 4 && ext[3] == 'd':
+			file = file[:idx+3] + "o"
+		case len(ext) == 4 && ext[3] == 'D':
+			file = file[:idx+3] + "O"
+		}
+	}
+	return file
+}
+
+/* new uncompress reader -------------------------------------------------------
+* wrap reader to uncompress gzip, unix compress (.Z), zip, tar and compact
+* RINEX (hatanaka compression) data on the fly
+* args   : io.Reader r      I   input reader
+*          int    *stat     O   status (-1:error,0:not compressed,
+*                                1:uncompressed) (NULL: no output)
+* return : reader of uncompressed data (nil: error)
+* notes  : the formats are detected by the magic numbers, not by the file
+*          extensions. nested formats (.crx.gz, .tar.Z, ...) are uncompressed
+*          recursively.
+*          for zip and tar archives, only the first regular file is read.
+*-----------------------------------------------------------------------------*/
+func NewUncompressReader(r io.Reader, stat *int) *bufio.Reader {
+	var (
+		rd       *bufio.Reader
+		st, i, f int
+	)
+
+	if rd, _ = r.(*bufio.Reader); rd == nil {
+		rd = bufio.NewReader(r)
+	}
+	for i = 0; i < MAXUNCMPLEVEL; i++ {
+		if f = UncompressFormat(rd); f == UNCMP_NONE {
+			break
+		}
+		Trace(4, "uncompress: level=%d format=%d\n", i, f)
+
+		if r = uncompressLayer(rd, f); r == nil {
+			st = -1
+			break
+		}
+		rd = bufio.NewReader(r)
+		st = 1
+	}
+	if stat != nil {
+		*stat = st
+	}
+	if st < 0 {
+		return nil
+	}
+	return rd
+}
+
+/* uncompress one layer of compressed format ---------------------------------*/
+func uncompressLayer(rd *bufio.Reader, format int) io.Reader {
+	switch format {
+	case UNCMP_GZIP:
+		zr, err := gzip.NewReader(rd)
+		if err != nil {
+			Trace(2, "gzip header error: %v\n", err)
+			return nil
+		}
+		return zr
+	case UNCMP_COMPRESS:
+		return NewLzwReader(rd)
+	case UNCMP_ZIP:
+		buff, err := io.ReadAll(rd)
+		if err != nil {
+			return nil
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buff), int64(len(buff)))
+		if err != nil {
+			Trace(2, "zip format error: %v\n", err)
+			return nil
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			if fr, err := f.Open(); err == nil {
+				return fr
+			}
+		}
+		Trace(2, "zip no file\n")
+		return nil
+	case UNCMP_TAR:
+		tr := tar.NewReader(rd)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				Trace(2, "tar no file\n")
+				return nil
+			}
+			if hdr.Typeflag == tar.TypeReg {
+				return tr
+			}
+		}
+	case UNCMP_CRX:
+		return NewCrxReader(rd)
+	}
+	return nil
+}
+
+/* open file with uncompression ------------------------------------------------
+* open file and uncompress it on the fly
+* args   : string file      I   file path
+*          int    *stat     O   status (-1:error,0:not compressed,
+*                                1:uncompressed) (NULL: no output)
+* return : reader of uncompressed data and opened file (nil: error)
+* notes  : close the returned file after use
+*-----------------------------------------------------------------------------*/
+func OpenUncompressFile(file string, stat *int) (*bufio.Reader, *os.File) {
+	fp, err := os.Open(file)
+	if err != nil {
+		if stat != nil {
+			*stat = -1
+		}
+		return nil, nil
+	}
+	rd := NewUncompressReader(fp, stat)
+	if rd == nil {
+		fp.Close()
+		return nil, nil
+	}
+	return rd, fp
+}
+
+/* extract tar archive -------------------------------------------------------*/
+func extractTar(rd io.Reader, dir string) int {
+	tr := tar.NewReader(rd)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return 1
+		}
+		if err != nil {
+			Trace(2, "tar extract error: %v\n", err)
+			return 0
+		}
+		path := filepath.Join(dir, hdr.Name)
+		if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+			Trace(2, "tar extract invalid path: %s\n", hdr.Name)
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			os.MkdirAll(path, 0755)
+		case tar.TypeReg:
+			os.MkdirAll(filepath.Dir(path), 0755)
+			fp, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				Trace(2, "tar extract file open error: %s\n", path)
+				return 0
+			}
+			_, err = io.Copy(fp, tr)
+			fp.Close()
+			if err != nil {
+				os.Remove(path)
+				return 0
+			}
+		}
+	}
+}
+
+/* write uncompressed file ---------------------------------------------------*/
+func uncompressFile(file, uncfile string) int {
+	var (
+		rd   *bufio.Reader
+		stat int
+	)
+
+	fp, err := os.Open(file)
+	if err != nil {
+		Trace(2, "uncompress file open error: %s\n", file)
+		return -1
+	}
+	defer fp.Close()
+
+	/* tar archive is extracted into the directory */
+	rd = bufio.NewReader(fp)
+	for i := 0; i < MAXUNCMPLEVEL; i++ {
+		f := UncompressFormat(rd)
+		if f == UNCMP_TAR {
+			if extractTar(rd, filepath.Dir(file)) == 0 {
+				return -1
+			}
+			return 1
+		}
+		if f == UNCMP_NONE {
+			break
+		}
+		r := uncompressLayer(rd, f)
+		if r == nil {
+			return -1
+		}
+		rd = bufio.NewReader(r)
+		stat = 1
+	}
+	if stat == 0 {
+		return 0
+	}
+	ofp, err := os.OpenFile(uncfile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		Trace(2, "uncompress file open error: %s\n", uncfile)
+		return -1
+	}
+	_, err = io.Copy(ofp, rd)
+	ofp.Close()
+	if err != nil {
+		Trace(2, "uncompress error: %s %v\n", file, err)
+		os.Remove(uncfile)
+		return -1
+	}
+	return 1
+}
+
+/*------------------------------------------------------------------------------
+* unix compress (.Z) reader
+*-----------------------------------------------------------------------------*/
+type LzwReader struct {
+	rd         io.Reader
+	maxbits    int  /* max code bits */
+	block      bool /* block mode (clear code enabled) */
+	nbits      int  /* current code bits */
+	maxcode    int  /* max code of current bits */
+	maxmaxcode int  /* max code of max bits */
+	freeent    int  /* first free entry */
+	oldcode    int  /* previous code (-1: none) */
+	finchar    byte /* first char of previous string */
+	buff       [LZWMAXBITS]byte
+	nbuff, pos int /* bytes in code group, bit position in group */
+	prefix     []uint16
+	suffix     []byte
+	stack      []byte
+	out        []byte /* pending output */
+	err        error
+}
+
+/* new unix compress reader --------------------------------------------------*/
+func NewLzwReader(r io.Reader) *LzwReader {
+	var hdr [3]byte
+
+	lzw := &LzwReader{rd: r, oldcode: -1}
+	if _, err := io.ReadFull(r, hdr[:]); err != nil || hdr[0] != 0x1F || hdr[1] != 0x9D {
+		Trace(2, "compress header error\n")
+		lzw.err = errors.New("compress header error")
+		return lzw
+	}
+	lzw.maxbits = int(hdr[2] & 0x1F)
+	lzw.block = hdr[2]&0x80 != 0
+	if lzw.maxbits < 9 || lzw.maxbits > LZWMAXBITS {
+		Trace(2, "compress max bits error: %d\n", lzw.maxbits)
+		lzw.err = errors.New("compress max bits error")
+		return lzw
+	}
+	lzw.maxmaxcode = 1 << lzw.maxbits
+	lzw.nbits = 9
+	lzw.maxcode = 1<<lzw.nbits - 1
+	lzw.freeent = 256
+	if lzw.block {
+		lzw.freeent = 257
+	}
+	lzw.prefix = make([]uint16, lzw.maxmaxcode)
+	lzw.suffix = make([]byte, lzw.maxmaxcode)
+	for i := 0; i < 256; i++ {
+		lzw.suffix[i] = byte(i)
+	}
+	return lzw
+}
+
+/* read code: codes are packed in groups of 8 codes (nbits bytes) ------------*/
+func (lzw *LzwReader) readCode() (int, error) {
+	if lzw.pos+lzw.nbits > lzw.nbuff*8 {
+		n, err := io.ReadFull(lzw.rd, lzw.buff[:lzw.nbits])
+		if n == 0 {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		lzw.nbuff, lzw.pos = n, 0
+		if lzw.nbits > n*8 {
+			return 0, io.EOF
+		}
+	}
+	code := 0
+	for i := 0; i < lzw.nbits; i++ {
+		p := lzw.pos + i
+		if lzw.buff[p>>3]&(1<<uint(p&7)) != 0 {
+			code |= 1 << uint(i)
+		}
+	}
+	lzw.pos += lzw.nbits
+	return code, nil
+}
+
+/* discard rest of code group and set code bits ------------------------------*/
+func (lzw *LzwReader) setBits(nbits int) {
+	lzw.pos = lzw.nbuff * 8
+	lzw.nbits = nbits
+	if nbits == lzw.maxbits {
+		lzw.maxcode = lzw.maxmaxcode
+	} else {
+		lzw.maxcode = 1<<nbits - 1
+	}
+}
+
+/* decode next code ----------------------------------------------------------*/
+func (lzw *LzwReader) decode() error {
+	var code, incode int
+
+	if lzw.freeent > lzw.maxcode {
+		lzw.setBits(lzw.nbits + 1)
+	}
+	code, err := lzw.readCode()
+	if err != nil {
+		return err
+	}
+	if lzw.oldcode == -1 {
+		if code >= 256 {
+			return errors.New("compress data error")
+		}
+		lzw.oldcode = code
+		lzw.finchar = byte(code)
+		lzw.out = append(lzw.out, lzw.finchar)
+		return nil
+	}
+	if code == 256 && lzw.block { /* clear */
+		for i := range lzw.prefix[:256] {
+			lzw.prefix[i] = 0
+		}
+		lzw.freeent = 256
+		lzw.setBits(9)
+		return nil
+	}
+	incode = code
+	lzw.stack = lzw.stack[:0]
+
+	if code >= lzw.freeent { /* special case for KwKwK string */
+		if code > lzw.freeent {
+			return errors.New("compress data error")
+		}
+		lzw.stack = append(lzw.stack, lzw.finchar)
+		code = lzw.oldcode
+	}
+	for code >= 256 {
+		lzw.stack = append(lzw.stack, lzw.suffix[code])
+		code = int(lzw.prefix[code])
+	}
+	lzw.finchar = lzw.suffix[code]
+	lzw.stack = append(lzw.stack, lzw.finchar)
+
+	for i := len(lzw.stack) - 1; i >= 0; i-- {
+		lzw.out = append(lzw.out, lzw.stack[i])
+	}
+	if lzw.freeent < lzw.maxmaxcode {
+		lzw.prefix[lzw.freeent] = uint16(lzw.oldcode)
+		lzw.suffix[lzw.freeent] = lzw.finchar
+		lzw.freeent++
+	}
+	lzw.oldcode = incode
+	return nil
+}
+
+/* read uncompressed data ----------------------------------------------------*/
+func (lzw *LzwReader) Read(p []byte) (int, error) {
+	for len(lzw.out) < len(p) && lzw.err == nil {
+		lzw.err = lzw.decode()
+	}
+	n := copy(p, lzw.out)
+	lzw.out = lzw.out[n:]
+	if n == 0 && lzw.err != nil {
+		return 0, lzw.err
+	}
+	return n, nil
+}
+
+/*------------------------------------------------------------------------------
+* compact RINEX (hatanaka compression) reader
+*-----------------------------------------------------------------------------*/
+type crxarc_t struct { /* differential arc */
+	order int                    /* order of differences */
+	n     int                    /* number of differences available */
+	u     [MAXCRXORDER + 1]int64 /* last values of 0..order differences */
+	valid bool                   /* arc valid */
+}
+
+type crxsat_t struct { /* satellite data */
+	arc  []crxarc_t /* differential arcs of observables */
+	flag string     /* LLI and signal strength flags */
+}
+
+type CrxReader struct {
+	rd     *bufio.Reader
+	ver    float64              /* compact RINEX version (1.0,3.0) */
+	ntype  map[byte]int         /* number of obs types (key: system, ' ': ver.1) */
+	epoch  string               /* epoch line */
+	clk    crxarc_t             /* receiver clock offset arc */
+	sats   map[string]*crxsat_t /* satellite data of previous epoch */
+	header bool                 /* header read */
+	out    bytes.Buffer         /* pending output */
+	err    error
+}
+
+/* new compact RINEX reader --------------------------------------------------*/
+func NewCrxReader(r io.Reader) *CrxReader {
+	crx := &CrxReader{ntype: make(map[byte]int), sats: make(map[string]*crxsat_t)}
+	if crx.rd, _ = r.(*bufio.Reader); crx.rd == nil {
+		crx.rd = bufio.NewReader(r)
+	}
+	return crx
+}
+
+/* read line without line feed -----------------------------------------------*/
+func (crx *CrxReader) readLine() (string, error) {
+	buff, err := crx.rd.ReadString('\n')
+	if len(buff) == 0 && err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buff, "\r\n"), nil
+}
+
+/* repair string by text difference ------------------------------------------*/
+func crxrepair(s, ds string) string {
+	b := []byte(s)
+	for i := 0; i < len(ds); i++ {
+		c := ds[i]
+		if i >= len(b) {
+			if c == '&' {
+				c = ' '
+			}
+			b = append(b, c)
+			continue
+		}
+		switch c {
+		case ' ':
+		case '&':
+			b[i] = ' '
+		default:
+			b[i] = c
+		}
+	}
+	return string(b)
+}
+
+/* pad string with spaces ----------------------------------------------------*/
+func crxpad(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+/* update differential arc ---------------------------------------------------*/
+func (arc *crxarc_t) update(s string) error {
+	if i := strings.IndexByte(s, '&'); i >= 0 { /* initialization of arc */
+		order, err := strconv.Atoi(s[:i])
+		if err != nil || order < 0 || order > MAXCRXORDER {
+			return errCrxFormat
+		}
+		v, err := strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil {
+			return errCrxFormat
+		}
+		arc.order, arc.n, arc.u[0], arc.valid = order, 0, v, true
+		return nil
+	}
+	if !arc.valid {
+		return errCrxFormat
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return errCrxFormat
+	}
+	if arc.n < arc.order {
+		arc.n++
+	}
+	arc.u[arc.n] = v
+	for j := arc.n - 1; j >= 0; j-- {
+		arc.u[j] += arc.u[j+1]
+	}
+	return nil
+}
+
+/* read compact RINEX header -------------------------------------------------*/
+func (crx *CrxReader) readHeader() error {
+	var n int
+
+	for i := 0; ; i++ {
+		buff, err := crx.readLine()
+		if err != nil {
+			return err
+		}
+		label := ""
+		if len(buff) > 60 {
+			label = buff[60:]
+		}
+		switch {
+		case i == 0:
+			if !strings.Contains(label, "CRINEX VERS") {
+				return errCrxFormat
+			}
+			crx.ver = Str2Num(buff, 0, 9)
+			continue
+		case strings.Contains(label, "CRINEX PROG / DATE"):
+			continue
+		case strings.Contains(label, "# / TYPES OF OBSERV"): /* ver.2 */
+			if n = int(Str2Num(buff, 0, 6)); n > 0 {
+				crx.ntype[' '] = n
+			}
+		case strings.Contains(label, "SYS / # / OBS TYPES"): /* ver.3 */
+			if buff[0] != ' ' {
+				crx.ntype[buff[0]] = int(Str2Num(buff, 3, 3))
+			}
+		}
+		crx.out.WriteString(buff + "\n")
+		if strings.Contains(label, "END OF HEADER") {
+			break
+		}
+	}
+	Trace(4, "crx header: ver=%.1f\n", crx.ver)
+	return nil
+}
+
+/* format observation data field ---------------------------------------------*/
+func crxobs(arc *crxarc_t, flag string, i int) string {
+	var lli, ssi byte = ' ', ' '
+
+	if 2*i < len(flag) {
+		lli = flag[2*i]
+	}
+	if 2*i+1 < len(flag) {
+		ssi = flag[2*i+1]
+	}
+	if arc == nil || !arc.valid {
+		return fmt.Sprintf("%14s%c%c", "", lli, ssi)
+	}
+	return fmt.Sprintf("%14.3f%c%c", float64(arc.u[0])/1e3, lli, ssi)
+}
+
+/* decode epoch of compact RINEX ---------------------------------------------*/
+func (crx *CrxReader) readEpoch() error {
+	var (
+		sats                  []string
+		clk                   string
+		flag, nsat, off, i, j int
+	)
+
+	buff, err := crx.readLine()
+	if err != nil {
+		return err
+	}
+	if len(buff) == 0 {
+		return nil
+	}
+	/* epoch line: initialization or text difference */
+	switch {
+	case crx.ver < 3.0 && buff[0] == '&':
+		crx.epoch = " " + buff[1:]
+	case crx.ver >= 3.0 && buff[0] == '>':
+		crx.epoch = buff
+	default:
+		if len(crx.epoch) == 0 {
+			return errCrxFormat
+		}
+		crx.epoch = crxrepair(crx.epoch, buff)
+	}
+	if crx.ver < 3.0 {
+		flag = int(Str2Num(crx.epoch, 28, 1))
+		nsat = int(Str2Num(crx.epoch, 29, 3))
+		off = 32
+	} else {
+		flag = int(Str2Num(crx.epoch, 31, 1))
+		nsat = int(Str2Num(crx.epoch, 32, 3))
+		off = 41
+	}
+	/* event flag: special records are copied as they are */
+	if flag > 1 {
+		crx.out.WriteString(strings.TrimRight(crx.epoch, " ") + "\n")
+		for i = 0; i < nsat; i++ {
+			if buff, err = crx.readLine(); err != nil {
+				return err
+			}
+			crx.out.WriteString(buff + "\n")
+		}
+		crx.epoch = ""
+		crx.clk.valid = false
+		crx.sats = make(map[string]*crxsat_t)
+		return nil
+	}
+	/* receiver clock offset */
+	if buff, err = crx.readLine(); err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(buff)) == 0 {
+		crx.clk.valid = false
+	} else if err = crx.clk.update(strings.TrimSpace(buff)); err != nil {
+		return err
+	}
+	if crx.clk.valid {
+		if crx.ver < 3.0 {
+			clk = fmt.Sprintf("%12.9f", float64(crx.clk.u[0])*1e-9)
+		} else {
+			clk = fmt.Sprintf("%15.12f", float64(crx.clk.u[0])*1e-12)
+		}
+	}
+	/* satellite list */
+	epoch := crxpad(crx.epoch, off+3*nsat)
+	for i = 0; i < nsat; i++ {
+		sats = append(sats, epoch[off+3*i:off+3*i+3])
+	}
+	if crx.ver < 3.0 { /* ver.2 epoch record */
+		for i = 0; i < nsat || i == 0; i += 12 {
+			line := epoch[:32]
+			if i > 0 {
+				line = strings.Repeat(" ", 32)
+			}
+			for j = i; j < nsat && j < i+12; j++ {
+				line += sats[j]
+			}
+			if i == 0 && len(clk) > 0 {
+				line = crxpad(line, 68) + clk
+			}
+			crx.out.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	} else { /* ver.3 epoch record */
+		line := epoch[:35]
+		if len(clk) > 0 {
+			line = crxpad(line, 41) + clk
+		}
+		crx.out.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	/* observation data */
+	sdata := make(map[string]*crxsat_t)
+	for i = 0; i < nsat; i++ {
+		if buff, err = crx.readLine(); err != nil {
+			return err
+		}
+		ntype := crx.ntype[' ']
+		if crx.ver >= 3.0 {
+			ntype = crx.ntype[sats[i][0]]
+		}
+		sat := crx.sats[sats[i]]
+		if sat == nil || len(sat.arc) != ntype {
+			sat = &crxsat_t{arc: make([]crxarc_t, ntype)}
+		}
+		sdata[sats[i]] = sat
+
+		fields := strings.SplitN(buff, " ", ntype+1)
+		for j = 0; j < ntype; j++ {
+			if j >= len(fields) || len(fields[j]) == 0 {
+				sat.arc[j].valid = false
+				continue
+			}
+			if err = sat.arc[j].update(fields[j]); err != nil {
+				Trace(2, "crx data error: sat=%s type=%d %s\n", sats[i], j, fields[j])
+				return err
+			}
+		}
+		if len(fields) > ntype {
+			sat.flag = crxrepair(sat.flag, fields[ntype])
+		}
+		/* output observation data record */
+		if crx.ver < 3.0 {
+			for j = 0; j < ntype || j == 0; j += 5 {
+				line := ""
+				for k := j; k < ntype && k < j+5; k++ {
+					line += crxobs(&sat.arc[k], sat.flag, k)
+				}
+				crx.out.WriteString(strings.TrimRight(line, " ") + "\n")
+			}
+		} else {
+			line := sats[i]
+			for j = 0; j < ntype; j++ {
+				line += crxobs(&sat.arc[j], sat.flag, j)
+			}
+			crx.out.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	crx.sats = sdata
+	return nil
+}
+
+/* read uncompacted RINEX data -----------------------------------------------*/
+func (crx *CrxReader) Read(p []byte) (int, error) {
+	for crx.out.Len() < len(p) && crx.err == nil {
+		if !crx.header {
+			crx.err = crx.readHeader()
+			crx.header = true
+		} else {
+			crx.err = crx.readEpoch()
+		}
+	}
+	n, _ := crx.out.Read(p)
+	if n == 0 && crx.err != nil {
+		return 0, crx.err
+	}
+	return n, nil
+}
@@ -0,0 +1,695 @@
+/*------------------------------------------------------------------------------
+* unicore.go : Unicore receiver dependent functions
+*
+*          Copyright (C) 2022-2026 by Feng Xuebin, All rights reserved.
+*
+* reference :
+*     [1] Unicore Communications, Unicore Reference Commands Manual for High
+*         Precision Products, R1.9, 2023
+*     [2] Unicore Communications, UM980 User Manual, R1.2, 2022
+*     [3] Unicore Communications, UB4B0 User Manual, R1.4, 2020
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, support OBSVM, OBSVH, OBSVMCMP, OBSVHCMP,
+*                           GPSEPH, GLOEPH, BDSEPH, GALEPH, QZSSEPH and
+*                           IONUTC messages
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	UNISYNC1 = 0xAA /* unicore message start sync code 1 */
+	UNISYNC2 = 0x44 /* unicore message start sync code 2 */
+	UNISYNC3 = 0xB5 /* unicore message start sync code 3 */
+	UNIHLEN  = 24   /* unicore message header length (bytes) */
+
+	/* message IDs */
+	ID_UNI_IONUTC   = 8   /* iono and utc data */
+	ID_UNI_OBSVM    = 12  /* master antenna observation */
+	ID_UNI_OBSVH    = 13  /* slave antenna observation */
+	ID_UNI_GPSEPH   = 106 /* gps ephemeris */
+	ID_UNI_GLOEPH   = 107 /* glonass ephemeris */
+	ID_UNI_BDSEPH   = 108 /* bds ephemeris */
+	ID_UNI_GALEPH   = 109 /* galileo ephemeris */
+	ID_UNI_QZSSEPH  = 110 /* qzss ephemeris */
+	ID_UNI_OBSVMCMP = 138 /* master antenna observation compressed */
+	ID_UNI_OBSVHCMP = 139 /* slave antenna observation compressed */
+)
+
+/* unicore signal type to obs code -------------------------------------------*/
+func uni_sig2code(sys, sigtype int) int {
+	switch sys {
+	case SYS_GPS:
+		switch sigtype {
+		case 0:
+			return CODE_L1C /* L1C/A */
+		case 3:
+			return CODE_L1L /* L1C pilot */
+		case 6:
+			return CODE_L5I /* L5 data */
+		case 9:
+			return CODE_L2W /* L2P(Y) semi-codeless */
+		case 11:
+			return CODE_L1S /* L1C data */
+		case 14:
+			return CODE_L5Q /* L5 pilot */
+		case 17:
+			return CODE_L2L /* L2C(L) */
+		}
+	case SYS_GLO:
+		switch sigtype {
+		case 0:
+			return CODE_L1C /* G1 C/A */
+		case 5:
+			return CODE_L2C /* G2 C/A */
+		case 6:
+			return CODE_L3I /* G3 data */
+		case 7:
+			return CODE_L3Q /* G3 pilot */
+		}
+	case SYS_SBS:
+		switch sigtype {
+		case 0:
+			return CODE_L1C /* L1C/A */
+		case 6:
+			return CODE_L5I /* L5 data */
+		}
+	case SYS_GAL:
+		switch sigtype {
+		case 1:
+			return CODE_L1B /* E1B */
+		case 2:
+			return CODE_L1C /* E1C */
+		case 6:
+			return CODE_L6B /* E6B */
+		case 7:
+			return CODE_L6C /* E6C */
+		case 12:
+			return CODE_L5Q /* E5a pilot */
+		case 17:
+			return CODE_L7Q /* E5b pilot */
+		case 18:
+			return CODE_L8Q /* E5 AltBOC pilot */
+		}
+	case SYS_CMP:
+		switch sigtype {
+		case 0, 4:
+			return CODE_L2I /* B1I */
+		case 5, 17:
+			return CODE_L7I /* B2I */
+		case 6, 21:
+			return CODE_L6I /* B3I */
+		case 8:
+			return CODE_L1P /* B1C pilot */
+		case 12:
+			return CODE_L5P /* B2a pilot */
+		case 13:
+			return CODE_L7P /* B2b pilot */
+		}
+	case SYS_QZS:
+		switch sigtype {
+		case 0:
+			return CODE_L1C /* L1C/A */
+		case 1:
+			return CODE_L1Z /* L1S */
+		case 3:
+			return CODE_L1L /* L1C pilot */
+		case 6:
+			return CODE_L5I /* L5 data */
+		case 14:
+			return CODE_L5Q /* L5 pilot */
+		case 17:
+			return CODE_L2L /* L2C(L) */
+		case 27:
+			return CODE_L6L /* LEX/L6 */
+		}
+	case SYS_IRN:
+		switch sigtype {
+		case 0, 14:
+			return CODE_L5A /* L5 SPS */
+		}
+	}
+	return CODE_NONE
+}
+
+/* decode unicore tracking status --------------------------------------------*/
+func uni_track_stat(stat uint32, sys, code, plock, clock, parity, halfc *int) int {
+	var satsys, sigtype, idx int
+
+	*code = CODE_NONE
+	*plock = int((stat >> 10) & 1)
+	*parity = int((stat >> 11) & 1)
+	*clock = int((stat >> 12) & 1)
+	satsys = int((stat >> 16) & 7)
+	*halfc = int((stat >> 28) & 1)
+	sigtype = int((stat >> 21) & 0x1F)
+
+	switch satsys {
+	case 0:
+		*sys = SYS_GPS
+	case 1:
+		*sys = SYS_GLO
+	case 2:
+		*sys = SYS_SBS
+	case 3:
+		*sys = SYS_GAL
+	case 4:
+		*sys = SYS_CMP
+	case 5:
+		*sys = SYS_QZS
+	case 6:
+		*sys = SYS_IRN
+	default:
+		Trace(2, "unicore unknown system: sys=%d\n", satsys)
+		return -1
+	}
+	*code = uni_sig2code(*sys, sigtype)
+	if idx = Code2Idx(*sys, uint8(*code)); *code == CODE_NONE || idx < 0 || idx >= NFREQ+NEXOBS {
+		Trace(3, "unicore signal type error: sys=%d sigtype=%d\n", *sys, sigtype)
+		return -1
+	}
+	return idx
+}
+
+/* unicore prn to system satellite number ------------------------------------*/
+func uni_satno(sys, prn int, code *int) int {
+	if sys == SYS_GLO {
+		prn -= 37
+	}
+	if sys == SYS_SBS && prn >= MINPRNQZS_S && prn <= MAXPRNQZS_S && *code == CODE_L1C {
+		sys = SYS_QZS
+		prn += 10
+		*code = CODE_L1Z /* QZS L1S */
+	}
+	return SatNo(sys, prn)
+}
+
+/* set observation data ------------------------------------------------------*/
+func uni_setobs(raw *Raw, sat, code, idx int, psr, adr, dop, snr, lockt float64,
+	plock, clock, parity, halfc int) {
+	var (
+		sys        = SatSys(sat, nil)
+		index, lli int
+		tt         float64
+	)
+	if math.Abs(TimeDiff(raw.ObsData.Data[0].Time, raw.Time)) > 1e-9 {
+		raw.ObsData.n = 0
+	}
+	if index = obsindex(raw, raw.Time, sat); index < 0 {
+		return
+	}
+	data := &raw.ObsData.Data[index]
+
+	/* select signal by code priority if frequency slot is already filled */
+	if data.Code[idx] != CODE_NONE &&
+		GetCodePri(sys, data.Code[idx], raw.Opt) >= GetCodePri(sys, uint8(code), raw.Opt) {
+		return
+	}
+	if raw.Tobs[sat-1][idx].Time != 0 {
+		tt = TimeDiff(raw.Time, raw.Tobs[sat-1][idx])
+		if lockt-raw.LockTime[sat-1][idx]+0.05 <= tt {
+			lli = LLI_SLIP
+		}
+	}
+	if parity == 0 {
+		lli |= LLI_HALFC
+	}
+	if halfc > 0 {
+		lli |= LLI_HALFA
+	}
+	raw.Tobs[sat-1][idx] = raw.Time
+	raw.LockTime[sat-1][idx] = lockt
+	raw.Halfc[sat-1][idx] = uint8(halfc)
+
+	if clock == 0 {
+		psr = 0.0
+	} /* code unlock */
+	if plock == 0 {
+		adr, dop = 0.0, 0.0
+	} /* phase unlock */
+
+	data.L[idx] = adr
+	data.P[idx] = psr
+	data.D[idx] = dop
+	data.SNR[idx] = uint16(snr/SNR_UNIT + 0.5)
+	data.LLI[idx] = uint8(lli)
+	data.Code[idx] = uint8(code)
+}
+
+/* decode OBSVM/OBSVH --------------------------------------------------------*/
+func decode_uni_obsvm(raw *Raw) int {
+	var (
+		p                                       = UNIHLEN
+		psr, adr, dop, snr, lockt               float64
+		i, nobs, prn, sat, sys, code, idx, gfrq int
+		plock, clock, parity, halfc             int
+	)
+	nobs = int(U4L(raw.Buff[p:]))
+	if raw.Len < UNIHLEN+4+nobs*40 {
+		Trace(2, "unicore obsvm length error: len=%d nobs=%d\n", raw.Len, nobs)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[len(strings.TrimRight(string(raw.MsgType[:]), "\x00")):],
+			[]byte(fmt.Sprintf(" nobs=%d", nobs)))
+	}
+	for i, p = 0, p+4; i < nobs; i, p = i+1, p+40 {
+		if idx = uni_track_stat(U4L(raw.Buff[p+36:]), &sys, &code, &plock, &clock,
+			&parity, &halfc); idx < 0 {
+			continue
+		}
+		prn = int(U2L(raw.Buff[p+2:]))
+		if sat = uni_satno(sys, prn, &code); sat == 0 {
+			Trace(3, "unicore obsvm satellite number error: sys=%d,prn=%d\n", sys, prn)
+			continue
+		}
+		gfrq = int(U2L(raw.Buff[p:])) /* GLONASS FCN+7 */
+		psr = R8L(raw.Buff[p+4:])
+		adr = R8L(raw.Buff[p+12:])
+		dop = float64(R4L(raw.Buff[p+24:]))
+		snr = float64(U2L(raw.Buff[p+28:])) / 100.0
+		lockt = float64(R4L(raw.Buff[p+32:]))
+
+		if sys == SYS_GLO {
+			SatSys(sat, &prn)
+			if raw.NavData.Glo_fcn[prn-1] == 0 {
+				raw.NavData.Glo_fcn[prn-1] = gfrq + 1 /* fcn+8 */
+			}
+		}
+		uni_setobs(raw, sat, code, idx, psr, -adr, dop, snr, lockt, plock, clock, parity, halfc)
+	}
+	return 1
+}
+
+/* decode OBSVMCMP/OBSVHCMP --------------------------------------------------*/
+func decode_uni_obsvmcmp(raw *Raw) int {
+	var (
+		p                                          = UNIHLEN
+		psr, adr, adr_rolls, lockt, dop, snr, freq float64
+		i, nobs, prn, sat, sys, code, idx, gfrq    int
+		plock, clock, parity, halfc                int
+	)
+	nobs = int(U4L(raw.Buff[p:]))
+	if raw.Len < UNIHLEN+4+nobs*24 {
+		Trace(2, "unicore obsvmcmp length error: len=%d nobs=%d\n", raw.Len, nobs)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[len(strings.TrimRight(string(raw.MsgType[:]), "\x00")):],
+			[]byte(fmt.Sprintf(" nobs=%d", nobs)))
+	}
+	for i, p = 0, p+4; i < nobs; i, p = i+1, p+24 {
+		if idx = uni_track_stat(U4L(raw.Buff[p:]), &sys, &code, &plock, &clock,
+			&parity, &halfc); idx < 0 {
+			continue
+		}
+		prn = int(U1(raw.Buff[p+17:]))
+		if sat = uni_satno(sys, prn, &code); sat == 0 {
+			Trace(3, "unicore obsvmcmp satellite number error: sys=%d,prn=%d\n", sys, prn)
+			continue
+		}
+		if sys == SYS_GLO {
+			gfrq = int(U1(raw.Buff[p+23:]) & 0x1F) /* GLONASS FCN+7 */
+			SatSys(sat, &prn)
+			if raw.NavData.Glo_fcn[prn-1] == 0 {
+				raw.NavData.Glo_fcn[prn-1] = gfrq + 1 /* fcn+8 */
+			}
+		}
+		dop = float64(exsign(U4L(raw.Buff[p+4:])&0xFFFFFFF, 28)) / 256.0
+		psr = float64(U4L(raw.Buff[p+7:])>>4)/128.0 + float64(U1(raw.Buff[p+11:]))*2097152.0
+
+		if freq = Sat2Freq(sat, uint8(code), &raw.NavData); freq != 0.0 {
+			adr = float64(I4L(raw.Buff[p+12:])) / 256.0
+			adr_rolls = (psr*freq/CLIGHT + adr) / MAXVAL
+			if adr_rolls <= 0 {
+				adr = -adr + MAXVAL*math.Floor(adr_rolls-0.5)
+			} else {
+				adr = -adr + MAXVAL*math.Floor(adr_rolls+0.5)
+			}
+		} else {
+			adr = 1e-9
+		}
+		lockt = float64(U4L(raw.Buff[p+18:])&0x1FFFFF) / 32.0 /* lock time */
+		snr = float64((U2L(raw.Buff[p+20:])&0x3FF)>>5) + 20.0
+
+		uni_setobs(raw, sat, code, idx, psr, adr, dop, snr, lockt, plock, clock, parity, halfc)
+	}
+	return 1
+}
+
+/* decode GPSEPH/QZSSEPH/GALEPH/BDSEPH ---------------------------------------*/
+func decode_uni_eph(raw *Raw, sys int) int {
+	var (
+		eph            Eph
+		p              = UNIHLEN
+		prn, sat, week int
+		tow, toc, ura  float64
+	)
+	if raw.Len < UNIHLEN+224 {
+		Trace(2, "unicore eph length error: len=%d\n", raw.Len)
+		return -1
+	}
+	prn = int(U4L(raw.Buff[p:]))
+	if sys == SYS_QZS && prn < MINPRNQZS {
+		prn += MINPRNQZS - 1
+	}
+	if sat = SatNo(sys, prn); sat == 0 {
+		Trace(2, "unicore eph satellite error: sys=%d prn=%d\n", sys, prn)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[len(strings.TrimRight(string(raw.MsgType[:]), "\x00")):],
+			[]byte(fmt.Sprintf(" prn=%d", prn)))
+	}
+	tow = R8L(raw.Buff[p+4:])
+	eph.Svh = int(U4L(raw.Buff[p+12:]))
+	eph.Iode = int(U4L(raw.Buff[p+16:]))
+	week = int(U4L(raw.Buff[p+24:]))
+	eph.Toes = R8L(raw.Buff[p+32:])
+	eph.A = R8L(raw.Buff[p+40:])
+	eph.Deln = R8L(raw.Buff[p+48:])
+	eph.M0 = R8L(raw.Buff[p+56:])
+	eph.E = R8L(raw.Buff[p+64:])
+	eph.Omg = R8L(raw.Buff[p+72:])
+	eph.Cuc = R8L(raw.Buff[p+80:])
+	eph.Cus = R8L(raw.Buff[p+88:])
+	eph.Crc = R8L(raw.Buff[p+96:])
+	eph.Crs = R8L(raw.Buff[p+104:])
+	eph.Cic = R8L(raw.Buff[p+112:])
+	eph.Cis = R8L(raw.Buff[p+120:])
+	eph.I0 = R8L(raw.Buff[p+128:])
+	eph.Idot = R8L(raw.Buff[p+136:])
+	eph.OMG0 = R8L(raw.Buff[p+144:])
+	eph.OMGd = R8L(raw.Buff[p+152:])
+	eph.Iodc = int(U4L(raw.Buff[p+160:]))
+	toc = R8L(raw.Buff[p+164:])
+	eph.Tgd[0] = R8L(raw.Buff[p+172:])
+	eph.F0 = R8L(raw.Buff[p+180:])
+	eph.F1 = R8L(raw.Buff[p+188:])
+	eph.F2 = R8L(raw.Buff[p+196:])
+	ura = R8L(raw.Buff[p+216:])
+
+	switch sys {
+	case SYS_GAL:
+		eph.Sva = sisaindex(ura)
+		eph.Iodc = eph.Iode
+		eph.Code = (1 << 0) + (1 << 2) + (1 << 9) /* data source: I/NAV E1-B */
+		if strings.Contains(raw.Opt, "-GALFNAV") {
+			return 0
+		}
+	case SYS_CMP:
+		eph.Sva = uraindex(ura)
+		eph.Svh &= 1
+	default:
+		eph.Sva = uraindex(ura)
+		eph.Fit = 4.0
+	}
+	eph.Sat = sat
+	if sys == SYS_CMP {
+		eph.Week = week                              /* bdt week */
+		eph.Toe = BDT2GpsT(BDT2Time(week, eph.Toes)) /* bdt . gpst */
+		eph.Toc = BDT2GpsT(BDT2Time(week, toc))      /* bdt . gpst */
+		eph.Ttr = BDT2GpsT(BDT2Time(week, tow))
+		if prn <= 5 || prn >= 59 {
+			eph.Flag = 2 /* nav type: GEO */
+		} else {
+			eph.Flag = 1 /* nav type: IGSO/MEO */
+		}
+	} else {
+		if raw.Time.Time != 0 {
+			eph.Toe = adjweek(raw.Time, eph.Toes)
+			eph.Toc = adjweek(raw.Time, toc)
+		} else {
+			eph.Toe = GpsT2Time(week, eph.Toes)
+			eph.Toc = GpsT2Time(week, toc)
+		}
+		eph.Ttr = raw.Time
+		Time2GpsT(eph.Toe, &eph.Week)
+	}
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if eph.Iode == raw.NavData.Ephs[sat-1].Iode &&
+			TimeDiff(eph.Toe, raw.NavData.Ephs[sat-1].Toe) == 0.0 &&
+			TimeDiff(eph.Toc, raw.NavData.Ephs[sat-1].Toc) == 0.0 {
+			return 0 /* unchanged */
+		}
+	}
+	raw.NavData.Ephs[sat-1] = eph
+	raw.EphSat = sat
+	raw.EphSet = 0
+	return 2
+}
+
+/* decode GLOEPH -------------------------------------------------------------*/
+func decode_uni_gloeph(raw *Raw) int {
+	var (
+		p              = UNIHLEN
+		geph           GEph
+		tow, tof, toff float64
+		prn, sat, week int
+	)
+	if raw.Len < UNIHLEN+144 {
+		Trace(2, "unicore gloeph length error: len=%d\n", raw.Len)
+		return -1
+	}
+	prn = int(U2L(raw.Buff[p:])) - 37
+
+	if sat = SatNo(SYS_GLO, prn); sat == 0 {
+		Trace(2, "unicore gloeph prn error: prn=%d\n", prn)
+		return -1
+	}
+	if raw.OutType > 0 {
+		copy(raw.MsgType[len(strings.TrimRight(string(raw.MsgType[:]), "\x00")):],
+			[]byte(fmt.Sprintf(" prn=%d", prn)))
+	}
+	geph.Frq = int(U2L(raw.Buff[p+2:])) + OFF_FRQNO
+	week = int(U2L(raw.Buff[p+6:]))
+	tow = math.Floor(float64(U4L(raw.Buff[p+8:]))/1000.0 + 0.5) /* rounded to integer sec */
+	toff = float64(U4L(raw.Buff[p+12:]))
+	geph.Iode = int(U4L(raw.Buff[p+20:]) & 0x7F)
+	geph.Svh = 1
+	if U4L(raw.Buff[p+24:]) < 4 {
+		geph.Svh = 0 /* 0:healthy,1:unhealthy */
+	}
+	geph.Pos[0] = R8L(raw.Buff[p+28:])
+	geph.Pos[1] = R8L(raw.Buff[p+36:])
+	geph.Pos[2] = R8L(raw.Buff[p+44:])
+	geph.Vel[0] = R8L(raw.Buff[p+52:])
+	geph.Vel[1] = R8L(raw.Buff[p+60:])
+	geph.Vel[2] = R8L(raw.Buff[p+68:])
+	geph.Acc[0] = R8L(raw.Buff[p+76:])
+	geph.Acc[1] = R8L(raw.Buff[p+84:])
+	geph.Acc[2] = R8L(raw.Buff[p+92:])
+	geph.Taun = R8L(raw.Buff[p+100:])
+	geph.DTaun = R8L(raw.Buff[p+108:])
+	geph.Gamn = R8L(raw.Buff[p+116:])
+	tof = float64(U4L(raw.Buff[p+124:])) - toff /* glonasst.gpst */
+	geph.Age = int(U4L(raw.Buff[p+136:]))
+	geph.Toe = GpsT2Time(week, tow)
+	tof += math.Floor(tow/86400.0) * 86400
+	if tof < tow-43200.0 {
+		tof += 86400.0
+	} else if tof > tow+43200.0 {
+		tof -= 86400.0
+	}
+	geph.Tof = GpsT2Time(week, tof)
+
+	if !strings.Contains(raw.Opt, "-EPHALL") {
+		if math.Abs(TimeDiff(geph.Toe, raw.NavData.Geph[prn-1].Toe)) < 1.0 &&
+			geph.Svh == raw.NavData.Geph[prn-1].Svh {
+			return 0
+		} /* unchanged */
+	}
+	if raw.NavData.Glo_fcn[prn-1] == 0 {
+		raw.NavData.Glo_fcn[prn-1] = geph.Frq + 8
+	}
+	geph.Sat = sat
+	raw.NavData.Geph[prn-1] = geph
+	raw.EphSat = sat
+	raw.EphSet = 0
+	return 2
+}
+
+/* decode IONUTC -------------------------------------------------------------*/
+func decode_uni_ionutc(raw *Raw) int {
+	p := UNIHLEN
+
+	if raw.Len < UNIHLEN+108 {
+		Trace(2, "unicore ionutc length error: len=%d\n", raw.Len)
+		return -1
+	}
+	for i := 0; i < 8; i++ {
+		raw.NavData.Ion_gps[i] = R8L(raw.Buff[p+i*8:])
+	}
+	raw.NavData.Utc_gps[0] = R8L(raw.Buff[p+72:])           /* A0 */
+	raw.NavData.Utc_gps[1] = R8L(raw.Buff[p+80:])           /* A1 */
+	raw.NavData.Utc_gps[2] = float64(U4L(raw.Buff[p+68:]))  /* tot */
+	raw.NavData.Utc_gps[3] = float64(U4L(raw.Buff[p+64:]))  /* WNt */
+	raw.NavData.Utc_gps[4] = float64(I4L(raw.Buff[p+96:]))  /* dt_LS */
+	raw.NavData.Utc_gps[5] = float64(U4L(raw.Buff[p+88:]))  /* WN_LSF */
+	raw.NavData.Utc_gps[6] = float64(U4L(raw.Buff[p+92:]))  /* DN */
+	raw.NavData.Utc_gps[7] = float64(I4L(raw.Buff[p+100:])) /* dt_LSF */
+	return 9
+}
+
+/* decode unicore message ----------------------------------------------------*/
+func decode_unicore(raw *Raw) int {
+	var (
+		tow        float64
+		tstr       string
+		week, stat int
+	)
+	ctype := U2L(raw.Buff[4:])
+
+	Trace(3, "decode_unicore: type=%3d len=%d\n", ctype, raw.Len)
+
+	/* check crc32 */
+	if Rtk_CRC32(raw.Buff[:], raw.Len) != U4L(raw.Buff[raw.Len:]) {
+		Trace(2, "unicore crc error: type=%3d len=%d\n", ctype, raw.Len)
+		return -1
+	}
+	stat = int(U1(raw.Buff[9:]))
+	week = int(U2L(raw.Buff[10:]))
+
+	if stat == 20 || week == 0 { /* time status unknown */
+		Trace(3, "unicore time error: type=%3d stat=%d week=%d\n", ctype, stat, week)
+		return 0
+	}
+	tow = float64(U4L(raw.Buff[12:])) * 0.001
+	raw.Time = GpsT2Time(week, tow)
+
+	if raw.OutType > 0 {
+		Time2Str(raw.Time, &tstr, 2)
+		copy(raw.MsgType[:], []byte(fmt.Sprintf("UNICORE %4d (%4d): %s", ctype, raw.Len, tstr)))
+	}
+	switch ctype {
+	case ID_UNI_OBSVM:
+		return decode_uni_obsvm(raw)
+	case ID_UNI_OBSVH:
+		if strings.Contains(raw.Opt, "-SLAVE") {
+			return decode_uni_obsvm(raw)
+		}
+	case ID_UNI_OBSVMCMP:
+		return decode_uni_obsvmcmp(raw)
+	case ID_UNI_OBSVHCMP:
+		if strings.Contains(raw.Opt, "-SLAVE") {
+			return decode_uni_obsvmcmp(raw)
+		}
+	case ID_UNI_GPSEPH:
+		return decode_uni_eph(raw, SYS_GPS)
+	case ID_UNI_QZSSEPH:
+		return decode_uni_eph(raw, SYS_QZS)
+	case ID_UNI_GALEPH:
+		return decode_uni_eph(raw, SYS_GAL)
+	case ID_UNI_BDSEPH:
+		return decode_uni_eph(raw, SYS_CMP)
+	case ID_UNI_GLOEPH:
+		return decode_uni_gloeph(raw)
+	case ID_UNI_IONUTC:
+		return decode_uni_ionutc(raw)
+	}
+	return 0
+}
+
+/* sync header ---------------------------------------------------------------*/
+func sync_unicore(buff []uint8, data uint8) int {
+	buff[0] = buff[1]
+	buff[1] = buff[2]
+	buff[2] = data
+	if buff[0] == UNISYNC1 && buff[1] == UNISYNC2 && buff[2] == UNISYNC3 {
+		return 1
+	}
+	return 0
+}
+
+/* input Unicore raw data from stream ------------------------------------------
+* fetch next Unicore raw data and input a mesasge from stream
+* args   : raw *Raw       IO  receiver raw data control struct
+*          uint8 data     I   stream data (1 byte)
+* return : status (-1: error message, 0: no message, 1: input observation data,
+*                  2: input ephemeris, 9: input ion/utc parameter)
+*
+* notes  : to specify input options, set raw.Opt to the following option
+*          strings separated by spaces.
+*
+*          -EPHALL  : input all ephemerides
+*          -SLAVE   : input observation data of slave antenna (OBSVH,OBSVHCMP)
+*          -GALFNAV : select F/NAV for Galileo ephemeris (GALEPH ignored)
+*          -GLxx,-RLxx,...: select signal by code priority (see GetCodePri())
+*-----------------------------------------------------------------------------*/
+func input_unicore(raw *Raw, data uint8) int {
+	Trace(5, "input_unicore: data=%02x\n", data)
+
+	/* synchronize frame */
+	if raw.NumByte == 0 {
+		if sync_unicore(raw.Buff[:], data) > 0 {
+			raw.NumByte = 3
+		}
+		return 0
+	}
+	raw.Buff[raw.NumByte] = data
+	raw.NumByte++
+	raw.Len = int(U2L(raw.Buff[6:])) + UNIHLEN
+	if raw.NumByte == 8 && raw.Len > MAXRAWLEN-4 {
+		Trace(2, "unicore length error: len=%d\n", raw.Len)
+		raw.NumByte = 0
+		return -1
+	}
+	if raw.NumByte < 8 || raw.NumByte < raw.Len+4 {
+		return 0
+	}
+	raw.NumByte = 0
+
+	/* decode unicore message */
+	return decode_unicore(raw)
+}
+
+/* input Unicore raw data from file --------------------------------------------
+* fetch next Unicore raw data and input a message from file
+* args   : raw *Raw       IO  receiver raw data control struct
+*          fp *os.File    I   file pointer
+* return : status(-2: end of file, -1...9: same as above)
+*-----------------------------------------------------------------------------*/
+func input_unicoref(raw *Raw, fp *os.File) int {
+	var c [1]byte
+
+	Trace(4, "input_unicoref:\n")
+
+	/* synchronize frame */
+	if raw.NumByte == 0 {
+		for i := 0; ; i++ {
+			if _, err := fp.Read(c[:]); err == io.EOF {
+				return -2
+			}
+			if sync_unicore(raw.Buff[:], c[0]) > 0 {
+				break
+			}
+			if i >= 4096 {
+				return 0
+			}
+		}
+	}
+	if n, _ := io.ReadFull(fp, raw.Buff[3:8]); n < 5 {
+		return -2
+	}
+	raw.NumByte = 8
+
+	if raw.Len = int(U2L(raw.Buff[6:])) + UNIHLEN; raw.Len > MAXRAWLEN-4 {
+		Trace(2, "unicore length error: len=%d\n", raw.Len)
+		raw.NumByte = 0
+		return -1
+	}
+	if n, _ := io.ReadFull(fp, raw.Buff[8:raw.Len+4]); n < raw.Len-4 {
+		return -2
+	}
+	raw.NumByte = 0
+
+	/* decode unicore message */
+	return decode_unicore(raw)
+}
@@ -0,0 +1,791 @@
+/*------------------------------------------------------------------------------
+* websocket.go : websocket stream functions
+*
+*          Copyright (C) 2026 by Feng Xuebin, All rights reserved.
+*
+* references :
+*     [1] RFC 6455, The WebSocket Protocol, December 2011
+*
+* version : $Revision:$ $Date:$
+* history : 2026/10/16 1.0  new, websocket server broadcasting written data to
+*                           all connected clients and websocket client
+*           2026/10/16 1.1  reject connection over MAXCLI by 503 before upgrade
+*-----------------------------------------------------------------------------*/
+package gnssgo
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	WS_PORT      = 80      /* default websocket port */
+	WS_TLS_PORT  = 443     /* default websocket port for tls */
+	WS_TIMEOUT   = 10      /* handshake/send timeout (s) */
+	WS_NQUEUE    = 256     /* max number of queued frames for client */
+	WS_BUFFSIZE  = 65536   /* max size of received data buffer (bytes) */
+	WS_MAXFRAME  = 1048576 /* max size of received frame payload (bytes) */
+	WS_GUID      = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	WS_OP_CONT   = 0x0 /* websocket opcode: continuation frame */
+	WS_OP_TEXT   = 0x1 /* websocket opcode: text frame */
+	WS_OP_BINARY = 0x2 /* websocket opcode: binary frame */
+	WS_OP_CLOSE  = 0x8 /* websocket opcode: connection close */
+	WS_OP_PING   = 0x9 /* websocket opcode: ping */
+	WS_OP_PONG   = 0xA /* websocket opcode: pong */
+)
+
+type ws_con struct { /* websocket server connection type */
+	sock  net.Conn    /* socket */
+	addr  string      /* remote address */
+	path  string      /* request path */
+	tcon  Gtime       /* connect time (utc) */
+	queue chan []byte /* queue of frames to send */
+	nsend uint64      /* sent bytes */
+	ndrop uint64      /* dropped frames by queue overflow */
+	once  sync.Once   /* close once */
+}
+
+type WsSvr struct { /* websocket server type */
+	state int          /* state (0:close,1:wait,2:connect) */
+	saddr string       /* address to listen */
+	port  int          /* port */
+	path  string       /* request path ("/": any path) */
+	text  int          /* send text frame (0:binary,1:text) */
+	conf  *tls.Config  /* tls configuration (nil: no tls) */
+	ls    net.Listener /* listener */
+	cli   []*ws_con    /* connected clients */
+	nacc  int          /* number of accepted clients */
+	buff  []byte       /* data received from clients */
+	lock  sync.Mutex   /* lock flag */
+}
+
+type WsClient struct { /* websocket client type */
+	state   int           /* state (0:close,1:wait,2:connect) */
+	addr    string        /* server address (addr:port) */
+	host    string        /* host for request */
+	path    string        /* request path */
+	user    string        /* user for basic authentication */
+	passwd  string        /* password for basic authentication */
+	text    int           /* send text frame (0:binary,1:text) */
+	conf    *tls.Config   /* tls configuration (nil: no tls) */
+	sock    net.Conn      /* socket */
+	tirecon int           /* reconnect interval (ms) */
+	ncon    int           /* number of connections */
+	nrecv   uint32        /* number of received frames */
+	buff    []byte        /* received data buffer */
+	lock    sync.Mutex    /* lock flag for state, socket and buffer */
+	wlock   sync.Mutex    /* lock flag for socket write */
+	done    chan struct{} /* close request */
+}
+
+/* generate websocket frame --------------------------------------------------*/
+func ws_frame(opcode byte, payload []byte, mask bool) []byte {
+	var key [4]byte
+
+	n := len(payload)
+	p := []byte{0x80 | opcode}
+	mb := byte(0)
+	if mask {
+		mb = 0x80
+	}
+	if n < 126 {
+		p = append(p, mb|byte(n))
+	} else if n < 65536 {
+		p = append(p, mb|126, byte(n>>8), byte(n))
+	} else {
+		p = append(p, mb|127)
+		for i := 7; i >= 0; i-- {
+			p = append(p, byte(uint64(n)>>(8*i)))
+		}
+	}
+	if !mask {
+		return append(p, payload...)
+	}
+	rand.Read(key[:])
+	p = append(p, key[:]...)
+	for i := 0; i < n; i++ {
+		p = append(p, payload[i]^key[i&3])
+	}
+	return p
+}
+
+/* receive websocket frame -----------------------------------------------------
+* receive websocket frame
+* args   : bufio.Reader *rd I  reader of socket
+*          []byte *payload  O  payload (unmasked)
+* return : opcode (WS_OP_???), error
+*-----------------------------------------------------------------------------*/
+func ws_recvframe(rd *bufio.Reader, payload *[]byte) (byte, error) {
+	var h [14]byte
+
+	if _, err := io.ReadFull(rd, h[:2]); err != nil {
+		return 0, err
+	}
+	opcode, n, i := h[0]&0x0F, uint64(h[1]&0x7F), 2
+
+	if n == 126 {
+		if _, err := io.ReadFull(rd, h[2:4]); err != nil {
+			return 0, err
+		}
+		n, i = uint64(h[2])<<8|uint64(h[3]), 4
+	} else if n == 127 {
+		if _, err := io.ReadFull(rd, h[2:10]); err != nil {
+			return 0, err
+		}
+		n = 0
+		for j := 2; j < 10; j++ {
+			n = n<<8 | uint64(h[j])
+		}
+		i = 10
+	}
+	if n > WS_MAXFRAME {
+		return 0, fmt.Errorf("frame size error (%d)", n)
+	}
+	if h[1]&0x80 != 0 {
+		if _, err := io.ReadFull(rd, h[i:i+4]); err != nil {
+			return 0, err
+		}
+	}
+	*payload = make([]byte, n)
+	if _, err := io.ReadFull(rd, *payload); err != nil {
+		return 0, err
+	}
+	if h[1]&0x80 != 0 {
+		for j := range *payload {
+			(*payload)[j] ^= h[i+(j&3)]
+		}
+	}
+	return opcode, nil
+}
+
+/* websocket accept key ------------------------------------------------------*/
+func ws_acceptkey(key string) string {
+	sum := sha1.Sum([]byte(key + WS_GUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+/* decode websocket path -------------------------------------------------------
+* decode websocket path [ws[s]://][user[:passwd]@][addr][:port][/path][::opt...]
+*   options: ::text         send data as text frame (default: binary frame)
+*            ::tls[...]     tls options (see decodetls())
+*   the prefix wss:// enables tls as ::tls.
+*-----------------------------------------------------------------------------*/
+func ws_decodepath(path string, ctype int, addr, port, user, passwd, rpath *string,
+	text *int, conf **tls.Config, msg *string) int {
+	var opts string
+
+	*text = 0
+	if strings.HasPrefix(path, "wss://") {
+		path, opts = path[6:], "::tls"
+	} else if strings.HasPrefix(path, "ws://") {
+		path = path[5:]
+	}
+	if i := strings.Index(path, "::"); i >= 0 {
+		for _, opt := range strings.Split(path[i+2:], "::") {
+			if opt == "text" {
+				*text = 1
+			} else {
+				opts += "::" + opt
+			}
+		}
+		path = path[:i]
+	}
+	opts = path + opts
+	if decodetls(&opts, ctype, conf, msg) == 0 {
+		return 0
+	}
+	DecodeTcpPath(path, addr, port, user, passwd, rpath, nil)
+	*rpath = "/" + *rpath
+	return 1
+}
+
+/* close websocket server connection (after queued frames sent) --------------*/
+func (con *ws_con) close() {
+	con.once.Do(func() { close(con.queue) })
+}
+
+/* websocket server connection sender ----------------------------------------*/
+func (con *ws_con) sender() {
+	for p := range con.queue {
+		con.sock.SetWriteDeadline(time.Now().Add(WS_TIMEOUT * time.Second))
+		if _, err := con.sock.Write(p); err != nil {
+			Tracet(2, "wssvr: send error addr=%s err=%s\n", con.addr, err.Error())
+			con.sock.Close()
+			for range con.queue { /* discard queued frames */
+			}
+			return
+		}
+		atomic.AddUint64(&con.nsend, uint64(len(p)))
+	}
+	con.sock.Close()
+}
+
+/* send frame to websocket server connection ---------------------------------*/
+func (con *ws_con) send(p []byte) (ok bool) {
+	defer func() { /* queue closed */
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	select {
+	case con.queue <- p:
+		return true
+	default:
+		atomic.AddUint64(&con.ndrop, 1)
+		return false
+	}
+}
+
+/* delete websocket server connection ----------------------------------------*/
+func (wssvr *WsSvr) delcon(con *ws_con) {
+	con.close()
+
+	wssvr.lock.Lock()
+	defer wssvr.lock.Unlock()
+
+	for i := range wssvr.cli {
+		if wssvr.cli[i] == con {
+			wssvr.cli = append(wssvr.cli[:i], wssvr.cli[i+1:]...)
+			break
+		}
+	}
+	if wssvr.state > 0 {
+		wssvr.state = 1
+		if len(wssvr.cli) > 0 {
+			wssvr.state = 2
+		}
+	}
+	Tracet(3, "wssvr: disconnect addr=%s ncli=%d\n", con.addr, len(wssvr.cli))
+}
+
+/* handle websocket server connection ----------------------------------------*/
+func (wssvr *WsSvr) handle(sock net.Conn) {
+	var payload []byte
+
+	addr := sock.RemoteAddr().String()
+
+	if wssvr.conf != nil { /* tls handshake */
+		c := tls.Server(sock, wssvr.conf)
+		c.SetDeadline(time.Now().Add(TLS_TIMEOUT * time.Second))
+		if err := c.Handshake(); err != nil {
+			Tracet(2, "wssvr: tls handshake error addr=%s err=%s\n", addr, err.Error())
+			sock.Close()
+			return
+		}
+		sock = c
+	}
+	/* opening handshake */
+	sock.SetDeadline(time.Now().Add(WS_TIMEOUT * time.Second))
+	rd := bufio.NewReader(sock)
+	req, err := http.ReadRequest(rd)
+	if err != nil {
+		Tracet(2, "wssvr: request error addr=%s err=%s\n", addr, err.Error())
+		sock.Close()
+		return
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || len(key) == 0 {
+		Tracet(2, "wssvr: not websocket request addr=%s\n", addr)
+		sock.Write([]byte("HTTP/1.1 400 Bad Request\r\nConnection: close\r\n\r\n"))
+		sock.Close()
+		return
+	}
+	if wssvr.path != "/" && req.URL.Path != wssvr.path {
+		Tracet(2, "wssvr: path error addr=%s path=%s\n", addr, req.URL.Path)
+		sock.Write([]byte("HTTP/1.1 404 Not Found\r\nConnection: close\r\n\r\n"))
+		sock.Close()
+		return
+	}
+	con := &ws_con{sock: sock, addr: addr, path: req.URL.Path, tcon: TimeGet(),
+		queue: make(chan []byte, WS_NQUEUE)}
+
+	/* add connection before upgrade (frames queued until sender started) */
+	wssvr.lock.Lock()
+	if wssvr.state == 0 || len(wssvr.cli) >= MAXCLI {
+		wssvr.lock.Unlock()
+		Tracet(2, "wssvr: too many connections addr=%s\n", addr)
+		sock.Write([]byte("HTTP/1.1 503 Service Unavailable\r\nConnection: close\r\n\r\n"))
+		sock.Close()
+		return
+	}
+	wssvr.cli = append(wssvr.cli, con)
+	wssvr.nacc++
+	wssvr.state = 2
+	wssvr.lock.Unlock()
+
+	rsp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n" +
+		"Connection: Upgrade\r\nSec-WebSocket-Accept: " + ws_acceptkey(key) + "\r\n\r\n"
+	if _, err = sock.Write([]byte(rsp)); err != nil {
+		wssvr.delcon(con)
+		sock.Close()
+		return
+	}
+	sock.SetDeadline(time.Time{})
+
+	Tracet(3, "wssvr: connect addr=%s path=%s\n", addr, con.path)
+
+	go con.sender()
+
+	/* receive frames from client */
+	for {
+		opcode, err := ws_recvframe(rd, &payload)
+		if err != nil {
+			break
+		}
+		if opcode == WS_OP_CLOSE {
+			con.send(ws_frame(WS_OP_CLOSE, payload, false))
+			break
+		} else if opcode == WS_OP_PING {
+			con.send(ws_frame(WS_OP_PONG, payload, false))
+		} else if opcode <= WS_OP_BINARY {
+			wssvr.lock.Lock()
+			wssvr.buff = append(wssvr.buff, payload...)
+			if n := len(wssvr.buff) - WS_BUFFSIZE; n > 0 { /* drop oldest */
+				wssvr.buff = wssvr.buff[n:]
+			}
+			wssvr.lock.Unlock()
+		}
+	}
+	wssvr.delcon(con)
+}
+
+/* websocket server accept thread --------------------------------------------*/
+func (wssvr *WsSvr) acceptthread(ls net.Listener) {
+	for {
+		sock, err := ls.Accept()
+		if err != nil {
+			Tracet(3, "wssvr: accept exit err=%s\n", err.Error())
+			return
+		}
+		go wssvr.handle(sock)
+	}
+}
+
+/* open websocket server -------------------------------------------------------
+* open websocket server stream
+* args   : string path      I   websocket server path
+*                                 [ws[s]://][addr]:port[/path][::text][::tls...]
+*          string *msg      O   error message
+* return : websocket server (nil: error)
+* notes  : data written is sent to all connected clients as websocket frames.
+*          data received from clients is read as the input.
+*-----------------------------------------------------------------------------*/
+func OpenWsSvr(path string, msg *string) *WsSvr {
+	wssvr := new(WsSvr)
+	if wssvr.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return wssvr
+}
+
+/* open websocket server port ------------------------------------------------*/
+func (wssvr *WsSvr) Open(path string, mode int, msg *string) int {
+	var port string
+
+	Tracet(3, "openwssvr: path=%s\n", path)
+
+	if ws_decodepath(path, 0, &wssvr.saddr, &port, nil, nil, &wssvr.path, &wssvr.text,
+		&wssvr.conf, msg) == 0 {
+		return 0
+	}
+	if n, _ := fmt.Sscanf(port, "%d", &wssvr.port); n < 1 {
+		*msg = fmt.Sprintf("port error: %s", port)
+		Tracet(1, "openwssvr: port error port=%s\n", port)
+		return 0
+	}
+	ls, err := net.Listen("tcp", net.JoinHostPort(wssvr.saddr, port))
+	if err != nil {
+		*msg = fmt.Sprintf("bind error: %d", wssvr.port)
+		Tracet(1, "openwssvr: bind error port=%d err=%s\n", wssvr.port, err.Error())
+		return 0
+	}
+	wssvr.ls = ls
+	wssvr.state = 1
+
+	go wssvr.acceptthread(ls)
+	return 1
+}
+
+/* close websocket server ----------------------------------------------------*/
+func (wssvr *WsSvr) Close() {
+	Tracet(3, "closewssvr: state=%d\n", wssvr.state)
+
+	wssvr.lock.Lock()
+	cli := wssvr.cli
+	wssvr.cli = nil
+	wssvr.state = 0
+	wssvr.lock.Unlock()
+
+	wssvr.ls.Close()
+	for _, con := range cli {
+		con.send(ws_frame(WS_OP_CLOSE, []byte{0x03, 0xE9}, false)) /* going away */
+		con.close()
+	}
+}
+
+/* read websocket server -----------------------------------------------------*/
+func (wssvr *WsSvr) Read(buff []byte, n int, msg *string) int {
+	Tracet(4, "readwssvr: n=%d\n", n)
+
+	wssvr.lock.Lock()
+	defer wssvr.lock.Unlock()
+
+	nr := copy(buff[:n], wssvr.buff)
+	wssvr.buff = wssvr.buff[nr:]
+	return nr
+}
+
+/* write websocket server (broadcast to clients) -----------------------------*/
+func (wssvr *WsSvr) Write(buff []byte, n int, msg *string) int {
+	var ns int
+
+	Tracet(4, "writewssvr: n=%d\n", n)
+
+	opcode := byte(WS_OP_BINARY)
+	if wssvr.text != 0 {
+		opcode = WS_OP_TEXT
+	}
+	p := ws_frame(opcode, buff[:n], false)
+
+	wssvr.lock.Lock()
+	defer wssvr.lock.Unlock()
+
+	for _, con := range wssvr.cli {
+		if con.send(p) {
+			ns = n
+		} else {
+			Tracet(2, "writewssvr: queue overflow addr=%s\n", con.addr)
+		}
+	}
+	return ns
+}
+
+/* get state websocket server ------------------------------------------------*/
+func (wssvr *WsSvr) State() int {
+	if wssvr == nil {
+		return 0
+	}
+	wssvr.lock.Lock()
+	defer wssvr.lock.Unlock()
+	return wssvr.state
+}
+
+/* get extended state websocket server ---------------------------------------*/
+func (wssvr *WsSvr) StateX(msg *string) int {
+	state := wssvr.State()
+
+	*msg += "wssvr:\n"
+	*msg += fmt.Sprintf("  state   = %d\n", state)
+	if state == 0 {
+		return 0
+	}
+	wssvr.lock.Lock()
+	defer wssvr.lock.Unlock()
+
+	*msg += fmt.Sprintf("  saddr   = %s\n", wssvr.saddr)
+	*msg += fmt.Sprintf("  port    = %d\n", wssvr.port)
+	*msg += fmt.Sprintf("  path    = %s\n", wssvr.path)
+	*msg += fmt.Sprintf("  text    = %d\n", wssvr.text)
+	*msg += fmt.Sprintf("  tls     = %t\n", wssvr.conf != nil)
+	*msg += fmt.Sprintf("  nacc    = %d\n", wssvr.nacc)
+	for i, con := range wssvr.cli {
+		*msg += fmt.Sprintf("  cli#%d:\n", i)
+		*msg += fmt.Sprintf("    addr  = %s\n", con.addr)
+		*msg += fmt.Sprintf("    path  = %s\n", con.path)
+		*msg += fmt.Sprintf("    tcon  = %s\n", TimeStr(con.tcon, 0))
+		*msg += fmt.Sprintf("    nsend = %d\n", atomic.LoadUint64(&con.nsend))
+		*msg += fmt.Sprintf("    ndrop = %d\n", atomic.LoadUint64(&con.ndrop))
+	}
+	return state
+}
+
+/* send websocket client frame -----------------------------------------------*/
+func (wscli *WsClient) sendframe(sock net.Conn, opcode byte, payload []byte) int {
+	p := ws_frame(opcode, payload, true) /* client frame masked */
+
+	wscli.wlock.Lock()
+	defer wscli.wlock.Unlock()
+
+	sock.SetWriteDeadline(time.Now().Add(WS_TIMEOUT * time.Second))
+	if _, err := sock.Write(p); err != nil {
+		Tracet(2, "wscli: send error err=%s\n", err.Error())
+		sock.Close() /* request reconnect */
+		return 0
+	}
+	return 1
+}
+
+/* connect to websocket server -----------------------------------------------*/
+func (wscli *WsClient) connect(rd **bufio.Reader) net.Conn {
+	var key [16]byte
+
+	Tracet(3, "wscli connect: addr=%s path=%s\n", wscli.addr, wscli.path)
+
+	sock, err := net.DialTimeout("tcp", wscli.addr, WS_TIMEOUT*time.Second)
+	if err != nil {
+		Tracet(2, "wscli connect: connect error addr=%s err=%s\n", wscli.addr, err.Error())
+		return nil
+	}
+	if wscli.conf != nil { /* tls handshake */
+		c := tls.Client(sock, wscli.conf)
+		c.SetDeadline(time.Now().Add(TLS_TIMEOUT * time.Second))
+		if err = c.Handshake(); err != nil {
+			Tracet(2, "wscli connect: tls handshake error addr=%s err=%s\n", wscli.addr,
+				err.Error())
+			sock.Close()
+			return nil
+		}
+		sock = c
+	}
+	/* opening handshake */
+	rand.Read(key[:])
+	skey := base64.StdEncoding.EncodeToString(key[:])
+	req := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\n", wscli.path,
+		wscli.host)
+	req += fmt.Sprintf("Connection: Upgrade\r\nSec-WebSocket-Key: %s\r\n", skey)
+	req += fmt.Sprintf("Sec-WebSocket-Version: 13\r\nUser-Agent: %s\r\n", NTRIP_AGENT)
+	if len(wscli.user) > 0 {
+		auth := base64.StdEncoding.EncodeToString([]byte(wscli.user + ":" + wscli.passwd))
+		req += fmt.Sprintf("Authorization: Basic %s\r\n", auth)
+	}
+	req += "\r\n"
+
+	sock.SetDeadline(time.Now().Add(WS_TIMEOUT * time.Second))
+	if _, err = sock.Write([]byte(req)); err != nil {
+		sock.Close()
+		return nil
+	}
+	*rd = bufio.NewReader(sock)
+	rsp, err := http.ReadResponse(*rd, nil)
+	if err == nil && (rsp.StatusCode != http.StatusSwitchingProtocols ||
+		rsp.Header.Get("Sec-WebSocket-Accept") != ws_acceptkey(skey)) {
+		err = errors.New(rsp.Status)
+	}
+	if err != nil {
+		Tracet(2, "wscli connect: handshake error addr=%s err=%s\n", wscli.addr,
+			err.Error())
+		sock.Close()
+		return nil
+	}
+	sock.SetDeadline(time.Time{})
+	return sock
+}
+
+/* receive websocket frames until disconnected -------------------------------*/
+func (wscli *WsClient) recvloop(sock net.Conn, rd *bufio.Reader) {
+	var payload []byte
+
+	for {
+		opcode, err := ws_recvframe(rd, &payload)
+		if err != nil {
+			Tracet(2, "wscli: receive error addr=%s err=%s\n", wscli.addr, err.Error())
+			return
+		}
+		Tracet(4, "wscli: receive opcode=%d len=%d\n", opcode, len(payload))
+
+		switch opcode {
+		case WS_OP_CONT, WS_OP_TEXT, WS_OP_BINARY:
+			wscli.lock.Lock()
+			wscli.buff = append(wscli.buff, payload...)
+			if n := len(wscli.buff) - WS_BUFFSIZE; n > 0 { /* drop oldest */
+				wscli.buff = wscli.buff[n:]
+			}
+			wscli.nrecv++
+			wscli.lock.Unlock()
+		case WS_OP_PING:
+			wscli.sendframe(sock, WS_OP_PONG, payload)
+		case WS_OP_CLOSE:
+			Tracet(3, "wscli: close by server addr=%s\n", wscli.addr)
+			wscli.sendframe(sock, WS_OP_CLOSE, payload)
+			return
+		}
+	}
+}
+
+/* websocket client thread ---------------------------------------------------*/
+func wsclithread(wscli *WsClient) {
+	var rd *bufio.Reader
+
+	Tracet(3, "wsclithread: addr=%s\n", wscli.addr)
+
+	for {
+		if sock := wscli.connect(&rd); sock != nil {
+			wscli.lock.Lock()
+			if wscli.state == 0 { /* closed while connecting */
+				wscli.lock.Unlock()
+				sock.Close()
+				return
+			}
+			wscli.sock = sock
+			wscli.state = 2
+			wscli.ncon++
+			wscli.lock.Unlock()
+
+			wscli.recvloop(sock, rd)
+
+			wscli.lock.Lock()
+			wscli.sock = nil
+			if wscli.state > 0 {
+				wscli.state = 1
+			}
+			wscli.lock.Unlock()
+			sock.Close()
+		}
+		select {
+		case <-wscli.done:
+			return
+		case <-time.After(time.Duration(wscli.tirecon) * time.Millisecond):
+		}
+	}
+}
+
+/* open websocket client -------------------------------------------------------
+* open websocket client stream
+* args   : string path      I   websocket client path
+*                                 [ws[s]://][user[:passwd]@]addr[:port][/path]
+*                                 [::text][::tls...]
+*          string *msg      O   error message
+* return : websocket client (nil: error)
+* notes  : data frames received from the server are read as the input.
+*          reconnect to the server by the reconnect interval.
+*-----------------------------------------------------------------------------*/
+func OpenWsClient(path string, msg *string) *WsClient {
+	wscli := new(WsClient)
+	if wscli.Open(path, STR_MODE_RW, msg) == 0 {
+		return nil
+	}
+	return wscli
+}
+
+/* open websocket client port ------------------------------------------------*/
+func (wscli *WsClient) Open(path string, mode int, msg *string) int {
+	var addr, port string
+
+	Tracet(3, "openwscli: path=%s\n", path)
+
+	if ws_decodepath(path, 1, &addr, &port, &wscli.user, &wscli.passwd, &wscli.path,
+		&wscli.text, &wscli.conf, msg) == 0 {
+		return 0
+	}
+	if len(addr) == 0 {
+		*msg = "no address"
+		Tracet(2, "openwscli: no address path=%s\n", path)
+		return 0
+	}
+	wscli.host = addr
+	if len(port) == 0 {
+		if wscli.conf != nil {
+			port = strconv.Itoa(WS_TLS_PORT)
+		} else {
+			port = strconv.Itoa(WS_PORT)
+		}
+	} else {
+		wscli.host = addr + ":" + port
+	}
+	if wscli.conf != nil && len(wscli.conf.ServerName) == 0 {
+		wscli.conf.ServerName = addr
+	}
+	wscli.addr = net.JoinHostPort(addr, port)
+	wscli.tirecon = ticonnect
+	wscli.state = 1
+	wscli.done = make(chan struct{})
+
+	go wsclithread(wscli)
+	return 1
+}
+
+/* close websocket client ----------------------------------------------------*/
+func (wscli *WsClient) Close() {
+	Tracet(3, "closewscli: state=%d\n", wscli.state)
+
+	wscli.lock.Lock()
+	sock := wscli.sock
+	wscli.state = 0
+	wscli.lock.Unlock()
+
+	close(wscli.done)
+	if sock != nil {
+		wscli.sendframe(sock, WS_OP_CLOSE, []byte{0x03, 0xE8}) /* normal closure */
+		sock.Close()
+	}
+}
+
+/* read websocket client -----------------------------------------------------*/
+func (wscli *WsClient) Read(buff []byte, n int, msg *string) int {
+	Tracet(4, "readwscli: n=%d\n", n)
+
+	wscli.lock.Lock()
+	defer wscli.lock.Unlock()
+
+	nr := copy(buff[:n], wscli.buff)
+	wscli.buff = wscli.buff[nr:]
+	return nr
+}
+
+/* write websocket client ----------------------------------------------------*/
+func (wscli *WsClient) Write(buff []byte, n int, msg *string) int {
+	Tracet(4, "writewscli: n=%d\n", n)
+
+	wscli.lock.Lock()
+	sock := wscli.sock
+	wscli.lock.Unlock()
+
+	if sock == nil {
+		return 0
+	}
+	opcode := byte(WS_OP_BINARY)
+	if wscli.text != 0 {
+		opcode = WS_OP_TEXT
+	}
+	if wscli.sendframe(sock, opcode, buff[:n]) == 0 {
+		*msg = "websocket send error"
+		return 0
+	}
+	return n
+}
+
+/* get state websocket client ------------------------------------------------*/
+func (wscli *WsClient) State() int {
+	if wscli == nil {
+		return 0
+	}
+	wscli.lock.Lock()
+	defer wscli.lock.Unlock()
+	return wscli.state
+}
+
+/* get extended state websocket client ---------------------------------------*/
+func (wscli *WsClient) StateX(msg *string) int {
+	state := wscli.State()
+
+	*msg += "wscli:\n"
+	*msg += fmt.Sprintf("  state   = %d\n", state)
+	if state == 0 {
+		return 0
+	}
+	wscli.lock.Lock()
+	defer wscli.lock.Unlock()
+
+	*msg += fmt.Sprintf("  addr    = %s\n", wscli.addr)
+	*msg += fmt.Sprintf("  path    = %s\n", wscli.path)
+	*msg += fmt.Sprintf("  user    = %s\n", wscli.user)
+	*msg += fmt.Sprintf("  text    = %d\n", wscli.text)
+	*msg += fmt.Sprintf("  tls     = %t\n", wscli.conf != nil)
+	*msg += fmt.Sprintf("  ncon    = %d\n", wscli.ncon)
+	*msg += fmt.Sprintf("  nrecv   = %d\n", wscli.nrecv)
+	*msg += fmt.Sprintf("  buff    = %d\n", len(wscli.buff))
+	return state
+}
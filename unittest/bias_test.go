@@ -0,0 +1,107 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : signal bias functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const biasfile = "testdata/bias/sample.bia"
+
+/* ReadBiasF(), SigBias() satellite and station OSB/DSB */
+func Test_biasutest1(t *testing.T) {
+	var bias float64
+	assert := assert.New(t)
+	nav := new(gnssgo.Nav)
+	sta := []gnssgo.Sta{{Name: "ABCD00USA"}}
+	ns := 1e-9 * gnssgo.CLIGHT
+	f1, f2 := gnssgo.FREQ1, gnssgo.FREQ2
+	g01, g05 := gnssgo.SatNo(gnssgo.SYS_GPS, 1), gnssgo.SatNo(gnssgo.SYS_GPS, 5)
+	time := gnssgo.TimeAdd(gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0}), 3600.0)
+
+	assert.Equal(0, nav.ReadBiasF("testdata/bias/nofile.bia", sta))
+	assert.Equal(1, nav.ReadBiasF(biasfile, sta))
+	assert.Equal(8, len(nav.Bias)) /* outside block, unknown station/unit skipped */
+	assert.Equal(2, len(nav.Bias[gnssgo.BiasKey{Sat: g01, Sys: gnssgo.SYS_GPS,
+		Code1: gnssgo.CODE_L1W}]))
+
+	/* satellite OSB by valid time */
+	assert.Equal(1, nav.SigBias(time, g01, 0, gnssgo.CODE_L1W, 0, &bias))
+	assert.InDelta(1.5*ns, bias, 1e-9)
+	assert.Equal(1, nav.SigBias(gnssgo.TimeAdd(time, 86400.0), g01, 0, gnssgo.CODE_L1W, 0,
+		&bias))
+	assert.InDelta(1.8*ns, bias, 1e-9)
+	assert.Equal(0, nav.SigBias(gnssgo.TimeAdd(time, -86400.0), g01, 0, gnssgo.CODE_L1W, 0,
+		&bias))
+	assert.Equal(1, nav.SigBias(time, g01, 0, gnssgo.CODE_L2W, 0, &bias))
+	assert.InDelta(-2.0*ns, bias, 1e-9)
+	assert.Equal(1, nav.SigBias(time, g01, 0, gnssgo.CODE_L1W, 1, &bias))
+	assert.InDelta(0.1*gnssgo.CLIGHT/f1, bias, 1e-9) /* cycle -> m */
+	assert.Equal(0, nav.SigBias(time, g01, 0, gnssgo.CODE_L5Q, 0, &bias))
+
+	/* satellite OSB derived from DSB: iono-free LC of C1W/C2W free of bias */
+	d12 := 3.0 * ns
+	osb1 := -f2 * f2 / (f1*f1 - f2*f2) * d12
+	osb2 := -f1 * f1 / (f1*f1 - f2*f2) * d12
+	assert.Equal(2, nav.SigBias(time, g05, 0, gnssgo.CODE_L1W, 0, &bias))
+	assert.InDelta(osb1, bias, 1e-9)
+	assert.Equal(2, nav.SigBias(time, g05, 0, gnssgo.CODE_L2W, 0, &bias))
+	assert.InDelta(osb2, bias, 1e-9)
+	assert.InDelta(0.0, (f1*f1*osb1-f2*f2*osb2)/(f1*f1-f2*f2), 1e-9)
+	assert.Equal(2, nav.SigBias(time, g05, 0, gnssgo.CODE_L1C, 0, &bias))
+	assert.InDelta(osb1-0.5*ns, bias, 1e-9)
+	assert.Equal(2, nav.SigBias(time, g05, 0, gnssgo.CODE_L2L, 0, &bias))
+	assert.InDelta(osb2+0.8*ns, bias, 1e-9)
+	assert.Equal(0, nav.SigBias(time, g05, 0, gnssgo.CODE_L1W, 1, &bias)) /* no phase DSB */
+
+	/* station OSB and OSB derived from station DSB, common to system */
+	assert.Equal(1, nav.SigBias(time, g05, 1, gnssgo.CODE_L1C, 0, &bias))
+	assert.InDelta(0.7*ns, bias, 1e-9)
+	assert.Equal(2, nav.SigBias(time, g01, 1, gnssgo.CODE_L2W, 0, &bias))
+	assert.InDelta(-f1*f1/(f1*f1-f2*f2)*1.0*ns, bias, 1e-9)
+	assert.Equal(0, nav.SigBias(time, g01, 2, gnssgo.CODE_L1C, 0, &bias))
+
+	/* observation bias: satellite + station */
+	obs := gnssgo.ObsD{Time: time, Sat: g01, Rcv: 1}
+	obs.Code[0] = gnssgo.CODE_L1W
+	assert.Equal(1, nav.ObsBias(&obs, 0, 0, &bias))
+	assert.InDelta(1.5*ns-f2*f2/(f1*f1-f2*f2)*1.0*ns, bias, 1e-9)
+	obs.Sat = gnssgo.SatNo(gnssgo.SYS_GPS, 9)
+	assert.Equal(0, nav.ObsBias(&obs, 0, 0, &bias))
+}
+
+/* CorrPhaseBiasSsr(), CorrPhaseBias(), CorrMeas() phase OSB applied once */
+func Test_biasutest2(t *testing.T) {
+	var L, P, dant [gnssgo.NFREQ]float64
+	var Lc, Pc float64
+	assert := assert.New(t)
+	nav := new(gnssgo.Nav)
+	opt := gnssgo.DefaultProcOpt()
+	lam1, lam2 := gnssgo.CLIGHT/gnssgo.FREQ1, gnssgo.CLIGHT/gnssgo.FREQ2
+	g01 := gnssgo.SatNo(gnssgo.SYS_GPS, 1)
+	time := gnssgo.TimeAdd(gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0}), 3600.0)
+
+	assert.Equal(1, nav.ReadBiasF(biasfile, nil))
+	nav.Ssr[g01-1].Pbias[gnssgo.CODE_L1W-1] = 0.03
+	nav.Ssr[g01-1].Pbias[gnssgo.CODE_L2W-1] = -0.02
+
+	for _, corr := range []func([]gnssgo.ObsD, int, *gnssgo.Nav){gnssgo.CorrPhaseBiasSsr,
+		gnssgo.CorrPhaseBias} {
+		obs := []gnssgo.ObsD{{Time: time, Sat: g01}}
+		obs[0].Code[0], obs[0].Code[1] = gnssgo.CODE_L1W, gnssgo.CODE_L2W
+		obs[0].L[0], obs[0].L[1] = 1.0e8, 8.0e7
+		obs[0].P[0], obs[0].P[1] = 2.0e7, 2.0e7
+		corr(obs, 1, nav)
+		assert.Equal(1.0e8, obs[0].L[0]) /* phase OSB: ssr phase bias skipped */
+		assert.InDelta(8.0e7+0.02/lam2, obs[0].L[1], 1e-6)
+
+		gnssgo.CorrMeas(&obs[0], nav, []float64{0.0, 0.5}, &opt, dant[:], dant[:], 0.0,
+			L[:], P[:], &Lc, &Pc)
+		assert.InDelta(1.0e8*lam1-0.1*lam1, L[0], 1e-6)
+		assert.InDelta(8.0e7*lam2+0.02, L[1], 1e-6)
+	}
+}
@@ -0,0 +1,278 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : ntrip caster functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"gnssgo"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* free tcp port -------------------------------------------------------------*/
+func freeport(t *testing.T) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+/* send caster request and read response header ------------------------------*/
+func casreq(t *testing.T, port int, req string) (net.Conn, *bufio.Reader, string) {
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprint(conn, req)
+	rd := bufio.NewReader(conn)
+	var head string
+	for {
+		line, err := rd.ReadString('\n')
+		if head += line; err != nil || line == "\r\n" || strings.HasPrefix(line, "ICY 200") ||
+			strings.HasPrefix(line, "OK") || strings.HasPrefix(line, "ERROR") {
+			break
+		}
+	}
+	return conn, rd, head
+}
+
+/* basic authorization header ------------------------------------------------*/
+func basicauth(user, passwd string) string {
+	return "Authorization: Basic " +
+		base64.StdEncoding.EncodeToString([]byte(user+":"+passwd)) + "\r\n"
+}
+
+/* read n bytes from connection ----------------------------------------------*/
+func connreadn(rd io.Reader, n int) string {
+	buff := make([]byte, n)
+	m, _ := io.ReadFull(rd, buff)
+	return string(buff[:m])
+}
+
+/* LoadUsers(), AddUser(), DelUser() client/source authentication */
+func Test_casterutest1(t *testing.T) {
+	var msg string
+	assert := assert.New(t)
+	dir := t.TempDir()
+	port := freeport(t)
+
+	file := filepath.Join(dir, "users.txt")
+	os.WriteFile(file, []byte("# caster users\n"+
+		"USER;rover;rpw;MNT1\n"+
+		"SOURCE;base;bpw;*\n"+
+		"BAD;x;y\n"), 0644)
+	cas := gnssgo.OpenCaster(fmt.Sprintf("%d", port), &msg)
+	if cas == nil {
+		t.Fatal(msg)
+	}
+	defer cas.CloseCaster()
+
+	cas.AddUser(1, "ext", "epw", "MNT1")
+	assert.Equal(0, cas.LoadUsers(filepath.Join(dir, "nofile.txt")))
+	assert.Equal(1, cas.LoadUsers(file))
+	assert.Equal(1, cas.LoadUsers(file)) /* same users not added twice */
+
+	/* source: ntrip 1.0 by password, unknown password rejected */
+	_, _, rsp := casreq(t, port, "SOURCE xxx /MNT1\r\nSource-Agent: NTRIP test\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_ERR_PWD, rsp)
+	src, _, rsp := casreq(t, port, "SOURCE bpw /MNT1\r\nSource-Agent: NTRIP test\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp)
+	defer src.Close()
+	assert.Eventually(func() bool { return strings.Contains(cas.SrcTbl(), "STR;MNT1;") },
+		5*time.Second, 10*time.Millisecond)
+
+	/* protected mountpoint: client authentication */
+	_, _, rsp = casreq(t, port, "GET /MNT1 HTTP/1.0\r\n\r\n")
+	assert.True(strings.HasPrefix(rsp, gnssgo.NTRIP_RSP_UNAUTH), rsp)
+	_, _, rsp = casreq(t, port, "GET /MNT1 HTTP/1.0\r\n"+basicauth("rover", "xxx")+"\r\n")
+	assert.True(strings.HasPrefix(rsp, gnssgo.NTRIP_RSP_UNAUTH), rsp)
+	for _, user := range [][2]string{{"rover", "rpw"}, {"ext", "epw"}} {
+		cli, _, rsp := casreq(t, port, "GET /MNT1 HTTP/1.0\r\n"+basicauth(user[0], user[1])+
+			"\r\n")
+		assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp, user[0])
+		cli.Close()
+	}
+	/* deleted user */
+	cas.DelUser(1, "ext", "epw", "MNT1")
+	_, _, rsp = casreq(t, port, "GET /MNT1 HTTP/1.0\r\n"+basicauth("ext", "epw")+"\r\n")
+	assert.True(strings.HasPrefix(rsp, gnssgo.NTRIP_RSP_UNAUTH), rsp)
+
+	/* ntrip 2.0 client */
+	cli, _, rsp := casreq(t, port, "GET /MNT1 HTTP/1.1\r\nNtrip-Version: Ntrip/2.0\r\n"+
+		basicauth("rover", "rpw")+"\r\n")
+	assert.True(strings.HasPrefix(rsp, "HTTP/1.1 200 OK\r\n"), rsp)
+	assert.Contains(rsp, "Transfer-Encoding: chunked\r\n")
+	cli.Close()
+	_, _, rsp = casreq(t, port, "GET /MNT1 HTTP/1.1\r\nNtrip-Version: Ntrip/2.0\r\n\r\n")
+	assert.True(strings.HasPrefix(rsp, "HTTP/1.1 401 Unauthorized\r\n"), rsp)
+
+	/* mountpoint taken */
+	_, _, rsp = casreq(t, port, "SOURCE bpw /MNT1\r\n\r\n")
+	assert.Equal("ERROR - Mount Point Taken or Invalid\r\n", rsp)
+}
+
+/* LoadSrcTbl(), SrcTbl() sourcetable listing */
+func Test_casterutest2(t *testing.T) {
+	var msg string
+	assert := assert.New(t)
+	dir := t.TempDir()
+	port := freeport(t)
+
+	file := filepath.Join(dir, "srctbl.txt")
+	os.WriteFile(file, []byte("CAS;caster.example.com;2101;TEST;gnssgo;0;JPN;35.00;139.00;0.0.0.0;0;http://example.com\n"+
+		"NET;TEST;gnssgo;B;N;http://example.com;none;none;none\n"+
+		"STR;OFF;Offline;RTCM 3.3;;2;GPS;TEST;JPN;35.00;139.00;0;0;gnssgo;none;N;N;0;\n"+
+		"garbage\n"), 0644)
+	cas := gnssgo.OpenCaster(fmt.Sprintf("%d", port), &msg)
+	if cas == nil {
+		t.Fatal(msg)
+	}
+	defer cas.CloseCaster()
+	assert.Equal(0, cas.LoadSrcTbl(filepath.Join(dir, "nofile.txt")))
+	assert.Equal(1, cas.LoadSrcTbl(file))
+
+	assert.Equal(1, cas.AddMount("LOCB", "Local B;RTCM 3.3;;2;GPS;TEST;JPN;35.00;139.00;0;0;gnssgo;none;N;N;0;", 1))
+	assert.Equal(1, cas.AddMount("LOCA", "", 1))
+	assert.Equal(1, cas.AddMount("IN", "", 2))   /* local read: not listed */
+	assert.Equal(0, cas.AddMount("LOCA", "", 1)) /* taken */
+
+	/* ntrip 1.0 sourcetable: online mountpoints sorted, CAS/NET records */
+	conn, rd, rsp := casreq(t, port, "GET / HTTP/1.0\r\n\r\n")
+	defer conn.Close()
+	assert.True(strings.HasPrefix(rsp, gnssgo.NTRIP_RSP_SRCTBL), rsp)
+	body, _ := io.ReadAll(rd)
+	recs := strings.Split(strings.TrimSpace(string(body)), "\r\n")
+	if assert.Equal(5, len(recs), string(body)) {
+		assert.True(strings.HasPrefix(recs[0], "STR;LOCA;"), recs[0])
+		assert.True(strings.HasPrefix(recs[1], "STR;LOCB;Local B;RTCM 3.3;"), recs[1])
+		assert.True(strings.HasPrefix(recs[2], "CAS;"), recs[2])
+		assert.True(strings.HasPrefix(recs[3], "NET;"), recs[3])
+		assert.Equal(gnssgo.NTRIP_RSP_TBLEND, recs[4])
+	}
+	assert.Contains(rsp, fmt.Sprintf("Content-Length: %d\r\n", len(body)))
+
+	/* ntrip 2.0 sourcetable for offline mountpoint */
+	conn, rd, rsp = casreq(t, port, "GET /OFF HTTP/1.1\r\nNtrip-Version: Ntrip/2.0\r\n\r\n")
+	defer conn.Close()
+	assert.True(strings.HasPrefix(rsp, "HTTP/1.1 200 OK\r\n"), rsp)
+	assert.Contains(rsp, "Content-Type: gnss/sourcetable\r\n")
+	body, _ = io.ReadAll(rd)
+	assert.NotContains(string(body), "STR;OFF;")
+	assert.True(strings.HasSuffix(string(body), gnssgo.NTRIP_RSP_TBLEND+"\r\n"))
+
+	/* deleted mountpoint not listed */
+	cas.DelMount("LOCB")
+	assert.NotContains(cas.SrcTbl(), "STR;LOCB;")
+}
+
+/* multi-mountpoint relay of remote and local sources */
+func Test_casterutest3(t *testing.T) {
+	var msg string
+	var buff [64]byte
+	assert := assert.New(t)
+	port := freeport(t)
+
+	cas := gnssgo.OpenCaster(fmt.Sprintf("%d", port), &msg)
+	if cas == nil {
+		t.Fatal(msg)
+	}
+	defer cas.CloseCaster()
+	cas.AddUser(0, "base", "bpw", "*")
+	assert.Equal(1, cas.AddMount("LOC", "", 1))
+	assert.Equal(1, cas.AddMount("IN", "", 2))
+
+	/* ntrip 1.0 and 2.0 sources */
+	src1, _, rsp := casreq(t, port, "SOURCE bpw /M1\r\nSource-Agent: NTRIP test\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp)
+	defer src1.Close()
+	src2, _, rsp := casreq(t, port, "POST /M2 HTTP/1.1\r\nNtrip-Version: Ntrip/2.0\r\n"+
+		basicauth("base", "bpw")+"\r\n")
+	assert.True(strings.HasPrefix(rsp, "HTTP/1.1 200 OK\r\n"), rsp)
+	defer src2.Close()
+	src3, _, rsp := casreq(t, port, "SOURCE bpw /IN\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp)
+	defer src3.Close()
+
+	/* two clients for each mountpoint */
+	var clis [3][2]*bufio.Reader
+	for i, mnt := range []string{"M1", "M2", "LOC"} {
+		for j := 0; j < 2; j++ {
+			conn, rd, rsp := casreq(t, port, "GET /"+mnt+" HTTP/1.0\r\n\r\n")
+			assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp, mnt)
+			defer conn.Close()
+			clis[i][j] = rd
+		}
+	}
+	assert.Eventually(func() bool { return len(cas.StatCaster()) == 9 }, 5*time.Second,
+		10*time.Millisecond)
+	stat := cas.StatCaster()
+	assert.Equal("IN", stat[0].Mntpnt)
+	assert.Equal(0, stat[0].Type)
+	assert.Equal(1, stat[len(stat)-1].Type)
+
+	src1.Write([]byte("data of M1"))
+	src2.Write([]byte("data of M2"))
+	assert.Equal(10, cas.WriteCaster("LOC", []byte("data of LC"), 10))
+	assert.Equal(0, cas.WriteCaster("IN", []byte("data of LC"), 10))
+	for i, data := range []string{"data of M1", "data of M2", "data of LC"} {
+		for j := 0; j < 2; j++ {
+			assert.Equal(data, connreadn(clis[i][j], 10), "mnt=%d cli=%d", i, j)
+		}
+	}
+	/* local read mountpoint */
+	src3.Write([]byte("data to IN"))
+	var data string
+	for i := 0; i < 200 && len(data) < 10; i++ {
+		if n := cas.ReadCaster("IN", buff[:], len(buff)); n > 0 {
+			data += string(buff[:n])
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	assert.Equal("data to IN", data)
+
+	/* source disconnected: clients of the mountpoint closed */
+	src1.Close()
+	_, err := clis[0][0].ReadByte()
+	assert.NotNil(err)
+}
+
+/* ntrip caster stream: users of stream deleted by closing stream */
+func Test_casterutest4(t *testing.T) {
+	var in, out gnssgo.Stream
+	assert := assert.New(t)
+	port := freeport(t)
+
+	in.InitStream()
+	out.InitStream()
+	assert.Equal(1, in.OpenStream(gnssgo.STR_NTRIPCAS, gnssgo.STR_MODE_R,
+		fmt.Sprintf("base:bpw@:%d/IN", port)))
+	assert.Equal(1, out.OpenStream(gnssgo.STR_NTRIPCAS, gnssgo.STR_MODE_W,
+		fmt.Sprintf(":%d/OUT", port)))
+	defer out.StreamClose()
+
+	src, _, rsp := casreq(t, port, "SOURCE bpw /IN\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_OK_CLI, rsp)
+	src.Write([]byte("data to IN"))
+	assert.Equal("data to IN", strreadn(&in, 10))
+	src.Close()
+
+	/* source user of closed stream deleted */
+	in.StreamClose()
+	time.Sleep(100 * time.Millisecond)
+	_, _, rsp = casreq(t, port, "SOURCE bpw /IN\r\n\r\n")
+	assert.Equal(gnssgo.NTRIP_RSP_ERR_PWD, rsp)
+}
@@ -0,0 +1,481 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : qzss clas compact ssr functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* bit buffer writer ---------------------------------------------------------*/
+type bitbuf struct {
+	buff [2048]byte
+	n    int
+}
+
+func (b *bitbuf) u(n int, v uint32) {
+	gnssgo.SetBitU(b.buff[:], b.n, n, v)
+	b.n += n
+}
+
+func (b *bitbuf) s(n int, v int32) {
+	gnssgo.SetBits(b.buff[:], b.n, n, v)
+	b.n += n
+}
+
+/* compact ssr message number, subtype and header (subtype 2-12) -------------*/
+func (b *bitbuf) cssrhead(subtype int, head bool) {
+	b.u(12, gnssgo.CSSR_MSGNO)
+	b.u(4, uint32(subtype))
+	if head {
+		b.u(12, 35) /* hourly epoch time (s) */
+		b.u(4, 2)   /* update interval (5 s) */
+		b.u(1, 0)   /* multiple message indicator */
+		b.u(4, 1)   /* iod ssr */
+	}
+}
+
+/* mask (subtype 1): G01,G02 {L1C,L2L}, J01 {L1C} ----------------------------*/
+func (b *bitbuf) cssrmask(iod uint32) {
+	b.cssrhead(1, false)
+	b.u(20, 86430)
+	b.u(4, 2)
+	b.u(1, 0)
+	b.u(4, iod)
+	b.u(4, 2)       /* number of gnss */
+	b.u(4, 0)       /* GPS */
+	b.u(8, 0xC0)    /* prn 1,2 */
+	b.u(32, 0)      /* prn 9-40 */
+	b.u(16, 0x8100) /* signals 0 (L1C),7 (L2L) */
+	b.u(1, 0)
+	b.u(4, 4) /* QZS */
+	b.u(8, 0x80)
+	b.u(32, 0)
+	b.u(16, 0x8000) /* signal 0 (L1C) */
+	b.u(1, 0)
+}
+
+/* qzss L6 frames of subframe ------------------------------------------------*/
+func l6frames(data []byte) []byte {
+	var frms []byte
+	for n := 0; n < gnssgo.L6_NFRM; n++ {
+		var frm [gnssgo.L6_FRMLEN]byte
+		gnssgo.SetBitU(frm[:], 0, 32, gnssgo.L6_PREAMB)
+		gnssgo.SetBitU(frm[:], 32, 8, 193)                  /* prn */
+		gnssgo.SetBitU(frm[:], 40, 3, gnssgo.L6_VENDOR_CLS) /* vendor id */
+		if n == 0 {
+			gnssgo.SetBitU(frm[:], 47, 1, 1) /* subframe indicator */
+		}
+		for i := 0; i < gnssgo.L6_DATALEN; i++ {
+			if j := n*gnssgo.L6_DATALEN + i; j < len(data)*8 {
+				gnssgo.SetBitU(frm[:], 49+i, 1, gnssgo.GetBitU(data, j, 1))
+			}
+		}
+		for i := 218; i < gnssgo.L6_FRMLEN; i++ { /* reed-solomon code */
+			frm[i] = byte(i)
+		}
+		frms = append(frms, frm[:]...)
+	}
+	return frms
+}
+
+/* input L6 frames and count status of subframe ------------------------------*/
+func inputl6(rtcm *gnssgo.Rtcm, frms []byte) (int, int) {
+	var n, ret int
+	for _, c := range frms {
+		if r := rtcm.InputL6(c); r != 0 {
+			n, ret = n+1, r
+		}
+	}
+	return n, ret
+}
+
+/* InputL6() */
+func Test_cssrutest1(t *testing.T) {
+	assert := assert.New(t)
+	var rtcm gnssgo.Rtcm
+	var b bitbuf
+
+	rtcm.InitRtcm()
+	rtcm.Time = gnssgo.GpsT2Time(2300, 86000.0)
+	t0 := gnssgo.GpsT2Time(2300, 86435.0)
+	g1 := gnssgo.SatNo(gnssgo.SYS_GPS, 1) - 1
+	g2 := gnssgo.SatNo(gnssgo.SYS_GPS, 2) - 1
+	j1 := gnssgo.SatNo(gnssgo.SYS_QZS, 193) - 1
+	l1, l2 := gnssgo.CODE_L1C-1, gnssgo.CODE_L2L-1
+
+	b.cssrmask(1)
+	b.cssrhead(2, true) /* orbit: iode, radial, along, cross */
+	b.u(8, 45)
+	b.s(15, 100)
+	b.s(13, -50)
+	b.s(13, 20)
+	b.u(8, 46)
+	b.s(15, 200)
+	b.s(13, 0)
+	b.s(13, 0)
+	b.u(8, 47)
+	b.s(15, -300)
+	b.s(13, 10)
+	b.s(13, -10)
+	b.cssrhead(3, true) /* clock */
+	b.s(15, 125)
+	b.s(15, -250)
+	b.s(15, 500)
+	b.cssrhead(4, true) /* code bias */
+	b.s(11, 50)
+	b.s(11, -25)
+	b.s(11, 10)
+	b.s(11, 20)
+	b.s(11, -5)
+	b.cssrhead(5, true) /* phase bias, discontinuity */
+	for _, v := range []int32{1000, -500, 100, 200, -50} {
+		b.s(15, v)
+		b.u(2, 0)
+	}
+	b.cssrhead(7, true) /* ura */
+	b.u(6, 10)
+	b.u(6, 20)
+	b.u(6, 30)
+	b.cssrhead(10, false) /* service information */
+	b.u(4, 0)
+	b.u(2, 1)
+	b.u(32, 0xFFFFFFFF)
+	b.u(32, 0xFFFFFFFF)
+	b.u(16, 0xFFFF)
+	b.cssrhead(6, true) /* network code bias: net 3, G02 */
+	b.u(3, 0x5)
+	b.u(5, 3)
+	b.u(3, 0x2)
+	b.s(11, 75)
+	b.s(11, -75)
+	b.cssrhead(11, true) /* network orbit/clock: net 3, G01,J01 */
+	b.u(3, 0x7)
+	b.u(5, 3)
+	b.u(3, 0x5)
+	b.u(8, 45)
+	b.s(15, 10)
+	b.s(13, 20)
+	b.s(13, 30)
+	b.s(15, 300)
+	b.u(8, 47)
+	b.s(15, -10)
+	b.s(13, -20)
+	b.s(13, -30)
+	b.s(15, -300)
+	b.cssrhead(11, true) /* network clock: net 5, G01 */
+	b.u(3, 0x3)
+	b.u(5, 5)
+	b.u(3, 0x4)
+	b.s(15, 600)
+	b.cssrhead(8, true) /* stec polynomial: net 3, G01,J01 */
+	b.u(2, 1)
+	b.u(5, 3)
+	b.u(3, 0x5)
+	b.u(6, 0x09)
+	b.s(14, 200)
+	b.s(12, 50)
+	b.s(12, -50)
+	b.u(6, 0)
+	b.s(14, -100)
+	b.s(12, 0)
+	b.s(12, 0)
+	b.cssrhead(9, true) /* gridded: net 3, G01, 2 grids */
+	b.u(2, 1)
+	b.u(1, 0)
+	b.u(5, 3)
+	b.u(3, 0x4)
+	b.u(6, 0)
+	b.u(6, 2)
+	b.s(9, 25)
+	b.s(8, -13)
+	b.s(7, 10)
+	b.s(9, -256)
+	b.s(8, 5)
+	b.s(7, -64)
+	b.cssrhead(12, true) /* atmospheric: net 4, G02, 2 grids */
+	b.u(2, 3)
+	b.u(2, 3)
+	b.u(5, 4)
+	b.u(6, 2)
+	b.u(6, 0)
+	b.u(2, 1)
+	b.s(9, 100)
+	b.s(7, 10)
+	b.s(7, -10)
+	b.u(1, 0)
+	b.u(4, 5)
+	b.s(6, 10)
+	b.s(6, -32)
+	b.u(3, 0x2)
+	b.u(6, 0)
+	b.u(2, 0)
+	b.s(14, 40)
+	b.u(2, 1)
+	b.s(4, 3)
+	b.s(4, -2)
+
+	/* frame sync after garbage */
+	frms := append([]byte{0x1A, 0xCF, 0xFC, 0x00, 0x1A}, l6frames(b.buff[:(b.n+7)/8])...)
+	n, ret := inputl6(&rtcm, frms)
+	assert.Equal(1, n)
+	assert.Equal(10, ret)
+	assert.Equal(1, rtcm.Cssr.Iod)
+	assert.Equal(3, rtcm.Cssr.Nsat)
+	assert.Equal(2, rtcm.Cssr.Nsig[0])
+	assert.Equal(1, rtcm.Cssr.Nsig[2])
+
+	/* subtype 2,3,4,5,7: global corrections */
+	ssr := &rtcm.Ssr[g1]
+	assert.Equal(0.0, gnssgo.TimeDiff(ssr.T0[0], t0))
+	assert.Equal(0.0, gnssgo.TimeDiff(ssr.T0[1], t0))
+	assert.Equal(5.0, ssr.Udi[0])
+	assert.Equal(1, ssr.Iod[0])
+	assert.Equal(45, ssr.Iode)
+	assert.InDeltaSlice([]float64{0.16, -0.32, 0.128}, ssr.Deph[:], 1e-9)
+	assert.InDelta(0.2, ssr.Dclk[0], 1e-9)
+	assert.InDelta(1.0, ssr.Cbias[l1], 1e-6)
+	assert.InDelta(-0.5, ssr.Cbias[l2], 1e-6)
+	assert.InDelta(1.0, ssr.Pbias[l1], 1e-9)
+	assert.InDelta(-0.5, ssr.Pbias[l2], 1e-9)
+	assert.Equal(10, ssr.Ura)
+	assert.Equal(uint8(1), ssr.Update)
+	assert.Equal(46, rtcm.Ssr[g2].Iode)
+	assert.InDelta(-0.4, rtcm.Ssr[g2].Dclk[0], 1e-9)
+	assert.InDelta(0.2, rtcm.Ssr[g2].Cbias[l1], 1e-6)
+	assert.InDelta(0.4, rtcm.Ssr[g2].Cbias[l2], 1e-6)
+	assert.InDeltaSlice([]float64{-0.48, 0.064, -0.064}, rtcm.Ssr[j1].Deph[:], 1e-9)
+	assert.InDelta(0.8, rtcm.Ssr[j1].Dclk[0], 1e-9)
+	assert.InDelta(-0.1, rtcm.Ssr[j1].Cbias[l1], 1e-6)
+	assert.InDelta(-0.05, rtcm.Ssr[j1].Pbias[l1], 1e-9)
+	assert.Equal(30, rtcm.Ssr[j1].Ura)
+
+	/* subtype 6,11: network corrections stored by network */
+	catm := rtcm.NavData.Catm
+	assert.Equal(gnssgo.CSSR_MAXNET, len(catm))
+	assert.Equal(0.0, gnssgo.TimeDiff(catm[3].Tn, t0))
+	assert.InDelta(1.5, catm[3].Ssr[g2].Cbias[l1], 1e-6)
+	assert.InDelta(-1.5, catm[3].Ssr[g2].Cbias[l2], 1e-6)
+	assert.InDeltaSlice([]float64{0.016, 0.128, 0.192}, catm[3].Ssr[g1].Deph[:], 1e-9)
+	assert.InDelta(0.48, catm[3].Ssr[g1].Dclk[0], 1e-9)
+	assert.InDelta(-0.48, catm[3].Ssr[j1].Dclk[0], 1e-9)
+	assert.Equal(uint64(0), catm[3].Ssr[g2].T0[0].Time)
+	assert.InDelta(0.96, catm[5].Ssr[g1].Dclk[0], 1e-9)
+	assert.Equal(uint64(0), catm[5].Ssr[g1].T0[0].Time)
+	assert.InDelta(0.2, ssr.Dclk[0], 1e-9)
+	assert.InDelta(0.2, rtcm.Ssr[g2].Cbias[l1], 1e-6)
+	assert.Nil(catm[4].Ssr)
+
+	/* subtype 8: stec polynomial */
+	assert.Equal(0.0, gnssgo.TimeDiff(catm[3].Ts[g1], t0))
+	assert.InDeltaSlice([]float64{10.0, 1.0, -1.0, 0, 0, 0}, catm[3].Stec[g1][:], 1e-9)
+	assert.InDelta(2.75, catm[3].StecQ[g1], 1e-9)
+	assert.InDelta(-5.0, catm[3].Stec[j1][0], 1e-9)
+
+	/* subtype 9: gridded tropos and stec residuals */
+	assert.Equal(1, catm[3].Trpf)
+	assert.Equal(2, catm[3].Ngrid)
+	assert.InDelta(2.4, catm[3].Trph[0], 1e-9)
+	assert.InDelta(0.2, catm[3].Trpw[0], 1e-9)
+	assert.Equal(gnssgo.CSSR_NOVAL, catm[3].Trph[1])
+	assert.InDelta(0.272, catm[3].Trpw[1], 1e-9)
+	assert.InDelta(0.4, catm[3].Stecr[g1][0], 1e-6)
+	assert.Equal(float32(gnssgo.CSSR_NOVAL), catm[3].Stecr[g1][1])
+
+	/* subtype 12: tropos polynomial/residuals and stec */
+	assert.Equal(2, catm[4].Trpf)
+	assert.InDeltaSlice([]float64{0.4, 0.02, -0.02, 0.0}, catm[4].Trp[:], 1e-9)
+	assert.Equal(gnssgo.CSSR_NOVAL, catm[4].Trph[0])
+	assert.InDelta(0.14, catm[4].Trpw[0], 1e-9)
+	assert.Equal(gnssgo.CSSR_NOVAL, catm[4].Trpw[1])
+	assert.InDelta(2.0, catm[4].Stec[g2][0], 1e-9)
+	assert.InDelta(0.36, catm[4].Stecr[g2][0], 1e-6)
+	assert.InDelta(-0.24, catm[4].Stecr[g2][1], 1e-6)
+
+	/* network selected by receiver position */
+	var nav gnssgo.Nav
+	var rr [3]float64
+	nav.Grid = []gnssgo.CssrGrid{
+		{Net: 3, No: 1, Pos: [3]float64{35.0 * gnssgo.D2R, 139.0 * gnssgo.D2R}},
+		{Net: 4, No: 1, Pos: [3]float64{35.5 * gnssgo.D2R, 139.0 * gnssgo.D2R}},
+		{Net: 5, No: 1, Pos: [3]float64{36.0 * gnssgo.D2R, 139.0 * gnssgo.D2R}}}
+	nav.UpdateCssrAtm(&rtcm.NavData)
+	nav.Ssr = rtcm.Ssr
+	assert.Equal(-1, nav.CssrSelSsr(t0, rr[:]))
+
+	gnssgo.Pos2Ecef([]float64{35.1 * gnssgo.D2R, 139.0 * gnssgo.D2R, 0.0}, rr[:])
+	assert.Equal(3, nav.CssrSelSsr(t0, rr[:]))
+	assert.InDeltaSlice([]float64{0.016, 0.128, 0.192}, nav.Ssr[g1].Deph[:], 1e-9)
+	assert.InDelta(0.48, nav.Ssr[g1].Dclk[0], 1e-9)
+	assert.InDelta(1.0, nav.Ssr[g1].Cbias[l1], 1e-6)
+	assert.InDelta(-0.4, nav.Ssr[g2].Dclk[0], 1e-9)
+	assert.InDelta(1.5, nav.Ssr[g2].Cbias[l1], 1e-6)
+	assert.InDelta(-0.48, nav.Ssr[j1].Dclk[0], 1e-9)
+
+	/* net 4 without network ssr corrections skipped */
+	nav.Ssr = rtcm.Ssr
+	gnssgo.Pos2Ecef([]float64{35.7 * gnssgo.D2R, 139.0 * gnssgo.D2R, 0.0}, rr[:])
+	assert.Equal(5, nav.CssrSelSsr(t0, rr[:]))
+	assert.InDeltaSlice([]float64{0.16, -0.32, 0.128}, nav.Ssr[g1].Deph[:], 1e-9)
+	assert.InDelta(0.96, nav.Ssr[g1].Dclk[0], 1e-9)
+	assert.InDelta(0.2, nav.Ssr[g2].Cbias[l1], 1e-6)
+
+	/* network corrections too old */
+	nav.Ssr = rtcm.Ssr
+	assert.Equal(-1, nav.CssrSelSsr(gnssgo.TimeAdd(t0, gnssgo.CSSR_MAXAGE+1.0), rr[:]))
+	assert.InDelta(0.2, nav.Ssr[g1].Dclk[0], 1e-9)
+}
+
+/* InputL6() subframe rejected by decode error */
+func Test_cssrutest2(t *testing.T) {
+	assert := assert.New(t)
+	var rtcm gnssgo.Rtcm
+	var b bitbuf
+
+	rtcm.InitRtcm()
+	rtcm.Time = gnssgo.GpsT2Time(2300, 86000.0)
+	g1 := gnssgo.SatNo(gnssgo.SYS_GPS, 1) - 1
+
+	b.cssrmask(1)
+	b.cssrhead(3, true)
+	b.s(15, 125)
+	b.s(15, -250)
+	b.s(15, 500)
+	n, ret := inputl6(&rtcm, l6frames(b.buff[:(b.n+7)/8]))
+	assert.Equal(1, n)
+	assert.Equal(10, ret)
+	rtcm.Ssr[g1].Update = 0
+
+	/* new mask, clock and network clock followed by unknown message */
+	b = bitbuf{}
+	b.cssrmask(2)
+	b.u(12, gnssgo.CSSR_MSGNO)
+	b.u(4, 3)
+	b.u(12, 40)
+	b.u(4, 2)
+	b.u(1, 0)
+	b.u(4, 2)
+	b.s(15, 300)
+	b.s(15, 300)
+	b.s(15, 300)
+	b.u(12, gnssgo.CSSR_MSGNO)
+	b.u(4, 11)
+	b.u(12, 40)
+	b.u(4, 2)
+	b.u(1, 0)
+	b.u(4, 2)
+	b.u(3, 0x3)
+	b.u(5, 7)
+	b.u(3, 0x4)
+	b.s(15, 600)
+	b.u(12, 4000)
+	b.u(4, 1)
+	n, ret = inputl6(&rtcm, l6frames(b.buff[:(b.n+7)/8]))
+	assert.Equal(0, n)
+	assert.Equal(0, ret)
+
+	/* corrections and mask of subframe discarded */
+	assert.Equal(1, rtcm.Cssr.Iod)
+	assert.InDelta(86430.0, rtcm.Cssr.Tow0, 1e-9)
+	assert.InDelta(0.2, rtcm.Ssr[g1].Dclk[0], 1e-9)
+	assert.Equal(uint8(0), rtcm.Ssr[g1].Update)
+	assert.Nil(rtcm.NavData.Catm[7].Ssr)
+	assert.Equal(uint8(0), rtcm.NavData.Catm[7].Update)
+	assert.Equal(0.0, gnssgo.TimeDiff(rtcm.Time, gnssgo.GpsT2Time(2300, 86435.0)))
+}
+
+/* InputRtcm3() type 4073 */
+func Test_cssrutest3(t *testing.T) {
+	assert := assert.New(t)
+	var rtcm gnssgo.Rtcm
+
+	rtcm.InitRtcm()
+	rtcm.Time = gnssgo.GpsT2Time(2300, 86000.0)
+	g2 := gnssgo.SatNo(gnssgo.SYS_GPS, 2) - 1
+
+	/* rtcm 3 frame of compact ssr message */
+	input := func(msg *bitbuf) int {
+		var buff [1024]byte
+		nbyte := (msg.n + 7) / 8
+		gnssgo.SetBitU(buff[:], 0, 8, gnssgo.RTCM3PREAMB)
+		gnssgo.SetBitU(buff[:], 14, 10, uint32(nbyte))
+		copy(buff[3:], msg.buff[:nbyte])
+		gnssgo.SetBitU(buff[:], (nbyte+3)*8, 24, gnssgo.Rtk_CRC24q(buff[:], nbyte+3))
+		ret := 0
+		for _, c := range buff[:nbyte+6] {
+			if r := rtcm.InputRtcm3(c); r != 0 {
+				ret = r
+			}
+		}
+		return ret
+	}
+	var b1, b2, b3 bitbuf
+	b1.cssrmask(1)
+	assert.Equal(0, input(&b1))
+	assert.Equal(3, rtcm.Cssr.Nsat)
+
+	b2.cssrhead(3, true)
+	b2.s(15, 125)
+	b2.s(15, -250)
+	b2.s(15, 500)
+	assert.Equal(10, input(&b2))
+	assert.InDelta(-0.4, rtcm.Ssr[g2].Dclk[0], 1e-9)
+	assert.Equal(0.0, gnssgo.TimeDiff(rtcm.Ssr[g2].T0[1], gnssgo.GpsT2Time(2300, 86435.0)))
+
+	/* message shorter than mask */
+	b3.cssrhead(3, true)
+	b3.s(15, 125)
+	assert.Equal(-1, input(&b3))
+}
+
+/* CssrIonCorr() */
+func Test_cssrutest4(t *testing.T) {
+	assert := assert.New(t)
+	var nav gnssgo.Nav
+	var ion, vari float64
+
+	/* grids north of receiver at 0.1,0.2,0.3,0.4,0.5 deg */
+	time := gnssgo.GpsT2Time(2300, 86435.0)
+	pos := []float64{35.0 * gnssgo.D2R, 139.0 * gnssgo.D2R, 0.0}
+	for i := 1; i <= 5; i++ {
+		nav.Grid = append(nav.Grid, gnssgo.CssrGrid{Net: 3, No: i,
+			Pos: [3]float64{(35.0 + 0.1*float64(i)) * gnssgo.D2R, 139.0 * gnssgo.D2R}})
+	}
+	nav.Grid = append(nav.Grid, gnssgo.CssrGrid{Net: 6, No: 1,
+		Pos: [3]float64{35.05 * gnssgo.D2R, 139.0 * gnssgo.D2R}})
+	nav.Catm = make([]gnssgo.CssrAtm, gnssgo.CSSR_MAXNET)
+	atm := &nav.Catm[3]
+	atm.Ngrid = 5
+	atm.Ts[0], atm.Tr[0] = time, time
+	atm.Stec[0] = [6]float64{10.0, 2.0}
+	atm.Stecr[0] = [gnssgo.CSSR_MAXGRID]float32{1.0, 2.0, 3.0, 4.0, 100.0}
+	fact := 40.3e16 / (gnssgo.FREQ1 * gnssgo.FREQ1)
+
+	/* stec = c00+c01*dlat (dlat=-0.1 deg from grid no.1) + residual by
+	   nearest 4 grids weighted by 1/d: w={12,6,4,3}/25 */
+	assert.Equal(1, nav.CssrIonCorr(time, 1, pos, &ion, &vari))
+	stec := 10.0 - 0.2 + (12*1.0+6*2.0+4*3.0+3*4.0)/25.0
+	assert.InDelta(fact*stec, ion, 1e-9)
+	assert.InDelta(gnssgo.SQR(fact*gnssgo.CSSR_ERR_STEC), vari, 1e-12)
+
+	/* no value of grid excluded: w={12,4,3}/19 */
+	atm.Stecr[0][1] = gnssgo.CSSR_NOVAL
+	atm.StecQ[0] = 0.5
+	assert.Equal(1, nav.CssrIonCorr(time, 1, pos, &ion, &vari))
+	stec = 10.0 - 0.2 + (12*1.0+4*3.0+3*4.0)/19.0
+	assert.InDelta(fact*stec, ion, 1e-9)
+	assert.InDelta(gnssgo.SQR(fact*0.5), vari, 1e-12)
+
+	/* receiver at grid no.2: residual of grid */
+	pos[0] = 35.2 * gnssgo.D2R
+	atm.Stecr[0][1] = 2.0
+	assert.Equal(1, nav.CssrIonCorr(time, 1, pos, &ion, &vari))
+	assert.InDelta(fact*(10.0+0.2+2.0), ion, 1e-9)
+
+	/* no correction of satellite or too old */
+	assert.Equal(0, nav.CssrIonCorr(time, 2, pos, &ion, &vari))
+	assert.Equal(0, nav.CssrIonCorr(gnssgo.TimeAdd(time, 121.0), 1, pos, &ion, &vari))
+}
@@ -0,0 +1,218 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : gnss data downloader
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"gnssgo"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* FTP stand-in server (USER/PASS/TYPE/EPSV/PASV/LIST/RETR/QUIT) -------------*/
+type ftpstub struct {
+	ln    net.Listener
+	files map[string][]byte /* file path -> contents */
+	lock  sync.Mutex
+	cmds  []string /* received commands */
+}
+
+func newftpstub(t *testing.T, files map[string][]byte) *ftpstub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ftpstub{ln: ln, files: files}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *ftpstub) addr() string { return s.ln.Addr().String() }
+
+func (s *ftpstub) serve(conn net.Conn) {
+	var data net.Listener
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	reply := func(format string, v ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", v...)
+	}
+	reply("220 stub ready")
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		s.lock.Lock()
+		s.cmds = append(s.cmds, cmd)
+		s.lock.Unlock()
+
+		switch cmd {
+		case "USER":
+			reply("331 password required")
+		case "PASS":
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 type set")
+		case "EPSV":
+			reply("500 not supported")
+		case "PASV":
+			if data, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+				reply("425 no data connection")
+				continue
+			}
+			port := data.Addr().(*net.TCPAddr).Port
+			reply("227 Entering Passive Mode (127,0,0,1,%d,%d)", port/256, port%256)
+		case "LIST", "RETR":
+			var buff []byte
+			if cmd == "LIST" {
+				for file := range s.files {
+					if dir, name := filepath.Split(file); dir == arg {
+						buff = append(buff, fmt.Sprintf("-rw-r--r-- 1 ftp ftp %d Feb 04 00:00 %s\r\n",
+							len(s.files[file]), name)...)
+					}
+				}
+			} else if buff = s.files[arg]; buff == nil {
+				data.Close()
+				reply("550 no such file")
+				continue
+			}
+			reply("150 opening data connection")
+			if c, err := data.Accept(); err == nil {
+				c.Write(buff)
+				c.Close()
+			}
+			data.Close()
+			reply("226 transfer complete")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+/* write url list file and read it by DL_ReadUrls() --------------------------*/
+func dlurls(t *testing.T, dir, path string) []gnssgo.Url {
+	var urls [1]gnssgo.Url
+	file := filepath.Join(dir, "urls.txt")
+	os.WriteFile(file, []byte(fmt.Sprintf("TEST %s %s\n", path, dir)), 0666)
+	if gnssgo.DL_ReadUrls(file, []string{"*"}, 1, urls[:], 1) != 1 {
+		t.Fatal("url list read error")
+	}
+	return urls[:]
+}
+
+/* dl_exec() via HTTP */
+func Test_downloadutest1(t *testing.T) {
+	assert := assert.New(t)
+	var msg string
+	ts := gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+	te := gnssgo.Epoch2Time([]float64{2024, 2, 5, 0, 0, 0})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2024/035/test0350.txt":
+			w.Write([]byte("day 035\n"))
+		case "/2024/036/test0360.txt.gz":
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte("day 036\n"))
+			zw.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	urls := dlurls(t, dir, srv.URL+"/%Y/%n/test%n0.txt")
+	stat := gnssgo.DL_ExecN(ts, te, 86400.0, 0, 0, urls, 1, nil, 0, dir, "", "", "",
+		0, 2, &msg, nil)
+	assert.Equal(1, stat)
+	assert.True(strings.HasPrefix(msg, "OK=1 No_File=1 Skip=0 Error=0"), msg)
+	buff, err := os.ReadFile(filepath.Join(dir, "test0350.txt"))
+	assert.Nil(err)
+	assert.Equal("day 035\n", string(buff))
+
+	/* gzip file uncompressed after download */
+	urls = dlurls(t, dir, srv.URL+"/%Y/%n/test%n0.txt.gz")
+	ts = te
+	stat = gnssgo.DL_ExecN(ts, te, 86400.0, 0, 0, urls, 1, nil, 0, dir, "", "", "",
+		0, 1, &msg, nil)
+	assert.Equal(1, stat)
+	assert.True(strings.HasPrefix(msg, "OK=1 "), msg)
+	buff, err = os.ReadFile(filepath.Join(dir, "test0360.txt"))
+	assert.Nil(err)
+	assert.Equal("day 036\n", string(buff))
+
+	/* existing local file skipped */
+	stat = gnssgo.DL_ExecN(ts, te, 86400.0, 0, 0, urls, 1, nil, 0, dir, "", "", "",
+		0, 1, &msg, nil)
+	assert.True(strings.HasPrefix(msg, "OK=0 No_File=0 Skip=1 Error=0"), msg)
+}
+
+/* dl_exec() via FTP with wild-card */
+func Test_downloadutest2(t *testing.T) {
+	assert := assert.New(t)
+	var msg string
+	ts := gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+
+	ftp := newftpstub(t, map[string][]byte{
+		"/pub/2024/035/abcd0350.24o": []byte("rinex obs\n"),
+		"/pub/2024/035/efgh0350.24o": []byte("other obs\n"),
+	})
+	defer ftp.ln.Close()
+
+	dir := t.TempDir()
+	urls := dlurls(t, dir, "ftp://"+ftp.addr()+"/pub/%Y/%n/%s%n0.%yo")
+	stas := []string{"abcd", "efgh", "ijkl"}
+	stat := gnssgo.DL_ExecN(ts, ts, 86400.0, 0, 0, urls, 1, stas, 3, dir, "", "", "",
+		0, 1, &msg, nil)
+	assert.Equal(1, stat)
+	assert.True(strings.HasPrefix(msg, "OK=2 No_File=1 Skip=0 Error=0"), msg)
+	for i, sta := range stas[:2] {
+		buff, err := os.ReadFile(filepath.Join(dir, sta+"0350.24o"))
+		assert.Nil(err)
+		assert.Equal([]string{"rinex obs\n", "other obs\n"}[i], string(buff))
+	}
+	/* remote file list read once for the directory */
+	nlist := 0
+	ftp.lock.Lock()
+	for _, cmd := range ftp.cmds {
+		if cmd == "LIST" {
+			nlist++
+		}
+	}
+	ftp.lock.Unlock()
+	assert.Equal(1, nlist)
+
+	/* wild-card extended by remote file list */
+	dir = t.TempDir()
+	urls = dlurls(t, dir, "ftp://"+ftp.addr()+"/pub/%Y/%n/efgh*.%yo")
+	stat = gnssgo.DL_ExecN(ts, ts, 86400.0, 0, 0, urls, 1, nil, 0, dir, "", "", "",
+		0, 1, &msg, nil)
+	assert.True(strings.HasPrefix(msg, "OK=1 No_File=0"), msg)
+	buff, err := os.ReadFile(filepath.Join(dir, "efgh0350.24o"))
+	assert.Nil(err)
+	assert.Equal("other obs\n", string(buff))
+}
@@ -0,0 +1,135 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : gnss/ins integration functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* InsMech(), InsUpdate() */
+func Test_insutest1(t *testing.T) {
+	assert := assert.New(t)
+	var ins gnssgo.Ins
+	var opt gnssgo.InsOpt
+	var imu gnssgo.ImuD
+	var sol gnssgo.Sol
+
+	/* imu at lat=0,lon=0 on the ellipsoid, body {fwd,right,down}={N,E,D} */
+	re := gnssgo.RE_WGS84
+	cbe0 := [9]float64{0, 0, 1, 0, 1, 0, -1, 0, 0}
+	ins.InitIns()
+	ins.Re = [3]float64{re, 0, 0}
+	ins.Cbe = cbe0
+	ins.Stat = 1
+
+	/* specific force of static imu: -(gravitation with J2 + centrifugal) */
+	g := 3.986004418e14/(re*re)*(1.0+1.5*1.082627e-3) - gnssgo.OMGE*gnssgo.OMGE*re
+	imu.Acc = [3]float64{0, 0, -g}
+	imu.Time = gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+	ins.Time = imu.Time
+
+	for i := 0; i < 100; i++ { /* 100 Hz, 1 s, zero angular rates */
+		imu.Time = gnssgo.TimeAdd(imu.Time, 0.01)
+		assert.Equal(1, ins.InsMech(&imu, &opt))
+	}
+	/* attitude rotated by -omega_ie*t w.r.t. ecef, no drift of velocity */
+	sn, cs := math.Sin(gnssgo.OMGE), math.Cos(gnssgo.OMGE)
+	for j := 0; j < 3; j++ {
+		c0, c1 := cbe0[j*3], cbe0[1+j*3]
+		assert.InDelta(cs*c0+sn*c1, ins.Cbe[j*3], 1e-12)
+		assert.InDelta(-sn*c0+cs*c1, ins.Cbe[1+j*3], 1e-12)
+		assert.InDelta(cbe0[2+j*3], ins.Cbe[2+j*3], 1e-12)
+	}
+	assert.Less(gnssgo.Norm(ins.Ve[:], 3), 1e-3)
+	assert.InDelta(re, ins.Re[0], 1e-3)
+	assert.InDelta(0.0, ins.Re[1], 1e-3)
+	assert.InDelta(0.0, ins.Re[2], 1e-3)
+
+	/* position update: P=1m^2, R=1m^2, innovation 1m -> correction 0.5m */
+	ins.Re = [3]float64{re, 0, 0}
+	ins.Ve = [3]float64{}
+	for i := range ins.P {
+		ins.P[i] = 0.0
+	}
+	for i := 0; i < 15; i++ {
+		ins.P[i+i*15] = 1.0
+	}
+	sol.Time = ins.Time
+	sol.Rr = [6]float64{re + 1.0, 0, 0}
+	sol.Qr = [6]float32{1, 1, 1}
+	assert.Equal(1, ins.InsUpdate(&sol, &opt))
+	assert.InDelta(re+0.5, ins.Re[0], 1e-9)
+	assert.InDelta(0.0, ins.Re[1], 1e-9)
+	assert.InDelta(0.5, ins.P[6+6*15], 1e-9)
+	assert.InDelta(0.5, ins.P[8+8*15], 1e-9)
+	assert.InDelta(1.0, ins.P[9+9*15], 1e-9)
+	assert.Equal(0.0, gnssgo.Norm(ins.Ba[:], 3))
+
+	/* gnss solution out of time */
+	sol.Time = gnssgo.TimeAdd(ins.Time, 2.0)
+	assert.Equal(0, ins.InsUpdate(&sol, &opt))
+}
+
+/* ReadImuCsv() */
+func Test_insutest2(t *testing.T) {
+	assert := assert.New(t)
+	var imu gnssgo.Imu
+	var week int
+
+	file := filepath.Join(t.TempDir(), "imu.csv")
+	os.WriteFile(file, []byte(
+		"# week,tow,ax,ay,az,gx,gy,gz\n"+
+			"2300,86400.000,0.1,-0.2,-9.8,0.001,0.002,-0.003\n"+
+			"%% comment\n"+
+			"\n"+
+			"2300 86400.010 0.2 -0.1 -9.7 0.0 0.0 0.01\n"+
+			"2024/02/04 00:00:00.020,0.3,0.0,-9.81,0.0,-0.01,0.0\n"+
+			"2300,86400.030,0.4\n"), 0666)
+
+	assert.Equal(0, gnssgo.ReadImuCsv(filepath.Join(t.TempDir(), "none.csv"), &imu))
+	assert.Equal(3, gnssgo.ReadImuCsv(file, &imu))
+	assert.Equal(3, imu.N())
+
+	tow := gnssgo.Time2GpsT(imu.Data[0].Time, &week)
+	assert.Equal(2300, week)
+	assert.InDelta(86400.000, tow, 1e-9)
+	assert.Equal([3]float64{0.1, -0.2, -9.8}, imu.Data[0].Acc)
+	assert.Equal([3]float64{0.001, 0.002, -0.003}, imu.Data[0].Gyro)
+	assert.InDelta(0.01, gnssgo.TimeDiff(imu.Data[1].Time, imu.Data[0].Time), 1e-9)
+	assert.Equal([3]float64{0.0, 0.0, 0.01}, imu.Data[1].Gyro)
+
+	/* calendar time in GPST */
+	tow = gnssgo.Time2GpsT(imu.Data[2].Time, &week)
+	assert.Equal(2300, week)
+	assert.InDelta(0.02, tow, 1e-9)
+	assert.Equal([3]float64{0.3, 0.0, -9.81}, imu.Data[2].Acc)
+}
+
+/* OpenSession() with ins */
+func Test_insutest3(t *testing.T) {
+	assert := assert.New(t)
+	var popt gnssgo.PrcOpt
+	var sopt gnssgo.SolOpt
+	var fopt gnssgo.FilOpt
+
+	popt.Mode = gnssgo.PMODE_KINEMA
+	popt.InsOpt.Mode = 1
+	for soltype, stat := range []int{1, 0, 0} { /* forward,backward,combined */
+		popt.SolType = soltype
+		p := gnssgo.NewPostProcessor()
+		assert.Equal(stat, p.OpenSession(&popt, &sopt, &fopt))
+		p.CloseSession()
+	}
+	/* forward only in single mode */
+	popt.Mode = gnssgo.PMODE_SINGLE
+	p := gnssgo.NewPostProcessor()
+	assert.Equal(1, p.OpenSession(&popt, &sopt, &fopt))
+	p.CloseSession()
+}
@@ -0,0 +1,250 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : mqtt stream functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"bufio"
+	"fmt"
+	"gnssgo"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* mqtt broker stand-in connection -------------------------------------------*/
+type mqttstub struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+/* accept connection and acknowledge connect request -------------------------*/
+func mqttaccept(t *testing.T, ln net.Listener) (*mqttstub, byte) {
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &mqttstub{conn: conn, rd: bufio.NewReader(conn)}
+	ptype, _, body := b.recv(t)
+	if ptype != gnssgo.MQTT_CONNECT || len(body) < 8 {
+		t.Fatalf("no connect: type=%d", ptype)
+	}
+	b.send(gnssgo.MQTT_CONNACK, 0, []byte{0, 0})
+	return b, body[7] /* connect flags */
+}
+
+/* receive packet (type, flags, body) ----------------------------------------*/
+func (b *mqttstub) recv(t *testing.T) (int, byte, []byte) {
+	b.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	h, err := b.rd.ReadByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, mul := 0, 1
+	for {
+		c, _ := b.rd.ReadByte()
+		n += int(c&0x7F) * mul
+		if mul *= 128; c&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	if _, err = io.ReadFull(b.rd, body); err != nil {
+		t.Fatal(err)
+	}
+	return int(h >> 4), h & 0x0F, body
+}
+
+/* send packet ---------------------------------------------------------------*/
+func (b *mqttstub) send(ptype int, flags byte, body []byte) {
+	b.conn.Write(append([]byte{byte(ptype)<<4 | flags, byte(len(body))}, body...))
+}
+
+/* no packet received in period ----------------------------------------------*/
+func (b *mqttstub) idle(ms int) bool {
+	b.conn.SetReadDeadline(time.Now().Add(time.Duration(ms) * time.Millisecond))
+	_, err := b.rd.ReadByte()
+	return err != nil
+}
+
+/* receive publish packet (flags, pid, payload) ------------------------------*/
+func (b *mqttstub) recvpub(t *testing.T, topic string) (byte, []byte, string) {
+	ptype, flags, body := b.recv(t)
+	if ptype != gnssgo.MQTT_PUBLISH || len(body) < 4+len(topic) ||
+		string(body[2:2+len(topic)]) != topic {
+		t.Fatalf("no publish: type=%d", ptype)
+	}
+	i := 2 + len(topic)
+	return flags, body[i : i+2], string(body[i+2:])
+}
+
+/* write stream until connected ----------------------------------------------*/
+func mqttwrite(stream *gnssgo.Stream, data string) int {
+	for i := 0; i < 200; i++ {
+		if n := stream.StreamWrite([]byte(data), len(data)); n > 0 {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return 0
+}
+
+/* publish qos 1 with resend on reconnect and without reconnect */
+func Test_mqttutest1(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_MQTT, gnssgo.STR_MODE_W,
+		fmt.Sprintf("%s/gnss::qos=1::id=utest1", ln.Addr().String())))
+	defer stream.StreamClose()
+	stream.StreamSetTimeout(0, 100)
+
+	/* first connection: publish not acknowledged */
+	b, cflag := mqttaccept(t, ln)
+	assert.Equal(byte(0), cflag&0x02) /* persistent session */
+	assert.Equal(4, mqttwrite(&stream, "msg1"))
+	flags, pid1, data := b.recvpub(t, "gnss")
+	assert.Equal(byte(0x02), flags)
+	assert.Equal("msg1", data)
+	b.conn.Close()
+
+	/* second connection: resent with dup flag and same packet identifier */
+	b, _ = mqttaccept(t, ln)
+	flags, pid, data := b.recvpub(t, "gnss")
+	assert.Equal(byte(0x0A), flags)
+	assert.Equal(pid1, pid)
+	assert.Equal("msg1", data)
+	b.send(gnssgo.MQTT_PUBACK, 0, pid)
+
+	assert.Equal(4, mqttwrite(&stream, "msg2"))
+	flags, pid, data = b.recvpub(t, "gnss")
+	assert.Equal(byte(0x02), flags)
+	assert.NotEqual(pid1, pid)
+	assert.Equal("msg2", data)
+	b.send(gnssgo.MQTT_PUBACK, 0, pid)
+	assert.True(b.idle(100))
+	b.conn.Close()
+
+	/* third connection: no message resent after acknowledged */
+	b, _ = mqttaccept(t, ln)
+	assert.True(b.idle(300))
+
+	/* no reconnect */
+	stream.StreamSetTimeout(0, 0)
+	b.conn.Close()
+	state := 0
+	for i := 0; i < 100 && state >= 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		state = stream.StreamStat(nil)
+	}
+	assert.Equal(-1, state)
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(300 * time.Millisecond))
+	_, err = ln.Accept()
+	assert.NotNil(err)
+}
+
+/* publish qos 2 with resend of pubrel on reconnect */
+func Test_mqttutest2(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_MQTT, gnssgo.STR_MODE_W,
+		fmt.Sprintf("%s/gnss/rtcm::qos=2", ln.Addr().String())))
+	defer stream.StreamClose()
+	stream.StreamSetTimeout(0, 100)
+
+	/* first connection: publish received but not completed */
+	b, _ := mqttaccept(t, ln)
+	assert.Equal(4, mqttwrite(&stream, "msg1"))
+	flags, pid1, data := b.recvpub(t, "gnss/rtcm")
+	assert.Equal(byte(0x04), flags)
+	assert.Equal("msg1", data)
+	b.send(gnssgo.MQTT_PUBREC, 0, pid1)
+	ptype, flags, body := b.recv(t)
+	assert.Equal(gnssgo.MQTT_PUBREL, ptype)
+	assert.Equal(byte(0x02), flags)
+	assert.Equal(pid1, body)
+	b.conn.Close()
+
+	/* second connection: pubrel resent instead of publish */
+	b, _ = mqttaccept(t, ln)
+	ptype, _, body = b.recv(t)
+	assert.Equal(gnssgo.MQTT_PUBREL, ptype)
+	assert.Equal(pid1, body)
+	b.send(gnssgo.MQTT_PUBCOMP, 0, pid1)
+	assert.True(b.idle(100))
+	b.conn.Close()
+
+	/* third connection: no message resent after completed */
+	b, _ = mqttaccept(t, ln)
+	assert.True(b.idle(300))
+	b.conn.Close()
+}
+
+/* subscribe qos 2 with duplicate message discarded until pubrel */
+func Test_mqttutest3(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_MQTT, gnssgo.STR_MODE_R,
+		fmt.Sprintf("%s/gnss::qos=2", ln.Addr().String())))
+	defer stream.StreamClose()
+	stream.StreamSetTimeout(0, 100)
+
+	publish := func(b *mqttstub, flags byte, pid byte, data string) {
+		b.send(gnssgo.MQTT_PUBLISH, flags, append([]byte{0, 4, 'g', 'n', 's', 's', 0, pid},
+			data...))
+		ptype, _, body := b.recv(t)
+		assert.Equal(gnssgo.MQTT_PUBREC, ptype)
+		assert.Equal([]byte{0, pid}, body)
+	}
+	/* first connection: publish received but not released */
+	b, _ := mqttaccept(t, ln)
+	ptype, _, body := b.recv(t)
+	assert.Equal(gnssgo.MQTT_SUBSCRIBE, ptype)
+	b.send(gnssgo.MQTT_SUBACK, 0, []byte{body[0], body[1], 2})
+	publish(b, 0x04, 7, "msg1")
+	publish(b, 0x0C, 7, "msg1") /* duplicate */
+	b.conn.Close()
+
+	/* second connection: resent publish discarded, released by pubrel */
+	b, _ = mqttaccept(t, ln)
+	ptype, _, body = b.recv(t)
+	assert.Equal(gnssgo.MQTT_SUBSCRIBE, ptype)
+	b.send(gnssgo.MQTT_SUBACK, 0, []byte{body[0], body[1], 2})
+	publish(b, 0x0C, 7, "msg1")
+	b.send(gnssgo.MQTT_PUBREL, 0x02, []byte{0, 7})
+	ptype, _, body = b.recv(t)
+	assert.Equal(gnssgo.MQTT_PUBCOMP, ptype)
+	assert.Equal([]byte{0, 7}, body)
+
+	/* packet identifier reused for new message after released */
+	publish(b, 0x04, 7, "msg2")
+	assert.Equal("msg1msg2", strreadn(&stream, 8))
+	assert.Equal("", strreadn(&stream, 1))
+	b.conn.Close()
+}
@@ -80,3 +80,130 @@ func Test_ppputest3(t *testing.T) {
 		assert.True(math.Abs(dr[i]-dp[i]) < 0.001)
 	}
 }
+
+/* generate dual-frequency gps observations with integer ambiguities ---------*/
+func pppobs(time gnssgo.Gtime, sats []int, N1, N2 []int, wlfrac []float64) []gnssgo.ObsD {
+	lam1, lam2 := gnssgo.CLIGHT/gnssgo.FREQ1, gnssgo.CLIGHT/gnssgo.FREQ2
+	obs := make([]gnssgo.ObsD, len(sats))
+	for i, sat := range sats {
+		rho := 2.1e7 + 1000.0*float64(sat)
+		obs[i] = gnssgo.ObsD{Time: time, Sat: sat, Rcv: 1}
+		obs[i].Code[0], obs[i].Code[1] = gnssgo.CODE_L1C, gnssgo.CODE_L2W
+		obs[i].P[0], obs[i].P[1] = rho, rho
+		obs[i].L[0] = rho/lam1 + float64(N1[i]) + wlfrac[i]
+		obs[i].L[1] = rho/lam2 + float64(N2[i])
+	}
+	return obs
+}
+
+/* UpdateWlPPP(), FixWlPPP() */
+func Test_ppputest4(t *testing.T) {
+	var rtk gnssgo.Rtk
+	var nwl int
+	assert := assert.New(t)
+	nav := new(gnssgo.Nav)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode, opt.IonoOpt = gnssgo.PMODE_PPP_KINEMA, gnssgo.IONOOPT_IFLC
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	sats := []int{3, 7, 11}
+	N1, N2 := []int{12, -5, 40}, []int{9, -1, 33}
+	t0 := gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+
+	/* wide-lane fixed by rounding after MIN_EPOCH_WL epochs */
+	for k := 0; k < 10; k++ {
+		obs := pppobs(gnssgo.TimeAdd(t0, float64(k)), sats, N1, N2, []float64{0.05, 0.0,
+			0.4})
+		rtk.UpdateWlPPP(obs, len(obs), nav)
+		if k < 9 {
+			assert.Equal(0, rtk.FixWlPPP(3, 7, &nwl))
+		}
+	}
+	assert.Equal(1, rtk.FixWlPPP(3, 7, &nwl))
+	assert.Equal((N1[0]-N2[0])-(N1[1]-N2[1]), nwl)
+
+	/* fraction over pos2-arthres2 rejected */
+	assert.Equal(0, rtk.FixWlPPP(11, 7, &nwl))
+	assert.Equal((N1[2]-N2[2])-(N1[1]-N2[1]), nwl)
+	rtk.Opt.ThresAr[2] = 0.45
+	assert.Equal(1, rtk.FixWlPPP(11, 7, &nwl))
+	rtk.Opt.ThresAr[2] = 0.25
+
+	/* average reset by cycle-slip */
+	rtk.Ssat[2].Slip[0] = 1
+	rtk.UpdateWlPPP(pppobs(gnssgo.TimeAdd(t0, 10.0), sats, N1, N2, []float64{0.05, 0.0,
+		0.4}), len(sats), nav)
+	assert.Equal(0, rtk.FixWlPPP(3, 7, &nwl))
+}
+
+/* PPPAmbiguity() */
+func Test_ppputest5(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+	nav := new(gnssgo.Nav)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode, opt.IonoOpt = gnssgo.PMODE_PPP_KINEMA, gnssgo.IONOOPT_IFLC
+	opt.ModeAr = gnssgo.ARMODE_CONT
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	f1, f2 := gnssgo.FREQ1, gnssgo.FREQ2
+	lamnl := gnssgo.CLIGHT / (f1 + f2)
+	sats := []int{2, 5, 9, 14, 21, 26}
+	N1, N2 := []int{10, -3, 25, 7, -12, 4}, []int{6, 1, 20, 9, -15, -2}
+	azel := []float64{0, 0.3, 0, 1.2, 0, 0.8, 0, 0.5, 0, 0.9, 0, 0.6}
+	exc := make([]int, len(sats))
+	t0 := gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+	for k := 0; k < 10; k++ {
+		obs := pppobs(gnssgo.TimeAdd(t0, float64(k)), sats, N1, N2, make([]float64,
+			len(sats)))
+		rtk.UpdateWlPPP(obs, len(obs), nav)
+	}
+	obs := pppobs(gnssgo.TimeAdd(t0, 10.0), sats, N1, N2, make([]float64, len(sats)))
+
+	/* float iono-free phase-bias states: B_IF=lam_NL*N1+c*f2/(f1^2-f2^2)*Nwl */
+	setstates := func(err []float64) ([]float64, []float64) {
+		nx := rtk.Nx
+		x, P := make([]float64, nx), make([]float64, nx*nx)
+		for i := 0; i < 3; i++ {
+			x[i], P[i+i*nx] = 1000.0*float64(i+1), 1.0
+		}
+		for i, sat := range sats {
+			j := gnssgo.IB(sat, 0, &rtk.Opt)
+			x[j] = 3.0 + lamnl*(float64(N1[i])+err[i]) +
+				gnssgo.CLIGHT*f2/(f1*f1-f2*f2)*float64(N1[i]-N2[i])
+			P[j+j*nx] = 1e-4
+		}
+		return x, P
+	}
+	sdamb := func(x []float64, i, ref int) float64 {
+		return (x[gnssgo.IB(sats[i], 0, &rtk.Opt)] - x[gnssgo.IB(sats[ref], 0, &rtk.Opt)] -
+			gnssgo.CLIGHT*f2/(f1*f1-f2*f2)*float64(N1[i]-N2[i]-N1[ref]+N2[ref])) / lamnl
+	}
+	/* narrow-lane rejected by ratio-test: ambiguity at half cycle */
+	x, P := setstates([]float64{0.0, 0.02, -0.03, 0.01, 0.5, 0.0})
+	x0 := append([]float64{}, x...)
+	assert.Equal(0, gnssgo.PPPAmbiguity(&rtk, obs, len(obs), exc, nav, azel, x, P))
+	assert.Greater(float64(rtk.RtkSol.Ratio), 0.0)
+	assert.Less(float64(rtk.RtkSol.Ratio), 3.0)
+	assert.Equal(x0, x)
+
+	/* narrow-lane fixed by LAMBDA, states constrained to fixed ambiguities */
+	x, P = setstates([]float64{0.0, 0.02, -0.03, 0.01, -0.04, 0.03})
+	assert.Equal(1, gnssgo.PPPAmbiguity(&rtk, obs, len(obs), exc, nav, azel, x, P))
+	assert.GreaterOrEqual(float64(rtk.RtkSol.Ratio), 3.0)
+	for i := range sats {
+		assert.Equal(uint8(2), rtk.Ssat[sats[i]-1].Fix[0], "sat=%d", sats[i])
+		if i != 1 { /* reference: highest elevation */
+			assert.InDelta(float64(N1[i]-N1[1]), sdamb(x, i, 1), 1e-3, "sat=%d", sats[i])
+		}
+		j := gnssgo.IB(sats[i], 0, &rtk.Opt)
+		assert.Less(P[j+j*rtk.Nx], 1e-4)
+	}
+	/* ambiguity resolution off */
+	rtk.Opt.ModeAr = gnssgo.ARMODE_OFF
+	assert.Equal(0, gnssgo.PPPAmbiguity(&rtk, obs, len(obs), exc, nav, azel, x, P))
+}
@@ -6,6 +6,7 @@ package gnss_test
 import (
 	"fmt"
 	"gnssgo"
+	"math"
 	"os"
 	"testing"
 
@@ -160,8 +161,8 @@ var opt2 gnssgo.RnxOpt = gnssgo.RnxOpt{
 func Test_renixutest3(t *testing.T) {
 	var nav gnssgo.Nav
 
-	opt1.OutRnxObsHeader(os.Stdout, &nav)
-	opt2.OutRnxObsHeader(os.Stdout, &nav)
+	gnssgo.OutRnxObsHeader(os.Stdout, &opt1, &nav)
+	gnssgo.OutRnxObsHeader(os.Stdout, &opt2, &nav)
 }
 
 /* outrneobsb() */
@@ -171,14 +172,14 @@ func Test_renixutest4(t *testing.T) {
 	var i, j int
 
 	gnssgo.ReadRnx(file, 1, "", &obs, nil, nil)
-	opt2.OutRnxObsBody(os.Stdout, obs.Data, 8, 9)
-	opt2.OutRnxObsBody(os.Stdout, obs.Data, 8, 0)
+	gnssgo.OutRnxObsBody(os.Stdout, &opt2, obs.Data, 8, 9)
+	gnssgo.OutRnxObsBody(os.Stdout, &opt2, obs.Data, 8, 0)
 
 	for i, j = 0, 0; i < obs.N(); i = j {
 		for j < obs.N() && gnssgo.TimeDiff(obs.Data[j].Time, obs.Data[i].Time) <= 0.0 {
 			j++
 		}
-		opt2.OutRnxObsBody(os.Stdout, obs.Data[i:], j-i, 0)
+		gnssgo.OutRnxObsBody(os.Stdout, &opt2, obs.Data[i:], j-i, 0)
 	}
 }
 
@@ -199,8 +200,8 @@ func Test_renixutest5(t *testing.T) {
 
 	gnssgo.ReadRnx(file1, 1, "", nil, &nav, nil)
 
-	opt1.OutRnxNavHeader(os.Stdout, &nav)
-	opt2.OutRnxNavHeader(os.Stdout, &nav)
+	gnssgo.OutRnxNavHeader(os.Stdout, &opt1, &nav)
+	gnssgo.OutRnxNavHeader(os.Stdout, &opt2, &nav)
 }
 
 /* outrnxnavb() */
@@ -210,7 +211,7 @@ func Test_renixutest6(t *testing.T) {
 	var i int
 	gnssgo.ReadRnx(file, 1, "", nil, &nav, nil)
 	for i = 0; i < nav.N(); i++ {
-		opt2.OutRnxNavBody(os.Stdout, &nav.Ephs[i])
+		gnssgo.OutRnxNavBody(os.Stdout, &opt2, &nav.Ephs[i])
 	}
 }
 
@@ -231,17 +232,121 @@ func Test_renixutest7(t *testing.T) {
 
 	gnssgo.ReadRnx(file1, 1, "", nil, &nav, nil)
 
-	opt2.OutRnxNavHeader(os.Stdout, &nav)
+	gnssgo.OutRnxNavHeader(os.Stdout, &opt2, &nav)
 	fp, _ := os.OpenFile(file2, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModeAppend|os.ModePerm)
 	defer fp.Close()
 	for i = 0; i < nav.N(); i++ {
-		opt2.OutRnxNavBody(fp, &nav.Ephs[i])
+		gnssgo.OutRnxNavBody(fp, &opt2, &nav.Ephs[i])
 	}
 
 	for i = 0; i < nav.Ng(); i++ {
-		opt2.OutRnxGnavBody(fp, &nav.Geph[i])
+		gnssgo.OutRnxGnavBody(fp, &opt2, &nav.Geph[i])
 	}
 	for i = 0; i < nav.Ns(); i++ {
-		opt2.OutRnxHnavBody(fp, &nav.Seph[i])
+		gnssgo.OutRnxHnavBody(fp, &opt2, &nav.Seph[i])
 	}
 }
+
+/* OutRnxNavBody(), OutRnxGnavBody(), OutRnxNavRecs(), ReadRnx() ver.4 */
+func Test_renixutest8(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		nav, nav2 gnssgo.Nav
+		opt       gnssgo.RnxOpt
+		ep        = []float64{2023, 3, 5, 12, 0, 0}
+		toc       = gnssgo.Epoch2Time(ep)
+		ttr       = gnssgo.TimeAdd(toc, -1800.0)
+		week      int
+	)
+	file := t.TempDir() + "/rnx4.nav"
+	opt.RnxVer, opt.NavSys, opt.Outiono, opt.OutputTime = 400, gnssgo.SYS_ALL, 1, 1
+
+	/* GPS LNAV and Galileo INAV ephemerides */
+	for i, sat := range []int{gnssgo.SatNo(gnssgo.SYS_GPS, 5), gnssgo.SatNo(gnssgo.SYS_GAL, 11)} {
+		var eph gnssgo.Eph
+		eph.Sat, eph.Iode, eph.Iodc, eph.Sva, eph.Fit = sat, 45+i, 45+i, 2, 4.0
+		eph.Toc, eph.Toe, eph.Ttr = toc, toc, ttr
+		eph.Toes = gnssgo.Time2GpsT(toc, &week)
+		eph.Week = week
+		eph.A, eph.E, eph.M0, eph.I0 = 26560000.0+float64(i)*3000000.0, 0.012, 1.25, 0.96
+		eph.OMG0, eph.Omg, eph.OMGd, eph.Deln = -2.1, 0.8, -8.1e-9, 4.3e-9
+		eph.F0, eph.F1, eph.Tgd[0] = 2.5e-4, -1.1e-12, -1.2e-8
+		if i == 1 {
+			eph.Code = 1<<0 | 1<<2 | 1<<9 /* I/NAV E1-B */
+		}
+		nav.Ephs = append(nav.Ephs, eph)
+	}
+	/* GLONASS FDMA ephemeris */
+	var geph gnssgo.GEph
+	geph.Sat, geph.Frq, geph.Sva, geph.Age = gnssgo.SatNo(gnssgo.SYS_GLO, 7), -2, 3, 1
+	geph.Toe, geph.Tof = gnssgo.TimeAdd(toc, 918.0), gnssgo.TimeAdd(toc, 888.0) /* utc+18s */
+	geph.Pos = [3]float64{1.2e7, -1.5e7, 1.8e7}
+	geph.Vel = [3]float64{-2500.5, 1200.25, 900.125}
+	geph.Taun, geph.Gamn, geph.DTaun = -1.5e-5, 1.8e-12, 2.8e-9
+	nav.Geph = append(nav.Geph, geph)
+
+	/* ionosphere, utc and earth orientation parameters */
+	copy(nav.Ion_gps[:], []float64{1.1e-8, 1.5e-8, -6.0e-8, -1.2e-7, 9.0e4, 1.3e5, -6.6e4, -4.6e5})
+	copy(nav.Ion_gal[:], []float64{62.0, 0.125, 0.0078, 0.0})
+	copy(nav.Utc_gps[:], []float64{-9.3e-10, 1.8e-15, 405504.0, 2251.0})
+	copy(nav.Utc_gal[:], []float64{2.8e-9, -4.4e-16, 345600.0, 2251.0})
+	nav.Erp.Data = []gnssgo.ErpD{{Mjd: 60008.0, Xp: 0.05 * gnssgo.AS2R, Yp: 0.42 * gnssgo.AS2R,
+		Xpr: 1.2e-4 * gnssgo.AS2R, Ypr: -3.0e-4 * gnssgo.AS2R, Ut1_utc: -0.0125, Lod: 2.1e-4}}
+
+	fp, err := os.Create(file)
+	assert.Nil(err)
+	gnssgo.OutRnxNavHeader(fp, &opt, &nav)
+	for i := 0; i < nav.N(); i++ {
+		assert.Equal(1, gnssgo.OutRnxNavBody(fp, &opt, &nav.Ephs[i]))
+	}
+	assert.Equal(1, gnssgo.OutRnxGnavBody(fp, &opt, &nav.Geph[0]))
+	gnssgo.OutRnxNavRecs(fp, &opt, &nav)
+	fp.Close()
+
+	assert.Equal(1, gnssgo.ReadRnx(file, 1, "", nil, &nav2, nil))
+	assert.Equal(2, nav2.N())
+	for i := 0; i < nav2.N(); i++ {
+		eph, eph2 := &nav.Ephs[i], &nav2.Ephs[i]
+		assert.Equal(eph.Sat, eph2.Sat)
+		assert.Equal(eph.Iode, eph2.Iode)
+		assert.Equal(0.0, gnssgo.TimeDiff(eph.Toe, eph2.Toe))
+		assert.Equal(0.0, gnssgo.TimeDiff(eph.Toc, eph2.Toc))
+		assert.InDelta(eph.A, eph2.A, 1e-3)
+		assert.InDelta(eph.E, eph2.E, 1e-14)
+		assert.InDelta(eph.M0, eph2.M0, 1e-12)
+		assert.InDelta(eph.OMGd, eph2.OMGd, 1e-20)
+		assert.InDelta(eph.F0, eph2.F0, 1e-16)
+		assert.InDelta(eph.Tgd[0], eph2.Tgd[0], 1e-20)
+	}
+	assert.Equal(1, nav2.Ng())
+	geph2 := &nav2.Geph[0]
+	assert.Equal(geph.Sat, geph2.Sat)
+	assert.Equal(geph.Frq, geph2.Frq)
+	assert.Equal(geph.Sva, geph2.Sva)
+	assert.Equal(0.0, gnssgo.TimeDiff(geph.Toe, geph2.Toe))
+	for i := 0; i < 3; i++ {
+		assert.InDelta(geph.Pos[i], geph2.Pos[i], 1e-6)
+		assert.InDelta(geph.Vel[i], geph2.Vel[i], 1e-9)
+	}
+	assert.InDelta(geph.Taun, geph2.Taun, 1e-17)
+	assert.InDelta(geph.Gamn, geph2.Gamn, 1e-24)
+	assert.InDelta(geph.DTaun, geph2.DTaun, 1e-20)
+
+	for i := 0; i < 8; i++ {
+		assert.InDelta(nav.Ion_gps[i], nav2.Ion_gps[i], math.Abs(nav.Ion_gps[i])*1e-11)
+	}
+	for i := 0; i < 4; i++ {
+		assert.InDelta(nav.Ion_gal[i], nav2.Ion_gal[i], 1e-12)
+		assert.InDelta(nav.Utc_gps[i], nav2.Utc_gps[i], math.Abs(nav.Utc_gps[i])*1e-11)
+		assert.InDelta(nav.Utc_gal[i], nav2.Utc_gal[i], math.Abs(nav.Utc_gal[i])*1e-11)
+	}
+	assert.Equal(1, nav2.Erp.N())
+	erp, erp2 := &nav.Erp.Data[0], &nav2.Erp.Data[0]
+	assert.InDelta(erp.Mjd, erp2.Mjd, 1e-9)
+	assert.InDelta(erp.Xp, erp2.Xp, 1e-18)
+	assert.InDelta(erp.Yp, erp2.Yp, 1e-18)
+	assert.InDelta(erp.Xpr, erp2.Xpr, 1e-20)
+	assert.InDelta(erp.Ypr, erp2.Ypr, 1e-20)
+	assert.InDelta(erp.Ut1_utc, erp2.Ut1_utc, 1e-15)
+	assert.InDelta(erp.Lod, erp2.Lod, 1e-16)
+}
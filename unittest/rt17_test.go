@@ -0,0 +1,202 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : trimble rt17 decoder functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"encoding/binary"
+	"gnssgo"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* generate Trimble packet (type, data) --------------------------------------*/
+func rt17packet(ctype byte, data []byte) []byte {
+	buff := []byte{gnssgo.RT17_STX, 0x00, ctype, byte(len(data))}
+	buff = append(buff, data...)
+	cs := byte(0)
+	for _, c := range buff[1:] {
+		cs += c
+	}
+	return append(buff, cs, gnssgo.RT17_ETX)
+}
+
+/* generate RAWDATA packets of record split into pages -----------------------*/
+func rt17rawdata(rectype, rif, reply byte, rec []byte, npage int) []byte {
+	var buff []byte
+	size := (len(rec) + npage - 1) / npage
+	for i := 0; i < npage; i++ {
+		end := (i + 1) * size
+		if end > len(rec) {
+			end = len(rec)
+		}
+		data := []byte{rectype, byte((i+1)<<4 | npage), reply, rif}
+		buff = append(buff, rt17packet(gnssgo.RT17_RAWDATA, append(data, rec[i*size:end]...))...)
+	}
+	return buff
+}
+
+/* big-endian field writers --------------------------------------------------*/
+func putr8b(p []byte, v float64) { binary.BigEndian.PutUint64(p, math.Float64bits(v)) }
+func putr4b(p []byte, v float32) { binary.BigEndian.PutUint32(p, math.Float32bits(v)) }
+
+/* RETSVDATA GPS ephemeris report --------------------------------------------*/
+func rt17eph(prn, week, iode int) []byte {
+	p := make([]byte, 176)
+	p[0], p[1] = gnssgo.RT17_SV_EPH, byte(prn)
+	binary.BigEndian.PutUint16(p[2:], uint16(week))
+	binary.BigEndian.PutUint16(p[4:], uint16(iode))
+	p[7] = byte(iode)
+	binary.BigEndian.PutUint32(p[8:], 340000)
+	binary.BigEndian.PutUint32(p[12:], 345600)
+	binary.BigEndian.PutUint32(p[16:], 345600)
+	putr8b(p[20:], -1.0e-8)
+	putr8b(p[44:], 1.0e-4)
+	putr8b(p[68:], 0.25)
+	putr8b(p[84:], 0.01)
+	putr8b(p[100:], 5153.6)
+	putr8b(p[132:], 0.3)
+	binary.BigEndian.PutUint32(p[172:], 2<<9) /* ura index 2 */
+	return p
+}
+
+/* input_rt17() RETSVDATA ephemeris, ion, utc */
+func Test_rt17utest1(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	assert.Equal(1, raw.InitRaw(gnssgo.STRFMT_RT17))
+	data := append([]byte{0x55, 0x03}, rt17packet(gnssgo.RT17_RETSVDATA, rt17eph(7, 2300, 45))...)
+	assert.Equal(2, inputraw(&raw, gnssgo.STRFMT_RT17, data))
+
+	sat := gnssgo.SatNo(gnssgo.SYS_GPS, 7)
+	assert.Equal(sat, raw.EphSat)
+	eph := raw.NavData.Ephs[sat-1]
+	assert.Equal(45, eph.Iode)
+	assert.Equal(45, eph.Iodc)
+	assert.Equal(2, eph.Sva)
+	assert.Equal(2300, eph.Week)
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Toe, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Ttr, gnssgo.GpsT2Time(2300, 340000.0)))
+	assert.InDelta(5153.6*5153.6, eph.A, 1e-6)
+	assert.InDelta(0.01, eph.E, 1e-15)
+	assert.InDelta(0.25*gnssgo.SC2RAD, eph.M0, 1e-15)
+	assert.InDelta(0.3*gnssgo.SC2RAD, eph.I0, 1e-15)
+	assert.InDelta(1.0e-4, eph.F0, 1e-15)
+	assert.InDelta(-1.0e-8, eph.Tgd[0], 1e-15)
+
+	/* unchanged ephemeris */
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_RT17, rt17packet(gnssgo.RT17_RETSVDATA,
+		rt17eph(7, 2300, 45))))
+
+	/* ionosphere parameters */
+	ion := make([]byte, 65)
+	ion[0] = gnssgo.RT17_SV_ION
+	for i := 0; i < 8; i++ {
+		putr8b(ion[1+i*8:], float64(i+1)*1e-8)
+	}
+	assert.Equal(9, inputraw(&raw, gnssgo.STRFMT_RT17, rt17packet(gnssgo.RT17_RETSVDATA, ion)))
+	assert.InDelta(8e-8, raw.NavData.Ion_gps[7], 1e-20)
+
+	/* checksum error */
+	data = rt17packet(gnssgo.RT17_RETSVDATA, ion)
+	data[len(data)-2]++
+	assert.Equal(-1, inputraw(&raw, gnssgo.STRFMT_RT17, data))
+	raw.FreeRaw()
+}
+
+/* input_rt17() RAWDATA real-time survey data (expanded, multi-page) */
+func Test_rt17utest2(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	raw.InitRaw(gnssgo.STRFMT_RT17)
+	rec := make([]byte, 17+8+40+24)
+	putr8b(rec, 345600000.0) /* receive time (ms) */
+	putr8b(rec[8:], 0.5)     /* clock offset (ms) */
+	rec[16] = 1
+	p := rec[17:]
+	p[0], p[1], p[2], p[3] = 12, 0x40|0x20|0x10|0x02|0x01, 0x04, 0x01 /* prn, flags1/2, status */
+	p = p[8:]
+	putr8b(p, 45.5)
+	putr8b(p[8:], 21000000.25)
+	putr8b(p[16:], -110000000.5)
+	putr8b(p[24:], -1234.5)
+	p = p[40:]
+	putr8b(p, 38.25)
+	putr8b(p[8:], -85000000.75)
+	putr8b(p[16:], 3.5)
+
+	/* gps week unknown */
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_RT17, rt17rawdata(0, 0, 1, rec, 1)))
+
+	/* missing page */
+	raw.Opt = "-WEEK=2300"
+	data := rt17rawdata(0, 0, 2, rec, 2)
+	assert.Equal(-1, inputraw(&raw, gnssgo.STRFMT_RT17, data[len(data)/2:]))
+
+	/* two pages */
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_RT17, data))
+	assert.Equal(1, raw.ObsData.N())
+	obs := raw.ObsData.Data[0]
+	assert.Equal(gnssgo.SatNo(gnssgo.SYS_GPS, 12), obs.Sat)
+	assert.InDelta(0.0, gnssgo.TimeDiff(obs.Time, gnssgo.GpsT2Time(2300, 345599.9995)), 1e-9)
+	assert.InDelta(21000000.25, obs.P[0], 1e-9)
+	assert.InDelta(110000000.5, obs.L[0], 1e-9)
+	assert.InDelta(-1234.5, obs.D[0], 1e-9)
+	assert.InDelta(45.5, float64(obs.SNR[0])*gnssgo.SNR_UNIT, 1e-6)
+	assert.InDelta(21000003.75, obs.P[1], 1e-9)
+	assert.InDelta(85000000.75, obs.L[1], 1e-9)
+	assert.InDelta(38.25, float64(obs.SNR[1])*gnssgo.SNR_UNIT, 1e-6)
+	assert.Equal(uint8(gnssgo.CODE_L1C), obs.Code[0])
+	assert.Equal(uint8(gnssgo.CODE_L2W), obs.Code[1])
+	assert.Equal(uint8(gnssgo.LLI_SLIP), obs.LLI[0])
+	assert.Equal(uint8(0), obs.LLI[1])
+	raw.FreeRaw()
+}
+
+/* input_rt17() RAWDATA real-time survey data (concise), GSOF gps week */
+func Test_rt17utest3(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	raw.InitRaw(gnssgo.STRFMT_RT17)
+
+	/* GSOF position time record: week from GENOUT */
+	gsof := []byte{1, 0, 0, gnssgo.RT17_GSOF_TIME, 10, 0, 0, 0, 0, 0x08, 0xFC, 8, 0, 0, 0}
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_RT17, rt17packet(gnssgo.RT17_GENOUT, gsof)))
+
+	rec := make([]byte, 17+8+21+13)
+	putr8b(rec, 100000000.0)
+	rec[16] = 1
+	p := rec[17:]
+	p[0], p[1], p[2] = 3, 0x40|0x20|0x10|0x04|0x01, 0x01
+	p = p[8:]
+	p[0] = 180 /* 45 dBHz */
+	putr8b(p[1:], 22000000.5)
+	putr8b(p[9:], -115000000.25)
+	putr4b(p[17:], 512.25)
+	p = p[21:]
+	p[0] = 160 /* 40 dBHz */
+	putr8b(p[1:], -90000000.5)
+	putr4b(p[9:], -2.5)
+
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_RT17, rt17rawdata(0, gnssgo.RT17_RIF_CONCISE,
+		1, rec, 1)))
+	obs := raw.ObsData.Data[0]
+	assert.Equal(gnssgo.SatNo(gnssgo.SYS_GPS, 3), obs.Sat)
+	assert.Equal(0.0, gnssgo.TimeDiff(obs.Time, gnssgo.GpsT2Time(2300, 100000.0)))
+	assert.InDelta(22000000.5, obs.P[0], 1e-9)
+	assert.InDelta(115000000.25, obs.L[0], 1e-9)
+	assert.InDelta(512.25, obs.D[0], 1e-9)
+	assert.InDelta(45.0, float64(obs.SNR[0])*gnssgo.SNR_UNIT, 1e-6)
+	assert.InDelta(21999998.0, obs.P[1], 1e-9)
+	assert.InDelta(90000000.5, obs.L[1], 1e-9)
+	assert.InDelta(40.0, float64(obs.SNR[1])*gnssgo.SNR_UNIT, 1e-6)
+	assert.Equal(uint8(gnssgo.CODE_L1P), obs.Code[0])
+	assert.Equal(uint8(gnssgo.CODE_L2X), obs.Code[1])
+	assert.Equal(uint8(gnssgo.LLI_SLIP), obs.LLI[1])
+	raw.FreeRaw()
+}
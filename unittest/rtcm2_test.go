@@ -0,0 +1,303 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : rtcm ver.2 functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* generate rtcm 2 frame in 6-of-8 form (type, station id, z-count, body) ----
+* word: last 30 bit word of the preceding frame in the stream (IO)
+*-----------------------------------------------------------------------------*/
+func rtcm2frame(word *uint32, ctype, staid, zcnt int, body []byte, nbit int) []byte {
+	var buff []byte
+
+	nw := (nbit + 23) / 24
+	msg := make([]byte, 6+nw*3)
+	gnssgo.SetBitU(msg, 0, 8, gnssgo.RTCM2PREAMB)
+	gnssgo.SetBitU(msg, 8, 6, uint32(ctype))
+	gnssgo.SetBitU(msg, 14, 10, uint32(staid))
+	gnssgo.SetBitU(msg, 24, 13, uint32(zcnt))
+	gnssgo.SetBitU(msg, 37, 3, 1)
+	gnssgo.SetBitU(msg, 40, 5, uint32(nw))
+	copy(msg[6:], body)
+	for i := 0; i < len(msg); i += 3 {
+		gnssgo.Encode_Word(gnssgo.GetBitU(msg, i*8, 24), word)
+		for j := 0; j < 5; j++ {
+			b := byte(0x40)
+			for k := 0; k < 6; k++ {
+				b |= byte((*word>>(29-j*6-k))&1) << k
+			}
+			buff = append(buff, b)
+		}
+	}
+	return buff
+}
+
+/* input rtcm 2 frame and return last non-zero status ------------------------*/
+func inputrtcm2(rtcm *gnssgo.Rtcm, data []byte) int {
+	ret := 0
+	for _, c := range data {
+		if r := rtcm.InputRtcm2(c); r != 0 {
+			ret = r
+		}
+	}
+	return ret
+}
+
+/* differential glonass correction (fact, udre, prn, prc, rrc, tb) -----------*/
+func rtcm2dglo(body []byte, pos, fact, udre, prn, prc, rrc, tb int) int {
+	gnssgo.SetBitU(body, pos, 1, uint32(fact))
+	gnssgo.SetBitU(body, pos+1, 2, uint32(udre))
+	gnssgo.SetBitU(body, pos+3, 5, uint32(prn))
+	gnssgo.SetBits(body, pos+8, 16, int32(prc))
+	gnssgo.SetBits(body, pos+24, 8, int32(rrc))
+	gnssgo.SetBitU(body, pos+33, 7, uint32(tb))
+	return pos + 40
+}
+
+/* InputRtcm2() type 31, 34: differential glonass corrections */
+func Test_rtcm2utest1(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		rtcm gnssgo.Rtcm
+		word uint32
+	)
+
+	rtcm.InitRtcm()
+	rtcm.Time = gnssgo.GpsT2Time(2300, 346600.0)
+
+	/* z-count in glonass time: utc 982.2 s past the hour (gpst 1000.2 s) */
+	body := make([]byte, 15)
+	i := rtcm2dglo(body, 0, 0, 1, 3, 1234, -56, 17)
+	i = rtcm2dglo(body, i, 0, 0, 4, -32768, 0, 5) /* satellite problem */
+	i = rtcm2dglo(body, i, 0, 2, 24, -500, 100, 99)
+	data := rtcm2frame(&word, 31, 123, 1637, body, i)
+	assert.Equal(7, inputrtcm2(&rtcm, data))
+
+	t0 := gnssgo.GpsT2Time(2300, 346600.2)
+	dgps := rtcm.Dgps[gnssgo.SatNo(gnssgo.SYS_GLO, 3)-1]
+	assert.InDelta(0.0, gnssgo.TimeDiff(dgps.T0, t0), 1e-6)
+	assert.InDelta(1234*0.02, dgps.Prc, 1e-9)
+	assert.InDelta(-56*0.002, dgps.Rrc, 1e-9)
+	assert.Equal(17, dgps.Iod)
+	assert.Equal(1.0, dgps.Udre)
+	dgps = rtcm.Dgps[gnssgo.SatNo(gnssgo.SYS_GLO, 24)-1]
+	assert.InDelta(0.0, gnssgo.TimeDiff(dgps.T0, t0), 1e-6)
+	assert.InDelta(-500*0.02, dgps.Prc, 1e-9)
+	assert.InDelta(100*0.002, dgps.Rrc, 1e-9)
+	assert.Equal(99, dgps.Iod)
+	assert.Equal(0.0, rtcm.Dgps[gnssgo.SatNo(gnssgo.SYS_GLO, 4)-1].Prc)
+
+	/* type 34 (partial correction set), scale factor, utc hour before gpst hour */
+	rtcm.Time = gnssgo.GpsT2Time(2300, 349205.0)
+	body = make([]byte, 6)
+	i = rtcm2dglo(body, 0, 1, 3, 3, -100, 10, 18)
+	data = rtcm2frame(&word, 34, 123, 5979, body, i)
+	assert.Equal(7, inputrtcm2(&rtcm, data))
+	dgps = rtcm.Dgps[gnssgo.SatNo(gnssgo.SYS_GLO, 3)-1]
+	assert.InDelta(0.0, gnssgo.TimeDiff(dgps.T0, gnssgo.GpsT2Time(2300, 349205.4)), 1e-6)
+	assert.InDelta(-100*0.32, dgps.Prc, 1e-9)
+	assert.InDelta(10*0.032, dgps.Rrc, 1e-9)
+	assert.Equal(18, dgps.Iod)
+	assert.Equal(3.0, dgps.Udre)
+	assert.Equal(uint32(1), rtcm.Nmsg2[31])
+	assert.Equal(uint32(1), rtcm.Nmsg2[34])
+	rtcm.FreeRtcm()
+}
+
+/* InputRtcm2() type 32: glonass reference station, 37: gnss time offset */
+func Test_rtcm2utest2(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		rtcm gnssgo.Rtcm
+		word uint32
+	)
+
+	rtcm.InitRtcm()
+	rtcm.Time = gnssgo.GpsT2Time(2300, 346600.0)
+
+	body := make([]byte, 12)
+	gnssgo.SetBits(body, 0, 32, -274895512)
+	gnssgo.SetBits(body, 32, 32, 475870036)
+	gnssgo.SetBits(body, 64, 32, 370994843)
+	assert.Equal(5, inputrtcm2(&rtcm, rtcm2frame(&word, 32, 45, 1637, body, 96)))
+	assert.InDelta(-2748955.12, rtcm.StaPara.Pos[0], 1e-6)
+	assert.InDelta(4758700.36, rtcm.StaPara.Pos[1], 1e-6)
+	assert.InDelta(3709948.43, rtcm.StaPara.Pos[2], 1e-6)
+	assert.Equal(45, rtcm.StaId)
+
+	/* length error */
+	assert.Equal(-1, inputrtcm2(&rtcm, rtcm2frame(&word, 32, 45, 1637, body[:6], 48)))
+
+	/* gps-glonass time offset */
+	body = make([]byte, 6)
+	gnssgo.SetBits(body, 8, 32, -123456)
+	assert.Equal(9, inputrtcm2(&rtcm, rtcm2frame(&word, 37, 45, 1640, body, 40)))
+	assert.InDelta(-123456e-9, rtcm.NavData.Utc_glo[1], 1e-15)
+
+	/* unsupported system */
+	gnssgo.SetBitU(body, 0, 1, 1)
+	gnssgo.SetBits(body, 8, 32, 777)
+	assert.Equal(0, inputrtcm2(&rtcm, rtcm2frame(&word, 37, 45, 1645, body, 40)))
+	assert.InDelta(-123456e-9, rtcm.NavData.Utc_glo[1], 1e-15)
+	rtcm.FreeRtcm()
+}
+
+/* generate rtcm 2 message and input it to decoder ---------------------------*/
+func genrtcm2(enc, dec *gnssgo.Rtcm, ctype, sync int) int {
+	if enc.GenRtcm2(ctype, sync) == 0 {
+		return -2
+	}
+	return inputrtcm2(dec, enc.Buff[:enc.Nbyte])
+}
+
+/* Encode_Word() */
+func Test_rtcm2utest3(t *testing.T) {
+	assert := assert.New(t)
+
+	/* parity by IS-GPS-200 20.3.5.2 with previous D29*,D30* */
+	for _, c := range []struct{ data, prev, word uint32 }{
+		{0x66A5C3, 0x0, 0x19A970C6},
+		{0x66A5C3, 0x1, 0x26568F10},
+		{0x123456, 0x2, 0x048D15A8},
+		{0x123456, 0x3, 0x3B72EA7E},
+	} {
+		word := c.prev
+		gnssgo.Encode_Word(c.data, &word)
+		assert.Equal(c.word, word)
+
+		buff := make([]byte, 3)
+		assert.Equal(1, gnssgo.Decode_Word(c.prev<<30|word, buff))
+		assert.Equal(c.data, gnssgo.GetBitU(buff, 0, 24))
+		assert.Equal(0, gnssgo.Decode_Word(c.prev<<30|word^0x4, buff))
+	}
+}
+
+/* GenRtcm2(), InputRtcm2() type 1, 3, 9, 18, 19, 22, 24, 31 */
+func Test_rtcm2utest4(t *testing.T) {
+	assert := assert.New(t)
+	var enc, dec gnssgo.Rtcm
+
+	enc.InitRtcm()
+	dec.InitRtcm()
+	time := gnssgo.GpsT2Time(2300, 345618.0) /* gpst and utc on modified z-count */
+	enc.StaId = 123
+	enc.StaPara.Pos = [3]float64{-2748955.1234, 4758700.3678, 3709948.4391}
+	enc.StaPara.Hgt = 1.2345
+
+	/* broadcast ephemerides */
+	gps, glo := []int{3, 7, 12, 24}, []int{1, 9}
+	for i, prn := range gps {
+		sat := gnssgo.SatNo(gnssgo.SYS_GPS, prn)
+		enc.NavData.Ephs[sat-1] = gnssgo.Eph{Sat: sat, Iode: 40 + i, Iodc: 40 + i, Week: 2300,
+			Toe: time, Toc: time, Ttr: time, Toes: 345618.0, A: 26560000.0, E: 0.01,
+			I0: 0.96, OMG0: float64(i) * 1.5, M0: float64(i)*0.7 - 1.0, F0: 1e-5}
+	}
+	for i, prn := range glo {
+		enc.NavData.Geph[prn-1] = gnssgo.GEph{Sat: gnssgo.SatNo(gnssgo.SYS_GLO, prn), Iode: 70 + i, Frq: i - 2, Toe: time,
+			Tof: time, Pos: [3]float64{-1.2e7 + float64(i)*4e6, 1.5e7, 1.6e7},
+			Vel: [3]float64{1500.0, -1200.0 + float64(i)*500.0, 1000.0}, Taun: -2e-5}
+	}
+	/* observation data: pseudorange = range - correction + receiver clock */
+	enc.ObsData.Data = nil
+	prc := []float64{12.34, -5.67, 20.5, -3.21, 8.76, -15.43}
+	rrc := []float64{0.05, -0.12, 0.08, 0.01, -0.06, 0.11}
+	for i, sat := range append(gps, glo...) {
+		obs := gnssgo.ObsD{Time: time, Code: [gnssgo.NFREQ + gnssgo.NEXOBS]uint8{
+			gnssgo.CODE_L1C, gnssgo.CODE_L2W}}
+		obs.Sat = gnssgo.SatNo(gnssgo.SYS_GPS, sat)
+		if i >= len(gps) {
+			obs.Sat = gnssgo.SatNo(gnssgo.SYS_GLO, sat)
+		}
+		obs.P[0], obs.P[1] = 2.2e7, 2.2e7+3.5
+		obs.L[0], obs.L[1] = 115000000.125+float64(i)*1e5, 89000000.75+float64(i)*1e5
+		enc.ObsData.AddObsData(&obs)
+	}
+	n := enc.ObsData.N()
+	rs, dts := make([]float64, 6*n), make([]float64, 2*n)
+	vari, svh := make([]float64, n), make([]int, n)
+	rng, rate := make([]float64, n), make([]float64, n)
+	var e [3]float64
+	for k := 0; k < 3; k++ {
+		enc.NavData.SatPoss(time, enc.ObsData.Data, n, gnssgo.EPHOPT_BRDC, rs, dts, vari, svh)
+		for i := range enc.ObsData.Data {
+			d := &enc.ObsData.Data[i]
+			r := gnssgo.GeoDist(rs[i*6:], enc.StaPara.Pos[:], e[:])
+			rng[i] = r - gnssgo.CLIGHT*dts[i*2]
+			rate[i] = gnssgo.Dot(rs[3+i*6:], e[:], 3) - gnssgo.CLIGHT*dts[1+i*2]
+			freq := gnssgo.Sat2Freq(d.Sat, d.Code[0], &enc.NavData)
+			d.P[0] = rng[i] - prc[i] + 300.0
+			d.P[1] = d.P[0] + 3.5
+			d.D[0] = (rrc[i] + 0.2 - rate[i]) * freq / gnssgo.CLIGHT
+		}
+	}
+	assert.Equal(0, svh[0])
+	dec.Time = gnssgo.TimeAdd(time, 120.0)
+
+	/* type 3, 22, 24: station parameters */
+	assert.Equal(5, genrtcm2(&enc, &dec, 3, 0))
+	assert.Equal(123, dec.StaId)
+	for j := 0; j < 3; j++ {
+		assert.InDelta(enc.StaPara.Pos[j], dec.StaPara.Pos[j], 0.005)
+	}
+	assert.Equal(5, genrtcm2(&enc, &dec, 22, 0))
+	for j := 0; j < 3; j++ {
+		assert.InDelta(enc.StaPara.Pos[j], dec.StaPara.Pos[j]+dec.StaPara.Del[j], 1/25600.0)
+	}
+	assert.InDelta(1.2345, dec.StaPara.Hgt, 1/25600.0)
+	assert.Equal(5, genrtcm2(&enc, &dec, 24, 0))
+	for j := 0; j < 3; j++ {
+		assert.InDelta(enc.StaPara.Pos[j], dec.StaPara.Pos[j], 0.00005)
+	}
+	assert.InDelta(1.2345, dec.StaPara.Hgt, 0.00005)
+
+	/* type 1, 9: gps corrections with receiver clock removed */
+	for _, ctype := range []int{1, 9} {
+		dec.Dgps = [gnssgo.MAXSAT]gnssgo.DGps{}
+		assert.Equal(7, genrtcm2(&enc, &dec, ctype, 0), ctype)
+		for i, prn := range gps {
+			dgps := dec.Dgps[gnssgo.SatNo(gnssgo.SYS_GPS, prn)-1]
+			assert.InDelta(0.0, gnssgo.TimeDiff(dgps.T0, time), 1e-6, ctype)
+			assert.InDelta(prc[i]-5.99, dgps.Prc, 0.011, ctype)
+			assert.InDelta(rrc[i]-0.005, dgps.Rrc, 0.0011, ctype)
+			assert.Equal(40+i, dgps.Iod, ctype)
+		}
+	}
+	assert.Equal(uint32(1), dec.Nmsg2[1])
+	assert.Equal(uint32(2), dec.Nmsg2[9]) /* 3 + 1 satellites */
+
+	/* type 31: glonass corrections with time tag in utc */
+	assert.Equal(7, genrtcm2(&enc, &dec, 31, 0))
+	for i, prn := range glo {
+		dgps := dec.Dgps[gnssgo.SatNo(gnssgo.SYS_GLO, prn)-1]
+		assert.InDelta(0.0, gnssgo.TimeDiff(dgps.T0, time), 1e-6)
+		assert.InDelta(prc[len(gps)+i]-(8.76-15.43)/2, dgps.Prc, 0.011)
+		assert.InDelta(rrc[len(gps)+i]-(-0.06+0.11)/2, dgps.Rrc, 0.0011)
+		assert.Equal(70+i, dgps.Iod)
+	}
+
+	/* type 18, 19: rtk carrier-phase and pseudorange */
+	assert.Equal(0, genrtcm2(&enc, &dec, 18, 1))
+	assert.Equal(1, genrtcm2(&enc, &dec, 19, 0))
+	assert.Equal(n, dec.ObsData.N())
+	for i := range enc.ObsData.Data {
+		d := &enc.ObsData.Data[i]
+		j := dec.ObsData.ObsIndex(time, d.Sat)
+		obs := &dec.ObsData.Data[j]
+		assert.InDelta(0.0, gnssgo.TimeDiff(obs.Time, time), 1e-6, d.Sat)
+		for f := 0; f < 2; f++ {
+			assert.InDelta(d.P[f], obs.P[f], 0.01, d.Sat)
+			assert.InDelta(d.L[f]-math.Floor(d.L[f]), obs.L[f], 1/256.0, d.Sat)
+		}
+		assert.Equal(uint8(gnssgo.CODE_L1C), obs.Code[0])
+		assert.Equal(uint8(gnssgo.CODE_L2P), obs.Code[1])
+	}
+	enc.FreeRtcm()
+	dec.FreeRtcm()
+}
@@ -0,0 +1,350 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : rtk positioning functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* SmoothCode() */
+func Test_rtkposutest1(t *testing.T) {
+	assert := assert.New(t)
+	var rtk gnssgo.Rtk
+	var nav gnssgo.Nav
+	var raw, smt [4][100]float64
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.CodeSmooth = 100
+	rtk.InitRtk(&opt)
+
+	/* constant range-rate, code noise 0.5m, clock jump (1ms) of all
+	   satellites at 40s and slip (10cyc) of sat 1 at 60s without LLI */
+	lam := gnssgo.CLIGHT / gnssgo.FREQ1
+	vel := []float64{-300.0, 100.0, 500.0, 50.0}
+	rnd := rand.New(rand.NewSource(1))
+	t0 := gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0})
+	rng := func(i, k int) float64 {
+		if k >= 40 {
+			return 2.0e7 + vel[i]*float64(k) + 1e-3*gnssgo.CLIGHT
+		}
+		return 2.0e7 + vel[i]*float64(k)
+	}
+	genobs := func(k, rcv int) []gnssgo.ObsD {
+		obs := make([]gnssgo.ObsD, 4)
+		for i := range obs {
+			obs[i] = gnssgo.ObsD{Time: gnssgo.TimeAdd(t0, float64(k)), Sat: i + 1, Rcv: rcv}
+			obs[i].Code[0] = gnssgo.CODE_L1C
+			obs[i].L[0] = rng(i, k) / lam
+			obs[i].P[0] = rng(i, k) + rnd.NormFloat64()*0.5
+			obs[i].D[0] = -vel[i] / lam
+			if i == 0 && k >= 60 {
+				obs[i].L[0] += 10.0
+			}
+		}
+		return obs
+	}
+	for k := 0; k < 100; k++ {
+		obs := genobs(k, 1)
+		for i := range obs {
+			raw[i][k] = obs[i].P[0] - rng(i, k)
+		}
+		rtk.SmoothCode(obs, 4, 1, &nav)
+		for i := range obs {
+			smt[i][k] = obs[i].P[0] - rng(i, k)
+		}
+		if k == 60 { /* reset by phase-rate jump */
+			assert.Equal(raw[0][k], smt[0][k])
+			assert.Equal(1, rtk.Csm[0][0].Cnt[0])
+		}
+	}
+	/* no reset by clock jump */
+	for i := 1; i < 4; i++ {
+		assert.Equal(100, rtk.Csm[0][i].Cnt[0])
+	}
+	/* noise reduction */
+	var sr, ss, bias float64
+	for k := 20; k < 100; k++ {
+		sr += raw[1][k] * raw[1][k]
+		ss += smt[1][k] * smt[1][k]
+	}
+	assert.Less(math.Sqrt(ss/80.0), 0.3*math.Sqrt(sr/80.0))
+	for k := 80; k < 100; k++ { /* no slip bias after reset */
+		bias += smt[0][k] / 20.0
+	}
+	assert.Less(math.Abs(bias), 0.3)
+
+	/* base station data of same epoch smoothed once for rover epochs */
+	for k := 0; k < 10; k++ {
+		obsb := genobs(k, 2)
+		obsc := make([]gnssgo.ObsD, 4)
+		copy(obsc, obsb)
+		rtk.SmoothCode(obsb, 4, 2, &nav)
+		rtk.SmoothCode(obsc, 4, 2, &nav)
+		for i := range obsb {
+			assert.Equal(obsb[i].P[0], obsc[i].P[0])
+			assert.Equal(k+1, rtk.Csm[1][i].Cnt[0])
+		}
+	}
+}
+
+/* DD ambiguity fixture: GPS sats 1-7 (el 80-20 deg), true SD ambiguities ----*/
+var (
+	ambsats = []int{1, 2, 3, 4, 5, 6, 7}
+	ambN1   = []int{5, -3, 12, 7, -8, 2, 20}
+	ambN2   = []int{1, -6, 10, 9, -2, 4, 15}
+)
+
+/* rover/base observations with SD ambiguities (dP: rover code bias (m)) -----*/
+func ambobs(k int, dP []float64) ([]gnssgo.ObsD, []int, []int) {
+	ns := len(ambsats)
+	obs := make([]gnssgo.ObsD, ns*2)
+	iu, ir := make([]int, ns), make([]int, ns)
+	t := gnssgo.TimeAdd(gnssgo.Epoch2Time([]float64{2024, 2, 4, 0, 0, 0}), float64(k))
+	lam := []float64{gnssgo.CLIGHT / gnssgo.FREQ1, gnssgo.CLIGHT / gnssgo.FREQ2}
+	for i, sat := range ambsats {
+		r := 2.2e7 + 1000.0*float64(sat) + 10.0*float64(k)
+		n := []float64{float64(ambN1[i]), float64(ambN2[i])}
+		noise := 0.1 * float64((k+i)%2*2-1)
+		for j, rcv := range []int{1, 2} {
+			o := &obs[i+j*ns]
+			o.Time, o.Sat, o.Rcv = t, sat, rcv
+			o.Code[0], o.Code[1] = gnssgo.CODE_L1C, gnssgo.CODE_L2W
+			for f := 0; f < 2; f++ {
+				if o.L[f], o.P[f] = (r+5.0*float64(j))/lam[f], r+5.0*float64(j); rcv == 1 {
+					o.L[f] += n[f]
+					o.P[f] += noise + dP[i]
+				}
+			}
+		}
+		iu[i], ir[i] = i, i+ns
+	}
+	return obs, iu, ir
+}
+
+/* rtk with float SD ambiguities of fixture (e1,e2: float errors (cycle)) ----*/
+func ambrtk(rtk *gnssgo.Rtk, e1, e2 []float64) {
+	nx := rtk.Nx
+	for i := range rtk.X {
+		rtk.X[i] = 0.0
+	}
+	for i := range rtk.P {
+		rtk.P[i] = 0.0
+	}
+	for i := 0; i < rtk.Na; i++ {
+		rtk.X[i], rtk.P[i+i*nx] = 1e6*float64(i+1), 1.0
+	}
+	for i, sat := range ambsats {
+		ssat := &rtk.Ssat[sat-1]
+		ssat.Sys, ssat.Azel[1] = gnssgo.SYS_GPS, float64(80-10*i)*gnssgo.D2R
+		for f, N := range []float64{float64(ambN1[i]) + e1[i], float64(ambN2[i]) + e2[i]} {
+			j := gnssgo.RIB(sat, f, &rtk.Opt)
+			rtk.X[j], rtk.P[j+j*nx] = N, 0.01
+			ssat.Vsat[f], ssat.Half[f], ssat.Lock[f], ssat.Slip[f] = 1, 1, 10, 0
+		}
+	}
+}
+
+/* UpdateWlRtk(), FixWlRtk() */
+func Test_rtkposutest2(t *testing.T) {
+	var (
+		rtk gnssgo.Rtk
+		nav gnssgo.Nav
+		nwl int
+	)
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode = gnssgo.PMODE_KINEMA
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	/* sat 6: MW-LC biased by half wide-lane cycle (0.431 m code bias) */
+	dP := []float64{0, 0, 0, 0, 0, 0.431, 0}
+	ns := len(ambsats)
+	for k := 0; k < 10; k++ {
+		if k == 9 { /* not enough epochs */
+			assert.Equal(0, rtk.FixWlRtk(1, 2, 0, &nwl))
+		}
+		obs, iu, ir := ambobs(k, dP)
+		rtk.UpdateWlRtk(obs, ambsats, iu, ir, ns, &nav)
+	}
+	for i := 1; i < ns; i++ {
+		if i == 5 {
+			assert.Equal(0, rtk.FixWlRtk(1, ambsats[i], 0, &nwl))
+			continue
+		}
+		assert.Equal(1, rtk.FixWlRtk(1, ambsats[i], 0, &nwl), "sat=%d", ambsats[i])
+		assert.Equal(ambN1[0]-ambN2[0]-ambN1[i]+ambN2[i], nwl, "sat=%d", ambsats[i])
+	}
+	/* no extra-wide-lane for dual-frequency */
+	assert.Equal(0, rtk.FixWlRtk(1, 2, 1, &nwl))
+
+	/* max fraction of wide-lane ambiguity */
+	rtk.Opt.ThresAr[2] = 0.5
+	assert.Equal(1, rtk.FixWlRtk(1, 6, 0, &nwl))
+	rtk.Opt.ThresAr[2] = 0.25
+
+	/* average reset by cycle-slip */
+	rtk.Ssat[1].Slip[1] = 1
+	obs, iu, ir := ambobs(10, dP)
+	rtk.UpdateWlRtk(obs, ambsats, iu, ir, ns, &nav)
+	assert.Equal(0, rtk.FixWlRtk(1, 2, 0, &nwl))
+	assert.Equal(1, rtk.FixWlRtk(1, 3, 0, &nwl))
+}
+
+/* ResolveAmb_WLNL(): wide-lane fix, then narrow-lane fix by LAMBDA */
+func Test_rtkposutest3(t *testing.T) {
+	var (
+		rtk gnssgo.Rtk
+		nav gnssgo.Nav
+	)
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode, opt.ModeAr = gnssgo.PMODE_KINEMA, gnssgo.ARMODE_WLNL
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	e1 := []float64{0.05, -0.08, 0.1, -0.04, 0.07, -0.06, 0.03}
+	e2 := []float64{-0.03, 0.06, -0.09, 0.08, -0.05, 0.04, -0.07}
+	bias, xa := make([]float64, rtk.Nx), make([]float64, rtk.Nx)
+	ns := len(ambsats)
+	dP := []float64{0, 0, 0, 0, 0, 0.431, 0}
+
+	/* no fixed wide-lane ambiguity */
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(0, rtk.ResolveAmb_WLNL(bias, xa))
+
+	for k := 0; k < 10; k++ {
+		obs, iu, ir := ambobs(k, dP)
+		rtk.UpdateWlRtk(obs, ambsats, iu, ir, ns, &nav)
+	}
+	/* sat 6 excluded by wide-lane, N1 and N2=N1-Nwl of 5 DD pairs fixed */
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(10, rtk.ResolveAmb_WLNL(bias, xa))
+	assert.GreaterOrEqual(float64(rtk.RtkSol.Ratio), 3.0)
+	for i, j := 1, 0; i < ns; i++ {
+		sat := ambsats[i]
+		if i == 5 {
+			assert.Equal(uint8(1), rtk.Ssat[sat-1].Fix[0])
+			assert.Equal(rtk.X[gnssgo.RIB(sat, 0, &rtk.Opt)], xa[gnssgo.RIB(sat, 0, &rtk.Opt)])
+			continue
+		}
+		assert.Equal(float64(ambN1[0]-ambN1[i]), bias[j*2], "sat=%d", sat)
+		assert.Equal(float64(ambN2[0]-ambN2[i]), bias[j*2+1], "sat=%d", sat)
+		j++
+		for f, N := range [][]int{ambN1, ambN2} {
+			assert.Equal(uint8(2), rtk.Ssat[sat-1].Fix[f], "sat=%d", sat)
+			assert.InDelta(float64(N[0]-N[i]), xa[gnssgo.RIB(1, f, &rtk.Opt)]-
+				xa[gnssgo.RIB(sat, f, &rtk.Opt)], 1e-9, "sat=%d", sat)
+		}
+	}
+	assert.Equal(uint8(2), rtk.Ssat[0].Fix[0])
+
+	/* narrow-lane at half cycle (sat 7): rejected by ratio-test */
+	e1[6], e2[6] = 0.5, 0.5
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(0, rtk.ResolveAmb_WLNL(bias, xa))
+	assert.Less(float64(rtk.RtkSol.Ratio), 3.0)
+
+	/* partial ar: lowest elevation satellite (sat 7) excluded */
+	rtk.Opt.ArPartial = 1
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(8, rtk.ResolveAmb_WLNL(bias, xa))
+	assert.GreaterOrEqual(float64(rtk.RtkSol.Ratio), 3.0)
+	assert.Equal(uint8(1), rtk.Ssat[6].Fix[0])
+	assert.Equal(uint8(2), rtk.Ssat[4].Fix[0])
+	for j, i := range []int{1, 2, 3, 4} {
+		assert.Equal(float64(ambN1[0]-ambN1[i]), bias[j*2])
+		assert.Equal(float64(ambN2[0]-ambN2[i]), bias[j*2+1])
+	}
+}
+
+/* ExcludeAmb() */
+func Test_rtkposutest4(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode, opt.ArPartial = gnssgo.PMODE_KINEMA, 1
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	zero := make([]float64, len(ambsats))
+	ambrtk(&rtk, zero, zero)
+	ix := make([]int, rtk.Nx*2)
+	nb := rtk.DDIndex(ix)
+	assert.Equal(12, nb)
+	tgt := func(ix []int, nb int) (sats []int) {
+		for i := 0; i < nb; i++ {
+			sats = append(sats, (ix[i*2+1]-rtk.Na)%gnssgo.MAXSAT+1)
+		}
+		return
+	}
+	/* lowest elevation satellite (sat 7) of all frequencies */
+	assert.Equal(10, rtk.ExcludeAmb(ix, nb))
+	assert.Equal([]int{2, 3, 4, 5, 6, 2, 3, 4, 5, 6}, tgt(ix, 10))
+	assert.Equal(uint8(1), rtk.Ssat[6].Fix[0])
+	assert.Equal(uint8(1), rtk.Ssat[6].Fix[1])
+	assert.Equal(uint8(2), rtk.Ssat[5].Fix[0])
+
+	/* least lock count (sat 3) */
+	rtk.Opt.ArPartial = 2
+	rtk.Ssat[2].Lock[1] = 3
+	assert.Equal(8, rtk.ExcludeAmb(ix, 10))
+	assert.Equal([]int{2, 4, 5, 6, 2, 4, 5, 6}, tgt(ix, 8))
+
+	/* min number of remaining ambiguities */
+	rtk.Opt.ArMinAmb = 7
+	assert.Equal(0, rtk.ExcludeAmb(ix, 8))
+	assert.Equal([]int{2, 4, 5, 6, 2, 4, 5, 6}, tgt(ix, 8))
+}
+
+/* ResolveAmb_LAMBDA() partial ar by excluding satellites */
+func Test_rtkposutest5(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode, opt.ModeAr = gnssgo.PMODE_KINEMA, gnssgo.ARMODE_CONT
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	e1 := []float64{0.05, -0.08, 0.1, -0.04, 0.07, -0.06, 0.5}
+	e2 := []float64{-0.03, 0.06, -0.09, 0.08, -0.05, 0.04, -0.07}
+	bias, xa := make([]float64, rtk.Nx), make([]float64, rtk.Nx)
+
+	/* bad ambiguity (sat 7 L1 at half cycle) fails ratio-test */
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(0, rtk.ResolveAmb_LAMBDA(bias, xa))
+	assert.Less(float64(rtk.RtkSol.Ratio), 3.0)
+
+	/* excluded until validation ok */
+	rtk.Opt.ArPartial = 1
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(10, rtk.ResolveAmb_LAMBDA(bias, xa))
+	assert.GreaterOrEqual(float64(rtk.RtkSol.Ratio), 3.0)
+	assert.Equal(uint8(1), rtk.Ssat[6].Fix[0])
+	for i := 1; i < 6; i++ {
+		assert.Equal(uint8(2), rtk.Ssat[i].Fix[0])
+		assert.Equal(float64(ambN1[0]-ambN1[i]), bias[i-1])
+		assert.Equal(float64(ambN2[0]-ambN2[i]), bias[i+4])
+	}
+	/* bad ambiguities of two satellites (sat 6, 7) excluded one by one */
+	e1[5] = -0.5
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(8, rtk.ResolveAmb_LAMBDA(bias, xa))
+	assert.Equal(uint8(1), rtk.Ssat[5].Fix[0])
+	assert.Equal(uint8(1), rtk.Ssat[6].Fix[0])
+
+	/* loop stopped by min number of ambiguities */
+	rtk.Opt.ArMinAmb = 9
+	ambrtk(&rtk, e1, e2)
+	assert.Equal(0, rtk.ResolveAmb_LAMBDA(bias, xa))
+}
@@ -0,0 +1,116 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : rtk server event subscription
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* Subscribe(), PublishEvent() fan-out by event kinds */
+func Test_rtksvrutest1(t *testing.T) {
+	assert := assert.New(t)
+	svr := new(gnssgo.RtkSvr)
+
+	sub1 := svr.Subscribe(gnssgo.SVREV_OBS, 4)
+	sub2 := svr.Subscribe(gnssgo.SVREV_ALL, 4)
+	sub3 := svr.Subscribe(gnssgo.SVREV_SOL, 4)
+
+	obs := []gnssgo.ObsD{{Sat: 5, Rcv: 1}}
+	svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_OBS, Obs: obs})
+	svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_STAT, Index: 1, State: 2})
+
+	ev := <-sub1.C
+	assert.Equal(gnssgo.SVREV_OBS, ev.Kind)
+	assert.Equal(5, ev.Obs[0].Sat)
+	assert.Equal(0, len(sub1.C))
+	assert.Equal(2, len(sub2.C))
+	ev = <-sub2.C
+	assert.Equal(gnssgo.SVREV_OBS, ev.Kind)
+	ev = <-sub2.C
+	assert.Equal(gnssgo.SVREV_STAT, ev.Kind)
+	assert.Equal(2, ev.State)
+	assert.Equal(0, len(sub3.C))
+	for _, sub := range []*gnssgo.SvrSubscriber{sub1, sub2, sub3} {
+		assert.Equal(uint64(0), sub.Dropped())
+	}
+}
+
+/* PublishEvent() drop on full buffer, blocking subscriber */
+func Test_rtksvrutest2(t *testing.T) {
+	assert := assert.New(t)
+	svr := new(gnssgo.RtkSvr)
+
+	sub := svr.Subscribe(gnssgo.SVREV_OBS, 1)
+	for i := 0; i < 3; i++ {
+		svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_OBS, Index: i})
+	}
+	assert.Equal(uint64(2), sub.Dropped())
+	assert.Equal(0, (<-sub.C).Index) /* oldest kept */
+	svr.Unsubscribe(sub)
+
+	/* blocking subscriber: server waits for reader */
+	sub = svr.Subscribe(gnssgo.SVREV_OBS|gnssgo.SVREV_BLOCK, 1)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_OBS, Index: i})
+		}
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		select {
+		case ev := <-sub.C:
+			assert.Equal(i, ev.Index)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event timeout")
+		}
+	}
+	<-done
+	assert.Equal(uint64(0), sub.Dropped())
+	svr.Unsubscribe(sub)
+}
+
+/* Unsubscribe() close channel, release blocked server */
+func Test_rtksvrutest3(t *testing.T) {
+	assert := assert.New(t)
+	svr := new(gnssgo.RtkSvr)
+
+	sub1 := svr.Subscribe(gnssgo.SVREV_OBS, 4)
+	sub2 := svr.Subscribe(gnssgo.SVREV_OBS|gnssgo.SVREV_BLOCK, 0)
+
+	/* server blocked by unread blocking subscriber */
+	done := make(chan struct{})
+	go func() {
+		svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_OBS})
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("server not blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	svr.Unsubscribe(sub2)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server not released")
+	}
+	_, ok := <-sub2.C
+	assert.False(ok)
+
+	/* no event after unsubscribe */
+	svr.Unsubscribe(sub1)
+	svr.Unsubscribe(sub1)
+	svr.PublishEvent(&gnssgo.SvrEvent{Kind: gnssgo.SVREV_OBS})
+	n := 0
+	for range sub1.C {
+		n++
+	}
+	assert.Equal(1, n) /* buffered before unsubscribe */
+	assert.Equal(0, len(svr.Subs))
+}
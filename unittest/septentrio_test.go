@@ -0,0 +1,173 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : septentrio sbf decoder functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"encoding/binary"
+	"gnssgo"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* generate SBF block (id, tow (ms), week, body) -----------------------------*/
+func sbfblock(id int, tow uint32, week int, body []byte) []byte {
+	n := (gnssgo.SBFHLEN + 6 + len(body) + 3) / 4 * 4
+	buff := make([]byte, n)
+	buff[0], buff[1] = gnssgo.SBFSYNC1, gnssgo.SBFSYNC2
+	binary.LittleEndian.PutUint16(buff[4:], uint16(id))
+	binary.LittleEndian.PutUint16(buff[6:], uint16(n))
+	binary.LittleEndian.PutUint32(buff[8:], tow)
+	binary.LittleEndian.PutUint16(buff[12:], uint16(week))
+	copy(buff[14:], body)
+	binary.LittleEndian.PutUint16(buff[2:], gnssgo.Rtk_CRC16(buff[4:], n-4))
+	return buff
+}
+
+/* input raw data and return last non-zero status ----------------------------*/
+func inputraw(raw *gnssgo.Raw, format int, data []byte) int {
+	ret := 0
+	for _, c := range data {
+		if r := raw.InputRaw(format, c); r != 0 {
+			ret = r
+		}
+	}
+	return ret
+}
+
+/* SBF MeasEpoch: G05 L1C/A (type-1) + L2C (type-2) --------------------------*/
+func sbfmeasepoch(ch byte, carrier uint16) []byte {
+	body := make([]byte, 6+20+12)
+	body[0], body[1], body[2] = 1, 20, 12
+	p := body[6:]
+	p[0], p[1], p[2] = ch, 0, 5 /* channel, sig 0 (L1C/A), svid 5 */
+	p[3] = 5                    /* code msb: 22000000.123 m */
+	binary.LittleEndian.PutUint32(p[4:], uint32(22000000123-5*4294967296))
+	dop := int32(-12345678)
+	binary.LittleEndian.PutUint32(p[8:], uint32(dop))
+	binary.LittleEndian.PutUint16(p[12:], carrier)
+	p[15] = 140 /* cn0: 45 dBHz */
+	binary.LittleEndian.PutUint16(p[16:], 100)
+	p[18], p[19] = 0, 1
+	p = p[20:]
+	p[0], p[1], p[2], p[3] = 3, 50, 120, 0 /* sig 3 (L2C), lock, cn0 40 dBHz */
+	binary.LittleEndian.PutUint16(p[6:], 2500)
+	binary.LittleEndian.PutUint16(p[8:], 500)
+	binary.LittleEndian.PutUint16(p[10:], 100)
+	return body
+}
+
+/* SBF MeasExtra: loss of continuity counter of channel ----------------------*/
+func sbfmeasextra(ch, lossc byte) []byte {
+	body := make([]byte, 6+16)
+	body[0], body[1] = 1, 16
+	body[6], body[7], body[6+12] = ch, 0, lossc
+	return body
+}
+
+/* input_sbf() MeasEpoch, MeasExtra, EndOfMeas */
+func Test_septentrioutest1(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	assert.Equal(1, raw.InitRaw(gnssgo.STRFMT_SEPT))
+	f1, f2 := gnssgo.FREQ1, gnssgo.FREQ2
+	P1, D1 := 22000000.123, -1234.5678
+
+	/* without MeasExtra: output at MeasEpoch */
+	data := append([]byte{0x00, 0x24}, sbfblock(gnssgo.ID_SBF_MEASEPOCH, 345600000, 2300,
+		sbfmeasepoch(7, 12345))...)
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	assert.Equal(1, raw.ObsData.N())
+	obs := raw.ObsData.Data[0]
+	assert.Equal(gnssgo.SatNo(gnssgo.SYS_GPS, 5), obs.Sat)
+	assert.Equal(0.0, gnssgo.TimeDiff(obs.Time, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(uint8(gnssgo.CODE_L1C), obs.Code[0])
+	assert.Equal(uint8(gnssgo.CODE_L2L), obs.Code[1])
+	assert.InDelta(P1, obs.P[0], 1e-6)
+	assert.InDelta(P1*f1/gnssgo.CLIGHT+12.345, obs.L[0], 1e-6)
+	assert.InDelta(D1, obs.D[0], 1e-6)
+	assert.InDelta(45.0, float64(obs.SNR[0])*gnssgo.SNR_UNIT, 1e-6)
+	assert.InDelta(P1+2.5, obs.P[1], 1e-6)
+	assert.InDelta((P1+2.5)*f2/gnssgo.CLIGHT+0.5, obs.L[1], 1e-6)
+	assert.InDelta(D1*f2/f1+0.01, obs.D[1], 1e-6)
+	assert.InDelta(40.0, float64(obs.SNR[1])*gnssgo.SNR_UNIT, 1e-6)
+	assert.Equal(uint8(0), obs.LLI[0])
+
+	/* MeasExtra: output at MeasExtra, slip by loss of continuity counter */
+	data = sbfblock(gnssgo.ID_SBF_MEASEXTRA, 345600000, 2300, sbfmeasextra(7, 3))
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	data = sbfblock(gnssgo.ID_SBF_MEASEPOCH, 345601000, 2300, sbfmeasepoch(7, 12346))
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	data = sbfblock(gnssgo.ID_SBF_MEASEXTRA, 345601000, 2300, sbfmeasextra(7, 4))
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	obs = raw.ObsData.Data[0]
+	assert.Equal(0.0, gnssgo.TimeDiff(obs.Time, gnssgo.GpsT2Time(2300, 345601.0)))
+	assert.Equal(uint8(gnssgo.LLI_SLIP), obs.LLI[0]&gnssgo.LLI_SLIP)
+	assert.Equal(uint8(0), obs.LLI[1]&gnssgo.LLI_SLIP)
+
+	/* EndOfMeas closes epoch */
+	data = sbfblock(gnssgo.ID_SBF_MEASEPOCH, 345602000, 2300, sbfmeasepoch(7, 12347))
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	data = sbfblock(gnssgo.ID_SBF_ENDOFMEAS, 345602000, 2300, nil)
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	assert.Equal(uint8(0), raw.ObsData.Data[0].LLI[0]&gnssgo.LLI_SLIP)
+
+	/* crc error */
+	data = sbfblock(gnssgo.ID_SBF_ENDOFMEAS, 345602000, 2300, nil)
+	data[len(data)-1] ^= 1
+	assert.Equal(-1, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	raw.FreeRaw()
+}
+
+/* input_sbf() GPSNav */
+func Test_septentrioutest2(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	raw.InitRaw(gnssgo.STRFMT_SEPT)
+	body := make([]byte, 126)
+	le := binary.LittleEndian
+	body[0] = 12                    /* prn */
+	le.PutUint16(body[2:], 2300)    /* week */
+	body[5], body[6] = 2, 0         /* ura, health */
+	le.PutUint16(body[8:], 0x123)   /* iodc */
+	body[10], body[11] = 0x23, 0x23 /* iode2, iode3 */
+	le.PutUint32(body[14:], math.Float32bits(-1.0e-8))
+	le.PutUint32(body[18:], 345600) /* toc */
+	le.PutUint32(body[30:], math.Float32bits(1.0e-4))
+	le.PutUint64(body[42:], math.Float64bits(0.25))
+	le.PutUint64(body[54:], math.Float64bits(0.01))
+	le.PutUint64(body[66:], math.Float64bits(5153.6))
+	le.PutUint32(body[74:], 345600) /* toe */
+	le.PutUint64(body[94:], math.Float64bits(0.3))
+	le.PutUint32(body[114:], math.Float32bits(-2.5e-9))
+	data := sbfblock(gnssgo.ID_SBF_GPSNAV, 345610000, 2300, body)
+	assert.Equal(2, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+
+	sat := gnssgo.SatNo(gnssgo.SYS_GPS, 12)
+	assert.Equal(sat, raw.EphSat)
+	eph := raw.NavData.Ephs[sat-1]
+	assert.Equal(0x23, eph.Iode)
+	assert.Equal(0x123, eph.Iodc)
+	assert.Equal(2, eph.Sva)
+	assert.Equal(2300, eph.Week)
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Toe, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Toc, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.InDelta(5153.6*5153.6, eph.A, 1e-6)
+	assert.InDelta(0.01, eph.E, 1e-15)
+	assert.InDelta(0.25*gnssgo.SC2RAD, eph.M0, 1e-15)
+	assert.InDelta(0.3*gnssgo.SC2RAD, eph.I0, 1e-15)
+	assert.InDelta(-2.5e-9*gnssgo.SC2RAD, eph.OMGd, 1e-15)
+	assert.InDelta(1.0e-4, eph.F0, 1e-10)
+	assert.InDelta(-1.0e-8, eph.Tgd[0], 1e-14)
+
+	/* same ephemeris not updated, iode mismatch rejected */
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_SEPT, data))
+	body[11] = 0x24
+	assert.Equal(-1, inputraw(&raw, gnssgo.STRFMT_SEPT, sbfblock(gnssgo.ID_SBF_GPSNAV,
+		345610000, 2300, body)))
+	raw.FreeRaw()
+}
@@ -0,0 +1,422 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : solution functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"gnssgo"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* moving-base solution: rover at enu vector from base (std 1 cm) ------------*/
+func attrtk(rtk *gnssgo.Rtk, enu []float64, stat uint8) {
+	var pos, dr [3]float64
+
+	rtk.Rb = [6]float64{-3957199.0, 3310205.0, 3737911.0}
+	gnssgo.Ecef2Pos(rtk.Rb[:], pos[:])
+	gnssgo.Enu2Ecef(pos[:], enu, dr[:])
+	rtk.RtkSol = gnssgo.Sol{Time: gnssgo.GpsT2Time(2300, 345618.25), Stat: stat, Ns: 10}
+	for i := 0; i < 3; i++ {
+		rtk.RtkSol.Rr[i] = rtk.Rb[i] + dr[i]
+		rtk.RtkSol.Qr[i] = 1e-4
+	}
+}
+
+/* MoveBaseAtt() */
+func Test_solutionutest1(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	opt.Mode = gnssgo.PMODE_MOVEB
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+
+	/* heading clockwise from north, pitch upward, var=sig^2/h^2, sig^2/l^2 */
+	attrtk(&rtk, []float64{3.0, 4.0, 1.0}, gnssgo.SOLQ_FIX)
+	assert.Equal(1, rtk.MoveBaseAtt())
+	sol := &rtk.RtkSol
+	assert.InDelta(math.Atan2(3.0, 4.0), sol.Att[0], 1e-9)
+	assert.InDelta(math.Atan2(1.0, 5.0), sol.Att[1], 1e-9)
+	assert.InDelta(1e-4/25.0, float64(sol.Qatt[0]), 1e-10)
+	assert.InDelta(1e-4/26.0, float64(sol.Qatt[1]), 1e-10)
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol.AttStat)
+
+	/* heading in 0-2pi, pitch downward, float */
+	attrtk(&rtk, []float64{-3.0, -4.0, -2.0}, gnssgo.SOLQ_FLOAT)
+	assert.Equal(1, rtk.MoveBaseAtt())
+	assert.InDelta(2.0*math.Pi+math.Atan2(-3.0, -4.0), sol.Att[0], 1e-9)
+	assert.InDelta(math.Atan2(-2.0, 5.0), sol.Att[1], 1e-9)
+	assert.Equal(uint8(gnssgo.SOLQ_FLOAT), sol.AttStat)
+
+	/* no fix/float solution */
+	attrtk(&rtk, []float64{3.0, 4.0, 1.0}, gnssgo.SOLQ_SINGLE)
+	assert.Equal(0, rtk.MoveBaseAtt())
+	assert.Equal(uint8(gnssgo.SOLQ_NONE), sol.AttStat)
+	assert.Equal(0.0, sol.Att[0])
+
+	/* horizontal baseline too short */
+	attrtk(&rtk, []float64{0.03, 0.04, 1.0}, gnssgo.SOLQ_FIX)
+	assert.Equal(0, rtk.MoveBaseAtt())
+
+	/* baseline length constraint (pos2-baselen, pos2-basesig) */
+	rtk.Opt.Baseline = [2]float64{math.Sqrt(26.0) + 0.03, 0.01}
+	attrtk(&rtk, []float64{3.0, 4.0, 1.0}, gnssgo.SOLQ_FIX)
+	assert.Equal(1, rtk.MoveBaseAtt())
+	rtk.Opt.Baseline[0] = math.Sqrt(26.0) + 0.05
+	assert.Equal(0, rtk.MoveBaseAtt())
+	assert.Equal(uint8(gnssgo.SOLQ_NONE), sol.AttStat)
+}
+
+/* OutSolNmeaHdt(), OutSolNmeaPashr(), DecodeNmea() HDT, PASHR */
+func Test_solutionutest2(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+
+	opt := gnssgo.DefaultProcOpt()
+	rtk.InitRtk(&opt)
+	defer rtk.FreeRtk()
+	attrtk(&rtk, []float64{3.0, 4.0, 1.0}, gnssgo.SOLQ_FIX)
+	rtk.MoveBaseAtt()
+	sol := rtk.RtkSol
+
+	var buff string
+	sol.OutSolNmeaHdt(&buff)
+	assert.Equal("$GPHDT,36.870,T*0F\r\n", buff)
+	buff = ""
+	sol.OutSolNmeaPashr(&buff)
+	assert.Equal("$PASHR,000000.250,36.87,T,0.00,11.31,0.00,0.000,0.112,0.115,2,0*1A\r\n", buff)
+
+	/* decoded to attitude of the current solution */
+	sol2 := gnssgo.Sol{Time: sol.Time, Stat: gnssgo.SOLQ_FIX}
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	assert.InDelta(sol.Att[0], sol2.Att[0], 0.005*gnssgo.D2R)
+	assert.InDelta(sol.Att[1], sol2.Att[1], 0.005*gnssgo.D2R)
+	assert.InDelta(math.Sqrt(float64(sol.Qatt[0])), math.Sqrt(float64(sol2.Qatt[0])),
+		0.0005*gnssgo.D2R)
+	assert.InDelta(math.Sqrt(float64(sol.Qatt[1])), math.Sqrt(float64(sol2.Qatt[1])),
+		0.0005*gnssgo.D2R)
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.AttStat)
+
+	sol2 = gnssgo.Sol{Time: sol.Time, Stat: gnssgo.SOLQ_FLOAT}
+	buff = ""
+	sol.OutSolNmeaHdt(&buff)
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	assert.InDelta(sol.Att[0], sol2.Att[0], 0.0005*gnssgo.D2R)
+	assert.Equal(uint8(gnssgo.SOLQ_FLOAT), sol2.AttStat)
+
+	/* time mismatch of PASHR, no solution, no attitude */
+	buff = ""
+	sol.OutSolNmeaPashr(&buff)
+	sol2 = gnssgo.Sol{Time: gnssgo.TimeAdd(sol.Time, 1.0), Stat: gnssgo.SOLQ_FIX}
+	assert.Equal(0, sol2.DecodeNmea([]byte(buff)))
+	sol2 = gnssgo.Sol{Time: sol.Time}
+	assert.Equal(0, sol2.DecodeNmea([]byte(buff)))
+	sol.AttStat = gnssgo.SOLQ_NONE
+	buff = ""
+	sol.OutSolNmeaHdt(&buff)
+	assert.Equal("$GPHDT,,T*", buff[:10])
+	sol2 = gnssgo.Sol{Time: sol.Time, Stat: gnssgo.SOLQ_FIX}
+	assert.Equal(0, sol2.DecodeNmea([]byte(buff)))
+}
+
+/* OutSolHeader(), OutSols(), DecodeSolOpt(), DecodeSol() velocity and attitude */
+func Test_solutionutest3(t *testing.T) {
+	var rtk gnssgo.Rtk
+	assert := assert.New(t)
+
+	popt := gnssgo.DefaultProcOpt()
+	rtk.InitRtk(&popt)
+	defer rtk.FreeRtk()
+	attrtk(&rtk, []float64{3.0, 4.0, 1.0}, gnssgo.SOLQ_FIX)
+	rtk.MoveBaseAtt()
+	sol := rtk.RtkSol
+	sol.Rr[3], sol.Rr[4], sol.Rr[5] = 0.5, -0.25, 0.125
+
+	for _, posf := range []int{gnssgo.SOLF_LLH, gnssgo.SOLF_XYZ, gnssgo.SOLF_ENU} {
+		for _, c := range []struct{ outvel, outatt int }{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			opt := gnssgo.DefaultSolOpt()
+			opt.Posf, opt.OutVel, opt.OutAtt = posf, c.outvel, c.outatt
+			var head, buff string
+			gnssgo.OutSolHeader(&head, &opt)
+			sol.OutSols(&buff, rtk.Rb[:], &opt)
+
+			/* options from header */
+			opt2 := gnssgo.DefaultSolOpt()
+			opt2.OutVel, opt2.OutAtt = 1-c.outvel, 1-c.outatt
+			for _, line := range strings.Split(head, "\r\n") {
+				if line != "" {
+					gnssgo.DecodeSolOpt(line, &opt2)
+				}
+			}
+			assert.Equal(posf, opt2.Posf)
+			if posf != gnssgo.SOLF_ENU { /* no velocity in e/n/u-baseline */
+				assert.Equal(c.outvel, opt2.OutVel, "posf=%d", posf)
+			}
+			assert.Equal(c.outatt, opt2.OutAtt, "posf=%d", posf)
+
+			var sol2 gnssgo.Sol
+			var rb [3]float64
+			copy(rb[:], rtk.Rb[:3])
+			assert.Equal(1, sol2.DecodeSol([]byte(buff), &opt2, rb[:]), buff)
+			if c.outvel > 0 && posf != gnssgo.SOLF_ENU {
+				assert.InDelta(0.5, sol2.Rr[3], 1e-4, buff)
+				assert.InDelta(-0.25, sol2.Rr[4], 1e-4, buff)
+			} else {
+				assert.Equal(0.0, sol2.Rr[3], buff)
+			}
+			if c.outatt > 0 {
+				assert.InDelta(sol.Att[0], sol2.Att[0], 1e-4*gnssgo.D2R, buff)
+				assert.InDelta(sol.Att[1], sol2.Att[1], 1e-4*gnssgo.D2R, buff)
+				assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.AttStat, buff)
+			} else {
+				assert.Equal(0.0, sol2.Att[0], buff)
+				assert.Equal(uint8(gnssgo.SOLQ_NONE), sol2.AttStat, buff)
+			}
+		}
+	}
+}
+
+/* solution with e/n/u covariance and velocity for NMEA sentences ------------*/
+func nmeasol() gnssgo.Sol {
+	var pos, r, v [3]float64
+	var P [9]float64
+
+	sol := gnssgo.Sol{Time: gnssgo.GpsT2Time(2300, 345618.25), Stat: gnssgo.SOLQ_FIX, Ns: 12,
+		Age: 1.5}
+	rb := []float64{-3957199.0, 3310205.0, 3737911.0}
+	gnssgo.Ecef2Pos(rb, pos[:])
+	gnssgo.Enu2Ecef(pos[:], []float64{3.0, 4.0, 1.0}, r[:])
+	gnssgo.Enu2Ecef(pos[:], []float64{1.2, -2.5, 0.3}, v[:])
+	for i := 0; i < 3; i++ {
+		sol.Rr[i], sol.Rr[i+3] = rb[i]+r[i], v[i]
+	}
+	Q := []float64{4e-4, 1.5e-4, 0.0, 1.5e-4, 9e-4, 0.0, 0.0, 0.0, 2.5e-3}
+	gnssgo.Ecef2Pos(sol.Rr[:], pos[:])
+	gnssgo.Cov2Ecef(pos[:], Q, P[:])
+	sol.Cov2Sol(P[:])
+	return sol
+}
+
+/* e/n/u covariance of solution ----------------------------------------------*/
+func nmeaenucov(sol *gnssgo.Sol) []float64 {
+	var pos [3]float64
+	var P, Q [9]float64
+
+	gnssgo.Ecef2Pos(sol.Rr[:], pos[:])
+	sol.Sol2Cov(P[:])
+	gnssgo.Cov2Enu(pos[:], P[:], Q[:])
+	return Q[:]
+}
+
+/* satellite status of GPS satellites (prn, residual) -----------------------*/
+func nmeassat() []gnssgo.SSat {
+	ssat := make([]gnssgo.SSat, gnssgo.MAXSAT)
+	for i, res := range []float32{-3.5, 1.25, 2.0} {
+		s := &ssat[gnssgo.SatNo(gnssgo.SYS_GPS, []int{5, 9, 12}[i])-1]
+		s.Vs, s.Resp[0] = 1, res
+		s.Azel = [2]float64{float64(i) * 2.0, 0.3 + float64(i)*0.4}
+	}
+	return ssat
+}
+
+/* NMEA sentence headers in buffer -------------------------------------------*/
+func nmeaheads(buff string) []string {
+	var heads []string
+	for _, line := range strings.Split(buff, "\r\n") {
+		if i := strings.IndexByte(line, ','); i > 0 {
+			heads = append(heads, line[:i])
+		}
+	}
+	return heads
+}
+
+/* OutSolNmeaGns(), OutSolNmeaGst(), OutSolNmeaVtg(), OutSolNmeaGbs(), DecodeNmea() */
+func Test_solutionutest4(t *testing.T) {
+	var buff string
+	assert := assert.New(t)
+	sol := nmeasol()
+
+	/* GNS: position, mode, satellites and age */
+	sol.OutSolNmeaGns(&buff)
+	assert.Equal("$GNGNS,", buff[:7])
+	assert.Contains(buff, ",R,12,")
+	sol2 := gnssgo.Sol{Time: sol.Time}
+	assert.Equal(1, sol2.DecodeNmea([]byte(buff)))
+	assert.Equal(0.0, gnssgo.TimeDiff(sol.Time, sol2.Time))
+	for i := 0; i < 3; i++ {
+		assert.InDelta(sol.Rr[i], sol2.Rr[i], 2e-3)
+	}
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.Stat)
+	assert.Equal(uint8(12), sol2.Ns)
+	assert.InDelta(1.5, float64(sol2.Age), 1e-6)
+
+	/* GST: std of e/n/u and e-n covariance by error ellipse */
+	buff = ""
+	sol.OutSolNmeaGst(&buff)
+	assert.Equal("$GNGST,", buff[:7])
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	Q, Q2 := nmeaenucov(&sol), nmeaenucov(&sol2)
+	for _, i := range []int{0, 4, 8} {
+		assert.InDelta(math.Sqrt(Q[i]), math.Sqrt(Q2[i]), 1e-3)
+	}
+	assert.InDelta(Q[1], Q2[1], 5e-5)
+
+	/* VTG: horizontal velocity */
+	buff = ""
+	sol.OutSolNmeaVtg(&buff)
+	assert.Equal("$GNVTG,", buff[:7])
+	assert.Contains(buff, ",K,R*")
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	var pos, v, v2 [3]float64
+	gnssgo.Ecef2Pos(sol.Rr[:], pos[:])
+	gnssgo.Ecef2Enu(pos[:], sol.Rr[3:], v[:])
+	gnssgo.Ecef2Enu(pos[:], sol2.Rr[3:], v2[:])
+	assert.InDelta(v[0], v2[0], 5e-3)
+	assert.InDelta(v[1], v2[1], 5e-3)
+	assert.InDelta(0.0, v2[2], 1e-6)
+
+	/* GBS: most likely failed satellite with max residual */
+	buff = ""
+	sol.OutSolNmeaGbs(&buff, nmeassat())
+	assert.Equal("$GNGBS,", buff[:7])
+	assert.Contains(buff, ",05,,-3.500,,1*")
+
+	/* covariance by GBS only without covariance by GST */
+	Q2 = nmeaenucov(&sol2)
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	assert.Equal(Q2, nmeaenucov(&sol2))
+	sol2.Qr = [6]float32{}
+	assert.Equal(3, sol2.DecodeNmea([]byte(buff)))
+	Q2 = nmeaenucov(&sol2)
+	for _, i := range []int{0, 4, 8} {
+		assert.InDelta(math.Sqrt(Q[i]), math.Sqrt(Q2[i]), 1e-3)
+	}
+	assert.InDelta(0.0, Q2[1], 1e-9)
+}
+
+/* DecodeNmea() checksum, clear of solution, invalid and unsupported sentences */
+func Test_solutionutest5(t *testing.T) {
+	var buff string
+	assert := assert.New(t)
+	sol := nmeasol()
+	sol.OutSolNmeaGga(&buff)
+
+	/* position sentences clear last solution except time */
+	sol2 := sol
+	sol2.Stat, sol2.Age = gnssgo.SOLQ_FLOAT, 9.0
+	assert.Equal(1, sol2.DecodeNmea([]byte(buff)))
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.Stat)
+	assert.Equal(0.0, sol2.Rr[3])
+	assert.Equal(float32(0.0), sol2.Qr[0])
+	assert.Equal(uint8(gnssgo.SOLQ_NONE), sol2.AttStat)
+
+	/* sentence without checksum */
+	sol3 := gnssgo.Sol{Time: sol.Time}
+	assert.Equal(1, sol3.DecodeNmea([]byte(buff[:strings.IndexByte(buff, '*')])))
+	assert.Equal(sol2.Rr, sol3.Rr)
+
+	/* no date info, no position for attributes, time mismatch */
+	sol3 = gnssgo.Sol{}
+	assert.Equal(0, sol3.DecodeNmea([]byte(buff)))
+	buff = ""
+	sol.OutSolNmeaGst(&buff)
+	sol3 = gnssgo.Sol{Time: sol.Time}
+	assert.Equal(0, sol3.DecodeNmea([]byte(buff)))
+	sol3 = sol2
+	sol3.Time = gnssgo.TimeAdd(sol.Time, 1.0)
+	assert.Equal(0, sol3.DecodeNmea([]byte(buff)))
+
+	/* invalid fields */
+	assert.Equal(0, sol2.DecodeNmea([]byte("$GNGST,000000.25,,0.030,0.020,60.0,,,*00")))
+	assert.Equal(0, sol2.DecodeNmea([]byte("$GNVTG,123.00,M,,M,1.00,N,1.85,K,R")))
+	assert.Equal(0, sol2.DecodeNmea([]byte("$GNGBS,000000.25,,,,,,,")))
+	assert.Equal(0, sol2.DecodeNmea([]byte("$GPHDT,36.870,M")))
+	assert.Equal(0, sol2.DecodeNmea([]byte("$PASHR,000000.250,,T,0.00,11.31,0.00")))
+
+	/* VTG speed by km/h */
+	assert.Equal(3, sol2.DecodeNmea([]byte("$GNVTG,90.00,T,,M,,N,36.00,K,R")))
+	var pos, v [3]float64
+	gnssgo.Ecef2Pos(sol2.Rr[:], pos[:])
+	gnssgo.Ecef2Enu(pos[:], sol2.Rr[3:], v[:])
+	assert.InDelta(10.0, v[0], 1e-6)
+	assert.InDelta(0.0, v[1], 1e-6)
+
+	/* GNS best mode of systems, unsupported sentences */
+	buff = ""
+	sol.OutSolNmeaGns(&buff)
+	assert.Equal(1, sol3.DecodeNmea([]byte(strings.Replace(buff, ",R,12,", ",NAF,12,", 1))))
+	assert.Equal(uint8(gnssgo.SOLQ_FLOAT), sol3.Stat)
+	assert.Equal(0, sol3.DecodeNmea([]byte("$GNGSA,A,3,05,09,12,,,,,,,,,,1.0,1.0,1.0,1*00")))
+	assert.Equal(0, sol3.DecodeNmea([]byte("$GN")))
+	assert.Equal(uint8(gnssgo.SOLQ_FLOAT), sol3.Stat)
+}
+
+/* OutSols(), OutSolExs() sentences by out-nmeasen, InputSol() NMEA stream */
+func Test_solutionutest6(t *testing.T) {
+	var popt gnssgo.PrcOpt
+	var solbuf gnssgo.SolBuf
+	assert := assert.New(t)
+	sol := nmeasol()
+	sol.Att[0], sol.Att[1], sol.AttStat = 0.6435, 0.1974, gnssgo.SOLQ_FIX
+	sol.Qatt[0], sol.Qatt[1] = 4e-6, 3.8e-6
+	ssat := nmeassat()
+	rb := make([]float64, 3)
+
+	/* default sentences */
+	opt := gnssgo.DefaultSolOpt()
+	opt.Posf = gnssgo.SOLF_NMEA
+	var buff string
+	sol.OutSols(&buff, rb, &opt)
+	assert.Equal([]string{"$GNRMC", "$GNGGA", "$GNGST", "$GPHDT", "$PASHR"}, nmeaheads(buff))
+	buff = ""
+	sol.OutSolExs(&buff, ssat, &opt)
+	assert.Equal([]string{"$GPGSA", "$GPGSV"}, nmeaheads(buff))
+
+	/* selected sentences by out-nmeasen, no attitude */
+	gnssgo.ResetSysOpts()
+	assert.Equal(1, gnssgo.SearchOpt("out-nmeasen", gnssgo.SysOpts).Str2Opt("928"))
+	gnssgo.GetSysOpts(&popt, &opt, nil)
+	gnssgo.ResetSysOpts()
+	assert.Equal(gnssgo.NMEA_VTG|gnssgo.NMEA_GNS|gnssgo.NMEA_GBS|gnssgo.NMEA_HDT, opt.NmeaSen)
+	opt.Posf = gnssgo.SOLF_NMEA
+	sol1 := sol
+	sol1.AttStat = gnssgo.SOLQ_NONE
+	buff = ""
+	sol1.OutSols(&buff, rb, &opt)
+	assert.Equal([]string{"$GNGNS", "$GNVTG"}, nmeaheads(buff))
+	buff = ""
+	sol1.OutSolExs(&buff, ssat, &opt)
+	assert.Equal([]string{"$GNGBS"}, nmeaheads(buff))
+
+	/* all sentences decoded to one solution */
+	opt.NmeaSen = 0x7FF
+	buff = ""
+	sol.OutSols(&buff, rb, &opt)
+	sol.OutSolExs(&buff, ssat, &opt)
+	assert.Equal(11, len(nmeaheads(buff)))
+	solbuf.InitSolBuf(0, 0)
+	defer solbuf.FreeSolBuf()
+	for i := 0; i < len(buff); i++ {
+		gnssgo.InputSol(buff[i], gnssgo.Gtime{}, gnssgo.Gtime{}, 0.0, 0, &opt, &solbuf)
+	}
+	assert.Equal(1, solbuf.N)
+	sol2 := solbuf.Data[0]
+	assert.Equal(0.0, gnssgo.TimeDiff(sol.Time, sol2.Time))
+	for i := 0; i < 3; i++ {
+		assert.InDelta(sol.Rr[i], sol2.Rr[i], 2e-3)
+	}
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.Stat)
+	Q, Q2 := nmeaenucov(&sol), nmeaenucov(&sol2)
+	for _, i := range []int{0, 4, 8} {
+		assert.InDelta(math.Sqrt(Q[i]), math.Sqrt(Q2[i]), 1e-3)
+	}
+	assert.NotEqual(0.0, gnssgo.Norm(sol2.Rr[3:], 3))
+	assert.InDelta(sol.Att[0], sol2.Att[0], 0.005*gnssgo.D2R)
+	assert.InDelta(sol.Att[1], sol2.Att[1], 0.005*gnssgo.D2R)
+	assert.Equal(uint8(gnssgo.SOLQ_FIX), sol2.AttStat)
+}
@@ -0,0 +1,429 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : stream functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"gnssgo"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* generate self-signed certificate and key files for 127.0.0.1 -------------*/
+func gencert(t *testing.T, dir string) (string, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, _ := x509.MarshalECPrivateKey(key)
+	file := filepath.Join(dir, "cert.pem")
+	buff := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	buff = append(buff, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder})...)
+	os.WriteFile(file, buff, 0600)
+	crt, err := tls.LoadX509KeyPair(file, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file, crt
+}
+
+/* read stream until n bytes or timeout --------------------------------------*/
+func strreadn(stream *gnssgo.Stream, n int) string {
+	var buff [256]byte
+	var data []byte
+	for i := 0; i < 200 && len(data) < n; i++ {
+		if m := stream.StreamRead(buff[:], len(buff)); m > 0 {
+			data = append(data, buff[:m]...)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return string(data)
+}
+
+/* ntrip 1.0 caster stand-in sending data after response --------------------*/
+func ntripstub(ln net.Listener, data string, req chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	var head string
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if head += line; line == "\r\n" {
+			break
+		}
+	}
+	req <- head
+	fmt.Fprintf(conn, "ICY 200 OK\r\n%s", data)
+	time.Sleep(500 * time.Millisecond)
+}
+
+/* tcp client with tls */
+func Test_streamutest1(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+	var msg string
+
+	_, crt := gencert(t, t.TempDir())
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{crt}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("tls data"))
+		time.Sleep(500 * time.Millisecond)
+	}()
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_TCPCLI, gnssgo.STR_MODE_R,
+		ln.Addr().String()+"::noverify"))
+	defer stream.StreamClose()
+	assert.Equal("tls data", strreadn(&stream, 8))
+	stream.StreamStat(&msg)
+}
+
+/* tcp server with tls and tcp client verified by ca */
+func Test_streamutest2(t *testing.T) {
+	assert := assert.New(t)
+	var svr, cli gnssgo.Stream
+
+	file, _ := gencert(t, t.TempDir())
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	svr.InitStream()
+	assert.Equal(1, svr.OpenStream(gnssgo.STR_TCPSVR, gnssgo.STR_MODE_W,
+		fmt.Sprintf(":%d::cert=%s", port, file)))
+	defer svr.StreamClose()
+
+	cli.InitStream()
+	assert.Equal(1, cli.OpenStream(gnssgo.STR_TCPCLI, gnssgo.STR_MODE_R,
+		fmt.Sprintf("127.0.0.1:%d::ca=%s", port, file)))
+	defer cli.StreamClose()
+
+	/* tcp client read blocks until data arrives */
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+				svr.StreamWrite([]byte("svr data"), 8)
+			}
+		}
+	}()
+	data := strreadn(&cli, 8)
+	close(done)
+	assert.True(strings.HasPrefix(data, "svr data"), data)
+}
+
+/* ntrip client with tls and without tls */
+func Test_streamutest3(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+	req := make(chan string, 1)
+
+	_, crt := gencert(t, t.TempDir())
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{crt}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go ntripstub(ln, "rtcm data", req)
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_NTRIPCLI, gnssgo.STR_MODE_R,
+		"user:passwd@"+ln.Addr().String()+"/MNT::noverify"))
+	assert.Equal("rtcm data", strreadn(&stream, 9))
+	stream.StreamClose()
+	select {
+	case head := <-req:
+		assert.True(strings.HasPrefix(head, "GET /MNT HTTP/1.1\r\n"), head)
+	default:
+		t.Error("no ntrip request")
+	}
+
+	/* default ntrip port without tls for non-tls options */
+	ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", gnssgo.NTRIP_CLI_PORT))
+	if err != nil {
+		t.Skip("ntrip port in use")
+	}
+	defer ln.Close()
+	go ntripstub(ln, "plain data", req)
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_NTRIPCLI, gnssgo.STR_MODE_R,
+		"127.0.0.1/MNT::unknown"))
+	assert.Equal("plain data", strreadn(&stream, 10))
+	stream.StreamClose()
+}
+
+/* hijack http connection of httptest server ---------------------------------*/
+func hijack(t *testing.T, w http.ResponseWriter) (net.Conn, *bufio.ReadWriter) {
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Error(err)
+	}
+	return conn, rw
+}
+
+/* send data split across writes ---------------------------------------------*/
+func sendsplit(conn net.Conn, data ...string) {
+	for _, p := range data {
+		conn.Write([]byte(p))
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+/* ntrip 2.0 client with chunked transfer encoding */
+func Test_streamutest4(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+	req := make(chan *http.Request, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req <- r
+		conn, _ := hijack(t, w)
+		defer conn.Close()
+
+		/* chunks split across reads, chunk in response header buffer */
+		sendsplit(conn, "HTTP/1.1 200 OK\r\nNtrip-Version: Ntrip/2.0\r\n"+
+			"Content-Type: gnss/data\r\nTransfer-Encoding: chunked\r\n\r\nA\r\n0123",
+			"456789\r", "\n3;ext=1\r", "\nabc\r\n1", "0\r\nDEF", "GHIJKLMNOPQRS\r\n0\r\n\r\n")
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_NTRIPCLI, gnssgo.STR_MODE_R,
+		"ntrip2://user:passwd@"+ts.Listener.Addr().String()+"/MNT"))
+	defer stream.StreamClose()
+	assert.Equal("0123456789abcDEFGHIJKLMNOPQRS", strreadn(&stream, 29))
+
+	r := <-req
+	assert.Equal("GET", r.Method)
+	assert.Equal("/MNT", r.URL.Path)
+	assert.Equal("Ntrip/2.0", r.Header.Get("Ntrip-Version"))
+	user, passwd, ok := r.BasicAuth()
+	assert.True(ok)
+	assert.Equal("user", user)
+	assert.Equal("passwd", passwd)
+}
+
+/* ntrip 2.0 server with chunked transfer encoding */
+func Test_streamutest5(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+	recv := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/MNT" ||
+			r.Header.Get("Ntrip-Version") != "Ntrip/2.0" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal([]string{"chunked"}, r.TransferEncoding)
+		conn, rw := hijack(t, w)
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nNtrip-Version: Ntrip/2.0\r\n\r\n"))
+
+		buff := make([]byte, 20)
+		_, err := io.ReadFull(httputil.NewChunkedReader(rw.Reader), buff)
+		assert.Nil(err)
+		recv <- string(buff)
+	}))
+	defer ts.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_NTRIPSVR, gnssgo.STR_MODE_W,
+		"ntrip2://user:passwd@"+ts.Listener.Addr().String()+"/MNT"))
+	defer stream.StreamClose()
+
+	for i, data := 0, []string{"svr data 1", "svr data 2"}; i < 200 && len(data) > 0; i++ {
+		if stream.StreamWrite([]byte(data[0]), 10) == 10 {
+			data = data[1:]
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case data := <-recv:
+		assert.Equal("svr data 1svr data 2", data)
+	case <-time.After(5 * time.Second):
+		t.Error("no data received")
+	}
+}
+
+/* ntrip caster relay of chunked source to ntrip 2.0 client */
+func Test_streamutest6(t *testing.T) {
+	var msg string
+	assert := assert.New(t)
+	var cli gnssgo.Stream
+	port := freeport(t)
+
+	cas := gnssgo.OpenCaster(fmt.Sprintf("%d", port), &msg)
+	if cas == nil {
+		t.Fatal(msg)
+	}
+	defer cas.CloseCaster()
+	cas.AddUser(0, "base", "bpw", "MNT")
+
+	/* ntrip 2.0 source with chunks split across writes */
+	src, _, rsp := casreq(t, port, "POST /MNT HTTP/1.1\r\nNtrip-Version: Ntrip/2.0\r\n"+
+		"Transfer-Encoding: chunked\r\n"+basicauth("base", "bpw")+"\r\n")
+	assert.True(strings.HasPrefix(rsp, "HTTP/1.1 200 OK\r\n"), rsp)
+	defer src.Close()
+
+	cli.InitStream()
+	assert.Equal(1, cli.OpenStream(gnssgo.STR_NTRIPCLI, gnssgo.STR_MODE_R,
+		fmt.Sprintf("ntrip2://127.0.0.1:%d/MNT", port)))
+	defer cli.StreamClose()
+
+	/* client connected by read */
+	data := make(chan string, 1)
+	go func() { data <- strreadn(&cli, 12) }()
+	assert.Eventually(func() bool { return len(cas.StatCaster()) == 2 }, 5*time.Second,
+		10*time.Millisecond)
+
+	sendsplit(src, "5\r\nrel", "ay\r\n", "7\r", "\n data 1\r\n")
+	assert.Equal("relay data 1", <-data)
+	stat := cas.StatCaster()
+	assert.Equal(2, stat[0].Ver)
+	assert.Equal(2, stat[1].Ver)
+	assert.Equal(uint64(12), stat[0].InBytes) /* dechunked source data */
+}
+
+/* loopback stream port registered by RegisterStreamType() -------------------*/
+type loopport struct {
+	path   string
+	mode   int
+	data   []byte
+	closed bool
+}
+
+func (port *loopport) Open(path string, mode int, msg *string) int {
+	if strings.HasPrefix(path, "error") {
+		*msg = "open error"
+		return 0
+	}
+	port.path, port.mode = path, mode
+	return 1
+}
+func (port *loopport) Read(buff []byte, n int, msg *string) int {
+	m := copy(buff[:n], port.data)
+	port.data = port.data[m:]
+	return m
+}
+func (port *loopport) Write(buff []byte, n int, msg *string) int {
+	port.data = append(port.data, buff[:n]...)
+	*msg = fmt.Sprintf("%d bytes", len(port.data))
+	return n
+}
+func (port *loopport) State() int { return 2 }
+func (port *loopport) StateX(msg *string) int {
+	*msg += fmt.Sprintf("  path   = %s\n", port.path)
+	return 2
+}
+func (port *loopport) Close() { port.closed = true }
+
+/* RegisterStreamType(), StreamType(), StreamScheme(), OpenStream() user stream */
+func Test_streamutest7(t *testing.T) {
+	var stream gnssgo.Stream
+	var ports []*loopport
+	var msg string
+	assert := assert.New(t)
+
+	factory := func() gnssgo.StreamPort {
+		port := new(loopport)
+		ports = append(ports, port)
+		return port
+	}
+	/* registered types */
+	ctype := gnssgo.RegisterStreamType("Loop", factory)
+	assert.GreaterOrEqual(ctype, gnssgo.STR_USER)
+	assert.Equal(ctype, gnssgo.StreamType("loop"))
+	assert.Equal(ctype, gnssgo.StreamType("LOOP"))
+	assert.Equal("loop", gnssgo.StreamScheme(ctype))
+	assert.Equal(ctype, gnssgo.RegisterStreamType("loop", factory))
+	assert.Equal(ctype+1, gnssgo.RegisterStreamType("loop2", factory))
+	assert.Equal(gnssgo.STR_TCPCLI, gnssgo.StreamType("tcpcli"))
+	assert.Equal("mqtt", gnssgo.StreamScheme(gnssgo.STR_MQTT))
+	assert.Equal(gnssgo.STR_NONE, gnssgo.StreamType("noloop"))
+	assert.Equal("", gnssgo.StreamScheme(ctype+100))
+	assert.Equal(gnssgo.STR_NONE, gnssgo.RegisterStreamType("loop:", factory))
+	assert.Equal(gnssgo.STR_NONE, gnssgo.RegisterStreamType("", factory))
+	assert.Equal(gnssgo.STR_NONE, gnssgo.RegisterStreamType("loop3", nil))
+
+	/* open, write, read, status and close through stream port */
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(ctype, gnssgo.STR_MODE_RW, "host:1234/path"))
+	assert.Equal(1, len(ports))
+	assert.Equal("host:1234/path", ports[0].path)
+	assert.Equal(gnssgo.STR_MODE_RW, ports[0].mode)
+	assert.Equal(5, stream.StreamWrite([]byte("user data"), 5))
+	assert.Equal(4, stream.StreamWrite([]byte("data"), 4))
+	assert.Equal("user data", strreadn(&stream, 9))
+	assert.Equal(uint32(9), stream.InBytes)
+	assert.Equal(uint32(9), stream.OutBytes)
+	assert.Equal(3, stream.StreamStat(&msg))
+	assert.Equal("9 bytes", msg)
+	msg = ""
+	assert.Equal(3, stream.StreamStateX(&msg))
+	assert.Contains(msg, "path   = host:1234/path")
+	stream.StreamClose()
+	assert.True(ports[0].closed)
+	assert.Equal(0, stream.StreamStat(nil))
+
+	/* open error */
+	assert.Equal(0, stream.OpenStream(ctype, gnssgo.STR_MODE_R, "error"))
+	assert.Equal(-1, stream.StreamStat(&msg))
+	assert.Equal("open error", msg)
+}
@@ -0,0 +1,129 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : uncompress functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"fmt"
+	"gnssgo"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const uncdir = "testdata/uncompress"
+
+/* text of lzw.txt.Z: repetitive lines and pseudo-random letters -------------*/
+func lzwtext() []byte {
+	var buff []byte
+	for i := 0; len(buff) < 12000; i++ {
+		buff = append(buff, fmt.Sprintf("G%02d 21000000.123 110353456.789\n", i%32+1)...)
+	}
+	for x := uint32(1); len(buff) < 26000; {
+		x = (x*1103515245 + 12345) & 0x7FFFFFFF
+		buff = append(buff, byte('a'+(x>>16)%4))
+	}
+	return buff
+}
+
+/* read file with uncompression ----------------------------------------------*/
+func readuncompress(t *testing.T, file string) ([]byte, int) {
+	var stat int
+	rd, fp := gnssgo.OpenUncompressFile(file, &stat)
+	if rd == nil {
+		return nil, stat
+	}
+	defer fp.Close()
+	buff, err := io.ReadAll(rd)
+	assert.Nil(t, err)
+	return buff, stat
+}
+
+/* UncompressFileName() */
+func Test_uncompressutest1(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("a/brdc0010.23n", gnssgo.UncompressFileName("a/brdc0010.23n.gz"))
+	assert.Equal("a/brdc0010.23n", gnssgo.UncompressFileName("a/brdc0010.23n.Z"))
+	assert.Equal("abcd0010.23o", gnssgo.UncompressFileName("abcd0010.23d.Z"))
+	assert.Equal("ABCD0010.23O", gnssgo.UncompressFileName("ABCD0010.23D"))
+	assert.Equal("ABCD00XXX_R_20230010000_01D_30S_MO.rnx",
+		gnssgo.UncompressFileName("ABCD00XXX_R_20230010000_01D_30S_MO.crx.gz"))
+	assert.Equal("a/igs.tar", gnssgo.UncompressFileName("a/igs.tgz"))
+	assert.Equal("a/igs.sp3", gnssgo.UncompressFileName("a/igs.sp3.zip"))
+	assert.Equal("a/igs.sp3", gnssgo.UncompressFileName("a/igs.sp3"))
+}
+
+/* OpenUncompressFile() gzip, zip, tar, compact RINEX */
+func Test_uncompressutest2(t *testing.T) {
+	assert := assert.New(t)
+
+	rnx, err := os.ReadFile(filepath.Join(uncdir, "sample.rnx"))
+	assert.Nil(err)
+	buff, stat := readuncompress(t, filepath.Join(uncdir, "sample.rnx"))
+	assert.Equal(0, stat)
+	assert.Equal(string(rnx), string(buff))
+
+	for _, file := range []string{"sample.rnx.gz", "sample.zip", "sample.tar", "sample.crx",
+		"sample.crx.gz"} {
+		buff, stat = readuncompress(t, filepath.Join(uncdir, file))
+		assert.Equal(1, stat, file)
+		assert.Equal(string(rnx), string(buff), file)
+	}
+	_, stat = readuncompress(t, filepath.Join(uncdir, "nofile.gz"))
+	assert.Equal(-1, stat)
+}
+
+/* NewLzwReader() unix compress with code width increase and clear code */
+func Test_uncompressutest3(t *testing.T) {
+	assert := assert.New(t)
+
+	/* lzw.txt.Z: max bits 10, codes 9->10 bits, clear code resets to 9 bits */
+	buff, stat := readuncompress(t, filepath.Join(uncdir, "lzw.txt.Z"))
+	assert.Equal(1, stat)
+	assert.Equal(lzwtext(), buff)
+
+	/* truncated data */
+	data, _ := os.ReadFile(filepath.Join(uncdir, "lzw.txt.Z"))
+	file := filepath.Join(t.TempDir(), "lzw.txt.Z")
+	os.WriteFile(file, data[:2000], 0644)
+	buff, stat = readuncompress(t, file)
+	assert.Equal(1, stat)
+	assert.Equal(lzwtext()[:len(buff)], buff)
+	assert.Less(len(buff), len(lzwtext()))
+
+	/* header error */
+	lzw := gnssgo.NewLzwReader(io.MultiReader())
+	n, err := lzw.Read(make([]byte, 16))
+	assert.Equal(0, n)
+	assert.NotNil(err)
+}
+
+/* Rtk_Uncompress() */
+func Test_uncompressutest4(t *testing.T) {
+	var uncfile string
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	rnx, _ := os.ReadFile(filepath.Join(uncdir, "sample.rnx"))
+	for _, file := range []string{"sample.crx.gz", "sample.tar", "sample.rnx"} {
+		data, _ := os.ReadFile(filepath.Join(uncdir, file))
+		os.WriteFile(filepath.Join(dir, file), data, 0644)
+	}
+	/* nested gzip and compact RINEX */
+	assert.Equal(1, gnssgo.Rtk_Uncompress(filepath.Join(dir, "sample.crx.gz"), &uncfile))
+	assert.Equal(filepath.Join(dir, "sample.rnx"), uncfile)
+	buff, _ := os.ReadFile(uncfile)
+	assert.Equal(string(rnx), string(buff))
+
+	/* tar archive extracted into the directory */
+	os.Remove(filepath.Join(dir, "sample.rnx"))
+	assert.Equal(1, gnssgo.Rtk_Uncompress(filepath.Join(dir, "sample.tar"), &uncfile))
+	buff, _ = os.ReadFile(filepath.Join(dir, "sample.rnx"))
+	assert.Equal(string(rnx), string(buff))
+
+	/* not compressed */
+	assert.Equal(0, gnssgo.Rtk_Uncompress(filepath.Join(dir, "sample.rnx"), &uncfile))
+}
@@ -0,0 +1,192 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : unicore decoder functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"encoding/binary"
+	"gnssgo"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* generate unicore message (id, time status, week, tow (ms), body) ----------*/
+func uniframe(id, stat, week int, tow uint32, body []byte) []byte {
+	buff := make([]byte, gnssgo.UNIHLEN+len(body)+4)
+	buff[0], buff[1], buff[2] = gnssgo.UNISYNC1, gnssgo.UNISYNC2, gnssgo.UNISYNC3
+	binary.LittleEndian.PutUint16(buff[4:], uint16(id))
+	binary.LittleEndian.PutUint16(buff[6:], uint16(len(body)))
+	buff[9] = byte(stat)
+	binary.LittleEndian.PutUint16(buff[10:], uint16(week))
+	binary.LittleEndian.PutUint32(buff[12:], tow)
+	copy(buff[gnssgo.UNIHLEN:], body)
+	n := gnssgo.UNIHLEN + len(body)
+	binary.LittleEndian.PutUint32(buff[n:], gnssgo.Rtk_CRC32(buff, n))
+	return buff
+}
+
+/* little-endian field writer ------------------------------------------------*/
+func putr8l(p []byte, v float64) { binary.LittleEndian.PutUint64(p, math.Float64bits(v)) }
+
+/* unicore tracking status (phase/code locked, parity known) -----------------*/
+func unistat(satsys, sigtype uint32) uint32 {
+	return 1<<10 | 1<<11 | 1<<12 | satsys<<16 | sigtype<<21
+}
+
+/* OBSVM observation record --------------------------------------------------*/
+func uniobs(p []byte, prn int, psr, adr float64, dop float32, snr int, lockt float32,
+	stat uint32) {
+	le := binary.LittleEndian
+	le.PutUint16(p[2:], uint16(prn))
+	putr8l(p[4:], psr)
+	putr8l(p[12:], adr)
+	le.PutUint32(p[24:], math.Float32bits(dop))
+	le.PutUint16(p[28:], uint16(snr))
+	le.PutUint32(p[32:], math.Float32bits(lockt))
+	le.PutUint32(p[36:], stat)
+}
+
+/* input_unicore() OBSVM */
+func Test_unicoreutest1(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	assert.Equal(1, raw.InitRaw(gnssgo.STRFMT_UNICORE))
+	body := make([]byte, 4+40*2)
+	body[0] = 2
+	uniobs(body[4:], 8, 21000000.5, 110000000.25, -1234.5, 4550, 10.0, unistat(0, 0))
+	uniobs(body[44:], 8, 21000003.5, 85000000.75, -962.0, 3825, 10.0, unistat(0, 9))
+	data := append([]byte{0xAA, 0x44}, uniframe(gnssgo.ID_UNI_OBSVM, 0, 2300, 345600000, body)...)
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+	assert.Equal(1, raw.ObsData.N())
+	obs := raw.ObsData.Data[0]
+	assert.Equal(gnssgo.SatNo(gnssgo.SYS_GPS, 8), obs.Sat)
+	assert.Equal(0.0, gnssgo.TimeDiff(obs.Time, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(uint8(gnssgo.CODE_L1C), obs.Code[0])
+	assert.Equal(uint8(gnssgo.CODE_L2W), obs.Code[1])
+	assert.InDelta(21000000.5, obs.P[0], 1e-9)
+	assert.InDelta(-110000000.25, obs.L[0], 1e-9)
+	assert.InDelta(-1234.5, obs.D[0], 1e-9)
+	assert.InDelta(45.5, float64(obs.SNR[0])*gnssgo.SNR_UNIT, 1e-6)
+	assert.InDelta(21000003.5, obs.P[1], 1e-9)
+	assert.InDelta(-85000000.75, obs.L[1], 1e-9)
+	assert.InDelta(38.25, float64(obs.SNR[1])*gnssgo.SNR_UNIT, 1e-6)
+	assert.Equal(uint8(0), obs.LLI[0])
+
+	/* lock time reset: slip, phase unlock: no carrier phase */
+	uniobs(body[4:], 8, 21000100.5, 110000500.25, -1234.5, 4550, 0.5, unistat(0, 0))
+	uniobs(body[44:], 8, 21000103.5, 85000400.75, -962.0, 3825, 11.0, unistat(0, 9)&^(1<<10))
+	data = uniframe(gnssgo.ID_UNI_OBSVM, 0, 2300, 345601000, body)
+	assert.Equal(1, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+	obs = raw.ObsData.Data[0]
+	assert.Equal(uint8(gnssgo.LLI_SLIP), obs.LLI[0]&gnssgo.LLI_SLIP)
+	assert.Equal(uint8(0), obs.LLI[1]&gnssgo.LLI_SLIP)
+	assert.Equal(0.0, obs.L[1])
+	assert.InDelta(21000103.5, obs.P[1], 1e-9)
+
+	/* slave antenna observation ignored without -SLAVE */
+	data = uniframe(gnssgo.ID_UNI_OBSVH, 0, 2300, 345602000, body)
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+
+	/* unknown time status */
+	data = uniframe(gnssgo.ID_UNI_OBSVM, 20, 2300, 345602000, body)
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+
+	/* crc error */
+	data = uniframe(gnssgo.ID_UNI_OBSVM, 0, 2300, 345602000, body)
+	data[len(data)-1] ^= 1
+	assert.Equal(-1, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+	raw.FreeRaw()
+}
+
+/* input_unicore() GPSEPH, GLOEPH, IONUTC */
+func Test_unicoreutest2(t *testing.T) {
+	assert := assert.New(t)
+	var raw gnssgo.Raw
+
+	raw.InitRaw(gnssgo.STRFMT_UNICORE)
+	le := binary.LittleEndian
+
+	/* gps ephemeris */
+	body := make([]byte, 224)
+	le.PutUint32(body, 21)        /* prn */
+	putr8l(body[4:], 345000.0)    /* tow */
+	le.PutUint32(body[16:], 77)   /* iode */
+	le.PutUint32(body[24:], 2300) /* week */
+	putr8l(body[32:], 345600.0)   /* toe */
+	putr8l(body[40:], 26560000.0) /* A */
+	putr8l(body[56:], 1.25)       /* M0 */
+	putr8l(body[64:], 0.012)      /* e */
+	putr8l(body[128:], 0.96)      /* i0 */
+	le.PutUint32(body[160:], 77)  /* iodc */
+	putr8l(body[164:], 345600.0)  /* toc */
+	putr8l(body[172:], -1.2e-8)   /* tgd */
+	putr8l(body[180:], 2.5e-4)    /* af0 */
+	putr8l(body[216:], 2.0)       /* ura */
+	data := uniframe(gnssgo.ID_UNI_GPSEPH, 0, 2300, 345610000, body)
+	assert.Equal(2, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+
+	sat := gnssgo.SatNo(gnssgo.SYS_GPS, 21)
+	assert.Equal(sat, raw.EphSat)
+	eph := raw.NavData.Ephs[sat-1]
+	assert.Equal(77, eph.Iode)
+	assert.Equal(77, eph.Iodc)
+	assert.Equal(2300, eph.Week)
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Toe, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(0.0, gnssgo.TimeDiff(eph.Toc, gnssgo.GpsT2Time(2300, 345600.0)))
+	assert.Equal(26560000.0, eph.A)
+	assert.Equal(1.25, eph.M0)
+	assert.Equal(0.012, eph.E)
+	assert.Equal(0.96, eph.I0)
+	assert.Equal(-1.2e-8, eph.Tgd[0])
+	assert.Equal(2.5e-4, eph.F0)
+	assert.Equal(4.0, eph.Fit)
+	assert.Equal(0, inputraw(&raw, gnssgo.STRFMT_UNICORE, data)) /* unchanged */
+
+	/* glonass ephemeris */
+	body = make([]byte, 144)
+	le.PutUint16(body, 5+37)          /* slot+37 */
+	le.PutUint16(body[2:], 7-2)       /* fcn+7 */
+	le.PutUint16(body[6:], 2300)      /* week */
+	le.PutUint32(body[8:], 346500000) /* tow (ms) */
+	le.PutUint32(body[12:], 18)       /* toff */
+	le.PutUint32(body[20:], 0x85)     /* iode */
+	putr8l(body[28:], 1.2e7)
+	putr8l(body[60:], -2500.5)
+	putr8l(body[100:], -1.5e-5)
+	putr8l(body[116:], 1.8e-12)
+	le.PutUint32(body[124:], 618) /* tof (time of day) */
+	data = uniframe(gnssgo.ID_UNI_GLOEPH, 0, 2300, 346510000, body)
+	assert.Equal(2, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+
+	sat = gnssgo.SatNo(gnssgo.SYS_GLO, 5)
+	assert.Equal(sat, raw.EphSat)
+	geph := raw.NavData.Geph[4]
+	assert.Equal(-2, geph.Frq)
+	assert.Equal(5, geph.Iode)
+	assert.Equal(0, geph.Svh)
+	assert.Equal(1.2e7, geph.Pos[0])
+	assert.Equal(-2500.5, geph.Vel[1])
+	assert.Equal(-1.5e-5, geph.Taun)
+	assert.Equal(1.8e-12, geph.Gamn)
+	assert.Equal(0.0, gnssgo.TimeDiff(geph.Toe, gnssgo.GpsT2Time(2300, 346500.0)))
+	assert.Equal(0.0, gnssgo.TimeDiff(geph.Tof, gnssgo.GpsT2Time(2300, 346200.0)))
+
+	/* ionosphere and utc parameters */
+	body = make([]byte, 108)
+	putr8l(body, 1.1e-8)
+	le.PutUint32(body[64:], 2300)
+	le.PutUint32(body[68:], 405504)
+	putr8l(body[72:], -9.3e-10)
+	le.PutUint32(body[96:], 18)
+	data = uniframe(gnssgo.ID_UNI_IONUTC, 0, 2300, 346520000, body)
+	assert.Equal(9, inputraw(&raw, gnssgo.STRFMT_UNICORE, data))
+	assert.Equal(1.1e-8, raw.NavData.Ion_gps[0])
+	assert.Equal(-9.3e-10, raw.NavData.Utc_gps[0])
+	assert.Equal(405504.0, raw.NavData.Utc_gps[2])
+	assert.Equal(2300.0, raw.NavData.Utc_gps[3])
+	assert.Equal(18.0, raw.NavData.Utc_gps[4])
+	raw.FreeRaw()
+}
@@ -0,0 +1,115 @@
+/*------------------------------------------------------------------------------
+* gnssgo unit test driver : websocket stream functions
+*-----------------------------------------------------------------------------*/
+package gnss_test
+
+import (
+	"bufio"
+	"fmt"
+	"gnssgo"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/* websocket client stand-in: opening handshake (status code) ----------------*/
+func wsdial(t *testing.T, addr, path string) (net.Conn, *bufio.Reader, int) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\n"+
+		"Connection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n", path, addr)
+	rd := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	rsp, err := http.ReadResponse(rd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.StatusCode == http.StatusSwitchingProtocols {
+		assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", rsp.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return conn, rd, rsp.StatusCode
+}
+
+/* receive unmasked short frame (opcode, payload) ----------------------------*/
+func wsrecv(t *testing.T, conn net.Conn, rd *bufio.Reader) (byte, string) {
+	var h [2]byte
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(rd, h[:]); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, h[1]&0x7F)
+	if _, err := io.ReadFull(rd, payload); err != nil {
+		t.Fatal(err)
+	}
+	return h[0], string(payload)
+}
+
+/* websocket server broadcast */
+func Test_websocketutest1(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_WSSVR, gnssgo.STR_MODE_RW, addr+"/gnss"))
+	defer stream.StreamClose()
+
+	c1, rd1, code1 := wsdial(t, addr, "/gnss")
+	defer c1.Close()
+	c2, rd2, code2 := wsdial(t, addr, "/gnss")
+	defer c2.Close()
+	assert.Equal(101, code1)
+	assert.Equal(101, code2)
+
+	/* one write received by both clients */
+	assert.Equal(8, stream.StreamWrite([]byte("rtcm msg"), 8))
+	for _, c := range []struct {
+		conn net.Conn
+		rd   *bufio.Reader
+	}{{c1, rd1}, {c2, rd2}} {
+		h, data := wsrecv(t, c.conn, c.rd)
+		assert.Equal(byte(0x80|gnssgo.WS_OP_BINARY), h)
+		assert.Equal("rtcm msg", data)
+	}
+
+	/* request path error */
+	c, _, code := wsdial(t, addr, "/other")
+	c.Close()
+	assert.Equal(404, code)
+}
+
+/* websocket server max clients */
+func Test_websocketutest2(t *testing.T) {
+	assert := assert.New(t)
+	var stream gnssgo.Stream
+
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	stream.InitStream()
+	assert.Equal(1, stream.OpenStream(gnssgo.STR_WSSVR, gnssgo.STR_MODE_RW, addr))
+	defer stream.StreamClose()
+
+	for i := 0; i < gnssgo.MAXCLI; i++ {
+		c, _, code := wsdial(t, addr, "/")
+		defer c.Close()
+		assert.Equal(101, code)
+	}
+	/* rejected before upgrade */
+	c, rd, code := wsdial(t, addr, "/")
+	assert.Equal(503, code)
+	_, err := rd.ReadByte()
+	assert.Equal(io.EOF, err)
+	c.Close()
+}